@@ -1,10 +1,8 @@
 package centosstream
 
 import (
-	"bytes"
 	"crypto/sha256"
 	"fmt"
-	"sort"
 	"strings"
 
 	v1 "kubevirt.io/api/core/v1"
@@ -15,8 +13,14 @@ import (
 	"kubevirt.io/containerdisks/pkg/hashsum"
 	"kubevirt.io/containerdisks/pkg/http"
 	"kubevirt.io/containerdisks/pkg/tests"
+	"kubevirt.io/containerdisks/pkg/version"
 )
 
+const license = "LicenseRef-CentOS"
+
+// checksumLocator finds the CHECKSUM file in the images directory of every release.
+var checksumLocator = hashsum.Locator{Location: hashsum.LocationDirectory, Name: "CHECKSUM", Format: hashsum.ChecksumFormatBSD}
+
 //nolint:lll
 const description = `<img src="https://upload.wikimedia.org/wikipedia/commons/thumb/9/9e/CentOS_Graphical_Symbol.svg/64px-CentOS_Graphical_Symbol.svg.png" alt="drawing" height="15"/> Centos Stream Generic Cloud images for KubeVirt.
 <br />
@@ -29,18 +33,28 @@ type centos struct {
 	Version         string
 	Variant         string
 	getter          http.Getter
-	Arch            string
+	Archs           []string
 	ExampleUserData *docs.UserData
 	EnvVariables    map[string]string
 }
 
-func (c *centos) Metadata() *api.Metadata {
+func (c *centos) Metadata() []*api.Metadata {
+	metadata := make([]*api.Metadata, 0, len(c.Archs))
+	for _, arch := range c.Archs {
+		metadata = append(metadata, c.metadata(arch))
+	}
+	return metadata
+}
+
+func (c *centos) metadata(arch string) *api.Metadata {
 	metadata := &api.Metadata{
-		Name:         "centos-stream",
-		Version:      c.Version,
-		Description:  description,
-		EnvVariables: c.EnvVariables,
-		Arch:         c.Arch,
+		Name:              "centos-stream",
+		Version:           c.Version,
+		Description:       description,
+		EnvVariables:      c.EnvVariables,
+		Arch:              arch,
+		License:           license,
+		AccessCredentials: true,
 	}
 
 	if c.ExampleUserData != nil {
@@ -50,25 +64,31 @@ func (c *centos) Metadata() *api.Metadata {
 	return metadata
 }
 
-func (c *centos) Inspect() (*api.ArtifactDetails, error) {
+// Inspect inspects the images of all architectures of the stream, each architecture has its own CHECKSUM file.
+func (c *centos) Inspect() ([]*api.ArtifactDetails, error) {
+	details := make([]*api.ArtifactDetails, 0, len(c.Archs))
+	for _, arch := range c.Archs {
+		archDetails, err := c.inspect(arch)
+		if err != nil {
+			return nil, fmt.Errorf("error inspecting %s: %v", arch, err)
+		}
+		details = append(details, archDetails)
+	}
+	return details, nil
+}
+
+func (c *centos) inspect(arch string) (*api.ArtifactDetails, error) {
 	var baseURL string
 
 	if strings.HasPrefix(c.Version, "9") || strings.HasPrefix(c.Version, "10") {
-		baseURL = fmt.Sprintf("https://cloud.centos.org/centos/%s-stream/%s/images/", c.Version, c.Arch)
+		baseURL = fmt.Sprintf("https://cloud.centos.org/centos/%s-stream/%s/images/", c.Version, arch)
 	} else {
 		panic(fmt.Sprintf("can't understand provided version: %q", c.Version))
 	}
 
-	checksumURL := baseURL + "CHECKSUM"
-	checksumFormat := hashsum.ChecksumFormatBSD
-
-	raw, err := c.getter.GetAll(checksumURL)
-	if err != nil {
-		return nil, fmt.Errorf("error downloading the centos stream checksum file: %v", err)
-	}
-	checksums, err := hashsum.Parse(bytes.NewReader(raw), checksumFormat)
+	checksums, err := checksumLocator.Checksums(c.getter, baseURL)
 	if err != nil {
-		return nil, fmt.Errorf("error reading the centos stream checksum file: %v", err)
+		return nil, err
 	}
 
 	candidates := []string{}
@@ -82,11 +102,10 @@ func (c *centos) Inspect() (*api.ArtifactDetails, error) {
 		return nil, fmt.Errorf("no candidates for version %q and variant %q found", c.Version, c.Variant)
 	}
 
-	sort.Strings(candidates)
-	candidate := candidates[len(candidates)-1]
+	candidate := version.Latest(candidates)
 
 	var additionalTags []string
-	suffix := fmt.Sprintf(".%s.qcow2", c.Arch)
+	suffix := fmt.Sprintf(".%s.qcow2", arch)
 	additionalTag := strings.TrimSuffix(strings.TrimPrefix(candidate, fmt.Sprintf("CentOS-Stream-%s-", c.Variant)), suffix)
 	additionalTags = append(additionalTags, additionalTag)
 
@@ -96,7 +115,7 @@ func (c *centos) Inspect() (*api.ArtifactDetails, error) {
 			ChecksumHash:         sha256.New,
 			DownloadURL:          baseURL + candidate,
 			AdditionalUniqueTags: additionalTags,
-			ImageArchitecture:    architecture.GetImageArchitecture(c.Arch),
+			ImageArchitecture:    architecture.GetImageArchitecture(arch),
 		}, nil
 	}
 
@@ -123,11 +142,12 @@ func (c *centos) Tests() []api.ArtifactTest {
 	}
 }
 
-// New accepts CentOS Stream 8 and 9 versions.
-func New(release, arch string, exampleUserData *docs.UserData, envVariables map[string]string) *centos {
+// New accepts CentOS Stream 9 and 10 versions and returns a provider of the images of all archs of the stream.
+// Use api.Split to turn it into artifacts.
+func New(release string, archs []string, exampleUserData *docs.UserData, envVariables map[string]string) *centos {
 	return &centos{
 		Version:         release,
-		Arch:            arch,
+		Archs:           archs,
 		Variant:         "GenericCloud",
 		getter:          &http.HTTPGetter{},
 		ExampleUserData: exampleUserData,
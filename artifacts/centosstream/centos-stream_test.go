@@ -1,6 +1,8 @@
 package centosstream
 
 import (
+	"context"
+	"slices"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -9,17 +11,44 @@ import (
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/common"
 	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/http"
 	"kubevirt.io/containerdisks/testutil"
 )
 
+var archs = []string{"x86_64", "aarch64", "s390x"}
+
+// countingGetter counts the checksum files downloaded from upstream.
+type countingGetter struct {
+	http.Getter
+	requests int
+}
+
+func (c *countingGetter) GetAll(fileURL string) ([]byte, error) {
+	c.requests++
+	return c.Getter.GetAll(fileURL)
+}
+
+func (c *countingGetter) GetAllWithContext(ctx context.Context, fileURL string) ([]byte, error) {
+	c.requests++
+	return c.Getter.GetAllWithContext(ctx, fileURL)
+}
+
 var _ = Describe("CentosStream", func() {
+	var mirror *testutil.MirrorServer
+
+	BeforeEach(func() {
+		mirror = testutil.NewMirrorServer("testdata/mirror")
+		DeferCleanup(mirror.Close)
+	})
+
 	DescribeTable("Inspect should be able to parse checksum files",
-		func(release, arch, mockFile string, details *api.ArtifactDetails,
+		func(release, arch string, details *api.ArtifactDetails,
 			exampleUserData *docs.UserData, envVariables map[string]string, metadata *api.Metadata,
 		) {
-			c := New(release, arch, exampleUserData, envVariables)
-			c.getter = testutil.NewMockGetter(mockFile)
-			got, err := c.Inspect()
+			c := New(release, archs, exampleUserData, envVariables)
+			c.getter = mirror.Getter("https://cloud.centos.org/")
+			artifact := api.Split(c)[slices.Index(archs, arch)]
+			got, err := artifact.Inspect()
 			Expect(err).NotTo(HaveOccurred())
 			Expect(got.ChecksumHash).ToNot(BeNil())
 			Expect(got.Checksum).To(Equal(details.Checksum))
@@ -27,9 +56,9 @@ var _ = Describe("CentosStream", func() {
 			Expect(got.AdditionalUniqueTags).To(Equal(details.AdditionalUniqueTags))
 			Expect(got.ImageArchitecture).To(Equal(details.ImageArchitecture))
 			Expect(got.Compression).To(Equal(details.Compression))
-			Expect(c.Metadata()).To(Equal(metadata))
+			Expect(artifact.Metadata()).To(Equal(metadata))
 		},
-		Entry("centos-stream:9 x86_64", "9", "x86_64", "testdata/centos-stream9-x86_64.checksum",
+		Entry("centos-stream:9 x86_64", "9", "x86_64",
 			&api.ArtifactDetails{
 				Checksum:             "bcebdc00511d6e18782732570056cfbc7cba318302748bfc8f66be9c0db68142",
 				DownloadURL:          "https://cloud.centos.org/centos/9-stream/x86_64/images/CentOS-Stream-GenericCloud-9-20211222.0.x86_64.qcow2",
@@ -54,10 +83,12 @@ var _ = Describe("CentosStream", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "centos.stream9",
 				},
-				Arch: "x86_64",
+				Arch:              "x86_64",
+				License:           license,
+				AccessCredentials: true,
 			},
 		),
-		Entry("centos-stream:9 aarch64", "9", "aarch64", "testdata/centos-stream9-aarch64.checksum",
+		Entry("centos-stream:9 aarch64", "9", "aarch64",
 			&api.ArtifactDetails{
 				Checksum:             "66dd927b7aa643b18ad21a9368571c6ef57cc381b4febc8934397b137f14b995",
 				DownloadURL:          "https://cloud.centos.org/centos/9-stream/aarch64/images/CentOS-Stream-GenericCloud-9-latest.aarch64.qcow2",
@@ -82,10 +113,12 @@ var _ = Describe("CentosStream", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "centos.stream9",
 				},
-				Arch: "aarch64",
+				Arch:              "aarch64",
+				License:           license,
+				AccessCredentials: true,
 			},
 		),
-		Entry("centos-stream:9 s390x", "9", "s390x", "testdata/centos-stream9-s390x.checksum",
+		Entry("centos-stream:9 s390x", "9", "s390x",
 			&api.ArtifactDetails{
 				Checksum:             "17322e2562832b57bb2554a5b7056fba6d06db662728c487496d83845d7f016c",
 				DownloadURL:          "https://cloud.centos.org/centos/9-stream/s390x/images/CentOS-Stream-GenericCloud-9-latest.s390x.qcow2",
@@ -110,10 +143,12 @@ var _ = Describe("CentosStream", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "centos.stream9",
 				},
-				Arch: "s390x",
+				Arch:              "s390x",
+				License:           license,
+				AccessCredentials: true,
 			},
 		),
-		Entry("centos-stream:10 x86_64", "10", "x86_64", "testdata/centos-stream10-x86_64.checksum",
+		Entry("centos-stream:10 x86_64", "10", "x86_64",
 			&api.ArtifactDetails{
 				Checksum:             "3cb1310f39d92d34d0ea62c1d6f8943f47dce9df6937adb5bd26af8efa5d921d",
 				DownloadURL:          "https://cloud.centos.org/centos/10-stream/x86_64/images/CentOS-Stream-GenericCloud-10-latest.x86_64.qcow2",
@@ -138,10 +173,12 @@ var _ = Describe("CentosStream", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "centos.stream10",
 				},
-				Arch: "x86_64",
+				Arch:              "x86_64",
+				License:           license,
+				AccessCredentials: true,
 			},
 		),
-		Entry("centos-stream:10 aarch64", "10", "aarch64", "testdata/centos-stream10-aarch64.checksum",
+		Entry("centos-stream:10 aarch64", "10", "aarch64",
 			&api.ArtifactDetails{
 				Checksum:             "dc929660b4e88eea4ad5f1dcf49c21405ab9462a898659228c938a89283ae93c",
 				DownloadURL:          "https://cloud.centos.org/centos/10-stream/aarch64/images/CentOS-Stream-GenericCloud-10-latest.aarch64.qcow2",
@@ -166,10 +203,12 @@ var _ = Describe("CentosStream", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "centos.stream10",
 				},
-				Arch: "aarch64",
+				Arch:              "aarch64",
+				License:           license,
+				AccessCredentials: true,
 			},
 		),
-		Entry("centos-stream:10 s390x", "10", "s390x", "testdata/centos-stream10-s390x.checksum",
+		Entry("centos-stream:10 s390x", "10", "s390x",
 			&api.ArtifactDetails{
 				Checksum:             "dc854a20aabbb7150ad8da3c2b39a1c9f810cf3270ec706837bf5bb80435c907",
 				DownloadURL:          "https://cloud.centos.org/centos/10-stream/s390x/images/CentOS-Stream-GenericCloud-10-latest.s390x.qcow2",
@@ -194,12 +233,74 @@ var _ = Describe("CentosStream", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "centos.stream10",
 				},
-				Arch: "s390x",
+				Arch:              "s390x",
+				License:           license,
+				AccessCredentials: true,
 			},
 		),
 	)
 })
 
+var _ = Describe("CentosStream artifacts", func() {
+	var mirror *testutil.MirrorServer
+
+	BeforeEach(func() {
+		mirror = testutil.NewMirrorServer("testdata/mirror")
+		DeferCleanup(mirror.Close)
+	})
+
+	It("should inspect all archs of the stream once", func() {
+		getter := &countingGetter{Getter: mirror.Getter("https://cloud.centos.org/")}
+		c := New("10", archs, nil, nil)
+		c.getter = getter
+		artifacts := api.Split(c)
+		Expect(artifacts).To(HaveLen(len(archs)))
+
+		for i, artifact := range artifacts {
+			Expect(artifact.Metadata().Arch).To(Equal(archs[i]))
+			details, err := artifact.Inspect()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(details.DownloadURL).To(HaveSuffix("." + archs[i] + ".qcow2"))
+		}
+		_, err := artifacts[0].Inspect()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getter.requests).To(Equal(len(archs)))
+	})
+
+	It("should fail all archs and inspect again if one arch fails", func() {
+		mirror.Apply(testutil.WithNotFound("/centos/10-stream/s390x/images/CHECKSUM"))
+		getter := &countingGetter{Getter: mirror.Getter("https://cloud.centos.org/")}
+		c := New("10", archs, nil, nil)
+		c.getter = getter
+		artifacts := api.Split(c)
+
+		_, err := artifacts[0].Inspect()
+		Expect(err).To(MatchError(ContainSubstring("error inspecting s390x")))
+		_, err = artifacts[1].Inspect()
+		Expect(err).To(HaveOccurred())
+		Expect(getter.requests).To(Equal(2 * len(archs)))
+	})
+
+	It("should not share the details populated during build", func() {
+		c := New("10", archs, nil, nil)
+		c.getter = mirror.Getter("https://cloud.centos.org/")
+		artifact := api.Split(c)[0]
+
+		details, err := artifact.Inspect()
+		Expect(err).ToNot(HaveOccurred())
+		details.VirtualSize = 1024
+		again, err := artifact.Inspect()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(again.VirtualSize).To(BeZero())
+	})
+})
+
+var _ = testutil.DescribeArtifactContract("centos-stream:9 x86_64", func() api.Artifact {
+	c := New("9", []string{"x86_64"}, nil, nil)
+	c.getter = testutil.NewMockGetter("testdata/mirror/centos/9-stream/x86_64/images/CHECKSUM")
+	return api.Split(c)[0]
+})
+
 func TestCentosStream(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "CentosStream Suite")
@@ -17,6 +17,8 @@ import (
 	"kubevirt.io/containerdisks/pkg/tests"
 )
 
+const license = "LicenseRef-Debian"
+
 type Annotations struct {
 	Digest string `json:"cloud.debian.org/digest"`
 }
@@ -51,7 +53,9 @@ type debian struct {
 const (
 	baseURLFmt  = "https://cloud.debian.org/images/cloud/%s/latest/"
 	baseNameFmt = "debian-%s-genericcloud-%s"
-	description = `Debian Generic Cloud images for KubeVirt.
+	// releaseNotesURLFmt is the URL of the release notes of a Debian release by its codename
+	releaseNotesURLFmt = "https://www.debian.org/releases/%s/releasenotes"
+	description        = `Debian Generic Cloud images for KubeVirt.
 <br />
 <br />
 Visit [debian.org](https://cloud.debian.org/images/cloud/) to learn more about Debian project.`
@@ -65,9 +69,14 @@ func (d *debian) Metadata() *api.Metadata {
 		Version:      d.Version,
 		Description:  description,
 		Arch:         d.Arch,
+		License:      license,
 		EnvVariables: d.envVariables,
 	}
 
+	if d.VersionName != "" {
+		metadata.ReleaseNotes = fmt.Sprintf(releaseNotesURLFmt, d.VersionName)
+	}
+
 	if d.ExampleUserData != nil {
 		metadata.ExampleUserData = *d.ExampleUserData
 	}
@@ -93,7 +102,7 @@ func (d *debian) getBuildData(jsonURL string) (additionalTags []string, checksum
 	}
 
 	var buildData BuildData
-	if json.Unmarshal(raw, &buildData) != nil {
+	if err := json.Unmarshal(raw, &buildData); err != nil {
 		return nil, "", fmt.Errorf("error decoding debian json file: %v", err)
 	}
 
@@ -46,10 +46,12 @@ var _ = Describe("Debian", func() {
 				common.DefaultPreferenceEnv:   "debian",
 			},
 			&api.Metadata{
-				Name:        "debian",
-				Version:     "11",
-				Arch:        "x86_64",
-				Description: description,
+				Name:         "debian",
+				Version:      "11",
+				Arch:         "x86_64",
+				License:      license,
+				Description:  description,
+				ReleaseNotes: "https://www.debian.org/releases/bullseye/releasenotes",
 				ExampleUserData: docs.UserData{
 					Username: "debian",
 				},
@@ -75,10 +77,12 @@ var _ = Describe("Debian", func() {
 				common.DefaultPreferenceEnv:   "debian",
 			},
 			&api.Metadata{
-				Name:        "debian",
-				Version:     "11",
-				Arch:        "aarch64",
-				Description: description,
+				Name:         "debian",
+				Version:      "11",
+				Arch:         "aarch64",
+				License:      license,
+				Description:  description,
+				ReleaseNotes: "https://www.debian.org/releases/bullseye/releasenotes",
 				ExampleUserData: docs.UserData{
 					Username: "debian",
 				},
@@ -104,10 +108,12 @@ var _ = Describe("Debian", func() {
 				common.DefaultPreferenceEnv:   "debian",
 			},
 			&api.Metadata{
-				Name:        "debian",
-				Version:     "12",
-				Arch:        "x86_64",
-				Description: description,
+				Name:         "debian",
+				Version:      "12",
+				Arch:         "x86_64",
+				License:      license,
+				Description:  description,
+				ReleaseNotes: "https://www.debian.org/releases/bookworm/releasenotes",
 				ExampleUserData: docs.UserData{
 					Username: "debian",
 				},
@@ -133,10 +139,12 @@ var _ = Describe("Debian", func() {
 				common.DefaultPreferenceEnv:   "debian",
 			},
 			&api.Metadata{
-				Name:        "debian",
-				Version:     "12",
-				Arch:        "aarch64",
-				Description: description,
+				Name:         "debian",
+				Version:      "12",
+				Arch:         "aarch64",
+				License:      license,
+				Description:  description,
+				ReleaseNotes: "https://www.debian.org/releases/bookworm/releasenotes",
 				ExampleUserData: docs.UserData{
 					Username: "debian",
 				},
@@ -163,10 +171,12 @@ var _ = Describe("Debian", func() {
 				common.DefaultPreferenceEnv:   "debian",
 			},
 			&api.Metadata{
-				Name:        "debian",
-				Version:     "13",
-				Arch:        "x86_64",
-				Description: description,
+				Name:         "debian",
+				Version:      "13",
+				Arch:         "x86_64",
+				License:      license,
+				Description:  description,
+				ReleaseNotes: "https://www.debian.org/releases/trixie/releasenotes",
 				ExampleUserData: docs.UserData{
 					Username: "debian",
 				},
@@ -192,10 +202,12 @@ var _ = Describe("Debian", func() {
 				common.DefaultPreferenceEnv:   "debian",
 			},
 			&api.Metadata{
-				Name:        "debian",
-				Version:     "13",
-				Arch:        "aarch64",
-				Description: description,
+				Name:         "debian",
+				Version:      "13",
+				Arch:         "aarch64",
+				License:      license,
+				Description:  description,
+				ReleaseNotes: "https://www.debian.org/releases/trixie/releasenotes",
 				ExampleUserData: docs.UserData{
 					Username: "debian",
 				},
@@ -208,6 +220,12 @@ var _ = Describe("Debian", func() {
 	)
 })
 
+var _ = testutil.DescribeArtifactContract("debian:13 x86_64", func() api.Artifact {
+	c := New("13", "trixie", "x86_64", nil, nil)
+	c.getter = testutil.NewMockGetter("testdata/debian-13-genericcloud-amd64.json")
+	return c
+})
+
 func TestDebian(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Debian Suite")
@@ -0,0 +1,36 @@
+package debian
+
+import (
+	"os"
+	"testing"
+
+	"kubevirt.io/containerdisks/testutil"
+)
+
+func FuzzGetBuildData(f *testing.F) {
+	for _, seed := range []string{
+		"testdata/debian-12-genericcloud-amd64.json",
+		"testdata/debian-13-genericcloud-arm64.json",
+	} {
+		data, err := os.ReadFile(seed)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d := New("13", "trixie", "x86_64", nil, nil)
+		d.getter = testutil.NewMockGetterFromBytes(data)
+		tags, checksum, err := d.getBuildData("")
+		if err != nil {
+			return
+		}
+		if len(tags) != 1 {
+			t.Errorf("expected exactly one additional tag, got %v", tags)
+		}
+		if checksum == "" {
+			t.Errorf("expected a checksum for tags %v", tags)
+		}
+	})
+}
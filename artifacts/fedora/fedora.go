@@ -3,6 +3,7 @@ package fedora
 import (
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
 	"sort"
@@ -15,10 +16,14 @@ import (
 	"kubevirt.io/containerdisks/pkg/architecture"
 	"kubevirt.io/containerdisks/pkg/common"
 	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/hashsum"
 	"kubevirt.io/containerdisks/pkg/http"
 	"kubevirt.io/containerdisks/pkg/tests"
+	"kubevirt.io/containerdisks/pkg/version"
 )
 
+const license = "LicenseRef-Fedora"
+
 type Releases []Release
 
 type Release struct {
@@ -37,10 +42,18 @@ type fedora struct {
 	// ReleaseVersion is the original version from releases.json (e.g. "44 Beta"),
 	// used to match against release entries during Inspect.
 	ReleaseVersion string
-	Arch           string
-	Variant        string
-	getter         http.Getter
-	EnvVariables   map[string]string
+	// Compose identifies a historical compose of ReleaseVersion (e.g. "1.5"), which is looked up
+	// in the upstream archives instead of releases.json.
+	Compose      string
+	Arch         string
+	Variant      string
+	getter       http.Getter
+	EnvVariables map[string]string
+}
+
+type fedoraArchiveGatherer struct {
+	Archs  []string
+	getter http.Getter
 }
 
 type fedoraGatherer struct {
@@ -56,6 +69,9 @@ const (
 	amd64Arch      = "x86_64"
 	arm64Arch      = "aarch64"
 	s390xArch      = "s390x"
+
+	releaseNotesURLFmt = "https://docs.fedoraproject.org/en-US/fedora/f%s/release-notes/"
+	releasesURL        = "https://getfedora.org/releases.json"
 )
 
 //nolint:lll
@@ -64,7 +80,17 @@ const description = `<img src="https://upload.wikimedia.org/wikipedia/commons/th
 <br />
 Visit [getfedora.org](https://getfedora.org/) to learn more about the Fedora project.`
 
-var additionalUniqueTagRegExp = regexp.MustCompile(`\d+-\d+\.\d+`)
+var (
+	additionalUniqueTagRegExp = regexp.MustCompile(`\d+-\d+\.\d+`)
+	archivedVersionRegExp     = regexp.MustCompile(`^(\d+)-(\d+\.\d+)$`)
+)
+
+// archiveURLs are the locations historical releases are looked up in, in order. Releases are moved
+// to the archive some time after their end of life, until then they are only on the regular mirrors.
+var archiveURLs = []string{
+	"https://archives.fedoraproject.org/pub/archive/fedora/linux/releases",
+	"https://dl.fedoraproject.org/pub/fedora/linux/releases",
+}
 
 func (f *fedora) Metadata() *api.Metadata {
 	return &api.Metadata{
@@ -76,11 +102,20 @@ func (f *fedora) Metadata() *api.Metadata {
 		},
 		EnvVariables: f.EnvVariables,
 		Arch:         f.Arch,
+		License:      license,
 		IsStable:     IsStableVersion(f.ReleaseVersion),
+		ReleaseNotes: releaseNotes(f.ReleaseVersion),
+		Hints:        docs.Hints{SecureBoot: f.Arch != s390xArch},
+		// The cloud base images ship the qemu-guest-agent
+		AccessCredentials: true,
 	}
 }
 
 func (f *fedora) Inspect() (*api.ArtifactDetails, error) {
+	if f.Compose != "" {
+		return f.inspectArchived()
+	}
+
 	releases, err := getReleases(f.getter)
 	if err != nil {
 		return nil, fmt.Errorf("error getting releases: %v", err)
@@ -102,6 +137,10 @@ func (f *fedora) Inspect() (*api.ArtifactDetails, error) {
 		fileName := components[len(components)-1]
 		if matches := additionalUniqueTagRegExp.FindStringSubmatch(fileName); len(matches) > 0 {
 			details.AdditionalUniqueTags = append(details.AdditionalUniqueTags, matches[0])
+			details.CrossChecksums, err = f.crossCheck(&releases[i], matches[0])
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		return details, nil
@@ -110,6 +149,64 @@ func (f *fedora) Inspect() (*api.ArtifactDetails, error) {
 	return nil, fmt.Errorf("no release information in releases.json for fedora:%q found", f.Version)
 }
 
+// crossCheck compares the checksum of releases.json with the CHECKSUM file of the compose, which is served
+// by the mirrors instead of getfedora.org.
+func (f *fedora) crossCheck(release *Release, compose string) ([]hashsum.Checksum, error) {
+	source := hashsum.Source{
+		Locator: hashsum.Locator{
+			Location: hashsum.LocationDirectory,
+			Name:     fmt.Sprintf("Fedora-Cloud-%s-%s-CHECKSUM", compose, f.Arch),
+			Format:   hashsum.ChecksumFormatBSD,
+			Signed:   true,
+		},
+		Algorithm: hashsum.SHA256,
+	}
+	known := []hashsum.Checksum{{Algorithm: hashsum.SHA256, Value: release.Sha256, Source: releasesURL}}
+	return hashsum.CrossCheck(f.getter, release.Link, known, source)
+}
+
+func (f *fedora) inspectArchived() (*api.ArtifactDetails, error) {
+	var errs []error
+	for _, archiveURL := range archiveURLs {
+		baseURL := fmt.Sprintf("%s/%s/Cloud/%s/images", archiveURL, f.ReleaseVersion, f.Arch)
+		locator := hashsum.Locator{
+			Location: hashsum.LocationDirectory,
+			Name:     fmt.Sprintf("Fedora-Cloud-%s-%s-%s-CHECKSUM", f.ReleaseVersion, f.Compose, f.Arch),
+			Format:   hashsum.ChecksumFormatBSD,
+			Signed:   true,
+		}
+		checksums, err := locator.Checksums(f.getter, baseURL)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for fileName, checksum := range checksums {
+			if isArchivedCloudBaseImage(fileName, f.Arch) {
+				return &api.ArtifactDetails{
+					Checksum:          checksum,
+					ChecksumHash:      sha256.New,
+					DownloadURL:       fmt.Sprintf("%s/%s", baseURL, fileName),
+					ImageArchitecture: architecture.GetImageArchitecture(f.Arch),
+				}, nil
+			}
+		}
+
+		return nil, fmt.Errorf("no cloud base image for fedora:%q found in %s", f.Version, locator.Name)
+	}
+
+	return nil, fmt.Errorf("fedora:%q not found in the archives: %v", f.Version, errors.Join(errs...))
+}
+
+// isArchivedCloudBaseImage matches both the old (Fedora-Cloud-Base-39-1.5.x86_64.qcow2) and the
+// new (Fedora-Cloud-Base-Generic.x86_64-40-1.14.qcow2) naming scheme of the cloud base images.
+func isArchivedCloudBaseImage(fileName, arch string) bool {
+	return strings.HasPrefix(fileName, "Fedora-Cloud-Base-") &&
+		strings.HasSuffix(fileName, ".qcow2") &&
+		strings.Contains(fileName, arch) &&
+		!strings.Contains(fileName, "UKI")
+}
+
 func (f *fedora) VM(name, imgRef, userData string) *v1.VirtualMachine {
 	if f.Arch == s390xArch {
 		return docs.NewVM(
@@ -169,7 +266,7 @@ func (f *fedoraGatherer) Gather() ([][]api.Artifact, error) {
 		case !iStable && jStable:
 			return false
 		default:
-			return versionKeys[i] > versionKeys[j]
+			return version.Compare(versionKeys[i], versionKeys[j]) > 0
 		}
 	})
 
@@ -186,8 +283,23 @@ func (f *fedoraGatherer) Gather() ([][]api.Artifact, error) {
 	return artifacts, nil
 }
 
+func (f *fedoraArchiveGatherer) GatherArchived(version string) ([]api.Artifact, error) {
+	matches := archivedVersionRegExp.FindStringSubmatch(version)
+	if matches == nil {
+		return nil, fmt.Errorf("invalid fedora version %q, expected <release>-<compose>, e.g. 39-1.5", version)
+	}
+
+	var artifacts []api.Artifact
+	for _, arch := range f.Archs {
+		artifact := NewArchived(matches[1], matches[2], arch)
+		artifact.getter = f.getter
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts, nil
+}
+
 func getReleases(getter http.Getter) (Releases, error) {
-	raw, err := getter.GetAll("https://getfedora.org/releases.json")
+	raw, err := getter.GetAll(releasesURL)
 	if err != nil {
 		return nil, fmt.Errorf("error downloading the fedora releases.json file: %v", err)
 	}
@@ -210,7 +322,11 @@ func (f *fedora) releaseMatches(release *Release) bool {
 // versionNumber extracts the leading numeric portion of a version string.
 // For example "44 Beta" returns 44, and "43" returns 43.
 func versionNumber(version string) (int, error) {
-	return strconv.Atoi(strings.Fields(version)[0])
+	fields := strings.Fields(version)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty version %q", version)
+	}
+	return strconv.Atoi(fields[0])
 }
 
 // IsStableVersion returns true if the version string is a pure integer
@@ -220,6 +336,14 @@ func IsStableVersion(version string) bool {
 	return err == nil
 }
 
+// releaseNotes returns the URL of the release notes of a stable Fedora release.
+func releaseNotes(version string) string {
+	if !IsStableVersion(version) {
+		return ""
+	}
+	return fmt.Sprintf(releaseNotesURLFmt, version)
+}
+
 // NormalizeVersion converts a version string into a valid container image tag
 // by lowercasing and replacing spaces with hyphens (e.g. "44 Beta" -> "44-beta").
 func NormalizeVersion(version string) string {
@@ -287,6 +411,22 @@ func New(release, arch string) *fedora {
 	return f
 }
 
+// NewArchived returns a fedora artifact for a historical compose of a release.
+// Its version is the unique tag of the compose, so floating tags are never moved by it.
+func NewArchived(release, compose, arch string) *fedora {
+	f := New(release, arch)
+	f.Version = fmt.Sprintf("%s-%s", release, compose)
+	f.Compose = compose
+	return f
+}
+
+func NewArchiveGatherer() *fedoraArchiveGatherer {
+	return &fedoraArchiveGatherer{
+		Archs:  []string{amd64Arch, arm64Arch},
+		getter: &http.HTTPGetter{},
+	}
+}
+
 func NewGatherer() *fedoraGatherer {
 	return &fedoraGatherer{
 		Archs:      []string{amd64Arch, arm64Arch, s390xArch},
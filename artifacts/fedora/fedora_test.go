@@ -46,8 +46,12 @@ var _ = Describe("Fedora", func() {
 					common.DefaultInstancetypeEnv: defaultInstancetype,
 					common.DefaultPreferenceEnv:   defaultPreferenceX86_64,
 				},
-				Arch:     "x86_64",
-				IsStable: true,
+				Arch:              "x86_64",
+				License:           license,
+				AccessCredentials: true,
+				IsStable:          true,
+				ReleaseNotes:      "https://docs.fedoraproject.org/en-US/fedora/f40/release-notes/",
+				Hints:             docs.Hints{SecureBoot: true},
 			},
 		),
 		Entry("fedora:40 aarch64", "40", "aarch64", "testdata/releases.json",
@@ -68,8 +72,12 @@ var _ = Describe("Fedora", func() {
 					common.DefaultInstancetypeEnv: defaultInstancetype,
 					common.DefaultPreferenceEnv:   defaultPreferenceAarch64,
 				},
-				Arch:     "aarch64",
-				IsStable: true,
+				Arch:              "aarch64",
+				License:           license,
+				AccessCredentials: true,
+				IsStable:          true,
+				ReleaseNotes:      "https://docs.fedoraproject.org/en-US/fedora/f40/release-notes/",
+				Hints:             docs.Hints{SecureBoot: true},
 			},
 		),
 		Entry("fedora:40 s390x", "40", "s390x", "testdata/releases.json",
@@ -90,8 +98,11 @@ var _ = Describe("Fedora", func() {
 					common.DefaultInstancetypeEnv: defaultInstancetype,
 					common.DefaultPreferenceEnv:   defaultPreferenceS390x,
 				},
-				Arch:     "s390x",
-				IsStable: true,
+				Arch:              "s390x",
+				License:           license,
+				AccessCredentials: true,
+				IsStable:          true,
+				ReleaseNotes:      "https://docs.fedoraproject.org/en-US/fedora/f40/release-notes/",
 			},
 		),
 		Entry("fedora:39 x86_64", "39", "x86_64", "testdata/releases.json",
@@ -112,8 +123,12 @@ var _ = Describe("Fedora", func() {
 					common.DefaultInstancetypeEnv: defaultInstancetype,
 					common.DefaultPreferenceEnv:   defaultPreferenceX86_64,
 				},
-				Arch:     "x86_64",
-				IsStable: true,
+				Arch:              "x86_64",
+				License:           license,
+				AccessCredentials: true,
+				IsStable:          true,
+				ReleaseNotes:      "https://docs.fedoraproject.org/en-US/fedora/f39/release-notes/",
+				Hints:             docs.Hints{SecureBoot: true},
 			},
 		),
 		Entry("fedora:39 aarch64", "39", "aarch64", "testdata/releases.json",
@@ -134,8 +149,12 @@ var _ = Describe("Fedora", func() {
 					common.DefaultInstancetypeEnv: defaultInstancetype,
 					common.DefaultPreferenceEnv:   defaultPreferenceAarch64,
 				},
-				Arch:     "aarch64",
-				IsStable: true,
+				Arch:              "aarch64",
+				License:           license,
+				AccessCredentials: true,
+				IsStable:          true,
+				ReleaseNotes:      "https://docs.fedoraproject.org/en-US/fedora/f39/release-notes/",
+				Hints:             docs.Hints{SecureBoot: true},
 			},
 		),
 		Entry("fedora:39 s390x", "39", "s390x", "testdata/releases.json",
@@ -156,8 +175,11 @@ var _ = Describe("Fedora", func() {
 					common.DefaultInstancetypeEnv: defaultInstancetype,
 					common.DefaultPreferenceEnv:   defaultPreferenceS390x,
 				},
-				Arch:     "s390x",
-				IsStable: true,
+				Arch:              "s390x",
+				License:           license,
+				AccessCredentials: true,
+				IsStable:          true,
+				ReleaseNotes:      "https://docs.fedoraproject.org/en-US/fedora/f39/release-notes/",
 			},
 		),
 		Entry("fedora:41-beta aarch64", "41 Beta", "aarch64", "testdata/releases.json",
@@ -178,7 +200,10 @@ var _ = Describe("Fedora", func() {
 					common.DefaultInstancetypeEnv: defaultInstancetype,
 					common.DefaultPreferenceEnv:   defaultPreferenceAarch64,
 				},
-				Arch: "aarch64",
+				Arch:              "aarch64",
+				License:           license,
+				AccessCredentials: true,
+				Hints:             docs.Hints{SecureBoot: true},
 			},
 		),
 	)
@@ -209,6 +234,39 @@ var _ = Describe("Fedora", func() {
 		Expect(got).To(Equal(artifacts))
 	})
 
+	It("Inspect should look up archived composes", func() {
+		c := NewArchived("39", "1.5", "x86_64")
+		c.getter = testutil.NewMockGetter("testdata/Fedora-Cloud-39-1.5-x86_64-CHECKSUM")
+		got, err := c.Inspect()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got.Checksum).To(Equal("ab5be5058c5c839528a7d6373934e0ce5ad6c8f80bd71ed3390032027da52f37"))
+		Expect(got.DownloadURL).To(Equal(
+			"https://archives.fedoraproject.org/pub/archive/fedora/linux/releases/39/Cloud/x86_64/images/Fedora-Cloud-Base-39-1.5.x86_64.qcow2")) //nolint:lll
+		Expect(got.AdditionalUniqueTags).To(BeEmpty())
+		Expect(got.ImageArchitecture).To(Equal("amd64"))
+		Expect(c.Metadata().Describe()).To(Equal("fedora:39-1.5"))
+	})
+
+	It("GatherArchived should return an artifact per architecture", func() {
+		c := NewArchiveGatherer()
+		got, err := c.GatherArchived("39-1.5")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(HaveLen(2))
+		Expect(got[0].Metadata().Arch).To(Equal("x86_64"))
+		Expect(got[1].Metadata().Arch).To(Equal("aarch64"))
+		Expect(got[0].Metadata().Version).To(Equal("39-1.5"))
+	})
+
+	DescribeTable("GatherArchived should reject invalid versions",
+		func(version string) {
+			_, err := NewArchiveGatherer().GatherArchived(version)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("release only", "39"),
+		Entry("prerelease", "41 Beta"),
+		Entry("garbage", "39-1.5/../.."),
+	)
+
 	DescribeTable("IsStableVersion",
 		func(version string, expected bool) {
 			Expect(IsStableVersion(version)).To(Equal(expected))
@@ -227,6 +285,18 @@ var _ = Describe("Fedora", func() {
 	)
 })
 
+var _ = testutil.DescribeArtifactContract("fedora:40 x86_64", func() api.Artifact {
+	c := New("40", "x86_64")
+	c.getter = testutil.NewMockGetter("testdata/releases.json")
+	return c
+})
+
+var _ = testutil.DescribeArtifactContract("fedora:39-1.5 x86_64", func() api.Artifact {
+	c := NewArchived("39", "1.5", "x86_64")
+	c.getter = testutil.NewMockGetter("testdata/Fedora-Cloud-39-1.5-x86_64-CHECKSUM")
+	return c
+})
+
 func parsedRelease(version, releaseVersion, arch, defaultPreference string) api.Artifact {
 	return &fedora{
 		Version:        version,
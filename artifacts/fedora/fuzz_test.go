@@ -0,0 +1,45 @@
+package fedora
+
+import (
+	"os"
+	"testing"
+
+	"kubevirt.io/containerdisks/testutil"
+)
+
+func FuzzGetReleases(f *testing.F) {
+	data, err := os.ReadFile("testdata/releases.json")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Add([]byte(`[{"version":" ","arch":"x86_64","variant":"Cloud","link":"x.qcow2"}]`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		getter := testutil.NewMockGetterFromBytes(data)
+		if _, err := getReleases(getter); err != nil {
+			return
+		}
+
+		g := NewGatherer()
+		g.getter = getter
+		artifacts, err := g.Gather()
+		if err != nil {
+			return
+		}
+		for _, release := range artifacts {
+			for _, artifact := range release {
+				a := artifact.(*fedora)
+				a.getter = getter
+				details, err := a.Inspect()
+				if err != nil {
+					t.Errorf("gathered artifact %s can't be inspected: %v", a.Metadata().Describe(), err)
+					continue
+				}
+				if details.DownloadURL == "" {
+					t.Errorf("gathered artifact %s has no download URL", a.Metadata().Describe())
+				}
+			}
+		}
+	})
+}
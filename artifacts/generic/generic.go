@@ -20,10 +20,12 @@ func (c *generic) Inspect() (*api.ArtifactDetails, error) {
 	return c.artifactDetails, nil
 }
 
+// VM configures the virtual hardware according to the hints of the metadata.
 func (c *generic) VM(name, imgRef, _ string) *v1.VirtualMachine {
-	return docs.BasicVM(
+	return docs.NewVM(
 		name,
 		imgRef,
+		c.metadata.Hints.Options()...,
 	)
 }
 
@@ -0,0 +1,171 @@
+package haos
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.podman.io/image/v5/pkg/compression/types"
+	v1 "kubevirt.io/api/core/v1"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/architecture"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+const (
+	license = "Apache-2.0"
+	// releasesURL is the GitHub API of the releases, a token for api.github.com can be configured in the
+	// credentials file to avoid its rate limit for anonymous requests.
+	releasesURL     = "https://api.github.com/repos/home-assistant/operating-system/releases"
+	releaseNotesURL = "https://github.com/home-assistant/operating-system/releases/tag/"
+
+	amd64Arch = "x86_64"
+	arm64Arch = "aarch64"
+
+	// digestPrefix prefixes the checksums GitHub computes for release assets.
+	digestPrefix = "sha256:"
+)
+
+// imageNames maps the architectures to the name patterns of their qcow2 images.
+var imageNames = map[string]string{
+	amd64Arch: "haos_ova-%s.qcow2.xz",
+	arm64Arch: "haos_generic-aarch64-%s.qcow2.xz",
+}
+
+const description = `[Home Assistant OS](https://www.home-assistant.io/installation/) for KubeVirt.
+<br />
+<br />
+The images require EFI firmware without secure boot. The onboarding is available on port 8123 once the first boot
+completed, which takes a few minutes.`
+
+type release struct {
+	TagName string  `json:"tag_name"`
+	Assets  []asset `json:"assets"`
+}
+
+type asset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+	// Digest is the checksum of the asset, e.g. sha256:e3b0c4...
+	Digest string `json:"digest"`
+}
+
+type haos struct {
+	Version string
+	Arch    string
+	getter  http.Getter
+}
+
+type haosGatherer struct {
+	Archs  []string
+	getter http.Getter
+}
+
+func (h *haos) Metadata() *api.Metadata {
+	return &api.Metadata{
+		Name:         "haos",
+		Version:      h.Version,
+		Description:  description,
+		Arch:         h.Arch,
+		IsStable:     true,
+		License:      license,
+		ReleaseNotes: releaseNotesURL + h.Version,
+		Verification: api.Verification{NoCloudInit: true},
+	}
+}
+
+// Inspect looks up the image in the assets of the release, which are verified against the checksums GitHub
+// computes when they are uploaded.
+func (h *haos) Inspect() (*api.ArtifactDetails, error) {
+	imageName, ok := imageNames[h.Arch]
+	if !ok {
+		return nil, fmt.Errorf("unsupported architecture %q for haos", h.Arch)
+	}
+	imageName = fmt.Sprintf(imageName, h.Version)
+
+	rel, err := getRelease(h.getter, releasesURL+"/tags/"+h.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range rel.Assets {
+		if a.Name != imageName {
+			continue
+		}
+		if !strings.HasPrefix(a.Digest, digestPrefix) {
+			return nil, fmt.Errorf("no sha256 digest for haos asset %s found", imageName)
+		}
+		return &api.ArtifactDetails{
+			Checksum:          strings.TrimPrefix(a.Digest, digestPrefix),
+			ChecksumHash:      sha256.New,
+			DownloadURL:       a.BrowserDownloadURL,
+			Compression:       types.XzAlgorithmName,
+			ImageArchitecture: architecture.GetImageArchitecture(h.Arch),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no asset %s in haos release %s found", imageName, h.Version)
+}
+
+func (h *haos) VM(name, imgRef, _ string) *v1.VirtualMachine {
+	return docs.NewVM(name, imgRef, docs.WithRng(), docs.WithEFI())
+}
+
+func (h *haos) UserData(_ *docs.UserData) string {
+	return ""
+}
+
+func (h *haos) Tests() []api.ArtifactTest {
+	return []api.ArtifactTest{}
+}
+
+// Gather returns the latest release, GitHub excludes drafts and prereleases from it.
+func (g *haosGatherer) Gather() ([][]api.Artifact, error) {
+	rel, err := getRelease(g.getter, releasesURL+"/latest")
+	if err != nil {
+		return nil, err
+	}
+	if rel.TagName == "" {
+		return nil, fmt.Errorf("no tag in the latest haos release found")
+	}
+
+	var artifacts []api.Artifact
+	for _, arch := range g.Archs {
+		artifact := New(rel.TagName, arch)
+		artifact.getter = g.getter
+		artifacts = append(artifacts, artifact)
+	}
+	return [][]api.Artifact{artifacts}, nil
+}
+
+func getRelease(getter http.Getter, releaseURL string) (*release, error) {
+	raw, err := getter.GetAll(releaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading the haos release: %v", err)
+	}
+
+	rel := &release{}
+	if err := json.Unmarshal(raw, rel); err != nil {
+		return nil, fmt.Errorf("error parsing the haos release: %v", err)
+	}
+
+	return rel, nil
+}
+
+func New(version, arch string) *haos {
+	return &haos{
+		Version: version,
+		Arch:    arch,
+		getter:  &http.HTTPGetter{},
+	}
+}
+
+func NewGatherer() *haosGatherer {
+	return &haosGatherer{
+		Archs:  []string{amd64Arch, arm64Arch},
+		getter: &http.HTTPGetter{},
+	}
+}
@@ -0,0 +1,77 @@
+package haos
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+const (
+	upstream    = "https://api.github.com/"
+	downloadURL = "https://github.com/home-assistant/operating-system/releases/download/"
+)
+
+var _ = Describe("Home Assistant OS", func() {
+	var mirror *testutil.MirrorServer
+
+	BeforeEach(func() {
+		mirror = testutil.NewMirrorServer("testdata/mirror")
+		DeferCleanup(mirror.Close)
+	})
+
+	It("Gather should return all architectures of the latest release", func() {
+		gatherer := &haosGatherer{Archs: []string{amd64Arch, arm64Arch}, getter: mirror.Getter(upstream)}
+		artifacts, err := gatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(artifacts).To(HaveLen(1))
+		Expect(artifacts[0]).To(HaveLen(2))
+		Expect(artifacts[0][0].Metadata().Describe()).To(Equal("haos:16.2"))
+		Expect(artifacts[0][1].Metadata().Arch).To(Equal(arm64Arch))
+	})
+
+	DescribeTable("Inspect should use the digest of the release asset",
+		func(arch, imageName, checksum string) {
+			artifact := &haos{Version: "16.2", Arch: arch, getter: mirror.Getter(upstream)}
+			details, err := artifact.Inspect()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(details.Checksum).To(Equal(checksum))
+			Expect(details.DownloadURL).To(Equal(downloadURL + "16.2/" + imageName))
+			Expect(details.Compression).To(Equal("Xz"))
+		},
+		Entry("x86_64", amd64Arch, "haos_ova-16.2.qcow2.xz", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"),
+		Entry("aarch64", arm64Arch, "haos_generic-aarch64-16.2.qcow2.xz",
+			"fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"),
+	)
+
+	It("Inspect should fail if the asset has no digest", func() {
+		artifact := &haos{Version: "15.0", Arch: amd64Arch, getter: mirror.Getter(upstream)}
+		_, err := artifact.Inspect()
+		Expect(err).To(MatchError(ContainSubstring("no sha256 digest")))
+	})
+
+	It("Inspect should fail if the release has no image for the architecture", func() {
+		artifact := &haos{Version: "15.0", Arch: arm64Arch, getter: mirror.Getter(upstream)}
+		_, err := artifact.Inspect()
+		Expect(err).To(MatchError(ContainSubstring("no asset haos_generic-aarch64-15.0.qcow2.xz")))
+	})
+
+	It("VM should boot with EFI without secure boot", func() {
+		vm := New("16.2", amd64Arch).VM("haos", "registry/haos:16.2", "")
+		Expect(*vm.Spec.Template.Spec.Domain.Firmware.Bootloader.EFI.SecureBoot).To(BeFalse())
+	})
+})
+
+var _ = testutil.DescribeArtifactContract("haos:16.2", func() api.Artifact {
+	artifact := New("16.2", amd64Arch)
+	artifact.getter = testutil.NewMockGetter("testdata/mirror/repos/home-assistant/operating-system/releases/tags/16.2")
+	return artifact
+})
+
+func TestHAOS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Home Assistant OS Suite")
+}
@@ -3,17 +3,19 @@ package leap
 import (
 	"crypto/sha256"
 	"fmt"
-	"strings"
 
 	v1 "kubevirt.io/api/core/v1"
 
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/architecture"
 	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/hashsum"
 	"kubevirt.io/containerdisks/pkg/http"
 	"kubevirt.io/containerdisks/pkg/tests"
 )
 
+const license = "LicenseRef-openSUSE"
+
 type leap struct {
 	Arch         string
 	Version      string
@@ -24,21 +26,32 @@ type leap struct {
 var _ api.Artifact = &leap{}
 
 const (
-	baseURLFmt  = "https://download.opensuse.org/distribution/leap/%s/appliances/openSUSE-Leap-%s-Minimal-VM.%s-Cloud.qcow2"
-	description = `openSUSE Leap images for KubeVirt.
+	baseURLFmt = "https://download.opensuse.org/distribution/leap/%s/appliances/openSUSE-Leap-%s-Minimal-VM.%s-Cloud.qcow2"
+	// releaseNotesURLFmt is the URL of the release notes of a Leap release by architecture and version
+	releaseNotesURLFmt = "https://doc.opensuse.org/release-notes/%s/openSUSE/Leap/%s/"
+	description        = `openSUSE Leap images for KubeVirt.
 <br />
 <br />
 Visit [get.opensuse.org/leap/](https://get.opensuse.org/leap/) to learn more about openSUSE Leap.`
 )
 
+// checksumLocator finds the checksum file published next to every image.
+var checksumLocator = hashsum.Locator{Location: hashsum.LocationImage, Name: ".sha256", Format: hashsum.ChecksumFormatGNU}
+
+// endOfLife lists the announced end of life dates of Leap releases.
+var endOfLife = map[string]string{
+	"15.5": "2024-12-31",
+	"15.6": "2026-04-30",
+}
+
 func (l *leap) Inspect() (*api.ArtifactDetails, error) {
 	baseURL := fmt.Sprintf(baseURLFmt, l.Version, l.Version, l.Arch)
-	checksumBytes, err := l.getter.GetAll(baseURL + ".sha256")
+	checksum, err := checksumLocator.Lookup(l.getter, baseURL)
 	if err != nil {
 		return nil, err
 	}
 	return &api.ArtifactDetails{
-		Checksum:          strings.Split(string(checksumBytes), " ")[0],
+		Checksum:          checksum,
 		ChecksumHash:      sha256.New,
 		DownloadURL:       baseURL,
 		ImageArchitecture: architecture.GetImageArchitecture(l.Arch),
@@ -55,6 +68,9 @@ func (l *leap) Metadata() *api.Metadata {
 		},
 		EnvVariables: l.envVariables,
 		Arch:         l.Arch,
+		License:      license,
+		EOL:          endOfLife[l.Version],
+		ReleaseNotes: fmt.Sprintf(releaseNotesURLFmt, l.Arch, l.Version),
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/common"
 	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/http"
 	"kubevirt.io/containerdisks/testutil"
 )
 
@@ -49,7 +50,10 @@ var _ = Describe("openSUSE Leap", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "opensuse.leap",
 				},
-				Arch: "x86_64",
+				Arch:         "x86_64",
+				License:      license,
+				EOL:          "2026-04-30",
+				ReleaseNotes: "https://doc.opensuse.org/release-notes/x86_64/openSUSE/Leap/15.6/",
 			},
 		),
 		Entry("leap:15.6 aarch64", "aarch64", "15.6", "testdata/openSUSE-Leap-15.6-Minimal-VM.aarch64-Cloud.qcow2.sha256",
@@ -66,7 +70,10 @@ var _ = Describe("openSUSE Leap", func() {
 				ExampleUserData: docs.UserData{
 					Username: "opensuse",
 				},
-				Arch: "aarch64",
+				Arch:         "aarch64",
+				License:      license,
+				EOL:          "2026-04-30",
+				ReleaseNotes: "https://doc.opensuse.org/release-notes/aarch64/openSUSE/Leap/15.6/",
 			},
 		),
 		Entry("leap:15.5 x86_64", "x86_64", "15.5", "testdata/openSUSE-Leap-15.5-Minimal-VM.x86_64-Cloud.qcow2.sha256",
@@ -90,7 +97,10 @@ var _ = Describe("openSUSE Leap", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "opensuse.leap",
 				},
-				Arch: "x86_64",
+				Arch:         "x86_64",
+				License:      license,
+				EOL:          "2024-12-31",
+				ReleaseNotes: "https://doc.opensuse.org/release-notes/x86_64/openSUSE/Leap/15.5/",
 			},
 		),
 		Entry("leap:15.5 aarch64", "aarch64", "15.5", "testdata/openSUSE-Leap-15.5-Minimal-VM.aarch64-Cloud.qcow2.sha256",
@@ -107,10 +117,30 @@ var _ = Describe("openSUSE Leap", func() {
 				ExampleUserData: docs.UserData{
 					Username: "opensuse",
 				},
-				Arch: "aarch64",
+				Arch:         "aarch64",
+				License:      license,
+				EOL:          "2024-12-31",
+				ReleaseNotes: "https://doc.opensuse.org/release-notes/aarch64/openSUSE/Leap/15.5/",
 			},
 		),
 	)
+
+	// Run with HTTP_RECORD=true to refresh the recordings from download.opensuse.org.
+	It("Inspect should work with recorded upstream responses", func() {
+		c := New("x86_64", "15.6", nil)
+		c.getter = http.NewRecordingGetter("testdata/recordings", http.RecordModeFromEnv(), nil)
+		got, err := c.Inspect()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got.Checksum).To(HaveLen(64))
+		Expect(got.DownloadURL).To(Equal(
+			"https://download.opensuse.org/distribution/leap/15.6/appliances/openSUSE-Leap-15.6-Minimal-VM.x86_64-Cloud.qcow2"))
+	})
+})
+
+var _ = testutil.DescribeArtifactContract("leap:15.6 x86_64", func() api.Artifact {
+	c := New("x86_64", "15.6", nil)
+	c.getter = testutil.NewMockGetter("testdata/openSUSE-Leap-15.6-Minimal-VM.x86_64-Cloud.qcow2.sha256")
+	return c
 })
 
 func TestLeap(t *testing.T) {
@@ -1,7 +1,6 @@
 package microos
 
 import (
-	"bytes"
 	"crypto/sha256"
 	"fmt"
 	"regexp"
@@ -14,8 +13,11 @@ import (
 	"kubevirt.io/containerdisks/pkg/hashsum"
 	"kubevirt.io/containerdisks/pkg/http"
 	"kubevirt.io/containerdisks/pkg/tests"
+	"kubevirt.io/containerdisks/pkg/version"
 )
 
+const license = "LicenseRef-openSUSE"
+
 type microos struct {
 	Arch         string
 	variant      string
@@ -25,6 +27,9 @@ type microos struct {
 
 var _ api.Artifact = &microos{}
 
+// checksumLocator finds the SHA256SUMS file in the appliances directory.
+var checksumLocator = hashsum.Locator{Location: hashsum.LocationDirectory, Name: "SHA256SUMS", Format: hashsum.ChecksumFormatGNU}
+
 const description = `openSUSE MicroOS images for KubeVirt.
 <br />
 <br />
@@ -38,28 +43,29 @@ const (
 
 func (t *microos) Inspect() (*api.ArtifactDetails, error) {
 	baseURL := t.retrieveBaseURL()
-	raw, err := t.getter.GetAll(baseURL + "SHA256SUMS")
+	checksums, err := checksumLocator.Checksums(t.getter, baseURL)
 	if err != nil {
-		return nil, fmt.Errorf("error downloading the SHA256SUMS file: %v", err)
-	}
-	checksums, err := hashsum.Parse(bytes.NewReader(raw), hashsum.ChecksumFormatGNU)
-	if err != nil {
-		return nil, fmt.Errorf("error reading the SHA256SUMS file: %v", err)
+		return nil, err
 	}
 
 	// openSUSE-MicroOS.x86_64-16.0.0-OpenStack-Cloud-Snapshot20260207.qcow2
 	// openSUSE-MicroOS.s390x-16.0.0-s390x-Cloud-Snapshot20260209.qcow2
 	r := regexp.MustCompile(fmt.Sprintf(`%s\.%s-%s-%s`, t.variant, t.Arch, t.retrieveRegexpVersion(), t.subvariantByArchitecture()))
-	for file, checksum := range checksums {
+	// Keep the latest snapshot if the checksum file lists several
+	var files []string
+	for file := range checksums {
 		if r.MatchString(file) {
-			return &api.ArtifactDetails{
-				Checksum:          checksum,
-				ChecksumHash:      sha256.New,
-				DownloadURL:       baseURL + file,
-				ImageArchitecture: architecture.GetImageArchitecture(t.Arch),
-			}, nil
+			files = append(files, file)
 		}
 	}
+	if file := version.Latest(files); file != "" {
+		return &api.ArtifactDetails{
+			Checksum:          checksums[file],
+			ChecksumHash:      sha256.New,
+			DownloadURL:       baseURL + file,
+			ImageArchitecture: architecture.GetImageArchitecture(t.Arch),
+		}, nil
+	}
 	return nil, fmt.Errorf("variant %q does not exist in the SHA256SUMS file: %v", t.variant, err)
 }
 
@@ -91,6 +97,7 @@ func (t *microos) Metadata() *api.Metadata {
 		},
 		EnvVariables: t.envVariables,
 		Arch:         t.Arch,
+		License:      license,
 	}
 }
 
@@ -49,7 +49,8 @@ var _ = Describe("openSUSE MicroOS", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "opensuse.tumbleweed",
 				},
-				Arch: "x86_64",
+				Arch:    "x86_64",
+				License: license,
 			},
 		),
 		Entry("microos:1 s390x", "s390x", "testdata/microos-s390x.SHA256SUM",
@@ -73,12 +74,19 @@ var _ = Describe("openSUSE MicroOS", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "opensuse.tumbleweed",
 				},
-				Arch: "s390x",
+				Arch:    "s390x",
+				License: license,
 			},
 		),
 	)
 })
 
+var _ = testutil.DescribeArtifactContract("microos:1 x86_64", func() api.Artifact {
+	c := New("x86_64", nil)
+	c.getter = testutil.NewMockGetter("testdata/microos.SHA256SUM")
+	return c
+})
+
 func TestMicroOS(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "openSUSE MicroOS Suite")
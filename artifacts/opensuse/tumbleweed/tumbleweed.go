@@ -1,8 +1,9 @@
 package tumbleweed
 
 import (
-	"bytes"
+	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"regexp"
 
@@ -14,18 +15,39 @@ import (
 	"kubevirt.io/containerdisks/pkg/hashsum"
 	"kubevirt.io/containerdisks/pkg/http"
 	"kubevirt.io/containerdisks/pkg/tests"
+	"kubevirt.io/containerdisks/pkg/version"
 )
 
+const (
+	license = "LicenseRef-openSUSE"
+	// historyURL lists the snapshots openSUSE keeps of the Tumbleweed tree. The s390x port lives outside of it.
+	historyURL = "https://download.opensuse.org/history/"
+	amd64Arch  = "x86_64"
+)
+
+// snapshotRex matches the snapshots of the history, e.g. 20240629.
+var snapshotRex = regexp.MustCompile(`^\d{8}$`)
+
 type tumbleweed struct {
-	Arch         string
+	Arch string
+	// Snapshot is the snapshot of the history the image is taken from, the current snapshot if it is empty.
+	Snapshot     string
 	variant      string
 	subVariant   string
 	getter       http.Getter
 	envVariables map[string]string
 }
 
+type tumbleweedArchiveGatherer struct {
+	envVariables map[string]string
+	getter       http.Getter
+}
+
 var _ api.Artifact = &tumbleweed{}
 
+// checksumLocator finds the SHA256SUMS file in the appliances directory.
+var checksumLocator = hashsum.Locator{Location: hashsum.LocationDirectory, Name: "SHA256SUMS", Format: hashsum.ChecksumFormatGNU}
+
 const description = `openSUSE Tumbleweed images for KubeVirt.
 <br />
 <br />
@@ -34,31 +56,39 @@ const s390xArch = "s390x"
 
 func (t *tumbleweed) Inspect() (*api.ArtifactDetails, error) {
 	baseURL := t.retrieveBaseURL()
-	raw, err := t.getter.GetAll(baseURL + "SHA256SUMS")
-	if err != nil {
-		return nil, fmt.Errorf("error downloading the tumbleweed SHA256SUMS file: %v", err)
-	}
-	checksums, err := hashsum.Parse(bytes.NewReader(raw), hashsum.ChecksumFormatGNU)
+	checksums, err := checksumLocator.Checksums(t.getter, baseURL)
 	if err != nil {
-		return nil, fmt.Errorf("error reading the SHA256SUMS file: %v", err)
+		return nil, err
 	}
 
 	// openSUSE-Tumbleweed-Minimal-VM.x86_64-1.0.0-kvm-and-xen-Snapshot20240629.qcow2
-	r := regexp.MustCompile(fmt.Sprintf(`%s\.%s-%s-%s`, t.variant, t.Arch, t.retrieveRegexpVersion(), t.subVariant))
-	for file, checksum := range checksums {
+	pattern := fmt.Sprintf(`%s\.%s-%s-%s`, t.variant, t.Arch, t.retrieveRegexpVersion(), t.subVariant)
+	if t.Snapshot != "" {
+		pattern += `-Snapshot` + t.Snapshot + `\.`
+	}
+	r := regexp.MustCompile(pattern)
+	// Keep the latest snapshot if the checksum file lists several
+	var files []string
+	for file := range checksums {
 		if r.MatchString(file) {
-			return &api.ArtifactDetails{
-				Checksum:          checksum,
-				ChecksumHash:      sha256.New,
-				DownloadURL:       baseURL + file,
-				ImageArchitecture: architecture.GetImageArchitecture(t.Arch),
-			}, nil
+			files = append(files, file)
 		}
 	}
+	if file := version.Latest(files); file != "" {
+		return &api.ArtifactDetails{
+			Checksum:          checksums[file],
+			ChecksumHash:      sha256.New,
+			DownloadURL:       baseURL + file,
+			ImageArchitecture: architecture.GetImageArchitecture(t.Arch),
+		}, nil
+	}
 	return nil, fmt.Errorf("variant %q does not exist in the SHA256SUMS file: %v", t.variant, err)
 }
 
 func (t *tumbleweed) retrieveBaseURL() string {
+	if t.Snapshot != "" {
+		return historyURL + t.Snapshot + "/tumbleweed/appliances/"
+	}
 	if t.Arch == s390xArch {
 		return "https://download.opensuse.org/ports/zsystems/tumbleweed/appliances/"
 	}
@@ -73,15 +103,20 @@ func (t *tumbleweed) retrieveRegexpVersion() string {
 }
 
 func (t *tumbleweed) Metadata() *api.Metadata {
+	version := "1.0.0"
+	if t.Snapshot != "" {
+		version = t.Snapshot
+	}
 	return &api.Metadata{
 		Name:        "opensuse-tumbleweed",
-		Version:     "1.0.0",
+		Version:     version,
 		Description: description,
 		ExampleUserData: docs.UserData{
 			Username: "opensuse",
 		},
 		EnvVariables: t.envVariables,
 		Arch:         t.Arch,
+		License:      license,
 	}
 }
 
@@ -104,6 +139,45 @@ func (t *tumbleweed) Tests() []api.ArtifactTest {
 	}
 }
 
+// GatherArchived returns the artifact of a snapshot of the history, e.g. 20240629.
+func (g *tumbleweedArchiveGatherer) GatherArchived(version string) ([]api.Artifact, error) {
+	if !snapshotRex.MatchString(version) {
+		return nil, fmt.Errorf("invalid tumbleweed snapshot %q, expected <yyyymmdd>, e.g. 20240629", version)
+	}
+	return []api.Artifact{g.newSnapshot(version)}, nil
+}
+
+// GatherSnapshots returns the artifacts of the latest snapshots of the history, the latest first.
+func (g *tumbleweedArchiveGatherer) GatherSnapshots(count int) ([][]api.Artifact, error) {
+	entries, err := http.List(context.Background(), g.getter, historyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []http.ListingEntry
+	for _, entry := range entries {
+		if entry.Dir && snapshotRex.MatchString(entry.Name) {
+			snapshots = append(snapshots, entry)
+		}
+	}
+	if len(snapshots) == 0 {
+		return nil, errors.New("no tumbleweed snapshots found in the history")
+	}
+	http.SortListingByVersion(snapshots)
+
+	var artifacts [][]api.Artifact
+	for _, snapshot := range snapshots[:min(count, len(snapshots))] {
+		artifacts = append(artifacts, []api.Artifact{g.newSnapshot(snapshot.Name)})
+	}
+	return artifacts, nil
+}
+
+func (g *tumbleweedArchiveGatherer) newSnapshot(snapshot string) *tumbleweed {
+	artifact := NewSnapshot(snapshot, g.envVariables)
+	artifact.getter = g.getter
+	return artifact
+}
+
 func New(arch string, envVariables map[string]string) *tumbleweed {
 	return &tumbleweed{
 		Arch:         arch,
@@ -113,3 +187,18 @@ func New(arch string, envVariables map[string]string) *tumbleweed {
 		envVariables: envVariables,
 	}
 }
+
+// NewSnapshot returns a tumbleweed artifact for a snapshot of the history. Its version is the snapshot, so
+// floating tags are never moved by it. Only x86_64 images are part of the history.
+func NewSnapshot(snapshot string, envVariables map[string]string) *tumbleweed {
+	t := New(amd64Arch, envVariables)
+	t.Snapshot = snapshot
+	return t
+}
+
+func NewArchiveGatherer(envVariables map[string]string) *tumbleweedArchiveGatherer {
+	return &tumbleweedArchiveGatherer{
+		envVariables: envVariables,
+		getter:       &http.HTTPGetter{},
+	}
+}
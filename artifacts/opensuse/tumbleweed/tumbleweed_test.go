@@ -49,7 +49,8 @@ var _ = Describe("openSUSE Tumbleweed", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "opensuse.tumbleweed",
 				},
-				Arch: "x86_64",
+				Arch:    "x86_64",
+				License: license,
 			},
 		),
 		Entry("tumbleweed:1 s390x", "s390x", "testdata/tumbleweed-s390x.SHA256SUM",
@@ -73,12 +74,76 @@ var _ = Describe("openSUSE Tumbleweed", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "opensuse.tumbleweed",
 				},
-				Arch: "s390x",
+				Arch:    "s390x",
+				License: license,
 			},
 		),
 	)
 })
 
+var _ = Describe("openSUSE Tumbleweed history", func() {
+	const upstream = "https://download.opensuse.org/"
+	var mirror *testutil.MirrorServer
+
+	BeforeEach(func() {
+		mirror = testutil.NewMirrorServer("testdata/mirror")
+		DeferCleanup(mirror.Close)
+	})
+
+	It("GatherSnapshots should return the latest snapshots", func() {
+		gatherer := NewArchiveGatherer(nil)
+		gatherer.getter = mirror.Getter(upstream)
+		snapshots, err := gatherer.GatherSnapshots(2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(HaveLen(2))
+		Expect(snapshots[0][0].Metadata().Describe()).To(Equal("opensuse-tumbleweed:20240629"))
+		Expect(snapshots[1][0].Metadata().Describe()).To(Equal("opensuse-tumbleweed:20240628"))
+		Expect(snapshots[1][0].Metadata().Arch).To(Equal("x86_64"))
+
+		snapshots, err = gatherer.GatherSnapshots(5)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(HaveLen(3))
+	})
+
+	It("Inspect should take the image of a snapshot from the history", func() {
+		artifact := NewSnapshot("20240628", nil)
+		artifact.getter = mirror.Getter(upstream)
+		details, err := artifact.Inspect()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(details.Checksum).To(Equal("3f4a2cf16bd4d1f6b1e3c63fa0f6bde81e7d6ef05e3c5f1ba7a0c3a4f0a9e2d1"))
+		Expect(details.DownloadURL).To(Equal(upstream +
+			"history/20240628/tumbleweed/appliances/openSUSE-Tumbleweed-Minimal-VM.x86_64-1.0.0-Cloud-Snapshot20240628.qcow2"))
+	})
+
+	It("Inspect should fail if the snapshot is not in the history", func() {
+		artifact := NewSnapshot("20240627", nil)
+		artifact.getter = mirror.Getter(upstream)
+		_, err := artifact.Inspect()
+		Expect(err).To(HaveOccurred())
+	})
+
+	DescribeTable("GatherArchived should validate the snapshot",
+		func(version, expectedErr string) {
+			artifacts, err := NewArchiveGatherer(nil).GatherArchived(version)
+			if expectedErr != "" {
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(artifacts).To(HaveLen(1))
+			Expect(artifacts[0].Metadata().Version).To(Equal(version))
+		},
+		Entry("snapshot", "20240629", ""),
+		Entry("version instead of snapshot", "1.0.0", "invalid tumbleweed snapshot"),
+	)
+})
+
+var _ = testutil.DescribeArtifactContract("tumbleweed:1 x86_64", func() api.Artifact {
+	c := New("x86_64", nil)
+	c.getter = testutil.NewMockGetter("testdata/tumbleweed.SHA256SUM")
+	return c
+})
+
 func TestTumbleweed(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "openSUSE Tumbleweed Suite")
@@ -0,0 +1,146 @@
+package openwrt
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"go.podman.io/image/v5/pkg/compression/types"
+	v1 "kubevirt.io/api/core/v1"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/architecture"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/hashsum"
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+const (
+	license     = "GPL-2.0-only"
+	releasesURL = "https://downloads.openwrt.org/releases/"
+	// arch is the architecture of the x86/64 target, the only one published.
+	arch = "x86_64"
+
+	// maxSeries is the number of release series published, OpenWrt supports the latest two.
+	maxSeries = 2
+)
+
+// releaseRex matches the directories of the stable releases, e.g. 24.10.4, and captures their series.
+var releaseRex = regexp.MustCompile(`^(\d+\.\d+)\.\d+$`)
+
+// checksumLocator finds the sha256sums file listing all images of a target.
+var checksumLocator = hashsum.Locator{Location: hashsum.LocationDirectory, Name: "sha256sums", Format: hashsum.ChecksumFormatGNU}
+
+const description = `[OpenWrt](https://openwrt.org/) x86_64 images for KubeVirt, with an ext4 root filesystem and
+EFI boot.
+<br />
+<br />
+The first interface is the LAN with the static address 192.168.1.1, log in on the console as root without
+password. A second interface is configured as WAN with DHCP.`
+
+type openWrt struct {
+	// Version is the release series, e.g. 24.10.
+	Version string
+	// Release is the point release of the series, e.g. 24.10.4.
+	Release string
+	getter  http.Getter
+}
+
+type openWrtGatherer struct {
+	getter http.Getter
+}
+
+func (o *openWrt) Metadata() *api.Metadata {
+	return &api.Metadata{
+		Name:         "openwrt",
+		Version:      o.Version,
+		Description:  description,
+		Arch:         arch,
+		IsStable:     true,
+		License:      license,
+		ReleaseNotes: fmt.Sprintf("https://openwrt.org/releases/%s/notes-%s", o.Version, o.Release),
+		Verification: api.Verification{NoCloudInit: true},
+	}
+}
+
+func (o *openWrt) Inspect() (*api.ArtifactDetails, error) {
+	imageURL := fmt.Sprintf("%s%s/targets/x86/64/openwrt-%s-x86-64-generic-ext4-combined-efi.img.gz",
+		releasesURL, o.Release, o.Release)
+	checksum, err := checksumLocator.Lookup(o.getter, imageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.ArtifactDetails{
+		Checksum:             checksum,
+		ChecksumHash:         sha256.New,
+		DownloadURL:          imageURL,
+		Compression:          types.GzipAlgorithmName,
+		ImageArchitecture:    architecture.GetImageArchitecture(arch),
+		AdditionalUniqueTags: []string{o.Release},
+	}, nil
+}
+
+func (o *openWrt) VM(name, imgRef, _ string) *v1.VirtualMachine {
+	return docs.NewVM(name, imgRef, docs.WithRng(), docs.WithEFI())
+}
+
+func (o *openWrt) UserData(_ *docs.UserData) string {
+	return ""
+}
+
+func (o *openWrt) Tests() []api.ArtifactTest {
+	return []api.ArtifactTest{}
+}
+
+// Gather returns the latest point release of the supported release series, the latest series first.
+func (g *openWrtGatherer) Gather() ([][]api.Artifact, error) {
+	entries, err := http.List(context.Background(), g.getter, releasesURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []http.ListingEntry
+	for _, entry := range entries {
+		if entry.Dir && releaseRex.MatchString(entry.Name) {
+			releases = append(releases, entry)
+		}
+	}
+	if len(releases) == 0 {
+		return nil, errors.New("no OpenWrt releases found")
+	}
+	http.SortListingByVersion(releases)
+
+	var artifacts [][]api.Artifact
+	series := map[string]bool{}
+	for _, release := range releases {
+		version := releaseRex.FindStringSubmatch(release.Name)[1]
+		if series[version] {
+			continue
+		}
+		series[version] = true
+		artifacts = append(artifacts, []api.Artifact{
+			&openWrt{Version: version, Release: release.Name, getter: g.getter},
+		})
+		if len(artifacts) == maxSeries {
+			break
+		}
+	}
+	return artifacts, nil
+}
+
+func New(version, release string) *openWrt {
+	return &openWrt{
+		Version: version,
+		Release: release,
+		getter:  &http.HTTPGetter{},
+	}
+}
+
+func NewGatherer() *openWrtGatherer {
+	return &openWrtGatherer{
+		getter: &http.HTTPGetter{},
+	}
+}
@@ -0,0 +1,60 @@
+package openwrt
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+const upstream = "https://downloads.openwrt.org/"
+
+var _ = Describe("OpenWrt", func() {
+	var mirror *testutil.MirrorServer
+
+	BeforeEach(func() {
+		mirror = testutil.NewMirrorServer("testdata/mirror")
+		DeferCleanup(mirror.Close)
+	})
+
+	It("Gather should return the latest point release of the two latest series", func() {
+		gatherer := &openWrtGatherer{getter: mirror.Getter(upstream)}
+		artifacts, err := gatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(artifacts).To(HaveLen(2))
+		Expect(artifacts[0][0].(*openWrt).Release).To(Equal("24.10.4"))
+		Expect(artifacts[0][0].Metadata().Describe()).To(Equal("openwrt:24.10"))
+		Expect(artifacts[1][0].(*openWrt).Release).To(Equal("23.05.5"))
+	})
+
+	It("Inspect should verify the combined EFI image against sha256sums", func() {
+		artifact := &openWrt{Version: "24.10", Release: "24.10.4", getter: mirror.Getter(upstream)}
+		details, err := artifact.Inspect()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(details.Checksum).To(Equal("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"))
+		Expect(details.DownloadURL).To(Equal(
+			upstream + "releases/24.10.4/targets/x86/64/openwrt-24.10.4-x86-64-generic-ext4-combined-efi.img.gz"))
+		Expect(details.Compression).To(Equal("gzip"))
+		Expect(details.AdditionalUniqueTags).To(Equal([]string{"24.10.4"}))
+	})
+
+	It("Inspect should fail if the release has no sha256sums", func() {
+		artifact := &openWrt{Version: "23.05", Release: "23.05.5", getter: mirror.Getter(upstream)}
+		_, err := artifact.Inspect()
+		Expect(err).To(MatchError(ContainSubstring("sha256sums")))
+	})
+})
+
+var _ = testutil.DescribeArtifactContract("openwrt:24.10", func() api.Artifact {
+	artifact := New("24.10", "24.10.4")
+	artifact.getter = testutil.NewMockGetter("testdata/mirror/releases/24.10.4/targets/x86/64/sha256sums")
+	return artifact
+})
+
+func TestOpenWrt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "OpenWrt Suite")
+}
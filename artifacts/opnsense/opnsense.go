@@ -0,0 +1,141 @@
+package opnsense
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"go.podman.io/image/v5/pkg/compression/types"
+	v1 "kubevirt.io/api/core/v1"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/architecture"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/hashsum"
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+const (
+	license     = "BSD-2-Clause"
+	releasesURL = "https://pkg.opnsense.org/releases/"
+	// OPNsense only publishes images for amd64.
+	arch = "x86_64"
+
+	// imageNano is the preinstalled image with serial console, which boots directly into the firewall.
+	imageNano = "nano"
+	// imageSerial is the live and installer image with serial console.
+	imageSerial = "serial"
+)
+
+// releaseRex matches the directories of the major releases, e.g. 25.7. Images are only published for them,
+// minor releases are installed as updates.
+var releaseRex = regexp.MustCompile(`^\d+\.\d+$`)
+
+const description = `[OPNsense](https://opnsense.org/) firewall and routing platform for KubeVirt.
+<br />
+<br />
+The console is on the serial port, log in as root with the password opnsense and change it right away. The first
+interface is the LAN, the second one the WAN interface, so VMs need a second network to route traffic.`
+
+type opnsense struct {
+	Version string
+	// Image is imageNano or imageSerial.
+	Image  string
+	getter http.Getter
+}
+
+type opnsenseGatherer struct {
+	getter http.Getter
+}
+
+func (o *opnsense) Metadata() *api.Metadata {
+	metadata := &api.Metadata{
+		Name:         "opnsense",
+		Version:      o.Version,
+		Description:  description,
+		Arch:         arch,
+		IsStable:     true,
+		License:      license,
+		Verification: api.Verification{NoCloudInit: true},
+	}
+	if o.Image == imageSerial {
+		metadata.Variant = api.VariantLive
+	}
+	return metadata
+}
+
+func (o *opnsense) Inspect() (*api.ArtifactDetails, error) {
+	baseURL := releasesURL + o.Version + "/"
+	imageName := fmt.Sprintf("OPNsense-%s-%s-amd64.img.bz2", o.Version, o.Image)
+	// All images of a release are listed in one checksum file in the BSD format of sha256(1)
+	checksumLocator := hashsum.Locator{
+		Location: hashsum.LocationDirectory,
+		Name:     fmt.Sprintf("OPNsense-%s-checksums-amd64.sha256", o.Version),
+		Format:   hashsum.ChecksumFormatBSD,
+	}
+	checksum, err := checksumLocator.Lookup(o.getter, baseURL+imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.ArtifactDetails{
+		Checksum:          checksum,
+		ChecksumHash:      sha256.New,
+		DownloadURL:       baseURL + imageName,
+		Compression:       types.Bzip2AlgorithmName,
+		ImageArchitecture: architecture.GetImageArchitecture(arch),
+	}, nil
+}
+
+func (o *opnsense) VM(name, imgRef, _ string) *v1.VirtualMachine {
+	return docs.NewVM(name, imgRef, docs.WithRng())
+}
+
+func (o *opnsense) UserData(_ *docs.UserData) string {
+	return ""
+}
+
+func (o *opnsense) Tests() []api.ArtifactTest {
+	return []api.ArtifactTest{}
+}
+
+// Gather returns the nano and serial images of the latest release, as separate entries of their variants.
+func (g *opnsenseGatherer) Gather() ([][]api.Artifact, error) {
+	entries, err := http.List(context.Background(), g.getter, releasesURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []http.ListingEntry
+	for _, entry := range entries {
+		if entry.Dir && releaseRex.MatchString(entry.Name) {
+			releases = append(releases, entry)
+		}
+	}
+	if len(releases) == 0 {
+		return nil, errors.New("no OPNsense releases found")
+	}
+	http.SortListingByVersion(releases)
+
+	version := releases[0].Name
+	return [][]api.Artifact{
+		{&opnsense{Version: version, Image: imageNano, getter: g.getter}},
+		{&opnsense{Version: version, Image: imageSerial, getter: g.getter}},
+	}, nil
+}
+
+func New(version, image string) *opnsense {
+	return &opnsense{
+		Version: version,
+		Image:   image,
+		getter:  &http.HTTPGetter{},
+	}
+}
+
+func NewGatherer() *opnsenseGatherer {
+	return &opnsenseGatherer{
+		getter: &http.HTTPGetter{},
+	}
+}
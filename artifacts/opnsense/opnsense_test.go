@@ -0,0 +1,62 @@
+package opnsense
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+const upstream = "https://pkg.opnsense.org/"
+
+var _ = Describe("OPNsense", func() {
+	var mirror *testutil.MirrorServer
+
+	BeforeEach(func() {
+		mirror = testutil.NewMirrorServer("testdata/mirror")
+		DeferCleanup(mirror.Close)
+	})
+
+	It("Gather should return the nano and serial images of the latest release", func() {
+		gatherer := &opnsenseGatherer{getter: mirror.Getter(upstream)}
+		artifacts, err := gatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(artifacts).To(HaveLen(2))
+		Expect(artifacts[0][0].Metadata().Describe()).To(Equal("opnsense:25.7"))
+		Expect(artifacts[1][0].Metadata().Describe()).To(Equal("opnsense:25.7-live"))
+	})
+
+	DescribeTable("Inspect should verify the image against the checksum file of the release",
+		func(image, checksum string) {
+			artifact := &opnsense{Version: "25.7", Image: image, getter: mirror.Getter(upstream)}
+			details, err := artifact.Inspect()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(details.Checksum).To(Equal(checksum))
+			Expect(details.DownloadURL).To(Equal(upstream + "releases/25.7/OPNsense-25.7-" + image + "-amd64.img.bz2"))
+			Expect(details.Compression).To(Equal("bzip2"))
+		},
+		Entry("nano", imageNano, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"),
+		Entry("serial", imageSerial, "fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"),
+	)
+
+	It("Inspect should fail if the release has no checksum file", func() {
+		artifact := &opnsense{Version: "25.1", Image: imageNano, getter: mirror.Getter(upstream)}
+		_, err := artifact.Inspect()
+		Expect(err).To(MatchError(ContainSubstring("OPNsense-25.1-checksums-amd64.sha256")))
+	})
+})
+
+var _ = testutil.DescribeArtifactContract("opnsense:25.7", func() api.Artifact {
+	artifact := New("25.7", imageNano)
+	artifact.getter = testutil.NewMockGetterFromBytes(
+		[]byte("SHA256 (OPNsense-25.7-nano-amd64.img.bz2) = e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855\n"))
+	return artifact
+})
+
+func TestOPNsense(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "OPNsense Suite")
+}
@@ -0,0 +1,151 @@
+package truenas
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"regexp"
+
+	v1 "kubevirt.io/api/core/v1"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/architecture"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/hashsum"
+	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/version"
+)
+
+const (
+	license     = "LicenseRef-TrueNAS"
+	downloadURL = "https://download.truenas.com/"
+	// TrueNAS is only available for amd64.
+	arch = "x86_64"
+
+	// minimumMemory is the memory TrueNAS requires, the installer refuses to install with less.
+	minimumMemory = "8Gi"
+	// installDiskCapacity is the size of the boot disk the example VM installs TrueNAS to.
+	installDiskCapacity = "16Gi"
+)
+
+var (
+	// trainRex matches the directories of the release trains, e.g. TrueNAS-SCALE-Fangtooth.
+	trainRex = regexp.MustCompile(`^TrueNAS-SCALE-([A-Za-z]+)$`)
+	// releaseRex matches the directories of the stable releases of a train, e.g. 25.04.2.4, skipping betas and RCs.
+	releaseRex = regexp.MustCompile(`^\d+\.\d+(\.\d+)*$`)
+)
+
+// checksumLocator finds the checksum file published next to the ISO.
+var checksumLocator = hashsum.Locator{Location: hashsum.LocationImage, Name: ".sha256", Format: hashsum.ChecksumFormatGNU}
+
+const description = `[TrueNAS](https://www.truenas.com/truenas-community-edition/) Community Edition installer ISO
+for KubeVirt.
+<br />
+<br />
+The ISO is attached as CD-ROM, the installer writes TrueNAS to a separate boot disk. Further disks are used for
+the storage pools.`
+
+type trueNAS struct {
+	Version string
+	// Train is the release train of Version, e.g. Fangtooth.
+	Train  string
+	getter http.Getter
+}
+
+type trueNASGatherer struct {
+	getter http.Getter
+}
+
+func (t *trueNAS) Metadata() *api.Metadata {
+	return &api.Metadata{
+		Name:         "truenas",
+		Version:      t.Version,
+		Description:  description,
+		Arch:         arch,
+		IsStable:     true,
+		License:      license,
+		Verification: api.Verification{NoCloudInit: true},
+	}
+}
+
+func (t *trueNAS) Inspect() (*api.ArtifactDetails, error) {
+	isoURL := fmt.Sprintf("%sTrueNAS-SCALE-%s/%s/TrueNAS-SCALE-%s.iso", downloadURL, t.Train, t.Version, t.Version)
+	checksum, err := checksumLocator.Lookup(t.getter, isoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.ArtifactDetails{
+		Checksum:          checksum,
+		ChecksumHash:      sha256.New,
+		DownloadURL:       isoURL,
+		ImageArchitecture: architecture.GetImageArchitecture(arch),
+	}, nil
+}
+
+func (t *trueNAS) VM(name, imgRef, _ string) *v1.VirtualMachine {
+	return docs.NewVM(
+		name,
+		imgRef,
+		docs.WithRng(),
+		docs.WithMemory(minimumMemory),
+		docs.WithCDRom(),
+		docs.WithEmptyDisk("boot", installDiskCapacity),
+	)
+}
+
+func (t *trueNAS) UserData(_ *docs.UserData) string {
+	return ""
+}
+
+func (t *trueNAS) Tests() []api.ArtifactTest {
+	return []api.ArtifactTest{}
+}
+
+// Gather returns the latest stable release of all release trains.
+func (g *trueNASGatherer) Gather() ([][]api.Artifact, error) {
+	trains, err := http.List(context.Background(), g.getter, downloadURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *trueNAS
+	for _, train := range trains {
+		matches := trainRex.FindStringSubmatch(train.Name)
+		if !train.Dir || matches == nil {
+			continue
+		}
+		releases, listErr := http.List(context.Background(), g.getter, downloadURL+train.Name+"/")
+		if listErr != nil {
+			return nil, listErr
+		}
+		for _, release := range releases {
+			if !release.Dir || !releaseRex.MatchString(release.Name) {
+				continue
+			}
+			if latest == nil || version.Compare(release.Name, latest.Version) > 0 {
+				latest = &trueNAS{Version: release.Name, Train: matches[1], getter: g.getter}
+			}
+		}
+	}
+	if latest == nil {
+		return nil, errors.New("no TrueNAS releases found")
+	}
+
+	return [][]api.Artifact{{latest}}, nil
+}
+
+func New(version, train string) *trueNAS {
+	return &trueNAS{
+		Version: version,
+		Train:   train,
+		getter:  &http.HTTPGetter{},
+	}
+}
+
+func NewGatherer() *trueNASGatherer {
+	return &trueNASGatherer{
+		getter: &http.HTTPGetter{},
+	}
+}
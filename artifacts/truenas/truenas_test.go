@@ -0,0 +1,66 @@
+package truenas
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+var _ = Describe("TrueNAS", func() {
+	var mirror *testutil.MirrorServer
+
+	BeforeEach(func() {
+		mirror = testutil.NewMirrorServer("testdata/mirror")
+		DeferCleanup(mirror.Close)
+	})
+
+	It("Gather should return the latest stable release of all trains", func() {
+		gatherer := &trueNASGatherer{getter: mirror.Getter(downloadURL)}
+		artifacts, err := gatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(artifacts).To(HaveLen(1))
+		Expect(artifacts[0]).To(HaveLen(1))
+		Expect(artifacts[0][0].Metadata().Describe()).To(Equal("truenas:25.04.2.4"))
+		Expect(artifacts[0][0].(*trueNAS).Train).To(Equal("Fangtooth"))
+	})
+
+	It("Inspect should verify the ISO against its checksum file", func() {
+		artifact := &trueNAS{Version: "25.04.2.4", Train: "Fangtooth", getter: mirror.Getter(downloadURL)}
+		details, err := artifact.Inspect()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(details.Checksum).To(Equal("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"))
+		Expect(details.DownloadURL).To(Equal(downloadURL + "TrueNAS-SCALE-Fangtooth/25.04.2.4/TrueNAS-SCALE-25.04.2.4.iso"))
+	})
+
+	It("Inspect should fail if the release has no checksum file", func() {
+		artifact := &trueNAS{Version: "25.04.1", Train: "Fangtooth", getter: mirror.Getter(downloadURL)}
+		_, err := artifact.Inspect()
+		Expect(err).To(MatchError(ContainSubstring("TrueNAS-SCALE-25.04.1.iso.sha256")))
+	})
+
+	It("VM should boot the installer from CD-ROM after the empty boot disk", func() {
+		vm := New("25.04.2.4", "Fangtooth").VM("truenas", "registry/truenas:25.04.2.4", "")
+		disks := vm.Spec.Template.Spec.Domain.Devices.Disks
+		Expect(disks).To(HaveLen(2))
+		Expect(disks[0].CDRom).ToNot(BeNil())
+		Expect(*disks[0].BootOrder).To(Equal(uint(2)))
+		Expect(*disks[1].BootOrder).To(Equal(uint(1)))
+		Expect(vm.Spec.Template.Spec.Volumes[1].EmptyDisk).ToNot(BeNil())
+	})
+})
+
+var _ = testutil.DescribeArtifactContract("truenas:25.04.2.4", func() api.Artifact {
+	artifact := New("25.04.2.4", "Fangtooth")
+	artifact.getter = testutil.NewMockGetterFromBytes(
+		[]byte("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  TrueNAS-SCALE-25.04.2.4.iso\n"))
+	return artifact
+})
+
+func TestTrueNAS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TrueNAS Suite")
+}
@@ -1,7 +1,6 @@
 package ubuntu
 
 import (
-	"bytes"
 	"crypto/sha256"
 	"fmt"
 
@@ -15,6 +14,8 @@ import (
 	"kubevirt.io/containerdisks/pkg/tests"
 )
 
+const license = "LicenseRef-Ubuntu"
+
 type ubuntu struct {
 	Version      string
 	Variant      string
@@ -24,6 +25,9 @@ type ubuntu struct {
 	EnvVariables map[string]string
 }
 
+// checksumLocator finds the SHA256SUMS file in the directory of every release.
+var checksumLocator = hashsum.Locator{Location: hashsum.LocationDirectory, Name: "SHA256SUMS", Format: hashsum.ChecksumFormatGNU}
+
 const description = `Ubuntu images for KubeVirt.
 <br />
 <br />
@@ -39,30 +43,23 @@ func (u *ubuntu) Metadata() *api.Metadata {
 		},
 		EnvVariables: u.EnvVariables,
 		Arch:         u.Arch,
+		License:      license,
 	}
 }
 
 func (u *ubuntu) Inspect() (*api.ArtifactDetails, error) {
 	baseURL := fmt.Sprintf("https://cloud-images.ubuntu.com/releases/%v/release/", u.Version)
-	checksumURL := baseURL + "SHA256SUMS"
-	raw, err := u.getter.GetAll(checksumURL)
+	checksum, err := checksumLocator.Lookup(u.getter, baseURL+u.Variant)
 	if err != nil {
-		return nil, fmt.Errorf("error downloading the ubuntu SHA256SUMS file: %v", err)
-	}
-	checksums, err := hashsum.Parse(bytes.NewReader(raw), hashsum.ChecksumFormatGNU)
-	if err != nil {
-		return nil, fmt.Errorf("error reading the SHA256SUMS.txt file: %v", err)
-	}
-	if checksum, exists := checksums[u.Variant]; exists {
-		return &api.ArtifactDetails{
-			Checksum:          checksum,
-			ChecksumHash:      sha256.New,
-			DownloadURL:       baseURL + u.Variant,
-			Compression:       u.Compression,
-			ImageArchitecture: architecture.GetImageArchitecture(u.Arch),
-		}, nil
+		return nil, err
 	}
-	return nil, fmt.Errorf("file %q does not exist in the SHA256SUMS file: %v", u.Variant, err)
+	return &api.ArtifactDetails{
+		Checksum:          checksum,
+		ChecksumHash:      sha256.New,
+		DownloadURL:       baseURL + u.Variant,
+		Compression:       u.Compression,
+		ImageArchitecture: architecture.GetImageArchitecture(u.Arch),
+	}, nil
 }
 
 func (u *ubuntu) VM(name, imgRef, userData string) *v1.VirtualMachine {
@@ -49,7 +49,8 @@ var _ = Describe("Ubuntu", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "ubuntu",
 				},
-				Arch: "x86_64",
+				Arch:    "x86_64",
+				License: license,
 			},
 		),
 		Entry("ubuntu:22.04 aarch64", "22.04", "aarch64", "testdata/SHA256SUM",
@@ -73,7 +74,8 @@ var _ = Describe("Ubuntu", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "ubuntu",
 				},
-				Arch: "aarch64",
+				Arch:    "aarch64",
+				License: license,
 			},
 		),
 		Entry("ubuntu:22.04 s390x", "22.04", "s390x", "testdata/SHA256SUM",
@@ -97,12 +99,19 @@ var _ = Describe("Ubuntu", func() {
 					common.DefaultInstancetypeEnv: "u1.medium",
 					common.DefaultPreferenceEnv:   "ubuntu",
 				},
-				Arch: "s390x",
+				Arch:    "s390x",
+				License: license,
 			},
 		),
 	)
 })
 
+var _ = testutil.DescribeArtifactContract("ubuntu:22.04 x86_64", func() api.Artifact {
+	c := New("22.04", "x86_64", nil)
+	c.getter = testutil.NewMockGetter("testdata/SHA256SUM")
+	return c
+})
+
 func TestUbuntu(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Ubuntu Suite")
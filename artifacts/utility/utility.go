@@ -0,0 +1,95 @@
+package utility
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "kubevirt.io/api/core/v1"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/architecture"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/utilitydisk"
+)
+
+// Namespace prefixes the names of all utility containerdisks, e.g. utility/swap.
+const Namespace = "utility/"
+
+var descriptions = map[utilitydisk.Filesystem]string{
+	utilitydisk.FilesystemNone: "Blank unformatted disk",
+	utilitydisk.FilesystemSwap: "Linux swap area labeled `swap`",
+	utilitydisk.FilesystemVFAT: "Empty FAT32 filesystem labeled `SCRATCH`",
+}
+
+type utility struct {
+	spec     utilitydisk.Spec
+	metadata *api.Metadata
+
+	checksumOnce sync.Once
+	checksum     string
+	checksumErr  error
+}
+
+func (u *utility) Metadata() *api.Metadata {
+	return u.metadata
+}
+
+// Inspect generates the disk once to compute its checksum, the disks are sparse and only a few clusters large.
+func (u *utility) Inspect() (*api.ArtifactDetails, error) {
+	u.checksumOnce.Do(func() {
+		hash := sha256.New()
+		u.checksumErr = utilitydisk.Generate(hash, u.spec)
+		u.checksum = hex.EncodeToString(hash.Sum(nil))
+	})
+	if u.checksumErr != nil {
+		return nil, u.checksumErr
+	}
+
+	return &api.ArtifactDetails{
+		Checksum:          u.checksum,
+		ChecksumHash:      sha256.New,
+		ImageArchitecture: architecture.GetImageArchitecture(u.metadata.Arch),
+		Generate: func(w io.Writer) error {
+			return utilitydisk.Generate(w, u.spec)
+		},
+	}, nil
+}
+
+func (u *utility) VM(name, imgRef, _ string) *v1.VirtualMachine {
+	return docs.NewVM(name, imgRef)
+}
+
+func (u *utility) UserData(_ *docs.UserData) string {
+	return ""
+}
+
+func (u *utility) Tests() []api.ArtifactTest {
+	return []api.ArtifactTest{}
+}
+
+// New returns a utility containerdisk with a disk of the given size, e.g. 10Gi, and filesystem. It is published
+// as utility/blank for unformatted disks or utility/<filesystem>, tagged with the size.
+func New(size string, filesystem utilitydisk.Filesystem, arch string) *utility {
+	name := string(filesystem)
+	if filesystem == utilitydisk.FilesystemNone {
+		name = "blank"
+	}
+	quantity := resource.MustParse(size)
+
+	return &utility{
+		spec: utilitydisk.Spec{Size: quantity.Value(), Filesystem: filesystem},
+		metadata: &api.Metadata{
+			Name:        Namespace + name,
+			Version:     size,
+			Description: fmt.Sprintf("%s of %s, generated by medius for scratch volumes.", descriptions[filesystem], size),
+			Arch:        arch,
+			License:     "CC0-1.0",
+			// The disks contain no OS to boot
+			Verification: api.Verification{SkipBoot: true},
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package utility
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/utilitydisk"
+)
+
+var _ = Describe("Utility", func() {
+	It("should name unformatted disks blank and tag them with their size", func() {
+		metadata := New("10Gi", utilitydisk.FilesystemNone, "x86_64").Metadata()
+		Expect(metadata.Describe()).To(Equal("utility/blank:10Gi"))
+		Expect(New("2Gi", utilitydisk.FilesystemSwap, "aarch64").Metadata().Name).To(Equal("utility/swap"))
+	})
+
+	It("should generate the disk matching the checksum", func() {
+		details, err := New("1Gi", utilitydisk.FilesystemVFAT, "aarch64").Inspect()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(details.ImageArchitecture).To(Equal("arm64"))
+
+		var disk bytes.Buffer
+		Expect(details.Generate(&disk)).To(Succeed())
+		checksum := sha256.Sum256(disk.Bytes())
+		Expect(details.Checksum).To(Equal(hex.EncodeToString(checksum[:])))
+	})
+})
+
+func TestUtility(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Utility Suite")
+}
@@ -0,0 +1,127 @@
+package virtiowin
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"regexp"
+
+	v1 "kubevirt.io/api/core/v1"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/architecture"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/hashsum"
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+const (
+	// license refers to virtio-win_license.txt published with every release.
+	license    = "LicenseRef-virtio-win"
+	archiveURL = "https://fedorapeople.org/groups/virt/virtio-win/direct-downloads/archive-virtio/"
+	// arch is the architecture of the containerdisk, the ISO contains the drivers of all Windows architectures.
+	arch = "x86_64"
+)
+
+// releaseRex matches the directories of the releases in the archive, e.g. virtio-win-0.1.271-1.
+var releaseRex = regexp.MustCompile(`^virtio-win-(\d+\.\d+\.\d+)-(\d+)$`)
+
+// checksumLocator finds the checksum file published next to the ISO.
+var checksumLocator = hashsum.Locator{Location: hashsum.LocationImage, Name: ".sha256", Format: hashsum.ChecksumFormatGNU}
+
+const description = `The [virtio-win](https://github.com/virtio-win/virtio-win-pkg-scripts) ISO with the VirtIO drivers and
+the guest agent for Windows guests. It is not bootable, attach it as CD-ROM to install Windows on VirtIO disks or to
+update the drivers of existing VMs.`
+
+type virtioWin struct {
+	Version string
+	// Release is the build of Version, e.g. 1 for virtio-win-0.1.271-1.
+	Release string
+	getter  http.Getter
+}
+
+type virtioWinGatherer struct {
+	getter http.Getter
+}
+
+func (v *virtioWin) Metadata() *api.Metadata {
+	return &api.Metadata{
+		Name:        "virtio-win",
+		Version:     v.Version,
+		Description: description,
+		Arch:        arch,
+		IsStable:    true,
+		License:     license,
+		// The ISO is not bootable
+		Verification: api.Verification{SkipBoot: true},
+	}
+}
+
+func (v *virtioWin) Inspect() (*api.ArtifactDetails, error) {
+	release := v.Version + "-" + v.Release
+	isoURL := fmt.Sprintf("%svirtio-win-%s/virtio-win-%s.iso", archiveURL, release, v.Version)
+	checksum, err := checksumLocator.Lookup(v.getter, isoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.ArtifactDetails{
+		Checksum:             checksum,
+		ChecksumHash:         sha256.New,
+		DownloadURL:          isoURL,
+		ImageArchitecture:    architecture.GetImageArchitecture(arch),
+		AdditionalUniqueTags: []string{release},
+	}, nil
+}
+
+// VM attaches the ISO as CD-ROM, Windows installers don't look for drivers on other disks.
+func (v *virtioWin) VM(name, imgRef, _ string) *v1.VirtualMachine {
+	return docs.NewVM(name, imgRef, docs.WithCDRom())
+}
+
+func (v *virtioWin) UserData(_ *docs.UserData) string {
+	return ""
+}
+
+func (v *virtioWin) Tests() []api.ArtifactTest {
+	return []api.ArtifactTest{}
+}
+
+// Gather returns the latest release of the archive.
+func (g *virtioWinGatherer) Gather() ([][]api.Artifact, error) {
+	entries, err := http.List(context.Background(), g.getter, archiveURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []http.ListingEntry
+	for _, entry := range entries {
+		if entry.Dir && releaseRex.MatchString(entry.Name) {
+			releases = append(releases, entry)
+		}
+	}
+	if len(releases) == 0 {
+		return nil, errors.New("no virtio-win releases found in the archive")
+	}
+	http.SortListingByVersion(releases)
+
+	matches := releaseRex.FindStringSubmatch(releases[0].Name)
+	return [][]api.Artifact{{
+		&virtioWin{Version: matches[1], Release: matches[2], getter: g.getter},
+	}}, nil
+}
+
+func New(version, release string) *virtioWin {
+	return &virtioWin{
+		Version: version,
+		Release: release,
+		getter:  &http.HTTPGetter{},
+	}
+}
+
+func NewGatherer() *virtioWinGatherer {
+	return &virtioWinGatherer{
+		getter: &http.HTTPGetter{},
+	}
+}
@@ -0,0 +1,63 @@
+package virtiowin
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+const upstream = "https://fedorapeople.org/groups/virt/virtio-win/direct-downloads/"
+
+var _ = Describe("virtio-win", func() {
+	var mirror *testutil.MirrorServer
+
+	BeforeEach(func() {
+		mirror = testutil.NewMirrorServer("testdata/mirror")
+		DeferCleanup(mirror.Close)
+	})
+
+	It("Gather should return the latest release of the archive", func() {
+		gatherer := &virtioWinGatherer{getter: mirror.Getter(upstream)}
+		artifacts, err := gatherer.Gather()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(artifacts).To(HaveLen(1))
+		Expect(artifacts[0]).To(HaveLen(1))
+		Expect(artifacts[0][0].Metadata().Describe()).To(Equal("virtio-win:0.1.271"))
+	})
+
+	It("Inspect should verify the ISO against its checksum file", func() {
+		artifact := &virtioWin{Version: "0.1.271", Release: "1", getter: mirror.Getter(upstream)}
+		details, err := artifact.Inspect()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(details.Checksum).To(Equal("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"))
+		Expect(details.DownloadURL).To(Equal(upstream + "archive-virtio/virtio-win-0.1.271-1/virtio-win-0.1.271.iso"))
+		Expect(details.AdditionalUniqueTags).To(Equal([]string{"0.1.271-1"}))
+	})
+
+	It("Inspect should fail if the release has no checksum file", func() {
+		artifact := &virtioWin{Version: "0.1.262", Release: "2", getter: mirror.Getter(upstream)}
+		_, err := artifact.Inspect()
+		Expect(err).To(MatchError(ContainSubstring("virtio-win-0.1.262.iso.sha256")))
+	})
+
+	It("VM should attach the ISO as CD-ROM", func() {
+		vm := New("0.1.271", "1").VM("virtio-win", "registry/virtio-win:0.1.271", "")
+		Expect(vm.Spec.Template.Spec.Domain.Devices.Disks[0].CDRom).ToNot(BeNil())
+	})
+})
+
+var _ = testutil.DescribeArtifactContract("virtio-win:0.1.271", func() api.Artifact {
+	artifact := New("0.1.271", "1")
+	artifact.getter = testutil.NewMockGetterFromBytes(
+		[]byte("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  virtio-win-0.1.271.iso\n"))
+	return artifact
+})
+
+func TestVirtioWin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "virtio-win Suite")
+}
@@ -0,0 +1,161 @@
+package airgap
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/airgap"
+)
+
+type exportOptions struct {
+	Registry   string
+	Images     []string
+	OutputFile string
+}
+
+type importOptions struct {
+	InputFile      string
+	TargetRegistry string
+}
+
+func NewExportCommand(options *common.Options) *cobra.Command {
+	exportOptions := &exportOptions{
+		Registry:   "quay.io/containerdisks",
+		OutputFile: "containerdisks-bundle.tar",
+	}
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export containerdisks with their signatures into a tarball for disconnected environments",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExport(cmd, options, exportOptions)
+		},
+	}
+	exportCmd.Flags().StringVar(&exportOptions.Registry, "registry",
+		exportOptions.Registry, "Registry the containerdisks are published in")
+	exportCmd.Flags().StringSliceVar(&exportOptions.Images, "image",
+		exportOptions.Images, "Images to export instead of the containerdisks selected by --focus")
+	exportCmd.Flags().StringVar(&exportOptions.OutputFile, "output",
+		exportOptions.OutputFile, "File to write the bundle to")
+
+	return exportCmd
+}
+
+func NewImportCommand(options *common.Options) *cobra.Command {
+	importOptions := &importOptions{}
+
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Push the containerdisks of an exported bundle into a registry",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport(cmd, options, importOptions)
+		},
+	}
+	importCmd.Flags().StringVar(&importOptions.InputFile, "input",
+		importOptions.InputFile, "Bundle created by medius export")
+	importCmd.Flags().StringVar(&importOptions.TargetRegistry, "target-registry",
+		importOptions.TargetRegistry, "Registry to push the containerdisks to, e.g. registry.example.com/containerdisks")
+
+	for _, flag := range []string{"input", "target-registry"} {
+		if err := importCmd.MarkFlagRequired(flag); err != nil {
+			logrus.Fatal(err)
+		}
+	}
+
+	return importCmd
+}
+
+func runExport(cmd *cobra.Command, options *common.Options, exportOptions *exportOptions) error {
+	images := exportOptions.Images
+	if len(images) == 0 {
+		registry := common.NewRegistry()
+		for i := range registry {
+			if common.ShouldSkip(options.Focus, &registry[i]) {
+				continue
+			}
+			images = append(images, registry[i].Image(exportOptions.Registry))
+		}
+	}
+	if len(images) == 0 {
+		return fmt.Errorf("no containerdisk was selected, focus '%s' did not match", options.Focus)
+	}
+
+	dir, err := os.MkdirTemp("", "medius-export")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	bundle, err := airgap.NewBundle(dir, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		logrus.Infof("Exporting %s", image)
+		if err := bundle.Add(cmd.Context(), image, craneOptions(options)...); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(exportOptions.OutputFile)
+	if err != nil {
+		return fmt.Errorf("error creating bundle: %v", err)
+	}
+	defer file.Close()
+	if err := airgap.Pack(dir, file); err != nil {
+		return err
+	}
+	logrus.Infof("Exported %d containerdisks to %s", len(images), exportOptions.OutputFile)
+
+	return file.Close()
+}
+
+func runImport(cmd *cobra.Command, options *common.Options, importOptions *importOptions) error {
+	file, err := os.Open(importOptions.InputFile)
+	if err != nil {
+		return fmt.Errorf("error opening bundle: %v", err)
+	}
+	defer file.Close()
+
+	dir, err := os.MkdirTemp("", "medius-import")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := airgap.Unpack(file, dir); err != nil {
+		return err
+	}
+	bundle, err := airgap.OpenBundle(dir)
+	if err != nil {
+		return err
+	}
+
+	if options.DryRun {
+		for _, image := range bundle.Metadata.Images {
+			logrus.Infof("Dry run enabled, not pushing %s to %s", image.Reference, importOptions.TargetRegistry)
+		}
+		return nil
+	}
+
+	pushed, err := bundle.Push(importOptions.TargetRegistry, airgap.RemoteWriter{
+		Options: append(craneOptions(options), crane.WithContext(cmd.Context())),
+	})
+	for _, ref := range pushed {
+		logrus.Infof("Pushed %s", ref)
+	}
+	return err
+}
+
+func craneOptions(options *common.Options) []crane.Option {
+	if options.AllowInsecureRegistry {
+		return []crane.Option{crane.Insecure}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package annotate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/provenance"
+	"kubevirt.io/containerdisks/pkg/repository"
+)
+
+type annotateOptions struct {
+	Registry string
+	Backfill bool
+}
+
+func NewAnnotateCommand(options *common.Options) *cobra.Command {
+	annotateOptions := &annotateOptions{
+		Registry: "quay.io/containerdisks",
+	}
+
+	annotateCmd := &cobra.Command{
+		Use:   "annotate",
+		Short: "Attach the annotations of published containerdisks they are missing as OCI referrers",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), options, annotateOptions)
+		},
+	}
+	annotateCmd.Flags().StringVar(&annotateOptions.Registry, "registry",
+		annotateOptions.Registry, "Registry the containerdisks are published in")
+	annotateCmd.Flags().BoolVar(&annotateOptions.Backfill, "backfill",
+		annotateOptions.Backfill, "Annotate all builds of the versions in the repositories, not only the current ones")
+
+	return annotateCmd
+}
+
+func run(ctx context.Context, options *common.Options, annotateOptions *annotateOptions) error {
+	registry, err := common.NewRegistryWithErrors()
+	if err != nil {
+		return fmt.Errorf("error gathering artifacts: %v", err)
+	}
+
+	repo := repository.New()
+	var failed []string
+	for i := range registry {
+		entry := &registry[i]
+		if common.ShouldSkip(options.Focus, entry) {
+			continue
+		}
+		log := common.Logger(entry.Artifacts[0])
+		if err := annotate(ctx, repo, entry, options, annotateOptions, log); err != nil {
+			log.WithError(err).Error("Failed to annotate containerdisk")
+			failed = append(failed, entry.Artifacts[0].Metadata().Describe())
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to annotate %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// annotate attaches the annotations missing on the builds of an entry. Builds sharing a digest are annotated once.
+func annotate(
+	ctx context.Context, repo repository.Repository, entry *common.Entry, options *common.Options,
+	annotateOptions *annotateOptions, log *logrus.Entry,
+) error {
+	metadata := entry.Artifacts[0].Metadata()
+	repoName := entry.Repository(annotateOptions.Registry)
+	tags := []string{metadata.Tag()}
+	if annotateOptions.Backfill {
+		published, err := repo.ListTags(ctx, repoName, options.AllowInsecureRegistry)
+		if err != nil {
+			return fmt.Errorf("error listing tags of %q: %v", repoName, err)
+		}
+		tags = provenance.VersionTags(published, metadata)
+	}
+
+	annotations := provenance.Annotations(metadata)
+	annotated := map[string]bool{}
+	for _, tag := range tags {
+		imgRef := repoName + ":" + tag
+		subject, err := repo.Descriptor(ctx, imgRef, options.AllowInsecureRegistry)
+		if err != nil {
+			return fmt.Errorf("error resolving %s: %v", imgRef, err)
+		}
+		if subject == nil {
+			log.Infof("%s is not published, skipping it", imgRef)
+			continue
+		}
+		if annotated[subject.Digest.String()] {
+			continue
+		}
+		annotated[subject.Digest.String()] = true
+
+		imageInfo, err := repo.ImageMetadata(imgRef, metadata.Arch, options.AllowInsecureRegistry)
+		if err != nil {
+			return fmt.Errorf("error reading the labels of %s: %v", imgRef, err)
+		}
+		missing := provenance.Missing(annotations, imageInfo.Labels)
+		if len(missing) == 0 {
+			continue
+		}
+
+		artifact, err := provenance.Artifact(*subject, missing)
+		if err != nil {
+			return err
+		}
+		digest, err := artifact.Digest()
+		if err != nil {
+			return err
+		}
+		if options.DryRun {
+			log.Infof("Dry run enabled, not attaching %d annotations to %s", len(missing), imgRef)
+			continue
+		}
+		log.Infof("Attaching %d annotations to %s", len(missing), imgRef)
+		if err := repo.PushImage(ctx, artifact, repoName+"@"+digest.String()); err != nil {
+			return fmt.Errorf("error attaching annotations to %s: %v", imgRef, err)
+		}
+	}
+	return nil
+}
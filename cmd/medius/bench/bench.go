@@ -0,0 +1,104 @@
+package bench
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"runtime"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/bench"
+	"kubevirt.io/containerdisks/pkg/build"
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+type benchOptions struct {
+	Size  string
+	URL   string
+	Image string
+}
+
+func NewBenchCommand(options *common.Options) *cobra.Command {
+	benchOptions := &benchOptions{
+		Size: "1Gi",
+	}
+
+	benchCmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Measure the download, hash, compression and upload throughput of this machine",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd, options, benchOptions)
+		},
+	}
+	benchCmd.Flags().StringVar(&benchOptions.Size, "size",
+		benchOptions.Size, "Amount of generated data to hash, compress and upload, e.g. 512Mi")
+	benchCmd.Flags().StringVar(&benchOptions.URL, "url",
+		benchOptions.URL, "Disk to measure the download with, e.g. a disk of a mirror used by the containerdisks")
+	benchCmd.Flags().StringVar(&benchOptions.Image, "image",
+		benchOptions.Image, "Image reference to measure the upload with, it is overwritten (requires --dry-run=false)")
+
+	return benchCmd
+}
+
+func run(cmd *cobra.Command, options *common.Options, benchOptions *benchOptions) error {
+	quantity, err := resource.ParseQuantity(benchOptions.Size)
+	if err != nil {
+		return fmt.Errorf("invalid size %q: %v", benchOptions.Size, err)
+	}
+	size := quantity.Value()
+
+	threads := runtime.NumCPU()
+	stages := []func() (bench.Result, error){
+		func() (bench.Result, error) { return bench.Hash("sha256", sha256.New, size) },
+		func() (bench.Result, error) { return bench.Hash("sha512", sha512.New, size) },
+		func() (bench.Result, error) {
+			return bench.Compress("gzip threads=1", build.Compression{Threads: 1}, size)
+		},
+		func() (bench.Result, error) {
+			return bench.Compress(fmt.Sprintf("gzip threads=%d", threads), build.Compression{Threads: threads}, size)
+		},
+		func() (bench.Result, error) {
+			return bench.Compress(fmt.Sprintf("zstd threads=%d", threads),
+				build.Compression{Algorithm: build.CompressionZstd, Threads: threads}, size)
+		},
+	}
+	if benchOptions.URL != "" {
+		stages = append(stages, func() (bench.Result, error) {
+			return bench.Download(cmd.Context(), &http.HTTPGetter{}, benchOptions.URL)
+		})
+	}
+	if benchOptions.Image != "" {
+		if options.DryRun {
+			logrus.Infof("Dry run enabled, not measuring the upload to %s", benchOptions.Image)
+		} else {
+			stages = append(stages, func() (bench.Result, error) {
+				return bench.Upload(cmd.Context(), benchOptions.Image, size, craneOptions(options)...)
+			})
+		}
+	}
+
+	for _, stage := range stages {
+		result, err := stage()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), result)
+		if err := cmd.Context().Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func craneOptions(options *common.Options) []crane.Option {
+	if options.AllowInsecureRegistry {
+		return []crane.Option{crane.Insecure}
+	}
+	return nil
+}
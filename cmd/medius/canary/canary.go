@@ -0,0 +1,91 @@
+package canary
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+// newRegistry gathers the registry of artifacts to inspect, it is replaced by tests.
+var newRegistry = common.NewRegistryWithErrors
+
+func NewCanaryCommand(options *common.Options) *cobra.Command {
+	var snapshot http.Snapshot
+	canaryCmd := &cobra.Command{
+		Use:   "canary",
+		Short: "Inspect all containerdisk sources upstream without downloading them to detect layout changes early",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := http.SetDefaultSnapshot(snapshot); err != nil {
+				return err
+			}
+			return run(options)
+		},
+	}
+	canaryCmd.Flags().StringVar(&snapshot.Timestamp, "at", snapshot.Timestamp,
+		"Inspect upstream as archived by the Wayback Machine at the timestamp, e.g. 20240115")
+	canaryCmd.Flags().StringVar(&snapshot.Dir, "replay", snapshot.Dir,
+		"Inspect upstream as recorded to the directory, e.g. the testdata of a provider")
+
+	return canaryCmd
+}
+
+func run(options *common.Options) error {
+	focusMatched := false
+	failed := 0
+
+	registry, gatherErr := newRegistry()
+	if gatherErr != nil {
+		logrus.WithError(gatherErr).Error("Gathering artifacts failed")
+	}
+	for i := range registry {
+		if common.ShouldSkip(options.Focus, &registry[i]) {
+			continue
+		}
+		focusMatched = true
+
+		for _, artifact := range registry[i].Artifacts {
+			log := common.Logger(artifact).WithField("arch", artifact.Metadata().Arch)
+			details, err := artifact.Inspect()
+			if err == nil {
+				err = validateDetails(details)
+			}
+			if err != nil {
+				failed++
+				log.WithError(err).Error("Upstream inspection failed")
+				continue
+			}
+			log.Infof("Upstream inspection succeeded: %s", details.DownloadURL)
+		}
+	}
+
+	if !focusMatched {
+		return fmt.Errorf("no artifact was processed, focus '%s' did not match", options.Focus)
+	}
+
+	var inspectErr error
+	if failed > 0 {
+		inspectErr = fmt.Errorf("upstream inspection failed for %d artifacts", failed)
+	}
+
+	return errors.Join(gatherErr, inspectErr)
+}
+
+func validateDetails(details *api.ArtifactDetails) error {
+	if details == nil {
+		return errors.New("no artifact details returned")
+	}
+	if details.DownloadURL == "" && details.Generate == nil {
+		return errors.New("no download URL found")
+	}
+	if details.ChecksumHash == nil {
+		return errors.New("no checksum hash function set")
+	}
+
+	return nil
+}
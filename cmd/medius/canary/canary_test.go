@@ -0,0 +1,83 @@
+package canary
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+type failingArtifact struct {
+	api.Artifact
+}
+
+func (a failingArtifact) Inspect() (*api.ArtifactDetails, error) {
+	return nil, errors.New("release listing changed")
+}
+
+var _ = Describe("Canary", func() {
+	valid := &api.ArtifactDetails{DownloadURL: "https://example.com/disk.qcow2", ChecksumHash: sha256.New}
+	entry := func(name string, details *api.ArtifactDetails) common.Entry {
+		return common.Entry{Artifacts: []api.Artifact{
+			generic.New(details, &api.Metadata{Name: name, Version: "1", Arch: "x86_64"}),
+		}}
+	}
+
+	setRegistry := func(registry []common.Entry, gatherErr error) {
+		newRegistry = func() ([]common.Entry, error) {
+			return registry, gatherErr
+		}
+		DeferCleanup(func() { newRegistry = common.NewRegistryWithErrors })
+	}
+
+	DescribeTable("validateDetails",
+		func(details *api.ArtifactDetails, expectedErr string) {
+			err := validateDetails(details)
+			if expectedErr == "" {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedErr))
+			}
+		},
+		Entry("should accept downloads", valid, ""),
+		Entry("should accept generated disks",
+			&api.ArtifactDetails{Generate: func(io.Writer) error { return nil }, ChecksumHash: sha256.New}, ""),
+		Entry("should reject missing details", nil, "no artifact details returned"),
+		Entry("should reject details without download URL", &api.ArtifactDetails{ChecksumHash: sha256.New}, "no download URL found"),
+		Entry("should reject details without checksum hash", &api.ArtifactDetails{DownloadURL: valid.DownloadURL},
+			"no checksum hash function set"),
+	)
+
+	It("should succeed if all focused artifacts can be inspected", func() {
+		setRegistry([]common.Entry{entry("distro", valid), entry("broken", &api.ArtifactDetails{})}, nil)
+		Expect(run(&common.Options{Focus: "distro:1"})).To(Succeed())
+	})
+
+	It("should reject a focus which matches no artifact", func() {
+		setRegistry([]common.Entry{entry("distro", valid)}, nil)
+		Expect(run(&common.Options{Focus: "other:1"})).To(MatchError("no artifact was processed, focus 'other:1' did not match"))
+	})
+
+	It("should join the errors of gathering and inspecting artifacts", func() {
+		gatherErr := errors.New("gathering fedora failed")
+		broken := entry("broken", valid)
+		broken.Artifacts[0] = failingArtifact{Artifact: broken.Artifacts[0]}
+		setRegistry([]common.Entry{entry("distro", valid), broken, entry("incomplete", &api.ArtifactDetails{})}, gatherErr)
+
+		err := run(&common.Options{})
+		Expect(err).To(MatchError(gatherErr))
+		Expect(err).To(MatchError(ContainSubstring("upstream inspection failed for 2 artifacts")))
+	})
+})
+
+func TestCanary(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Canary Suite")
+}
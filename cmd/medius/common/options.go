@@ -1,24 +1,82 @@
 package common
 
+import (
+	"time"
+
+	"kubevirt.io/containerdisks/pkg/build"
+	"kubevirt.io/containerdisks/pkg/metrics"
+	"kubevirt.io/containerdisks/pkg/progress"
+	"kubevirt.io/containerdisks/pkg/workspace"
+)
+
 type Options struct {
 	AllowInsecureRegistry bool
 	DryRun                bool
 	Focus                 string
-	ImagesOptions         ImagesOptions
-	PublishDocsOptions    PublishDocsOptions
-	PublishImagesOptions  PublishImageOptions
-	PromoteImageOptions   PromoteImageOptions
-	VerifyImagesOptions   VerifyImageOptions
+	LockFile              string
+	// Org is the organization or namespace the {{org}} placeholder of the repository template is replaced with.
+	Org string
+	// RepositoryTemplate names the repositories of containerdisks, e.g. {{registry}}/{{org}}/{{name}}-{{arch}}.
+	RepositoryTemplate string
+	// RegistryBackends select the registry client per registry host, e.g. ghcr.io=go-containerregistry.
+	RegistryBackends []string
+	// RegistryAuthSecret references a registry auth file in the format of ~/.docker/config.json in a secret store.
+	RegistryAuthSecret   string
+	ScratchDir           string
+	ScratchQuota         string
+	ImagesOptions        ImagesOptions
+	PublishDocsOptions   PublishDocsOptions
+	PublishImagesOptions PublishImageOptions
+	PromoteImageOptions  PromoteImageOptions
+	VerifyImagesOptions  VerifyImageOptions
+	// Workspace is set up at the start of a run and holds all its temporary files.
+	Workspace *workspace.Workspace
+	// Metrics are loaded at the start of a run if a metrics file is configured.
+	Metrics *metrics.Set
+	// Progress shows the progress of all artifacts on the terminal if it is set.
+	Progress *progress.Tracker
 }
 
 type ImagesOptions struct {
-	ResultsFile string
-	Workers     int
+	// BufferSize is the size of the pooled buffers of the packaging pipeline, e.g. 1Mi.
+	BufferSize string
+	// Compression configures the compression of the layers of built containerdisks.
+	Compression build.Compression
+	// Layout configures the path of the disk and the ownership of the files in built containerdisks.
+	Layout build.Layout
+	// MetricsFile is updated with the metrics of every stage, e.g. for the textfile collector of the node exporter.
+	MetricsFile string
+	// ProgressInterval is the time between two logs of the transfers in progress if the progress is not shown
+	// on the terminal, zero disables them.
+	ProgressInterval time.Duration
+	ResultsFile      string
+	// Snapshots selects the latest snapshots of the focused rolling containerdisk.
+	Snapshots int
+	// Version selects a historical upstream version of the focused containerdisk.
+	Version string
+	Workers int
+	// RunLockDir is a directory shared by all runs, holding the leases of the repositories runs publish to.
+	// Runs don't lock their repositories if it is unset.
+	RunLockDir string
+	// RunLockTTL is the time a lease is valid, leases are renewed while the run is going on.
+	RunLockTTL time.Duration
+	// ForceUnlock removes the leases of other runs on the repositories before locking them.
+	ForceUnlock bool
+}
+
+// Archived returns true if historical versions are selected instead of the current containerdisks.
+func (o *ImagesOptions) Archived() bool {
+	return o.Version != "" || o.Snapshots > 0
 }
 
 type PromoteImageOptions struct {
 	SourceRegistry string
 	TargetRegistry string
+	// RolloutFile tracks promoted containerdisks whose floating tags were not moved yet.
+	RolloutFile string
+	// RequiredSuccesses is the number of verifications in a row a containerdisk has to pass before
+	// its floating tags are moved.
+	RequiredSuccesses int
 }
 
 type PublishDocsOptions struct {
@@ -27,16 +85,50 @@ type PublishDocsOptions struct {
 }
 
 type PublishImageOptions struct {
-	ForceBuild     bool
-	NoFail         bool
-	SourceRegistry string
-	TargetRegistry string
+	// Attempts is how often a failing step of publishing a containerdisk is run before it fails for good.
+	Attempts     int
+	Chunked      bool
+	DeltaCache   string
+	Differential bool
+	ForceBuild   bool
+	// Harbor adapts publishing to Harbor registries: unique tags which exist already are not pushed again, so
+	// tag immutability rules can protect them. It is implied by HarborLabels and HarborReplicationPolicies.
+	Harbor bool
+	// HarborLabels are attached to the pushed containerdisks with the Harbor API.
+	HarborLabels []string
+	// HarborReplicationPolicies have to replicate the pushed containerdisks successfully before the push succeeds.
+	HarborReplicationPolicies []string
+	// HarborReplicationTimeout limits the time waiting for the replication policies.
+	HarborReplicationTimeout time.Duration
+	// Inventory attaches the packages, kernel and cloud-init version of the disks as OCI referrer.
+	Inventory bool
+	// InjectGuestAgent installs the qemu-guest-agent into disks whose inventory lacks it. It implies Inventory.
+	InjectGuestAgent bool
+	Locked           bool
+	Metalink         bool
+	NoFail           bool
+	// PrivateRegistry is the only registry containerdisks with the private publish policy are pushed to.
+	PrivateRegistry string
+	SourceRegistry  string
+	TargetRegistry  string
 }
 
 type VerifyImageOptions struct {
-	Registry           string
-	Namespace          string
-	NoFail             bool
+	Registry  string
+	Namespace string
+	NoFail    bool
+	// QuarantineFile tracks failing containerdisks across runs. Quarantine is disabled if it is empty.
+	QuarantineFile string
+	// MaxFailures is the number of failed runs in a row after which a containerdisk is quarantined.
+	MaxFailures int
+	// RolloutFile lists promoted containerdisks to verify again until their floating tags are moved.
+	RolloutFile string
+	// RolloutRegistry contains the promoted containerdisks of the rollout file.
+	RolloutRegistry string
+	// BootTimeFile keeps the boot times of containerdisks across runs. Boot times are not tracked if it is empty.
+	BootTimeFile string
+	// BootTimeThreshold is the relative increase of the boot time over older builds which is reported.
+	BootTimeThreshold  float64
 	Timeout            int
 	TargetArchitecture string
 }
@@ -2,6 +2,10 @@ package common
 
 import (
 	"crypto/sha256"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -10,37 +14,57 @@ import (
 	"kubevirt.io/containerdisks/artifacts/debian"
 	"kubevirt.io/containerdisks/artifacts/fedora"
 	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/artifacts/haos"
 	"kubevirt.io/containerdisks/artifacts/opensuse/leap"
 	"kubevirt.io/containerdisks/artifacts/opensuse/microos"
 	"kubevirt.io/containerdisks/artifacts/opensuse/tumbleweed"
+	"kubevirt.io/containerdisks/artifacts/openwrt"
+	"kubevirt.io/containerdisks/artifacts/opnsense"
+	"kubevirt.io/containerdisks/artifacts/truenas"
 	"kubevirt.io/containerdisks/artifacts/ubuntu"
+	"kubevirt.io/containerdisks/artifacts/utility"
+	"kubevirt.io/containerdisks/artifacts/virtiowin"
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/common"
 	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/repository"
+	"kubevirt.io/containerdisks/pkg/tagpolicy"
+	"kubevirt.io/containerdisks/pkg/utilitydisk"
 )
 
 type Entry struct {
-	Artifacts          []api.Artifact
-	UseForDocs         bool
-	UseForLatest       bool
+	Artifacts  []api.Artifact
+	UseForDocs bool
+	// UseForLatest moves the "latest" tag to this entry. At most one entry per name may set it and
+	// rolling distributions, where "latest" would be ambiguous, should never set it.
+	UseForLatest bool
+	// Aliases are additional floating tags for the stream of this entry, e.g. release code names.
+	// Like "latest", they are only moved in the target registry once verification succeeded.
+	Aliases            []string
 	SkipWhenNotFocused bool
 }
 
+// Repository returns the repository of the containerdisks of the entry in registry, as named by the
+// repository template. An empty registry returns the repository relative to the registry.
+func (e *Entry) Repository(registry string) string {
+	metadata := e.Artifacts[0].Metadata()
+	return repository.Name(registry, metadata.Name, metadata.Arch)
+}
+
+// Image returns the reference of the moving version tag of the containerdisks of the entry in registry.
+func (e *Entry) Image(registry string) string {
+	return e.Repository(registry) + ":" + e.Artifacts[0].Metadata().Tag()
+}
+
 var staticRegistry = []Entry{
 	{
-		Artifacts: []api.Artifact{
-			centosstream.New("10", "x86_64", &docs.UserData{Username: "cloud-user"}, defaultEnvVariables("u1.medium", "centos.stream10")),
-			centosstream.New("10", "aarch64", &docs.UserData{Username: "cloud-user"}, defaultEnvVariables("u1.medium", "centos.stream10")),
-			centosstream.New("10", "s390x", &docs.UserData{Username: "cloud-user"}, defaultEnvVariables("u1.medium", "centos.stream10")),
-		},
+		Artifacts: api.Split(centosstream.New("10", []string{"x86_64", "aarch64", "s390x"},
+			&docs.UserData{Username: "cloud-user"}, defaultEnvVariables("u1.medium", "centos.stream10"))),
 		UseForDocs: true,
 	},
 	{
-		Artifacts: []api.Artifact{
-			centosstream.New("9", "x86_64", &docs.UserData{Username: "cloud-user"}, defaultEnvVariables("u1.medium", "centos.stream9")),
-			centosstream.New("9", "aarch64", &docs.UserData{Username: "cloud-user"}, defaultEnvVariables("u1.medium", "centos.stream9")),
-			centosstream.New("9", "s390x", &docs.UserData{Username: "cloud-user"}, defaultEnvVariables("u1.medium", "centos.stream9")),
-		},
+		Artifacts: api.Split(centosstream.New("9", []string{"x86_64", "aarch64", "s390x"},
+			&docs.UserData{Username: "cloud-user"}, defaultEnvVariables("u1.medium", "centos.stream9"))),
 		UseForDocs: true,
 	},
 	{
@@ -107,21 +131,42 @@ var staticRegistry = []Entry{
 			debian.New("11", "bullseye", "x86_64", &docs.UserData{Username: "debian"}, defaultEnvVariables("u1.medium", "debian")),
 			debian.New("11", "bullseye", "aarch64", &docs.UserData{Username: "debian"}, defaultEnvVariables("u1.medium", "debian")),
 		},
+		Aliases: []string{"bullseye"},
 	},
 	{
 		Artifacts: []api.Artifact{
 			debian.New("12", "bookworm", "x86_64", &docs.UserData{Username: "debian"}, defaultEnvVariables("u1.medium", "debian")),
 			debian.New("12", "bookworm", "aarch64", &docs.UserData{Username: "debian"}, defaultEnvVariables("u1.medium", "debian")),
 		},
+		Aliases: []string{"bookworm"},
 	},
 	{
 		Artifacts: []api.Artifact{
 			debian.New("13", "trixie", "x86_64", &docs.UserData{Username: "debian"}, defaultEnvVariables("u1.medium", "debian")),
 			debian.New("13", "trixie", "aarch64", &docs.UserData{Username: "debian"}, defaultEnvVariables("u1.medium", "debian")),
 		},
+		Aliases:      []string{"trixie"},
 		UseForDocs:   true,
 		UseForLatest: true,
 	},
+	{
+		Artifacts: []api.Artifact{
+			utility.New("10Gi", utilitydisk.FilesystemNone, "x86_64"),
+			utility.New("10Gi", utilitydisk.FilesystemNone, "aarch64"),
+		},
+	},
+	{
+		Artifacts: []api.Artifact{
+			utility.New("10Gi", utilitydisk.FilesystemVFAT, "x86_64"),
+			utility.New("10Gi", utilitydisk.FilesystemVFAT, "aarch64"),
+		},
+	},
+	{
+		Artifacts: []api.Artifact{
+			utility.New("2Gi", utilitydisk.FilesystemSwap, "x86_64"),
+			utility.New("2Gi", utilitydisk.FilesystemSwap, "aarch64"),
+		},
+	},
 	// for testing only
 	{
 		Artifacts: []api.Artifact{
@@ -135,6 +180,7 @@ var staticRegistry = []Entry{
 				&api.Metadata{
 					Name:    "cirros",
 					Version: "6.1",
+					License: "GPL-2.0-only",
 				},
 			),
 			generic.New(
@@ -147,6 +193,7 @@ var staticRegistry = []Entry{
 				&api.Metadata{
 					Name:    "cirros",
 					Version: "6.1",
+					License: "GPL-2.0-only",
 				},
 			),
 		},
@@ -155,11 +202,13 @@ var staticRegistry = []Entry{
 	},
 }
 
-func gatherArtifacts(registry *[]Entry, gatherers []api.ArtifactsGatherer) {
+func gatherArtifacts(registry *[]Entry, gatherers []api.ArtifactsGatherer) []error {
+	var errs []error
 	for _, gatherer := range gatherers {
 		artifacts, err := gatherer.Gather()
 		if err != nil {
 			logrus.Warn("Failed to gather artifacts", err)
+			errs = append(errs, err)
 		} else {
 			firstStable := true
 			for i := range artifacts {
@@ -175,6 +224,73 @@ func gatherArtifacts(registry *[]Entry, gatherers []api.ArtifactsGatherer) {
 			}
 		}
 	}
+	return errs
+}
+
+// gatheredProviders discover the releases of the containerdisks which are not part of the static registry.
+var gatheredProviders = []struct {
+	name        string
+	newGatherer func() api.ArtifactsGatherer
+}{
+	{"fedora", func() api.ArtifactsGatherer { return fedora.NewGatherer() }},
+	{"haos", func() api.ArtifactsGatherer { return haos.NewGatherer() }},
+	{"openwrt", func() api.ArtifactsGatherer { return openwrt.NewGatherer() }},
+	{"opnsense", func() api.ArtifactsGatherer { return opnsense.NewGatherer() }},
+	{"truenas", func() api.ArtifactsGatherer { return truenas.NewGatherer() }},
+	{"virtio-win", func() api.ArtifactsGatherer { return virtiowin.NewGatherer() }},
+}
+
+func archiveGatherers() map[string]api.ArchiveGatherer {
+	return map[string]api.ArchiveGatherer{
+		"fedora":              fedora.NewArchiveGatherer(),
+		"opensuse-tumbleweed": tumbleweed.NewArchiveGatherer(defaultEnvVariables("u1.medium", "opensuse.tumbleweed")),
+	}
+}
+
+func snapshotGatherers() map[string]api.SnapshotGatherer {
+	return map[string]api.SnapshotGatherer{
+		"opensuse-tumbleweed": tumbleweed.NewArchiveGatherer(defaultEnvVariables("u1.medium", "opensuse.tumbleweed")),
+	}
+}
+
+// Provider describes a containerdisk medius can publish and the features it supports.
+type Provider struct {
+	Name string
+	// Gathered is set if the releases of the containerdisk are discovered upstream on every run.
+	Gathered bool
+	// Archive is set if historical versions can be published with --version.
+	Archive bool
+	// Snapshots is set if the latest snapshots of the rolling containerdisk can be published with --snapshots.
+	Snapshots bool
+}
+
+// Providers returns the containerdisks medius can publish sorted by name, without contacting upstream.
+func Providers() []Provider {
+	byName := map[string]*Provider{}
+	provider := func(name string) *Provider {
+		if byName[name] == nil {
+			byName[name] = &Provider{Name: name}
+		}
+		return byName[name]
+	}
+	for i := range staticRegistry {
+		provider(staticRegistry[i].Artifacts[0].Metadata().Name)
+	}
+	for _, gathered := range gatheredProviders {
+		provider(gathered.name).Gathered = true
+	}
+	for name := range archiveGatherers() {
+		provider(name).Archive = true
+	}
+	for name := range snapshotGatherers() {
+		provider(name).Snapshots = true
+	}
+
+	providers := make([]Provider, 0, len(byName))
+	for _, name := range slices.Sorted(maps.Keys(byName)) {
+		providers = append(providers, *byName[name])
+	}
+	return providers
 }
 
 func defaultEnvVariables(defaultInstancetype, defaultPreference string) map[string]string {
@@ -185,13 +301,109 @@ func defaultEnvVariables(defaultInstancetype, defaultPreference string) map[stri
 }
 
 func NewRegistry() []Entry {
+	registry, _ := NewRegistryWithErrors()
+	return registry
+}
+
+// NewRegistryWithErrors is like NewRegistry but additionally returns the errors of failed gatherers.
+// The registry is always returned, even if gathering failed.
+func NewRegistryWithErrors() ([]Entry, error) {
 	registry := make([]Entry, len(staticRegistry))
 	copy(registry, staticRegistry)
 
-	gatherers := []api.ArtifactsGatherer{fedora.NewGatherer()}
-	gatherArtifacts(&registry, gatherers)
+	gatherers := make([]api.ArtifactsGatherer, 0, len(gatheredProviders))
+	for _, provider := range gatheredProviders {
+		gatherers = append(gatherers, provider.newGatherer())
+	}
+	errs := gatherArtifacts(&registry, gatherers)
+	if err := ValidateRegistry(registry); err != nil {
+		errs = append(errs, err)
+	}
 
-	return registry
+	return registry, errors.Join(errs...)
+}
+
+// NewArchivedRegistry returns a registry which only contains the historical version of the containerdisk
+// with the given name. The entry never claims floating tags, so publishing it does not affect current versions.
+func NewArchivedRegistry(name, version string) ([]Entry, error) {
+	gatherer, ok := archiveGatherers()[name]
+	if !ok {
+		return nil, fmt.Errorf("containerdisk %q does not support publishing historical versions", name)
+	}
+
+	artifacts, err := gatherer.GatherArchived(version)
+	if err != nil {
+		return nil, fmt.Errorf("error gathering %s:%s from the archives: %v", name, version, err)
+	}
+
+	return []Entry{{Artifacts: artifacts}}, nil
+}
+
+// NewSnapshotRegistry returns a registry which only contains the latest count snapshots of the rolling
+// containerdisk with the given name, so users can pin a recent snapshot if the latest one regressed. Like
+// archived entries, the entries never claim floating tags.
+func NewSnapshotRegistry(name string, count int) ([]Entry, error) {
+	gatherer, ok := snapshotGatherers()[name]
+	if !ok {
+		return nil, fmt.Errorf("containerdisk %q does not support publishing snapshots", name)
+	}
+
+	snapshots, err := gatherer.GatherSnapshots(count)
+	if err != nil {
+		return nil, fmt.Errorf("error gathering the snapshots of %s: %v", name, err)
+	}
+
+	registry := make([]Entry, 0, len(snapshots))
+	for _, artifacts := range snapshots {
+		registry = append(registry, Entry{Artifacts: artifacts})
+	}
+	return registry, nil
+}
+
+// ValidateRegistry ensures that floating tags are unambiguous: "latest" and every alias
+// must only be claimed by a single entry per name and variant. All artifacts of an entry
+// have to be of the same variant, so they can be assembled into a single manifest list.
+func ValidateRegistry(registry []Entry) error {
+	claimed := map[string]int{}
+	for i := range registry {
+		if len(registry[i].Artifacts) == 0 {
+			continue
+		}
+		metadata := registry[i].Artifacts[0].Metadata()
+		if err := validateVariant(registry[i].Artifacts); err != nil {
+			return fmt.Errorf("invalid entry %s: %v", metadata.Describe(), err)
+		}
+		if err := metadata.SizeBudget.Validate(); err != nil {
+			return fmt.Errorf("invalid entry %s: %v", metadata.Describe(), err)
+		}
+
+		floating := registry[i].Aliases
+		if registry[i].UseForLatest {
+			floating = append(slices.Clone(floating), tagpolicy.LatestTag)
+		}
+		for _, tag := range metadata.Variant.Tags(floating) {
+			ref := metadata.Name + ":" + tag
+			if other, exists := claimed[ref]; exists && other != i {
+				return fmt.Errorf("floating tag %s is claimed by multiple entries", ref)
+			}
+			claimed[ref] = i
+		}
+	}
+
+	return nil
+}
+
+func validateVariant(artifacts []api.Artifact) error {
+	variant := artifacts[0].Metadata().Variant
+	if err := variant.Validate(); err != nil {
+		return err
+	}
+	for _, artifact := range artifacts[1:] {
+		if other := artifact.Metadata().Variant; other != variant {
+			return fmt.Errorf("artifacts of variants %q and %q can't be published together", variant, other)
+		}
+	}
+	return nil
 }
 
 func ShouldSkip(focus string, entry *Entry) bool {
@@ -212,3 +424,36 @@ func ShouldSkip(focus string, entry *Entry) bool {
 
 	return focus != entry.Artifacts[0].Metadata().Describe()
 }
+
+// FocusCompletions returns the focus values of all entries starting with toComplete, i.e. their
+// descriptions like "fedora:43" and wildcards like "fedora:*", for shell completion of --focus.
+func FocusCompletions(entries []Entry, toComplete string) []string {
+	var completions []string
+	for i := range entries {
+		if len(entries[i].Artifacts) == 0 {
+			continue
+		}
+		metadata := entries[i].Artifacts[0].Metadata()
+		for _, focus := range []string{metadata.Name + ":*", metadata.Describe()} {
+			if strings.HasPrefix(focus, toComplete) && !slices.Contains(completions, focus) {
+				completions = append(completions, focus)
+			}
+		}
+	}
+	slices.Sort(completions)
+	return completions
+}
+
+// StaticFocusCompletions completes --focus without gathering, so shell completion never contacts upstream. The
+// versions of gathered providers are unknown without gathering, only their wildcards like "fedora:*" are completed.
+func StaticFocusCompletions(toComplete string) []string {
+	completions := FocusCompletions(staticRegistry, toComplete)
+	for _, provider := range Providers() {
+		focus := provider.Name + ":*"
+		if provider.Gathered && strings.HasPrefix(focus, toComplete) && !slices.Contains(completions, focus) {
+			completions = append(completions, focus)
+		}
+	}
+	slices.Sort(completions)
+	return completions
+}
@@ -0,0 +1,109 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+var _ = ginkgo.Describe("Registry", func() {
+	newEntry := func(version string, useForLatest bool, aliases ...string) Entry {
+		return Entry{
+			Artifacts: []api.Artifact{
+				generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: "distro", Version: version, Arch: "x86_64"}),
+			},
+			UseForLatest: useForLatest,
+			Aliases:      aliases,
+		}
+	}
+
+	ginkgo.It("should accept the static registry", func() {
+		Expect(ValidateRegistry(staticRegistry)).To(Succeed())
+	})
+
+	ginkgo.It("should list the providers and their features", func() {
+		Expect(Providers()).To(ContainElements(
+			Provider{Name: "centos-stream"},
+			Provider{Name: "fedora", Gathered: true, Archive: true},
+			Provider{Name: "opensuse-tumbleweed", Archive: true, Snapshots: true},
+		))
+	})
+
+	ginkgo.It("should accept unique floating tags", func() {
+		Expect(ValidateRegistry([]Entry{
+			newEntry("1", false, "one"),
+			newEntry("2", true, "two"),
+		})).To(Succeed())
+	})
+
+	ginkgo.It("should reject latest claimed by multiple entries", func() {
+		Expect(ValidateRegistry([]Entry{
+			newEntry("1", true),
+			newEntry("2", true),
+		})).To(MatchError(ContainSubstring("distro:latest")))
+	})
+
+	ginkgo.It("should reject aliases claimed by multiple entries", func() {
+		Expect(ValidateRegistry([]Entry{
+			newEntry("1", false, "stable"),
+			newEntry("2", false, "stable"),
+		})).To(MatchError(ContainSubstring("distro:stable")))
+	})
+
+	ginkgo.It("should accept latest claimed once per variant", func() {
+		minimal := newEntry("2", true)
+		minimal.Artifacts = []api.Artifact{
+			generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: "distro", Version: "2", Arch: "x86_64", Variant: api.VariantMinimal}),
+		}
+		Expect(ValidateRegistry([]Entry{newEntry("2", true), minimal})).To(Succeed())
+		Expect(minimal.Image("registry")).To(Equal("registry/distro:2-minimal"))
+	})
+
+	ginkgo.It("should reject entries mixing variants", func() {
+		entry := newEntry("1", false)
+		entry.Artifacts = append(entry.Artifacts,
+			generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: "distro", Version: "1", Arch: "aarch64", Variant: api.VariantLive}))
+		Expect(ValidateRegistry([]Entry{entry})).To(MatchError(ContainSubstring(`variants "" and "live"`)))
+
+		entry.Artifacts = entry.Artifacts[1:]
+		entry.Artifacts[0].Metadata().Variant = "tiny"
+		Expect(ValidateRegistry([]Entry{entry})).To(MatchError(ContainSubstring(`unknown variant "tiny"`)))
+	})
+
+	ginkgo.It("should reject invalid size budgets", func() {
+		entry := newEntry("1", false)
+		entry.Artifacts[0].Metadata().SizeBudget = api.SizeBudget{Min: resource.MustParse("2Gi"), Max: resource.MustParse("1Gi")}
+		Expect(ValidateRegistry([]Entry{entry})).To(MatchError(ContainSubstring("distro:1: minimum of size budget 2Gi-1Gi")))
+
+		entry.Artifacts[0].Metadata().SizeBudget = api.SizeBudget{Min: resource.MustParse("-1")}
+		Expect(ValidateRegistry([]Entry{entry})).To(MatchError(ContainSubstring("must not be negative")))
+	})
+
+	ginkgo.It("should complete focus values", func() {
+		entries := []Entry{newEntry("1", false), newEntry("2", true), {}}
+		Expect(FocusCompletions(entries, "")).To(Equal([]string{"distro:*", "distro:1", "distro:2"}))
+		Expect(FocusCompletions(entries, "distro:2")).To(Equal([]string{"distro:2"}))
+		Expect(FocusCompletions(entries, "fedora")).To(BeEmpty())
+	})
+
+	ginkgo.It("should complete focus values without gathering", func() {
+		Expect(StaticFocusCompletions("centos-stream")).To(ContainElements("centos-stream:*", "centos-stream:10"))
+		Expect(StaticFocusCompletions("fedora")).To(Equal([]string{"fedora:*"}))
+		Expect(StaticFocusCompletions("haos:")).To(Equal([]string{"haos:*"}))
+	})
+
+	ginkgo.It("should reject snapshots of containerdisks without a history", func() {
+		_, err := NewSnapshotRegistry("debian", 3)
+		Expect(err).To(MatchError(`containerdisk "debian" does not support publishing snapshots`))
+	})
+})
+
+func TestCommon(t *testing.T) {
+	RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "Common Suite")
+}
@@ -0,0 +1,174 @@
+package docs
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/ociartifact"
+)
+
+const (
+	// LabelCatalogVersion is added to all resources of a bundle to identify the pipeline run which generated it.
+	LabelCatalogVersion = "containerdisks.kubevirt.io/catalog-version"
+
+	kustomizationFileName = "kustomization.yaml"
+	examplesDir           = "examples"
+)
+
+type bundleOptions struct {
+	Registry  string
+	OutputDir string
+	Version   string
+	Examples  bool
+}
+
+// kustomization is the subset of the kustomize Kustomization used by bundles.
+type kustomization struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Namespace  string               `json:"namespace,omitempty"`
+	Labels     []kustomizationLabel `json:"labels,omitempty"`
+	Resources  []string             `json:"resources"`
+}
+
+type kustomizationLabel struct {
+	Pairs map[string]string `json:"pairs"`
+}
+
+// bundleArtifact is a containerdisk of the catalog together with its details.
+type bundleArtifact struct {
+	Artifact api.Artifact
+	Details  *api.ArtifactDetails
+	// Image is the reference of the published containerdisk.
+	Image string
+}
+
+func NewBundleCommand(options *common.Options) *cobra.Command {
+	bundleOptions := &bundleOptions{
+		Registry:  "quay.io/containerdisks",
+		OutputDir: "bundle",
+		Version:   time.Now().Format("0601021504"),
+	}
+
+	bundleCmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Generate a kustomize bundle deploying the catalog as golden images",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBundle(options, bundleOptions)
+		},
+	}
+	bundleCmd.Flags().StringVar(&bundleOptions.Registry, "registry",
+		bundleOptions.Registry, "Registry the containerdisks are published in")
+	bundleCmd.Flags().StringVar(&bundleOptions.OutputDir, "output-dir",
+		bundleOptions.OutputDir, "Directory to write the bundle to")
+	bundleCmd.Flags().StringVar(&bundleOptions.Version, "catalog-version",
+		bundleOptions.Version, "Version of the bundle, defaults to the timestamp of the run")
+	bundleCmd.Flags().BoolVar(&bundleOptions.Examples, "examples",
+		bundleOptions.Examples, "Add example VirtualMachines to the examples directory of the bundle")
+
+	return bundleCmd
+}
+
+func runBundle(options *common.Options, bundleOptions *bundleOptions) error {
+	var artifacts []bundleArtifact
+	registry := common.NewRegistry()
+	for i, p := range registry {
+		if common.ShouldSkip(options.Focus, &registry[i]) || !p.UseForDocs {
+			continue
+		}
+
+		artifact, details, err := getPreferredArtifact(p.Artifacts)
+		if err != nil {
+			return fmt.Errorf("error getting artifact: %v", err)
+		}
+		image := p.Image(bundleOptions.Registry)
+		if details.VirtualSize == 0 {
+			details.VirtualSize = lookupVirtualSize(image, details.ImageArchitecture, options.AllowInsecureRegistry)
+		}
+		artifacts = append(artifacts, bundleArtifact{Artifact: artifact, Details: details, Image: image})
+	}
+
+	if len(artifacts) == 0 {
+		return fmt.Errorf("no artifact was processed, focus '%s' did not match", options.Focus)
+	}
+
+	files, err := createBundle(artifacts, bundleOptions)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Writing bundle %s to %s", bundleOptions.Version, bundleOptions.OutputDir)
+	return writeFiles(bundleOptions.OutputDir, files)
+}
+
+// createBundle renders a kustomization with the golden image manifests of all artifacts. The
+// example VMs are put into a separate kustomization, so they are only deployed on request.
+func createBundle(artifacts []bundleArtifact, bundleOptions *bundleOptions) ([]ociartifact.File, error) {
+	var (
+		files     []ociartifact.File
+		resources []string
+		examples  []string
+	)
+
+	for _, a := range artifacts {
+		metadata := a.Artifact.Metadata()
+
+		manifests, err := createGoldenImageManifests(a.Artifact, a.Details, a.Image)
+		if err != nil {
+			return nil, err
+		}
+		fileName := metadata.Name + ".yaml"
+		files = append(files, ociartifact.File{Name: fileName, MediaType: GoldenImageMediaType, Data: []byte(manifests)})
+		resources = append(resources, fileName)
+
+		if bundleOptions.Examples {
+			vm := a.Artifact.VM(metadata.Name, a.Image,
+				a.Artifact.UserData(&metadata.ExampleUserData))
+			example, err := yaml.Marshal(vm)
+			if err != nil {
+				return nil, fmt.Errorf("error marshaling example for %q: %v", metadata.Name, err)
+			}
+			files = append(files, ociartifact.File{Name: path.Join(examplesDir, fileName), Data: example})
+			examples = append(examples, fileName)
+		}
+	}
+
+	labels := []kustomizationLabel{{Pairs: map[string]string{LabelCatalogVersion: bundleOptions.Version}}}
+	kustomizationFile, err := marshalKustomization(docs.GoldenImageNamespace, labels, resources)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, ociartifact.File{Name: kustomizationFileName, Data: kustomizationFile})
+
+	if len(examples) > 0 {
+		examplesKustomization, err := marshalKustomization("", labels, examples)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, ociartifact.File{Name: path.Join(examplesDir, kustomizationFileName), Data: examplesKustomization})
+	}
+
+	return files, nil
+}
+
+func marshalKustomization(namespace string, labels []kustomizationLabel, resources []string) ([]byte, error) {
+	data, err := yaml.Marshal(&kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Namespace:  namespace,
+		Labels:     labels,
+		Resources:  resources,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling kustomization: %v", err)
+	}
+	return data, nil
+}
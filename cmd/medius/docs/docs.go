@@ -5,7 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"path"
+	"strconv"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -14,9 +14,12 @@ import (
 
 	"kubevirt.io/containerdisks/cmd/medius/common"
 	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/build"
 	pkgcommon "kubevirt.io/containerdisks/pkg/common"
 	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/inventory"
 	"kubevirt.io/containerdisks/pkg/quay"
+	"kubevirt.io/containerdisks/pkg/repository"
 )
 
 func NewPublishDocsCommand(options *common.Options) *cobra.Command {
@@ -48,12 +51,6 @@ func run(options *common.Options) error {
 	success := true
 	focusMatched := false
 
-	quayOrg, err := getQuayOrg(options.PublishDocsOptions.Registry)
-	if err != nil {
-		return err
-	}
-
-	client := quay.NewQuayClient(options.PublishDocsOptions.TokenFile, quayOrg)
 	registry := common.NewRegistry()
 	for i, p := range registry {
 		if common.ShouldSkip(options.Focus, &registry[i]) || !p.UseForDocs {
@@ -61,7 +58,7 @@ func run(options *common.Options) error {
 		}
 		focusMatched = true
 
-		artifact, err := getPreferredArtifact(p.Artifacts)
+		artifact, details, err := getPreferredArtifact(p.Artifacts)
 		if err != nil {
 			success = false
 			logrus.Errorf("error getting artifact: %v", err)
@@ -69,9 +66,22 @@ func run(options *common.Options) error {
 		}
 
 		log := common.Logger(artifact)
-		name := artifact.Metadata().Name
+		quayOrg, name, err := getQuayRepository(p.Repository(options.PublishDocsOptions.Registry))
+		if err != nil {
+			return err
+		}
+		client := quay.NewQuayClient(options.PublishDocsOptions.TokenFile, quayOrg)
+
+		image := p.Image(options.PublishDocsOptions.Registry)
+		labels := lookupLabels(image, details.ImageArchitecture, options.AllowInsecureRegistry)
+		if details.VirtualSize == 0 {
+			details.VirtualSize = virtualSize(labels)
+		}
 
-		description, err := createDescription(artifact, options.PublishDocsOptions.Registry)
+		description, err := createDescription(artifact, details, image, published{
+			InventorySummary: labels[inventory.AnnotationSummary],
+			Variants:         variants(registry, &registry[i], options.PublishDocsOptions.Registry),
+		})
 		if err != nil {
 			success = false
 			log.Errorf("error marshaling example for %q: %v", name, err)
@@ -98,41 +108,98 @@ func run(options *common.Options) error {
 	return nil
 }
 
-func getQuayOrg(registry string) (string, error) {
-	elements := strings.Split(registry, "/")
-	if len(elements) != 2 || elements[0] != "quay.io" || elements[1] == "" {
-		return "", fmt.Errorf(
-			"error determining quay.io organization from %v, this command only works with quay.io",
-			registry,
+// getQuayRepository splits a repository like quay.io/containerdisks/fedora into its organization and name.
+func getQuayRepository(repository string) (org, name string, err error) {
+	elements := strings.Split(repository, "/")
+	if len(elements) != 3 || elements[0] != "quay.io" || elements[1] == "" || elements[2] == "" {
+		return "", "", fmt.Errorf(
+			"error determining quay.io organization and repository from %v, this command only works with quay.io",
+			repository,
 		)
 	}
 
-	return elements[1], nil
+	return elements[1], elements[2], nil
+}
+
+// lookupVirtualSize reads the virtual size of the disk from the published containerdisk.
+// It returns 0 if the size can't be determined.
+func lookupVirtualSize(imgRef, arch string, insecure bool) int64 {
+	return virtualSize(lookupLabels(imgRef, arch, insecure))
+}
+
+// lookupLabels reads the labels of the published containerdisk. It returns nil if the image can't be introspected.
+func lookupLabels(imgRef, arch string, insecure bool) map[string]string {
+	imageInfo, err := repository.New().ImageMetadata(imgRef, arch, insecure)
+	if err != nil {
+		logrus.WithError(err).Warnf("Failed to read the labels of %s", imgRef)
+		return nil
+	}
+
+	return imageInfo.Labels
+}
+
+// virtualSize returns the virtual size of the disk stored in the labels or 0 if it is unknown.
+func virtualSize(labels map[string]string) int64 {
+	size, err := strconv.ParseInt(labels[build.AnnotationVirtualSize], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return size
 }
 
 // getPreferredArtifact returns the preferred artifact which has the amd64 architecture.
 // If no artifact with the amd64 architecture can be found, it will try to return the first artifact.
-func getPreferredArtifact(artifacts []api.Artifact) (api.Artifact, error) {
+func getPreferredArtifact(artifacts []api.Artifact) (api.Artifact, *api.ArtifactDetails, error) {
 	if len(artifacts) == 0 {
-		return nil, errors.New("no artifacts provided")
+		return nil, nil, errors.New("no artifacts provided")
 	}
 
-	for _, artifact := range artifacts {
+	var firstDetails *api.ArtifactDetails
+	for i, artifact := range artifacts {
 		details, err := artifact.Inspect()
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if details.ImageArchitecture == "amd64" {
-			return artifact, nil
+			return artifact, details, nil
+		}
+		if i == 0 {
+			firstDetails = details
 		}
 	}
 
-	return artifacts[0], nil
+	return artifacts[0], firstDetails, nil
 }
 
-func createDescription(artifact api.Artifact, registry string) (string, error) {
+// published describes the published image of an artifact beyond its metadata.
+type published struct {
+	// InventorySummary summarizes the content of the published disk, if it was inventoried.
+	InventorySummary string
+	// Variants are the other published variants of the documented version.
+	Variants []docs.Variant
+}
+
+// variants returns the images of the entries publishing other variants of the same version as entry.
+func variants(registry []common.Entry, entry *common.Entry, imageRegistry string) []docs.Variant {
+	metadata := entry.Artifacts[0].Metadata()
+	var result []docs.Variant
+	for i := range registry {
+		if len(registry[i].Artifacts) == 0 {
+			continue
+		}
+		other := registry[i].Artifacts[0].Metadata()
+		if other.Name == metadata.Name && other.Version == metadata.Version && other.Variant != metadata.Variant {
+			result = append(result, docs.Variant{Name: string(other.Variant), Image: registry[i].Image(imageRegistry)})
+		}
+	}
+	return result
+}
+
+// createDescription renders the description of an artifact published as image. The details are
+// optional and used to document additional disks of the artifact.
+func createDescription(artifact api.Artifact, details *api.ArtifactDetails, image string, p published) (string, error) {
 	metadata := artifact.Metadata()
-	image := path.Join(registry, metadata.Describe())
 	vm := artifact.VM(
 		metadata.Name,
 		image,
@@ -144,13 +211,44 @@ func createDescription(artifact api.Artifact, registry string) (string, error) {
 		return "", fmt.Errorf("error marshaling example for for %q: %v", metadata.Name, err)
 	}
 
+	goldenImage, err := createGoldenImageManifests(artifact, details, image)
+	if err != nil {
+		return "", err
+	}
+
 	data := &docs.TemplateData{
-		Name:         metadata.Name,
-		Description:  metadata.Description,
-		Example:      string(example),
-		Image:        image,
-		Instancetype: metadata.EnvVariables[pkgcommon.DefaultInstancetypeEnv],
-		Preference:   metadata.EnvVariables[pkgcommon.DefaultPreferenceEnv],
+		Name:                 metadata.Name,
+		Description:          metadata.Description,
+		Example:              string(example),
+		Image:                image,
+		Instancetype:         metadata.EnvVariables[pkgcommon.DefaultInstancetypeEnv],
+		Preference:           metadata.EnvVariables[pkgcommon.DefaultPreferenceEnv],
+		ImportSize:           importSize(details),
+		GoldenImageManifests: goldenImage,
+		Hints:                metadata.Hints,
+		ReleaseNotes:         metadata.ReleaseNotes,
+		Inventory:            p.InventorySummary,
+		Variant:              string(metadata.Variant),
+		Variants:             p.Variants,
+	}
+	if metadata.AccessCredentials {
+		data.AccessCredentialsUser = metadata.ExampleUserData.Username
+	}
+	if details != nil {
+		for _, disk := range details.AdditionalDisks {
+			data.AdditionalDisks = append(data.AdditionalDisks, docs.AdditionalDisk{
+				Name: disk.Name,
+				Path: build.DiskPath(build.AdditionalDiskFileName(disk.Name)),
+			})
+		}
+		if details.KernelBoot != nil {
+			data.KernelBoot = &docs.KernelBoot{
+				Image:      image + build.KernelBootTagSuffix,
+				KernelPath: build.KernelPath(),
+				InitrdPath: build.InitrdPath(),
+				KernelArgs: details.KernelBoot.KernelArgs,
+			}
+		}
 	}
 
 	var result bytes.Buffer
@@ -160,3 +258,37 @@ func createDescription(artifact api.Artifact, registry string) (string, error) {
 
 	return result.String(), nil
 }
+
+// createGoldenImageManifests renders a DataImportCron and its DataSource, which import the
+// containerdisk published as image as golden image and keep it up to date.
+func createGoldenImageManifests(artifact api.Artifact, details *api.ArtifactDetails, image string) (string, error) {
+	metadata := artifact.Metadata()
+	labels := docs.GoldenImageLabels(
+		metadata.EnvVariables[pkgcommon.DefaultInstancetypeEnv],
+		metadata.EnvVariables[pkgcommon.DefaultPreferenceEnv],
+	)
+	dataImportCron := docs.NewDataImportCron(metadata.Name, docs.GoldenImageNamespace,
+		image, importSize(details), labels)
+	dataSource := docs.NewDataSource(metadata.Name, docs.GoldenImageNamespace, labels)
+
+	var result bytes.Buffer
+	for i, obj := range []interface{}{dataImportCron, dataSource} {
+		manifest, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", fmt.Errorf("error marshaling golden image manifests for %q: %v", metadata.Name, err)
+		}
+		if i > 0 {
+			result.WriteString("---\n")
+		}
+		result.Write(manifest)
+	}
+
+	return result.String(), nil
+}
+
+func importSize(details *api.ArtifactDetails) string {
+	if details == nil {
+		return docs.DefaultImportSize
+	}
+	return docs.ImportSize(details.VirtualSize)
+}
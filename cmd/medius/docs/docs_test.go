@@ -0,0 +1,130 @@
+package docs
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/artifacts/centosstream"
+	"kubevirt.io/containerdisks/artifacts/debian"
+	"kubevirt.io/containerdisks/artifacts/fedora"
+	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/artifacts/haos"
+	"kubevirt.io/containerdisks/artifacts/opensuse/leap"
+	"kubevirt.io/containerdisks/artifacts/opensuse/microos"
+	"kubevirt.io/containerdisks/artifacts/opensuse/tumbleweed"
+	"kubevirt.io/containerdisks/artifacts/openwrt"
+	"kubevirt.io/containerdisks/artifacts/opnsense"
+	"kubevirt.io/containerdisks/artifacts/truenas"
+	"kubevirt.io/containerdisks/artifacts/ubuntu"
+	"kubevirt.io/containerdisks/artifacts/utility"
+	"kubevirt.io/containerdisks/artifacts/virtiowin"
+	medius "kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/common"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/utilitydisk"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+var _ = Describe("Docs", func() {
+	envVariables := func(preference string) map[string]string {
+		return map[string]string{
+			common.DefaultInstancetypeEnv: "u1.medium",
+			common.DefaultPreferenceEnv:   preference,
+		}
+	}
+
+	DescribeTable("createDescription should render stable descriptions",
+		func(artifact api.Artifact) {
+			description, err := createDescription(artifact, nil, "quay.io/containerdisks/"+artifact.Metadata().Describe(), published{})
+			Expect(err).ToNot(HaveOccurred())
+			metadata := artifact.Metadata()
+			testutil.ExpectGolden(fmt.Sprintf("testdata/%s-%s.golden.md", metadata.Name, metadata.Version), []byte(description))
+		},
+		Entry("centos-stream", api.Split(centosstream.New("10", []string{"x86_64"},
+			&docs.UserData{Username: "cloud-user"}, envVariables("centos.stream10")))[0]),
+		Entry("debian", debian.New("13", "trixie", "x86_64", &docs.UserData{Username: "debian"}, envVariables("debian"))),
+		Entry("fedora", fedora.New("43", "x86_64")),
+		Entry("opensuse-leap", leap.New("x86_64", "15.6", envVariables("opensuse.leap"))),
+		Entry("opensuse-microos", microos.New("x86_64", envVariables("opensuse.tumbleweed"))),
+		Entry("opensuse-tumbleweed", tumbleweed.New("x86_64", envVariables("opensuse.tumbleweed"))),
+		Entry("ubuntu", ubuntu.New("24.04", "x86_64", envVariables("ubuntu"))),
+		Entry("virtio-win", virtiowin.New("0.1.271", "1")),
+		Entry("opnsense", opnsense.New("25.7", "nano")),
+		Entry("haos", haos.New("16.2", "x86_64")),
+		Entry("truenas", truenas.New("25.04.2.4", "Fangtooth")),
+		Entry("openwrt", openwrt.New("24.10", "24.10.4")),
+		Entry("utility blank", utility.New("10Gi", utilitydisk.FilesystemNone, "x86_64")),
+		Entry("utility vfat", utility.New("10Gi", utilitydisk.FilesystemVFAT, "x86_64")),
+		Entry("utility swap", utility.New("2Gi", utilitydisk.FilesystemSwap, "x86_64")),
+	)
+
+	It("every provider should have a golden description", func() {
+		for _, provider := range medius.Providers() {
+			// cirros is only published for testing, its description is never pushed
+			if provider.Name == "cirros" {
+				continue
+			}
+			goldens, err := filepath.Glob(fmt.Sprintf("testdata/%s-*.golden.md", provider.Name))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(goldens).ToNot(BeEmpty(), "add an entry rendering the description of %s", provider.Name)
+		}
+	})
+
+	It("createDescription should document additional disks, kernel boot, hints, the inventory and variants", func() {
+		details := &api.ArtifactDetails{
+			DownloadURL:       "https://example.org/appliance.qcow2",
+			ImageArchitecture: "amd64",
+			VirtualSize:       20 * 1024 * 1024 * 1024,
+			AdditionalDisks:   []api.DiskDetails{{Name: "data", DownloadURL: "https://example.org/data.qcow2"}},
+			KernelBoot: &api.KernelBootDetails{
+				Kernel:     api.DiskDetails{DownloadURL: "https://example.org/vmlinuz"},
+				Initrd:     api.DiskDetails{DownloadURL: "https://example.org/initrd.img"},
+				KernelArgs: "console=ttyS0",
+			},
+		}
+		artifact := generic.New(details, &api.Metadata{
+			Name:        "appliance",
+			Version:     "1",
+			Description: "Appliance",
+			Hints:       docs.Hints{RequiresRng: true, MachineType: "q35", RequiresTPM: true},
+		})
+		description, err := createDescription(artifact, details, "quay.io/containerdisks/appliance:1", published{
+			InventorySummary: "kernel 6.9, cloud-init 24.1",
+			Variants:         []docs.Variant{{Name: "minimal", Image: "quay.io/containerdisks/appliance:1-minimal"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		testutil.ExpectGolden("testdata/appliance-1.golden.md", []byte(description))
+	})
+
+	It("createBundle should render a kustomization with optional examples", func() {
+		artifacts := []bundleArtifact{
+			{Artifact: fedora.New("43", "x86_64"), Image: "quay.io/containerdisks/fedora:43"},
+			{Artifact: ubuntu.New("24.04", "x86_64", envVariables("ubuntu")), Image: "quay.io/containerdisks/ubuntu:24.04"},
+		}
+		files, err := createBundle(artifacts, &bundleOptions{Registry: "quay.io/containerdisks", Version: "2406291230", Examples: true})
+		Expect(err).ToNot(HaveOccurred())
+
+		contents := map[string]string{}
+		for _, file := range files {
+			contents[file.Name] = string(file.Data)
+		}
+		Expect(contents).To(HaveKey("fedora.yaml"))
+		Expect(contents).To(HaveKey("examples/ubuntu.yaml"))
+		testutil.ExpectGolden("testdata/bundle/kustomization.yaml", []byte(contents["kustomization.yaml"]))
+		testutil.ExpectGolden("testdata/bundle/examples/kustomization.yaml", []byte(contents["examples/kustomization.yaml"]))
+
+		files, err = createBundle(artifacts, &bundleOptions{Registry: "quay.io/containerdisks", Version: "2406291230"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(3))
+	})
+})
+
+func TestDocs(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Docs Suite")
+}
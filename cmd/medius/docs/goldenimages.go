@@ -0,0 +1,135 @@
+package docs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/ociartifact"
+	"kubevirt.io/containerdisks/pkg/repository"
+)
+
+const (
+	// GoldenImageMediaType is the media type of the golden image manifests in the OCI artifact bundle.
+	GoldenImageMediaType types.MediaType = "application/vnd.kubevirt.containerdisks.golden-image.v1+yaml"
+	// GoldenImagesConfigMediaType is the config media type of the golden images OCI artifact bundle.
+	GoldenImagesConfigMediaType types.MediaType = "application/vnd.kubevirt.containerdisks.golden-images.config.v1+json"
+)
+
+type goldenImagesOptions struct {
+	Registry  string
+	OutputDir string
+	PushRef   string
+}
+
+func NewGoldenImagesCommand(options *common.Options) *cobra.Command {
+	goldenImagesOptions := &goldenImagesOptions{
+		Registry:  "quay.io/containerdisks",
+		OutputDir: "golden-images",
+	}
+
+	goldenImagesCmd := &cobra.Command{
+		Use:   "golden-images",
+		Short: "Generate DataImportCron and DataSource manifests importing the containerdisks as golden images",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGoldenImages(cmd, options, goldenImagesOptions)
+		},
+	}
+	goldenImagesCmd.Flags().StringVar(&goldenImagesOptions.Registry, "registry",
+		goldenImagesOptions.Registry, "Registry the containerdisks are published in")
+	goldenImagesCmd.Flags().StringVar(&goldenImagesOptions.OutputDir, "output-dir",
+		goldenImagesOptions.OutputDir, "Directory to write the manifests to, empty to skip writing them")
+	goldenImagesCmd.Flags().StringVar(&goldenImagesOptions.PushRef, "push",
+		goldenImagesOptions.PushRef, "Reference to push the manifests to as OCI artifact bundle, e.g. quay.io/containerdisks/golden-images:latest")
+
+	return goldenImagesCmd
+}
+
+func runGoldenImages(cmd *cobra.Command, options *common.Options, goldenImagesOptions *goldenImagesOptions) error {
+	var files []ociartifact.File
+	registry := common.NewRegistry()
+	for i, p := range registry {
+		if common.ShouldSkip(options.Focus, &registry[i]) || !p.UseForDocs {
+			continue
+		}
+
+		artifact, details, err := getPreferredArtifact(p.Artifacts)
+		if err != nil {
+			return fmt.Errorf("error getting artifact: %v", err)
+		}
+		image := p.Image(goldenImagesOptions.Registry)
+		if details.VirtualSize == 0 {
+			details.VirtualSize = lookupVirtualSize(image, details.ImageArchitecture, options.AllowInsecureRegistry)
+		}
+
+		manifests, err := createGoldenImageManifests(artifact, details, image)
+		if err != nil {
+			return err
+		}
+		files = append(files, ociartifact.File{
+			Name:      artifact.Metadata().Name + ".yaml",
+			MediaType: GoldenImageMediaType,
+			Data:      []byte(manifests),
+		})
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no artifact was processed, focus '%s' did not match", options.Focus)
+	}
+
+	return publishGoldenImages(cmd, options, goldenImagesOptions, files)
+}
+
+func publishGoldenImages(cmd *cobra.Command, options *common.Options, goldenImagesOptions *goldenImagesOptions,
+	files []ociartifact.File,
+) error {
+	var errs []error
+	if goldenImagesOptions.OutputDir != "" {
+		logrus.Infof("Writing golden image manifests to %s", goldenImagesOptions.OutputDir)
+		if err := writeFiles(goldenImagesOptions.OutputDir, files); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if goldenImagesOptions.PushRef != "" {
+		if options.DryRun {
+			logrus.Infof("Dry run enabled, not pushing golden image manifests to %s", goldenImagesOptions.PushRef)
+			return errors.Join(errs...)
+		}
+
+		bundle, err := ociartifact.New(GoldenImagesConfigMediaType, files...)
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		logrus.Infof("Pushing golden image manifests to %s", goldenImagesOptions.PushRef)
+		if err := repository.New().PushImage(cmd.Context(), bundle, goldenImagesOptions.PushRef); err != nil {
+			errs = append(errs, fmt.Errorf("error pushing golden image manifests: %v", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func writeFiles(dir string, files []ociartifact.File) error {
+	const (
+		permissionDir  = 0o755
+		permissionFile = 0o644
+	)
+	for _, file := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(file.Name))
+		if err := os.MkdirAll(filepath.Dir(filePath), permissionDir); err != nil {
+			return fmt.Errorf("error creating directory %s: %v", filepath.Dir(filePath), err)
+		}
+		if err := os.WriteFile(filePath, file.Data, permissionFile); err != nil {
+			return fmt.Errorf("error writing %s: %v", file.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"kubevirt.io/containerdisks/pkg/doctor"
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+// defaultHosts are the registry containerdisks are published to and the upstream hosts of the providers
+var defaultHosts = []string{
+	"quay.io",
+	"getfedora.org",
+	"dl.fedoraproject.org",
+	"cloud.centos.org",
+	"cloud.debian.org",
+	"cloud-images.ubuntu.com",
+	"download.opensuse.org",
+}
+
+type doctorOptions struct {
+	Hosts       []string
+	RequireIPv6 bool
+}
+
+func NewDoctorCommand() *cobra.Command {
+	doctorOptions := &doctorOptions{
+		Hosts: defaultHosts,
+	}
+
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that the registry and upstream hosts can be reached over IPv4 and IPv6",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd, doctorOptions)
+		},
+	}
+	doctorCmd.Flags().StringSliceVar(&doctorOptions.Hosts, "host",
+		doctorOptions.Hosts, "Hosts to check, optionally with port, the mirrors of MEDIUS_HTTP_OVERRIDES are checked as well")
+	doctorCmd.Flags().BoolVar(&doctorOptions.RequireIPv6, "require-ipv6",
+		doctorOptions.RequireIPv6, "Fail if a host can't be reached over IPv6, e.g. to prepare a move to an IPv6-only network")
+
+	return doctorCmd
+}
+
+func run(cmd *cobra.Command, doctorOptions *doctorOptions) error {
+	hosts := slices.Clone(doctorOptions.Hosts)
+	overrides, err := http.OverridesFromEnv()
+	if err != nil {
+		return err
+	}
+	for _, override := range overrides {
+		if u, err := url.Parse(override.Mirror); err == nil && !slices.Contains(hosts, u.Host) {
+			hosts = append(hosts, u.Host)
+		}
+	}
+
+	checker := doctor.NewChecker()
+	results := make([]doctor.Result, 0, len(hosts))
+	var errs []error
+	for _, host := range hosts {
+		result := checker.Check(cmd.Context(), host)
+		results = append(results, result)
+		switch {
+		case !result.Reachable():
+			errs = append(errs, fmt.Errorf("%s is not reachable", host))
+		case !result.IPv6.Reachable && doctorOptions.RequireIPv6:
+			errs = append(errs, fmt.Errorf("%s is not reachable over IPv6", host))
+		case len(result.IPv6.Addresses) == 0:
+			logrus.Warnf("%s has no IPv6 address, configure a fallback mirror for IPv6-only networks", host)
+		}
+	}
+
+	if _, err := os.Stdout.WriteString(doctor.Markdown(results)); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
@@ -4,13 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"kubevirt.io/containerdisks/cmd/medius/common"
 	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/progress"
+	"kubevirt.io/containerdisks/pkg/runlock"
 )
 
 const (
@@ -24,10 +29,37 @@ type workerResult struct {
 	Value api.ArtifactResult
 }
 
-func spawnWorkers(ctx context.Context, o *common.Options,
-	fn func(*common.Entry) (*api.ArtifactResult, error),
+// spawnWorkers runs fn for all focused entries of the registry. Runs moving tags pass the registry they publish
+// to as lockRegistry, so concurrent runs can't publish to the same repositories. If the run can't be started,
+// e.g. because the registry can't be loaded or its repositories are locked by another run, resultsChan is nil
+// and err tells why.
+func spawnWorkers(ctx context.Context, o *common.Options, lockRegistry string,
+	fn func(context.Context, *common.Entry) (*api.ArtifactResult, error),
 ) (matched bool, resultsChan chan workerResult, err error) {
-	registry := common.NewRegistry()
+	registry, err := loadRegistry(o)
+	if err != nil {
+		return false, nil, err
+	}
+	var focused []*common.Entry
+	for i := range registry {
+		// Archived registries only contain the explicitly requested version
+		if o.ImagesOptions.Archived() || !common.ShouldSkip(o.Focus, &registry[i]) {
+			focused = append(focused, &registry[i])
+		}
+	}
+	if lockRegistry != "" && o.ImagesOptions.RunLockDir != "" {
+		var unlock func()
+		if ctx, unlock, err = lockRepositories(ctx, o, lockRegistry, focused); err != nil {
+			return false, nil, err
+		}
+		defer unlock()
+		defer func() {
+			// Workers ignore canceled contexts, a lost lease has to fail the run nevertheless
+			if lost := context.Cause(ctx); err == nil && lost != nil && !errors.Is(lost, context.Canceled) {
+				err = lost
+			}
+		}()
+	}
 	count := len(registry)
 	errChan := make(chan error, count)
 	jobChan := make(chan *common.Entry, count)
@@ -39,6 +71,16 @@ func spawnWorkers(ctx context.Context, o *common.Options,
 		o.ImagesOptions.Workers = count
 	}
 
+	tracker := o.Progress
+	if tracker != nil {
+		stop := showProgress(ctx, tracker)
+		defer stop()
+	} else if o.ImagesOptions.ProgressInterval > 0 {
+		tracker = progress.NewTracker()
+		stop := logProgress(ctx, tracker, o.ImagesOptions.ProgressInterval)
+		defer stop()
+	}
+
 	wg := &sync.WaitGroup{}
 	wg.Add(o.ImagesOptions.Workers)
 	for x := 0; x < o.ImagesOptions.Workers; x++ {
@@ -46,8 +88,16 @@ func spawnWorkers(ctx context.Context, o *common.Options,
 			defer wg.Done()
 			for e := range jobChan {
 				artifact := e.Artifacts[0]
-				result, workerErr := fn(e)
+				jobCtx := ctx
+				var reporter *progress.Reporter
+				if tracker != nil {
+					reporter = tracker.Start(artifact.Metadata().Describe())
+					jobCtx = progress.WithReporter(ctx, reporter)
+				}
+				result, workerErr := fn(jobCtx, e)
+				reporter.SetState(finalState(result, workerErr))
 				if result != nil {
+					result.Maintainers = artifact.Metadata().Maintainers
 					resultsChan <- workerResult{
 						Key:   artifact.Metadata().Describe(),
 						Value: *result,
@@ -64,11 +114,9 @@ func spawnWorkers(ctx context.Context, o *common.Options,
 		}()
 	}
 
-	for i := range registry {
-		if !common.ShouldSkip(o.Focus, &registry[i]) {
-			jobChan <- &registry[i]
-			matched = true
-		}
+	for _, e := range focused {
+		jobChan <- e
+		matched = true
 	}
 	close(jobChan)
 
@@ -82,6 +130,149 @@ func spawnWorkers(ctx context.Context, o *common.Options,
 	}
 }
 
+// lockRepositories locks the repositories of the entries in registry for the run. The returned context is
+// canceled if the leases are lost, e.g. because another run unlocked them forcibly.
+func lockRepositories(ctx context.Context, o *common.Options, registry string, entries []*common.Entry,
+) (context.Context, func(), error) {
+	repositories := make([]string, 0, len(entries))
+	for _, e := range entries {
+		repositories = append(repositories, e.Repository(registry))
+	}
+	locks := runlock.New(o.ImagesOptions.RunLockDir, runlock.Holder(), repositories, o.ImagesOptions.RunLockTTL)
+
+	if o.ImagesOptions.ForceUnlock {
+		removed, err := locks.ForceUnlock()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, lease := range removed {
+			logrus.Warnf("Removed the lease of %s on %s, acquired %s", lease.Holder, lease.Repository,
+				lease.Acquired.Format(time.RFC3339))
+		}
+	}
+	if err := locks.Acquire(time.Now()); err != nil {
+		return nil, nil, fmt.Errorf("%v, use --force-unlock if the run holding it was killed", err)
+	}
+
+	lockedCtx, cancel := context.WithCancelCause(ctx)
+	stop := locks.Keep(lockedCtx, func(err error) {
+		logrus.WithError(err).Error("Lost the run lock, stopping the run")
+		cancel(fmt.Errorf("lost the run lock: %v", err))
+	})
+	return lockedCtx, func() {
+		stop()
+		cancel(nil)
+		if err := locks.Release(); err != nil {
+			logrus.WithError(err).Warn("Failed to release the run lock")
+		}
+	}, nil
+}
+
+// progressInterval is the time between two redraws of the progress
+const progressInterval = 500 * time.Millisecond
+
+// showProgress draws the progress of all artifacts on the terminal and shows logs below it, until stop is called.
+func showProgress(ctx context.Context, tracker *progress.Tracker) (stop func()) {
+	logrus.SetOutput(tracker)
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tracker.Run(runCtx, os.Stderr, progressInterval)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		logrus.SetOutput(os.Stderr)
+	}
+}
+
+// logProgress logs the transfers of all artifacts which are in progress every interval, until stop is called.
+func logProgress(ctx context.Context, tracker *progress.Tracker, interval time.Duration) (stop func()) {
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				for _, status := range tracker.Snapshot() {
+					logStatus(&status)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func logStatus(status *progress.Status) {
+	fields := logrus.Fields{"artifact": status.Name, "state": status.State}
+	active := false
+	for kind, transfer := range map[string]*progress.Transfer{
+		"download": &status.Download, "hash": &status.Hash, "upload": &status.Upload,
+	} {
+		if !transfer.Active() {
+			continue
+		}
+		active = true
+		fields[kind+"_bytes"] = transfer.Done
+		if transfer.Total > 0 {
+			fields[kind+"_total"] = transfer.Total
+		}
+		fields[kind+"_rate"] = progress.FormatRate(transfer.Rate)
+		if transfer.ETA > 0 {
+			fields[kind+"_eta"] = transfer.ETA.String()
+		}
+	}
+	if active {
+		logrus.WithFields(fields).Info("Progress")
+	}
+}
+
+func finalState(result *api.ArtifactResult, err error) string {
+	switch {
+	case err != nil:
+		return "failed"
+	case result == nil:
+		return "nothing to do"
+	default:
+		return "done"
+	}
+}
+
+// loadRegistry returns the registry of current containerdisks or, if a version or snapshots are requested,
+// the archived registry of the focused containerdisk.
+// newRegistry gathers the current registry, it is replaced by tests.
+var newRegistry = common.NewRegistry
+
+func loadRegistry(o *common.Options) ([]common.Entry, error) {
+	if !o.ImagesOptions.Archived() {
+		return newRegistry(), nil
+	}
+
+	name, _, _ := strings.Cut(o.Focus, ":")
+	if name == "" {
+		return nil, errors.New("publishing a historical version requires a focus on a containerdisk name")
+	}
+	if o.ImagesOptions.Version != "" && o.ImagesOptions.Snapshots > 0 {
+		return nil, errors.New("a historical version and snapshots can't be published at the same time")
+	}
+	if o.ImagesOptions.Snapshots > 0 {
+		return common.NewSnapshotRegistry(name, o.ImagesOptions.Snapshots)
+	}
+
+	return common.NewArchivedRegistry(name, o.ImagesOptions.Version)
+}
+
 func writeResultsFile(fileName string, results map[string]api.ArtifactResult) error {
 	logrus.Info("Writing results file")
 
@@ -0,0 +1,92 @@
+package images
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+var distroEntries = []*common.Entry{{
+	Artifacts: []api.Artifact{generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: "distro", Version: "1"})},
+}}
+
+var _ = Describe("LockRepositories", func() {
+	var options *common.Options
+
+	BeforeEach(func() {
+		options = &common.Options{ImagesOptions: common.ImagesOptions{
+			RunLockDir: GinkgoT().TempDir(),
+			RunLockTTL: 30 * time.Millisecond,
+		}}
+	})
+
+	It("should refuse concurrent runs on the same repositories", func() {
+		_, unlock, err := lockRepositories(context.Background(), options, "registry.example.com", distroEntries)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, _, err = lockRepositories(context.Background(), options, "registry.example.com", distroEntries)
+		Expect(err).To(MatchError(ContainSubstring("use --force-unlock")))
+
+		unlock()
+		_, unlock, err = lockRepositories(context.Background(), options, "registry.example.com", distroEntries)
+		Expect(err).ToNot(HaveOccurred())
+		unlock()
+	})
+
+	It("should stop runs whose locks were removed with --force-unlock", func() {
+		ctx, unlock, err := lockRepositories(context.Background(), options, "registry.example.com", distroEntries)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(unlock)
+
+		forced := *options
+		forced.ImagesOptions.ForceUnlock = true
+		_, forcedUnlock, err := lockRepositories(context.Background(), &forced, "registry.example.com", distroEntries)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(forcedUnlock)
+
+		Eventually(ctx.Done()).Should(BeClosed())
+		Expect(context.Cause(ctx)).To(MatchError(ContainSubstring("lost the run lock")))
+	})
+})
+
+var _ = Describe("SpawnWorkers", func() {
+	It("should return the error of a registry which can't be loaded", func() {
+		options := &common.Options{ImagesOptions: common.ImagesOptions{Version: "39-1.5", Workers: 1}}
+		matched, resultsChan, err := spawnWorkers(context.Background(), options, "",
+			func(context.Context, *common.Entry) (*api.ArtifactResult, error) {
+				Fail("no worker should run")
+				return nil, nil
+			})
+		Expect(err).To(MatchError(ContainSubstring("requires a focus on a containerdisk name")))
+		Expect(matched).To(BeFalse())
+		Expect(resultsChan).To(BeNil())
+	})
+
+	It("should return the error of repositories locked by another run", func() {
+		options := &common.Options{ImagesOptions: common.ImagesOptions{
+			RunLockDir: GinkgoT().TempDir(),
+			RunLockTTL: time.Minute,
+			Workers:    1,
+		}}
+		newRegistry = func() []common.Entry { return []common.Entry{*distroEntries[0]} }
+		DeferCleanup(func() { newRegistry = common.NewRegistry })
+
+		_, unlock, err := lockRepositories(context.Background(), options, "registry.example.com", distroEntries)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(unlock)
+
+		_, resultsChan, err := spawnWorkers(context.Background(), options, "registry.example.com",
+			func(context.Context, *common.Entry) (*api.ArtifactResult, error) {
+				Fail("no worker should run")
+				return nil, nil
+			})
+		Expect(err).To(MatchError(ContainSubstring("use --force-unlock")))
+		Expect(resultsChan).To(BeNil())
+	})
+})
@@ -0,0 +1,55 @@
+package images
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash"
+
+	"kubevirt.io/containerdisks/pkg/hashsum"
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+// crossCheckingReader computes the digests of the cross checksums of a download while it is read, so the
+// download is verified against all checksum files of upstream without being read twice.
+type crossCheckingReader struct {
+	http.ReadCloserWithChecksum
+	checksums []hashsum.Checksum
+	hashes    []hash.Hash
+}
+
+// newCrossCheckingReader skips checksums equal to the primary one, they are verified by the download already.
+func newCrossCheckingReader(readCloser http.ReadCloserWithChecksum, primary string, checksums []hashsum.Checksum) (
+	*crossCheckingReader, error,
+) {
+	r := &crossCheckingReader{ReadCloserWithChecksum: readCloser}
+	for _, checksum := range checksums {
+		if checksum.Value == primary {
+			continue
+		}
+		newHash, err := checksum.Algorithm.New()
+		if err != nil {
+			return nil, err
+		}
+		r.checksums = append(r.checksums, checksum)
+		r.hashes = append(r.hashes, newHash())
+	}
+	return r, nil
+}
+
+func (r *crossCheckingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloserWithChecksum.Read(p)
+	for _, h := range r.hashes {
+		_, _ = h.Write(p[:n])
+	}
+	return n, err
+}
+
+// verify fails if the download does not match any of the cross checksums.
+func (r *crossCheckingReader) verify() error {
+	for i, checksum := range r.checksums {
+		if actual := hex.EncodeToString(r.hashes[i].Sum(nil)); actual != checksum.Value {
+			return fmt.Errorf("expected %s checksum %q from %s but got %q", checksum.Algorithm, checksum.Value, checksum.Source, actual)
+		}
+	}
+	return nil
+}
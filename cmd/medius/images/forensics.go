@@ -0,0 +1,64 @@
+package images
+
+import (
+	"hash"
+	"io"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/forensics"
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+// chunkRecordingReader records the chunk digests of a download while it is read.
+type chunkRecordingReader struct {
+	http.ReadCloserWithChecksum
+	chunks *forensics.Chunks
+}
+
+func newChunkRecordingReader(readCloser http.ReadCloserWithChecksum) *chunkRecordingReader {
+	return &chunkRecordingReader{ReadCloserWithChecksum: readCloser, chunks: forensics.NewChunks()}
+}
+
+func (r *chunkRecordingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloserWithChecksum.Read(p)
+	_, _ = r.chunks.Write(p[:n])
+	return n, err
+}
+
+// investigateMismatch downloads a disk whose checksum did not match a second time and reports what is known
+// about both downloads, so the cause of the mismatch can be told from the run report.
+func (b *buildAndPublish) investigateMismatch(disk *api.DiskDetails, actual string, first *chunkRecordingReader) {
+	report := forensics.Report{
+		URL:      disk.DownloadURL,
+		Expected: disk.Checksum,
+		Actual:   actual,
+	}
+	if info := http.ResponseInfoOf(first.ReadCloserWithChecksum); info != nil {
+		report.SetResponse(info.URL, info.RemoteAddr, info.Header)
+	}
+
+	b.Log.Warnf("Checksum mismatch, downloading %q again to investigate ...", disk.DownloadURL)
+	second, redownloaded, err := b.redownload(disk.DownloadURL, disk.ChecksumHash)
+	if err != nil {
+		report.RedownloadError = err.Error()
+	}
+	report.Redownloaded = redownloaded
+	report.Classify(disk.ChecksumHash, first.chunks, second)
+
+	b.Log.WithField("verdict", report.Verdict).WithField("ranges", report.DifferingRanges).Warn("Checksum mismatch investigated")
+	b.Forensics = append(b.Forensics, report)
+}
+
+func (b *buildAndPublish) redownload(downloadURL string, checksumHash func() hash.Hash) (*forensics.Chunks, string, error) {
+	readCloser, err := b.Getter.GetWithChecksumAndContext(b.Ctx, downloadURL, checksumHash)
+	if err != nil {
+		return nil, "", err
+	}
+	defer readCloser.Close()
+
+	chunks := forensics.NewChunks()
+	if _, err := io.Copy(chunks, readCloser); err != nil {
+		return nil, "", err
+	}
+	return chunks, readCloser.Checksum(), nil
+}
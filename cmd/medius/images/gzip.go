@@ -0,0 +1,62 @@
+package images
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// gzipReader decompresses all members of a gzip stream like gzip(1) does, including its tolerance for trailing
+// data after the last member, e.g. the metadata OpenWrt appends to its images. The trailing data is read anyway,
+// so the checksum of the download covers the whole file.
+type gzipReader struct {
+	compressed   *bufio.Reader
+	decompressed *gzip.Reader
+	done         bool
+}
+
+func newGzipReader(r io.Reader) (*gzipReader, error) {
+	compressed := bufio.NewReader(r)
+	decompressed, err := gzip.NewReader(compressed)
+	if err != nil {
+		return nil, err
+	}
+	decompressed.Multistream(false)
+	return &gzipReader{compressed: compressed, decompressed: decompressed}, nil
+}
+
+func (g *gzipReader) Read(p []byte) (int, error) {
+	for !g.done {
+		n, err := g.decompressed.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+		if err := g.nextMember(); err != nil {
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+	return 0, io.EOF
+}
+
+// nextMember continues with the next member of the stream, or drains the trailing data if there is none.
+func (g *gzipReader) nextMember() error {
+	err := g.decompressed.Reset(g.compressed)
+	switch {
+	case err == io.EOF:
+		g.done = true
+		return nil
+	case errors.Is(err, gzip.ErrHeader):
+		g.done = true
+		_, err = io.Copy(io.Discard, g.compressed)
+		return err
+	case err != nil:
+		return err
+	}
+	// Reset enables multistream mode again
+	g.decompressed.Multistream(false)
+	return nil
+}
@@ -0,0 +1,87 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/harbor"
+)
+
+const stepHarbor = "harbor"
+
+// defaultHarborReplicationTimeout is the time waited for replication policies if no timeout is configured
+const defaultHarborReplicationTimeout = 30 * time.Minute
+
+// newHarborClient returns the client of the Harbor instance serving a registry host, it is replaced by tests.
+var newHarborClient = harbor.NewClient
+
+func harborEnabled(options *common.PublishImageOptions) bool {
+	return options.Harbor || len(options.HarborLabels) > 0 || len(options.HarborReplicationPolicies) > 0
+}
+
+// skipExistingUniqueTags drops the names whose tag is unique to a version and exists already. Harbor rejects
+// pushing them again if a tag immutability rule protects them, while their content would not change anyway.
+// The first name is unique to the build and always pushed.
+func (b *buildAndPublish) skipExistingUniqueTags(names, uniqueTags []string) ([]string, error) {
+	if len(names) == 0 {
+		return names, nil
+	}
+
+	repo := names[0][:strings.LastIndex(names[0], ":")]
+	existing, err := b.Repo.ListTags(b.Ctx, repo, b.Options.AllowInsecureRegistry)
+	if err != nil {
+		return nil, fmt.Errorf("error listing the tags of %q: %v", repo, err)
+	}
+
+	kept := []string{names[0]}
+	for _, name := range names[1:] {
+		tag := name[strings.LastIndex(name, ":")+1:]
+		if slices.Contains(uniqueTags, tag) && slices.Contains(existing, tag) {
+			b.Log.Infof("Unique tag %s exists already, not pushing it again", name)
+			continue
+		}
+		kept = append(kept, name)
+	}
+	return kept, nil
+}
+
+// publishToHarbor labels the containerdisk pushed to name and waits for the replication policies to copy it
+// to the replicas, which started after since.
+func (b *buildAndPublish) publishToHarbor(name string, since time.Time) error {
+	options := &b.Options.PublishImagesOptions
+	if b.Options.DryRun {
+		b.Log.Infof("Dry run enabled, not labeling and replicating %s in Harbor", name)
+		return nil
+	}
+
+	repo, err := harbor.ParseRepository(name[:strings.LastIndex(name, ":")])
+	if err != nil {
+		return err
+	}
+	client := newHarborClient(repo.Host)
+
+	tag := name[strings.LastIndex(name, ":")+1:]
+	for _, label := range options.HarborLabels {
+		if err := client.AddLabel(b.Ctx, repo, tag, label); err != nil {
+			return fmt.Errorf("error adding label %q to %s: %v", label, name, err)
+		}
+	}
+
+	timeout := options.HarborReplicationTimeout
+	if timeout == 0 {
+		timeout = defaultHarborReplicationTimeout
+	}
+	ctx, cancel := context.WithTimeout(b.Ctx, timeout)
+	defer cancel()
+	for _, policy := range options.HarborReplicationPolicies {
+		b.Log.Infof("Waiting for Harbor replication policy %q", policy)
+		if err := client.WaitForReplication(ctx, policy, since); err != nil {
+			return err
+		}
+	}
+	return nil
+}
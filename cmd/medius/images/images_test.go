@@ -0,0 +1,616 @@
+package images
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	kvirtv1 "kubevirt.io/api/core/v1"
+
+	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/boottime"
+	"kubevirt.io/containerdisks/pkg/build"
+	pkgcommon "kubevirt.io/containerdisks/pkg/common"
+	"kubevirt.io/containerdisks/pkg/forensics"
+	"kubevirt.io/containerdisks/pkg/harbor"
+	"kubevirt.io/containerdisks/pkg/hashsum"
+	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/inventory"
+	"kubevirt.io/containerdisks/pkg/lockfile"
+	"kubevirt.io/containerdisks/pkg/metrics"
+	"kubevirt.io/containerdisks/pkg/repository"
+	"kubevirt.io/containerdisks/pkg/tagpolicy"
+)
+
+var _ = Describe("Images", func() {
+	timestamp := time.Date(2024, 6, 29, 12, 30, 0, 0, time.UTC)
+
+	DescribeTable("prepareTags should apply the tag policy",
+		func(scheme tagpolicy.Scheme, variant api.Variant, uniqueTags []string, latest bool, expected []string) {
+			details := &api.ArtifactDetails{AdditionalUniqueTags: uniqueTags}
+			entry := &common.Entry{
+				Artifacts: []api.Artifact{
+					generic.New(details, &api.Metadata{Name: "distro", Version: "1.2", TagScheme: scheme, Variant: variant}),
+				},
+				UseForLatest: latest,
+			}
+			Expect(prepareTags(timestamp, "registry", entry, details)).To(Equal(expected))
+		},
+		Entry("exact", tagpolicy.SchemeExact, api.VariantDefault, []string{"1.2-20240629"}, false, []string{
+			"registry/distro:1.2-2406291230",
+			"registry/distro:1.2-20240629",
+			"registry/distro:1.2",
+		}),
+		Entry("semver with latest", tagpolicy.SchemeSemver, api.VariantDefault, []string{"1.2.3"}, true, []string{
+			"registry/distro:1.2-2406291230",
+			"registry/distro:1.2.3",
+			"registry/distro:1.2",
+			"registry/distro:1",
+			"registry/distro:latest",
+		}),
+		Entry("semver variant with latest", tagpolicy.SchemeSemver, api.VariantMinimal, []string{"1.2.3"}, true, []string{
+			"registry/distro:1.2-2406291230-minimal",
+			"registry/distro:1.2.3-minimal",
+			"registry/distro:1.2-minimal",
+			"registry/distro:1-minimal",
+			"registry/distro:latest-minimal",
+		}),
+	)
+
+	It("floatingTags should leave out the version-pinned tags", func() {
+		tags := []string{
+			"distro:1.2.3-2406291230",
+			"distro:1.2.3",
+			"distro:1.2",
+			"distro:1",
+			"distro:latest",
+		}
+		Expect(floatingTags(tags, []string{"1.2.3"})).To(Equal([]string{"distro:1.2", "distro:1", "distro:latest"}))
+		Expect(floatingTags(tags[:1], nil)).To(BeEmpty())
+	})
+
+	It("publish should push single images, manifest lists or nothing", func() {
+		repo := &fakeRepository{}
+		b := buildAndPublish{
+			Ctx:     context.Background(),
+			Log:     logrus.NewEntry(logrus.StandardLogger()),
+			Options: &common.Options{},
+			Repo:    repo,
+		}
+
+		for name, images := range map[string][]v1.Image{
+			"single":   {empty.Image},
+			"multiple": {empty.Image, empty.Image},
+			"none":     nil,
+		} {
+			p, err := assemble(images)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p.empty()).To(Equal(len(images) == 0))
+			Expect(b.publish(&p, name)).To(Succeed())
+		}
+		Expect(repo.pushed).To(ConsistOf("image single", "index multiple"))
+	})
+
+	It("tag should only put the manifests of single images or manifest lists", func() {
+		repo := &fakeRepository{}
+		b := buildAndPublish{
+			Ctx:     context.Background(),
+			Log:     logrus.NewEntry(logrus.StandardLogger()),
+			Options: &common.Options{},
+			Repo:    repo,
+		}
+
+		Expect(b.tag(&publishable{image: empty.Image}, "distro:1")).To(Succeed())
+		Expect(b.tag(&publishable{index: empty.Index}, "distro:latest")).To(Succeed())
+		Expect(b.tag(&publishable{}, "distro:none")).To(Succeed())
+		Expect(repo.pushed).To(Equal([]string{"tag distro:1", "tag distro:latest"}))
+
+		b.Options.DryRun = true
+		Expect(b.tag(&publishable{image: empty.Image}, "distro:2")).To(Succeed())
+		Expect(repo.pushed).To(HaveLen(2))
+	})
+
+	It("checkDigests should flag tags referencing other digests", func() {
+		containerDisk := publishable{image: empty.Image}
+		chunked := publishable{index: empty.Index}
+		imageDigest, err := containerDisk.digest()
+		Expect(err).ToNot(HaveOccurred())
+		indexDigest, err := chunked.digest()
+		Expect(err).ToNot(HaveOccurred())
+
+		repo := &fakeRepository{digests: map[string]string{
+			"distro:1-2406291230":                          imageDigest,
+			"distro:1-2406291230" + build.ChunkedTagSuffix: indexDigest,
+			"distro:1":                          imageDigest,
+			"distro:1" + build.ChunkedTagSuffix: indexDigest,
+		}}
+		b := buildAndPublish{
+			Ctx:     context.Background(),
+			Log:     logrus.NewEntry(logrus.StandardLogger()),
+			Options: &common.Options{},
+			Repo:    repo,
+		}
+		names := []string{"distro:1-2406291230", "distro:1"}
+		Expect(b.checkDigests(names, &containerDisk, &chunked, &publishable{})).To(Succeed())
+
+		repo.digests["distro:1"] = "sha256:stale"
+		Expect(b.checkDigests(names, &containerDisk, &chunked, &publishable{})).To(MatchError(
+			"tags reference unexpected digests: distro:1 references sha256:stale instead of " + imageDigest))
+	})
+
+	It("skipExistingUniqueTags should only push new unique tags and all floating tags", func() {
+		b := buildAndPublish{
+			Ctx:     context.Background(),
+			Log:     logrus.NewEntry(logrus.StandardLogger()),
+			Options: &common.Options{},
+			Repo:    &fakeRepository{tags: map[string][]string{"harbor.example.com/containerdisks/distro": {"1", "1-20240629", "latest"}}},
+		}
+		names := []string{
+			"harbor.example.com/containerdisks/distro:1-2407011200",
+			"harbor.example.com/containerdisks/distro:1-20240629",
+			"harbor.example.com/containerdisks/distro:1-20240701",
+			"harbor.example.com/containerdisks/distro:1",
+			"harbor.example.com/containerdisks/distro:latest",
+		}
+		kept, err := b.skipExistingUniqueTags(names, []string{"1-20240629", "1-20240701"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(kept).To(Equal([]string{
+			"harbor.example.com/containerdisks/distro:1-2407011200",
+			"harbor.example.com/containerdisks/distro:1-20240701",
+			"harbor.example.com/containerdisks/distro:1",
+			"harbor.example.com/containerdisks/distro:latest",
+		}))
+	})
+
+	It("publishToHarbor should label the pushed containerdisk", func() {
+		var labeled []string
+		server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			switch r.URL.Path {
+			case "/api/v2.0/projects/containerdisks":
+				_, _ = w.Write([]byte(`{"project_id": 1}`))
+			case "/api/v2.0/labels":
+				_, _ = w.Write([]byte(`[{"id": 5, "name": "` + r.URL.Query().Get("name") + `"}]`))
+			default:
+				labeled = append(labeled, r.Method+" "+r.URL.Path)
+			}
+		}))
+		DeferCleanup(server.Close)
+		DeferCleanup(func(newClient func(string) *harbor.Client) { newHarborClient = newClient }, newHarborClient)
+		newHarborClient = func(host string) *harbor.Client {
+			Expect(host).To(Equal("harbor.example.com"))
+			return &harbor.Client{BaseURL: server.URL + "/api/v2.0"}
+		}
+
+		b := buildAndPublish{
+			Ctx:     context.Background(),
+			Log:     logrus.NewEntry(logrus.StandardLogger()),
+			Options: &common.Options{PublishImagesOptions: common.PublishImageOptions{HarborLabels: []string{"verified"}}},
+		}
+		Expect(b.publishToHarbor("harbor.example.com/containerdisks/distro:1-2407011200", time.Now())).To(Succeed())
+		Expect(labeled).To(Equal([]string{"POST /api/v2.0/projects/containerdisks/repositories/distro/artifacts/1-2407011200/labels"}))
+	})
+
+	It("pushInventories should attach inventories as referrers", func() {
+		repo := &fakeRepository{}
+		b := buildAndPublish{
+			Ctx:     context.Background(),
+			Log:     logrus.NewEntry(logrus.StandardLogger()),
+			Options: &common.Options{},
+			Repo:    repo,
+		}
+		Expect(b.pushInventories("registry.example.com/distro:1")).To(Succeed())
+		Expect(repo.pushed).To(BeEmpty())
+
+		b.Inventories = []imageInventory{{image: empty.Image, inventory: &inventory.Inventory{Kernel: "6.9.4"}}}
+		Expect(b.pushInventories("registry.example.com/distro:1")).To(Succeed())
+		Expect(repo.pushed).To(ConsistOf(MatchRegexp(`^image registry\.example\.com/distro@sha256:[0-9a-f]{64}$`)))
+	})
+
+	It("checkGuestAgent should warn about disks without qemu-guest-agent", func() {
+		b := buildAndPublish{
+			Ctx:     context.Background(),
+			Log:     logrus.NewEntry(logrus.StandardLogger()),
+			Options: &common.Options{},
+		}
+		withAgent := &inventory.Inventory{Packages: []inventory.Package{{Name: inventory.GuestAgentPackage}}}
+		got, err := b.checkGuestAgent("disk.qcow2", withAgent, &api.Metadata{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(got).To(BeIdenticalTo(withAgent))
+		Expect(b.Warnings).To(BeEmpty())
+
+		withoutAgent := &inventory.Inventory{}
+		got, err = b.checkGuestAgent("disk.qcow2", withoutAgent, &api.Metadata{AccessCredentials: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(got).To(BeIdenticalTo(withoutAgent))
+		Expect(b.Warnings).To(ConsistOf(And(
+			ContainSubstring("no qemu-guest-agent"),
+			ContainSubstring("declares support for accessCredentials"),
+		)))
+	})
+
+	It("checkDrift should warn about architectures with differing kernels", func() {
+		b := buildAndPublish{
+			Log: logrus.NewEntry(logrus.StandardLogger()),
+			Inventories: []imageInventory{
+				{arch: "x86_64", inventory: &inventory.Inventory{Kernel: "6.9.4-200.fc40.x86_64"}},
+				{arch: "aarch64", inventory: &inventory.Inventory{Kernel: "6.9.4-200.fc40.aarch64"}},
+			},
+		}
+		b.checkDrift()
+		Expect(b.Warnings).To(BeEmpty())
+
+		b.Inventories[1].inventory.Kernel = "6.8.5-301.fc40.aarch64"
+		b.checkDrift()
+		Expect(b.Warnings).To(ConsistOf(ContainSubstring("kernel versions differ between architectures")))
+	})
+
+	DescribeTable("checkSizeBudget should warn about or reject disks outside of the budget",
+		func(budget api.SizeBudget, size int64, expectedErr, expectedWarning string) {
+			b := buildAndPublish{Log: logrus.NewEntry(logrus.StandardLogger())}
+			err := b.checkSizeBudget(&api.Metadata{SizeBudget: budget}, size)
+			if expectedErr == "" {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			}
+			if expectedWarning == "" {
+				Expect(b.Warnings).To(BeEmpty())
+			} else {
+				Expect(b.Warnings).To(ConsistOf(ContainSubstring(expectedWarning)))
+			}
+		},
+		Entry("without budget", api.SizeBudget{}, int64(1<<40), "", ""),
+		Entry("within budget", api.SizeBudget{Min: resource.MustParse("1Mi"), Max: resource.MustParse("1Gi")}, int64(1<<20), "", ""),
+		Entry("below budget", api.SizeBudget{Min: resource.MustParse("1Mi")}, int64(1024), "", "below the size budget >= 1Mi"),
+		Entry("above budget", api.SizeBudget{Max: resource.MustParse("1Gi")}, int64(1<<31), "", "exceeds the size budget <= 1Gi"),
+		Entry("above enforced budget", api.SizeBudget{Max: resource.MustParse("1Gi"), Enforce: true}, int64(1<<31),
+			"disk size of 2147483648 bytes exceeds the size budget <= 1Gi", ""),
+	)
+
+	It("getDisk should investigate checksum mismatches", func() {
+		downloads := 0
+		server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			downloads++
+			w.Header().Set("Server", "mirror")
+			_, _ = w.Write([]byte("disk"))
+		}))
+		DeferCleanup(server.Close)
+
+		b := buildAndPublish{
+			Ctx:     context.Background(),
+			Log:     logrus.NewEntry(logrus.StandardLogger()),
+			Options: &common.Options{},
+			Getter:  &http.HTTPGetter{},
+		}
+		disk := &api.DiskDetails{
+			Checksum:     "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+			ChecksumHash: sha256.New,
+			DownloadURL:  server.URL + "/disk.img",
+		}
+		_, err := b.getDisk(disk)
+		Expect(err).To(MatchError(ContainSubstring("expected checksum")))
+		Expect(downloads).To(Equal(2))
+
+		Expect(b.Forensics).To(HaveLen(1))
+		report := b.Forensics[0]
+		Expect(report.Verdict).To(Equal(forensics.VerdictChecksumFile))
+		Expect(report.Redownloaded).To(Equal(report.Actual))
+		Expect(report.Size).To(Equal(int64(4)))
+		Expect(report.DifferingRanges).To(BeEmpty())
+		Expect(report.Headers).To(HaveKeyWithValue("Server", "mirror"))
+		Expect(report.RemoteAddr).ToNot(BeEmpty())
+	})
+
+	DescribeTable("getDisk should verify the cross checksums",
+		func(sha512Sum, expectedErr string) {
+			server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+				_, _ = w.Write([]byte("disk"))
+			}))
+			DeferCleanup(server.Close)
+
+			b := buildAndPublish{
+				Ctx:     context.Background(),
+				Log:     logrus.NewEntry(logrus.StandardLogger()),
+				Options: &common.Options{},
+				Getter:  &http.HTTPGetter{},
+			}
+			const sha256Sum = "1044dec7206e8d7c9fbb4ae8f766668406d2567fc7fc1a160a9d4700fcf8f8e9"
+			disk := &api.DiskDetails{
+				Checksum:     sha256Sum,
+				ChecksumHash: sha256.New,
+				CrossChecksums: []hashsum.Checksum{
+					{Algorithm: hashsum.SHA256, Value: sha256Sum},
+					{Algorithm: hashsum.SHA512, Value: sha512Sum, Source: "https://example.com/SHA512SUMS"},
+				},
+				DownloadURL: server.URL + "/disk.img",
+			}
+			_, err := b.getDisk(disk)
+			if expectedErr == "" {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			}
+		},
+		Entry("matching", "fc9036b84fbb16cdc29b2fa9fdb5edc932dfedd0b3322c1d47e8e63db96bb4efdae3658f4348504df6de9622a07c09af7d6c53a1ef040975530f989d5f94c825", ""), //nolint:lll
+		Entry("mismatching", "cccc", `expected sha512 checksum "cccc" from https://example.com/SHA512SUMS`),
+	)
+
+	It("prepareTags should name repositories with the repository template", func() {
+		template, err := repository.ParseTemplate("{{registry}}/{{org}}/{{name}}-{{arch}}", "downstream")
+		Expect(err).ToNot(HaveOccurred())
+		repository.SetDefaultTemplate(template)
+		defaultTemplate, err := repository.ParseTemplate(repository.DefaultTemplate, "")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(repository.SetDefaultTemplate, defaultTemplate)
+
+		details := &api.ArtifactDetails{}
+		entry := &common.Entry{
+			Artifacts: []api.Artifact{
+				generic.New(details, &api.Metadata{Name: "distro", Version: "1.2", Arch: "aarch64"}),
+			},
+		}
+		Expect(prepareTags(timestamp, "registry", entry, details)).To(Equal([]string{
+			"registry/downstream/distro-aarch64:1.2-2406291230",
+			"registry/downstream/distro-aarch64:1.2",
+		}))
+		Expect(prepareTags(timestamp, "", entry, details)).To(Equal([]string{
+			"downstream/distro-aarch64:1.2-2406291230",
+			"downstream/distro-aarch64:1.2",
+		}))
+	})
+
+	DescribeTable("versionPublished should compare unique tags with the published tags",
+		func(published []string, uniqueTags []string, expected bool) {
+			b := buildAndPublish{
+				Ctx:     context.Background(),
+				Options: &common.Options{},
+				Repo:    &fakeRepository{tags: map[string][]string{"registry/distro": published}},
+			}
+			entry := &common.Entry{
+				Artifacts: []api.Artifact{
+					generic.New(&api.ArtifactDetails{AdditionalUniqueTags: uniqueTags}, &api.Metadata{Name: "distro", Version: "1"}),
+				},
+			}
+			Expect(b.versionPublished(entry, "registry")).To(Equal(expected))
+		},
+		Entry("published", []string{"1", "1-20240629"}, []string{"1-20240629"}, true),
+		Entry("new version", []string{"1", "1-20240629"}, []string{"1-20240701"}, false),
+		Entry("new repository", nil, []string{"1-20240701"}, false),
+		Entry("no unique tags", []string{"1"}, nil, false),
+	)
+
+	DescribeTable("publishRegistries should follow the publish policy",
+		func(policy api.PublishPolicy, privateRegistry, expectedSource, expectedTarget string) {
+			options := &common.PublishImageOptions{SourceRegistry: "source", TargetRegistry: "target", PrivateRegistry: privateRegistry}
+			source, target := publishRegistries(options, &api.Metadata{PublishPolicy: policy})
+			Expect(source).To(Equal(expectedSource))
+			Expect(target).To(Equal(expectedTarget))
+		},
+		Entry("public", api.PublishPolicyPublic, "private", "source", "target"),
+		Entry("private", api.PublishPolicyPrivate, "private", "private", "private"),
+		Entry("private without private registry", api.PublishPolicyPrivate, "", "", ""),
+		Entry("skip", api.PublishPolicySkip, "private", "", ""),
+	)
+
+	DescribeTable("verifyDefaultsLabels should check the labels of the default instancetype and preference",
+		func(envVariables, labels map[string]string, expectedErr string) {
+			artifact := generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: "distro", Version: "1", EnvVariables: envVariables})
+			repo := &fakeRepository{labels: map[string]map[string]string{"registry/distro:1": labels}}
+			err := verifyDefaultsLabels(repo, artifact, "registry/distro:1", &common.Options{})
+			if expectedErr == "" {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			}
+		},
+		Entry("without defaults", nil, nil, ""),
+		Entry("labels present",
+			map[string]string{pkgcommon.DefaultInstancetypeEnv: "u1.medium", pkgcommon.DefaultPreferenceEnv: "fedora"},
+			map[string]string{pkgcommon.DefaultInstancetypeLabel: "u1.medium", pkgcommon.DefaultPreferenceLabel: "fedora"}, ""),
+		Entry("label missing",
+			map[string]string{pkgcommon.DefaultInstancetypeEnv: "u1.medium", pkgcommon.DefaultPreferenceEnv: "fedora"},
+			map[string]string{pkgcommon.DefaultInstancetypeLabel: "u1.medium"},
+			`label instancetype.kubevirt.io/default-preference="", expected "fedora"`),
+		Entry("label differs",
+			map[string]string{pkgcommon.DefaultInstancetypeEnv: "u1.medium"},
+			map[string]string{pkgcommon.DefaultInstancetypeLabel: "u1.small"},
+			`label instancetype.kubevirt.io/default-instancetype="u1.small", expected "u1.medium"`),
+	)
+
+	DescribeTable("checkAccessCredentials should compare the declared support with the guest agent",
+		func(declared, connected bool, expectedErr string) {
+			vmi := &kvirtv1.VirtualMachineInstance{}
+			if connected {
+				vmi.Status.Conditions = []kvirtv1.VirtualMachineInstanceCondition{
+					{Type: kvirtv1.VirtualMachineInstanceAgentConnected, Status: k8sv1.ConditionTrue},
+				}
+			}
+			err := checkAccessCredentials(logrus.NewEntry(logrus.New()), &api.Metadata{AccessCredentials: declared}, vmi)
+			if expectedErr == "" {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			}
+		},
+		Entry("declared and connected", true, true, ""),
+		Entry("declared but not connected", true, false, "qemu-guest-agent is not connected"),
+		Entry("not declared but connected", false, true, ""),
+		Entry("neither declared nor connected", false, false, ""),
+	)
+
+	It("checkAccessCredentials should require the guest agent if the verification expects it", func() {
+		metadata := &api.Metadata{Verification: api.Verification{GuestAgent: true}}
+		Expect(checkAccessCredentials(logrus.NewEntry(logrus.New()), metadata, &kvirtv1.VirtualMachineInstance{})).To(
+			MatchError("the qemu-guest-agent is required but not connected"))
+	})
+
+	It("bootTimeout should prefer the maximum boot time of the artifact", func() {
+		o := &common.VerifyImageOptions{Timeout: 600}
+		Expect(bootTimeout(&api.Metadata{}, o)).To(Equal(10 * time.Minute))
+		Expect(bootTimeout(&api.Metadata{Verification: api.Verification{MaxBootTime: 20 * time.Minute}}, o)).To(Equal(20 * time.Minute))
+	})
+
+	It("recordBootTime should warn about builds booting slower than older builds", func() {
+		o := &common.Options{Metrics: metrics.New(), VerifyImagesOptions: common.VerifyImageOptions{TargetArchitecture: "amd64"}}
+		h := boottime.New(0.25)
+		older := generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: "distro", Version: "1"})
+		newer := generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: "distro", Version: "2"})
+
+		Expect(recordBootTime(o, h, older, "distro:1-2406291230", time.Minute)).To(BeEmpty())
+		Expect(recordBootTime(o, h, newer, "distro:2-2406291230", 2*time.Minute)).To(ConsistOf(
+			"boot time 2m0s regressed by 100% over the baseline of 1m0s of older builds"))
+		Expect(o.Metrics.Get(metrics.VerificationBootTime, "distro:2")).To(Equal(120.0))
+		Expect(o.Metrics.Get(metrics.BootTimeRegression, "distro:2")).To(Equal(1.0))
+		Expect(o.Metrics.Get(metrics.BootTimeRegression, "distro:1")).To(Equal(0.0))
+	})
+
+	It("recordBootTime should only set the metric without a boot time history", func() {
+		o := &common.Options{Metrics: metrics.New()}
+		artifact := generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: "distro", Version: "1"})
+		Expect(recordBootTime(o, nil, artifact, "distro:1-2406291230", time.Minute)).To(BeEmpty())
+		Expect(o.Metrics.Get(metrics.VerificationBootTime, "distro:1")).To(Equal(60.0))
+	})
+
+	It("mergeVerifications should require all published architectures to pass", func() {
+		pushed := api.ArtifactResult{Tags: []string{"distro:1"}, Stage: StagePush}
+		verified := func(arch, err string, quarantined bool) api.ArtifactResult {
+			return api.ArtifactResult{Tags: pushed.Tags, Stage: StageVerify, Architecture: arch, Err: err, Quarantined: quarantined}
+		}
+		results := map[string]api.ArtifactResult{"distro:1": pushed, "distro:2": pushed, "distro:3": pushed, "other:1": pushed}
+		amd64 := map[string]api.ArtifactResult{
+			"distro:1": verified("amd64", "", false),
+			"distro:2": verified("amd64", "", false),
+			"distro:3": verified("amd64", "ssh failed", true),
+		}
+		arm64 := map[string]api.ArtifactResult{
+			"distro:1": verified("arm64", "", false),
+			"distro:3": verified("arm64", "boot failed", true),
+			"other:1":  pushed,
+		}
+		archs := map[string][]string{
+			"distro:1": {"amd64", "arm64"}, "distro:2": {"amd64", "arm64"}, "distro:3": {"amd64", "arm64"}, "other:1": {"amd64"},
+		}
+
+		merged := mergeVerifications(results, []map[string]api.ArtifactResult{amd64, arm64}, nil, archs)
+		Expect(merged["distro:1"].Err).To(BeEmpty())
+		Expect(merged["distro:1"].VerifiedArchitectures).To(Equal([]string{"amd64", "arm64"}))
+		Expect(merged["distro:1"].Architecture).To(BeEmpty())
+		Expect(merged["distro:2"].Err).To(Equal("arm64: not verified"))
+		Expect(merged["distro:2"].Quarantined).To(BeFalse())
+		Expect(merged["distro:3"].Err).To(Equal("amd64: ssh failed; arm64: boot failed"))
+		Expect(merged["distro:3"].Quarantined).To(BeTrue())
+		Expect(merged["other:1"]).To(Equal(pushed))
+
+		merged = mergeVerifications(results, []map[string]api.ArtifactResult{amd64}, []string{"amd64"}, archs)
+		Expect(merged["distro:2"].Err).To(BeEmpty())
+		Expect(merged["distro:2"].Stage).To(Equal(StageVerify))
+	})
+
+	It("lockedEntry should pin the artifacts of an entry", func() {
+		details := &api.ArtifactDetails{Checksum: "aaaa", DownloadURL: "https://example.com/disk.qcow2"}
+		entry := &common.Entry{
+			Artifacts: []api.Artifact{
+				generic.New(details, &api.Metadata{Name: "distro", Version: "1", Arch: "x86_64"}),
+			},
+			UseForLatest: true,
+		}
+		lock := lockfile.New()
+		Expect(lock.Add(entry.Artifacts[0])).To(Succeed())
+
+		locked := lockedEntry(entry, lock)
+		Expect(locked.UseForLatest).To(BeTrue())
+		Expect(locked.Artifacts[0].Metadata()).To(Equal(entry.Artifacts[0].Metadata()))
+		_, err := locked.Artifacts[0].Inspect()
+		Expect(err).ToNot(HaveOccurred())
+
+		details.Checksum = "bbbb"
+		_, err = locked.Artifacts[0].Inspect()
+		Expect(err).To(HaveOccurred())
+	})
+
+	DescribeTable("gzipReader should decompress all members and read trailing data",
+		func(trailer string, members ...string) {
+			var compressed bytes.Buffer
+			for _, member := range members {
+				writer := gzip.NewWriter(&compressed)
+				_, err := writer.Write([]byte(member))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(writer.Close()).To(Succeed())
+			}
+			compressed.WriteString(trailer)
+
+			reader, err := newGzipReader(&compressed)
+			Expect(err).ToNot(HaveOccurred())
+			decompressed, err := io.ReadAll(reader)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(decompressed)).To(Equal(strings.Join(members, "")))
+			Expect(compressed.Len()).To(BeZero())
+		},
+		Entry("single member", "", "disk"),
+		Entry("multiple members", "", "first ", "second"),
+		Entry("trailing metadata", `{"metadata_version": "1.1"}`, "disk"),
+	)
+})
+
+type fakeRepository struct {
+	repository.RepositoryImpl
+	tags   map[string][]string
+	labels map[string]map[string]string
+	pushed []string
+	// digests are the digests tags resolve to
+	digests map[string]string
+}
+
+func (r *fakeRepository) PushImage(_ context.Context, _ v1.Image, imgRef string) error {
+	r.pushed = append(r.pushed, "image "+imgRef)
+	return nil
+}
+
+func (r *fakeRepository) PushImageIndex(_ context.Context, _ v1.ImageIndex, imgRef string) error {
+	r.pushed = append(r.pushed, "index "+imgRef)
+	return nil
+}
+
+func (r *fakeRepository) TagManifest(_ context.Context, _ remote.Taggable, imgRef string) error {
+	r.pushed = append(r.pushed, "tag "+imgRef)
+	return nil
+}
+
+func (r *fakeRepository) PinnedReference(_ context.Context, imgRef string, _ bool) (string, error) {
+	digest, exists := r.digests[imgRef]
+	if !exists {
+		return "", fmt.Errorf("%s not found", imgRef)
+	}
+	return imgRef[:strings.LastIndex(imgRef, ":")] + "@" + digest, nil
+}
+
+func (r *fakeRepository) ImageMetadata(imgRef, _ string, _ bool) (*repository.ImageInfo, error) {
+	return &repository.ImageInfo{Labels: r.labels[imgRef]}, nil
+}
+
+func (r *fakeRepository) ListTags(_ context.Context, repo string, _ bool) ([]string, error) {
+	return r.tags[repo], nil
+}
+
+func TestImages(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Images Suite")
+}
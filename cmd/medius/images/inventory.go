@@ -0,0 +1,113 @@
+package images
+
+import (
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/inventory"
+	"kubevirt.io/containerdisks/pkg/progress"
+)
+
+// imageInventory is the inventory of the disk of a built image.
+type imageInventory struct {
+	image     v1.Image
+	arch      string
+	inventory *inventory.Inventory
+}
+
+// collectInventory inspects a disk with libguestfs. The inventory is informational only,
+// so containerdisks are still published without it if the inspection fails.
+func (b *buildAndPublish) collectInventory(disk string) *inventory.Inventory {
+	progress.FromContext(b.Ctx).SetState("inspecting disk")
+	b.Log.Info("Collecting the inventory of the disk ...")
+	diskInventory, err := inventory.Collect(b.Ctx, disk)
+	if err != nil {
+		b.Log.WithError(err).Warn("Failed to collect the inventory of the disk")
+		return nil
+	}
+	b.Log.Infof("Disk inventory: %s", diskInventory.Summary())
+	return diskInventory
+}
+
+// pushInventories attaches the inventories of the built images as OCI referrers in the repository of imageName.
+func (b *buildAndPublish) pushInventories(imageName string) error {
+	if len(b.Inventories) == 0 {
+		return nil
+	}
+	ref, err := name.ParseReference(imageName)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range b.Inventories {
+		if err := b.pushInventory(ref.Context(), i); err != nil {
+			return fmt.Errorf("error pushing inventory: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (b *buildAndPublish) pushInventory(repo name.Repository, i imageInventory) error {
+	subject, err := partial.Descriptor(i.image)
+	if err != nil {
+		return err
+	}
+	artifact, err := i.inventory.Artifact(*subject)
+	if err != nil {
+		return err
+	}
+	digest, err := artifact.Digest()
+	if err != nil {
+		return err
+	}
+	return b.pushImage(artifact, repo.Digest(digest.String()).String())
+}
+
+// checkGuestAgent warns about disks without the qemu-guest-agent and injects it if requested.
+// It returns the inventory of the disk after the injection.
+func (b *buildAndPublish) checkGuestAgent(
+	disk string, diskInventory *inventory.Inventory, metadata *api.Metadata,
+) (*inventory.Inventory, error) {
+	if diskInventory == nil || diskInventory.HasGuestAgent() {
+		return diskInventory, nil
+	}
+
+	if !b.Options.PublishImagesOptions.InjectGuestAgent {
+		warning := "disk ships no qemu-guest-agent, KubeVirt features like guest information and accessCredentials won't work"
+		if metadata.AccessCredentials {
+			warning += ", although the artifact declares support for accessCredentials"
+		}
+		b.warn(warning)
+		return diskInventory, nil
+	}
+
+	b.Log.Info("Disk ships no qemu-guest-agent, injecting it ...")
+	progress.FromContext(b.Ctx).SetState("injecting qemu-guest-agent")
+	// The disk may be modified partially, so it must not be published if the injection failed
+	if err := inventory.InjectGuestAgent(b.Ctx, disk); err != nil {
+		return nil, fmt.Errorf("error injecting the qemu-guest-agent: %v", err)
+	}
+	return b.collectInventory(disk), nil
+}
+
+// warn logs a problem of the containerdisk which does not fail publishing and reports it in the results.
+func (b *buildAndPublish) warn(warning string) {
+	b.Log.Warn(warning)
+	b.Warnings = append(b.Warnings, warning)
+}
+
+// checkDrift warns about architectures whose disks differ noticeably from the other ones of the same version.
+func (b *buildAndPublish) checkDrift() {
+	inventories := map[string]*inventory.Inventory{}
+	for _, i := range b.Inventories {
+		inventories[i.arch] = i.inventory
+	}
+	for _, drift := range inventory.Drift(inventories) {
+		b.warn(drift)
+	}
+}
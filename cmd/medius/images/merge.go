@@ -0,0 +1,143 @@
+package images
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/architecture"
+)
+
+type mergeOptions struct {
+	RequiredArchitectures []string
+}
+
+func NewMergeResultsCommand(options *common.Options) *cobra.Command {
+	mergeOptions := &mergeOptions{}
+
+	mergeCmd := &cobra.Command{
+		Use:   "merge <verify-results.json>...",
+		Short: "Merge the results of verifying containerdisks on machines of different architectures",
+		Long: `Merge the results files the verify stage wrote on machines of different architectures into the results
+file of the push stage. Containerdisks are only promoted if they passed verification on all required
+architectures they are published for.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results, err := readResultsFile(options.ImagesOptions.ResultsFile)
+			if err != nil {
+				return err
+			}
+
+			var verifications []map[string]api.ArtifactResult
+			for _, arg := range args {
+				verification, readErr := readResultsFile(arg)
+				if readErr != nil {
+					return readErr
+				}
+				verifications = append(verifications, verification)
+			}
+
+			registry, err := loadRegistry(options)
+			if err != nil {
+				return err
+			}
+
+			merged := mergeVerifications(results, verifications, mergeOptions.RequiredArchitectures, architecturesOf(registry))
+			for _, name := range slices.Sorted(maps.Keys(merged)) {
+				if merged[name].Stage == StageVerify && merged[name].Err != "" && !merged[name].Quarantined {
+					logrus.Warnf("%s failed verification: %s", name, merged[name].Err)
+				}
+			}
+			return writeResultsFile(options.ImagesOptions.ResultsFile, merged)
+		},
+	}
+	mergeCmd.Flags().StringSliceVar(&mergeOptions.RequiredArchitectures, "required-architectures",
+		mergeOptions.RequiredArchitectures,
+		"Architectures containerdisks have to pass verification on, defaults to the architectures of the merged results")
+
+	return mergeCmd
+}
+
+// architecturesOf returns the image architectures of the containerdisks of the registry, by their description.
+func architecturesOf(registry []common.Entry) map[string][]string {
+	archs := map[string][]string{}
+	for i := range registry {
+		description := registry[i].Artifacts[0].Metadata().Describe()
+		for _, artifact := range registry[i].Artifacts {
+			archs[description] = append(archs[description], architecture.GetImageArchitecture(artifact.Metadata().Arch))
+		}
+	}
+	return archs
+}
+
+// mergeVerifications merges the results of verify runs on different architectures into the results of the push
+// stage. A containerdisk passes verification only if it passed on all required architectures it is published for,
+// and is only quarantined if all its failures are quarantined.
+func mergeVerifications(
+	results map[string]api.ArtifactResult, verifications []map[string]api.ArtifactResult, required []string,
+	archs map[string][]string,
+) map[string]api.ArtifactResult {
+	byName := map[string]map[string]api.ArtifactResult{}
+	for _, verification := range verifications {
+		for name, result := range verification {
+			if result.Stage != StageVerify {
+				continue
+			}
+			if byName[name] == nil {
+				byName[name] = map[string]api.ArtifactResult{}
+			}
+			byName[name][result.Architecture] = result
+		}
+	}
+	if len(required) == 0 {
+		seen := map[string]bool{}
+		for _, byArch := range byName {
+			for arch := range byArch {
+				seen[arch] = true
+			}
+		}
+		required = slices.Sorted(maps.Keys(seen))
+	}
+
+	merged := maps.Clone(results)
+	for name, byArch := range byName {
+		merged[name] = mergeVerification(byArch, required, archs[name])
+	}
+	return merged
+}
+
+func mergeVerification(byArch map[string]api.ArtifactResult, required, published []string) api.ArtifactResult {
+	archs := slices.Sorted(maps.Keys(byArch))
+	merged := byArch[archs[0]]
+	merged.Architecture = ""
+	merged.VerifiedArchitectures = nil
+
+	var failures []string
+	quarantined := true
+	for _, arch := range archs {
+		result := byArch[arch]
+		if result.Err == "" {
+			merged.VerifiedArchitectures = append(merged.VerifiedArchitectures, arch)
+			continue
+		}
+		failures = append(failures, fmt.Sprintf("%s: %s", arch, result.Err))
+		quarantined = quarantined && result.Quarantined
+	}
+	for _, arch := range required {
+		// Architectures a containerdisk is not published for are not verified
+		if _, verified := byArch[arch]; !verified && (published == nil || slices.Contains(published, arch)) {
+			failures = append(failures, fmt.Sprintf("%s: not verified", arch))
+			quarantined = false
+		}
+	}
+
+	merged.Err = strings.Join(failures, "; ")
+	merged.Quarantined = len(failures) > 0 && quarantined
+	return merged
+}
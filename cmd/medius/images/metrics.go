@@ -0,0 +1,123 @@
+package images
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sirupsen/logrus"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/metrics"
+	"kubevirt.io/containerdisks/pkg/repository"
+)
+
+// Registry operations counted by the metrics
+const (
+	operationMetadata = "metadata"
+	operationPush     = "push"
+	operationPushIdx  = "push-index"
+	operationTag      = "tag"
+	operationCopy     = "copy"
+	operationResolve  = "resolve"
+	operationListTags = "list-tags"
+	operationHead     = "head"
+)
+
+// newRepository returns the repository of a stage, which counts registry operations if metrics are enabled.
+func newRepository(o *common.Options) repository.Repository {
+	if o.Metrics == nil {
+		return repository.New()
+	}
+	return &instrumentedRepository{Repository: repository.New(), metrics: o.Metrics}
+}
+
+// instrumentedRepository counts registry operations and their failures.
+type instrumentedRepository struct {
+	repository.Repository
+	metrics *metrics.Set
+}
+
+func (r *instrumentedRepository) count(operation string, err error) {
+	r.metrics.Add(metrics.RegistryRequests, 1, operation)
+	if err != nil {
+		r.metrics.Add(metrics.RegistryErrors, 1, operation)
+	}
+}
+
+func (r *instrumentedRepository) ImageMetadata(imgRef, arch string, insecure bool) (*repository.ImageInfo, error) {
+	imageInfo, err := r.Repository.ImageMetadata(imgRef, arch, insecure)
+	r.count(operationMetadata, err)
+	return imageInfo, err
+}
+
+func (r *instrumentedRepository) PushImage(ctx context.Context, img v1.Image, imgRef string) error {
+	err := r.Repository.PushImage(ctx, img, imgRef)
+	r.count(operationPush, err)
+	return err
+}
+
+func (r *instrumentedRepository) PushImageIndex(ctx context.Context, img v1.ImageIndex, imgRef string) error {
+	err := r.Repository.PushImageIndex(ctx, img, imgRef)
+	r.count(operationPushIdx, err)
+	return err
+}
+
+func (r *instrumentedRepository) TagManifest(ctx context.Context, manifest remote.Taggable, imgRef string) error {
+	err := r.Repository.TagManifest(ctx, manifest, imgRef)
+	r.count(operationTag, err)
+	return err
+}
+
+func (r *instrumentedRepository) CopyImage(ctx context.Context, srcRef, dstRef string, insecure bool) error {
+	err := r.Repository.CopyImage(ctx, srcRef, dstRef, insecure)
+	r.count(operationCopy, err)
+	return err
+}
+
+func (r *instrumentedRepository) PinnedReference(ctx context.Context, imgRef string, insecure bool) (string, error) {
+	pinnedRef, err := r.Repository.PinnedReference(ctx, imgRef, insecure)
+	r.count(operationResolve, err)
+	return pinnedRef, err
+}
+
+func (r *instrumentedRepository) Descriptor(ctx context.Context, imgRef string, insecure bool) (*v1.Descriptor, error) {
+	descriptor, err := r.Repository.Descriptor(ctx, imgRef, insecure)
+	r.count(operationHead, err)
+	return descriptor, err
+}
+
+func (r *instrumentedRepository) ListTags(ctx context.Context, repo string, insecure bool) ([]string, error) {
+	tags, err := r.Repository.ListTags(ctx, repo, insecure)
+	r.count(operationListTags, err)
+	return tags, err
+}
+
+// recordStage records the end of a stage and writes the metrics file, if metrics are enabled.
+func recordStage(o *common.Options, stage string, workerErr error) {
+	if o.Metrics == nil {
+		return
+	}
+
+	now := float64(time.Now().Unix())
+	o.Metrics.Set(metrics.StageLastRun, now, stage)
+	if workerErr == nil {
+		o.Metrics.Set(metrics.StageLastSuccess, now, stage)
+	}
+	if err := o.Metrics.Write(o.ImagesOptions.MetricsFile); err != nil {
+		logrus.WithError(err).Error("Failed to write metrics")
+	}
+}
+
+// recordVerificationMetrics tracks how many runs in a row a containerdisk failed verification.
+func recordVerificationMetrics(o *common.Options, description string, err error) {
+	if o.Metrics == nil {
+		return
+	}
+	if err == nil {
+		o.Metrics.Set(metrics.VerificationFailures, 0, description)
+	} else {
+		o.Metrics.Add(metrics.VerificationFailures, 1, description)
+	}
+}
@@ -5,73 +5,130 @@ import (
 	"errors"
 	"fmt"
 	"path"
+	"slices"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"kubevirt.io/containerdisks/cmd/medius/common"
 	"kubevirt.io/containerdisks/pkg/api"
-	"kubevirt.io/containerdisks/pkg/repository"
+	"kubevirt.io/containerdisks/pkg/build"
+	"kubevirt.io/containerdisks/pkg/progress"
+	"kubevirt.io/containerdisks/pkg/rollout"
 )
 
 func NewPromoteImagesCommand(options *common.Options) *cobra.Command {
 	options.PromoteImageOptions = common.PromoteImageOptions{
-		TargetRegistry: "quay.io/containerdisks",
+		TargetRegistry:    "quay.io/containerdisks",
+		RequiredSuccesses: rollout.DefaultRequiredSuccesses,
 	}
 
 	promoteCmd := &cobra.Command{
 		Use:   "promote",
 		Short: "Promote verified containerdisks from one registry to another registry",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			results, err := readResultsFile(options.ImagesOptions.ResultsFile)
 			if err != nil {
-				logrus.Fatal(err)
+				return err
 			}
 
-			focusMatched, resultsChan, workerErr := spawnWorkers(cmd.Context(), options, func(e *common.Entry) (*api.ArtifactResult, error) {
-				artifact := e.Artifacts[0]
-				description := artifact.Metadata().Describe()
-				r, ok := results[description]
-				if !ok {
-					return nil, nil
-				}
-				if r.Err != "" {
-					return nil, fmt.Errorf("artifact %s failed in stage %s: %s", description, r.Stage, r.Err)
-				}
-				if r.Stage != StageVerify {
-					return nil, nil
-				}
+			ro, err := readRollout(&options.PromoteImageOptions)
+			if err != nil {
+				return err
+			}
 
-				errString := ""
-				err := promoteArtifact(cmd.Context(), artifact, r.Tags, options)
-				if err != nil {
-					errString = err.Error()
-				}
+			focusMatched, resultsChan, workerErr := spawnWorkers(cmd.Context(), options, options.PromoteImageOptions.TargetRegistry,
+				func(ctx context.Context, e *common.Entry) (*api.ArtifactResult, error) {
+					artifact := e.Artifacts[0]
+					description := artifact.Metadata().Describe()
+					r, ok := results[description]
+					if !ok {
+						return nil, nil
+					}
+					if r.Rollout {
+						if ro == nil || r.Stage != StageVerify {
+							return nil, nil
+						}
+						return promoteRolloutCandidate(ctx, artifact, ro, r, options)
+					}
+					if r.Quarantined {
+						common.Logger(artifact).Warnf("Not promoting quarantined containerdisk: %s", r.Err)
+						return nil, nil
+					}
+					if r.Err != "" {
+						return nil, fmt.Errorf("artifact %s failed in stage %s: %s", description, r.Stage, r.Err)
+					}
+					if r.Stage != StageVerify {
+						return nil, nil
+					}
+
+					// Containerdisks of private registries must never reach the public target registry
+					if r.Registry != "" {
+						common.Logger(artifact).Infof("Not promoting private containerdisk of %s", r.Registry)
+						return nil, nil
+					}
+
+					pinnedTags, floatingTags := splitFloatingTags(r.Tags, r.FloatingTags)
+					if ro != nil && !ro.Propose(description, rollout.Candidate{
+						Tags:         pinnedTags,
+						FloatingTags: floatingTags,
+						KernelBoot:   len(r.KernelBootTags) > 0,
+					}) {
+						common.Logger(artifact).Infof("Not moving floating tags %v before %d successful verifications in a row",
+							floatingTags, ro.RequiredSuccesses)
+						floatingTags = nil
+					}
+
+					errString := ""
+					sourceRegistry := options.PromoteImageOptions.SourceRegistry
+					err := promoteArtifact(ctx, artifact, sourceRegistry, slices.Concat(pinnedTags, floatingTags), options)
+					if err == nil && len(r.KernelBootTags) > 0 {
+						err = promoteArtifact(ctx, artifact, sourceRegistry,
+							kernelBootTags(slices.Concat(pinnedTags, floatingTags)), options)
+					}
+					if err != nil {
+						errString = err.Error()
+					}
 
-				return &api.ArtifactResult{
-					Tags:  r.Tags,
-					Stage: StagePromote,
-					Err:   errString,
-				}, err
-			})
+					return &api.ArtifactResult{
+						Tags:           r.Tags,
+						FloatingTags:   r.FloatingTags,
+						Digest:         r.Digest,
+						Stage:          StagePromote,
+						Err:            errString,
+						KernelBootTags: r.KernelBootTags,
+						BuiltBy:        r.BuiltBy,
+					}, err
+				})
+			if resultsChan == nil {
+				return workerErr
+			}
 
 			for result := range resultsChan {
 				results[result.Key] = result.Value
 			}
 
 			if !focusMatched {
-				logrus.Fatalf("no artifact was processed, focus '%s' did not match", options.Focus)
+				return fmt.Errorf("no artifact was processed, focus '%s' did not match", options.Focus)
 			}
 
 			if !options.DryRun {
 				if err := writeResultsFile(options.ImagesOptions.ResultsFile, results); err != nil {
-					logrus.Fatal(err)
+					return err
+				}
+				if ro != nil {
+					if err := ro.Write(options.PromoteImageOptions.RolloutFile); err != nil {
+						return err
+					}
 				}
 			}
 
-			if workerErr != nil {
-				logrus.Fatal(workerErr)
+			// Dry runs don't promote anything, so they must not count as a successful publish
+			if !options.DryRun {
+				recordStage(options, StagePromote, workerErr)
 			}
+
+			return workerErr
 		},
 	}
 	promoteCmd.Flags().StringVar(&options.PromoteImageOptions.SourceRegistry, "source-registry",
@@ -79,6 +136,11 @@ func NewPromoteImagesCommand(options *common.Options) *cobra.Command {
 	promoteCmd.Flags().StringVar(&options.PromoteImageOptions.TargetRegistry, "target-registry",
 		options.PromoteImageOptions.TargetRegistry, "Registry to promote images to")
 
+	promoteCmd.Flags().StringVar(&options.PromoteImageOptions.RolloutFile, "rollout-file",
+		options.PromoteImageOptions.RolloutFile, "File tracking promoted containerdisks whose floating tags were not moved yet")
+	promoteCmd.Flags().IntVar(&options.PromoteImageOptions.RequiredSuccesses, "required-successes",
+		options.PromoteImageOptions.RequiredSuccesses, "Verifications in a row a containerdisk has to pass before its floating tags are moved")
+
 	err := promoteCmd.MarkFlagRequired("source-registry")
 	if err != nil {
 		logrus.Fatal(err)
@@ -87,7 +149,77 @@ func NewPromoteImagesCommand(options *common.Options) *cobra.Command {
 	return promoteCmd
 }
 
-func promoteArtifact(ctx context.Context, artifact api.Artifact, tags []string, options *common.Options) error {
+// readRollout returns the rollout of previous runs or nil if floating tags are moved right away.
+func readRollout(o *common.PromoteImageOptions) (*rollout.Rollout, error) {
+	if o.RolloutFile == "" {
+		return nil, nil
+	}
+	return rollout.Read(o.RolloutFile, o.RequiredSuccesses)
+}
+
+// promoteRolloutCandidate counts the verification of an already promoted containerdisk and moves its
+// floating tags within the target registry once it passed enough verifications in a row.
+func promoteRolloutCandidate(
+	ctx context.Context, artifact api.Artifact, ro *rollout.Rollout, r api.ArtifactResult, options *common.Options,
+) (*api.ArtifactResult, error) {
+	log := common.Logger(artifact)
+	description := artifact.Metadata().Describe()
+	if r.Err != "" {
+		ro.RecordFailure(description)
+		if r.Quarantined {
+			log.Warnf("Not moving floating tags of quarantined containerdisk: %s", r.Err)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("promoted artifact %s failed verification again: %s", description, r.Err)
+	}
+
+	candidate, ready := ro.RecordSuccess(description)
+	if !ready {
+		log.Infof("Containerdisk passed %d of %d verifications in a row", candidate.Successes, ro.RequiredSuccesses)
+		return nil, nil
+	}
+
+	targetRegistry := options.PromoteImageOptions.TargetRegistry
+	err := promoteArtifact(ctx, artifact, targetRegistry, slices.Concat(candidate.Tags[:1], candidate.FloatingTags), options)
+	if err == nil && candidate.KernelBoot {
+		err = promoteArtifact(ctx, artifact, targetRegistry,
+			kernelBootTags(slices.Concat(candidate.Tags[:1], candidate.FloatingTags)), options)
+	}
+	errString := ""
+	if err != nil {
+		errString = err.Error()
+	}
+
+	return &api.ArtifactResult{
+		Tags:         candidate.Tags,
+		FloatingTags: candidate.FloatingTags,
+		Stage:        StagePromote,
+		Err:          errString,
+		Rollout:      true,
+	}, err
+}
+
+// splitFloatingTags splits tags into the version-pinned and the floating tags, keeping their order.
+func splitFloatingTags(tags, floating []string) (pinnedTags, floatingTags []string) {
+	for _, tag := range tags {
+		if slices.Contains(floating, tag) {
+			floatingTags = append(floatingTags, tag)
+		} else {
+			pinnedTags = append(pinnedTags, tag)
+		}
+	}
+	return pinnedTags, floatingTags
+}
+
+func kernelBootTags(tags []string) []string {
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag+build.KernelBootTagSuffix)
+	}
+	return names
+}
+
+func promoteArtifact(ctx context.Context, artifact api.Artifact, sourceRegistry string, tags []string, options *common.Options) error {
 	log := common.Logger(artifact)
 
 	if len(tags) == 0 {
@@ -96,12 +228,26 @@ func promoteArtifact(ctx context.Context, artifact api.Artifact, tags []string,
 		return err
 	}
 
-	repo := repository.RepositoryImpl{}
-	srcRef := path.Join(options.PromoteImageOptions.SourceRegistry, tags[0])
+	repo := newRepository(options)
+	srcRef := path.Join(sourceRegistry, tags[0])
+	if !options.DryRun {
+		// Pin the source to its digest, so that all tags including the floating
+		// ones end up pointing to exactly the image which was verified.
+		pinnedRef, err := repo.PinnedReference(ctx, srcRef, options.AllowInsecureRegistry)
+		if err != nil {
+			log.WithError(err).Error("Failed to resolve image digest")
+			return err
+		}
+		srcRef = pinnedRef
+	}
+
+	// Tags are ordered from the most to the least specific one, so floating
+	// tags like "latest" are only moved once all specific tags were copied.
 	for _, tag := range tags {
 		dstRef := path.Join(options.PromoteImageOptions.TargetRegistry, tag)
 		if !options.DryRun {
 			log.Infof("Copying %s -> %s", srcRef, dstRef)
+			progress.FromContext(ctx).SetState("copying " + tag)
 			if err := repo.CopyImage(ctx, srcRef, dstRef, options.AllowInsecureRegistry); err != nil {
 				log.WithError(err).Error("Failed to copy image")
 				return err
@@ -1,16 +1,22 @@
 package images
 
 import (
-	"compress/gzip"
+	"compress/bzip2"
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"maps"
 	"os"
-	"path"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/ulikunitz/xz"
@@ -18,9 +24,19 @@ import (
 
 	"kubevirt.io/containerdisks/cmd/medius/common"
 	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/buffers"
 	"kubevirt.io/containerdisks/pkg/build"
+	"kubevirt.io/containerdisks/pkg/buildinfo"
+	"kubevirt.io/containerdisks/pkg/dag"
+	"kubevirt.io/containerdisks/pkg/forensics"
 	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/inventory"
+	"kubevirt.io/containerdisks/pkg/lockfile"
+	"kubevirt.io/containerdisks/pkg/progress"
+	"kubevirt.io/containerdisks/pkg/provenance"
 	"kubevirt.io/containerdisks/pkg/repository"
+	"kubevirt.io/containerdisks/pkg/tagpolicy"
+	"kubevirt.io/containerdisks/pkg/workspace"
 )
 
 type buildAndPublish struct {
@@ -29,47 +45,101 @@ type buildAndPublish struct {
 	Options *common.Options
 	Repo    repository.Repository
 	Getter  http.Getter
+	// Scratch is the scratch space for downloaded disks. If unset, disks are stored in the temp directory.
+	Scratch *workspace.Allocation
+	// Forensics collects the evidence gathered about downloads whose checksum did not match.
+	Forensics []forensics.Report
+	// Inventories are the inventories of the built images, if requested.
+	Inventories []imageInventory
+	// Warnings describe problems of the containerdisk which do not fail the publishing.
+	Warnings []string
+	// Digest is the digest of the assembled containerdisk.
+	Digest string
 }
 
 func NewPublishImagesCommand(options *common.Options) *cobra.Command {
 	options.PublishImagesOptions = common.PublishImageOptions{
+		Attempts:       1,
 		SourceRegistry: "quay.io/containerdisks",
 	}
 
 	publishCmd := &cobra.Command{
 		Use:   "push",
 		Short: "Determine if containerdisks need an update and push an update to the target registry if needed",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			if options.PublishImagesOptions.TargetRegistry == "" {
 				options.PublishImagesOptions.TargetRegistry = options.PublishImagesOptions.SourceRegistry
 			}
 
-			focusMatched, resultsChan, workerErr := spawnWorkers(cmd.Context(), options, func(e *common.Entry) (*api.ArtifactResult, error) {
-				errString := ""
-				artifact := e.Artifacts[0]
-
-				b := buildAndPublish{
-					Ctx:     cmd.Context(),
-					Log:     common.Logger(artifact),
-					Options: options,
-					Repo:    &repository.RepositoryImpl{},
-					Getter:  &http.HTTPGetter{},
-				}
-				tags, err := b.Do(e, time.Now())
-				if err != nil {
-					errString = err.Error()
+			var lock *lockfile.Lock
+			if options.PublishImagesOptions.Locked {
+				var err error
+				if lock, err = lockfile.Read(options.LockFile); err != nil {
+					return err
 				}
+			}
 
-				if tags == nil && err == nil {
-					return nil, nil
-				}
+			focusMatched, resultsChan, workerErr := spawnWorkers(cmd.Context(), options, options.PublishImagesOptions.TargetRegistry,
+				func(ctx context.Context, e *common.Entry) (*api.ArtifactResult, error) {
+					errString := ""
+					if lock != nil {
+						e = lockedEntry(e, lock)
+					}
+					artifact := e.Artifacts[0]
+
+					var getter http.Getter = &http.HTTPGetter{}
+					if options.PublishImagesOptions.Metalink {
+						getter = http.NewMetalinkGetter(getter)
+					}
+					if options.PublishImagesOptions.DeltaCache != "" {
+						getter = http.NewZsyncGetter(getter, options.PublishImagesOptions.DeltaCache)
+					}
+
+					b := buildAndPublish{
+						Ctx:     ctx,
+						Log:     common.Logger(artifact),
+						Options: options,
+						Repo:    newRepository(options),
+						Getter:  getter,
+					}
+					if options.Workspace != nil {
+						scratch, err := options.Workspace.Allocate(artifact.Metadata().Describe())
+						if err != nil {
+							return nil, err
+						}
+						// Release the scratch space as soon as the artifact is done
+						defer func() {
+							if err := scratch.Release(); err != nil {
+								b.Log.WithError(err).Warn("Failed to release scratch space")
+							}
+						}()
+						b.Scratch = scratch
+					}
+					tags, floatingTags, kernelBootTags, err := b.Do(e, time.Now())
+					if err != nil {
+						errString = err.Error()
+					}
+
+					if tags == nil && err == nil {
+						return nil, nil
+					}
 
-				return &api.ArtifactResult{
-					Tags:  tags,
-					Stage: StagePush,
-					Err:   errString,
-				}, err
-			})
+					return &api.ArtifactResult{
+						Tags:           tags,
+						FloatingTags:   floatingTags,
+						Digest:         b.Digest,
+						Stage:          StagePush,
+						Err:            errString,
+						KernelBootTags: kernelBootTags,
+						Registry:       privateRegistry(&options.PublishImagesOptions, artifact.Metadata()),
+						Forensics:      b.Forensics,
+						Warnings:       b.Warnings,
+						BuiltBy:        buildinfo.Get().String(),
+					}, err
+				})
+			if resultsChan == nil {
+				return workerErr
+			}
 
 			results := map[string]api.ArtifactResult{}
 			for result := range resultsChan {
@@ -77,28 +147,54 @@ func NewPublishImagesCommand(options *common.Options) *cobra.Command {
 			}
 
 			if !focusMatched {
-				logrus.Fatalf("no artifact was processed, focus '%s' did not match", options.Focus)
+				return fmt.Errorf("no artifact was processed, focus '%s' did not match", options.Focus)
 			}
 
 			if !options.DryRun {
 				if err := writeResultsFile(options.ImagesOptions.ResultsFile, results); err != nil {
-					logrus.Fatal(err)
+					return err
 				}
 			}
 
-			if workerErr != nil {
-				if options.PublishImagesOptions.NoFail {
-					logrus.Warn(workerErr)
-				} else {
-					logrus.Fatal(workerErr)
-				}
+			recordStage(options, StagePush, workerErr)
+
+			if workerErr != nil && options.PublishImagesOptions.NoFail {
+				logrus.Warn(workerErr)
+				return nil
 			}
+			return workerErr
 		},
 	}
+	publishCmd.Flags().BoolVar(&options.PublishImagesOptions.Chunked, "chunked",
+		options.PublishImagesOptions.Chunked, "Additionally publish the disks split into content-defined chunks as <tag>"+build.ChunkedTagSuffix)
+	publishCmd.Flags().StringVar(&options.PublishImagesOptions.DeltaCache, "delta-cache",
+		options.PublishImagesOptions.DeltaCache, "Directory keeping downloaded disks as base of zsync delta downloads of their next version")
+	publishCmd.Flags().BoolVar(&options.PublishImagesOptions.Differential, "differential",
+		options.PublishImagesOptions.Differential, "Skip artifacts whose unique upstream version tag is already published")
 	publishCmd.Flags().BoolVar(&options.PublishImagesOptions.ForceBuild, "force",
 		options.PublishImagesOptions.ForceBuild, "Force a rebuild and push")
+	publishCmd.Flags().BoolVar(&options.PublishImagesOptions.Harbor, "harbor",
+		options.PublishImagesOptions.Harbor, "Don't push existing unique tags again, so Harbor tag immutability rules can protect them")
+	publishCmd.Flags().StringSliceVar(&options.PublishImagesOptions.HarborLabels, "harbor-label",
+		options.PublishImagesOptions.HarborLabels, "Harbor label to attach to the pushed containerdisks, implies --harbor")
+	publishCmd.Flags().StringSliceVar(&options.PublishImagesOptions.HarborReplicationPolicies, "harbor-replication-policy",
+		options.PublishImagesOptions.HarborReplicationPolicies, "Harbor replication policy which has to succeed, implies --harbor")
+	publishCmd.Flags().DurationVar(&options.PublishImagesOptions.HarborReplicationTimeout, "harbor-replication-timeout",
+		defaultHarborReplicationTimeout, "Time to wait for the Harbor replication policies")
+	publishCmd.Flags().BoolVar(&options.PublishImagesOptions.Inventory, "inventory",
+		options.PublishImagesOptions.Inventory, "Inspect the disks with libguestfs and attach their package inventory as OCI referrer")
+	publishCmd.Flags().BoolVar(&options.PublishImagesOptions.InjectGuestAgent, "inject-guest-agent",
+		options.PublishImagesOptions.InjectGuestAgent, "Install the qemu-guest-agent into disks lacking it, implies --inventory")
+	publishCmd.Flags().BoolVar(&options.PublishImagesOptions.Locked, "locked",
+		options.PublishImagesOptions.Locked, "Only build the upstream versions pinned in the lock file")
+	publishCmd.Flags().BoolVar(&options.PublishImagesOptions.Metalink, "metalink",
+		options.PublishImagesOptions.Metalink, "Download disks from the mirrors listed in their Metalink, if upstream publishes one")
+	publishCmd.Flags().IntVar(&options.PublishImagesOptions.Attempts, "attempts",
+		options.PublishImagesOptions.Attempts, "How often a failing step like pushing a tag is run, without repeating the steps before it")
 	publishCmd.Flags().BoolVar(&options.PublishImagesOptions.NoFail, "no-fail",
 		options.PublishImagesOptions.NoFail, "Return success even if a worker fails")
+	publishCmd.Flags().StringVar(&options.PublishImagesOptions.PrivateRegistry, "private-registry",
+		options.PublishImagesOptions.PrivateRegistry, "Registry for containerdisks with private publish policy, they are not pushed without it")
 	publishCmd.Flags().StringVar(&options.PublishImagesOptions.SourceRegistry, "source-registry",
 		options.PublishImagesOptions.SourceRegistry, "Registry to check if updates are needed")
 	publishCmd.Flags().StringVar(&options.PublishImagesOptions.TargetRegistry, "target-registry",
@@ -107,65 +203,360 @@ func NewPublishImagesCommand(options *common.Options) *cobra.Command {
 	return publishCmd
 }
 
-func (b *buildAndPublish) Do(entry *common.Entry, timestamp time.Time) ([]string, error) {
+// lockedEntry returns a copy of the entry whose artifacts fail to inspect if upstream
+// does not serve the disks pinned in the lock file anymore.
+func lockedEntry(entry *common.Entry, lock *lockfile.Lock) *common.Entry {
+	locked := *entry
+	locked.Artifacts = make([]api.Artifact, 0, len(entry.Artifacts))
+	for _, artifact := range entry.Artifacts {
+		locked.Artifacts = append(locked.Artifacts, lock.Locked(artifact))
+	}
+	return &locked
+}
+
+func (b *buildAndPublish) Do(entry *common.Entry, timestamp time.Time) (tags, floating, kernelBootTags []string, err error) {
 	metadata := entry.Artifacts[0].Metadata()
 	artifactInfo, err := entry.Artifacts[0].Inspect()
 	if err != nil {
-		return nil, fmt.Errorf("error introspecting artifact %q: %v", metadata.Describe(), err)
+		return nil, nil, nil, fmt.Errorf("error introspecting artifact %q: %v", metadata.Describe(), err)
+	}
+
+	sourceRegistry, targetRegistry := publishRegistries(&b.Options.PublishImagesOptions, metadata)
+	if targetRegistry == "" {
+		return nil, nil, nil, b.buildOnly(entry, metadata.PublishPolicy)
 	}
 
-	rebuildNeeded, err := b.rebuildNeeded(entry)
+	if b.Options.PublishImagesOptions.Differential && !b.Options.PublishImagesOptions.ForceBuild {
+		published, err := b.versionPublished(entry, sourceRegistry)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if published {
+			b.Log.Info("Upstream version is already published, nothing to do.")
+			return nil, nil, nil, nil
+		}
+	}
+
+	rebuildNeeded, err := b.rebuildNeeded(entry, sourceRegistry)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 	if !rebuildNeeded && !b.Options.PublishImagesOptions.ForceBuild {
 		b.Log.Info("Nothing to do.")
-		return nil, nil
+		return nil, nil, nil, nil
 	}
 	if errors.Is(b.Ctx.Err(), context.Canceled) {
-		return nil, b.Ctx.Err()
+		return nil, nil, nil, b.Ctx.Err()
 	}
 
-	images, artifacts, err := b.buildImages(entry)
-	if err != nil {
-		return nil, err
+	var (
+		artifacts                          []string
+		containerDisk, chunked, kernelBoot publishable
+	)
+	defer func() { cleanupArtifacts(artifacts) }()
+
+	names := prepareTags(timestamp, targetRegistry, entry, artifactInfo)
+	if harborEnabled(&b.Options.PublishImagesOptions) {
+		if names, err = b.skipExistingUniqueTags(names, metadata.Variant.Tags(artifactInfo.AdditionalUniqueTags)); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if err := b.publishGraph(entry, names, &artifacts, &containerDisk, &chunked, &kernelBoot).Run(b.Ctx); err != nil {
+		return nil, nil, nil, err
+	}
+
+	tags = prepareTags(timestamp, "", entry, artifactInfo)
+	if !kernelBoot.empty() {
+		for _, tag := range tags {
+			kernelBootTags = append(kernelBootTags, tag+build.KernelBootTagSuffix)
+		}
+	}
+
+	return tags, floatingTags(tags, metadata.Variant.Tags(artifactInfo.AdditionalUniqueTags)), kernelBootTags, nil
+}
+
+// publishRegistries returns the registries to check for updates and to push the containerdisks of an artifact to,
+// according to its publish policy. An empty target registry means that the containerdisks must not be pushed.
+func publishRegistries(options *common.PublishImageOptions, metadata *api.Metadata) (sourceRegistry, targetRegistry string) {
+	switch metadata.PublishPolicy {
+	case api.PublishPolicyPrivate:
+		return options.PrivateRegistry, options.PrivateRegistry
+	case api.PublishPolicySkip:
+		return "", ""
+	default:
+		return options.SourceRegistry, options.TargetRegistry
+	}
+}
+
+// privateRegistry returns the registry the containerdisks of an artifact were pushed to if it is private.
+func privateRegistry(options *common.PublishImageOptions, metadata *api.Metadata) string {
+	if metadata.PublishPolicy == api.PublishPolicyPrivate {
+		return options.PrivateRegistry
 	}
+	return ""
+}
+
+// buildOnly builds the containerdisks of an entry which must not be pushed, so broken builds are still noticed.
+func (b *buildAndPublish) buildOnly(entry *common.Entry, policy api.PublishPolicy) error {
+	if policy == api.PublishPolicyPrivate {
+		b.Log.Info("No private registry configured, building the private containerdisk without pushing it")
+	} else {
+		b.Log.Infof("Publish policy %q, building the containerdisk without pushing it", policy)
+	}
+
+	_, _, artifacts, err := b.buildImages(entry)
 	defer cleanupArtifacts(artifacts)
+	if err != nil {
+		return err
+	}
+	_, kernelBootArtifacts, err := b.buildKernelBootImages(entry)
+	defer cleanupArtifacts(kernelBootArtifacts)
+	return err
+}
 
-	names := prepareTags(timestamp, b.Options.PublishImagesOptions.TargetRegistry, entry, artifactInfo)
-	for _, name := range names {
-		if len(images) > 1 {
-			containerDiskIndex, err := build.ContainerDiskIndex(images)
-			if err != nil {
-				return nil, fmt.Errorf("error creating the containerdisk index : %v", err)
+// publishRetryBackoff is the time to wait before a failed step is run again
+const publishRetryBackoff = 10 * time.Second
+
+// Steps of the publish graph of a containerdisk
+const (
+	stepBuild              = "build"
+	stepBuildKernelBoot    = "build kernel boot"
+	stepAssemble           = "assemble"
+	stepAssembleKernelBoot = "assemble kernel boot"
+	stepPush               = "push "
+	stepPushKernelBoot     = "push kernel boot "
+	stepPushChunked        = "push chunked "
+	stepPushInventory      = "push inventory"
+	stepCheckDigests       = "check digests"
+)
+
+// publishGraph models building the images of all architectures, assembling them into manifest lists and
+// pushing them with all names, so a failing step can be retried on its own. Names are pushed from the most
+// to the least specific one, and nothing is pushed before all images were built.
+func (b *buildAndPublish) publishGraph(
+	entry *common.Entry, names []string, artifacts *[]string, containerDisk, chunked, kernelBoot *publishable,
+) *dag.Graph {
+	var images, chunkedImages, kernelBootImages []v1.Image
+
+	g := dag.New()
+	g.Attempts = b.Options.PublishImagesOptions.Attempts
+	g.Backoff = publishRetryBackoff
+	g.OnRetry = func(node string, err error) {
+		b.Log.WithError(err).Warnf("Step %q failed, retrying...", node)
+	}
+
+	g.MustAdd(stepBuild, func(ctx context.Context) error {
+		progress.FromContext(ctx).SetState("building")
+		var built []string
+		var err error
+		b.Inventories = nil
+		b.Warnings = nil
+		images, chunkedImages, built, err = b.buildImages(entry)
+		*artifacts = append(*artifacts, built...)
+		return err
+	})
+	g.MustAdd(stepBuildKernelBoot, func(context.Context) error {
+		var built []string
+		var err error
+		kernelBootImages, built, err = b.buildKernelBootImages(entry)
+		*artifacts = append(*artifacts, built...)
+		return err
+	})
+	g.MustAdd(stepAssemble, func(context.Context) error {
+		var err error
+		if *containerDisk, err = assemble(images); err != nil {
+			return err
+		}
+		if b.Digest, err = containerDisk.digest(); err != nil {
+			return err
+		}
+		*chunked, err = assemble(chunkedImages)
+		return err
+	}, stepBuild)
+	g.MustAdd(stepAssembleKernelBoot, func(context.Context) error {
+		var err error
+		*kernelBoot, err = assemble(kernelBootImages)
+		return err
+	}, stepBuildKernelBoot)
+
+	var pushStarted time.Time
+	previous := []string{stepAssemble, stepAssembleKernelBoot}
+	for i, name := range names {
+		// The images are uploaded once with the first name, all other names only tag their manifests
+		push, state := b.publish, "pushing "
+		if i > 0 {
+			push, state = b.tag, "tagging "
+		}
+		g.MustAdd(stepPush+name, func(ctx context.Context) error {
+			if pushStarted.IsZero() {
+				pushStarted = time.Now()
 			}
-			if err := b.pushImageIndex(containerDiskIndex, name); err != nil {
-				return nil, err
+			progress.FromContext(ctx).SetState(state + name)
+			return push(containerDisk, name)
+		}, previous...)
+		g.MustAdd(stepPushKernelBoot+name, func(context.Context) error {
+			return push(kernelBoot, name+build.KernelBootTagSuffix)
+		}, stepPush+name)
+		g.MustAdd(stepPushChunked+name, func(context.Context) error {
+			return push(chunked, name+build.ChunkedTagSuffix)
+		}, stepPush+name)
+		previous = []string{stepPush + name, stepPushKernelBoot + name, stepPushChunked + name}
+	}
+	if len(names) > 0 {
+		g.MustAdd(stepPushInventory, func(context.Context) error {
+			return b.pushInventories(names[0])
+		}, stepPush+names[0])
+		g.MustAdd(stepCheckDigests, func(ctx context.Context) error {
+			progress.FromContext(ctx).SetState("checking digests")
+			return b.checkDigests(names, containerDisk, chunked, kernelBoot)
+		}, previous...)
+	}
+	if len(names) > 0 && harborEnabled(&b.Options.PublishImagesOptions) {
+		g.MustAdd(stepHarbor, func(context.Context) error {
+			return b.publishToHarbor(names[0], pushStarted)
+		}, previous...)
+	}
+
+	return g
+}
+
+// publishable is a single image or an index of the images of multiple architectures.
+type publishable struct {
+	image v1.Image
+	index v1.ImageIndex
+}
+
+func (p *publishable) empty() bool {
+	return p.image == nil && p.index == nil
+}
+
+// manifest returns the image or index, or nil if p is empty.
+func (p *publishable) manifest() remote.Taggable {
+	switch {
+	case p.index != nil:
+		return p.index
+	case p.image != nil:
+		return p.image
+	default:
+		return nil
+	}
+}
+
+// digest returns the digest of the image or index, or nothing if p is empty.
+func (p *publishable) digest() (string, error) {
+	var digest v1.Hash
+	var err error
+	switch {
+	case p.index != nil:
+		digest, err = p.index.Digest()
+	case p.image != nil:
+		digest, err = p.image.Digest()
+	default:
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error computing the digest of the containerdisk: %v", err)
+	}
+	return digest.String(), nil
+}
+
+// assemble wraps multiple images into an index, an empty list of images results in an empty publishable.
+func assemble(images []v1.Image) (publishable, error) {
+	if len(images) > 1 {
+		containerDiskIndex, err := build.ContainerDiskIndex(images)
+		if err != nil {
+			return publishable{}, fmt.Errorf("error creating the containerdisk index : %v", err)
+		}
+		return publishable{index: containerDiskIndex}, nil
+	} else if len(images) == 1 {
+		return publishable{image: images[0]}, nil
+	}
+
+	return publishable{}, nil
+}
+
+// publish pushes a single image or an index of multiple images to name, nothing is pushed if p is empty.
+func (b *buildAndPublish) publish(p *publishable, name string) error {
+	if p.index != nil {
+		return b.pushImageIndex(p.index, name)
+	} else if p.image != nil {
+		return b.pushImage(p.image, name)
+	}
+
+	return nil
+}
+
+// tag puts the manifest of a single image or index, which was published to the same repository before, under
+// name. Nothing is tagged if p is empty.
+func (b *buildAndPublish) tag(p *publishable, name string) error {
+	manifest := p.manifest()
+	if manifest == nil {
+		return nil
+	}
+	if b.Options.DryRun {
+		b.Log.Infof("Dry run enabled, not tagging %s", name)
+		return nil
+	}
+
+	b.Log.Infof("Tagging %s", name)
+	if err := b.Repo.TagManifest(b.Ctx, manifest, name); err != nil {
+		b.Log.WithError(err).Error("Failed to tag image")
+		return err
+	}
+	return nil
+}
+
+// checkDigests resolves all tags the containerdisk was published with and makes sure they reference the
+// pushed digests, so stale registry caches or partial retags are noticed before users pull the tags. The digest
+// of a manifest list covers the images of all architectures.
+func (b *buildAndPublish) checkDigests(names []string, containerDisk, chunked, kernelBoot *publishable) error {
+	if b.Options.DryRun {
+		return nil
+	}
+
+	var mismatches []string
+	for _, published := range []struct {
+		publishable *publishable
+		suffix      string
+	}{
+		{containerDisk, ""},
+		{kernelBoot, build.KernelBootTagSuffix},
+		{chunked, build.ChunkedTagSuffix},
+	} {
+		expected, err := published.publishable.digest()
+		if err != nil {
+			return err
+		}
+		if expected == "" {
+			continue
+		}
+		for _, name := range names {
+			ref := name + published.suffix
+			pinnedRef, err := b.Repo.PinnedReference(b.Ctx, ref, b.Options.AllowInsecureRegistry)
+			if err != nil {
+				return fmt.Errorf("error resolving the digest of %s: %v", ref, err)
 			}
-		} else if len(images) == 1 {
-			if err := b.pushImage(images[0], name); err != nil {
-				return nil, err
+			if digest := pinnedRef[strings.LastIndex(pinnedRef, "@")+1:]; digest != expected {
+				mismatches = append(mismatches, fmt.Sprintf("%s references %s instead of %s", ref, digest, expected))
 			}
 		}
-		if errors.Is(b.Ctx.Err(), context.Canceled) {
-			return nil, b.Ctx.Err()
-		}
 	}
-
-	return prepareTags(timestamp, "", entry, artifactInfo), nil
+	if len(mismatches) > 0 {
+		return fmt.Errorf("tags reference unexpected digests: %s", strings.Join(mismatches, ", "))
+	}
+	return nil
 }
 
-func (b *buildAndPublish) getImageChecksum(description, arch string) (imageChecksum string, err error) {
-	imageName := path.Join(b.Options.PublishImagesOptions.SourceRegistry, description)
+func (b *buildAndPublish) getImageLabels(imageName, arch string) (labels map[string]string, err error) {
 	imageInfo, err := b.Repo.ImageMetadata(imageName, arch, b.Options.AllowInsecureRegistry)
 	if err != nil {
 		err = b.handleMetadataError(imageName, err)
 	} else {
 		b.Log.Infof("Latest containerdisk checksum: %q", imageInfo.Labels[build.LabelShaSum])
-		imageChecksum = imageInfo.Labels[build.LabelShaSum]
+		labels = imageInfo.Labels
 	}
 
-	return imageChecksum, err
+	return labels, err
 }
 
 func (b *buildAndPublish) handleMetadataError(imageName string, err error) error {
@@ -186,13 +577,64 @@ func (b *buildAndPublish) handleMetadataError(imageName string, err error) error
 }
 
 func (b *buildAndPublish) getArtifact(artifactInfo *api.ArtifactDetails) (string, error) {
-	artifactReader, err := b.getArtifactReader(artifactInfo)
+	if artifactInfo.Generate != nil {
+		b.Log.Info("Rebuild needed, generating disk ...")
+		return b.generateArtifact(artifactInfo)
+	}
+
+	b.Log.Infof("Rebuild needed, downloading %q ...", artifactInfo.DownloadURL)
+	disk := &api.DiskDetails{
+		Checksum:       artifactInfo.Checksum,
+		ChecksumHash:   artifactInfo.ChecksumHash,
+		CrossChecksums: artifactInfo.CrossChecksums,
+		DownloadURL:    artifactInfo.DownloadURL,
+		Compression:    artifactInfo.Compression,
+	}
+
+	file, err := b.getDisk(disk)
+	if err != nil {
+		return "", err
+	}
+
+	artifactInfo.Checksum = disk.Checksum
+	return file, nil
+}
+
+// generateArtifact writes the locally generated disk of the artifact to a temporary file.
+func (b *buildAndPublish) generateArtifact(artifactInfo *api.ArtifactDetails) (string, error) {
+	file, err := b.createTemp("containerdisks")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := artifactInfo.ChecksumHash()
+	if err := artifactInfo.Generate(io.MultiWriter(file, hash)); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("error generating the disk: %v", err)
+	}
+	if checksum := hex.EncodeToString(hash.Sum(nil)); checksum != artifactInfo.Checksum {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("expected checksum %q of the generated disk but got %q", artifactInfo.Checksum, checksum)
+	}
+	return file.Name(), nil
+}
+
+func (b *buildAndPublish) getDisk(disk *api.DiskDetails) (string, error) {
+	downloadReader, err := b.getArtifactReader(disk.DownloadURL, disk.ChecksumHash)
+	if err != nil {
+		return "", err
+	}
+	defer downloadReader.Close()
+	artifactReader := newChunkRecordingReader(downloadReader)
+	crossCheckingReader, err := newCrossCheckingReader(artifactReader, disk.Checksum, disk.CrossChecksums)
 	if err != nil {
 		return "", err
 	}
-	defer artifactReader.Close()
 
-	file, err := b.readArtifact(artifactReader, artifactInfo.Compression)
+	file, err := b.readArtifact(crossCheckingReader, disk.Compression)
 	if err != nil {
 		return "", err
 	}
@@ -203,24 +645,33 @@ func (b *buildAndPublish) getArtifact(artifactInfo *api.ArtifactDetails) (string
 	checksum := artifactReader.Checksum()
 	// When the upstream checksum is empty (e.g. Fedora beta releases),
 	// use the computed checksum so it propagates to the container image label.
-	if artifactInfo.Checksum == "" {
-		artifactInfo.Checksum = checksum
-	} else if checksum != artifactInfo.Checksum {
-		return "", fmt.Errorf("expected checksum %q but got %q", artifactInfo.Checksum, checksum)
+	if disk.Checksum == "" {
+		disk.Checksum = checksum
+	} else if checksum != disk.Checksum {
+		b.investigateMismatch(disk, checksum, artifactReader)
+		return "", fmt.Errorf("expected checksum %q but got %q", disk.Checksum, checksum)
+	}
+	if err := crossCheckingReader.verify(); err != nil {
+		return "", err
 	}
 
 	return file, nil
 }
 
-func (b *buildAndPublish) getArtifactReader(artifactInfo *api.ArtifactDetails) (http.ReadCloserWithChecksum, error) {
+// getArtifactReader opens the download. The getter retries transient HTTP failures of a single request, failures
+// it gave up on, e.g. an exhausted retry budget, get another chance with a fresh request.
+func (b *buildAndPublish) getArtifactReader(downloadURL string, checksumHash func() hash.Hash) (http.ReadCloserWithChecksum, error) {
 	var artifactReader http.ReadCloserWithChecksum
 	var err error
 	const retries = 3
 	for range retries {
-		artifactReader, err = b.Getter.GetWithChecksumAndContext(b.Ctx, artifactInfo.DownloadURL, artifactInfo.ChecksumHash)
+		artifactReader, err = b.Getter.GetWithChecksumAndContext(b.Ctx, downloadURL, checksumHash)
 		if err == nil {
 			return artifactReader, nil
 		}
+		if b.Ctx.Err() != nil {
+			return nil, b.Ctx.Err()
+		}
 		b.Log.Infof("Artifact download verification failed, retrying...")
 	}
 	return nil, fmt.Errorf("error opening a connection to the specified download location: %v", err)
@@ -232,29 +683,50 @@ func (b *buildAndPublish) readArtifact(artifactReader http.ReadCloserWithChecksu
 	// Initialize reader with the artifactReader for the case where no compression is used
 	var reader io.Reader = artifactReader
 
+	// Decompression bombs fail instead of filling the disk
+	guard := http.NewDecompressionGuard()
+	compressed := guard.Compressed(artifactReader)
 	switch compression {
 	case types.GzipAlgorithmName:
-		reader, err = gzip.NewReader(artifactReader)
+		reader, err = newGzipReader(compressed)
 		if err != nil {
 			return "", fmt.Errorf("error creating a gunzip reader for the specified download location: %v", err)
 		}
+		reader = guard.Decompressed(reader)
 	case types.XzAlgorithmName:
-		reader, err = xz.NewReader(artifactReader)
+		reader, err = xz.NewReader(compressed)
 		if err != nil {
 			return "", fmt.Errorf("error creating a lzma reader for the specified download location: %v", err)
 		}
+		reader = guard.Decompressed(reader)
+	case types.Bzip2AlgorithmName:
+		reader = guard.Decompressed(bzip2.NewReader(compressed))
 	}
 
-	file, err := os.CreateTemp("", "containerdisks")
+	file, err := b.createTemp("containerdisks")
 	if err != nil {
 		return "", err
 	}
 	defer file.Close()
+	var writer io.Writer = file
+	if b.Scratch != nil {
+		writer = b.Scratch.Writer(file)
+	}
+
+	// Don't leave partial downloads behind on errors or interruptions
+	complete := false
+	defer func() {
+		if !complete {
+			// Windows can't remove open files
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}()
 
 	// Uncompress disks in chunks up to size defined below
 	const chunkSize = 1024 * 1024 * 50 // MiB
 	for {
-		_, err := io.CopyN(file, reader, chunkSize)
+		_, err := buffers.CopyN(writer, reader, chunkSize)
 		if err != nil {
 			if err == io.EOF {
 				break
@@ -266,36 +738,161 @@ func (b *buildAndPublish) readArtifact(artifactReader http.ReadCloserWithChecksu
 		}
 	}
 
+	complete = true
 	return file.Name(), nil
 }
 
-func (b *buildAndPublish) buildImages(entry *common.Entry) ([]v1.Image, []string, error) {
-	var images []v1.Image
-	var artifacts []string
+// checkSizeBudget reports disks outside of the size budget of their artifact, it only fails if the budget is enforced.
+func (b *buildAndPublish) checkSizeBudget(metadata *api.Metadata, actualSize int64) error {
+	err := metadata.SizeBudget.Check(actualSize)
+	if err == nil {
+		return nil
+	}
+	if metadata.SizeBudget.Enforce {
+		return err
+	}
+	b.warn(err.Error())
+	return nil
+}
+
+func (b *buildAndPublish) createTemp(pattern string) (*os.File, error) {
+	if b.Scratch != nil {
+		return b.Scratch.CreateTemp(pattern)
+	}
+	return os.CreateTemp("", pattern)
+}
 
+// buildImages builds the containerdisks of all artifacts and, if requested, their chunked variants.
+func (b *buildAndPublish) buildImages(entry *common.Entry) (images, chunkedImages []v1.Image, artifacts []string, err error) {
 	for i := range entry.Artifacts {
 		metadata := entry.Artifacts[i].Metadata()
 		artifactInfo, err := entry.Artifacts[i].Inspect()
 		if err != nil {
-			return nil, nil, fmt.Errorf("error introspecting artifact %q: %v", metadata.Describe(), err)
+			return nil, nil, artifacts, fmt.Errorf("error introspecting artifact %q: %v", metadata.Describe(), err)
 		}
 
-		b.Log.Infof("Rebuild needed, downloading %q ...", artifactInfo.DownloadURL)
 		file, err := b.getArtifact(artifactInfo)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, artifacts, err
 		}
 		artifacts = append(artifacts, file)
 
+		var diskInventory *inventory.Inventory
+		if b.Options.PublishImagesOptions.Inventory || b.Options.PublishImagesOptions.InjectGuestAgent {
+			if diskInventory, err = b.checkGuestAgent(file, b.collectInventory(file), metadata); err != nil {
+				return nil, nil, artifacts, err
+			}
+		}
+
+		artifactInfo.VirtualSize, artifactInfo.ActualSize, err = build.DiskSize(file)
+		if err != nil {
+			return nil, nil, artifacts, fmt.Errorf("error determining the disk size: %v", err)
+		}
+		b.Log.Infof("Disk virtual size: %d bytes, actual size: %d bytes", artifactInfo.VirtualSize, artifactInfo.ActualSize)
+		if err = b.checkSizeBudget(metadata, artifactInfo.ActualSize); err != nil {
+			return nil, nil, artifacts, err
+		}
+
+		config := build.ContainerDiskConfig(artifactInfo.Checksum, metadata.EnvVariables)
+		maps.Copy(config.Labels, build.DiskSizeLabels(artifactInfo.VirtualSize, artifactInfo.ActualSize))
+		annotations := provenance.Annotations(metadata)
+		maps.Copy(annotations, buildinfo.Get().Annotations())
+		if diskInventory != nil {
+			annotations[inventory.AnnotationSummary] = diskInventory.Summary()
+			annotations[inventory.AnnotationGuestAgent] = strconv.FormatBool(diskInventory.HasGuestAgent())
+		}
+		maps.Copy(config.Labels, annotations)
+		// Chunked containerdisks only contain the primary disk
+		chunkedConfig := config
+		chunkedConfig.Labels = maps.Clone(config.Labels)
+		var additionalDisks []build.AdditionalDisk
+		for j := range artifactInfo.AdditionalDisks {
+			disk := &artifactInfo.AdditionalDisks[j]
+			b.Log.Infof("Downloading additional disk %q from %q ...", disk.Name, disk.DownloadURL)
+			diskFile, err := b.getDisk(disk)
+			if err != nil {
+				return nil, nil, artifacts, err
+			}
+			artifacts = append(artifacts, diskFile)
+			additionalDisks = append(additionalDisks, build.AdditionalDisk{Name: disk.Name, Path: diskFile})
+			config.Labels[build.AdditionalDiskLabel(disk.Name)] = disk.Checksum
+		}
+
 		b.Log.Info("Building containerdisk ...")
-		image, err := build.ContainerDisk(file,
-			artifactInfo.ImageArchitecture,
-			build.ContainerDiskConfig(artifactInfo.Checksum, metadata.EnvVariables))
+		image, err := build.ContainerDiskWithCompression(b.layerCompression(metadata), file, artifactInfo.ImageArchitecture,
+			config, additionalDisks...)
+		if err != nil {
+			return nil, nil, artifacts, fmt.Errorf("error creating the containerdisk : %v", err)
+		}
+		image = build.Annotate(build.AnnotateDiskSize(image, artifactInfo.VirtualSize, artifactInfo.ActualSize), annotations)
+		if errors.Is(b.Ctx.Err(), context.Canceled) {
+			return nil, nil, artifacts, b.Ctx.Err()
+		}
+		images = append(images, image)
+		if diskInventory != nil {
+			b.Inventories = append(b.Inventories, imageInventory{image: image, arch: metadata.Arch, inventory: diskInventory})
+		}
+
+		if b.Options.PublishImagesOptions.Chunked {
+			b.Log.Info("Building chunked containerdisk ...")
+			chunked, err := build.ChunkedContainerDisk(file, artifactInfo.ImageArchitecture, chunkedConfig)
+			if err != nil {
+				return nil, nil, artifacts, fmt.Errorf("error creating the chunked containerdisk : %v", err)
+			}
+			chunked = build.AnnotateDiskSize(chunked, artifactInfo.VirtualSize, artifactInfo.ActualSize)
+			chunkedImages = append(chunkedImages, build.Annotate(chunked, annotations))
+		}
+	}
+	b.checkDrift()
+
+	return images, chunkedImages, artifacts, nil
+}
+
+// layerCompression returns the compression of the layers of a containerdisk, which metadata can override.
+func (b *buildAndPublish) layerCompression(metadata *api.Metadata) build.Compression {
+	compression := b.Options.ImagesOptions.Compression
+	if metadata.LayerCompression != "" && metadata.LayerCompression != compression.Algorithm {
+		compression = build.Compression{Algorithm: metadata.LayerCompression, Threads: compression.Threads}
+	}
+	return compression
+}
+
+func (b *buildAndPublish) buildKernelBootImages(entry *common.Entry) ([]v1.Image, []string, error) {
+	var images []v1.Image
+	var artifacts []string
+
+	for i := range entry.Artifacts {
+		metadata := entry.Artifacts[i].Metadata()
+		artifactInfo, err := entry.Artifacts[i].Inspect()
+		if err != nil {
+			return nil, artifacts, fmt.Errorf("error introspecting artifact %q: %v", metadata.Describe(), err)
+		}
+		if artifactInfo.KernelBoot == nil {
+			continue
+		}
+
+		b.Log.Infof("Downloading kernel %q ...", artifactInfo.KernelBoot.Kernel.DownloadURL)
+		kernel, err := b.getDisk(&artifactInfo.KernelBoot.Kernel)
+		if err != nil {
+			return nil, artifacts, err
+		}
+		artifacts = append(artifacts, kernel)
+
+		b.Log.Infof("Downloading initrd %q ...", artifactInfo.KernelBoot.Initrd.DownloadURL)
+		initrd, err := b.getDisk(&artifactInfo.KernelBoot.Initrd)
+		if err != nil {
+			return nil, artifacts, err
+		}
+		artifacts = append(artifacts, initrd)
+
+		b.Log.Info("Building kernel boot container ...")
+		config := build.ContainerDiskConfig(artifactInfo.KernelBoot.Kernel.Checksum, metadata.EnvVariables)
+		image, err := build.KernelBootContainer(kernel, initrd, artifactInfo.ImageArchitecture, config)
 		if err != nil {
-			return nil, nil, fmt.Errorf("error creating the containerdisk : %v", err)
+			return nil, artifacts, fmt.Errorf("error creating the kernel boot container : %v", err)
 		}
 		if errors.Is(b.Ctx.Err(), context.Canceled) {
-			return nil, nil, b.Ctx.Err()
+			return nil, artifacts, b.Ctx.Err()
 		}
 		images = append(images, image)
 	}
@@ -303,7 +900,7 @@ func (b *buildAndPublish) buildImages(entry *common.Entry) ([]v1.Image, []string
 	return images, artifacts, nil
 }
 
-func (b *buildAndPublish) rebuildNeeded(entry *common.Entry) (bool, error) {
+func (b *buildAndPublish) rebuildNeeded(entry *common.Entry, sourceRegistry string) (bool, error) {
 	if len(entry.Artifacts) == 0 {
 		err := errors.New("entry has no artifacts to check for rebuild")
 		b.Log.Error(err)
@@ -316,18 +913,50 @@ func (b *buildAndPublish) rebuildNeeded(entry *common.Entry) (bool, error) {
 		if err != nil {
 			return false, fmt.Errorf("error introspecting artifact %q: %v", metadata.Describe(), err)
 		}
-		imageChecksum, err := b.getImageChecksum(metadata.Describe(), artifactInfo.ImageArchitecture)
+		labels, err := b.getImageLabels(entry.Repository(sourceRegistry)+":"+metadata.Tag(), artifactInfo.ImageArchitecture)
 		if err != nil {
 			return false, err
 		}
-		if imageChecksum != artifactInfo.Checksum {
+		if labels[build.LabelShaSum] != artifactInfo.Checksum {
 			return true, nil
 		}
+		for _, disk := range artifactInfo.AdditionalDisks {
+			if labels[build.AdditionalDiskLabel(disk.Name)] != disk.Checksum {
+				return true, nil
+			}
+		}
 	}
 
 	return false, nil
 }
 
+// versionPublished checks if the unique version tags of all artifacts of the entry already exist in the
+// source registry. Artifacts without unique tags can't be told apart by tags and are never considered published.
+func (b *buildAndPublish) versionPublished(entry *common.Entry, sourceRegistry string) (bool, error) {
+	repo := entry.Repository(sourceRegistry)
+	tags, err := b.Repo.ListTags(b.Ctx, repo, b.Options.AllowInsecureRegistry)
+	if err != nil {
+		return false, fmt.Errorf("error listing tags of %q: %v", repo, err)
+	}
+
+	for i := range entry.Artifacts {
+		artifactInfo, err := entry.Artifacts[i].Inspect()
+		if err != nil {
+			return false, fmt.Errorf("error introspecting artifact %q: %v", entry.Artifacts[i].Metadata().Describe(), err)
+		}
+		if len(artifactInfo.AdditionalUniqueTags) == 0 {
+			return false, nil
+		}
+		for _, tag := range entry.Artifacts[i].Metadata().Variant.Tags(artifactInfo.AdditionalUniqueTags) {
+			if !slices.Contains(tags, tag) {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
+
 func (b *buildAndPublish) pushImage(containerDisk v1.Image, name string) error {
 	if !b.Options.DryRun {
 		b.Log.Infof("Pushing %s", name)
@@ -358,25 +987,36 @@ func (b *buildAndPublish) pushImageIndex(containerDiskIndex v1.ImageIndex, name
 
 func prepareTags(timestamp time.Time, registry string, entry *common.Entry, artifactDetails *api.ArtifactDetails) []string {
 	metadata := entry.Artifacts[0].Metadata()
-	imageName := path.Join(registry, metadata.Describe())
+	repo := entry.Repository(registry)
 
-	names := []string{fmt.Sprintf("%s-%s", imageName, timestamp.Format("0601021504"))}
-	for _, tag := range artifactDetails.AdditionalUniqueTags {
-		if tag == "" {
-			continue
-		}
-		names = append(names, fmt.Sprintf("%s:%s", path.Join(registry, metadata.Name), tag))
-	}
+	names := []string{fmt.Sprintf("%s:%s", repo, metadata.Variant.Tag(metadata.Version+"-"+timestamp.Format("0601021504")))}
 	// the least specific tag is last
-	names = append(names, imageName)
-
-	if entry.UseForLatest {
-		names = append(names, fmt.Sprintf("%s:%s", path.Join(registry, metadata.Name), "latest"))
+	tags := metadata.Variant.Tags(
+		tagpolicy.Tags(metadata.TagScheme, metadata.Version, artifactDetails.AdditionalUniqueTags, entry.Aliases, entry.UseForLatest),
+	)
+	for _, tag := range tags {
+		names = append(names, fmt.Sprintf("%s:%s", repo, tag))
 	}
 
 	return names
 }
 
+// floatingTags returns the tags of a containerdisk which move from build to build. The first tag is unique
+// to the build and the additional unique tags of the artifact are pinned to its version as well.
+func floatingTags(tags, uniqueTags []string) []string {
+	var floating []string
+	for i, name := range tags {
+		if i == 0 {
+			continue
+		}
+		if slices.Contains(uniqueTags, name[strings.LastIndex(name, ":")+1:]) {
+			continue
+		}
+		floating = append(floating, name)
+	}
+	return floating
+}
+
 func cleanupArtifacts(artifacts []string) {
 	for _, file := range artifacts {
 		os.Remove(file)
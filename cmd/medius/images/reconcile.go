@@ -0,0 +1,243 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	kvirtcli "kubevirt.io/client-go/kubecli"
+
+	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/apis/v1alpha1"
+	"kubevirt.io/containerdisks/pkg/architecture"
+	"kubevirt.io/containerdisks/pkg/hashsum"
+	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/repository"
+)
+
+type reconcileOptions struct {
+	Namespace string
+	Interval  time.Duration
+	Once      bool
+}
+
+func NewReconcileCommand(options *common.Options) *cobra.Command {
+	const defaultInterval = time.Hour
+	reconcileOptions := &reconcileOptions{
+		Interval: defaultInterval,
+	}
+
+	reconcileCmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Build and publish the containerdisks declared by ContainerDiskSources in a cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := kvirtcli.GetKubevirtClient()
+			if err != nil {
+				return err
+			}
+			return reconcileLoop(cmd.Context(), client.DynamicClient(), options, reconcileOptions)
+		},
+	}
+	reconcileCmd.Flags().StringVar(&reconcileOptions.Namespace, "namespace",
+		reconcileOptions.Namespace, "Namespace to watch ContainerDiskSources in, defaults to all namespaces")
+	reconcileCmd.Flags().DurationVar(&reconcileOptions.Interval, "interval",
+		reconcileOptions.Interval, "Interval to check ContainerDiskSources and their upstreams for updates")
+	reconcileCmd.Flags().BoolVar(&reconcileOptions.Once, "once",
+		reconcileOptions.Once, "Reconcile all ContainerDiskSources once and exit")
+	reconcileCmd.Flags().AddGoFlagSet(kvirtcli.FlagSet())
+
+	return reconcileCmd
+}
+
+func reconcileLoop(ctx context.Context, client dynamic.Interface, options *common.Options, reconcileOptions *reconcileOptions) error {
+	for {
+		if err := reconcileAll(ctx, client, options, reconcileOptions.Namespace); err != nil {
+			if reconcileOptions.Once || errors.Is(err, context.Canceled) {
+				return err
+			}
+			logrus.WithError(err).Error("Reconciling ContainerDiskSources failed")
+		} else if reconcileOptions.Once {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(reconcileOptions.Interval):
+		}
+	}
+}
+
+func reconcileAll(ctx context.Context, client dynamic.Interface, options *common.Options, namespace string) error {
+	resource := client.Resource(v1alpha1.GroupVersionResource).Namespace(namespace)
+	list, err := resource.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing ContainerDiskSources: %v", err)
+	}
+
+	var errs []error
+	for i := range list.Items {
+		obj := &list.Items[i]
+		source := &v1alpha1.ContainerDiskSource{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, source); err != nil {
+			errs = append(errs, fmt.Errorf("error converting ContainerDiskSource %s/%s: %v", obj.GetNamespace(), obj.GetName(), err))
+			continue
+		}
+
+		status := reconcileSource(ctx, options, &http.HTTPGetter{}, source)
+		if options.DryRun {
+			logrus.Infof("Dry run enabled, not updating status of ContainerDiskSource %s/%s", source.Namespace, source.Name)
+			continue
+		}
+		if err := updateStatus(ctx, client, obj, status); err != nil {
+			errs = append(errs, err)
+		}
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return ctx.Err()
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// reconcileSource publishes the containerdisk declared by the source, if it is not published yet.
+func reconcileSource(ctx context.Context, options *common.Options, getter http.Getter,
+	source *v1alpha1.ContainerDiskSource,
+) v1alpha1.ContainerDiskSourceStatus {
+	status := source.Status
+	status.ObservedGeneration = source.Generation
+
+	artifact, err := ArtifactForSource(getter, source)
+	if err == nil {
+		sourceOptions := *options
+		registry := path.Dir(source.Spec.TargetRepository)
+		sourceOptions.PublishImagesOptions.SourceRegistry = registry
+		sourceOptions.PublishImagesOptions.TargetRegistry = registry
+
+		b := buildAndPublish{
+			Ctx:     ctx,
+			Log:     common.Logger(artifact).WithField("source", source.Namespace+"/"+source.Name),
+			Options: &sourceOptions,
+			Repo:    repository.New(),
+			Getter:  getter,
+		}
+		var tags []string
+		tags, _, _, err = b.Do(&common.Entry{Artifacts: []api.Artifact{artifact}}, time.Now())
+		if err == nil && tags != nil {
+			details, _ := artifact.Inspect()
+			status.Checksum = details.Checksum
+			status.Tags = tags
+			now := metav1.Now()
+			status.LastPublished = &now
+		}
+	}
+
+	if err != nil {
+		status.Phase = v1alpha1.PhaseFailed
+		status.Message = err.Error()
+	} else {
+		status.Phase = v1alpha1.PhasePublished
+		status.Message = ""
+	}
+
+	return status
+}
+
+func updateStatus(ctx context.Context, client dynamic.Interface, obj *unstructured.Unstructured,
+	status v1alpha1.ContainerDiskSourceStatus,
+) error {
+	unstructuredStatus, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		return fmt.Errorf("error converting status of ContainerDiskSource %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+	}
+	obj.Object["status"] = unstructuredStatus
+
+	_, err = client.Resource(v1alpha1.GroupVersionResource).Namespace(obj.GetNamespace()).UpdateStatus(ctx, obj, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("error updating status of ContainerDiskSource %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+	}
+
+	return nil
+}
+
+// ArtifactForSource turns a ContainerDiskSource into an artifact, resolving the checksum from the checksum file if needed.
+func ArtifactForSource(getter http.Getter, source *v1alpha1.ContainerDiskSource) (api.Artifact, error) {
+	spec := &source.Spec
+	if spec.URL == "" || spec.Version == "" || spec.TargetRepository == "" {
+		return nil, errors.New("url, version and targetRepository are required")
+	}
+
+	checksum := spec.Checksum
+	if checksum == "" {
+		if spec.ChecksumURL == "" {
+			return nil, errors.New("either checksum or checksumURL is required")
+		}
+		var err error
+		if checksum, err = lookupChecksum(getter, spec.ChecksumURL, spec.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	arch := spec.Arch
+	if arch == "" {
+		arch = "x86_64"
+	}
+
+	return generic.New(
+		&api.ArtifactDetails{
+			Checksum:          checksum,
+			ChecksumHash:      sha256.New,
+			DownloadURL:       spec.URL,
+			Compression:       spec.Compression,
+			ImageArchitecture: architecture.GetImageArchitecture(arch),
+		},
+		&api.Metadata{
+			Name:             path.Base(spec.TargetRepository),
+			Version:          spec.Version,
+			Description:      fmt.Sprintf("Declared by ContainerDiskSource %s/%s", source.Namespace, source.Name),
+			Arch:             arch,
+			TagScheme:        spec.TagPolicy,
+			License:          spec.License,
+			OSInfo:           spec.OSInfo,
+			LayerCompression: spec.LayerCompression,
+		},
+	), nil
+}
+
+func lookupChecksum(getter http.Getter, checksumURL, diskURL string) (string, error) {
+	raw, err := getter.GetAll(checksumURL)
+	if err != nil {
+		return "", fmt.Errorf("error downloading checksum file: %v", err)
+	}
+
+	u, err := url.Parse(diskURL)
+	if err != nil {
+		return "", fmt.Errorf("error parsing url %s: %v", diskURL, err)
+	}
+	fileName := path.Base(u.Path)
+
+	for _, format := range []hashsum.ChecksumFormat{hashsum.ChecksumFormatBSD, hashsum.ChecksumFormatGNU} {
+		checksums, err := hashsum.Parse(bytes.NewReader(raw), format)
+		if err != nil {
+			return "", fmt.Errorf("error parsing checksum file: %v", err)
+		}
+		if checksum, ok := checksums[fileName]; ok {
+			return checksum, nil
+		}
+	}
+
+	return "", fmt.Errorf("no checksum for %s found in %s", fileName, checksumURL)
+}
@@ -0,0 +1,73 @@
+package images
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/apis/v1alpha1"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+var _ = Describe("ArtifactForSource", func() {
+	const gnuChecksums = "aaaa  other.qcow2\nbbbb  disk.qcow2\n"
+
+	newSource := func(spec v1alpha1.ContainerDiskSourceSpec) *v1alpha1.ContainerDiskSource {
+		source := &v1alpha1.ContainerDiskSource{Spec: spec}
+		source.Namespace = "default"
+		source.Name = "disk"
+		return source
+	}
+
+	It("should use the checksum of the spec", func() {
+		source := newSource(v1alpha1.ContainerDiskSourceSpec{
+			URL:              "https://example.com/images/disk.qcow2",
+			Checksum:         "cccc",
+			Version:          "1.0",
+			TargetRepository: "quay.io/org/disk",
+		})
+		artifact, err := ArtifactForSource(testutil.NewMockGetterFromBytes(nil), source)
+		Expect(err).ToNot(HaveOccurred())
+
+		details, err := artifact.Inspect()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(details.Checksum).To(Equal("cccc"))
+		Expect(artifact.Metadata().Name).To(Equal("disk"))
+		Expect(artifact.Metadata().Arch).To(Equal("x86_64"))
+	})
+
+	It("should resolve the checksum from the checksum file", func() {
+		source := newSource(v1alpha1.ContainerDiskSourceSpec{
+			URL:              "https://example.com/images/disk.qcow2",
+			ChecksumURL:      "https://example.com/images/SHA256SUMS",
+			Version:          "1.0",
+			TargetRepository: "quay.io/org/disk",
+		})
+		artifact, err := ArtifactForSource(testutil.NewMockGetterFromBytes([]byte(gnuChecksums)), source)
+		Expect(err).ToNot(HaveOccurred())
+
+		details, err := artifact.Inspect()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(details.Checksum).To(Equal("bbbb"))
+	})
+
+	It("should fail if the checksum file has no entry for the disk", func() {
+		source := newSource(v1alpha1.ContainerDiskSourceSpec{
+			URL:              "https://example.com/images/missing.qcow2",
+			ChecksumURL:      "https://example.com/images/SHA256SUMS",
+			Version:          "1.0",
+			TargetRepository: "quay.io/org/disk",
+		})
+		_, err := ArtifactForSource(testutil.NewMockGetterFromBytes([]byte(gnuChecksums)), source)
+		Expect(err).To(MatchError(ContainSubstring("no checksum for missing.qcow2")))
+	})
+
+	It("should require a checksum", func() {
+		source := newSource(v1alpha1.ContainerDiskSourceSpec{
+			URL:              "https://example.com/images/disk.qcow2",
+			Version:          "1.0",
+			TargetRepository: "quay.io/org/disk",
+		})
+		_, err := ArtifactForSource(testutil.NewMockGetterFromBytes(nil), source)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"errors"
 	"fmt"
+	"maps"
 	"path"
 	"slices"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"golang.org/x/crypto/ssh"
+	k8sv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	urand "k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -24,85 +26,155 @@ import (
 	"kubevirt.io/containerdisks/cmd/medius/common"
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/architecture"
+	"kubevirt.io/containerdisks/pkg/boottime"
+	pkgcommon "kubevirt.io/containerdisks/pkg/common"
 	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/metrics"
+	"kubevirt.io/containerdisks/pkg/progress"
+	"kubevirt.io/containerdisks/pkg/quarantine"
+	"kubevirt.io/containerdisks/pkg/repository"
+	"kubevirt.io/containerdisks/pkg/rollout"
 )
 
 func NewVerifyImagesCommand(options *common.Options) *cobra.Command {
 	options.VerifyImagesOptions = common.VerifyImageOptions{
-		Namespace: "kubevirt",
-		Timeout:   600,
+		Namespace:         "kubevirt",
+		Timeout:           600,
+		MaxFailures:       quarantine.DefaultMaxFailures,
+		BootTimeThreshold: boottime.DefaultThreshold,
 	}
 
 	verifyCmd := &cobra.Command{
 		Use:   "verify",
 		Short: "Verify that containerdisks are bootable and guests are working",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			results, err := readResultsFile(options.ImagesOptions.ResultsFile)
 			if err != nil {
-				logrus.Fatal(err)
+				return err
 			}
 
 			// Silence the kubevirt client log
 			kvirtlog.Log = kvirtlog.MakeLogger(kvirtlog.NullLogger{})
 			client, err := kvirtcli.GetKubevirtClient()
 			if err != nil {
-				logrus.Fatal(err)
+				return err
 			}
 
 			// Set target architecture
-			defineTargetArch(options, client)
-
-			focusMatched, resultsChan, workerErr := spawnWorkers(cmd.Context(), options, func(e *common.Entry) (*api.ArtifactResult, error) {
-				artifact, err := retrieveArchitectureArtifact(options, e)
-				if err != nil {
-					firstArtifactMedatada := e.Artifacts[0].Metadata()
-					logrus.Warn("Skipped " + firstArtifactMedatada.Name + ":" + firstArtifactMedatada.Version + " - " + err.Error())
-					return nil, nil
-				}
-				description := artifact.Metadata().Describe()
-				r, ok := results[description]
-				if !ok {
-					return nil, nil
-				}
-				if r.Err != "" {
-					return nil, fmt.Errorf("artifact %s failed in stage %s: %s", description, r.Stage, r.Err)
-				}
-				if r.Stage != StagePush {
-					return nil, nil
-				}
+			if err := defineTargetArch(options, client); err != nil {
+				return err
+			}
 
-				errString := ""
-				err = verifyArtifact(cmd.Context(), artifact, r, options, client)
-				if err != nil {
-					errString = err.Error()
-				}
+			q, err := readQuarantine(&options.VerifyImagesOptions)
+			if err != nil {
+				return err
+			}
+
+			if err := addRolloutCandidates(results, &options.VerifyImagesOptions); err != nil {
+				return err
+			}
+
+			bootTimes, err := readBootTimes(&options.VerifyImagesOptions)
+			if err != nil {
+				return err
+			}
 
-				return &api.ArtifactResult{
-					Tags:  r.Tags,
-					Stage: StageVerify,
-					Err:   errString,
-				}, err
-			})
+			focusMatched, resultsChan, workerErr := spawnWorkers(cmd.Context(), options, "",
+				func(ctx context.Context, e *common.Entry) (*api.ArtifactResult, error) {
+					artifact, err := retrieveArchitectureArtifact(options, e)
+					if err != nil {
+						firstArtifactMedatada := e.Artifacts[0].Metadata()
+						logrus.Warn("Skipped " + firstArtifactMedatada.Name + ":" + firstArtifactMedatada.Version + " - " + err.Error())
+						return nil, nil
+					}
+					description := artifact.Metadata().Describe()
+					r, ok := results[description]
+					if !ok {
+						return nil, nil
+					}
+					if r.Err != "" {
+						return nil, fmt.Errorf("artifact %s failed in stage %s: %s", description, r.Stage, r.Err)
+					}
+					if r.Stage != StagePush {
+						return nil, nil
+					}
+
+					errString := ""
+					var warnings []string
+					bootTime, err := verifyArtifact(ctx, artifact, r, options, client)
+					if err != nil {
+						errString = err.Error()
+					} else if bootTime > 0 {
+						warnings = recordBootTime(options, bootTimes, artifact, r.Tags[0], bootTime)
+					}
+					quarantined := false
+					if !errors.Is(err, context.Canceled) {
+						recordVerificationMetrics(options, description, err)
+						if q != nil {
+							quarantined = recordVerification(q, description, err)
+						}
+					}
+
+					result := &api.ArtifactResult{
+						Tags:           r.Tags,
+						Digest:         r.Digest,
+						Stage:          StageVerify,
+						Err:            errString,
+						KernelBootTags: r.KernelBootTags,
+						FloatingTags:   r.FloatingTags,
+						Registry:       r.Registry,
+						Quarantined:    quarantined,
+						Rollout:        r.Rollout,
+						Warnings:       warnings,
+						Architecture:   options.VerifyImagesOptions.TargetArchitecture,
+						BuiltBy:        r.BuiltBy,
+					}
+					if quarantined {
+						common.Logger(artifact).WithError(err).Warn("Containerdisk is quarantined, its failure does not fail the run")
+						return result, nil
+					}
+					return result, err
+				})
+			if resultsChan == nil {
+				return workerErr
+			}
 
 			for result := range resultsChan {
 				results[result.Key] = result.Value
 			}
 
+			if q != nil {
+				for _, name := range q.Quarantined() {
+					record, _ := q.Get(name)
+					logrus.Warnf("Quarantined: %s failed %d times in a row since %s: %s",
+						name, record.Failures, record.Since.Format(time.RFC3339), record.LastError)
+				}
+				if err := q.Write(options.VerifyImagesOptions.QuarantineFile); err != nil {
+					return err
+				}
+			}
+
+			if bootTimes != nil {
+				if err := bootTimes.Write(options.VerifyImagesOptions.BootTimeFile); err != nil {
+					return err
+				}
+			}
+
 			if !focusMatched {
-				logrus.Fatalf("no artifact was processed, focus '%s' did not match", options.Focus)
+				return fmt.Errorf("no artifact was processed, focus '%s' did not match", options.Focus)
 			}
 
 			if err := writeResultsFile(options.ImagesOptions.ResultsFile, results); err != nil {
-				logrus.Fatal(err)
+				return err
 			}
 
-			if workerErr != nil {
-				if options.VerifyImagesOptions.NoFail {
-					logrus.Warn(workerErr)
-				} else {
-					logrus.Fatal(workerErr)
-				}
+			recordStage(options, StageVerify, workerErr)
+
+			if workerErr != nil && options.VerifyImagesOptions.NoFail {
+				logrus.Warn(workerErr)
+				return nil
 			}
+			return workerErr
 		},
 	}
 	verifyCmd.Flags().StringVar(&options.VerifyImagesOptions.Registry, "registry",
@@ -111,6 +183,18 @@ func NewVerifyImagesCommand(options *common.Options) *cobra.Command {
 		options.VerifyImagesOptions.Namespace, "Namespace to run verify in")
 	verifyCmd.Flags().BoolVar(&options.VerifyImagesOptions.NoFail, "no-fail",
 		options.VerifyImagesOptions.NoFail, "Return success even if a worker fails")
+	verifyCmd.Flags().StringVar(&options.VerifyImagesOptions.QuarantineFile, "quarantine-file",
+		options.VerifyImagesOptions.QuarantineFile, "File tracking failing containerdisks across runs, quarantined ones don't fail the run")
+	verifyCmd.Flags().IntVar(&options.VerifyImagesOptions.MaxFailures, "max-failures",
+		options.VerifyImagesOptions.MaxFailures, "Number of failed runs in a row after which a containerdisk is quarantined")
+	verifyCmd.Flags().StringVar(&options.VerifyImagesOptions.RolloutFile, "rollout-file",
+		options.VerifyImagesOptions.RolloutFile, "File of the promote stage listing containerdisks to verify until their floating tags move")
+	verifyCmd.Flags().StringVar(&options.VerifyImagesOptions.RolloutRegistry, "rollout-registry",
+		options.VerifyImagesOptions.RolloutRegistry, "Registry containing the promoted containerdisks of the rollout file")
+	verifyCmd.Flags().StringVar(&options.VerifyImagesOptions.BootTimeFile, "boot-time-file",
+		options.VerifyImagesOptions.BootTimeFile, "File keeping the boot times of containerdisks across runs to detect regressions")
+	verifyCmd.Flags().Float64Var(&options.VerifyImagesOptions.BootTimeThreshold, "boot-time-threshold",
+		options.VerifyImagesOptions.BootTimeThreshold, "Relative increase of the boot time over older builds which is reported as regression")
 	verifyCmd.Flags().IntVar(&options.VerifyImagesOptions.Timeout, "timeout",
 		options.VerifyImagesOptions.Timeout, "Maximum seconds to wait for VM to be running")
 	verifyCmd.Flags().StringVar(&options.VerifyImagesOptions.TargetArchitecture, "target-architecture",
@@ -125,17 +209,108 @@ func NewVerifyImagesCommand(options *common.Options) *cobra.Command {
 	return verifyCmd
 }
 
-func defineTargetArch(options *common.Options, client kvirtcli.KubevirtClient) {
+// readQuarantine returns the quarantine of previous runs or nil if quarantine is disabled.
+func readQuarantine(o *common.VerifyImageOptions) (*quarantine.Quarantine, error) {
+	if o.QuarantineFile == "" {
+		return nil, nil
+	}
+	return quarantine.Read(o.QuarantineFile, o.MaxFailures)
+}
+
+// recordVerification records the outcome of a verification and returns true if the containerdisk is quarantined.
+func recordVerification(q *quarantine.Quarantine, description string, err error) bool {
+	if err == nil {
+		q.RecordSuccess(description)
+		return false
+	}
+	return q.RecordFailure(description, err, time.Now())
+}
+
+// readBootTimes returns the boot times of previous runs or nil if boot times are not tracked.
+func readBootTimes(o *common.VerifyImageOptions) (*boottime.History, error) {
+	if o.BootTimeFile == "" {
+		return nil, nil
+	}
+	return boottime.Read(o.BootTimeFile, o.BootTimeThreshold)
+}
+
+// recordBootTime records the boot time of a verified build and returns a warning if it regressed compared to
+// older builds of the containerdisk.
+func recordBootTime(o *common.Options, h *boottime.History, a api.Artifact, build string, bootTime time.Duration) []string {
+	metadata := a.Metadata()
+	description := metadata.Describe()
+	if o.Metrics != nil {
+		o.Metrics.Set(metrics.VerificationBootTime, bootTime.Seconds(), description)
+	}
+	if h == nil {
+		return nil
+	}
+
+	regression := h.Record(metadata.Variant.Tag(metadata.Name), boottime.Sample{
+		Version: metadata.Version,
+		Build:   build,
+		Arch:    o.VerifyImagesOptions.TargetArchitecture,
+		Seconds: bootTime.Seconds(),
+		Time:    time.Now(),
+	})
+	if o.Metrics != nil {
+		regressed := 0.0
+		if regression != nil {
+			regressed = 1
+		}
+		o.Metrics.Set(metrics.BootTimeRegression, regressed, description)
+	}
+	if regression == nil {
+		return nil
+	}
+
+	common.Logger(a).Warn("The " + regression.String())
+	return []string{regression.String()}
+}
+
+// addRolloutCandidates adds the promoted containerdisks waiting for their floating tags to the results,
+// so they are verified again in every run. Freshly pushed containerdisks replace older candidates.
+func addRolloutCandidates(results map[string]api.ArtifactResult, o *common.VerifyImageOptions) error {
+	if o.RolloutFile == "" {
+		return nil
+	}
+	if o.RolloutRegistry == "" {
+		return errors.New("--rollout-registry is required to verify the containerdisks of the rollout file")
+	}
+
+	r, err := rollout.Read(o.RolloutFile, 0)
+	if err != nil {
+		return err
+	}
+	for _, name := range r.Pending() {
+		if _, ok := results[name]; ok {
+			continue
+		}
+		candidate, _ := r.Get(name)
+		logrus.Infof("Verifying %s again before moving its floating tags, passed %d times in a row", name, candidate.Successes)
+		results[name] = api.ArtifactResult{
+			Tags:     candidate.Tags,
+			Stage:    StagePush,
+			Registry: o.RolloutRegistry,
+			Rollout:  true,
+		}
+	}
+
+	return nil
+}
+
+func defineTargetArch(options *common.Options, client kvirtcli.KubevirtClient) error {
 	if options.VerifyImagesOptions.TargetArchitecture != "" {
-		return
+		return nil
 	}
 	logrus.Info("Target architecture not specified, retrieving node architecture")
 	nodeArchitecture, err := retrieveNodeArch(client)
 	if err != nil {
-		logrus.Fatal(err)
+		return err
 	}
 	logrus.Infof("Node Architecture: %s\n", nodeArchitecture)
 	options.VerifyImagesOptions.TargetArchitecture = nodeArchitecture
+	return nil
 }
 
 func retrieveNodeArch(client kvirtcli.KubevirtClient) (string, error) {
@@ -160,30 +335,50 @@ func retrieveArchitectureArtifact(options *common.Options, e *common.Entry) (api
 	return e.Artifacts[archIndex], nil
 }
 
-func verifyArtifact(ctx context.Context, a api.Artifact, res api.ArtifactResult, o *common.Options, client kvirtcli.KubevirtClient) error {
+// verifyArtifact boots a VM of the containerdisk and runs the tests of its artifact. It returns the time from
+// creating the VM until the tests passed, which is 0 if the containerdisk is not booted.
+func verifyArtifact(
+	ctx context.Context, a api.Artifact, res api.ArtifactResult, o *common.Options, client kvirtcli.KubevirtClient,
+) (time.Duration, error) {
 	log := common.Logger(a)
+	reporter := progress.FromContext(ctx)
 
 	if len(res.Tags) == 0 {
 		err := errors.New("no containerdisks to verify")
 		log.Error(err)
-		return err
+		return 0, err
+	}
+
+	registry := o.VerifyImagesOptions.Registry
+	if res.Registry != "" {
+		registry = res.Registry
+	}
+	imgRef := path.Join(registry, res.Tags[0])
+	if err := verifyDefaultsLabels(newRepository(o), a, imgRef, o); err != nil {
+		log.WithError(err).Error("Failed to verify labels of containerdisk")
+		return 0, err
+	}
+	if a.Metadata().Verification.SkipBoot {
+		log.Info("Not booting the containerdisk, its artifact skips the boot test")
+		return 0, nil
 	}
 
-	imgRef := path.Join(o.VerifyImagesOptions.Registry, res.Tags[0])
 	vm, username, privateKey, err := createVM(a, imgRef)
 	if err != nil {
 		log.WithError(err).Error("Failed to create VM object")
-		return err
+		return 0, err
 	}
 	if errors.Is(ctx.Err(), context.Canceled) {
-		return ctx.Err()
+		return 0, ctx.Err()
 	}
 
 	vmClient := client.VirtualMachine(o.VerifyImagesOptions.Namespace)
 	log.Info("Creating VM")
+	reporter.SetState("creating VM")
+	created := time.Now()
 	if vm, err = vmClient.Create(ctx, vm, metav1.CreateOptions{}); err != nil {
 		log.WithError(err).Error("Failed to create VM")
-		return err
+		return 0, err
 	}
 
 	defer func() {
@@ -193,40 +388,106 @@ func verifyArtifact(ctx context.Context, a api.Artifact, res api.ArtifactResult,
 	}()
 
 	if errors.Is(ctx.Err(), context.Canceled) {
-		return ctx.Err()
+		return 0, ctx.Err()
 	}
 
 	log.Info("Waiting for VM to be ready")
-	if err = waitVMReady(ctx, vm.Name, vmClient, o.VerifyImagesOptions.Timeout); err != nil {
+	reporter.SetState("waiting for VM")
+	if err = waitVMReady(ctx, vm.Name, vmClient, bootTimeout(a.Metadata(), &o.VerifyImagesOptions)); err != nil {
 		if errors.Is(ctx.Err(), context.Canceled) {
-			return ctx.Err()
+			return 0, ctx.Err()
 		}
 
 		log.WithError(err).Error("VM not ready")
-		return err
+		return 0, err
 	}
 
 	vmi, err := client.VirtualMachineInstance(o.VerifyImagesOptions.Namespace).Get(ctx, vm.Name, metav1.GetOptions{})
 	if err != nil {
 		log.WithError(err).Error("Failed to get VMI")
-		return err
+		return 0, err
 	}
 	if errors.Is(ctx.Err(), context.Canceled) {
-		return ctx.Err()
+		return 0, ctx.Err()
 	}
 
 	log.Info("Running tests on VMI")
+	reporter.SetState("running tests")
 	for _, testFn := range a.Tests() {
 		if err = testFn(ctx, vmi, &api.ArtifactTestParams{Username: username, PrivateKey: privateKey}); err != nil {
 			log.WithError(err).Error("Failed to verify containerdisk")
-			return err
+			return 0, err
 		}
 		if errors.Is(ctx.Err(), context.Canceled) {
-			return ctx.Err()
+			return 0, ctx.Err()
+		}
+	}
+	bootTime := time.Since(created)
+
+	// The guest agent may connect late, so it is checked after all tests passed
+	vmi, err = client.VirtualMachineInstance(o.VerifyImagesOptions.Namespace).Get(ctx, vm.Name, metav1.GetOptions{})
+	if err != nil {
+		log.WithError(err).Error("Failed to get VMI")
+		return 0, err
+	}
+	if err = checkAccessCredentials(log, a.Metadata(), vmi); err != nil {
+		log.WithError(err).Error("Failed to verify accessCredentials support")
+		return 0, err
+	}
+
+	log.WithField("bootTime", bootTime.Round(time.Second)).Info("Tests successful")
+	return bootTime, nil
+}
+
+// bootTimeout returns the time the VM of a containerdisk may take until it is ready.
+func bootTimeout(metadata *api.Metadata, o *common.VerifyImageOptions) time.Duration {
+	if metadata.Verification.MaxBootTime > 0 {
+		return metadata.Verification.MaxBootTime
+	}
+	return time.Duration(o.Timeout) * time.Second
+}
+
+// checkAccessCredentials compares the declared accessCredentials support with the detected qemu-guest-agent.
+func checkAccessCredentials(log *logrus.Entry, metadata *api.Metadata, vmi *v1.VirtualMachineInstance) error {
+	connected := guestAgentConnected(vmi)
+	if metadata.AccessCredentials && !connected {
+		return errors.New("accessCredentials are declared as supported but the qemu-guest-agent is not connected")
+	}
+	if metadata.RequiresGuestAgent() && !connected {
+		return errors.New("the qemu-guest-agent is required but not connected")
+	}
+	if !metadata.AccessCredentials && connected {
+		log.Info("The qemu-guest-agent is connected, accessCredentials are supported but not declared")
+	}
+	return nil
+}
+
+func guestAgentConnected(vmi *v1.VirtualMachineInstance) bool {
+	for _, condition := range vmi.Status.Conditions {
+		if condition.Type == v1.VirtualMachineInstanceAgentConnected {
+			return condition.Status == k8sv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// verifyDefaultsLabels checks that the pushed containerdisk carries the labels of its default instancetype and preference.
+func verifyDefaultsLabels(repo repository.Repository, a api.Artifact, imgRef string, o *common.Options) error {
+	expected := pkgcommon.DefaultsLabels(a.Metadata().EnvVariables)
+	if len(expected) == 0 {
+		return nil
+	}
+
+	imageInfo, err := repo.ImageMetadata(imgRef, o.VerifyImagesOptions.TargetArchitecture, o.AllowInsecureRegistry)
+	if err != nil {
+		return fmt.Errorf("error introspecting image %q: %v", imgRef, err)
+	}
+	for _, label := range slices.Sorted(maps.Keys(expected)) {
+		if imageInfo.Labels[label] != expected[label] {
+			return fmt.Errorf("image %q has label %s=%q, expected %q", imgRef, label, imageInfo.Labels[label], expected[label])
 		}
 	}
 
-	log.Info("Tests successful")
 	return nil
 }
 
@@ -244,12 +505,15 @@ func createVM(artifact api.Artifact, imgRef string) (*v1.VirtualMachine, string,
 		return nil, "", nil, err
 	}
 
-	userData := artifact.UserData(
-		&docs.UserData{
-			Username:       username,
-			AuthorizedKeys: []string{publicKey},
-		},
-	)
+	userData := ""
+	if !metadata.Verification.NoCloudInit {
+		userData = artifact.UserData(
+			&docs.UserData{
+				Username:       username,
+				AuthorizedKeys: []string{publicKey},
+			},
+		)
+	}
 
 	name := randName(metadata.Name)
 	vm := artifact.VM(name, imgRef, userData)
@@ -272,8 +536,8 @@ func randName(name string) string {
 	return name + "-" + urand.String(randomCharCount)
 }
 
-func waitVMReady(ctx context.Context, name string, client kvirtcli.VirtualMachineInterface, timeout int) error {
-	return wait.PollUntilContextTimeout(ctx, time.Second, time.Duration(timeout)*time.Second, true, func(_ context.Context) (bool, error) {
+func waitVMReady(ctx context.Context, name string, client kvirtcli.VirtualMachineInterface, timeout time.Duration) error {
+	return wait.PollUntilContextTimeout(ctx, time.Second, timeout, true, func(_ context.Context) (bool, error) {
 		vm, err := client.Get(ctx, name, metav1.GetOptions{})
 		if err != nil {
 			return false, err
@@ -0,0 +1,121 @@
+package licenses
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/licenses"
+	"kubevirt.io/containerdisks/pkg/repository"
+)
+
+const (
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+)
+
+type licensesOptions struct {
+	Registry        string
+	OutputFile      string
+	Format          string
+	RequireLicenses bool
+}
+
+func NewLicensesCommand(options *common.Options) *cobra.Command {
+	licensesOptions := &licensesOptions{
+		Registry:   "quay.io/containerdisks",
+		OutputFile: "licenses.md",
+		Format:     formatMarkdown,
+	}
+
+	licensesCmd := &cobra.Command{
+		Use:   "licenses",
+		Short: "Generate a license compliance report of all published containerdisks",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd, options, licensesOptions)
+		},
+	}
+	licensesCmd.Flags().StringVar(&licensesOptions.Registry, "registry",
+		licensesOptions.Registry, "Registry the containerdisks are published in")
+	licensesCmd.Flags().StringVar(&licensesOptions.OutputFile, "output",
+		licensesOptions.OutputFile, "File to write the report to, - for stdout")
+	licensesCmd.Flags().StringVar(&licensesOptions.Format, "format",
+		licensesOptions.Format, "Format of the report, markdown or json")
+	licensesCmd.Flags().BoolVar(&licensesOptions.RequireLicenses, "require-licenses",
+		licensesOptions.RequireLicenses, "Fail if a published containerdisk does not declare a license")
+
+	return licensesCmd
+}
+
+func run(cmd *cobra.Command, options *common.Options, licensesOptions *licensesOptions) error {
+	if licensesOptions.Format != formatMarkdown && licensesOptions.Format != formatJSON {
+		return fmt.Errorf("unknown format %q, expected %s or %s", licensesOptions.Format, formatMarkdown, formatJSON)
+	}
+
+	repo := repository.New()
+	report := licenses.New(time.Now())
+
+	registry, err := common.NewRegistryWithErrors()
+	if err != nil {
+		return fmt.Errorf("error gathering artifacts: %v", err)
+	}
+
+	for i := range registry {
+		entry := &registry[i]
+		if common.ShouldSkip(options.Focus, entry) {
+			continue
+		}
+
+		metadata := entry.Artifacts[0].Metadata()
+		image := entry.Image(licensesOptions.Registry)
+		pinnedRef, err := repo.PinnedReference(cmd.Context(), image, options.AllowInsecureRegistry)
+		if err != nil {
+			common.Logger(entry.Artifacts[0]).WithError(err).Warn("Containerdisk is not published, skipping it")
+			continue
+		}
+		_, digest, _ := strings.Cut(pinnedRef, "@")
+
+		var architectures []string
+		for _, artifact := range entry.Artifacts {
+			if arch := artifact.Metadata().Arch; arch != "" && !slices.Contains(architectures, arch) {
+				architectures = append(architectures, arch)
+			}
+		}
+		report.Add(licenses.ContainerDisk{
+			Image:           image,
+			Digest:          digest,
+			Architectures:   architectures,
+			License:         metadata.License,
+			Redistributable: metadata.PublishPolicy == api.PublishPolicyPublic,
+		})
+	}
+
+	data := report.Markdown()
+	if licensesOptions.Format == formatJSON {
+		if data, err = report.Marshal(); err != nil {
+			return err
+		}
+	}
+	if licensesOptions.OutputFile == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		logrus.Infof("Writing license report to %s", licensesOptions.OutputFile)
+		const permissionFile = 0o644
+		err = os.WriteFile(licensesOptions.OutputFile, data, permissionFile)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing license report: %v", err)
+	}
+
+	if undeclared := report.Undeclared(); licensesOptions.RequireLicenses && len(undeclared) > 0 {
+		return fmt.Errorf("containerdisks without license: %s", strings.Join(undeclared, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package list
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/catalog"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+type listOptions struct {
+	Registry string
+	Format   string
+	Inspect  bool
+	Schema   bool
+}
+
+func NewListCommand(options *common.Options) *cobra.Command {
+	listOptions := &listOptions{
+		Registry: "quay.io/containerdisks",
+		Format:   formatText,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the containerdisks medius publishes",
+		Long: `List the containerdisks medius publishes. The json format is a catalog of a versioned schema, printed
+with --schema, which only changes compatibly within its version.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if listOptions.Schema {
+				_, err := os.Stdout.Write(catalog.Schema)
+				return err
+			}
+			return run(options, listOptions)
+		},
+	}
+	listCmd.Flags().StringVar(&listOptions.Registry, "registry",
+		listOptions.Registry, "Registry the containerdisks are published in")
+	listCmd.Flags().StringVar(&listOptions.Format, "format",
+		listOptions.Format, "Output format, text for one containerdisk per line or json")
+	listCmd.Flags().BoolVar(&listOptions.Inspect, "inspect",
+		listOptions.Inspect, "Inspect upstream to add the URLs and checksums of the images to the json format")
+	listCmd.Flags().BoolVar(&listOptions.Schema, "schema",
+		listOptions.Schema, "Print the JSON schema of the json format instead")
+
+	return listCmd
+}
+
+func run(options *common.Options, listOptions *listOptions) error {
+	if listOptions.Format != formatText && listOptions.Format != formatJSON {
+		return fmt.Errorf("unknown format %q, expected %s or %s", listOptions.Format, formatText, formatJSON)
+	}
+
+	registry, err := common.NewRegistryWithErrors()
+	if err != nil {
+		return fmt.Errorf("error gathering artifacts: %v", err)
+	}
+
+	containerdisks := []catalog.Containerdisk{}
+	for i := range registry {
+		entry := &registry[i]
+		if common.ShouldSkip(options.Focus, entry) {
+			continue
+		}
+		containerdisk, err := newContainerdisk(entry, listOptions)
+		if err != nil {
+			return err
+		}
+		containerdisks = append(containerdisks, containerdisk)
+	}
+
+	if listOptions.Format == formatJSON {
+		data, err := catalog.Marshal(catalog.New(containerdisks))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(os.Stdout, string(data))
+		return err
+	}
+	for i := range containerdisks {
+		archs := make([]string, 0, len(containerdisks[i].Artifacts))
+		for _, artifact := range containerdisks[i].Artifacts {
+			archs = append(archs, artifact.Arch)
+		}
+		if _, err := fmt.Fprintf(os.Stdout, "%s\t%s\n", containerdisks[i].Image, strings.Join(archs, ",")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newContainerdisk(entry *common.Entry, listOptions *listOptions) (catalog.Containerdisk, error) {
+	metadata := make([]*api.Metadata, 0, len(entry.Artifacts))
+	for _, artifact := range entry.Artifacts {
+		metadata = append(metadata, artifact.Metadata())
+	}
+	containerdisk := catalog.NewContainerdisk(entry.Image(listOptions.Registry), metadata)
+	if !listOptions.Inspect {
+		return containerdisk, nil
+	}
+
+	for i, artifact := range entry.Artifacts {
+		details, err := artifact.Inspect()
+		if err != nil {
+			return containerdisk, fmt.Errorf("error inspecting %s: %v", metadata[i].Describe(), err)
+		}
+		if containerdisk.Artifacts[i].Source, err = catalog.NewSource(details); err != nil {
+			return containerdisk, err
+		}
+	}
+	return containerdisk, nil
+}
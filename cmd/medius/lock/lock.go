@@ -0,0 +1,60 @@
+package lock
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/lockfile"
+)
+
+func NewLockCommand(options *common.Options) *cobra.Command {
+	lockCmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Pin the current upstream versions and checksums of all containerdisks in a lock file for review",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(options)
+		},
+	}
+
+	return lockCmd
+}
+
+func run(options *common.Options) error {
+	focusMatched := false
+	lock := lockfile.New()
+
+	registry, err := common.NewRegistryWithErrors()
+	if err != nil {
+		return fmt.Errorf("error gathering artifacts: %v", err)
+	}
+
+	var errs []error
+	for i := range registry {
+		if common.ShouldSkip(options.Focus, &registry[i]) {
+			continue
+		}
+		focusMatched = true
+
+		for _, artifact := range registry[i].Artifacts {
+			if err := lock.Add(artifact); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			common.Logger(artifact).WithField("arch", artifact.Metadata().Arch).Info("Locked upstream version")
+		}
+	}
+
+	if !focusMatched {
+		return fmt.Errorf("no artifact was processed, focus '%s' did not match", options.Focus)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	logrus.Infof("Writing lock file %s", options.LockFile)
+	return lock.Write(options.LockFile)
+}
@@ -2,30 +2,191 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"k8s.io/apimachinery/pkg/api/resource"
 
+	"kubevirt.io/containerdisks/cmd/medius/airgap"
+	"kubevirt.io/containerdisks/cmd/medius/annotate"
+	"kubevirt.io/containerdisks/cmd/medius/bench"
+	"kubevirt.io/containerdisks/cmd/medius/canary"
 	"kubevirt.io/containerdisks/cmd/medius/common"
 	"kubevirt.io/containerdisks/cmd/medius/docs"
+	"kubevirt.io/containerdisks/cmd/medius/doctor"
 	"kubevirt.io/containerdisks/cmd/medius/images"
+	"kubevirt.io/containerdisks/cmd/medius/licenses"
+	"kubevirt.io/containerdisks/cmd/medius/list"
+	"kubevirt.io/containerdisks/cmd/medius/lock"
+	"kubevirt.io/containerdisks/cmd/medius/report"
+	"kubevirt.io/containerdisks/cmd/medius/search"
+	"kubevirt.io/containerdisks/cmd/medius/serve"
+	"kubevirt.io/containerdisks/cmd/medius/streams"
+	"kubevirt.io/containerdisks/cmd/medius/userdata"
+	"kubevirt.io/containerdisks/cmd/medius/version"
+	"kubevirt.io/containerdisks/pkg/bandwidth"
+	"kubevirt.io/containerdisks/pkg/buffers"
+	"kubevirt.io/containerdisks/pkg/build"
+	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/metrics"
+	"kubevirt.io/containerdisks/pkg/progress"
+	"kubevirt.io/containerdisks/pkg/repository"
+	"kubevirt.io/containerdisks/pkg/resolver"
+	"kubevirt.io/containerdisks/pkg/runlock"
+	"kubevirt.io/containerdisks/pkg/secrets"
+	"kubevirt.io/containerdisks/pkg/workspace"
 )
 
 func main() {
-	options := &common.Options{
-		DryRun: true,
+	options := newOptions()
+	rootCmd := newRootCommand(options)
+
+	credentials, err := http.CredentialsFromEnv()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	http.SetDefaultCredentials(credentials)
+
+	overrides, err := http.OverridesFromEnv()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	http.SetDefaultOverrides(overrides)
+
+	pins, err := http.PinsFromEnv()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	http.SetDefaultPins(pins)
+
+	// logrus.Fatal exits without running deferred functions
+	logrus.RegisterExitHandler(func() { cleanup(options) })
+	defer cleanup(options)
+
+	ctx, cancel := getInterruptibleContext(func() { cleanup(options) })
+	defer cancel()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+// newOptions returns the defaults of all options.
+func newOptions() *common.Options {
+	return &common.Options{
+		DryRun:             true,
+		LockFile:           "medius.lock",
+		RepositoryTemplate: repository.DefaultTemplate,
 		ImagesOptions: common.ImagesOptions{
-			ResultsFile: "results.json",
-			Workers:     1,
+			BufferSize:       "1Mi",
+			Compression:      build.Compression{Algorithm: build.CompressionGzip},
+			Layout:           build.DefaultLayout,
+			ResultsFile:      "results.json",
+			ProgressInterval: time.Minute,
+			Workers:          1,
+			RunLockTTL:       runlock.DefaultTTL,
 		},
 	}
+}
+
+// newRootCommand returns the medius command with all subcommands and flags, it sets up every run with options.
+func newRootCommand(options *common.Options) *cobra.Command {
+	politeness := http.Politeness{UserAgent: http.DefaultUserAgent, RespectRobots: true}
+	cacheOptions := http.CacheOptions{}
+	retry := http.DefaultRetry
+	maxFileSize, maxDownloadSize, maxDecompressedSize := "64Mi", "64Gi", "256Gi"
+	maxCompressionRatio := http.DefaultSizeLimits.MaxCompressionRatio
+	tui := false
+	resolverSpec := ""
+	bandwidthLimit := ""
+	checksumKeyring := ""
+	diskMode := fmt.Sprintf("%#o", build.DefaultLayout.FileMode)
+	var hostBandwidthLimits []string
 
 	rootCmd := &cobra.Command{
 		Use:   "medius",
 		Short: "medius determines if new OS images are released and publishes them as containerdisks",
 		Run:   func(cmd *cobra.Command, args []string) {},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if resolverSpec != "" {
+				r, err := resolver.New(resolverSpec)
+				if err != nil {
+					return err
+				}
+				resolver.Install(r)
+			}
+			http.SetDefaultPoliteness(politeness)
+			http.SetDefaultRetry(retry)
+			sizeLimits, err := http.ParseSizeLimits(maxFileSize, maxDownloadSize, maxDecompressedSize, maxCompressionRatio)
+			if err != nil {
+				return err
+			}
+			http.SetDefaultSizeLimits(sizeLimits)
+			limits, err := bandwidth.ParseLimits(bandwidthLimit, hostBandwidthLimits)
+			if err != nil {
+				return err
+			}
+			bandwidth.SetDefaultLimits(limits)
+			if err := http.SetCacheOptions(cacheOptions); err != nil {
+				return err
+			}
+			if checksumKeyring != "" {
+				keyring, err := http.LoadKeyring(checksumKeyring)
+				if err != nil {
+					return err
+				}
+				http.SetDefaultKeyring(keyring)
+			}
+			if err := build.SetDefaultCompression(options.ImagesOptions.Compression); err != nil {
+				return err
+			}
+			if err := setLayout(options, diskMode); err != nil {
+				return err
+			}
+			if err := setBufferSize(options.ImagesOptions.BufferSize); err != nil {
+				return err
+			}
+			template, err := repository.ParseTemplate(options.RepositoryTemplate, options.Org)
+			if err != nil {
+				return err
+			}
+			repository.SetDefaultTemplate(template)
+			backends, err := repository.ParseBackends(options.RegistryBackends)
+			if err != nil {
+				return err
+			}
+			repository.SetDefaultBackends(backends)
+			if options.ImagesOptions.MetricsFile != "" {
+				if options.Metrics, err = metrics.Load(options.ImagesOptions.MetricsFile); err != nil {
+					return err
+				}
+			}
+			if tui {
+				setupProgress(options)
+			}
+			if needsWorkspace(cmd) {
+				if err := setupWorkspace(options); err != nil {
+					return err
+				}
+			}
+			if err := setupRegistryAuth(cmd.Context(), options); err != nil {
+				return err
+			}
+			// Errors of the run are no usage errors, the flags were parsed successfully
+			cmd.SilenceUsage = true
+			return nil
+		},
 	}
 
 	imagesCmd := &cobra.Command{
@@ -35,18 +196,40 @@ func main() {
 		},
 	}
 	docsCmd := &cobra.Command{
-		Use: "docs",
+		Use:         "docs",
+		Annotations: map[string]string{withoutWorkspace: ""},
 		Run: func(cmd *cobra.Command, args []string) {
 			os.Exit(1)
 		},
 	}
 	rootCmd.AddCommand(imagesCmd)
 	rootCmd.AddCommand(docsCmd)
+	rootCmd.AddCommand(canary.NewCanaryCommand(options))
+	rootCmd.AddCommand(lock.NewLockCommand(options))
+	rootCmd.AddCommand(streams.NewStreamsCommand(options))
+	rootCmd.AddCommand(licenses.NewLicensesCommand(options))
+	rootCmd.AddCommand(report.NewReportCommand())
+	rootCmd.AddCommand(doctor.NewDoctorCommand())
+	rootCmd.AddCommand(annotate.NewAnnotateCommand(options))
+	rootCmd.AddCommand(userdata.NewUserDataCommand())
+	rootCmd.AddCommand(serve.NewServeCommand(options))
+	rootCmd.AddCommand(airgap.NewExportCommand(options))
+	rootCmd.AddCommand(airgap.NewImportCommand(options))
+	rootCmd.AddCommand(bench.NewBenchCommand(options))
+	rootCmd.AddCommand(search.NewSearchCommand(options))
+	rootCmd.AddCommand(list.NewListCommand(options))
+	versionCmd := version.NewVersionCommand()
+	versionCmd.Annotations = map[string]string{withoutWorkspace: ""}
+	rootCmd.AddCommand(versionCmd)
 
 	imagesCmd.AddCommand(images.NewPromoteImagesCommand(options))
 	imagesCmd.AddCommand(images.NewPublishImagesCommand(options))
 	imagesCmd.AddCommand(images.NewVerifyImagesCommand(options))
+	imagesCmd.AddCommand(images.NewMergeResultsCommand(options))
+	imagesCmd.AddCommand(images.NewReconcileCommand(options))
 	docsCmd.AddCommand(docs.NewPublishDocsCommand(options))
+	docsCmd.AddCommand(docs.NewGoldenImagesCommand(options))
+	docsCmd.AddCommand(docs.NewBundleCommand(options))
 
 	rootCmd.PersistentFlags().BoolVar(&options.AllowInsecureRegistry, "insecure-skip-tls",
 		options.AllowInsecureRegistry, "allow connecting to insecure registries")
@@ -54,25 +237,246 @@ func main() {
 		options.DryRun, "don't publish anything")
 	rootCmd.PersistentFlags().StringVar(&options.Focus, "focus",
 		options.Focus, "Focus on a specific containerdisk")
+	if err := rootCmd.RegisterFlagCompletionFunc("focus", completeFocus); err != nil {
+		logrus.Fatal(err)
+	}
+	rootCmd.PersistentFlags().StringVar(&politeness.UserAgent, "user-agent",
+		politeness.UserAgent, "User-Agent sent to upstream mirrors")
+	rootCmd.PersistentFlags().DurationVar(&politeness.MinInterval, "request-interval",
+		politeness.MinInterval, "Minimum time between two requests to the same upstream host")
+	rootCmd.PersistentFlags().StringVar(&resolverSpec, "resolver",
+		resolverSpec, "DNS-over-HTTPS endpoint or comma separated nameservers resolving all hosts instead of the system DNS")
+	rootCmd.PersistentFlags().IntVar(&politeness.RequestsPerMinute, "requests-per-minute",
+		politeness.RequestsPerMinute, "Maximum number of requests per minute to the same upstream host, 0 for unlimited")
+	rootCmd.PersistentFlags().BoolVar(&politeness.RespectRobots, "respect-robots-txt",
+		politeness.RespectRobots, "Space out requests to upstream hosts by the Crawl-delay of their robots.txt")
+	rootCmd.PersistentFlags().StringVar(&bandwidthLimit, "bandwidth-limit",
+		bandwidthLimit, "Maximum bytes per second of all downloads and uploads together, e.g. 50Mi")
+	rootCmd.PersistentFlags().StringSliceVar(&hostBandwidthLimits, "host-bandwidth-limit",
+		hostBandwidthLimits, "Maximum bytes per second of the transfers from or to a host, e.g. quay.io=20Mi")
+	rootCmd.PersistentFlags().IntVar(&retry.Attempts, "http-retry-attempts",
+		retry.Attempts, "Maximum number of attempts of transiently failing requests to upstream hosts and registries, 1 disables retries")
+	rootCmd.PersistentFlags().DurationVar(&retry.Backoff, "http-retry-backoff",
+		retry.Backoff, "Time to wait before the first retry of a request, doubled for every further retry")
+	rootCmd.PersistentFlags().Float64Var(&retry.Budget, "http-retry-budget",
+		retry.Budget, "Ratio of retries to requests allowed per host, 0 for unlimited")
+	rootCmd.PersistentFlags().StringVar(&maxFileSize, "max-file-size",
+		maxFileSize, "Maximum size of checksum files, release metadata and directory listings read from upstream, 0 for unlimited")
+	rootCmd.PersistentFlags().StringVar(&maxDownloadSize, "max-download-size",
+		maxDownloadSize, "Maximum size of downloaded images before decompression, 0 for unlimited")
+	rootCmd.PersistentFlags().StringVar(&maxDecompressedSize, "max-decompressed-size",
+		maxDecompressedSize, "Maximum size of decompressed images, 0 for unlimited")
+	rootCmd.PersistentFlags().Int64Var(&maxCompressionRatio, "max-compression-ratio",
+		maxCompressionRatio, "Maximum ratio of the decompressed to the downloaded size of images, 0 for unlimited")
+	rootCmd.PersistentFlags().StringVar(&cacheOptions.Dir, "http-cache-dir",
+		cacheOptions.Dir, "Directory persisting upstream checksum files, so subsequent invocations only revalidate them")
+	rootCmd.PersistentFlags().DurationVar(&cacheOptions.MaxAge, "http-cache-max-age",
+		cacheOptions.MaxAge, "Time cached upstream files are used without revalidating them, e.g. for the duration of a pipeline run")
+	rootCmd.PersistentFlags().StringVar(&checksumKeyring, "checksum-keyring",
+		checksumKeyring, "File of armored OpenPGP keys verifying the signatures of clearsigned checksum files, e.g. fedora.gpg")
+	rootCmd.PersistentFlags().StringVar(&options.RepositoryTemplate, "repository-template",
+		options.RepositoryTemplate, "Template naming the repositories of containerdisks, e.g. {{registry}}/{{org}}/{{name}}-{{arch}}")
+	rootCmd.PersistentFlags().StringVar(&options.Org, "org",
+		options.Org, "Organization or namespace replacing {{org}} in the repository template")
+	rootCmd.PersistentFlags().StringSliceVar(&options.RegistryBackends, "registry-backend",
+		options.RegistryBackends, "Registry client of a registry host, e.g. harbor.example.com=oras-go, or of all registries without host")
+	rootCmd.PersistentFlags().StringVar(&options.RegistryAuthSecret, "registry-auth-secret",
+		options.RegistryAuthSecret, "Secret holding the registry auth file, e.g. vault://secret/ci/registries#auth.json")
+	rootCmd.PersistentFlags().StringVar(&options.LockFile, "lock-file",
+		options.LockFile, "File pinning the upstream versions and checksums of containerdisks")
+	imagesCmd.PersistentFlags().BoolVar(&tui, "tui",
+		tui, "Show download, upload and verification progress of all containerdisks on the terminal")
+	imagesCmd.PersistentFlags().StringVar(&options.ImagesOptions.MetricsFile, "metrics-file",
+		options.ImagesOptions.MetricsFile, "File updated with Prometheus metrics of every stage, e.g. for the node exporter textfile collector")
+	imagesCmd.PersistentFlags().DurationVar(&options.ImagesOptions.ProgressInterval, "progress-interval",
+		options.ImagesOptions.ProgressInterval, "Interval of progress logs of downloads, hashing and uploads without --tui, 0 disables them")
 	imagesCmd.PersistentFlags().StringVar(&options.ImagesOptions.ResultsFile, "results-file",
 		options.ImagesOptions.ResultsFile, "File to store/read results of operations")
+	imagesCmd.PersistentFlags().IntVar(&options.ImagesOptions.Snapshots, "snapshots",
+		options.ImagesOptions.Snapshots, "Number of the latest snapshots of the focused rolling containerdisk to publish under their unique tags")
+	imagesCmd.PersistentFlags().StringVar(&options.ImagesOptions.Version, "version",
+		options.ImagesOptions.Version, "Historical upstream version of the focused containerdisk, e.g. --focus fedora --version 39-1.5")
 	imagesCmd.PersistentFlags().IntVar(&options.ImagesOptions.Workers, "workers",
 		options.ImagesOptions.Workers, "Number of parallel workers")
+	imagesCmd.PersistentFlags().StringVar(&options.ImagesOptions.RunLockDir, "run-lock-dir",
+		options.ImagesOptions.RunLockDir, "Directory shared by all runs, locking the repositories of push and promote runs against other runs")
+	imagesCmd.PersistentFlags().DurationVar(&options.ImagesOptions.RunLockTTL, "run-lock-ttl",
+		options.ImagesOptions.RunLockTTL, "Time the locks of a killed run block other runs, the locks of running runs are renewed")
+	imagesCmd.PersistentFlags().BoolVar(&options.ImagesOptions.ForceUnlock, "force-unlock",
+		options.ImagesOptions.ForceUnlock, "Remove the locks of other runs on the repositories of this run, e.g. after a run was killed")
+	imagesCmd.PersistentFlags().StringVar(&options.ImagesOptions.BufferSize, "buffer-size",
+		options.ImagesOptions.BufferSize, "Size of the pooled buffers used to copy, hash and compress disks")
+	imagesCmd.PersistentFlags().StringVar(&options.ImagesOptions.Compression.Algorithm, "compression",
+		options.ImagesOptions.Compression.Algorithm, "Compression of the layers of built containerdisks, gzip, zstd or none")
+	imagesCmd.PersistentFlags().IntVar(&options.ImagesOptions.Compression.Level, "compression-level",
+		options.ImagesOptions.Compression.Level, "Compression level, defaults to the fastest gzip level or the default zstd level")
+	imagesCmd.PersistentFlags().IntVar(&options.ImagesOptions.Compression.Threads, "compression-threads",
+		options.ImagesOptions.Compression.Threads, "Number of threads compressing a gzip layer, defaults to the number of CPUs")
+	imagesCmd.PersistentFlags().StringVar(&options.ImagesOptions.Layout.DiskPath, "disk-path",
+		options.ImagesOptions.Layout.DiskPath, "Path of the disk inside built containerdisks")
+	imagesCmd.PersistentFlags().IntVar(&options.ImagesOptions.Layout.UID, "disk-uid",
+		options.ImagesOptions.Layout.UID, "User owning the files of built containerdisks")
+	imagesCmd.PersistentFlags().IntVar(&options.ImagesOptions.Layout.GID, "disk-gid",
+		options.ImagesOptions.Layout.GID, "Group owning the files of built containerdisks")
+	imagesCmd.PersistentFlags().StringVar(&diskMode, "disk-mode",
+		diskMode, "Octal permissions of the files of built containerdisks, they have to be readable by qemu")
 
-	ctx, cancel := getInterruptibleContext()
-	defer cancel()
+	rootCmd.PersistentFlags().StringVar(&options.ScratchDir, "scratch-dir",
+		options.ScratchDir, "Directory to create the temporary workspace of a run in, defaults to the system temp directory")
 
-	if err := rootCmd.ExecuteContext(ctx); err != nil {
-		logrus.Fatal(err)
+	rootCmd.PersistentFlags().StringVar(&options.ScratchQuota, "scratch-quota",
+		options.ScratchQuota, "Maximum size of all temporary files of a run at the same time, e.g. 50Gi")
+
+	return rootCmd
+}
+
+// setupProgress enables the progress view if medius runs on a terminal.
+func setupProgress(options *common.Options) {
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		logrus.Warn("Not showing progress, stderr is no terminal")
+		return
+	}
+	options.Progress = progress.NewTracker()
+}
+
+// completeFocus completes --focus with the containerdisks of the registry, without contacting upstream.
+func completeFocus(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return common.StaticFocusCompletions(toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// withoutWorkspace annotates commands which need no scratch space, so running them neither creates a
+// workspace nor cleans up the workspaces of other runs. It applies to the subcommands as well.
+const withoutWorkspace = "medius/without-workspace"
+
+func needsWorkspace(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if _, ok := c.Annotations[withoutWorkspace]; ok {
+			return false
+		}
+		switch c.Name() {
+		case "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return false
+		}
 	}
+	return true
 }
 
-func getInterruptibleContext() (ctx context.Context, cancel func()) {
+// setupWorkspace removes workspaces leaked by aborted runs and creates the workspace of this run.
+// All temporary files are created inside of it by pointing the temp directory to it.
+func setupWorkspace(options *common.Options) error {
+	removed, err := workspace.CleanupStale(options.ScratchDir)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to clean up stale workspaces")
+	}
+	for _, dir := range removed {
+		logrus.Infof("Removed stale workspace %s", dir)
+	}
+
+	ws, err := workspace.New(options.ScratchDir)
+	if err != nil {
+		return err
+	}
+	options.Workspace = ws
+
+	if options.ScratchQuota != "" {
+		quota, err := resource.ParseQuantity(options.ScratchQuota)
+		if err != nil {
+			return fmt.Errorf("invalid scratch quota %q: %v", options.ScratchQuota, err)
+		}
+		ws.SetQuota(quota.Value())
+	}
+
+	return ws.UseAsTempDir()
+}
+
+// registryAuthDir holds the registry auth file of commands without workspace, e.g. docs. It is removed by cleanup.
+var registryAuthDir string
+
+// setupRegistryAuth writes the registry auth file of the secret store into the workspace and points the
+// registry clients to it, so the registry credentials never have to be stored on disk outside of a run.
+// Commands without workspace get a private temporary directory for it.
+func setupRegistryAuth(ctx context.Context, options *common.Options) error {
+	if options.RegistryAuthSecret == "" {
+		return nil
+	}
+
+	auth, err := secrets.Resolve(ctx, options.RegistryAuthSecret)
+	if err != nil {
+		return err
+	}
+	if !json.Valid([]byte(auth)) {
+		return errors.New("the registry auth secret is no JSON auth file")
+	}
+
+	const permissionDir, permissionFile = 0o700, 0o600
+	var dir string
+	if options.Workspace != nil {
+		dir = filepath.Join(options.Workspace.Dir(), "registry-auth")
+		if err = os.MkdirAll(dir, permissionDir); err != nil {
+			return err
+		}
+	} else {
+		// MkdirTemp creates the directory with permissionDir
+		if dir, err = os.MkdirTemp(options.ScratchDir, "medius-registry-auth-"); err != nil {
+			return fmt.Errorf("error creating the registry auth directory: %v", err)
+		}
+		registryAuthDir = dir
+	}
+	authFile := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(authFile, []byte(auth), permissionFile); err != nil {
+		return fmt.Errorf("error writing the registry auth file: %v", err)
+	}
+	// containers/image reads REGISTRY_AUTH_FILE, go-containerregistry the config.json in DOCKER_CONFIG
+	if err := os.Setenv("REGISTRY_AUTH_FILE", authFile); err != nil {
+		return err
+	}
+	return os.Setenv("DOCKER_CONFIG", dir)
+}
+
+func setBufferSize(size string) error {
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return fmt.Errorf("invalid buffer size %q: %v", size, err)
+	}
+	return buffers.SetSize(quantity.Value())
+}
+
+func setLayout(options *common.Options, diskMode string) error {
+	mode, err := strconv.ParseInt(diskMode, 8, 64)
+	if err != nil {
+		return fmt.Errorf("invalid disk mode %q: %v", diskMode, err)
+	}
+	options.ImagesOptions.Layout.FileMode = mode
+	return build.SetDefaultLayout(options.ImagesOptions.Layout)
+}
+
+var cleanupOnce sync.Once
+
+// cleanup removes the workspace and the registry auth file of the run.
+func cleanup(options *common.Options) {
+	cleanupOnce.Do(func() {
+		if registryAuthDir != "" {
+			if err := os.RemoveAll(registryAuthDir); err != nil {
+				logrus.WithError(err).Error("Failed to remove the registry auth file")
+			}
+		}
+		if options.Workspace == nil {
+			return
+		}
+		if err := options.Workspace.Close(); err != nil {
+			logrus.WithError(err).Error("Failed to remove workspace")
+		}
+	})
+}
+
+// getInterruptibleContext returns a context which is canceled on SIGINT or SIGTERM, so in-flight
+// operations can stop and partial results can be written. A second signal forces an exit after cleanup.
+func getInterruptibleContext(cleanup func()) (ctx context.Context, cancel func()) {
 	ctx = context.Background()
 	ctx, cancelCtx := context.WithCancel(ctx)
 
-	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, os.Interrupt)
+	signalChan := make(chan os.Signal, 2)
+	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 
 	cancel = func() {
 		signal.Stop(signalChan)
@@ -81,10 +485,21 @@ func getInterruptibleContext() (ctx context.Context, cancel func()) {
 
 	go func() {
 		select {
-		case <-signalChan:
+		case sig := <-signalChan:
+			logrus.Warnf("Received %s, shutting down gracefully, send it again to force an exit", sig)
 			cancelCtx()
 		case <-ctx.Done():
+			return
+		}
+
+		sig, ok := <-signalChan
+		if !ok {
+			return
 		}
+		logrus.Warnf("Received %s again, exiting", sig)
+		cleanup()
+		const exitCodeInterrupted = 130
+		os.Exit(exitCodeInterrupted)
 	}()
 
 	return ctx, cancel
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Medius", func() {
+	DescribeTable("should only create workspaces for commands needing scratch space",
+		func(args []string, expected bool) {
+			rootCmd := newRootCommand(newOptions())
+			rootCmd.InitDefaultCompletionCmd()
+			cmd, _, err := rootCmd.Find(args)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(needsWorkspace(cmd)).To(Equal(expected))
+		},
+		Entry("push", []string{"images", "push"}, true),
+		Entry("serve", []string{"serve"}, true),
+		Entry("version", []string{"version"}, false),
+		Entry("docs", []string{"docs", "bundle"}, false),
+		Entry("completion", []string{"completion", "bash"}, false),
+	)
+
+	It("should write the registry auth file of commands without workspace to a private directory", func() {
+		GinkgoT().Setenv("MEDIUS_TEST_AUTH", `{"auths":{}}`)
+		// Restore the variables pointing the registry clients to the auth file
+		GinkgoT().Setenv("REGISTRY_AUTH_FILE", os.Getenv("REGISTRY_AUTH_FILE"))
+		GinkgoT().Setenv("DOCKER_CONFIG", os.Getenv("DOCKER_CONFIG"))
+
+		options := newOptions()
+		options.ScratchDir = GinkgoT().TempDir()
+		rootCmd := newRootCommand(options)
+		rootCmd.SetArgs([]string{"version", "--registry-auth-secret", "env:MEDIUS_TEST_AUTH"})
+		rootCmd.SetOut(GinkgoWriter)
+		Expect(rootCmd.Execute()).To(Succeed())
+
+		Expect(options.Workspace).To(BeNil())
+		Expect(registryAuthDir).To(HavePrefix(options.ScratchDir))
+		Expect(os.Getenv("DOCKER_CONFIG")).To(Equal(registryAuthDir))
+		Expect(os.ReadFile(filepath.Join(registryAuthDir, "config.json"))).To(MatchJSON(`{"auths":{}}`))
+
+		cleanup(options)
+		Expect(registryAuthDir).ToNot(BeADirectory())
+	})
+})
+
+func TestMedius(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Medius Suite")
+}
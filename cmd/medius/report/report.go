@@ -0,0 +1,347 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/announce"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/feed"
+	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/rundiff"
+)
+
+const (
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+)
+
+// defaultEOLWindow is how long before their end of life containerdisks are announced as deprecated
+const defaultEOLWindow = 90 * 24 * time.Hour
+
+// feedAll is the name of the feed of all containerdisks, the other feeds are named after the containerdisk
+const feedAll = "all"
+
+type diffOptions struct {
+	OutputFile string
+	Format     string
+}
+
+type announceOptions struct {
+	OutputFile        string
+	Format            string
+	PreviousFile      string
+	EOLWindow         time.Duration
+	FetchReleaseNotes bool
+}
+
+type failuresOptions struct {
+	OutputFile          string
+	FallbackMaintainers []string
+}
+
+type feedOptions struct {
+	Dir          string
+	Registry     string
+	PreviousFile string
+	MaxEntries   int
+}
+
+func NewReportCommand() *cobra.Command {
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Report about the results of pipeline runs",
+	}
+	reportCmd.AddCommand(newDiffCommand())
+	reportCmd.AddCommand(newAnnounceCommand())
+	reportCmd.AddCommand(newFeedCommand())
+	reportCmd.AddCommand(newFailuresCommand())
+	return reportCmd
+}
+
+func newDiffCommand() *cobra.Command {
+	diffOptions := &diffOptions{
+		OutputFile: "-",
+		Format:     formatMarkdown,
+	}
+
+	diffCmd := &cobra.Command{
+		Use:   "diff <results-a.json> <results-b.json>",
+		Short: "Show which containerdisks changed version, digest or verification status between two runs",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDiff(args[0], args[1], diffOptions)
+		},
+	}
+	diffCmd.Flags().StringVar(&diffOptions.OutputFile, "output",
+		diffOptions.OutputFile, "File to write the diff to, - for stdout")
+	diffCmd.Flags().StringVar(&diffOptions.Format, "format",
+		diffOptions.Format, "Format of the diff, markdown or json")
+
+	return diffCmd
+}
+
+func newAnnounceCommand() *cobra.Command {
+	announceOptions := &announceOptions{
+		OutputFile:        "-",
+		Format:            formatMarkdown,
+		EOLWindow:         defaultEOLWindow,
+		FetchReleaseNotes: true,
+	}
+
+	announceCmd := &cobra.Command{
+		Use:   "announce <results.json>",
+		Short: "Generate an announcement of the versions, security fixes and deprecations of a run",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAnnounce(cmd, args[0], announceOptions)
+		},
+	}
+	announceCmd.Flags().StringVar(&announceOptions.OutputFile, "output",
+		announceOptions.OutputFile, "File to write the announcement to, - for stdout")
+	announceCmd.Flags().StringVar(&announceOptions.Format, "format",
+		announceOptions.Format, "Format of the announcement, markdown or json")
+	announceCmd.Flags().StringVar(&announceOptions.PreviousFile, "previous",
+		announceOptions.PreviousFile, "Results file of the previous run, to only announce versions which changed since")
+	announceCmd.Flags().DurationVar(&announceOptions.EOLWindow, "eol-window",
+		announceOptions.EOLWindow, "Announce containerdisks reaching their end of life within this duration as deprecated")
+	announceCmd.Flags().BoolVar(&announceOptions.FetchReleaseNotes, "fetch-release-notes",
+		announceOptions.FetchReleaseNotes, "Fetch the release notes of new versions to list the security advisories they reference")
+
+	return announceCmd
+}
+
+func newFeedCommand() *cobra.Command {
+	feedOptions := &feedOptions{
+		Dir:        "feeds",
+		Registry:   "quay.io/containerdisks",
+		MaxEntries: 100,
+	}
+
+	feedCmd := &cobra.Command{
+		Use:   "feed <results.json>",
+		Short: "Add the containerdisks published by a run to Atom feeds of all and of every containerdisk",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFeed(args[0], feedOptions)
+		},
+	}
+	feedCmd.Flags().StringVar(&feedOptions.Dir, "dir",
+		feedOptions.Dir, "Directory containing the feeds, existing feeds are updated")
+	feedCmd.Flags().StringVar(&feedOptions.Registry, "registry",
+		feedOptions.Registry, "Registry the containerdisks are published in")
+	feedCmd.Flags().StringVar(&feedOptions.PreviousFile, "previous",
+		feedOptions.PreviousFile, "Results file of the previous run, to only add versions which changed since")
+	feedCmd.Flags().IntVar(&feedOptions.MaxEntries, "max-entries",
+		feedOptions.MaxEntries, "Maximum number of entries kept in a feed, 0 for unlimited")
+
+	return feedCmd
+}
+
+func newFailuresCommand() *cobra.Command {
+	failuresOptions := &failuresOptions{
+		OutputFile: "-",
+	}
+
+	failuresCmd := &cobra.Command{
+		Use:   "failures <results.json>",
+		Short: "Describe the failed containerdisks of a run and mention their maintainers, e.g. to file an issue",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results, err := rundiff.Load(args[0])
+			if err != nil {
+				return err
+			}
+			if err := write(failuresOptions.OutputFile, []byte(announce.Failures(results, failuresOptions.FallbackMaintainers))); err != nil {
+				return fmt.Errorf("error writing the failures: %v", err)
+			}
+			return nil
+		},
+	}
+	failuresCmd.Flags().StringVar(&failuresOptions.OutputFile, "output",
+		failuresOptions.OutputFile, "File to write the failures to, - for stdout")
+	failuresCmd.Flags().StringArrayVar(&failuresOptions.FallbackMaintainers, "fallback-maintainer",
+		failuresOptions.FallbackMaintainers, "GitHub handle mentioned for failed containerdisks without maintainers, can be repeated")
+
+	return failuresCmd
+}
+
+func runDiff(beforeFile, afterFile string, diffOptions *diffOptions) error {
+	if err := validateFormat(diffOptions.Format); err != nil {
+		return err
+	}
+
+	before, err := rundiff.Load(beforeFile)
+	if err != nil {
+		return err
+	}
+	after, err := rundiff.Load(afterFile)
+	if err != nil {
+		return err
+	}
+	changes := rundiff.Diff(before, after)
+
+	data := []byte(rundiff.Markdown(changes))
+	if diffOptions.Format == formatJSON {
+		if changes == nil {
+			changes = []rundiff.Change{}
+		}
+		if data, err = json.MarshalIndent(changes, "", "  "); err != nil {
+			return err
+		}
+	}
+	if err := write(diffOptions.OutputFile, data); err != nil {
+		return fmt.Errorf("error writing the diff: %v", err)
+	}
+	return nil
+}
+
+func runAnnounce(cmd *cobra.Command, resultsFile string, announceOptions *announceOptions) error {
+	if err := validateFormat(announceOptions.Format); err != nil {
+		return err
+	}
+
+	results, err := rundiff.Load(resultsFile)
+	if err != nil {
+		return err
+	}
+	previous, err := loadPrevious(announceOptions.PreviousFile)
+	if err != nil {
+		return err
+	}
+
+	a := announce.New(time.Now(), announceOptions.EOLWindow, results, previous, registryMetadata())
+	if announceOptions.FetchReleaseNotes {
+		addAdvisories(cmd, a, &http.HTTPGetter{})
+	}
+
+	data := []byte(a.Markdown())
+	if announceOptions.Format == formatJSON {
+		if data, err = json.MarshalIndent(a, "", "  "); err != nil {
+			return err
+		}
+	}
+	if err := write(announceOptions.OutputFile, data); err != nil {
+		return fmt.Errorf("error writing the announcement: %v", err)
+	}
+	return nil
+}
+
+func runFeed(resultsFile string, feedOptions *feedOptions) error {
+	results, err := rundiff.Load(resultsFile)
+	if err != nil {
+		return err
+	}
+	previous, err := loadPrevious(feedOptions.PreviousFile)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	a := announce.New(now, 0, results, previous, registryMetadata())
+	entries := map[string][]feed.Entry{}
+	for _, r := range a.Releases {
+		name, _, _ := strings.Cut(r.Artifact, ":")
+		entry := feed.Entry{
+			ID:      feed.EntryID(r.Artifact, r.Version),
+			Title:   fmt.Sprintf("%s %s", name, r.Version),
+			Summary: fmt.Sprintf("%s/%s:%s@%s", feedOptions.Registry, name, r.Version, r.Digest),
+		}
+		if r.ReleaseNotes != "" {
+			entry.Link = &feed.Link{Href: r.ReleaseNotes, Rel: "related"}
+		}
+		entries[feedAll] = append(entries[feedAll], entry)
+		entries[name] = append(entries[name], entry)
+	}
+
+	const permissionDir = 0o755
+	if err := os.MkdirAll(feedOptions.Dir, permissionDir); err != nil {
+		return err
+	}
+	for name, feedEntries := range entries {
+		title := "KubeVirt containerdisks"
+		if name != feedAll {
+			title = fmt.Sprintf("KubeVirt %s containerdisks", name)
+		}
+		fileName := filepath.Join(feedOptions.Dir, name+".atom")
+		f, err := feed.Load(fileName, name, title)
+		if err != nil {
+			return err
+		}
+		if !f.Add(now, feedOptions.MaxEntries, feedEntries...) {
+			continue
+		}
+		data, err := f.Marshal()
+		if err != nil {
+			return err
+		}
+		logrus.Infof("Updating feed %s", fileName)
+		if err := write(fileName, data); err != nil {
+			return fmt.Errorf("error writing the feed %s: %v", fileName, err)
+		}
+	}
+	return nil
+}
+
+func loadPrevious(previousFile string) (map[string]api.ArtifactResult, error) {
+	if previousFile == "" {
+		return nil, nil
+	}
+	return rundiff.Load(previousFile)
+}
+
+// registryMetadata returns the metadata of all artifacts keyed like the results of runs.
+func registryMetadata() map[string]*api.Metadata {
+	metadata := map[string]*api.Metadata{}
+	for _, entry := range common.NewRegistry() {
+		for _, artifact := range entry.Artifacts {
+			metadata[artifact.Metadata().Describe()] = artifact.Metadata()
+		}
+	}
+	return metadata
+}
+
+// addAdvisories fetches the release notes of the announced versions and adds the advisories they reference.
+// Release notes which can't be fetched are skipped, the announcement is still useful without them.
+func addAdvisories(cmd *cobra.Command, a *announce.Announcement, getter http.Getter) {
+	pages := map[string][]byte{}
+	for i := range a.Releases {
+		r := &a.Releases[i]
+		if r.ReleaseNotes == "" {
+			continue
+		}
+		page, fetched := pages[r.ReleaseNotes]
+		if !fetched {
+			var err error
+			if page, err = getter.GetAllWithContext(cmd.Context(), r.ReleaseNotes); err != nil {
+				logrus.WithError(err).Warnf("Failed to fetch the release notes of %s", r.Artifact)
+			}
+			pages[r.ReleaseNotes] = page
+		}
+		r.Advisories = announce.Advisories(page)
+	}
+}
+
+func validateFormat(format string) error {
+	if format != formatMarkdown && format != formatJSON {
+		return fmt.Errorf("unknown format %q, expected %s or %s", format, formatMarkdown, formatJSON)
+	}
+	return nil
+}
+
+func write(outputFile string, data []byte) error {
+	if outputFile == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	const permissionFile = 0o644
+	return os.WriteFile(outputFile, data, permissionFile)
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/repository"
+	"kubevirt.io/containerdisks/pkg/search"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+type searchOptions struct {
+	Registry string
+	Format   string
+	Pinned   bool
+}
+
+func NewSearchCommand(options *common.Options) *cobra.Command {
+	searchOptions := &searchOptions{
+		Registry: "quay.io/containerdisks",
+		Format:   formatText,
+	}
+
+	searchCmd := &cobra.Command{
+		Use:   "search <query>...",
+		Short: "Print the image references of the containerdisks matching a query, e.g. fedora arch:aarch64",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd, options, searchOptions, strings.Join(args, " "))
+		},
+	}
+	searchCmd.Flags().StringVar(&searchOptions.Registry, "registry",
+		searchOptions.Registry, "Registry the containerdisks are published in")
+	searchCmd.Flags().StringVar(&searchOptions.Format, "format",
+		searchOptions.Format, "Output format, text for one image reference per line or json")
+	searchCmd.Flags().BoolVar(&searchOptions.Pinned, "pinned",
+		searchOptions.Pinned, "Print references pinned to the digest of the published containerdisks, skipping unpublished ones")
+
+	return searchCmd
+}
+
+func run(cmd *cobra.Command, options *common.Options, searchOptions *searchOptions, query string) error {
+	if searchOptions.Format != formatText && searchOptions.Format != formatJSON {
+		return fmt.Errorf("unknown format %q, expected %s or %s", searchOptions.Format, formatText, formatJSON)
+	}
+	q, err := search.Parse(query)
+	if err != nil {
+		return err
+	}
+
+	registry, err := common.NewRegistryWithErrors()
+	if err != nil {
+		return fmt.Errorf("error gathering artifacts: %v", err)
+	}
+
+	repo := repository.New()
+	items := []search.Item{}
+	for i := range registry {
+		entry := &registry[i]
+		if len(entry.Artifacts) == 0 {
+			continue
+		}
+		metadata := make([]*api.Metadata, 0, len(entry.Artifacts))
+		for _, artifact := range entry.Artifacts {
+			metadata = append(metadata, artifact.Metadata())
+		}
+		item := search.NewItem(entry.Image(searchOptions.Registry), metadata)
+		if !q.Match(&item) {
+			continue
+		}
+
+		if searchOptions.Pinned {
+			pinnedRef, err := repo.PinnedReference(cmd.Context(), item.Image, options.AllowInsecureRegistry)
+			if err != nil {
+				common.Logger(entry.Artifacts[0]).WithError(err).Warn("Containerdisk is not published, skipping it")
+				continue
+			}
+			item.Image = pinnedRef
+		}
+		items = append(items, item)
+	}
+
+	if searchOptions.Format == formatJSON {
+		data, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(os.Stdout, string(data))
+		return err
+	}
+	for i := range items {
+		if _, err := fmt.Fprintln(os.Stdout, items[i].Image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
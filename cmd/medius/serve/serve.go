@@ -0,0 +1,223 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+)
+
+// Config describes the publish pipeline run by medius serve. It is re-read before every run,
+// so changes to a mounted ConfigMap are picked up without restarting.
+type Config struct {
+	// Interval between two pipeline runs, e.g. "24h".
+	Interval string `json:"interval,omitempty"`
+	// Focus limits the pipeline to specific containerdisks.
+	Focus string `json:"focus,omitempty"`
+	// Workers is the number of parallel workers of every stage.
+	Workers int `json:"workers,omitempty"`
+	// StagingRegistry receives the built containerdisks before verification.
+	StagingRegistry string `json:"stagingRegistry"`
+	// VerifyRegistry is the staging registry as seen from within the cluster, defaults to StagingRegistry.
+	VerifyRegistry string `json:"verifyRegistry,omitempty"`
+	// TargetRegistry receives verified containerdisks.
+	TargetRegistry string `json:"targetRegistry"`
+	// InsecureSkipTLS allows connecting to insecure registries.
+	InsecureSkipTLS bool `json:"insecureSkipTLS,omitempty"`
+	// PromoteDryRun runs the whole pipeline but does not promote anything.
+	PromoteDryRun bool `json:"promoteDryRun,omitempty"`
+	// Kubeconfig used by the verification, defaults to the in-cluster configuration.
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// Namespace to run the verification VMs in.
+	Namespace string `json:"namespace,omitempty"`
+	// QuarantineFile tracks containerdisks failing verification across runs, e.g. on a persistent volume.
+	QuarantineFile string `json:"quarantineFile,omitempty"`
+	// MaxFailures is the number of failed runs in a row after which a containerdisk is quarantined.
+	MaxFailures int `json:"maxFailures,omitempty"`
+	// MetricsFile is updated with the metrics of every stage, e.g. in the directory of the node exporter textfile collector.
+	MetricsFile string `json:"metricsFile,omitempty"`
+	// RolloutFile tracks promoted containerdisks whose floating tags were not moved yet, e.g. on a persistent volume.
+	RolloutFile string `json:"rolloutFile,omitempty"`
+	// RequiredSuccesses is the number of verifications in a row before floating tags like "latest" are moved.
+	RequiredSuccesses int `json:"requiredSuccesses,omitempty"`
+}
+
+const defaultInterval = 24 * time.Hour
+
+type serveOptions struct {
+	ConfigFile string
+	Once       bool
+}
+
+func NewServeCommand(options *common.Options) *cobra.Command {
+	serveOptions := &serveOptions{
+		ConfigFile: "/etc/medius/config.yaml",
+	}
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run the push, verify and promote pipeline periodically, e.g. inside of a cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd.Context(), options, serveOptions)
+		},
+	}
+	serveCmd.Flags().StringVar(&serveOptions.ConfigFile, "config",
+		serveOptions.ConfigFile, "Pipeline configuration, e.g. mounted from a ConfigMap")
+	serveCmd.Flags().BoolVar(&serveOptions.Once, "once",
+		serveOptions.Once, "Run the pipeline once and exit, e.g. when running as CronJob")
+
+	return serveCmd
+}
+
+func run(ctx context.Context, options *common.Options, serveOptions *serveOptions) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("error locating the medius executable: %v", err)
+	}
+
+	// The results of the stages are only passed between them, they are removed with the workspace
+	resultsDir := os.TempDir()
+	if options.Workspace != nil {
+		resultsDir = options.Workspace.Dir()
+	}
+
+	for {
+		config, err := ReadConfig(serveOptions.ConfigFile)
+		if err != nil {
+			return err
+		}
+
+		err = runPipeline(ctx, executable, filepath.Join(resultsDir, "results.json"), config)
+		if serveOptions.Once || errors.Is(err, context.Canceled) {
+			return err
+		}
+		if err != nil {
+			logrus.WithError(err).Error("Pipeline run failed")
+		}
+
+		interval, _ := time.ParseDuration(config.Interval)
+		logrus.Infof("Next pipeline run in %s", interval)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// ReadConfig reads and defaults the pipeline configuration.
+func ReadConfig(fileName string) (*Config, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config: %v", err)
+	}
+
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("error parsing config %s: %v", fileName, err)
+	}
+
+	if config.StagingRegistry == "" || config.TargetRegistry == "" {
+		return nil, errors.New("stagingRegistry and targetRegistry are required")
+	}
+	if config.Interval == "" {
+		config.Interval = defaultInterval.String()
+	}
+	if interval, err := time.ParseDuration(config.Interval); err != nil || interval <= 0 {
+		return nil, fmt.Errorf("invalid interval %q", config.Interval)
+	}
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.VerifyRegistry == "" {
+		config.VerifyRegistry = config.StagingRegistry
+	}
+
+	return config, nil
+}
+
+// Stages returns the arguments of the medius invocations of a pipeline run, mirroring pipeline-periodic.sh.
+func Stages(config *Config, resultsFile string) [][]string {
+	shared := []string{
+		"--results-file=" + resultsFile,
+		"--workers=" + strconv.Itoa(config.Workers),
+		"--insecure-skip-tls=" + strconv.FormatBool(config.InsecureSkipTLS),
+	}
+	if config.Focus != "" {
+		shared = append(shared, "--focus="+config.Focus)
+	}
+	if config.MetricsFile != "" {
+		shared = append(shared, "--metrics-file="+config.MetricsFile)
+	}
+
+	push := append([]string{
+		"images", "push", "--no-fail", "--dry-run=false",
+		"--source-registry=" + config.TargetRegistry, "--target-registry=" + config.StagingRegistry,
+	}, shared...)
+
+	verify := append([]string{
+		"images", "verify", "--no-fail", "--dry-run=false",
+		"--registry=" + config.VerifyRegistry,
+	}, shared...)
+	if config.Kubeconfig != "" {
+		verify = append(verify, "--kubeconfig="+config.Kubeconfig)
+	}
+	if config.Namespace != "" {
+		verify = append(verify, "--namespace="+config.Namespace)
+	}
+	if config.QuarantineFile != "" {
+		verify = append(verify, "--quarantine-file="+config.QuarantineFile)
+	}
+	if config.MaxFailures > 0 {
+		verify = append(verify, "--max-failures="+strconv.Itoa(config.MaxFailures))
+	}
+
+	promote := append([]string{
+		"images", "promote", "--dry-run=" + strconv.FormatBool(config.PromoteDryRun),
+		"--source-registry=" + config.StagingRegistry, "--target-registry=" + config.TargetRegistry,
+	}, shared...)
+	if config.RolloutFile != "" {
+		verify = append(verify, "--rollout-file="+config.RolloutFile, "--rollout-registry="+config.TargetRegistry)
+		promote = append(promote, "--rollout-file="+config.RolloutFile)
+	}
+	if config.RequiredSuccesses > 0 {
+		promote = append(promote, "--required-successes="+strconv.Itoa(config.RequiredSuccesses))
+	}
+
+	return [][]string{push, verify, promote}
+}
+
+// runPipeline runs every stage in its own process, so a failing stage can't take down the long-running server.
+func runPipeline(ctx context.Context, executable, resultsFile string, config *Config) error {
+	logrus.Info("Starting pipeline run")
+	// Every run starts from scratch, stale results would be promoted again otherwise
+	if err := os.Remove(resultsFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing results file: %v", err)
+	}
+
+	for _, args := range Stages(config, resultsFile) {
+		logrus.Infof("Running medius %s %s", args[0], args[1])
+		cmd := exec.CommandContext(ctx, executable, args...) //nolint:gosec // the executable is medius itself
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			return fmt.Errorf("stage %s failed: %v", args[1], err)
+		}
+	}
+
+	logrus.Info("Pipeline run finished")
+	return nil
+}
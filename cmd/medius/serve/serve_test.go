@@ -0,0 +1,75 @@
+package serve
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Serve", func() {
+	writeConfig := func(content string) string {
+		fileName := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		Expect(os.WriteFile(fileName, []byte(content), 0o600)).To(Succeed())
+		return fileName
+	}
+
+	It("should read and default the config", func() {
+		config, err := ReadConfig(writeConfig("stagingRegistry: registry:5000\ntargetRegistry: quay.io/containerdisks\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config).To(Equal(&Config{
+			Interval:        "24h0m0s",
+			Workers:         1,
+			StagingRegistry: "registry:5000",
+			VerifyRegistry:  "registry:5000",
+			TargetRegistry:  "quay.io/containerdisks",
+		}))
+	})
+
+	DescribeTable("should reject invalid configs",
+		func(content string) {
+			_, err := ReadConfig(writeConfig(content))
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("missing registries", "interval: 1h\n"),
+		Entry("invalid interval", "stagingRegistry: a\ntargetRegistry: b\ninterval: often\n"),
+		Entry("unknown field", "stagingRegistry: a\ntargetRegistry: b\nschedule: daily\n"),
+	)
+
+	It("should build the pipeline stages", func() {
+		stages := Stages(&Config{
+			Focus:             "fedora:*",
+			Workers:           3,
+			StagingRegistry:   "localhost:5000",
+			VerifyRegistry:    "registry:5000",
+			TargetRegistry:    "quay.io/containerdisks",
+			InsecureSkipTLS:   true,
+			Namespace:         "medius",
+			QuarantineFile:    "/var/lib/medius/quarantine.json",
+			RolloutFile:       "/var/lib/medius/rollout.json",
+			RequiredSuccesses: 3,
+		}, "/tmp/results.json")
+
+		Expect(stages).To(HaveLen(3))
+		shared := []string{"--results-file=/tmp/results.json", "--workers=3", "--insecure-skip-tls=true", "--focus=fedora:*"}
+		Expect(stages[0]).To(Equal(append([]string{
+			"images", "push", "--no-fail", "--dry-run=false",
+			"--source-registry=quay.io/containerdisks", "--target-registry=localhost:5000",
+		}, shared...)))
+		Expect(stages[1]).To(Equal(append(append([]string{
+			"images", "verify", "--no-fail", "--dry-run=false", "--registry=registry:5000",
+		}, shared...), "--namespace=medius", "--quarantine-file=/var/lib/medius/quarantine.json",
+			"--rollout-file=/var/lib/medius/rollout.json", "--rollout-registry=quay.io/containerdisks")))
+		Expect(stages[2]).To(Equal(append(append([]string{
+			"images", "promote", "--dry-run=false",
+			"--source-registry=localhost:5000", "--target-registry=quay.io/containerdisks",
+		}, shared...), "--rollout-file=/var/lib/medius/rollout.json", "--required-successes=3")))
+	})
+})
+
+func TestServe(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Serve Suite")
+}
@@ -0,0 +1,131 @@
+package streams
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/repository"
+	"kubevirt.io/containerdisks/pkg/streams"
+)
+
+type streamsOptions struct {
+	Registry   string
+	OutputFile string
+	PushRef    string
+}
+
+func NewStreamsCommand(options *common.Options) *cobra.Command {
+	streamsOptions := &streamsOptions{
+		Registry:   "quay.io/containerdisks",
+		OutputFile: streams.FileName,
+	}
+
+	streamsCmd := &cobra.Command{
+		Use:   "streams",
+		Short: "Generate a machine-readable streams document describing all published containerdisks",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd, options, streamsOptions)
+		},
+	}
+	streamsCmd.Flags().StringVar(&streamsOptions.Registry, "registry",
+		streamsOptions.Registry, "Registry the containerdisks are published in")
+	streamsCmd.Flags().StringVar(&streamsOptions.OutputFile, "output",
+		streamsOptions.OutputFile, "File to write the streams document to, empty to skip writing it")
+	streamsCmd.Flags().StringVar(&streamsOptions.PushRef, "push",
+		streamsOptions.PushRef, "Reference to push the streams document to as OCI artifact, e.g. quay.io/containerdisks/streams:latest")
+
+	return streamsCmd
+}
+
+func run(cmd *cobra.Command, options *common.Options, streamsOptions *streamsOptions) error {
+	repo := repository.New()
+	s := streams.New(time.Now())
+
+	registry, err := common.NewRegistryWithErrors()
+	if err != nil {
+		return fmt.Errorf("error gathering artifacts: %v", err)
+	}
+
+	for i := range registry {
+		entry := &registry[i]
+		if common.ShouldSkip(options.Focus, entry) {
+			continue
+		}
+
+		metadata := entry.Artifacts[0].Metadata()
+		log := common.Logger(entry.Artifacts[0])
+		image := entry.Image(streamsOptions.Registry)
+
+		pinnedRef, err := repo.PinnedReference(cmd.Context(), image, options.AllowInsecureRegistry)
+		if err != nil {
+			log.WithError(err).Warn("Containerdisk is not published, skipping it")
+			continue
+		}
+		_, digest, _ := strings.Cut(pinnedRef, "@")
+
+		stream := streams.Stream{
+			Image:         image,
+			Digest:        digest,
+			Latest:        entry.UseForLatest,
+			EOL:           metadata.EOL,
+			ReleaseNotes:  metadata.ReleaseNotes,
+			Variant:       string(metadata.Variant),
+			Architectures: map[string]streams.Architecture{},
+		}
+		for _, artifact := range entry.Artifacts {
+			arch := artifact.Metadata().Arch
+			info, err := repo.ImageMetadata(pinnedRef, arch, options.AllowInsecureRegistry)
+			if err != nil {
+				if repository.IsArchUnknownError(err) {
+					continue
+				}
+				return fmt.Errorf("error introspecting image %q: %v", image, err)
+			}
+			stream.Architectures[arch] = streams.Architecture{Labels: info.Labels}
+		}
+		s.Add(metadata.Name, metadata.Tag(), stream)
+	}
+
+	return publish(cmd, options, streamsOptions, s)
+}
+
+func publish(cmd *cobra.Command, options *common.Options, streamsOptions *streamsOptions, s *streams.Streams) error {
+	data, err := s.Marshal()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if streamsOptions.OutputFile != "" {
+		logrus.Infof("Writing streams document to %s", streamsOptions.OutputFile)
+		const permissionFile = 0o644
+		if err := os.WriteFile(streamsOptions.OutputFile, data, permissionFile); err != nil {
+			errs = append(errs, fmt.Errorf("error writing streams document: %v", err))
+		}
+	}
+
+	if streamsOptions.PushRef != "" {
+		if options.DryRun {
+			logrus.Infof("Dry run enabled, not pushing streams document to %s", streamsOptions.PushRef)
+			return errors.Join(errs...)
+		}
+
+		artifact, err := s.Artifact()
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		logrus.Infof("Pushing streams document to %s", streamsOptions.PushRef)
+		if err := repository.New().PushImage(cmd.Context(), artifact, streamsOptions.PushRef); err != nil {
+			errs = append(errs, fmt.Errorf("error pushing streams document: %v", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
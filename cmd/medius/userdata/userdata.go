@@ -0,0 +1,116 @@
+package userdata
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/userdata"
+)
+
+type userDataOptions struct {
+	SSHKeys     []string
+	SSHKeyFiles []string
+	Format      string
+	Secret      string
+	Namespace   string
+}
+
+func NewUserDataCommand() *cobra.Command {
+	userDataOptions := &userDataOptions{}
+
+	userDataCmd := &cobra.Command{
+		Use:   "userdata <containerdisk>",
+		Short: "Print the cloud-init or Ignition user data of a containerdisk, e.g. fedora:43, optionally as Secret",
+		Args:  cobra.ExactArgs(1),
+		ValidArgsFunction: func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			// Completion never contacts upstream, so the versions of gathered containerdisks are not completed
+			var completions []string
+			for _, completion := range common.StaticFocusCompletions(toComplete) {
+				if !strings.HasSuffix(completion, ":*") {
+					completions = append(completions, completion)
+				}
+			}
+			return completions, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(args[0], userDataOptions)
+		},
+	}
+	userDataCmd.Flags().StringSliceVar(&userDataOptions.SSHKeys, "ssh-key",
+		userDataOptions.SSHKeys, "SSH public key to authorize, a placeholder key is used if no key is given")
+	userDataCmd.Flags().StringSliceVar(&userDataOptions.SSHKeyFiles, "ssh-key-file",
+		userDataOptions.SSHKeyFiles, "File with SSH public keys to authorize, one per line, e.g. ~/.ssh/id_ed25519.pub")
+	userDataCmd.Flags().StringVar(&userDataOptions.Format, "format",
+		userDataOptions.Format, "Format of the user data, cloud-init or ignition, defaults to the one of the containerdisk")
+	userDataCmd.Flags().StringVar(&userDataOptions.Secret, "secret",
+		userDataOptions.Secret, "Print a Secret with this name holding the user data instead of the plain user data")
+	userDataCmd.Flags().StringVar(&userDataOptions.Namespace, "namespace",
+		userDataOptions.Namespace, "Namespace of the Secret")
+
+	return userDataCmd
+}
+
+func run(containerDisk string, userDataOptions *userDataOptions) error {
+	artifact, err := findArtifact(common.NewRegistry(), containerDisk)
+	if err != nil {
+		return err
+	}
+
+	keys, err := authorizedKeys(userDataOptions)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		logrus.Warn("No SSH key given, replace the placeholder key before using the user data")
+	}
+
+	userData, err := userdata.Generate(artifact, userdata.Format(userDataOptions.Format), keys)
+	if err != nil {
+		return err
+	}
+
+	data := []byte(userData.Data)
+	if userDataOptions.Secret != "" {
+		if data, err = yaml.Marshal(userData.Secret(userDataOptions.Secret, userDataOptions.Namespace)); err != nil {
+			return err
+		}
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// findArtifact returns the first artifact of the entry described by containerDisk, like --focus without wildcards.
+func findArtifact(registry []common.Entry, containerDisk string) (api.Artifact, error) {
+	for i := range registry {
+		if len(registry[i].Artifacts) > 0 && registry[i].Artifacts[0].Metadata().Describe() == containerDisk {
+			return registry[i].Artifacts[0], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown containerdisk %q", containerDisk)
+}
+
+func authorizedKeys(userDataOptions *userDataOptions) ([]string, error) {
+	keys := userDataOptions.SSHKeys
+	for _, keyFile := range userDataOptions.SSHKeyFiles {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading SSH keys: %v", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				keys = append(keys, line)
+			}
+		}
+	}
+	return keys, nil
+}
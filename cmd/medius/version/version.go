@@ -0,0 +1,92 @@
+package version
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/buildinfo"
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+func NewVersionCommand() *cobra.Command {
+	verbose := false
+
+	versionCmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the version of medius",
+		Long: `Print the version of medius. Published containerdisks carry the same version in their
+` + buildinfo.AnnotationVersion + ` label, to tell which build of the pipeline produced them.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(os.Stdout, buildinfo.Get(), verbose)
+		},
+	}
+	versionCmd.Flags().BoolVarP(&verbose, "verbose", "v",
+		verbose, "Print the commit, the build settings, the providers and the enabled features as well")
+
+	return versionCmd
+}
+
+func run(w io.Writer, info buildinfo.Info, verbose bool) error {
+	if !verbose {
+		_, err := fmt.Fprintf(w, "medius %s\n", info)
+		return err
+	}
+
+	lines := []string{
+		"Version:     " + info.Version,
+		"Commit:      " + info.Commit,
+		"Commit time: " + info.CommitTime,
+		fmt.Sprintf("Modified:    %t", info.Modified),
+		"Go version:  " + info.GoVersion,
+		"Platform:    " + info.Platform,
+	}
+	for _, key := range slices.Sorted(maps.Keys(info.Settings)) {
+		lines = append(lines, fmt.Sprintf("Build:       %s=%s", key, info.Settings[key]))
+	}
+
+	lines = append(lines, "", "Providers:")
+	for _, provider := range common.Providers() {
+		lines = append(lines, "  "+describeProvider(provider))
+	}
+
+	lines = append(lines, "", "Features:")
+	for _, env := range []string{http.CredentialsFileEnv, http.OverridesFileEnv, http.PinsFileEnv} {
+		lines = append(lines, fmt.Sprintf("  %s: %s", env, enabled(os.Getenv(env) != "")))
+	}
+
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// describeProvider names the provider with the features it supports, e.g. fedora (gathered, archive).
+func describeProvider(provider common.Provider) string {
+	var features []string
+	if provider.Gathered {
+		features = append(features, "gathered")
+	}
+	if provider.Archive {
+		features = append(features, "archive")
+	}
+	if provider.Snapshots {
+		features = append(features, "snapshots")
+	}
+	if len(features) == 0 {
+		return provider.Name
+	}
+	return fmt.Sprintf("%s (%s)", provider.Name, strings.Join(features, ", "))
+}
+
+func enabled(on bool) string {
+	if on {
+		return "enabled"
+	}
+	return "disabled"
+}
@@ -0,0 +1,36 @@
+// monitoring generates the recommended Prometheus alert rules and the Grafana dashboard
+// of the medius pipeline from the metrics medius emits.
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"kubevirt.io/containerdisks/pkg/metrics"
+)
+
+func main() {
+	const outputDir = "deploy/monitoring"
+
+	alerts, err := metrics.Alerts()
+	if err != nil {
+		log.Fatal(err)
+	}
+	dashboard, err := metrics.Dashboard()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	const permissionDir = 0o755
+	if err := os.MkdirAll(outputDir, permissionDir); err != nil {
+		log.Fatal(err)
+	}
+	const permissionFile = 0o644
+	if err := os.WriteFile(filepath.Join(outputDir, "alerts.yaml"), alerts, permissionFile); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "dashboard.json"), append(dashboard, '\n'), permissionFile); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -6,6 +6,7 @@ export KUBEVIRT_MEMORY_SIZE=9216M
 PROMOTE_DRY_RUN=${PROMOTE_DRY_RUN:-false}
 
 make medius
+./bin/medius version --verbose
 make cluster-up
 trap '{ make cluster-down; }' EXIT SIGINT SIGTERM
 
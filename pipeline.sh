@@ -5,6 +5,7 @@ set -e -o pipefail
 FOCUS=${FOCUS:-centos-stream:9}
 
 make medius
+./bin/medius version --verbose
 make cluster-up
 
 registry="$(./hack/kubevirtci.sh registry)"
@@ -0,0 +1,285 @@
+package airgap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+const (
+	// MetadataFileName is the file describing the images of a bundle, next to the OCI layout.
+	MetadataFileName = "medius-bundle.json"
+	// AnnotationRefName is the OCI annotation storing the original reference of an image in the layout.
+	AnnotationRefName = "org.opencontainers.image.ref.name"
+
+	signatureTagSuffix = ".sig"
+)
+
+// Metadata describes the images of a bundle.
+type Metadata struct {
+	Created time.Time `json:"created"`
+	Images  []Image   `json:"images"`
+}
+
+type Image struct {
+	// Reference the image was exported from, e.g. quay.io/containerdisks/fedora:43.
+	Reference string `json:"reference"`
+	// Name of the image without registry and organization, e.g. fedora.
+	Name   string `json:"name"`
+	Tag    string `json:"tag"`
+	Digest string `json:"digest"`
+	// Signature is the tag of the cosign signature of the image, if it is signed.
+	Signature string `json:"signature,omitempty"`
+}
+
+// Bundle is an OCI layout with the metadata of its images in a local directory.
+type Bundle struct {
+	Metadata Metadata
+	path     layout.Path
+}
+
+// NewBundle creates an empty bundle in dir.
+func NewBundle(dir string, created time.Time) (*Bundle, error) {
+	p, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		return nil, fmt.Errorf("error creating OCI layout in %s: %v", dir, err)
+	}
+	return &Bundle{Metadata: Metadata{Created: created.UTC()}, path: p}, nil
+}
+
+// OpenBundle opens a bundle previously written or unpacked to dir.
+func OpenBundle(dir string) (*Bundle, error) {
+	p, err := layout.FromPath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error opening OCI layout in %s: %v", dir, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, MetadataFileName))
+	if err != nil {
+		return nil, fmt.Errorf("error reading bundle metadata: %v", err)
+	}
+	b := &Bundle{path: p}
+	if err := json.Unmarshal(data, &b.Metadata); err != nil {
+		return nil, fmt.Errorf("error parsing bundle metadata: %v", err)
+	}
+
+	return b, nil
+}
+
+// Add pulls imgRef, including all architectures and its cosign signature if there is one, into the bundle.
+func (b *Bundle) Add(ctx context.Context, imgRef string, options ...crane.Option) error {
+	ref, err := name.NewTag(imgRef)
+	if err != nil {
+		return fmt.Errorf("only tagged images can be bundled: %v", err)
+	}
+	remoteOptions := crane.GetOptions(append([]crane.Option{crane.WithContext(ctx)}, options...)...).Remote
+
+	desc, err := remote.Get(ref, remoteOptions...)
+	if err != nil {
+		return fmt.Errorf("error fetching %s: %v", imgRef, err)
+	}
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return err
+		}
+		err = b.AddIndex(ref, idx)
+	} else {
+		img, err := desc.Image()
+		if err != nil {
+			return err
+		}
+		err = b.AddImage(ref, img)
+	}
+	if err != nil {
+		return err
+	}
+
+	sigDesc, err := remote.Get(signatureTag(ref, desc.Digest), remoteOptions...)
+	if err != nil {
+		// The image is not signed
+		return nil
+	}
+	sig, err := sigDesc.Image()
+	if err != nil {
+		return err
+	}
+	return b.AddSignature(ref, sig)
+}
+
+// AddIndex stores an image index with all its images in the bundle.
+func (b *Bundle) AddIndex(ref name.Tag, idx v1.ImageIndex) error {
+	digest, err := idx.Digest()
+	if err != nil {
+		return err
+	}
+	if err := b.path.AppendIndex(idx, refNameAnnotation(ref)); err != nil {
+		return fmt.Errorf("error writing %s to the bundle: %v", ref, err)
+	}
+	return b.addMetadata(ref, digest)
+}
+
+// AddImage stores a single image in the bundle.
+func (b *Bundle) AddImage(ref name.Tag, img v1.Image) error {
+	digest, err := img.Digest()
+	if err != nil {
+		return err
+	}
+	if err := b.path.AppendImage(img, refNameAnnotation(ref)); err != nil {
+		return fmt.Errorf("error writing %s to the bundle: %v", ref, err)
+	}
+	return b.addMetadata(ref, digest)
+}
+
+// AddSignature stores the cosign signature of an image which was added before.
+func (b *Bundle) AddSignature(ref name.Tag, sig v1.Image) error {
+	for i := range b.Metadata.Images {
+		image := &b.Metadata.Images[i]
+		if image.Reference != ref.String() {
+			continue
+		}
+
+		digest, err := v1.NewHash(image.Digest)
+		if err != nil {
+			return err
+		}
+		sigRef := signatureTag(ref, digest)
+		if err := b.path.AppendImage(sig, refNameAnnotation(sigRef)); err != nil {
+			return fmt.Errorf("error writing %s to the bundle: %v", sigRef, err)
+		}
+		image.Signature = sigRef.TagStr()
+		return b.writeMetadata()
+	}
+
+	return fmt.Errorf("bundle contains no image %s", ref)
+}
+
+func (b *Bundle) addMetadata(ref name.Tag, digest v1.Hash) error {
+	b.Metadata.Images = append(b.Metadata.Images, Image{
+		Reference: ref.String(),
+		Name:      path.Base(ref.Context().RepositoryStr()),
+		Tag:       ref.TagStr(),
+		Digest:    digest.String(),
+	})
+	return b.writeMetadata()
+}
+
+// signatureTag returns the tag cosign stores the signature of the image with digest at.
+func signatureTag(ref name.Tag, digest v1.Hash) name.Tag {
+	return ref.Context().Tag(digest.Algorithm + "-" + digest.Hex + signatureTagSuffix)
+}
+
+func refNameAnnotation(ref name.Reference) layout.Option {
+	return layout.WithAnnotations(map[string]string{AnnotationRefName: ref.String()})
+}
+
+func (b *Bundle) writeMetadata() error {
+	data, err := json.MarshalIndent(&b.Metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling bundle metadata: %v", err)
+	}
+	const permissionFile = 0o644
+	if err := os.WriteFile(filepath.Join(string(b.path), MetadataFileName), data, permissionFile); err != nil {
+		return fmt.Errorf("error writing bundle metadata: %v", err)
+	}
+	return nil
+}
+
+// Writer pushes images of a bundle to a registry.
+type Writer interface {
+	Write(ref name.Reference, img v1.Image) error
+	WriteIndex(ref name.Reference, idx v1.ImageIndex) error
+}
+
+// RemoteWriter pushes images with go-containerregistry.
+type RemoteWriter struct {
+	Options []crane.Option
+}
+
+func (w RemoteWriter) Write(ref name.Reference, img v1.Image) error {
+	return remote.Write(ref, img, crane.GetOptions(w.Options...).Remote...)
+}
+
+func (w RemoteWriter) WriteIndex(ref name.Reference, idx v1.ImageIndex) error {
+	return remote.WriteIndex(ref, idx, crane.GetOptions(w.Options...).Remote...)
+}
+
+// Push pushes all images of the bundle with their signatures to targetRegistry, e.g.
+// registry.example.com/containerdisks. It returns the pushed references.
+func (b *Bundle) Push(targetRegistry string, writer Writer) ([]string, error) {
+	idx, err := b.path.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	descriptors := map[string]v1.Descriptor{}
+	for _, desc := range manifest.Manifests {
+		descriptors[desc.Annotations[AnnotationRefName]] = desc
+	}
+
+	var (
+		pushed []string
+		errs   []error
+	)
+	for _, image := range b.Metadata.Images {
+		ref, err := name.ParseReference(image.Reference)
+		if err != nil {
+			return pushed, err
+		}
+		// The signature is pushed after the image it belongs to
+		tags := []name.Tag{ref.Context().Tag(image.Tag)}
+		if image.Signature != "" {
+			tags = append(tags, ref.Context().Tag(image.Signature))
+		}
+
+		for _, tag := range tags {
+			desc, ok := descriptors[tag.String()]
+			if !ok {
+				errs = append(errs, fmt.Errorf("bundle contains no image %s", tag))
+				continue
+			}
+			target, err := name.NewTag(fmt.Sprintf("%s:%s", path.Join(targetRegistry, image.Name), tag.TagStr()))
+			if err != nil {
+				return pushed, err
+			}
+			if err := pushDescriptor(idx, desc, target, writer); err != nil {
+				errs = append(errs, fmt.Errorf("error pushing %s: %v", target, err))
+				continue
+			}
+			pushed = append(pushed, target.String())
+		}
+	}
+
+	return pushed, errors.Join(errs...)
+}
+
+func pushDescriptor(idx v1.ImageIndex, desc v1.Descriptor, target name.Reference, writer Writer) error {
+	if desc.MediaType.IsIndex() {
+		child, err := idx.ImageIndex(desc.Digest)
+		if err != nil {
+			return err
+		}
+		return writer.WriteIndex(target, child)
+	}
+
+	img, err := idx.Image(desc.Digest)
+	if err != nil {
+		return err
+	}
+	return writer.Write(target, img)
+}
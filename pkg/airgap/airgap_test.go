@@ -0,0 +1,97 @@
+package airgap
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/build"
+)
+
+var _ = Describe("Bundle", func() {
+	It("should export and import images with their signatures", func() {
+		disk := filepath.Join(GinkgoT().TempDir(), "disk")
+		Expect(os.WriteFile(disk, []byte("disk"), 0o600)).To(Succeed())
+		img, err := build.ContainerDisk(disk, "amd64", build.ContainerDiskConfig("abcd", nil))
+		Expect(err).ToNot(HaveOccurred())
+		idx, err := build.ContainerDiskIndex([]v1.Image{img})
+		Expect(err).ToNot(HaveOccurred())
+		digest, err := idx.Digest()
+		Expect(err).ToNot(HaveOccurred())
+
+		ref, err := name.NewTag("quay.io/containerdisks/fedora:43")
+		Expect(err).ToNot(HaveOccurred())
+
+		exportDir := GinkgoT().TempDir()
+		bundle, err := NewBundle(exportDir, time.Date(2024, 6, 29, 12, 30, 0, 0, time.UTC))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bundle.AddIndex(ref, idx)).To(Succeed())
+		Expect(bundle.AddSignature(ref, empty.Image)).To(Succeed())
+
+		var packed bytes.Buffer
+		Expect(Pack(exportDir, &packed)).To(Succeed())
+
+		importDir := GinkgoT().TempDir()
+		Expect(Unpack(&packed, importDir)).To(Succeed())
+		imported, err := OpenBundle(importDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(imported.Metadata).To(Equal(bundle.Metadata))
+		Expect(imported.Metadata.Images[0].Digest).To(Equal(digest.String()))
+
+		writer := &fakeWriter{digests: map[string]v1.Hash{}}
+		pushed, err := imported.Push("registry.example.com/mirror", writer)
+		Expect(err).ToNot(HaveOccurred())
+		sigTag := "registry.example.com/mirror/fedora:sha256-" + digest.Hex + ".sig"
+		Expect(pushed).To(Equal([]string{"registry.example.com/mirror/fedora:43", sigTag}))
+		Expect(writer.digests).To(HaveKeyWithValue("registry.example.com/mirror/fedora:43", digest))
+	})
+
+	It("should reject digest references", func() {
+		bundle, err := NewBundle(GinkgoT().TempDir(), time.Now())
+		Expect(err).ToNot(HaveOccurred())
+		err = bundle.Add(GinkgoT().Context(), "quay.io/containerdisks/fedora@sha256:"+
+			"1044dec7206e8d7c9fbb4ae8f766668406d2567fc7fc1a160a9d4700fcf8f8e9")
+		Expect(err).To(MatchError(ContainSubstring("only tagged images")))
+	})
+
+	It("should refuse to unpack entries outside of the target directory", func() {
+		var buf bytes.Buffer
+		tarWriter := tar.NewWriter(&buf)
+		Expect(tarWriter.WriteHeader(&tar.Header{Name: "../escape", Typeflag: tar.TypeReg, Size: 1, Mode: 0o644})).To(Succeed())
+		_, err := tarWriter.Write([]byte("x"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tarWriter.Close()).To(Succeed())
+
+		Expect(Unpack(&buf, GinkgoT().TempDir())).To(MatchError(ContainSubstring("refusing to unpack")))
+	})
+})
+
+type fakeWriter struct {
+	digests map[string]v1.Hash
+}
+
+func (w *fakeWriter) Write(ref name.Reference, img v1.Image) error {
+	digest, err := img.Digest()
+	w.digests[ref.String()] = digest
+	return err
+}
+
+func (w *fakeWriter) WriteIndex(ref name.Reference, idx v1.ImageIndex) error {
+	digest, err := idx.Digest()
+	w.digests[ref.String()] = digest
+	return err
+}
+
+func TestAirgap(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Airgap Suite")
+}
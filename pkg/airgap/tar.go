@@ -0,0 +1,114 @@
+package airgap
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kubevirt.io/containerdisks/pkg/buffers"
+)
+
+// Pack writes the bundle in dir as tarball to w.
+func Pack(dir string, w io.Writer) error {
+	tarWriter := tar.NewWriter(w)
+
+	err := filepath.WalkDir(dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || p == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		// Tarballs always use forward slashes, also when packed on Windows
+		header.Name = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			header.Name += "/"
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = buffers.Copy(tarWriter, file)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error packing bundle: %v", err)
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("error packing bundle: %v", err)
+	}
+	return nil
+}
+
+// Unpack extracts a bundle tarball from r into dir. Only directories and regular files are extracted.
+func Unpack(r io.Reader, dir string) error {
+	tarReader := tar.NewReader(r)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error unpacking bundle: %v", err)
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if rel, err := filepath.Rel(dir, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("refusing to unpack %s outside of %s", header.Name, dir)
+		}
+
+		const permissionDir = 0o755
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, permissionDir); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), permissionDir); err != nil {
+				return err
+			}
+			if err := unpackFile(tarReader, target); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported entry %s in bundle", header.Name)
+		}
+	}
+}
+
+func unpackFile(r io.Reader, target string) error {
+	const permissionFile = 0o644
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, permissionFile)
+	if err != nil {
+		return fmt.Errorf("error unpacking bundle: %v", err)
+	}
+	if _, err := buffers.Copy(file, r); err != nil {
+		file.Close()
+		return fmt.Errorf("error unpacking bundle: %v", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,146 @@
+package announce
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/rundiff"
+)
+
+const eolLayout = "2006-01-02"
+
+var advisoryRegexp = regexp.MustCompile(`CVE-\d{4}-\d{4,}`)
+
+// Release is a version of a containerdisk published by a run.
+type Release struct {
+	Artifact     string
+	Version      string
+	Digest       string `json:",omitempty"`
+	ReleaseNotes string `json:",omitempty"`
+	// Advisories are the security advisories, e.g. CVE-2024-1234, referenced by the release notes.
+	Advisories []string `json:",omitempty"`
+}
+
+// Deprecation is a containerdisk whose upstream version reached or is about to reach its end of life.
+type Deprecation struct {
+	Artifact string
+	EOL      string
+	// Expired is true if the end of life date passed already.
+	Expired bool
+}
+
+// Announcement summarizes what a run published for humans, e.g. for the mailing list.
+type Announcement struct {
+	Date         time.Time
+	Releases     []Release
+	Deprecations []Deprecation
+}
+
+// New creates the announcement of the results of a run, metadata is keyed like the results. Without the
+// results of the previous run every artifact pushed by the run is announced, otherwise only artifacts whose
+// version changed. Artifacts reaching their end of life before now plus window are announced as deprecated.
+func New(now time.Time, window time.Duration, results, previous map[string]api.ArtifactResult,
+	metadata map[string]*api.Metadata) *Announcement {
+	a := &Announcement{Date: now}
+	for artifact, result := range results {
+		m := metadata[artifact]
+		if m == nil {
+			continue
+		}
+
+		if published(&result, previous, artifact) {
+			a.Releases = append(a.Releases, Release{
+				Artifact:     artifact,
+				Version:      rundiff.StateOf(&result).Version,
+				Digest:       result.Digest,
+				ReleaseNotes: m.ReleaseNotes,
+			})
+		}
+
+		eol, err := time.Parse(eolLayout, m.EOL)
+		if err != nil || eol.After(now.Add(window)) {
+			continue
+		}
+		a.Deprecations = append(a.Deprecations, Deprecation{Artifact: artifact, EOL: m.EOL, Expired: !eol.After(now)})
+	}
+
+	sort.Slice(a.Releases, func(i, j int) bool {
+		return a.Releases[i].Artifact < a.Releases[j].Artifact
+	})
+	sort.Slice(a.Deprecations, func(i, j int) bool {
+		return a.Deprecations[i].Artifact < a.Deprecations[j].Artifact
+	})
+	return a
+}
+
+func published(result *api.ArtifactResult, previous map[string]api.ArtifactResult, artifact string) bool {
+	if result.Err != "" || result.Quarantined || result.Digest == "" {
+		return false
+	}
+	if previous == nil {
+		return true
+	}
+	previousResult, exists := previous[artifact]
+	return !exists || rundiff.StateOf(&previousResult).Version != rundiff.StateOf(result).Version
+}
+
+// Advisories returns the sorted security advisories referenced by a release notes page.
+func Advisories(page []byte) []string {
+	var advisories []string
+	for _, match := range advisoryRegexp.FindAll(page, -1) {
+		if advisory := string(match); !slices.Contains(advisories, advisory) {
+			advisories = append(advisories, advisory)
+		}
+	}
+	sort.Strings(advisories)
+	return advisories
+}
+
+// Markdown renders the announcement as markdown document.
+func (a *Announcement) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# KubeVirt containerdisks update %s\n\n", a.Date.Format(eolLayout))
+
+	sb.WriteString("## New versions\n\n")
+	if len(a.Releases) == 0 {
+		sb.WriteString("No new versions were published.\n")
+	}
+	for i := range a.Releases {
+		r := &a.Releases[i]
+		fmt.Fprintf(&sb, "- %s: %s", r.Artifact, r.Version)
+		if r.ReleaseNotes != "" {
+			fmt.Fprintf(&sb, " ([release notes](%s))", r.ReleaseNotes)
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\n## Security\n\n")
+	security := false
+	for i := range a.Releases {
+		if r := &a.Releases[i]; len(r.Advisories) > 0 {
+			fmt.Fprintf(&sb, "- %s: %s\n", r.Artifact, strings.Join(r.Advisories, ", "))
+			security = true
+		}
+	}
+	if !security {
+		sb.WriteString("No security advisories are referenced by the release notes.\n")
+	}
+
+	sb.WriteString("\n## Deprecations\n\n")
+	if len(a.Deprecations) == 0 {
+		sb.WriteString("No containerdisks reach their end of life soon.\n")
+	}
+	for _, d := range a.Deprecations {
+		if d.Expired {
+			fmt.Fprintf(&sb, "- %s reached its end of life on %s and will no longer be updated\n", d.Artifact, d.EOL)
+		} else {
+			fmt.Fprintf(&sb, "- %s reaches its end of life on %s\n", d.Artifact, d.EOL)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,100 @@
+package announce
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+var _ = Describe("Announce", func() {
+	const (
+		digest       = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+		releaseNotes = "https://example.com/leap/15.6/"
+		window       = 90 * 24 * time.Hour
+	)
+
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	metadata := map[string]*api.Metadata{
+		"leap:15.6-x86_64":   {Name: "leap", EOL: "2026-04-30", ReleaseNotes: releaseNotes},
+		"debian:11-x86_64":   {Name: "debian", EOL: "2026-02-28"},
+		"fedora:43-x86_64":   {Name: "fedora", EOL: "2026-12-09"},
+		"ubuntu:24.04-amd64": {Name: "ubuntu"},
+	}
+	results := map[string]api.ArtifactResult{
+		"leap:15.6-x86_64":   {Tags: []string{"leap:15.6-2603010000", "leap:15.6"}, FloatingTags: []string{"leap:15.6"}, Digest: digest},
+		"debian:11-x86_64":   {Tags: []string{"debian:11-2603010000"}, Stage: "verify", Err: "timed out", Digest: digest},
+		"fedora:43-x86_64":   {Tags: []string{"fedora:43-1.6"}, Digest: digest},
+		"ubuntu:24.04-amd64": {Tags: []string{"ubuntu:24.04-2603010000"}, Quarantined: true, Digest: digest},
+		"unknown:1-x86_64":   {Tags: []string{"unknown:1-2603010000"}, Digest: digest},
+	}
+
+	It("New should announce published versions and deprecations", func() {
+		a := New(now, window, results, nil, metadata)
+		Expect(a.Releases).To(Equal([]Release{
+			{Artifact: "fedora:43-x86_64", Version: "43-1.6", Digest: digest},
+			{Artifact: "leap:15.6-x86_64", Version: "15.6-2603010000", Digest: digest, ReleaseNotes: releaseNotes},
+		}))
+		Expect(a.Deprecations).To(Equal([]Deprecation{
+			{Artifact: "debian:11-x86_64", EOL: "2026-02-28", Expired: true},
+			{Artifact: "leap:15.6-x86_64", EOL: "2026-04-30"},
+		}))
+	})
+
+	It("New should only announce changed versions if the previous results are known", func() {
+		previous := map[string]api.ArtifactResult{"fedora:43-x86_64": {Tags: []string{"fedora:43-1.6"}}}
+		a := New(now, window, results, previous, metadata)
+		Expect(a.Releases).To(HaveLen(1))
+		Expect(a.Releases[0].Artifact).To(Equal("leap:15.6-x86_64"))
+	})
+
+	It("Advisories should return unique sorted CVE IDs", func() {
+		Expect(Advisories([]byte("Fixes CVE-2025-12345 and CVE-2024-0001, see CVE-2025-12345 and CVE-123"))).
+			To(Equal([]string{"CVE-2024-0001", "CVE-2025-12345"}))
+		Expect(Advisories([]byte("Nothing to see"))).To(BeEmpty())
+	})
+
+	It("Markdown should render all sections", func() {
+		a := New(now, window, results, nil, metadata)
+		a.Releases[1].Advisories = []string{"CVE-2025-1111", "CVE-2025-2222"}
+		Expect(a.Markdown()).To(Equal(`# KubeVirt containerdisks update 2026-03-01
+
+## New versions
+
+- fedora:43-x86_64: 43-1.6
+- leap:15.6-x86_64: 15.6-2603010000 ([release notes](https://example.com/leap/15.6/))
+
+## Security
+
+- leap:15.6-x86_64: CVE-2025-1111, CVE-2025-2222
+
+## Deprecations
+
+- debian:11-x86_64 reached its end of life on 2026-02-28 and will no longer be updated
+- leap:15.6-x86_64 reaches its end of life on 2026-04-30
+`))
+
+		Expect((&Announcement{Date: now}).Markdown()).To(Equal(`# KubeVirt containerdisks update 2026-03-01
+
+## New versions
+
+No new versions were published.
+
+## Security
+
+No security advisories are referenced by the release notes.
+
+## Deprecations
+
+No containerdisks reach their end of life soon.
+`))
+	})
+})
+
+func TestAnnounce(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Announce Suite")
+}
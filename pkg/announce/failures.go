@@ -0,0 +1,54 @@
+package announce
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+// Failures renders the failed and quarantined artifacts of a run as markdown, e.g. as body of an issue. The
+// maintainers of every failed artifact are mentioned, fallback for artifacts without maintainers.
+func Failures(results map[string]api.ArtifactResult, fallback []string) string {
+	var artifacts []string
+	for artifact, result := range results {
+		if result.Err != "" || result.Quarantined {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	if len(artifacts) == 0 {
+		return "No containerdisks failed.\n"
+	}
+	sort.Strings(artifacts)
+
+	var sb strings.Builder
+	for _, artifact := range artifacts {
+		result := results[artifact]
+		status := result.Stage + " failed"
+		if result.Quarantined {
+			status = "quarantined"
+		}
+		fmt.Fprintf(&sb, "### %s (%s)\n\n", artifact, status)
+		if result.Err != "" {
+			fmt.Fprintf(&sb, "```\n%s\n```\n\n", result.Err)
+		}
+
+		maintainers := result.Maintainers
+		if len(maintainers) == 0 {
+			maintainers = fallback
+		}
+		if len(maintainers) > 0 {
+			fmt.Fprintf(&sb, "cc %s\n\n", mentions(maintainers))
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func mentions(handles []string) string {
+	mentioned := make([]string, 0, len(handles))
+	for _, handle := range handles {
+		mentioned = append(mentioned, "@"+strings.TrimPrefix(handle, "@"))
+	}
+	return strings.Join(mentioned, " ")
+}
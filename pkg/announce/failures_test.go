@@ -0,0 +1,31 @@
+package announce
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+var _ = Describe("Failures", func() {
+	It("should mention the maintainers of failed artifacts", func() {
+		results := map[string]api.ArtifactResult{
+			"fedora:43-x86_64": {Stage: "verify", Err: "timed out", Maintainers: []string{"alice", "@bob"}},
+			"debian:13-x86_64": {Stage: "push", Err: "checksum mismatch"},
+			"leap:15.6-x86_64": {Stage: "verify", Quarantined: true, Maintainers: []string{"carol"}},
+			"ubuntu:24.04":     {Stage: "promote", Maintainers: []string{"dave"}},
+		}
+		Expect(Failures(results, []string{"example-org/maintainers"})).To(Equal("### debian:13-x86_64 (push failed)\n\n" +
+			"```\nchecksum mismatch\n```\n\n" +
+			"cc @example-org/maintainers\n\n" +
+			"### fedora:43-x86_64 (verify failed)\n\n" +
+			"```\ntimed out\n```\n\n" +
+			"cc @alice @bob\n\n" +
+			"### leap:15.6-x86_64 (quarantined)\n\n" +
+			"cc @carol\n"))
+	})
+
+	It("should report runs without failures", func() {
+		Expect(Failures(map[string]api.ArtifactResult{"ubuntu:24.04": {Stage: "promote"}}, nil)).To(Equal("No containerdisks failed.\n"))
+	})
+})
@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"hash"
+	"io"
 
 	v1 "kubevirt.io/api/core/v1"
 
 	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/forensics"
+	"kubevirt.io/containerdisks/pkg/hashsum"
+	"kubevirt.io/containerdisks/pkg/tagpolicy"
 )
 
 type ArtifactTest func(ctx context.Context, vmi *v1.VirtualMachineInstance, params *ArtifactTestParams) error
@@ -22,10 +26,36 @@ type ArtifactTestParams struct {
 type ArtifactResult struct {
 	// Tags contains all tags the built containerdisk was tagged with.
 	Tags []string `json:",omitempty"`
+	// FloatingTags contains the tags of Tags which move from build to build, like the version tag and "latest".
+	FloatingTags []string `json:",omitempty"`
+	// Digest is the digest of the pushed containerdisk, the manifest list if it has multiple architectures.
+	Digest string `json:",omitempty"`
 	// Stage is the current stage of the containerdisk
 	Stage string
 	// Err indicates if an error happened while creating, verifying or promoting a containerdisk.
 	Err string `json:",omitempty"`
+	// KernelBootTags contains all tags the built kernel boot container was tagged with.
+	KernelBootTags []string `json:",omitempty"`
+	// Registry is set if the containerdisk was pushed to a private registry instead of the target registry.
+	Registry string `json:",omitempty"`
+	// Quarantined is set if the containerdisk failed verification too many times in a row. Its failure
+	// is reported but does not fail the run.
+	Quarantined bool `json:",omitempty"`
+	// Rollout is set if an already promoted containerdisk is verified again, before its floating tags are moved.
+	Rollout bool `json:",omitempty"`
+	// Forensics contains the evidence gathered about downloads whose checksum did not match upstream.
+	Forensics []forensics.Report `json:",omitempty"`
+	// Warnings describe problems of the containerdisk which do not fail the stage, e.g. a missing qemu-guest-agent.
+	Warnings []string `json:",omitempty"`
+	// Maintainers are copied from the Metadata of the containerdisk, to route failures to them.
+	Maintainers []string `json:",omitempty"`
+	// Architecture is the architecture the containerdisk was verified on by a single verify run.
+	Architecture string `json:",omitempty"`
+	// VerifiedArchitectures are the architectures which passed verification, once the results of the verify
+	// runs of all architectures were merged.
+	VerifiedArchitectures []string `json:",omitempty"`
+	// BuiltBy is the version of medius which built the containerdisk, e.g. v0.2.0 (commit 0123456789ab).
+	BuiltBy string `json:",omitempty"`
 }
 
 type ArtifactDetails struct {
@@ -33,17 +63,58 @@ type ArtifactDetails struct {
 	Checksum string
 	// ChecksumHash is the digest function used to compute the checksum
 	ChecksumHash func() hash.Hash
+	// CrossChecksums are checksums of the image from further upstream checksum files, e.g. SHA512SUMS next to
+	// SHA256SUMS. The download is verified against all of them in addition to Checksum.
+	CrossChecksums []hashsum.Checksum
 	// DownloadURL points to the target image.
 	DownloadURL string
+	// Generate writes the image instead of downloading it from DownloadURL, for disks which are generated
+	// locally. The same image has to be written every time, so it matches Checksum.
+	Generate func(w io.Writer) error
 	// ImageArchitecture is the target architecture of the image.
 	ImageArchitecture string
 	// Compression describes the compression format of the downloaded image.
-	// Supported are "" (none), "gzip" and "xz".
+	// Supported are "" (none), "gzip", "bzip2" and "xz".
 	Compression string
 	// AdditionalUniqueTags describes additional tags which furter specify the downloaded
 	// artifact version. For instance the main moving tag for fedora 35 would be '35' and here additional tags
 	// like '35-1.2'. This is useful for people to easier cross-reference the sources.
 	AdditionalUniqueTags []string
+	// AdditionalDisks describes further disk files shipped with the artifact, e.g. separate data disks of appliances.
+	// They are embedded next to the primary disk in the containerdisk.
+	AdditionalDisks []DiskDetails
+	// VirtualSize is the size of the disk in bytes as seen by the guest. It is populated during build.
+	VirtualSize int64
+	// ActualSize is the size of the uncompressed disk file in bytes. It is populated during build.
+	ActualSize int64
+	// KernelBoot optionally describes a kernel and initrd for direct kernel boot.
+	// If set, they are published as separate container image next to the containerdisk.
+	KernelBoot *KernelBootDetails
+}
+
+type KernelBootDetails struct {
+	// Kernel describes the kernel to download, its name is ignored.
+	Kernel DiskDetails
+	// Initrd describes the initrd to download, its name is ignored.
+	Initrd DiskDetails
+	// KernelArgs are the kernel command line arguments required to boot the kernel.
+	KernelArgs string
+}
+
+type DiskDetails struct {
+	// Name identifies the disk. The disk is stored as /disk/<Name>.img in the containerdisk.
+	Name string
+	// Checksum is the checksum of the disk to download.
+	Checksum string
+	// ChecksumHash is the digest function used to compute the checksum
+	ChecksumHash func() hash.Hash
+	// CrossChecksums are further checksums of the disk, see ArtifactDetails.
+	CrossChecksums []hashsum.Checksum
+	// DownloadURL points to the disk.
+	DownloadURL string
+	// Compression describes the compression format of the downloaded disk.
+	// Supported are "" (none), "gzip", "bzip2" and "xz".
+	Compression string
 }
 
 type Metadata struct {
@@ -63,10 +134,62 @@ type Metadata struct {
 	// IsStable indicates whether this artifact is a stable release version.
 	// Only stable artifacts are used for the "latest" tag or documentation.
 	IsStable bool
+	// EOL is the end of life date (YYYY-MM-DD) of the upstream version, if known.
+	EOL string
+	// ReleaseNotes is the URL of the upstream release notes of the version, if known.
+	ReleaseNotes string
+	// Maintainers are the GitHub handles, without "@", of the people looking after the provider. They are
+	// mentioned instead of the whole repository when the containerdisk fails.
+	Maintainers []string
+	// Variant distinguishes flavors of the same version, e.g. minimal or live images. Each variant is a separate
+	// registry entry whose tags are suffixed with the variant, see Tag.
+	Variant Variant
+	// License is the SPDX license expression of the OS, e.g. "LicenseRef-Fedora" for the terms of a distribution
+	// aggregating software under many licenses. It is reported by "medius licenses".
+	License string
+	// OSInfo is the libosinfo short ID of the OS, e.g. fedora43. It is derived from Name and Version for the
+	// known providers, so only other containerdisks need to set it.
+	OSInfo string
+	// TagScheme describes how Version and AdditionalUniqueTags are expanded into tags.
+	// Defaults to using them as they are.
+	TagScheme tagpolicy.Scheme
+	// PublishPolicy restricts where the containerdisks are pushed to. Defaults to the public target registry.
+	PublishPolicy PublishPolicy
+	// Hints optionally describe the virtual hardware the guest requires or works best with.
+	Hints docs.Hints
+	// LayerCompression overrides the compression of the layers of the containerdisk set with --compression, e.g.
+	// "none" for disks which are mostly pulled from local registries and should start as fast as possible.
+	LayerCompression string
+	// Verification selects the checks of the verify stage, by default all of them run.
+	Verification Verification
+	// SizeBudget is the expected size range of the disk. Disks outside of it are reported or, if enforced, not
+	// published at all.
+	SizeBudget SizeBudget
+	// AccessCredentials indicates that the guest runs the qemu-guest-agent, so SSH public keys can be
+	// injected at runtime with accessCredentials. It is checked during verification.
+	AccessCredentials bool
 }
 
+// PublishPolicy describes where the containerdisks of an artifact may be pushed to.
+type PublishPolicy string
+
+const (
+	// PublishPolicyPublic pushes containerdisks to the target registry.
+	PublishPolicyPublic PublishPolicy = ""
+	// PublishPolicyPrivate pushes containerdisks only to the designated private registry, e.g. for images
+	// whose license does not allow redistribution. They are verified there but never promoted.
+	PublishPolicyPrivate PublishPolicy = "private"
+	// PublishPolicySkip builds containerdisks without pushing them anywhere.
+	PublishPolicySkip PublishPolicy = "skip"
+)
+
 func (m Metadata) Describe() string {
-	return fmt.Sprintf("%s:%s", m.Name, m.Version)
+	return fmt.Sprintf("%s:%s", m.Name, m.Tag())
+}
+
+// Tag returns the moving version tag of the containerdisk, including its variant.
+func (m Metadata) Tag() string {
+	return m.Variant.Tag(m.Version)
 }
 
 type Artifact interface {
@@ -82,3 +205,15 @@ type ArtifactsGatherer interface {
 	// Artifacts have to be sorted in descending order with the latest release coming first.
 	Gather() ([][]Artifact, error)
 }
+
+type ArchiveGatherer interface {
+	// GatherArchived must return the artifacts of a specific historical upstream version,
+	// one per architecture, discovering them in the archives of the upstream project.
+	GatherArchived(version string) ([]Artifact, error)
+}
+
+type SnapshotGatherer interface {
+	// GatherSnapshots must return the artifacts of the latest count snapshots of a rolling distribution,
+	// the latest first. Their versions have to be unique to the snapshot, like those of archived artifacts.
+	GatherSnapshots(count int) ([][]Artifact, error)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+
+	v1 "kubevirt.io/api/core/v1"
+
+	"kubevirt.io/containerdisks/pkg/docs"
+)
+
+// MultiArtifact is implemented by providers which describe several related artifacts with a single inspection
+// of upstream, e.g. all architectures of a stream, instead of requiring one provider instance per artifact.
+// Split turns it into artifacts for the registry.
+type MultiArtifact interface {
+	// Inspect returns the details of all artifacts, in the order of Metadata.
+	Inspect() ([]*ArtifactDetails, error)
+	// Metadata returns the metadata of all artifacts.
+	Metadata() []*Metadata
+	VM(name, imgRef, userData string) *v1.VirtualMachine
+	UserData(data *docs.UserData) string
+	Tests() []ArtifactTest
+}
+
+// multiInspection shares the inspection of a MultiArtifact between the artifacts it was split into.
+type multiInspection struct {
+	MultiArtifact
+	mu      sync.Mutex
+	details []*ArtifactDetails
+}
+
+// inspect inspects upstream on the first call, failed inspections are repeated on the next call.
+func (m *multiInspection) inspect() ([]*ArtifactDetails, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.details != nil {
+		return m.details, nil
+	}
+	details, err := m.MultiArtifact.Inspect()
+	if err != nil {
+		return nil, err
+	}
+	if expected := len(m.Metadata()); len(details) != expected {
+		return nil, fmt.Errorf("inspection returned %d artifacts, expected %d", len(details), expected)
+	}
+	m.details = details
+	return details, nil
+}
+
+type splitArtifact struct {
+	*multiInspection
+	index int
+}
+
+// Inspect returns a copy of the details of the artifact, since the build stage populates some of them.
+func (s *splitArtifact) Inspect() (*ArtifactDetails, error) {
+	details, err := s.inspect()
+	if err != nil {
+		return nil, err
+	}
+	inspected := *details[s.index]
+	return &inspected, nil
+}
+
+func (s *splitArtifact) Metadata() *Metadata {
+	return s.MultiArtifact.Metadata()[s.index]
+}
+
+// Split returns an artifact for each of the artifacts of m, in the order of its metadata. m is only inspected
+// once for all of them.
+func Split(m MultiArtifact) []Artifact {
+	inspection := &multiInspection{MultiArtifact: m}
+	artifacts := make([]Artifact, 0, len(m.Metadata()))
+	for i := range m.Metadata() {
+		artifacts = append(artifacts, &splitArtifact{multiInspection: inspection, index: i})
+	}
+	return artifacts
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// SizeBudget is the expected range of the actual size of the uncompressed disk file of an artifact. Disks outside
+// of it usually are upstream packaging accidents, e.g. images which were not minimized or are truncated.
+type SizeBudget struct {
+	// Min is the lower bound of the size, e.g. 200Mi. A zero quantity leaves the range open.
+	Min resource.Quantity
+	// Max is the upper bound of the size, e.g. 2Gi. A zero quantity leaves the range open.
+	Max resource.Quantity
+	// Enforce fails publishing disks outside of the budget, by default they are only reported as warning.
+	Enforce bool
+}
+
+// IsZero returns true if no bound is set.
+func (s *SizeBudget) IsZero() bool {
+	return s.Min.IsZero() && s.Max.IsZero()
+}
+
+// Validate returns an error if a bound is negative or the range is empty.
+func (s *SizeBudget) Validate() error {
+	if s.Min.Sign() < 0 || s.Max.Sign() < 0 {
+		return fmt.Errorf("size budget %s must not be negative", s)
+	}
+	if !s.Max.IsZero() && s.Min.Cmp(s.Max) > 0 {
+		return fmt.Errorf("minimum of size budget %s is larger than its maximum", s)
+	}
+	return nil
+}
+
+// Check returns an error if size, in bytes, is outside of the budget.
+func (s *SizeBudget) Check(size int64) error {
+	if !s.Min.IsZero() && size < s.Min.Value() {
+		return fmt.Errorf("disk size of %d bytes is below the size budget %s", size, s)
+	}
+	if !s.Max.IsZero() && size > s.Max.Value() {
+		return fmt.Errorf("disk size of %d bytes exceeds the size budget %s", size, s)
+	}
+	return nil
+}
+
+func (s *SizeBudget) String() string {
+	switch {
+	case s.Max.IsZero():
+		return ">= " + s.Min.String()
+	case s.Min.IsZero():
+		return "<= " + s.Max.String()
+	default:
+		return s.Min.String() + "-" + s.Max.String()
+	}
+}
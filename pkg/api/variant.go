@@ -0,0 +1,64 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Variant is a flavor of a distribution version, published next to the default flavor with its own tags.
+type Variant string
+
+const (
+	// VariantDefault is the flavor published with the plain version tags, usually the cloud image.
+	VariantDefault Variant = ""
+	VariantMinimal Variant = "minimal"
+	VariantCloud   Variant = "cloud"
+	VariantLive    Variant = "live"
+	// VariantCustomized is an upstream image modified while building the containerdisk.
+	VariantCustomized Variant = "customized"
+	// VariantRaw is an upstream image published in raw instead of qcow2 format.
+	VariantRaw Variant = "raw"
+)
+
+var knownVariants = []Variant{VariantDefault, VariantMinimal, VariantCloud, VariantLive, VariantCustomized, VariantRaw}
+
+// Validate returns an error if the variant is unknown.
+func (v Variant) Validate() error {
+	if !slices.Contains(knownVariants, v) {
+		return fmt.Errorf("unknown variant %q", v)
+	}
+	return nil
+}
+
+// Tag appends the variant to a tag, e.g. "43" becomes "43-minimal" and "latest" becomes "latest-minimal".
+// Tags of the default variant are returned unchanged.
+func (v Variant) Tag(tag string) string {
+	if v == VariantDefault {
+		return tag
+	}
+	return tag + "-" + string(v)
+}
+
+// VariantOf returns the variant a tag was suffixed with by Tag, the default variant if it has no suffix of a
+// known variant.
+func VariantOf(tag string) Variant {
+	for _, v := range knownVariants {
+		if v != VariantDefault && strings.HasSuffix(tag, "-"+string(v)) {
+			return v
+		}
+	}
+	return VariantDefault
+}
+
+// Tags appends the variant to all tags.
+func (v Variant) Tags(tags []string) []string {
+	if v == VariantDefault {
+		return tags
+	}
+	variantTags := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		variantTags = append(variantTags, v.Tag(tag))
+	}
+	return variantTags
+}
@@ -0,0 +1,22 @@
+package api
+
+import "time"
+
+// Verification selects the checks the verify stage runs for a containerdisk, so appliances, BSDs and disks
+// without an OS are verified with the checks that fit them. The zero value runs all checks.
+type Verification struct {
+	// SkipBoot only checks the pushed containerdisk without booting a VM, e.g. for driver ISOs and blank disks.
+	SkipBoot bool
+	// GuestAgent requires the qemu-guest-agent to connect. It is implied by AccessCredentials.
+	GuestAgent bool
+	// NoCloudInit creates the VM without cloud-init user data, for guests which don't ship cloud-init. Tests
+	// logging in with the SSH key injected by cloud-init can't pass then.
+	NoCloudInit bool
+	// MaxBootTime is the time the VM may take until it is ready, it overrides --timeout of the verify stage.
+	MaxBootTime time.Duration
+}
+
+// RequiresGuestAgent returns true if verification fails without a connected qemu-guest-agent.
+func (m *Metadata) RequiresGuestAgent() bool {
+	return m.AccessCredentials || m.Verification.GuestAgent
+}
@@ -0,0 +1,74 @@
+// Package v1alpha1 contains the ContainerDiskSource API, which declares containerdisks to build and publish in-cluster.
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kubevirt.io/containerdisks/pkg/tagpolicy"
+)
+
+const (
+	Group    = "containerdisks.kubevirt.io"
+	Version  = "v1alpha1"
+	Kind     = "ContainerDiskSource"
+	Resource = "containerdisksources"
+)
+
+var GroupVersionResource = schema.GroupVersionResource{Group: Group, Version: Version, Resource: Resource}
+
+// ContainerDiskSource declares a disk image which is built into a containerdisk and published to a repository.
+type ContainerDiskSource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ContainerDiskSourceSpec   `json:"spec"`
+	Status ContainerDiskSourceStatus `json:"status,omitempty"`
+}
+
+type ContainerDiskSourceSpec struct {
+	// URL of the disk image.
+	URL string `json:"url"`
+	// Checksum is the SHA256 checksum of the disk image. Either Checksum or ChecksumURL has to be set.
+	Checksum string `json:"checksum,omitempty"`
+	// ChecksumURL points to a BSD or GNU style SHA256 checksum file which lists the disk image.
+	ChecksumURL string `json:"checksumURL,omitempty"`
+	// Compression of the disk image, "" (none), "gzip" or "xz".
+	Compression string `json:"compression,omitempty"`
+	// Arch of the disk image, defaults to x86_64.
+	Arch string `json:"arch,omitempty"`
+	// Version is the tag of the containerdisk, expanded according to the TagPolicy.
+	Version string `json:"version"`
+	// TagPolicy is the scheme to expand the version into tags, "" (exact), "semver" or "point-release".
+	TagPolicy tagpolicy.Scheme `json:"tagPolicy,omitempty"`
+	// TargetRepository is the repository to publish the containerdisk to, e.g. registry.example.com/containerdisks/appliance.
+	TargetRepository string `json:"targetRepository"`
+	// License is the SPDX license expression of the disk image, it is reported by "medius licenses".
+	License string `json:"license,omitempty"`
+	// OSInfo is the libosinfo short ID of the OS of the disk image, e.g. fedora43, the published containerdisk is
+	// labeled with.
+	OSInfo string `json:"osInfo,omitempty"`
+	// LayerCompression is the compression of the layers of the containerdisk, gzip, zstd or none, overriding the
+	// compression medius was started with.
+	LayerCompression string `json:"layerCompression,omitempty"`
+}
+
+const (
+	PhasePublished = "Published"
+	PhaseFailed    = "Failed"
+)
+
+type ContainerDiskSourceStatus struct {
+	// ObservedGeneration is the generation of the spec which was reconciled last.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Phase is either Published or Failed.
+	Phase string `json:"phase,omitempty"`
+	// Message describes why the last reconciliation failed.
+	Message string `json:"message,omitempty"`
+	// Checksum of the published disk image.
+	Checksum string `json:"checksum,omitempty"`
+	// Tags the containerdisk was published with.
+	Tags []string `json:"tags,omitempty"`
+	// LastPublished is the time the containerdisk was published last.
+	LastPublished *metav1.Time `json:"lastPublished,omitempty"`
+}
@@ -0,0 +1,193 @@
+// Package bandwidth limits the bandwidth of downloads from upstream hosts and uploads to registries, in total
+// and per host, so publish runs on shared infrastructure leave room for the other users of the uplink.
+package bandwidth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Limits are bandwidth limits in bytes per second, zero is unlimited.
+type Limits struct {
+	// Global limits all transfers together.
+	Global int64
+	// Hosts limits the transfers from and to each host, by host name with optional port.
+	Hosts map[string]int64
+}
+
+// ParseLimits parses a global limit like 50Mi and host limits like quay.io=20Mi, all in bytes per second.
+// An empty global limit is unlimited.
+func ParseLimits(global string, hosts []string) (Limits, error) {
+	limits := Limits{Hosts: map[string]int64{}}
+	if global != "" {
+		value, err := parseRate(global)
+		if err != nil {
+			return Limits{}, err
+		}
+		limits.Global = value
+	}
+
+	for _, spec := range hosts {
+		host, value, found := strings.Cut(spec, "=")
+		if !found || host == "" {
+			return Limits{}, fmt.Errorf("invalid host bandwidth limit %q, expected <host>=<bytes per second>", spec)
+		}
+		bytesPerSecond, err := parseRate(value)
+		if err != nil {
+			return Limits{}, err
+		}
+		limits.Hosts[host] = bytesPerSecond
+	}
+	return limits, nil
+}
+
+func parseRate(value string) (int64, error) {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth limit %q: %v", value, err)
+	}
+	if quantity.Sign() < 0 {
+		return 0, fmt.Errorf("invalid bandwidth limit %q: must not be negative", value)
+	}
+	return quantity.Value(), nil
+}
+
+// limiters are the token buckets of limits. The size of a bucket is the transfer of one second, which bounds
+// how far a transfer can burst after being idle.
+type limiters struct {
+	global *rate.Limiter
+	hosts  map[string]*rate.Limiter
+}
+
+func newLimiters(limits Limits) *limiters {
+	l := &limiters{global: newLimiter(limits.Global), hosts: map[string]*rate.Limiter{}}
+	for host, bytesPerSecond := range limits.Hosts {
+		if limiter := newLimiter(bytesPerSecond); limiter != nil {
+			l.hosts[host] = limiter
+		}
+	}
+	return l
+}
+
+func newLimiter(bytesPerSecond int64) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), int(min(bytesPerSecond, maxBurst)))
+}
+
+// maxBurst keeps the bucket size within int on all platforms.
+const maxBurst = 1 << 30
+
+// forHost returns the limiters applying to transfers from and to host.
+func (l *limiters) forHost(host string) []*rate.Limiter {
+	var applying []*rate.Limiter
+	if l.global != nil {
+		applying = append(applying, l.global)
+	}
+	limiter, exists := l.hosts[host]
+	if !exists {
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			limiter = l.hosts[hostname]
+		}
+	}
+	if limiter != nil {
+		applying = append(applying, limiter)
+	}
+	return applying
+}
+
+var (
+	defaultLimitersMu sync.RWMutex
+	defaultLimiters   = newLimiters(Limits{})
+)
+
+// SetDefaultLimits sets the limits of all transfers. Transfers in progress keep their previous limits.
+func SetDefaultLimits(limits Limits) {
+	defaultLimitersMu.Lock()
+	defer defaultLimitersMu.Unlock()
+	defaultLimiters = newLimiters(limits)
+}
+
+func getDefaultLimiters() *limiters {
+	defaultLimitersMu.RLock()
+	defer defaultLimitersMu.RUnlock()
+	return defaultLimiters
+}
+
+// Limited returns whether any bandwidth limit is set.
+func Limited() bool {
+	l := getDefaultLimiters()
+	return l.global != nil || len(l.hosts) > 0
+}
+
+// Reader limits the bandwidth of reading body, a transfer from or to host. Reads block until all limits
+// applying to the host allow them, or ctx is done.
+func Reader(ctx context.Context, host string, body io.ReadCloser) io.ReadCloser {
+	applying := getDefaultLimiters().forHost(host)
+	if len(applying) == 0 {
+		return body
+	}
+	return &limitedReader{ctx: ctx, body: body, limiters: applying}
+}
+
+type limitedReader struct {
+	ctx      context.Context
+	body     io.ReadCloser
+	limiters []*rate.Limiter
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	// A read can't take more tokens than fit into the smallest bucket
+	for _, limiter := range r.limiters {
+		p = p[:min(len(p), limiter.Burst())]
+	}
+	n, err := r.body.Read(p)
+	if n > 0 {
+		for _, limiter := range r.limiters {
+			if waitErr := limiter.WaitN(r.ctx, n); waitErr != nil {
+				return n, waitErr
+			}
+		}
+	}
+	return n, err
+}
+
+func (r *limitedReader) Close() error {
+	return r.body.Close()
+}
+
+// Transport returns a round tripper limiting the bandwidth of the request and response bodies sent through
+// base, e.g. for the registry clients. base is returned as is if no limit is set.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if !Limited() {
+		return base
+	}
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody {
+		// A round tripper must not modify the request it is given
+		req = req.Clone(req.Context())
+		req.Body = Reader(req.Context(), req.URL.Host, req.Body)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = Reader(req.Context(), req.URL.Host, resp.Body)
+	return resp, nil
+}
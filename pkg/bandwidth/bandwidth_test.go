@@ -0,0 +1,97 @@
+package bandwidth
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bandwidth", func() {
+	AfterEach(func() {
+		SetDefaultLimits(Limits{})
+	})
+
+	It("should parse global and host limits", func() {
+		limits, err := ParseLimits("50Mi", []string{"quay.io=20Mi", "mirror.example.com:8443=1M"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(limits).To(Equal(Limits{
+			Global: 50 * 1024 * 1024,
+			Hosts:  map[string]int64{"quay.io": 20 * 1024 * 1024, "mirror.example.com:8443": 1000 * 1000},
+		}))
+
+		_, err = ParseLimits("", []string{"quay.io"})
+		Expect(err).To(MatchError(`invalid host bandwidth limit "quay.io", expected <host>=<bytes per second>`))
+		_, err = ParseLimits("-1Mi", nil)
+		Expect(err).To(MatchError(`invalid bandwidth limit "-1Mi": must not be negative`))
+	})
+
+	It("should apply the global limit and the limit of the host", func() {
+		SetDefaultLimits(Limits{Global: 100, Hosts: map[string]int64{"quay.io": 10, "ghcr.io": 0}})
+		Expect(Limited()).To(BeTrue())
+
+		l := getDefaultLimiters()
+		Expect(l.forHost("quay.io")).To(HaveLen(2))
+		Expect(l.forHost("quay.io:443")).To(HaveLen(2))
+		Expect(l.forHost("ghcr.io")).To(HaveLen(1))
+		Expect(l.forHost("docker.io")).To(HaveLen(1))
+	})
+
+	It("should throttle reads", func() {
+		const bytesPerSecond = 10 * 1024
+		SetDefaultLimits(Limits{Hosts: map[string]int64{"quay.io": bytesPerSecond}})
+
+		data := bytes.Repeat([]byte{0x42}, 2*bytesPerSecond+bytesPerSecond/2)
+		start := time.Now()
+		read, err := io.ReadAll(Reader(context.Background(), "quay.io", io.NopCloser(bytes.NewReader(data))))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(read).To(Equal(data))
+		// The full bucket covers the first second, the rest is paced
+		Expect(time.Since(start)).To(BeNumerically(">=", time.Second))
+
+		body := io.NopCloser(bytes.NewReader(data))
+		Expect(Reader(context.Background(), "ghcr.io", body)).To(BeIdenticalTo(body))
+	})
+
+	It("should stop waiting when the context is done", func() {
+		SetDefaultLimits(Limits{Global: 1})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := io.ReadAll(Reader(ctx, "quay.io", io.NopCloser(bytes.NewReader([]byte("data")))))
+		Expect(err).To(MatchError(context.Canceled))
+	})
+
+	It("should limit request and response bodies of the transport", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.Copy(w, r.Body)
+		}))
+		DeferCleanup(server.Close)
+
+		base := http.DefaultTransport
+		Expect(Transport(base)).To(BeIdenticalTo(base))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		SetDefaultLimits(Limits{Hosts: map[string]int64{serverURL.Hostname(): 1024 * 1024}})
+		client := &http.Client{Transport: Transport(base)}
+
+		resp, err := client.Post(server.URL, "application/octet-stream", bytes.NewReader([]byte("layer")))
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.Body).To(BeAssignableToTypeOf(&limitedReader{}))
+		Expect(io.ReadAll(resp.Body)).To(Equal([]byte("layer")))
+	})
+})
+
+func TestBandwidth(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Bandwidth Suite")
+}
@@ -0,0 +1,130 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"math/rand"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+
+	"kubevirt.io/containerdisks/pkg/build"
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+const (
+	// dataBlockSize is the size of the alternating random and zeroed blocks of generated data.
+	dataBlockSize = 64 * 1024
+	mebibyte      = 1024 * 1024
+)
+
+// Result is the throughput of a stage of the pipeline.
+type Result struct {
+	Stage    string        `json:"stage"`
+	Bytes    int64         `json:"bytes"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Throughput returns the processed MiB per second.
+func (r Result) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / mebibyte / r.Duration.Seconds()
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%-28s %10.1f MiB %10.2fs %10.1f MiB/s", r.Stage, float64(r.Bytes)/mebibyte, r.Duration.Seconds(), r.Throughput())
+}
+
+// NewData returns a reproducible reader of size bytes which roughly compresses like a disk image,
+// half of it is random and the other half zeroed.
+func NewData(size int64) io.Reader {
+	return io.LimitReader(&dataReader{random: rand.New(rand.NewSource(1))}, size) //nolint:gosec // no secrets
+}
+
+type dataReader struct {
+	random *rand.Rand
+	offset int64
+}
+
+func (d *dataReader) Read(p []byte) (int, error) {
+	n := len(p)
+	if remaining := dataBlockSize - int(d.offset%dataBlockSize); n > remaining {
+		n = remaining
+	}
+	if (d.offset/dataBlockSize)%2 == 0 {
+		d.random.Read(p[:n])
+	} else {
+		clear(p[:n])
+	}
+	d.offset += int64(n)
+	return n, nil
+}
+
+// Hash measures hashing size bytes.
+func Hash(name string, newHash func() hash.Hash, size int64) (Result, error) {
+	start := time.Now()
+	n, err := io.Copy(newHash(), NewData(size))
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Stage: "hash " + name, Bytes: n, Duration: time.Since(start)}, nil
+}
+
+// Compress measures compressing size bytes like a layer.
+func Compress(name string, compression build.Compression, size int64) (Result, error) {
+	start := time.Now()
+	if err := compression.Compress(io.Discard, NewData(size)); err != nil {
+		return Result{}, err
+	}
+	return Result{Stage: "compress " + name, Bytes: size, Duration: time.Since(start)}, nil
+}
+
+// Download measures downloading and hashing fileURL like a disk.
+func Download(ctx context.Context, getter http.Getter, fileURL string) (Result, error) {
+	start := time.Now()
+	reader, err := getter.GetWithChecksumAndContext(ctx, fileURL, sha256.New)
+	if err != nil {
+		return Result{}, err
+	}
+	defer reader.Close()
+
+	n, err := io.Copy(io.Discard, reader)
+	if err != nil {
+		return Result{}, fmt.Errorf("error downloading %s: %v", fileURL, err)
+	}
+	return Result{Stage: "download", Bytes: n, Duration: time.Since(start)}, nil
+}
+
+// Upload measures pushing an image with a layer of size bytes to ref. The layer is compressed before
+// the measurement, so only the upload is measured.
+func Upload(ctx context.Context, ref string, size int64, options ...crane.Option) (Result, error) {
+	var compressed bytes.Buffer
+	if err := (&build.Compression{}).Compress(&compressed, NewData(size)); err != nil {
+		return Result{}, err
+	}
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(compressed.Bytes())), nil
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("error creating the layer: %v", err)
+	}
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		return Result{}, fmt.Errorf("error creating the image: %v", err)
+	}
+
+	start := time.Now()
+	if err := crane.Push(img, ref, append([]crane.Option{crane.WithContext(ctx)}, options...)...); err != nil {
+		return Result{}, fmt.Errorf("error pushing %s: %v", ref, err)
+	}
+	return Result{Stage: "upload", Bytes: int64(compressed.Len()), Duration: time.Since(start)}, nil
+}
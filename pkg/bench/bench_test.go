@@ -0,0 +1,97 @@
+package bench
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/build"
+	mediushttp "kubevirt.io/containerdisks/pkg/http"
+)
+
+var _ = Describe("Bench", func() {
+	It("NewData should generate reproducible, partly zeroed data", func() {
+		data, err := io.ReadAll(NewData(4 * dataBlockSize))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(HaveLen(4 * dataBlockSize))
+		Expect(data[dataBlockSize : 2*dataBlockSize]).To(Equal(make([]byte, dataBlockSize)))
+		Expect(data[:dataBlockSize]).ToNot(Equal(make([]byte, dataBlockSize)))
+
+		again, err := io.ReadAll(NewData(4 * dataBlockSize))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(again).To(Equal(data))
+	})
+
+	It("Result should calculate the throughput", func() {
+		result := Result{Stage: "hash", Bytes: 100 * mebibyte, Duration: 2 * time.Second}
+		Expect(result.Throughput()).To(Equal(50.0))
+		Expect(result.String()).To(ContainSubstring("50.0 MiB/s"))
+		Expect(Result{}.Throughput()).To(BeZero())
+	})
+
+	It("should measure hashing and compression of the requested size", func() {
+		result, err := Hash("sha256", sha256.New, mebibyte)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Bytes).To(Equal(int64(mebibyte)))
+
+		result, err = Compress("gzip", build.Compression{Threads: 2}, mebibyte)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Stage).To(Equal("compress gzip"))
+		Expect(result.Bytes).To(Equal(int64(mebibyte)))
+	})
+
+	It("should measure downloads", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.Copy(w, bytes.NewReader(make([]byte, mebibyte)))
+		}))
+		DeferCleanup(server.Close)
+
+		result, err := Download(GinkgoT().Context(), &mediushttp.HTTPGetter{}, server.URL+"/disk.img")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Bytes).To(Equal(int64(mebibyte)))
+	})
+})
+
+func BenchmarkHashSHA256(b *testing.B) {
+	benchmarkReader(b, func(r io.Reader) error {
+		_, err := io.Copy(sha256.New(), r)
+		return err
+	})
+}
+
+func BenchmarkCompressGzip(b *testing.B) {
+	compression := build.Compression{}
+	benchmarkReader(b, func(r io.Reader) error {
+		return compression.Compress(io.Discard, r)
+	})
+}
+
+func BenchmarkCompressZstd(b *testing.B) {
+	compression := build.Compression{Algorithm: build.CompressionZstd}
+	benchmarkReader(b, func(r io.Reader) error {
+		return compression.Compress(io.Discard, r)
+	})
+}
+
+// benchmarkReader reports the throughput of f processing generated data.
+func benchmarkReader(b *testing.B, f func(r io.Reader) error) {
+	const size = 64 * mebibyte
+	b.SetBytes(size)
+	for b.Loop() {
+		if err := f(NewData(size)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestBench(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Bench Suite")
+}
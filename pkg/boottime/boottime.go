@@ -0,0 +1,151 @@
+package boottime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"kubevirt.io/containerdisks/pkg/version"
+)
+
+// DefaultThreshold is the relative increase of the boot time over the baseline which is a regression.
+const DefaultThreshold = 0.25
+
+const (
+	// maxSamples is the number of boot times kept per artifact.
+	maxSamples = 50
+	// baselineSamples is the number of the latest boot times of older builds the baseline is computed from.
+	baselineSamples = 5
+)
+
+// Sample is the boot time of a build of an artifact, measured by a single verification.
+type Sample struct {
+	Version string `json:"version"`
+	// Build identifies the build, e.g. by its unique tag. Artifacts like CentOS Stream publish new builds of
+	// the same version.
+	Build   string    `json:"build"`
+	Arch    string    `json:"arch"`
+	Seconds float64   `json:"seconds"`
+	Time    time.Time `json:"time"`
+}
+
+// Regression describes a boot time exceeding the baseline of the older builds of an artifact.
+type Regression struct {
+	BootTime time.Duration
+	Baseline time.Duration
+}
+
+func (r *Regression) String() string {
+	return fmt.Sprintf("boot time %s regressed by %.0f%% over the baseline of %s of older builds",
+		r.BootTime.Round(time.Second), (r.Ratio()-1)*100, r.Baseline.Round(time.Second))
+}
+
+// Ratio returns the boot time relative to the baseline.
+func (r *Regression) Ratio() float64 {
+	return r.BootTime.Seconds() / r.Baseline.Seconds()
+}
+
+// History keeps the boot times of artifacts across runs, to detect new builds which take noticeably longer
+// until the guest can be logged in to than older builds did.
+type History struct {
+	Threshold float64 `json:"-"`
+	// Artifacts maps artifact names to their boot times, the oldest first.
+	Artifacts map[string][]Sample `json:"artifacts"`
+
+	mu sync.Mutex
+}
+
+func New(threshold float64) *History {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	return &History{Threshold: threshold, Artifacts: map[string][]Sample{}}
+}
+
+// Read loads the history of previous runs. A missing file results in an empty history.
+func Read(fileName string, threshold float64) (*History, error) {
+	h := New(threshold)
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading boot time history: %v", err)
+	}
+
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("error parsing boot time history %s: %v", fileName, err)
+	}
+	if h.Artifacts == nil {
+		h.Artifacts = map[string][]Sample{}
+	}
+
+	return h, nil
+}
+
+func (h *History) Write(fileName string) error {
+	h.mu.Lock()
+	data, err := json.MarshalIndent(h, "", "  ")
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	const permissionFile = 0o644
+	if err := os.WriteFile(fileName, append(data, '\n'), permissionFile); err != nil {
+		return fmt.Errorf("error writing boot time history %s: %v", fileName, err)
+	}
+
+	return nil
+}
+
+// Record adds the boot time of a build of an artifact. It returns a regression if the boot time exceeds the
+// median of the latest boot times of older builds on the same architecture by more than the threshold.
+// Other verifications of the same build, e.g. during a rollout, are not part of its baseline.
+func (h *History) Record(name string, sample Sample) *Regression {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	baseline := h.baseline(name, sample)
+	samples := append(h.Artifacts[name], sample)
+	if len(samples) > maxSamples {
+		samples = samples[len(samples)-maxSamples:]
+	}
+	h.Artifacts[name] = samples
+
+	if baseline == 0 || sample.Seconds <= baseline*(1+h.Threshold) {
+		return nil
+	}
+	return &Regression{BootTime: seconds(sample.Seconds), Baseline: seconds(baseline)}
+}
+
+// baseline returns the median of the latest boot times of older builds, or 0 if there are none. Builds of
+// newer versions are excluded, so maintained older versions don't regress by the boot times of newer ones.
+func (h *History) baseline(name string, sample Sample) float64 {
+	var baseline []float64
+	samples := h.Artifacts[name]
+	for i := len(samples) - 1; i >= 0 && len(baseline) < baselineSamples; i-- {
+		older := samples[i].Arch == sample.Arch && samples[i].Build != sample.Build &&
+			version.Compare(samples[i].Version, sample.Version) <= 0
+		if older {
+			baseline = append(baseline, samples[i].Seconds)
+		}
+	}
+	if len(baseline) == 0 {
+		return 0
+	}
+
+	slices.Sort(baseline)
+	if len(baseline)%2 == 1 {
+		return baseline[len(baseline)/2]
+	}
+	return (baseline[len(baseline)/2-1] + baseline[len(baseline)/2]) / 2
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,75 @@
+package boottime
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Boot time history", func() {
+	now := time.Date(2024, 6, 29, 12, 30, 0, 0, time.UTC)
+
+	sample := func(version, build string, seconds float64) Sample {
+		return Sample{Version: version, Build: build, Arch: "amd64", Seconds: seconds, Time: now}
+	}
+
+	It("should report builds booting slower than older builds", func() {
+		h := New(0.25)
+		Expect(h.Record("fedora", sample("42", "a", 50))).To(BeNil())
+		Expect(h.Record("fedora", sample("42", "b", 45))).To(BeNil())
+		Expect(h.Record("fedora", sample("42", "c", 55))).To(BeNil())
+
+		regression := h.Record("fedora", sample("43", "d", 80))
+		Expect(regression).To(Equal(&Regression{BootTime: 80 * time.Second, Baseline: 50 * time.Second}))
+		Expect(regression.Ratio()).To(Equal(1.6))
+		Expect(regression.String()).To(Equal("boot time 1m20s regressed by 60% over the baseline of 50s of older builds"))
+	})
+
+	It("should tolerate boot times within the threshold", func() {
+		h := New(0.25)
+		Expect(h.Record("fedora", sample("42", "a", 40))).To(BeNil())
+		Expect(h.Record("fedora", sample("43", "b", 50))).To(BeNil())
+	})
+
+	It("should not compare builds to newer versions, other architectures or themselves", func() {
+		h := New(0.25)
+		Expect(h.Record("fedora", sample("43", "a", 40))).To(BeNil())
+		Expect(h.Record("fedora", sample("42", "b", 80))).To(BeNil())
+		Expect(h.Record("fedora", sample("42", "b", 80))).To(BeNil())
+
+		arm64 := sample("43", "c", 120)
+		arm64.Arch = "arm64"
+		Expect(h.Record("fedora", arm64)).To(BeNil())
+		Expect(h.Record("centos-stream", sample("43", "d", 120))).To(BeNil())
+	})
+
+	It("should limit the samples per artifact", func() {
+		h := New(0.25)
+		for range maxSamples + 1 {
+			h.Record("fedora", sample("43", "a", 40))
+		}
+		Expect(h.Artifacts["fedora"]).To(HaveLen(maxSamples))
+	})
+
+	It("should persist boot times across runs", func() {
+		fileName := filepath.Join(GinkgoT().TempDir(), "boottimes.json")
+		h, err := Read(fileName, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(h.Threshold).To(Equal(DefaultThreshold))
+		Expect(h.Record("fedora", sample("42", "a", 40))).To(BeNil())
+		Expect(h.Write(fileName)).To(Succeed())
+
+		h, err = Read(fileName, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(h.Artifacts["fedora"]).To(Equal([]Sample{sample("42", "a", 40)}))
+		Expect(h.Record("fedora", sample("43", "b", 60))).ToNot(BeNil())
+	})
+})
+
+func TestBootTime(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Boot Time Suite")
+}
@@ -0,0 +1,82 @@
+// Package buffers provides pooled buffers for the download, hash, tar and compression pipeline, so
+// packaging many large disks concurrently doesn't allocate new buffers for every copy.
+package buffers
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultSize is the default size of pooled buffers.
+	DefaultSize = 1024 * 1024
+	// MinSize is the smallest supported buffer size.
+	MinSize = 4 * 1024
+)
+
+var (
+	size atomic.Int64
+	pool = sync.Pool{
+		New: func() any {
+			buf := make([]byte, Size())
+			return &buf
+		},
+	}
+)
+
+func init() {
+	size.Store(DefaultSize)
+}
+
+// SetSize sets the size of pooled buffers. Buffers of the previous size are dropped when they are returned.
+func SetSize(n int64) error {
+	if n < MinSize {
+		return errors.New("buffer size must be at least 4KiB")
+	}
+	size.Store(n)
+	return nil
+}
+
+// Size returns the size of pooled buffers.
+func Size() int64 {
+	return size.Load()
+}
+
+// Get returns a buffer of Size bytes. It must be returned with Put once it is not used anymore.
+func Get() *[]byte {
+	buf := pool.Get().(*[]byte)
+	if int64(len(*buf)) != Size() {
+		*buf = make([]byte, Size())
+	}
+	return buf
+}
+
+// Put returns a buffer to the pool.
+func Put(buf *[]byte) {
+	if int64(len(*buf)) != Size() {
+		return
+	}
+	pool.Put(buf)
+}
+
+// Copy is like io.Copy, but uses a pooled buffer.
+func Copy(dst io.Writer, src io.Reader) (int64, error) {
+	buf := Get()
+	defer Put(buf)
+	return io.CopyBuffer(dst, src, *buf)
+}
+
+// CopyN is like io.CopyN, but uses a pooled buffer.
+func CopyN(dst io.Writer, src io.Reader, n int64) (int64, error) {
+	written, err := Copy(dst, io.LimitReader(src, n))
+	if written == n {
+		return n, nil
+	}
+	if written < n && err == nil {
+		// src stopped early; must have been EOF
+		err = io.EOF
+	}
+	return written, err
+}
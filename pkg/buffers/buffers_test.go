@@ -0,0 +1,76 @@
+package buffers
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Buffers", func() {
+	AfterEach(func() {
+		Expect(SetSize(DefaultSize)).To(Succeed())
+	})
+
+	It("should return buffers of the configured size", func() {
+		Expect(SetSize(64 * 1024)).To(Succeed())
+		buf := Get()
+		Expect(*buf).To(HaveLen(64 * 1024))
+		Put(buf)
+
+		Expect(SetSize(128 * 1024)).To(Succeed())
+		buf = Get()
+		Expect(*buf).To(HaveLen(128 * 1024))
+		Put(buf)
+	})
+
+	It("should reject too small buffers", func() {
+		Expect(SetSize(1024)).To(MatchError(ContainSubstring("at least 4KiB")))
+		Expect(Size()).To(Equal(int64(DefaultSize)))
+	})
+
+	It("Copy should copy everything", func() {
+		var out bytes.Buffer
+		n, err := Copy(&out, strings.NewReader("data"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(int64(4)))
+		Expect(out.String()).To(Equal("data"))
+	})
+
+	DescribeTable("CopyN should behave like io.CopyN", func(input string, n int64) {
+		var expected, actual bytes.Buffer
+		expectedN, expectedErr := io.CopyN(&expected, strings.NewReader(input), n)
+		actualN, actualErr := CopyN(&actual, strings.NewReader(input), n)
+		Expect(actualN).To(Equal(expectedN))
+		Expect(actual.String()).To(Equal(expected.String()))
+		if expectedErr == nil {
+			Expect(actualErr).ToNot(HaveOccurred())
+		} else {
+			Expect(actualErr).To(MatchError(expectedErr))
+		}
+	},
+		Entry("longer input", "data", int64(2)),
+		Entry("exact input", "data", int64(4)),
+		Entry("shorter input", "data", int64(8)),
+	)
+})
+
+func BenchmarkCopy(b *testing.B) {
+	data := make([]byte, 16*DefaultSize)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		// Hide WriterTo and ReaderFrom, so the pooled buffer is used
+		if _, err := Copy(struct{ io.Writer }{io.Discard}, struct{ io.Reader }{bytes.NewReader(data)}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestBuffers(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Buffers Suite")
+}
@@ -2,28 +2,41 @@ package build
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/partial"
-	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"kubevirt.io/containerdisks/pkg/common"
 )
 
 const (
 	LabelShaSum = "shasum"
 	ImageOS     = "linux"
+	// AnnotationReleaseNotes is the annotation and label containing the URL of the upstream release notes.
+	AnnotationReleaseNotes = "containerdisks.kubevirt.io/release-notes"
+	// AnnotationLicenses is the annotation and label containing the SPDX license expression of the OS.
+	AnnotationLicenses = "org.opencontainers.image.licenses"
+	// AnnotationVariant is the annotation and label containing the variant of the containerdisk, e.g. minimal.
+	AnnotationVariant = "containerdisks.kubevirt.io/variant"
 )
 
 func ContainerDiskConfig(checksum string, envVariables map[string]string) v1.Config {
-	labels := map[string]string{
-		LabelShaSum: checksum,
+	// The default instancetype and preference are also exposed as labels with the keys KubeVirt recognizes
+	labels := common.DefaultsLabels(envVariables)
+	labels[LabelShaSum] = checksum
+	if layout := getDefaultLayout(); layout.DiskPath != DefaultLayout.DiskPath {
+		labels[LabelDiskPath] = layout.DiskPath
 	}
 
+	// Sort the env variables to keep the resulting image config reproducible
 	var env []string
-	for k, v := range envVariables {
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	for _, k := range slices.Sorted(maps.Keys(envVariables)) {
+		env = append(env, fmt.Sprintf("%s=%s", k, envVariables[k]))
 	}
 
 	// OCI runtimes like crun-vm [1] may be able to run a containerdisk container image directly, in which case it
@@ -41,18 +54,62 @@ func ContainerDiskConfig(checksum string, envVariables map[string]string) v1.Con
 	return v1.Config{Labels: labels, Env: env, Entrypoint: entrypoint}
 }
 
-func ContainerDisk(imgPath, imgArch string, config v1.Config) (v1.Image, error) {
-	layer, err := tarball.LayerFromOpener(StreamLayerOpener(imgPath))
+// AdditionalDisk is a disk which is embedded next to the primary disk in a containerdisk.
+type AdditionalDisk struct {
+	// Name of the disk, it is stored as /disk/<Name>.img.
+	Name string
+	// Path to the disk on the local filesystem.
+	Path string
+}
+
+// AdditionalDiskFileName returns the file name of an additional disk inside the containerdisk.
+func AdditionalDiskFileName(name string) string {
+	return name + ".img"
+}
+
+// AdditionalDiskLabel returns the label which stores the checksum of an additional disk.
+func AdditionalDiskLabel(name string) string {
+	return LabelShaSum + "." + name
+}
+
+func ContainerDisk(imgPath, imgArch string, config v1.Config, additionalDisks ...AdditionalDisk) (v1.Image, error) {
+	return ContainerDiskWithCompression(getDefaultCompression(), imgPath, imgArch, config, additionalDisks...)
+}
+
+// ContainerDiskWithCompression builds a containerdisk like ContainerDisk, but compresses its layers with compression
+// instead of the default compression, e.g. to publish single containerdisks uncompressed.
+func ContainerDiskWithCompression(
+	compression Compression, imgPath, imgArch string, config v1.Config, additionalDisks ...AdditionalDisk,
+) (v1.Image, error) {
+	if err := compression.validate(); err != nil {
+		return nil, err
+	}
+
+	layer, err := newLayerWithCompression(StreamLayerOpener(imgPath), compression)
 	if err != nil {
 		return nil, fmt.Errorf("error creating an image layer from disk: %v", err)
 	}
+	layers := []v1.Layer{layer}
 
-	img := mutate.MediaType(empty.Image, types.DockerManifestSchema2)
-	img, err = mutate.AppendLayers(img, layer)
+	// Every additional disk gets its own layer, so unchanged disks can be shared between versions
+	for _, disk := range additionalDisks {
+		layer, err := newLayerWithCompression(StreamNamedLayerOpener(disk.Path, AdditionalDiskFileName(disk.Name)), compression)
+		if err != nil {
+			return nil, fmt.Errorf("error creating an image layer from disk %q: %v", disk.Name, err)
+		}
+		layers = append(layers, layer)
+	}
+
+	img := mutate.MediaType(empty.Image, compression.manifestMediaType())
+	img, err = mutate.AppendLayers(img, layers...)
 	if err != nil {
 		return nil, fmt.Errorf("error appending the image layer: %v", err)
 	}
 
+	return configureImage(img, imgArch, config)
+}
+
+func configureImage(img v1.Image, imgArch string, config v1.Config) (v1.Image, error) {
 	cf, err := img.ConfigFile()
 	if err != nil {
 		return nil, fmt.Errorf("error getting the image config file: %v", err)
@@ -74,7 +131,17 @@ func ContainerDisk(imgPath, imgArch string, config v1.Config) (v1.Image, error)
 func ContainerDiskIndex(images []v1.Image) (v1.ImageIndex, error) {
 	var indexAddendum []mutate.IndexAddendum
 
+	// Docker manifest lists can't refer to the OCI manifests of containerdisks with a different compression
+	indexMediaType := compressionIndexMediaType()
 	for _, image := range images {
+		mediaType, err := image.MediaType()
+		if err != nil {
+			return nil, err
+		}
+		if mediaType == types.OCIManifestSchema1 {
+			indexMediaType = types.OCIImageIndex
+		}
+
 		configFile, err := image.ConfigFile()
 		if err != nil {
 			return nil, err
@@ -92,6 +159,6 @@ func ContainerDiskIndex(images []v1.Image) (v1.ImageIndex, error) {
 		})
 	}
 
-	idx := mutate.IndexMediaType(empty.Index, types.DockerManifestList)
+	idx := mutate.IndexMediaType(empty.Index, indexMediaType)
 	return mutate.AppendManifests(idx, indexAddendum...), nil
 }
@@ -0,0 +1,148 @@
+package build
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/common"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+var _ = Describe("Build", func() {
+	It("ContainerDiskConfig should create a stable config", func() {
+		config := ContainerDiskConfig("abcdef", map[string]string{
+			common.DefaultPreferenceEnv:   "fedora",
+			common.DefaultInstancetypeEnv: "u1.medium",
+		})
+		data, err := json.MarshalIndent(config, "", "  ")
+		Expect(err).ToNot(HaveOccurred())
+		testutil.ExpectGolden("testdata/config.golden.json", data)
+	})
+
+	It("ContainerDisk should embed additional disks as separate layers", func() {
+		dir := GinkgoT().TempDir()
+		primary := filepath.Join(dir, "primary")
+		Expect(os.WriteFile(primary, []byte("primary"), 0o600)).To(Succeed())
+		data := filepath.Join(dir, "data")
+		Expect(os.WriteFile(data, []byte("data"), 0o600)).To(Succeed())
+
+		img, err := ContainerDisk(primary, "amd64", ContainerDiskConfig("abcdef", nil), AdditionalDisk{Name: "data", Path: data})
+		Expect(err).ToNot(HaveOccurred())
+		layers, err := img.Layers()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(layers).To(HaveLen(2))
+
+		var names []string
+		for _, layer := range layers {
+			reader, err := layer.Uncompressed()
+			Expect(err).ToNot(HaveOccurred())
+			tarReader := tar.NewReader(reader)
+			for {
+				header, err := tarReader.Next()
+				if err == io.EOF {
+					break
+				}
+				Expect(err).ToNot(HaveOccurred())
+				names = append(names, header.Name)
+			}
+			Expect(reader.Close()).To(Succeed())
+		}
+		Expect(names).To(Equal([]string{"disk/", "disk/disk.img", "disk/", "disk/data.img"}))
+	})
+
+	DescribeTable("DiskSize should determine virtual and actual size",
+		func(content []byte, virtualSize, actualSize int64) {
+			disk := filepath.Join(GinkgoT().TempDir(), "disk")
+			Expect(os.WriteFile(disk, content, 0o600)).To(Succeed())
+			gotVirtualSize, gotActualSize, err := DiskSize(disk)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gotVirtualSize).To(Equal(virtualSize))
+			Expect(gotActualSize).To(Equal(actualSize))
+		},
+		Entry("qcow2", append(
+			[]byte{'Q', 'F', 'I', 0xfb, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 16, 0, 0, 0, 1, 0, 0, 0, 0},
+			make([]byte, 32)...), int64(1<<32), int64(64)),
+		Entry("raw", make([]byte, 100), int64(100), int64(100)),
+		Entry("tiny raw", []byte("hello"), int64(5), int64(5)),
+	)
+
+	It("AnnotateDiskSize should annotate the manifest", func() {
+		disk := filepath.Join(GinkgoT().TempDir(), "disk")
+		Expect(os.WriteFile(disk, []byte("disk"), 0o600)).To(Succeed())
+		img, err := ContainerDisk(disk, "amd64", ContainerDiskConfig("abcdef", nil))
+		Expect(err).ToNot(HaveOccurred())
+		manifest, err := AnnotateDiskSize(img, 1024, 4).Manifest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Annotations).To(HaveKeyWithValue(AnnotationVirtualSize, "1024"))
+		Expect(manifest.Annotations).To(HaveKeyWithValue(AnnotationActualSize, "4"))
+	})
+
+	It("KernelBootContainer should contain kernel and initrd", func() {
+		dir := GinkgoT().TempDir()
+		kernel := filepath.Join(dir, "kernel")
+		Expect(os.WriteFile(kernel, []byte("kernel"), 0o600)).To(Succeed())
+		initrd := filepath.Join(dir, "initrd")
+		Expect(os.WriteFile(initrd, []byte("initrd"), 0o600)).To(Succeed())
+
+		img, err := KernelBootContainer(kernel, initrd, "arm64", ContainerDiskConfig("abcdef", nil))
+		Expect(err).ToNot(HaveOccurred())
+		cf, err := img.ConfigFile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cf.Architecture).To(Equal("arm64"))
+
+		layers, err := img.Layers()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(layers).To(HaveLen(1))
+		contents := layerContents(layers[0])
+		Expect(contents).To(Equal(map[string]string{
+			KernelBootDir:                  "",
+			KernelBootDir + KernelFileName: "kernel",
+			KernelBootDir + InitrdFileName: "initrd",
+		}))
+	})
+})
+
+func layerContents(layer v1.Layer) map[string]string {
+	reader, err := layer.Uncompressed()
+	Expect(err).ToNot(HaveOccurred())
+	defer reader.Close()
+
+	contents := map[string]string{}
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(tarReader)
+		Expect(err).ToNot(HaveOccurred())
+		contents[header.Name] = string(data)
+	}
+	return contents
+}
+
+func BenchmarkContainerDisk(b *testing.B) {
+	data := make([]byte, 64*1024*1024)
+	rand.New(rand.NewSource(1)).Read(data[:len(data)/2]) //nolint:gosec // deterministic test data
+	disk := filepath.Join(b.TempDir(), "disk")
+	if err := os.WriteFile(disk, data, 0o600); err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		if _, err := ContainerDisk(disk, "amd64", ContainerDiskConfig("abcdef", nil)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
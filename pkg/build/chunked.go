@@ -0,0 +1,149 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+
+	"kubevirt.io/containerdisks/pkg/chunker"
+)
+
+const (
+	// ChunkDir is the directory inside a chunked containerdisk which contains the chunks of the disk.
+	ChunkDir = DiskDir + "chunks/"
+	// ChunkIndexFileName is the file name of the chunk index inside DiskDir. It lists the sha256 of
+	// the chunks in the order in which they have to be concatenated to restore the disk.
+	ChunkIndexFileName = DiskFileName + ".chunks"
+	// ChunkedTagSuffix is appended to the containerdisk tags to form the chunked containerdisk tags.
+	ChunkedTagSuffix = "-chunked"
+	// AnnotationChunk is the layer annotation containing the sha256 of the chunk stored in the layer.
+	AnnotationChunk = "containerdisks.kubevirt.io/chunk"
+	// AnnotationChunkIndex is the layer annotation marking the layer which contains the chunk index.
+	AnnotationChunkIndex = "containerdisks.kubevirt.io/chunk-index"
+	// MaxChunks limits the number of chunk layers, as container runtimes only support a limited number of layers.
+	MaxChunks = 120
+)
+
+// chunkOptions returns the chunk size bounds of a disk, it is replaced by tests to split small disks.
+var chunkOptions = func(size int64) chunker.Options {
+	return chunker.OptionsForSize(size, MaxChunks)
+}
+
+// ChunkIndexPath returns the absolute path of the chunk index inside the chunked containerdisk.
+func ChunkIndexPath() string {
+	return "/" + DiskDir + ChunkIndexFileName
+}
+
+// ChunkedContainerDisk creates a containerdisk which stores the disk in content-defined chunks, one layer
+// per chunk. Successive versions of a disk share most of their chunks and thereby most of their layers,
+// which reduces the storage in registries and the size of pulls of new versions.
+// The disk has to be restored by concatenating the chunks in the order of the chunk index, so KubeVirt
+// can't boot chunked containerdisks directly.
+func ChunkedContainerDisk(imgPath, imgArch string, config v1.Config) (v1.Image, error) {
+	chunks, err := splitDisk(imgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		index   strings.Builder
+		addenda []mutate.Addendum
+	)
+	seen := map[string]bool{}
+	for _, chunk := range chunks {
+		index.WriteString(chunk.Digest + "\n")
+		// Repeated chunks, e.g. of zeroed areas of raw disks, are stored only once
+		if seen[chunk.Digest] {
+			continue
+		}
+		seen[chunk.Digest] = true
+
+		layer, err := newLayer(chunkLayerOpener(imgPath, chunk))
+		if err != nil {
+			return nil, fmt.Errorf("error creating an image layer from chunk %s: %v", chunk.Digest, err)
+		}
+		addenda = append(addenda, mutate.Addendum{
+			Layer:       layer,
+			Annotations: map[string]string{AnnotationChunk: chunk.Digest},
+		})
+	}
+
+	indexLayer, err := chunkIndexLayer(index.String())
+	if err != nil {
+		return nil, fmt.Errorf("error creating the chunk index layer: %v", err)
+	}
+	addenda = append(addenda, mutate.Addendum{
+		Layer:       indexLayer,
+		Annotations: map[string]string{AnnotationChunkIndex: ChunkIndexFileName},
+	})
+
+	img := mutate.MediaType(empty.Image, compressionManifestMediaType())
+	img, err = mutate.Append(img, addenda...)
+	if err != nil {
+		return nil, fmt.Errorf("error appending the image layers: %v", err)
+	}
+
+	return configureImage(img, imgArch, config)
+}
+
+func splitDisk(imgPath string) ([]chunker.Chunk, error) {
+	file, err := os.Open(imgPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening disk: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error getting file information with stat: %w", err)
+	}
+
+	chunks, err := chunker.Split(file, chunkOptions(stat.Size()))
+	if err != nil {
+		return nil, fmt.Errorf("error splitting disk into chunks: %v", err)
+	}
+	return chunks, nil
+}
+
+func chunkLayerOpener(imgPath string, chunk chunker.Chunk) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		file, err := os.Open(imgPath)
+		if err != nil {
+			return nil, fmt.Errorf("error opening file: %w", err)
+		}
+
+		pipeReader, pipeWriter := io.Pipe()
+		go func() {
+			defer file.Close()
+			section := io.NewSectionReader(file, chunk.Offset, chunk.Length)
+			pipeWriter.CloseWithError(writeChunkLayer(pipeWriter, section, chunk.Length, ChunkDir+chunk.Digest))
+		}()
+
+		return pipeReader, nil
+	}
+}
+
+func chunkIndexLayer(index string) (v1.Layer, error) {
+	var buf bytes.Buffer
+	if err := writeChunkLayer(&buf, strings.NewReader(index), int64(len(index)), DiskDir+ChunkIndexFileName); err != nil {
+		return nil, err
+	}
+	return newLayer(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	})
+}
+
+// writeChunkLayer writes a layer containing only the file name, which is reproducible for equal content.
+func writeChunkLayer(w io.Writer, reader io.Reader, size int64, name string) error {
+	layerWriter := newLayerWriter(w)
+	if err := layerWriter.addFile(name, reader, size); err != nil {
+		return fmt.Errorf("error adding '%s' to tarball: %w", name, err)
+	}
+	return layerWriter.close()
+}
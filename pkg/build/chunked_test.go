@@ -0,0 +1,71 @@
+package build
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/chunker"
+)
+
+var _ = Describe("ChunkedContainerDisk", func() {
+	BeforeEach(func() {
+		original := chunkOptions
+		chunkOptions = func(int64) chunker.Options {
+			return chunker.Options{MinSize: 1024, AvgSize: 4096, MaxSize: 16384}
+		}
+		DeferCleanup(func() { chunkOptions = original })
+	})
+
+	build := func(data []byte) (v1.Image, *v1.Manifest) {
+		disk := filepath.Join(GinkgoT().TempDir(), "disk")
+		Expect(os.WriteFile(disk, data, 0o600)).To(Succeed())
+		img, err := ChunkedContainerDisk(disk, "amd64", ContainerDiskConfig("abcdef", nil))
+		Expect(err).ToNot(HaveOccurred())
+		manifest, err := img.Manifest()
+		Expect(err).ToNot(HaveOccurred())
+		return img, manifest
+	}
+
+	It("should share the layers of unchanged chunks between versions", func() {
+		data := make([]byte, 256*1024)
+		rand.New(rand.NewSource(1)).Read(data) //nolint:gosec // deterministic test data
+		_, manifest := build(data)
+
+		modified := bytes.Clone(data)
+		copy(modified[100*1024:], []byte("modified"))
+		_, modifiedManifest := build(modified)
+
+		layers := map[v1.Hash]bool{}
+		for _, layer := range manifest.Layers {
+			layers[layer.Digest] = true
+		}
+		changed := 0
+		for _, layer := range modifiedManifest.Layers {
+			if !layers[layer.Digest] {
+				changed++
+			}
+		}
+		// The modified chunk and the index
+		Expect(changed).To(Equal(2))
+		Expect(len(modifiedManifest.Layers)).To(BeNumerically(">", 10))
+	})
+
+	It("should store repeated chunks once and list them in the index", func() {
+		img, manifest := build(make([]byte, 3*16384))
+		Expect(manifest.Layers).To(HaveLen(2))
+		Expect(manifest.Layers[0].Annotations).To(HaveKey(AnnotationChunk))
+		Expect(manifest.Layers[1].Annotations).To(HaveKeyWithValue(AnnotationChunkIndex, ChunkIndexFileName))
+
+		layers, err := img.Layers()
+		Expect(err).ToNot(HaveOccurred())
+		contents := layerContents(layers[1])
+		chunk := manifest.Layers[0].Annotations[AnnotationChunk]
+		Expect(contents).To(HaveKeyWithValue(DiskDir+ChunkIndexFileName, chunk+"\n"+chunk+"\n"+chunk+"\n"))
+	})
+})
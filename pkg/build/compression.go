@@ -0,0 +1,203 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/klauspost/compress/zstd"
+	"github.com/klauspost/pgzip"
+
+	"kubevirt.io/containerdisks/pkg/buffers"
+)
+
+const (
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+	// CompressionNone stores layers uncompressed, so nodes pulling them from fast local registries don't have to
+	// decompress them, at the cost of storing the whole disk in the registry.
+	CompressionNone = "none"
+
+	// compressionBlockSize is the size of the blocks compressed in parallel. It is fixed, so the compressed
+	// layers and their digests don't depend on the number of threads.
+	compressionBlockSize = 1024 * 1024
+
+	// zstdConcurrency is the concurrency of the zstd encoder. It compresses a single stream, encoding a block
+	// while the previous one is written, so more threads don't speed it up. It is fixed, so the compressed layers
+	// are the same on every machine, like the gzip layers.
+	zstdConcurrency = 2
+)
+
+// Compression configures how layers are compressed.
+type Compression struct {
+	// Algorithm is CompressionGzip, CompressionZstd or CompressionNone. Defaults to gzip.
+	// zstd compressed and uncompressed layers are only supported in OCI images, so they switch the images to OCI
+	// media types.
+	Algorithm string
+	// Level is the gzip (1-9) or zstd (1-22) compression level. Defaults to the fastest gzip level
+	// and the default zstd level.
+	Level int
+	// Threads is the number of blocks compressed in parallel with gzip. Defaults to the number of CPUs.
+	Threads int
+}
+
+var (
+	defaultCompressionMu sync.RWMutex
+	defaultCompression   = Compression{Algorithm: CompressionGzip}
+)
+
+// SetDefaultCompression sets the compression of all layers built by this package.
+func SetDefaultCompression(compression Compression) error {
+	if err := compression.validate(); err != nil {
+		return err
+	}
+
+	defaultCompressionMu.Lock()
+	defer defaultCompressionMu.Unlock()
+	defaultCompression = compression
+	return nil
+}
+
+func getDefaultCompression() Compression {
+	defaultCompressionMu.RLock()
+	defer defaultCompressionMu.RUnlock()
+	return defaultCompression
+}
+
+func (c *Compression) validate() error {
+	const (
+		maxGzipLevel = 9
+		maxZstdLevel = 22
+	)
+
+	maxLevel := 0
+	switch c.Algorithm {
+	case "", CompressionGzip:
+		maxLevel = maxGzipLevel
+	case CompressionZstd:
+		maxLevel = maxZstdLevel
+	case CompressionNone:
+		if c.Level != 0 {
+			return fmt.Errorf("uncompressed layers have no compression level")
+		}
+	default:
+		return fmt.Errorf("unsupported compression %q, use %s, %s or %s", c.Algorithm, CompressionGzip, CompressionZstd, CompressionNone)
+	}
+	if c.Level < 0 || c.Level > maxLevel {
+		return fmt.Errorf("%s compression level must be between 1 and %d", c.algorithm(), maxLevel)
+	}
+	if c.Threads < 0 {
+		return fmt.Errorf("compression threads must not be negative")
+	}
+	return nil
+}
+
+func (c *Compression) algorithm() string {
+	if c.Algorithm == "" {
+		return CompressionGzip
+	}
+	return c.Algorithm
+}
+
+func (c *Compression) threads() int {
+	if c.Threads == 0 {
+		return runtime.NumCPU()
+	}
+	return c.Threads
+}
+
+// compressionManifestMediaType returns the manifest media type supporting the layers of the default compression.
+func compressionManifestMediaType() types.MediaType {
+	c := getDefaultCompression()
+	return c.manifestMediaType()
+}
+
+func compressionIndexMediaType() types.MediaType {
+	if compressionManifestMediaType() == types.OCIManifestSchema1 {
+		return types.OCIImageIndex
+	}
+	return types.DockerManifestList
+}
+
+func (c *Compression) manifestMediaType() types.MediaType {
+	if c.algorithm() == CompressionGzip {
+		return types.DockerManifestSchema2
+	}
+	return types.OCIManifestSchema1
+}
+
+func (c *Compression) layerMediaType() types.MediaType {
+	switch c.algorithm() {
+	case CompressionZstd:
+		return types.OCILayerZStd
+	case CompressionNone:
+		return types.OCIUncompressedLayer
+	default:
+		return types.DockerLayer
+	}
+}
+
+// compressedOpener wraps the opener, so it returns the compressed tarball.
+func (c *Compression) compressedOpener(opener tarball.Opener) tarball.Opener {
+	return func() (io.ReadCloser, error) {
+		uncompressed, err := opener()
+		if err != nil {
+			return nil, err
+		}
+
+		pipeReader, pipeWriter := io.Pipe()
+		go func() {
+			defer uncompressed.Close()
+			pipeWriter.CloseWithError(c.Compress(pipeWriter, uncompressed))
+		}()
+
+		return pipeReader, nil
+	}
+}
+
+// Compress writes the compressed content of r to w.
+func (c *Compression) Compress(w io.Writer, r io.Reader) error {
+	var compressor io.WriteCloser
+	switch c.algorithm() {
+	case CompressionNone:
+		if _, err := buffers.Copy(w, r); err != nil {
+			return fmt.Errorf("error copying layer: %v", err)
+		}
+		return nil
+	case CompressionZstd:
+		options := []zstd.EOption{zstd.WithEncoderConcurrency(zstdConcurrency)}
+		if c.Level != 0 {
+			options = append(options, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(c.Level)))
+		}
+		encoder, err := zstd.NewWriter(w, options...)
+		if err != nil {
+			return fmt.Errorf("error creating zstd compressor: %v", err)
+		}
+		compressor = encoder
+	default:
+		level := c.Level
+		if level == 0 {
+			level = pgzip.BestSpeed
+		}
+		writer, err := pgzip.NewWriterLevel(w, level)
+		if err != nil {
+			return fmt.Errorf("error creating gzip compressor: %v", err)
+		}
+		if err := writer.SetConcurrency(compressionBlockSize, c.threads()); err != nil {
+			return fmt.Errorf("error configuring gzip compressor: %v", err)
+		}
+		compressor = writer
+	}
+
+	if _, err := buffers.Copy(compressor, r); err != nil {
+		compressor.Close()
+		return fmt.Errorf("error compressing layer: %v", err)
+	}
+	if err := compressor.Close(); err != nil {
+		return fmt.Errorf("error compressing layer: %v", err)
+	}
+	return nil
+}
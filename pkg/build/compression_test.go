@@ -0,0 +1,87 @@
+package build
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compression", func() {
+	var disk string
+
+	BeforeEach(func() {
+		data := make([]byte, 3*compressionBlockSize+100)
+		rand.New(rand.NewSource(1)).Read(data[:compressionBlockSize]) //nolint:gosec // deterministic test data
+		disk = filepath.Join(GinkgoT().TempDir(), "disk")
+		Expect(os.WriteFile(disk, data, 0o600)).To(Succeed())
+		DeferCleanup(SetDefaultCompression, Compression{Algorithm: CompressionGzip})
+	})
+
+	build := func(compression Compression) (v1.Image, v1.Layer) {
+		Expect(SetDefaultCompression(compression)).To(Succeed())
+		img, err := ContainerDisk(disk, "amd64", ContainerDiskConfig("abcdef", nil))
+		Expect(err).ToNot(HaveOccurred())
+		layers, err := img.Layers()
+		Expect(err).ToNot(HaveOccurred())
+		return img, layers[0]
+	}
+
+	It("should compress gzip layers independently of the number of threads", func() {
+		img, layer := build(Compression{Threads: 1})
+		mediaType, err := img.MediaType()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mediaType).To(Equal(types.DockerManifestSchema2))
+
+		_, parallelLayer := build(Compression{Threads: 4})
+		digest, err := layer.Digest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parallelLayer.Digest()).To(Equal(digest))
+		Expect(layerContents(parallelLayer)).To(HaveKey(DiskDir + DiskFileName))
+	})
+
+	It("should compress zstd layers in OCI images", func() {
+		img, layer := build(Compression{Algorithm: CompressionZstd, Level: 19, Threads: 2})
+		mediaType, err := img.MediaType()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mediaType).To(Equal(types.OCIManifestSchema1))
+		Expect(layer.MediaType()).To(Equal(types.OCILayerZStd))
+		Expect(layerContents(layer)).To(HaveKey(DiskDir + DiskFileName))
+
+		idx, err := ContainerDiskIndex([]v1.Image{img})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(idx.MediaType()).To(Equal(types.OCIImageIndex))
+	})
+
+	It("should store uncompressed layers of single containerdisks in OCI images", func() {
+		gzipImg, _ := build(Compression{})
+		img, err := ContainerDiskWithCompression(Compression{Algorithm: CompressionNone}, disk, "arm64", ContainerDiskConfig("abcdef", nil))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(img.MediaType()).To(Equal(types.OCIManifestSchema1))
+		layers, err := img.Layers()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(layers[0].MediaType()).To(Equal(types.OCIUncompressedLayer))
+		digest, err := layers[0].Digest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(layers[0].DiffID()).To(Equal(digest))
+		Expect(layerContents(layers[0])).To(HaveKey(DiskDir + DiskFileName))
+
+		idx, err := ContainerDiskIndex([]v1.Image{gzipImg, img})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(idx.MediaType()).To(Equal(types.OCIImageIndex))
+	})
+
+	DescribeTable("should reject invalid settings", func(compression Compression, message string) {
+		Expect(SetDefaultCompression(compression)).To(MatchError(ContainSubstring(message)))
+	},
+		Entry("unknown algorithm", Compression{Algorithm: "xz"}, "unsupported compression"),
+		Entry("gzip level", Compression{Level: 10}, "between 1 and 9"),
+		Entry("zstd level", Compression{Algorithm: CompressionZstd, Level: 23}, "between 1 and 22"),
+		Entry("uncompressed level", Compression{Algorithm: CompressionNone, Level: 1}, "no compression level"),
+		Entry("threads", Compression{Threads: -1}, "must not be negative"),
+	)
+})
@@ -0,0 +1,49 @@
+package build
+
+import (
+	"fmt"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+)
+
+const (
+	// KernelBootDir is the directory inside the kernel boot container which contains kernel and initrd.
+	KernelBootDir = "boot/"
+	// KernelFileName is the file name of the kernel inside KernelBootDir.
+	KernelFileName = "vmlinuz"
+	// InitrdFileName is the file name of the initrd inside KernelBootDir.
+	InitrdFileName = "initrd.img"
+	// KernelBootTagSuffix is appended to the containerdisk tags to form the kernel boot container tags.
+	KernelBootTagSuffix = "-kernel"
+)
+
+// KernelPath returns the absolute path of the kernel inside the kernel boot container.
+func KernelPath() string {
+	return "/" + KernelBootDir + KernelFileName
+}
+
+// InitrdPath returns the absolute path of the initrd inside the kernel boot container.
+func InitrdPath() string {
+	return "/" + KernelBootDir + InitrdFileName
+}
+
+// KernelBootContainer creates a container image usable as KubeVirt kernel boot container.
+func KernelBootContainer(kernelPath, initrdPath, imgArch string, config v1.Config) (v1.Image, error) {
+	layer, err := newLayer(streamLayerOpener(KernelBootDir, []layerFile{
+		{path: kernelPath, name: KernelFileName},
+		{path: initrdPath, name: InitrdFileName},
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("error creating an image layer from kernel and initrd: %v", err)
+	}
+
+	img := mutate.MediaType(empty.Image, compressionManifestMediaType())
+	img, err = mutate.AppendLayers(img, layer)
+	if err != nil {
+		return nil, fmt.Errorf("error appending the image layer: %v", err)
+	}
+
+	return configureImage(img, imgArch, config)
+}
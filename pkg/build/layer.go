@@ -0,0 +1,126 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// layer is a tarball layer which is compressed on the fly. Unlike layers of tarball.LayerFromOpener,
+// which read and compress the tarball once for the digest and once more for the diff ID, the digest,
+// diff ID and size are computed in a single pass over the tarball.
+type layer struct {
+	uncompressed tarball.Opener
+	compression  Compression
+	mediaType    types.MediaType
+
+	digest v1.Hash
+	diffID v1.Hash
+	size   int64
+}
+
+// newLayer creates a layer from an opener of an uncompressed tarball, compressing it in parallel.
+func newLayer(opener tarball.Opener) (v1.Layer, error) {
+	return newLayerWithCompression(opener, getDefaultCompression())
+}
+
+func newLayerWithCompression(opener tarball.Opener, compression Compression) (v1.Layer, error) {
+	l := &layer{
+		uncompressed: opener,
+		compression:  compression,
+		mediaType:    compression.layerMediaType(),
+	}
+
+	if err := l.computeDigests(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *layer) computeDigests() error {
+	uncompressed, err := l.uncompressed()
+	if err != nil {
+		return err
+	}
+	defer uncompressed.Close()
+
+	diffIDHash := sha256.New()
+	digestHash := sha256.New()
+	counter := &countingWriter{}
+	err = l.compression.Compress(io.MultiWriter(digestHash, counter), io.TeeReader(uncompressed, diffIDHash))
+	if err != nil {
+		return fmt.Errorf("error computing the layer digests: %v", err)
+	}
+
+	l.diffID = sha256Hash(diffIDHash)
+	l.digest = sha256Hash(digestHash)
+	l.size = counter.n
+	return nil
+}
+
+func (l *layer) Digest() (v1.Hash, error) {
+	return l.digest, nil
+}
+
+func (l *layer) DiffID() (v1.Hash, error) {
+	return l.diffID, nil
+}
+
+// Compressed compresses the tarball again. The encoders produce the same stream for the same tarball, which is
+// verified against the digest of the first pass, so a layer never uploads content not matching its digest.
+func (l *layer) Compressed() (io.ReadCloser, error) {
+	compressed, err := l.compression.compressedOpener(l.uncompressed)()
+	if err != nil {
+		return nil, err
+	}
+	return &verifyingReader{ReadCloser: compressed, hash: sha256.New(), digest: l.digest}, nil
+}
+
+func (l *layer) Uncompressed() (io.ReadCloser, error) {
+	return l.uncompressed()
+}
+
+func (l *layer) Size() (int64, error) {
+	return l.size, nil
+}
+
+func (l *layer) MediaType() (types.MediaType, error) {
+	return l.mediaType, nil
+}
+
+func sha256Hash(h hash.Hash) v1.Hash {
+	return v1.Hash{Algorithm: "sha256", Hex: hex.EncodeToString(h.Sum(nil))}
+}
+
+// verifyingReader fails at the end of the compressed stream if it does not match the digest.
+type verifyingReader struct {
+	io.ReadCloser
+	hash   hash.Hash
+	digest v1.Hash
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF {
+		if digest := sha256Hash(r.hash); digest != r.digest {
+			return n, fmt.Errorf("compressed layer does not match its digest %s, got %s", r.digest, digest)
+		}
+	}
+	return n, err
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
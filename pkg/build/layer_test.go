@@ -0,0 +1,86 @@
+package build
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"math/rand"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Layer", func() {
+	It("should compute digest, diff ID and size in a single pass", func() {
+		var uncompressed bytes.Buffer
+		Expect(writeChunkLayer(&uncompressed, bytes.NewReader(make([]byte, 100000)), 100000, DiskDir+DiskFileName)).To(Succeed())
+
+		opened := 0
+		layer, err := newLayer(func() (io.ReadCloser, error) {
+			opened++
+			return io.NopCloser(bytes.NewReader(uncompressed.Bytes())), nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opened).To(Equal(1))
+
+		// go-containerregistry computes the digests of the compressed layer on its own
+		expected, err := tarball.LayerFromOpener(layer.Compressed)
+		Expect(err).ToNot(HaveOccurred())
+		digest, err := expected.Digest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(layer.Digest()).To(Equal(digest))
+		diffID, err := expected.DiffID()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(layer.DiffID()).To(Equal(diffID))
+		size, err := expected.Size()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(layer.Size()).To(Equal(size))
+	})
+
+	DescribeTable("should upload the compressed layer matching its digest",
+		func(compression Compression) {
+			// Several blocks of compressible and random data keep all threads busy
+			data := make([]byte, 8*compressionBlockSize)
+			random := rand.New(rand.NewSource(1)) //nolint:gosec // deterministic test data
+			for offset := 0; offset < len(data); offset += 3 * 4096 {
+				random.Read(data[offset : offset+4096])
+			}
+			var uncompressed bytes.Buffer
+			Expect(writeChunkLayer(&uncompressed, bytes.NewReader(data), int64(len(data)), DiskDir+DiskFileName)).To(Succeed())
+
+			layer, err := newLayerWithCompression(func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(uncompressed.Bytes())), nil
+			}, compression)
+			Expect(err).ToNot(HaveOccurred())
+
+			for range 3 {
+				compressed, err := layer.Compressed()
+				Expect(err).ToNot(HaveOccurred())
+				hash := sha256.New()
+				size, err := io.Copy(hash, compressed)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(compressed.Close()).To(Succeed())
+				Expect(layer.Digest()).To(Equal(sha256Hash(hash)))
+				Expect(layer.Size()).To(Equal(size))
+			}
+		},
+		Entry("gzip", Compression{Algorithm: CompressionGzip, Threads: 4}),
+		Entry("zstd", Compression{Algorithm: CompressionZstd, Threads: 4}),
+	)
+
+	It("should fail compressed layers not matching their digest", func() {
+		l, err := newLayer(func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(make([]byte, 1024))), nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		l.(*layer).digest.Hex = strings.Repeat("0", 64)
+
+		compressed, err := l.Compressed()
+		Expect(err).ToNot(HaveOccurred())
+		defer compressed.Close()
+		_, err = io.ReadAll(compressed)
+		Expect(err).To(MatchError(ContainSubstring("compressed layer does not match its digest")))
+	})
+})
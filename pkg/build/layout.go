@@ -0,0 +1,110 @@
+package build
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+)
+
+const (
+	// LabelDiskPath is the label containing the path of the primary disk if it is not stored at the default path.
+	// Volumes have to select it with the path of their containerDisk.
+	LabelDiskPath = "containerdisks.kubevirt.io/disk-path"
+
+	// qemuUID and qemuGID are the user and group virt-launcher reads the disks as.
+	qemuUID = 107
+	qemuGID = 107
+)
+
+// Layout describes where the primary disk is stored in containerdisk layers and who owns their entries.
+type Layout struct {
+	// DiskPath is the absolute path of the primary disk inside the containerdisk.
+	DiskPath string
+	// UID and GID own all entries of the layers.
+	UID int
+	GID int
+	// FileMode contains the permission bits of the files in the layers. Directories additionally get the execute
+	// bits of the classes which may read the files.
+	FileMode int64
+}
+
+// DefaultLayout is the layout KubeVirt expects: the disk is found in /disk without a path and readable by qemu.
+var DefaultLayout = Layout{
+	DiskPath: DiskPath(DiskFileName),
+	UID:      qemuUID,
+	GID:      qemuGID,
+	FileMode: fileMode,
+}
+
+var (
+	defaultLayoutMu sync.RWMutex
+	defaultLayout   = DefaultLayout
+)
+
+// SetDefaultLayout sets the layout of all containerdisks built by this package.
+func SetDefaultLayout(layout Layout) error {
+	if err := layout.validate(); err != nil {
+		return err
+	}
+
+	defaultLayoutMu.Lock()
+	defer defaultLayoutMu.Unlock()
+	defaultLayout = layout
+	return nil
+}
+
+func getDefaultLayout() Layout {
+	defaultLayoutMu.RLock()
+	defer defaultLayoutMu.RUnlock()
+	return defaultLayout
+}
+
+func (l *Layout) validate() error {
+	const permissionBits = 0o777
+
+	if !path.IsAbs(l.DiskPath) || path.Clean(l.DiskPath) != l.DiskPath || l.DiskPath == separator {
+		return fmt.Errorf("disk path %q must be a clean absolute file path", l.DiskPath)
+	}
+	if strings.HasPrefix(l.DiskPath, "/"+ChunkDir) || l.DiskPath == ChunkIndexPath() {
+		return fmt.Errorf("disk path %q is reserved for chunked containerdisks", l.DiskPath)
+	}
+	if l.UID < 0 || l.GID < 0 {
+		return fmt.Errorf("disk owner %d:%d must not be negative", l.UID, l.GID)
+	}
+	if l.FileMode&^permissionBits != 0 {
+		return fmt.Errorf("disk mode %#o must only contain permission bits", l.FileMode)
+	}
+	if !l.readableByQemu() {
+		return fmt.Errorf("disk owned by %d:%d with mode %#o is not readable by qemu (%d:%d)",
+			l.UID, l.GID, l.FileMode, qemuUID, qemuGID)
+	}
+	return nil
+}
+
+// readableByQemu returns whether virt-launcher, which runs as qemu, can read the disks.
+func (l *Layout) readableByQemu() bool {
+	const (
+		ownerRead = 0o400
+		groupRead = 0o040
+		otherRead = 0o004
+	)
+
+	switch {
+	case l.UID == qemuUID:
+		return l.FileMode&ownerRead != 0
+	case l.GID == qemuGID:
+		return l.FileMode&groupRead != 0
+	default:
+		return l.FileMode&otherRead != 0
+	}
+}
+
+func (l *Layout) dirMode() int64 {
+	return l.FileMode | (l.FileMode&0o444)>>2
+}
+
+// diskDir returns the directory of the primary disk without leading separator, and the file name of the disk.
+func (l *Layout) diskDir() (dir, fileName string) {
+	return path.Split(strings.TrimPrefix(l.DiskPath, separator))
+}
@@ -0,0 +1,64 @@
+package build
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Layout", func() {
+	BeforeEach(func() {
+		DeferCleanup(SetDefaultLayout, DefaultLayout)
+	})
+
+	It("should store the disk at the configured path with the configured owner", func() {
+		Expect(SetDefaultLayout(Layout{DiskPath: "/images/vm/root.qcow2", UID: 1000, GID: qemuGID, FileMode: 0o440})).To(Succeed())
+		disk := filepath.Join(GinkgoT().TempDir(), "disk")
+		Expect(os.WriteFile(disk, []byte("disk"), 0o600)).To(Succeed())
+
+		config := ContainerDiskConfig("abcdef", nil)
+		Expect(config.Labels).To(HaveKeyWithValue(LabelDiskPath, "/images/vm/root.qcow2"))
+		img, err := ContainerDisk(disk, "amd64", config)
+		Expect(err).ToNot(HaveOccurred())
+		layers, err := img.Layers()
+		Expect(err).ToNot(HaveOccurred())
+		reader, err := layers[0].Uncompressed()
+		Expect(err).ToNot(HaveOccurred())
+		defer reader.Close()
+
+		modes := map[string]int64{}
+		tarReader := tar.NewReader(reader)
+		for {
+			header, err := tarReader.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(header.Uid).To(Equal(1000))
+			Expect(header.Uname).To(BeEmpty())
+			Expect(header.Gname).To(Equal("qemu"))
+			modes[header.Name] = header.Mode
+		}
+		Expect(modes).To(Equal(map[string]int64{"images/": 0o550, "images/vm/": 0o550, "images/vm/root.qcow2": 0o440}))
+	})
+
+	It("should not label containerdisks with the default disk path", func() {
+		Expect(ContainerDiskConfig("abcdef", nil).Labels).ToNot(HaveKey(LabelDiskPath))
+	})
+
+	DescribeTable("should reject layouts KubeVirt can't use", func(layout Layout, message string) {
+		Expect(SetDefaultLayout(layout)).To(MatchError(ContainSubstring(message)))
+	},
+		Entry("relative path", Layout{DiskPath: "disk/disk.img", UID: qemuUID, GID: qemuGID, FileMode: 0o444}, "clean absolute"),
+		Entry("directory", Layout{DiskPath: "/disk/", UID: qemuUID, GID: qemuGID, FileMode: 0o444}, "clean absolute"),
+		Entry("chunk path", Layout{DiskPath: ChunkIndexPath(), UID: qemuUID, GID: qemuGID, FileMode: 0o444}, "reserved"),
+		Entry("special bits", Layout{DiskPath: "/disk/disk.img", UID: qemuUID, GID: qemuGID, FileMode: 0o4444}, "permission bits"),
+		Entry("unreadable by the owner", Layout{DiskPath: "/disk/disk.img", UID: qemuUID, GID: 0, FileMode: 0o044}, "not readable"),
+		Entry("unreadable by others", Layout{DiskPath: "/disk/disk.img", UID: 0, GID: 0, FileMode: 0o440}, "not readable"),
+	)
+})
@@ -0,0 +1,111 @@
+package build
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"kubevirt.io/containerdisks/pkg/buffers"
+)
+
+// DefaultArch is the architecture of containerdisks built from a reader if none is set.
+const DefaultArch = "amd64"
+
+// ReaderOptions configure building a containerdisk from a reader.
+type ReaderOptions struct {
+	// Arch is the OCI architecture of the image, e.g. amd64 or arm64. Defaults to DefaultArch.
+	Arch string
+	// EnvVariables are added to the image config, e.g. to describe an instancetype or preference.
+	EnvVariables map[string]string
+	// ExpectedChecksum is the optional sha256 checksum of the disk. Building fails on a mismatch.
+	ExpectedChecksum string
+	// TempDir is the directory the disk is buffered in. Defaults to the system temp directory.
+	TempDir string
+}
+
+// ReaderImage is a containerdisk built from a reader. The disk is buffered in a temporary
+// file, which is removed by Close. The image can be pushed until it is closed.
+type ReaderImage struct {
+	v1.Image
+	// Checksum is the sha256 checksum of the disk, it is also stored in the LabelShaSum label.
+	Checksum    string
+	VirtualSize int64
+	ActualSize  int64
+
+	path string
+}
+
+// FromReader builds a containerdisk from a disk image read from r, with the same layout,
+// labels and annotations medius uses for published containerdisks.
+func FromReader(r io.Reader, options ReaderOptions) (*ReaderImage, error) {
+	file, err := os.CreateTemp(options.TempDir, "containerdisk-*.img")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temporary file: %v", err)
+	}
+	img := &ReaderImage{path: file.Name()}
+
+	hasher := sha256.New()
+	_, err = buffers.Copy(io.MultiWriter(file, hasher), r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		img.Close()
+		return nil, fmt.Errorf("error buffering disk: %v", err)
+	}
+
+	img.Checksum = hex.EncodeToString(hasher.Sum(nil))
+	if options.ExpectedChecksum != "" && options.ExpectedChecksum != img.Checksum {
+		img.Close()
+		return nil, fmt.Errorf("checksum mismatch: expected %s, got %s", options.ExpectedChecksum, img.Checksum)
+	}
+
+	if img.Image, err = img.build(options); err != nil {
+		img.Close()
+		return nil, err
+	}
+
+	return img, nil
+}
+
+func (i *ReaderImage) build(options ReaderOptions) (v1.Image, error) {
+	var err error
+	i.VirtualSize, i.ActualSize, err = DiskSize(i.path)
+	if err != nil {
+		return nil, fmt.Errorf("error determining the disk size: %v", err)
+	}
+
+	arch := options.Arch
+	if arch == "" {
+		arch = DefaultArch
+	}
+
+	config := ContainerDiskConfig(i.Checksum, options.EnvVariables)
+	maps.Copy(config.Labels, DiskSizeLabels(i.VirtualSize, i.ActualSize))
+	img, err := ContainerDisk(i.path, arch, config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the containerdisk: %v", err)
+	}
+
+	return AnnotateDiskSize(img, i.VirtualSize, i.ActualSize), nil
+}
+
+// Push pushes the containerdisk to imgRef. Additional options, e.g. for authentication, are passed to crane.
+func (i *ReaderImage) Push(ctx context.Context, imgRef string, options ...crane.Option) error {
+	return crane.Push(i.Image, imgRef, append([]crane.Option{crane.WithContext(ctx)}, options...)...)
+}
+
+// Close removes the buffered disk.
+func (i *ReaderImage) Close() error {
+	if err := os.Remove(i.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
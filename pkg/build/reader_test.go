@@ -0,0 +1,45 @@
+package build
+
+import (
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FromReader", func() {
+	// sha256 of "disk"
+	const checksum = "1044dec7206e8d7c9fbb4ae8f766668406d2567fc7fc1a160a9d4700fcf8f8e9"
+
+	It("should build a labeled containerdisk and remove the buffered disk on close", func() {
+		dir := GinkgoT().TempDir()
+		img, err := FromReader(strings.NewReader("disk"), ReaderOptions{Arch: "arm64", ExpectedChecksum: checksum, TempDir: dir})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(img.ActualSize).To(Equal(int64(4)))
+
+		configFile, err := img.ConfigFile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(configFile.Architecture).To(Equal("arm64"))
+		Expect(configFile.Config.Labels).To(HaveKeyWithValue(LabelShaSum, checksum))
+		Expect(configFile.Config.Labels).To(HaveKeyWithValue(AnnotationVirtualSize, "4"))
+
+		manifest, err := img.Manifest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Annotations).To(HaveKeyWithValue(AnnotationActualSize, "4"))
+
+		Expect(img.Close()).To(Succeed())
+		entries, err := os.ReadDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("should fail on a checksum mismatch", func() {
+		dir := GinkgoT().TempDir()
+		_, err := FromReader(strings.NewReader("other"), ReaderOptions{ExpectedChecksum: checksum, TempDir: dir})
+		Expect(err).To(MatchError(ContainSubstring("checksum mismatch")))
+		entries, err := os.ReadDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+})
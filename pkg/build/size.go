@@ -0,0 +1,82 @@
+package build
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+)
+
+const (
+	// AnnotationVirtualSize is the annotation and label containing the virtual size of the disk in bytes.
+	AnnotationVirtualSize = "containerdisks.kubevirt.io/virtual-size"
+	// AnnotationActualSize is the annotation and label containing the actual size of the disk file in bytes.
+	AnnotationActualSize = "containerdisks.kubevirt.io/actual-size"
+)
+
+var qcow2Magic = []byte{'Q', 'F', 'I', 0xfb}
+
+// DiskSize returns the virtual and actual size of a disk image. The virtual size is read from
+// the qcow2 header, for all other formats it is equal to the actual size.
+func DiskSize(imgPath string) (virtualSize, actualSize int64, err error) {
+	file, err := os.Open(imgPath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error opening disk: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, 0, fmt.Errorf("error getting disk information with stat: %w", err)
+	}
+	actualSize = stat.Size()
+
+	// The qcow2 header starts with the magic, followed by the version, the backing file offset
+	// and size, the cluster bits and finally the virtual size at offset 24.
+	const qcow2HeaderSize = 32
+	header := make([]byte, qcow2HeaderSize)
+	if _, err := io.ReadFull(file, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return actualSize, actualSize, nil
+		}
+		return 0, 0, fmt.Errorf("error reading disk header: %w", err)
+	}
+
+	if !bytes.Equal(header[:len(qcow2Magic)], qcow2Magic) {
+		return actualSize, actualSize, nil
+	}
+
+	const virtualSizeOffset = 24
+	virtualSize = int64(binary.BigEndian.Uint64(header[virtualSizeOffset:])) //nolint:gosec // sizes above 8 EiB are not realistic
+	if virtualSize < 0 {
+		return 0, 0, fmt.Errorf("invalid qcow2 virtual size in %s", imgPath)
+	}
+
+	return virtualSize, actualSize, nil
+}
+
+// DiskSizeLabels returns the labels describing the disk size.
+func DiskSizeLabels(virtualSize, actualSize int64) map[string]string {
+	return map[string]string{
+		AnnotationVirtualSize: strconv.FormatInt(virtualSize, 10),
+		AnnotationActualSize:  strconv.FormatInt(actualSize, 10),
+	}
+}
+
+// AnnotateDiskSize adds the disk size annotations to the image manifest.
+func AnnotateDiskSize(img v1.Image, virtualSize, actualSize int64) v1.Image {
+	return Annotate(img, DiskSizeLabels(virtualSize, actualSize))
+}
+
+// Annotate adds annotations to the image manifest.
+func Annotate(img v1.Image, annotations map[string]string) v1.Image {
+	if len(annotations) == 0 {
+		return img
+	}
+	return mutate.Annotations(img, annotations).(v1.Image)
+}
@@ -5,12 +5,45 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"strings"
 	"time"
+
+	"kubevirt.io/containerdisks/pkg/buffers"
+)
+
+const (
+	// DiskDir is the directory inside the containerdisk which contains the disks.
+	DiskDir = "disk/"
+	// DiskFileName is the file name of the primary disk inside DiskDir.
+	DiskFileName = "disk.img"
 )
 
+// DiskPath returns the absolute path of a disk file inside the containerdisk.
+func DiskPath(fileName string) string {
+	return "/" + DiskDir + fileName
+}
+
+// StreamLayerOpener stores the disk at the disk path of the default layout, /disk/disk.img unless it is changed.
 func StreamLayerOpener(imagePath string) func() (io.ReadCloser, error) {
-	modTime := time.Now()
+	layout := getDefaultLayout()
+	dir, fileName := layout.diskDir()
+	return streamLayerOpener(dir, []layerFile{{path: imagePath, name: fileName}})
+}
+
+// StreamNamedLayerOpener is like StreamLayerOpener, but stores the disk as fileName in DiskDir.
+func StreamNamedLayerOpener(imagePath, fileName string) func() (io.ReadCloser, error) {
+	return streamLayerOpener(DiskDir, []layerFile{{path: imagePath, name: fileName}})
+}
 
+type layerFile struct {
+	// path of the file on the local filesystem
+	path string
+	// name of the file inside the layer directory
+	name string
+}
+
+func streamLayerOpener(dir string, files []layerFile) func() (io.ReadCloser, error) {
 	return func() (io.ReadCloser, error) {
 		fileErrorChan := make(chan error)
 		pipeReader, pipeWriter := io.Pipe()
@@ -18,35 +51,48 @@ func StreamLayerOpener(imagePath string) func() (io.ReadCloser, error) {
 		go func() {
 			defer pipeWriter.Close()
 
-			file, err := os.Open(imagePath)
-			if err != nil {
-				fileErrorChan <- fmt.Errorf("error opening file: %w", err)
-				return
-			}
-			defer file.Close()
-
-			stat, err := file.Stat()
-			if err != nil {
-				fileErrorChan <- fmt.Errorf("error getting file information with stat: %w", err)
-				return
+			opened := make([]*os.File, 0, len(files))
+			stats := make([]os.FileInfo, 0, len(files))
+			defer func() {
+				for _, file := range opened {
+					file.Close()
+				}
+			}()
+
+			for _, f := range files {
+				file, err := os.Open(f.path)
+				if err != nil {
+					fileErrorChan <- fmt.Errorf("error opening file: %w", err)
+					return
+				}
+				opened = append(opened, file)
+
+				stat, err := file.Stat()
+				if err != nil {
+					fileErrorChan <- fmt.Errorf("error getting file information with stat: %w", err)
+					return
+				}
+				stats = append(stats, stat)
 			}
 
-			// Close channel after successfully opening file to avoid deadlock
+			// Close channel after successfully opening files to avoid deadlock
 			close(fileErrorChan)
 
-			tarWriter := tar.NewWriter(pipeWriter)
-			err = addFileToTarWriter(file, stat, modTime, tarWriter)
-			if err != nil {
-				// Move the error to the PipeReader side. It is ok to call close on PipeWriter multiple times.
-				pipeWriter.CloseWithError(fmt.Errorf("error adding file '%s', to tarball: %w", imagePath, err))
+			layerWriter := newLayerWriter(pipeWriter)
+			for i, f := range files {
+				err := layerWriter.addFile(dir+f.name, opened[i], stats[i].Size())
+				if err != nil {
+					// Move the error to the PipeReader side. It is ok to call close on PipeWriter multiple times.
+					pipeWriter.CloseWithError(fmt.Errorf("error adding file '%s', to tarball: %w", f.path, err))
+					return
+				}
 			}
-			err = tarWriter.Close()
-			if err != nil {
-				pipeWriter.CloseWithError(fmt.Errorf("error writing footer of tarball: %w", err))
+			if err := layerWriter.close(); err != nil {
+				pipeWriter.CloseWithError(err)
 			}
 		}()
 
-		// Wait until file is opened or immediately return any errors
+		// Wait until files are opened or immediately return any errors
 		if err, ok := <-fileErrorChan; ok {
 			return nil, err
 		}
@@ -55,44 +101,86 @@ func StreamLayerOpener(imagePath string) func() (io.ReadCloser, error) {
 	}
 }
 
-func addFileToTarWriter(file io.Reader, stat os.FileInfo, modTime time.Time, tarWriter *tar.Writer) error {
-	header := &tar.Header{
-		Typeflag: tar.TypeDir,
-		Name:     "disk/",
-		Mode:     0o555,
-		Uid:      107,
-		Gid:      107,
-		Uname:    "qemu",
-		Gname:    "qemu",
-		ModTime:  modTime,
-	}
+const (
+	qemuName  = "qemu"
+	fileMode  = 0o444
+	separator = "/"
+)
 
-	err := tarWriter.WriteHeader(header)
-	if err != nil {
-		return fmt.Errorf("error writing disks directory tar header: %w", err)
-	}
+// layerModTime is the modification time of all entries of containerdisk layers. Neither the time of the build
+// nor the one of the download ends up in a layer, so building the same disks again results in the same digest.
+var layerModTime = time.Unix(0, 0)
+
+// layerWriter writes the tarball of a containerdisk layer. Like the tar code of the container tools on Windows,
+// it must not rely on unix permissions and ownership of the local files, which don't exist there, so the mode
+// and owner of every entry are taken from the default layout and layers built on any platform are usable by qemu.
+type layerWriter struct {
+	tarWriter *tar.Writer
+	dirs      map[string]bool
+	layout    Layout
+}
 
-	header = &tar.Header{
-		Typeflag: tar.TypeReg,
-		Uid:      107,
-		Gid:      107,
-		Uname:    "qemu",
-		Gname:    "qemu",
-		Name:     "disk/disk.img",
-		Size:     stat.Size(),
-		Mode:     0o444,
-		ModTime:  stat.ModTime(),
+func newLayerWriter(w io.Writer) *layerWriter {
+	return &layerWriter{tarWriter: tar.NewWriter(w), dirs: map[string]bool{}, layout: getDefaultLayout()}
+}
+
+// addFile adds the content of reader as file name, preceded by the entries of its parent directories
+// which were not added yet.
+func (l *layerWriter) addFile(name string, reader io.Reader, size int64) error {
+	name = strings.TrimPrefix(path.Clean(name), separator)
+	if err := l.addParents(name); err != nil {
+		return err
 	}
 
-	err = tarWriter.WriteHeader(header)
-	if err != nil {
+	if err := l.tarWriter.WriteHeader(l.header(tar.TypeReg, name, l.layout.FileMode, size)); err != nil {
 		return fmt.Errorf("error writing image file tar header: %w", err)
 	}
+	if _, err := buffers.Copy(l.tarWriter, reader); err != nil {
+		return fmt.Errorf("error writing file into tarball: %w", err)
+	}
+	return nil
+}
+
+func (l *layerWriter) addParents(name string) error {
+	dir := path.Dir(name)
+	if dir == "." || l.dirs[dir] {
+		return nil
+	}
+	if err := l.addParents(dir); err != nil {
+		return err
+	}
 
-	_, err = io.Copy(tarWriter, file)
-	if err != nil {
-		return fmt.Errorf("error writingfile into tarball: %w", err)
+	l.dirs[dir] = true
+	if err := l.tarWriter.WriteHeader(l.header(tar.TypeDir, dir+separator, l.layout.dirMode(), 0)); err != nil {
+		return fmt.Errorf("error writing directory tar header: %w", err)
 	}
+	return nil
+}
 
+// close writes the footer of the tarball, it does not close the underlying writer.
+func (l *layerWriter) close() error {
+	if err := l.tarWriter.Close(); err != nil {
+		return fmt.Errorf("error writing footer of tarball: %w", err)
+	}
 	return nil
 }
+
+func (l *layerWriter) header(typeflag byte, name string, mode, size int64) *tar.Header {
+	h := &tar.Header{
+		Typeflag: typeflag,
+		Name:     name,
+		Size:     size,
+		Mode:     mode,
+		Uid:      l.layout.UID,
+		Gid:      l.layout.GID,
+		ModTime:  layerModTime,
+	}
+	// Only qemu is known to exist in virt-launcher, other owners are identified by their IDs
+	if h.Uid == qemuUID {
+		h.Uname = qemuName
+	}
+	if h.Gid == qemuGID {
+		h.Gname = qemuName
+	}
+	return h
+}
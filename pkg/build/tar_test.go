@@ -2,10 +2,14 @@ package build
 
 import (
 	"archive/tar"
+	"bytes"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -45,6 +49,51 @@ var _ = Describe("Tar", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(string(data)).To(Equal(imageContent))
 	})
+
+	It("StreamLayer should be reproducible", func() {
+		imageName := filepath.Join(GinkgoT().TempDir(), "image")
+		Expect(os.WriteFile(imageName, []byte("hello"), 0o600)).To(Succeed())
+
+		read := func() []byte {
+			reader, err := StreamLayerOpener(imageName)()
+			Expect(err).ToNot(HaveOccurred())
+			data, err := io.ReadAll(reader)
+			Expect(err).ToNot(HaveOccurred())
+			return data
+		}
+		first := read()
+		Expect(os.Chtimes(imageName, time.Now(), time.Now().Add(time.Hour))).To(Succeed())
+		Expect(read()).To(Equal(first))
+	})
+
+	It("layerWriter should add parent directories once with fixed headers", func() {
+		var buf bytes.Buffer
+		layerWriter := newLayerWriter(&buf)
+		Expect(layerWriter.addFile("/disk/chunks/a", strings.NewReader("a"), 1)).To(Succeed())
+		Expect(layerWriter.addFile("disk/chunks/b", strings.NewReader("b"), 1)).To(Succeed())
+		Expect(layerWriter.close()).To(Succeed())
+
+		var names []string
+		tarReader := tar.NewReader(&buf)
+		for {
+			header, err := tarReader.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			Expect(err).ToNot(HaveOccurred())
+			names = append(names, header.Name)
+			Expect(header.Uid).To(Equal(107))
+			Expect(header.Gid).To(Equal(107))
+			Expect(header.Uname).To(Equal("qemu"))
+			Expect(header.ModTime.Unix()).To(BeZero())
+			if header.Typeflag == tar.TypeDir {
+				Expect(header.Mode).To(Equal(int64(0o555)))
+			} else {
+				Expect(header.Mode).To(Equal(int64(0o444)))
+			}
+		}
+		Expect(names).To(Equal([]string{"disk/", "disk/chunks/", "disk/chunks/a", "disk/chunks/b"}))
+	})
 })
 
 func TestTar(t *testing.T) {
@@ -0,0 +1,118 @@
+// Package buildinfo identifies the build of medius, so published containerdisks and run reports can be traced
+// back to the pipeline build which produced them.
+package buildinfo
+
+import (
+	"cmp"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+const (
+	// AnnotationVersion is the annotation and label containing the version of medius which built the image.
+	AnnotationVersion = "containerdisks.kubevirt.io/medius-version"
+	// AnnotationCommit is the annotation and label containing the commit medius was built from.
+	AnnotationCommit = "containerdisks.kubevirt.io/medius-commit"
+)
+
+// develVersion is the version of builds which were not stamped and are no tagged module version either.
+const develVersion = "devel"
+
+// version is stamped by the Makefile with -ldflags "-X kubevirt.io/containerdisks/pkg/buildinfo.version=...".
+var version = ""
+
+// Info describes a build of medius.
+type Info struct {
+	// Version is the stamped version, e.g. the output of git describe, or "devel".
+	Version string `json:"version"`
+	// Commit is the git commit medius was built from, if the build embedded it.
+	Commit string `json:"commit,omitempty"`
+	// CommitTime is the time of Commit in RFC 3339 format.
+	CommitTime string `json:"commitTime,omitempty"`
+	// Modified is set if the working tree had uncommitted changes.
+	Modified bool `json:"modified,omitempty"`
+	// GoVersion is the version of the Go toolchain which built medius.
+	GoVersion string `json:"goVersion"`
+	// Platform is the operating system and architecture medius was built for, e.g. linux/amd64.
+	Platform string `json:"platform"`
+	// Settings are the build settings which change the behavior of medius, e.g. CGO_ENABLED and -tags.
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// Get returns the build info of the running binary.
+func Get() Info {
+	bi, _ := debug.ReadBuildInfo()
+	return fromBuildInfo(bi, version)
+}
+
+func fromBuildInfo(bi *debug.BuildInfo, stamped string) Info {
+	info := Info{
+		Version:   stamped,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if bi == nil {
+		info.Version = cmp.Or(info.Version, develVersion)
+		return info
+	}
+
+	// Binaries installed with go install carry the module version
+	if bi.Main.Version != "(devel)" {
+		info.Version = cmp.Or(info.Version, bi.Main.Version)
+	}
+	info.Version = cmp.Or(info.Version, develVersion)
+	info.GoVersion = cmp.Or(bi.GoVersion, info.GoVersion)
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.time":
+			info.CommitTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		case "CGO_ENABLED", "-tags", "-race":
+			if info.Settings == nil {
+				info.Settings = map[string]string{}
+			}
+			info.Settings[setting.Key] = setting.Value
+		}
+	}
+	return info
+}
+
+// String returns the version with the abbreviated commit, e.g. v0.1.0 (commit 0123456789ab, modified).
+func (i Info) String() string {
+	var details []string
+	if i.Commit != "" {
+		details = append(details, "commit "+shortCommit(i.Commit))
+	}
+	if i.Modified {
+		details = append(details, "modified")
+	}
+	if len(details) == 0 {
+		return i.Version
+	}
+	return fmt.Sprintf("%s (%s)", i.Version, strings.Join(details, ", "))
+}
+
+// Annotations returns the annotations identifying the build of medius on a published image.
+func (i Info) Annotations() map[string]string {
+	annotations := map[string]string{AnnotationVersion: i.Version}
+	if i.Commit != "" {
+		annotations[AnnotationCommit] = i.Commit
+		if i.Modified {
+			annotations[AnnotationCommit] += "-modified"
+		}
+	}
+	return annotations
+}
+
+func shortCommit(commit string) string {
+	const length = 12
+	if len(commit) > length {
+		return commit[:length]
+	}
+	return commit
+}
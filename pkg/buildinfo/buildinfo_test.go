@@ -0,0 +1,62 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Buildinfo", func() {
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+
+	buildInfo := func(moduleVersion string, settings ...debug.BuildSetting) *debug.BuildInfo {
+		return &debug.BuildInfo{GoVersion: "go1.24.0", Main: debug.Module{Version: moduleVersion}, Settings: settings}
+	}
+
+	It("should prefer the stamped version", func() {
+		info := fromBuildInfo(buildInfo("v0.1.0"), "v0.2.0-3-g0123456")
+		Expect(info.Version).To(Equal("v0.2.0-3-g0123456"))
+		Expect(info.GoVersion).To(Equal("go1.24.0"))
+	})
+
+	DescribeTable("should fall back to the module version",
+		func(bi *debug.BuildInfo, expected string) {
+			Expect(fromBuildInfo(bi, "").Version).To(Equal(expected))
+		},
+		Entry("installed with go install", buildInfo("v0.1.0"), "v0.1.0"),
+		Entry("built from a checkout", buildInfo("(devel)"), develVersion),
+		Entry("without build info", nil, develVersion),
+	)
+
+	It("should read the commit and the build settings", func() {
+		info := fromBuildInfo(buildInfo("(devel)",
+			debug.BuildSetting{Key: "vcs.revision", Value: commit},
+			debug.BuildSetting{Key: "vcs.time", Value: "2024-07-01T12:00:00Z"},
+			debug.BuildSetting{Key: "vcs.modified", Value: "true"},
+			debug.BuildSetting{Key: "CGO_ENABLED", Value: "0"},
+			debug.BuildSetting{Key: "GOAMD64", Value: "v1"},
+		), "v0.2.0")
+		Expect(info.Commit).To(Equal(commit))
+		Expect(info.CommitTime).To(Equal("2024-07-01T12:00:00Z"))
+		Expect(info.Modified).To(BeTrue())
+		Expect(info.Settings).To(Equal(map[string]string{"CGO_ENABLED": "0"}))
+		Expect(info.String()).To(Equal("v0.2.0 (commit 0123456789ab, modified)"))
+		Expect(info.Annotations()).To(Equal(map[string]string{
+			AnnotationVersion: "v0.2.0",
+			AnnotationCommit:  commit + "-modified",
+		}))
+	})
+
+	It("should only annotate the version of builds without commit", func() {
+		info := fromBuildInfo(buildInfo("(devel)"), "")
+		Expect(info.String()).To(Equal(develVersion))
+		Expect(info.Annotations()).To(Equal(map[string]string{AnnotationVersion: develVersion}))
+	})
+})
+
+func TestBuildinfo(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Buildinfo Suite")
+}
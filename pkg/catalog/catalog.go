@@ -0,0 +1,141 @@
+// Package catalog defines the versioned JSON document of the containerdisks medius publishes. External tools
+// consume it from "medius list", so unlike the api types it only changes compatibly within a version.
+package catalog
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/common"
+	"kubevirt.io/containerdisks/pkg/osinfo"
+)
+
+const (
+	// APIVersion identifies the schema of the catalog. Fields may be added within a version, renaming or
+	// removing fields and adding required fields requires a new version.
+	APIVersion = "containerdisks.kubevirt.io/catalog/v1"
+	Kind       = "Catalog"
+)
+
+// Catalog lists containerdisks.
+type Catalog struct {
+	APIVersion     string          `json:"apiVersion"`
+	Kind           string          `json:"kind"`
+	Containerdisks []Containerdisk `json:"containerdisks"`
+}
+
+// Containerdisk is a version of a containerdisk, published as manifest list of all its architectures.
+type Containerdisk struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Tag is the moving version tag of the containerdisk, including its variant.
+	Tag string `json:"tag"`
+	// Image is the reference of the moving version tag of the containerdisk.
+	Image               string     `json:"image"`
+	Variant             string     `json:"variant,omitempty"`
+	Stable              bool       `json:"stable"`
+	EOL                 string     `json:"eol,omitempty"`
+	ReleaseNotes        string     `json:"releaseNotes,omitempty"`
+	License             string     `json:"license,omitempty"`
+	OSInfo              string     `json:"osInfo,omitempty"`
+	Maintainers         []string   `json:"maintainers,omitempty"`
+	DefaultInstancetype string     `json:"defaultInstancetype,omitempty"`
+	DefaultPreference   string     `json:"defaultPreference,omitempty"`
+	Artifacts           []Artifact `json:"artifacts"`
+}
+
+// Artifact is the image of a single architecture of a containerdisk.
+type Artifact struct {
+	Arch string `json:"arch"`
+	// Source describes the upstream image, it is only set if upstream was inspected.
+	Source *Source `json:"source,omitempty"`
+}
+
+// Source is the upstream image an artifact is built from.
+type Source struct {
+	// URL is empty for disks which are generated by medius.
+	URL               string   `json:"url,omitempty"`
+	Checksum          string   `json:"checksum"`
+	ChecksumAlgorithm string   `json:"checksumAlgorithm"`
+	Compression       string   `json:"compression,omitempty"`
+	UniqueTags        []string `json:"uniqueTags,omitempty"`
+}
+
+func New(containerdisks []Containerdisk) *Catalog {
+	if containerdisks == nil {
+		containerdisks = []Containerdisk{}
+	}
+	return &Catalog{APIVersion: APIVersion, Kind: Kind, Containerdisks: containerdisks}
+}
+
+// NewContainerdisk returns the containerdisk published as image, the metadata of the artifacts of all
+// architectures is merged.
+func NewContainerdisk(image string, metadata []*api.Metadata) Containerdisk {
+	first := metadata[0]
+	containerdisk := Containerdisk{
+		Name:                first.Name,
+		Version:             first.Version,
+		Tag:                 first.Tag(),
+		Image:               image,
+		Variant:             string(first.Variant),
+		Stable:              first.IsStable,
+		EOL:                 first.EOL,
+		ReleaseNotes:        first.ReleaseNotes,
+		License:             first.License,
+		OSInfo:              osinfo.ID(first),
+		Maintainers:         first.Maintainers,
+		DefaultInstancetype: first.EnvVariables[common.DefaultInstancetypeEnv],
+		DefaultPreference:   first.EnvVariables[common.DefaultPreferenceEnv],
+		Artifacts:           []Artifact{},
+	}
+	for _, m := range metadata {
+		containerdisk.Artifacts = append(containerdisk.Artifacts, Artifact{Arch: m.Arch})
+	}
+	return containerdisk
+}
+
+// NewSource returns the source of inspected artifact details.
+func NewSource(details *api.ArtifactDetails) (*Source, error) {
+	algorithm, err := checksumAlgorithm(details)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{
+		URL:               details.DownloadURL,
+		Checksum:          details.Checksum,
+		ChecksumAlgorithm: algorithm,
+		Compression:       details.Compression,
+		UniqueTags:        details.AdditionalUniqueTags,
+	}, nil
+}
+
+// checksumAlgorithm names the digest function of the checksum by the size of its digests.
+func checksumAlgorithm(details *api.ArtifactDetails) (string, error) {
+	if details.ChecksumHash == nil {
+		return "", fmt.Errorf("no checksum algorithm of %s", details.DownloadURL)
+	}
+	switch details.ChecksumHash().Size() {
+	case sha256.Size:
+		return "sha256", nil
+	case sha512.Size:
+		return "sha512", nil
+	default:
+		return "", fmt.Errorf("unknown checksum algorithm of %s", details.DownloadURL)
+	}
+}
+
+// Marshal encodes the catalog and validates it against the schema, so medius never emits a catalog which
+// breaks the promises of its version.
+func Marshal(c *Catalog) ([]byte, error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := Validate(data); err != nil {
+		return nil, fmt.Errorf("catalog does not match its schema: %v", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,136 @@
+package catalog
+
+import (
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/common"
+)
+
+var _ = Describe("Catalog", func() {
+	metadata := func(arch string) *api.Metadata {
+		return &api.Metadata{
+			Name:         "fedora",
+			Version:      "43",
+			Arch:         arch,
+			IsStable:     true,
+			EOL:          "2026-12-02",
+			ReleaseNotes: "https://example.com/fedora/43",
+			License:      "LicenseRef-Fedora",
+			Maintainers:  []string{"octocat"},
+			Variant:      api.VariantMinimal,
+			EnvVariables: map[string]string{
+				common.DefaultInstancetypeEnv: "u1.medium",
+				common.DefaultPreferenceEnv:   "fedora",
+			},
+		}
+	}
+
+	It("should marshal containerdisks matching the schema", func() {
+		containerdisk := NewContainerdisk("quay.io/containerdisks/fedora:43-minimal",
+			[]*api.Metadata{metadata("x86_64"), metadata("aarch64")})
+		source, err := NewSource(&api.ArtifactDetails{
+			Checksum:             "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			ChecksumHash:         sha256.New,
+			DownloadURL:          "https://example.com/fedora-43.qcow2",
+			Compression:          "Xz",
+			AdditionalUniqueTags: []string{"43-1.6"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		containerdisk.Artifacts[0].Source = source
+
+		data, err := Marshal(New([]Containerdisk{containerdisk}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(MatchJSON(`{
+			"apiVersion": "containerdisks.kubevirt.io/catalog/v1",
+			"kind": "Catalog",
+			"containerdisks": [{
+				"name": "fedora",
+				"version": "43",
+				"tag": "43-minimal",
+				"image": "quay.io/containerdisks/fedora:43-minimal",
+				"variant": "minimal",
+				"stable": true,
+				"eol": "2026-12-02",
+				"releaseNotes": "https://example.com/fedora/43",
+				"license": "LicenseRef-Fedora",
+				"osInfo": "fedora43",
+				"maintainers": ["octocat"],
+				"defaultInstancetype": "u1.medium",
+				"defaultPreference": "fedora",
+				"artifacts": [
+					{
+						"arch": "x86_64",
+						"source": {
+							"url": "https://example.com/fedora-43.qcow2",
+							"checksum": "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+							"checksumAlgorithm": "sha256",
+							"compression": "Xz",
+							"uniqueTags": ["43-1.6"]
+						}
+					},
+					{"arch": "aarch64"}
+				]
+			}]
+		}`))
+	})
+
+	It("should marshal empty catalogs", func() {
+		data, err := Marshal(New(nil))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(MatchJSON(`{"apiVersion": "containerdisks.kubevirt.io/catalog/v1", "kind": "Catalog", "containerdisks": []}`))
+	})
+
+	It("should not marshal catalogs violating the schema", func() {
+		containerdisk := NewContainerdisk("quay.io/containerdisks/fedora:43", []*api.Metadata{{Name: "fedora", EOL: "soon"}})
+		_, err := Marshal(New([]Containerdisk{containerdisk}))
+		Expect(err).To(MatchError(`catalog does not match its schema: $.containerdisks[0].eol: "soon" does not match ^\d{4}-\d{2}-\d{2}$`))
+	})
+
+	DescribeTable("NewSource should name the checksum algorithm",
+		func(details *api.ArtifactDetails, expected, expectedErr string) {
+			source, err := NewSource(details)
+			if expectedErr != "" {
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+				return
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(source.ChecksumAlgorithm).To(Equal(expected))
+		},
+		Entry("sha256", &api.ArtifactDetails{ChecksumHash: sha256.New}, "sha256", ""),
+		Entry("sha512", &api.ArtifactDetails{ChecksumHash: sha512.New}, "sha512", ""),
+		Entry("unknown", &api.ArtifactDetails{ChecksumHash: md5.New}, "", "unknown checksum algorithm"),
+		Entry("missing", &api.ArtifactDetails{}, "", "no checksum algorithm"),
+	)
+
+	DescribeTable("Validate should reject documents violating the schema",
+		func(document, expectedErr string) {
+			Expect(Validate([]byte(document))).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("other version", `{"apiVersion": "containerdisks.kubevirt.io/catalog/v2", "kind": "Catalog", "containerdisks": []}`,
+			"$.apiVersion: must be containerdisks.kubevirt.io/catalog/v1"),
+		Entry("missing property", `{"apiVersion": "containerdisks.kubevirt.io/catalog/v1", "kind": "Catalog"}`,
+			"$: containerdisks is required"),
+		Entry("unknown property", `{"apiVersion": "containerdisks.kubevirt.io/catalog/v1", "kind": "Catalog", "containerdisks": [],
+			"items": []}`, "$: unknown property items"),
+		Entry("wrong type", `{"apiVersion": "containerdisks.kubevirt.io/catalog/v1", "kind": "Catalog", "containerdisks": [
+			{"name": "fedora", "version": "43", "tag": "43", "image": "fedora:43", "stable": "yes", "artifacts": []}]}`,
+			"$.containerdisks[0].stable: must be of type boolean"),
+		Entry("unknown algorithm", `{"apiVersion": "containerdisks.kubevirt.io/catalog/v1", "kind": "Catalog", "containerdisks": [
+			{"name": "fedora", "version": "43", "tag": "43", "image": "fedora:43", "stable": true, "artifacts": [
+				{"arch": "x86_64", "source": {"checksum": "abc", "checksumAlgorithm": "md5"}}]}]}`,
+			"$.containerdisks[0].artifacts[0].source.checksumAlgorithm: must be one of [sha256 sha512]"),
+		Entry("invalid JSON", `{`, "error parsing the catalog"),
+	)
+})
+
+func TestCatalog(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Catalog Suite")
+}
@@ -0,0 +1,129 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Schema is the JSON schema of the catalog of APIVersion. It is published with every release of medius.
+//
+//go:embed catalog.v1.schema.json
+var Schema []byte
+
+// schema is the subset of JSON schema keywords the catalog schema uses.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Defs                 map[string]*schema `json:"$defs"`
+	Type                 string             `json:"type"`
+	Const                any                `json:"const"`
+	Enum                 []any              `json:"enum"`
+	Pattern              string             `json:"pattern"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+}
+
+// Validate validates a JSON document against the schema of the catalog.
+func Validate(data []byte) error {
+	root := &schema{}
+	if err := json.Unmarshal(Schema, root); err != nil {
+		return fmt.Errorf("error parsing the catalog schema: %v", err)
+	}
+
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("error parsing the catalog: %v", err)
+	}
+
+	return root.validate(root, "$", document)
+}
+
+func (s *schema) validate(root *schema, path string, value any) error {
+	if s.Ref != "" {
+		def, ok := root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !ok {
+			return fmt.Errorf("%s: unknown reference %s", path, s.Ref)
+		}
+		return def.validate(root, path, value)
+	}
+
+	if s.Const != nil && value != s.Const {
+		return fmt.Errorf("%s: must be %v", path, s.Const)
+	}
+	if s.Enum != nil && !slices.Contains(s.Enum, value) {
+		return fmt.Errorf("%s: must be one of %v", path, s.Enum)
+	}
+	if err := s.validateType(path, value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case string:
+		if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(v) {
+			return fmt.Errorf("%s: %q does not match %s", path, v, s.Pattern)
+		}
+	case []any:
+		if s.Items == nil {
+			return nil
+		}
+		for i, item := range v {
+			if err := s.Items.validate(root, fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		return s.validateObject(root, path, v)
+	}
+	return nil
+}
+
+func (s *schema) validateType(path string, value any) error {
+	var ok bool
+	switch s.Type {
+	case "":
+		return nil
+	case "object":
+		_, ok = value.(map[string]any)
+	case "array":
+		_, ok = value.([]any)
+	case "string":
+		_, ok = value.(string)
+	case "boolean":
+		_, ok = value.(bool)
+	default:
+		return fmt.Errorf("%s: unsupported type %s in the schema", path, s.Type)
+	}
+	if !ok {
+		return fmt.Errorf("%s: must be of type %s", path, s.Type)
+	}
+	return nil
+}
+
+func (s *schema) validateObject(root *schema, path string, object map[string]any) error {
+	var errs []error
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			errs = append(errs, fmt.Errorf("%s: %s is required", path, name))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(object)) {
+		property, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				errs = append(errs, fmt.Errorf("%s: unknown property %s", path, name))
+			}
+			continue
+		}
+		if err := property.validate(root, path+"."+name, object[name]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
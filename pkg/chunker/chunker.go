@@ -0,0 +1,130 @@
+package chunker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"kubevirt.io/containerdisks/pkg/buffers"
+)
+
+const (
+	// DefaultAvgSize is the average chunk size of small files.
+	DefaultAvgSize = 32 * 1024 * 1024
+	// minSizeDivisor and maxSizeFactor bound the chunk size relative to the average size.
+	minSizeDivisor = 4
+	maxSizeFactor  = 4
+)
+
+// Chunk is a content-defined part of a file.
+type Chunk struct {
+	Offset int64
+	Length int64
+	// Digest is the hex encoded sha256 of the chunk.
+	Digest string
+}
+
+// Options bound the size of chunks.
+type Options struct {
+	MinSize int64
+	// AvgSize must be a power of two. Chunks are on average about MinSize+AvgSize long.
+	AvgSize int64
+	MaxSize int64
+}
+
+// OptionsForSize returns options which split a file of the given size into at most maxChunks chunks.
+// The average size is doubled until the limit holds, so files of similar size use the same options and
+// successive versions of a file share most of their chunks.
+func OptionsForSize(size int64, maxChunks int) Options {
+	avgSize := int64(DefaultAvgSize)
+	for minSizeDivisor*size > avgSize*int64(maxChunks-1) {
+		avgSize *= 2
+	}
+	return Options{
+		MinSize: avgSize / minSizeDivisor,
+		AvgSize: avgSize,
+		MaxSize: avgSize * maxSizeFactor,
+	}
+}
+
+// Split cuts the content of r into chunks with boundaries at content-defined positions, so that inserting
+// or removing data only changes the chunks around the modification.
+func Split(r io.Reader, options Options) ([]Chunk, error) {
+	if options.AvgSize <= 0 || options.AvgSize&(options.AvgSize-1) != 0 {
+		return nil, fmt.Errorf("average chunk size %d is not a power of two", options.AvgSize)
+	}
+	if options.MinSize < 0 || options.MaxSize < options.MinSize {
+		return nil, fmt.Errorf("invalid chunk size bounds %d-%d", options.MinSize, options.MaxSize)
+	}
+	mask := uint64(options.AvgSize - 1)
+
+	var (
+		chunks []Chunk
+		offset int64
+		length int64
+		gear   uint64
+	)
+	checksum := sha256.New()
+	cut := func() {
+		chunks = append(chunks, Chunk{Offset: offset, Length: length, Digest: digest(checksum)})
+		offset += length
+		length = 0
+		gear = 0
+		checksum.Reset()
+	}
+
+	pooled := buffers.Get()
+	defer buffers.Put(pooled)
+	buf := *pooled
+	for {
+		n, err := r.Read(buf)
+		data := buf[:n]
+		for len(data) > 0 {
+			i, boundary := 0, false
+			for i < len(data) && !boundary {
+				gear = gear<<1 + gearTable[data[i]]
+				length++
+				i++
+				boundary = length >= options.MaxSize || (length >= options.MinSize && gear&mask == 0)
+			}
+			checksum.Write(data[:i])
+			data = data[i:]
+			if boundary {
+				cut()
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if length > 0 {
+		cut()
+	}
+
+	return chunks, nil
+}
+
+func digest(h hash.Hash) string {
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// gearTable maps bytes to random values for the rolling gear hash. The values define the chunk
+// boundaries and must never change, otherwise new chunks can't be shared with published ones.
+var gearTable = func() (table [256]uint64) {
+	// splitmix64 with a fixed seed
+	state := uint64(0x6b7562657669727)
+	for i := range table {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
@@ -0,0 +1,108 @@
+package chunker
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Chunker", func() {
+	options := Options{MinSize: 1024, AvgSize: 4096, MaxSize: 16384}
+
+	randomData := func(seed int64, size int) []byte {
+		data := make([]byte, size)
+		rand.New(rand.NewSource(seed)).Read(data) //nolint:gosec // deterministic test data
+		return data
+	}
+
+	digests := func(chunks []Chunk) []string {
+		var result []string
+		for _, chunk := range chunks {
+			result = append(result, chunk.Digest)
+		}
+		return result
+	}
+
+	It("should cover the whole file with bounded chunks", func() {
+		data := randomData(1, 1024*1024)
+		chunks, err := Split(bytes.NewReader(data), options)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(len(chunks)).To(BeNumerically(">", 50))
+
+		offset := int64(0)
+		for i, chunk := range chunks {
+			Expect(chunk.Offset).To(Equal(offset))
+			Expect(chunk.Length).To(BeNumerically("<=", options.MaxSize))
+			if i < len(chunks)-1 {
+				Expect(chunk.Length).To(BeNumerically(">=", options.MinSize))
+			}
+			offset += chunk.Length
+		}
+		Expect(offset).To(Equal(int64(len(data))))
+	})
+
+	It("should only change the chunks around an insertion", func() {
+		data := randomData(2, 1024*1024)
+		chunks, err := Split(bytes.NewReader(data), options)
+		Expect(err).ToNot(HaveOccurred())
+
+		modified := append(bytes.Clone(data[:512*1024]), append([]byte("inserted"), data[512*1024:]...)...)
+		modifiedChunks, err := Split(bytes.NewReader(modified), options)
+		Expect(err).ToNot(HaveOccurred())
+
+		shared := 0
+		known := map[string]bool{}
+		for _, digest := range digests(chunks) {
+			known[digest] = true
+		}
+		for _, digest := range digests(modifiedChunks) {
+			if known[digest] {
+				shared++
+			}
+		}
+		Expect(shared).To(BeNumerically(">=", len(modifiedChunks)-2))
+	})
+
+	It("should cut chunks of constant data at the maximum size", func() {
+		chunks, err := Split(bytes.NewReader(make([]byte, 100000)), options)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(chunks).To(HaveLen(7))
+		Expect(chunks[0].Digest).To(Equal(chunks[1].Digest))
+	})
+
+	It("should reject an average size which is not a power of two", func() {
+		_, err := Split(bytes.NewReader(nil), Options{AvgSize: 3000})
+		Expect(err).To(MatchError(ContainSubstring("power of two")))
+	})
+
+	DescribeTable("OptionsForSize should limit the number of chunks",
+		func(size int64, avgSize int64) {
+			opts := OptionsForSize(size, 120)
+			Expect(opts.AvgSize).To(Equal(avgSize))
+			Expect(size/opts.MinSize + 1).To(BeNumerically("<=", 120))
+		},
+		Entry("small disk", int64(700*1024*1024), int64(DefaultAvgSize)),
+		Entry("large disk", int64(10*1024*1024*1024), int64(512*1024*1024)),
+	)
+})
+
+func TestChunker(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Chunker Suite")
+}
+
+func BenchmarkSplit(b *testing.B) {
+	data := make([]byte, 64*1024*1024)
+	rand.New(rand.NewSource(1)).Read(data) //nolint:gosec // deterministic test data
+	options := OptionsForSize(int64(len(data)), 120)
+
+	b.SetBytes(int64(len(data)))
+	for b.Loop() {
+		if _, err := Split(bytes.NewReader(data), options); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -3,4 +3,21 @@ package common
 const (
 	DefaultInstancetypeEnv = "INSTANCETYPE_KUBEVIRT_IO_DEFAULT_INSTANCETYPE"
 	DefaultPreferenceEnv   = "INSTANCETYPE_KUBEVIRT_IO_DEFAULT_PREFERENCE"
+
+	// DefaultInstancetypeLabel and DefaultPreferenceLabel are the labels KubeVirt and CDI use to infer
+	// the instancetype and preference of VMs created from an image.
+	DefaultInstancetypeLabel = "instancetype.kubevirt.io/default-instancetype"
+	DefaultPreferenceLabel   = "instancetype.kubevirt.io/default-preference"
 )
+
+// DefaultsLabels returns the labels describing the default instancetype and preference set in the env variables.
+func DefaultsLabels(envVariables map[string]string) map[string]string {
+	labels := map[string]string{}
+	if instancetype := envVariables[DefaultInstancetypeEnv]; instancetype != "" {
+		labels[DefaultInstancetypeLabel] = instancetype
+	}
+	if preference := envVariables[DefaultPreferenceEnv]; preference != "" {
+		labels[DefaultPreferenceLabel] = preference
+	}
+	return labels
+}
@@ -0,0 +1,145 @@
+package dag
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Func is the work of a single node.
+type Func func(ctx context.Context) error
+
+type node struct {
+	name string
+	deps []string
+	fn   Func
+	done bool
+}
+
+// NodeError reports the node a graph failed at.
+type NodeError struct {
+	Node string
+	Err  error
+}
+
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("step %q failed: %v", e.Node, e.Err)
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// Graph models the steps of publishing a containerdisk, like pushing the images of all architectures,
+// assembling the manifest list and tagging it, with their dependencies. Nodes can only depend on nodes
+// added before them, so every graph is acyclic and nodes run in the order they were added.
+//
+// A failing node is retried on its own, without repeating the nodes it depends on. Nodes depending on a
+// node which failed for good are skipped, all other nodes still run. Running the graph again resumes at
+// the failed nodes.
+type Graph struct {
+	// Attempts is how often a failing node is run before it fails for good, at least once.
+	Attempts int
+	// Backoff is the time to wait between two attempts of a node.
+	Backoff time.Duration
+	// OnRetry is called before a failed node is run again.
+	OnRetry func(node string, err error)
+
+	nodes []*node
+}
+
+func New() *Graph {
+	return &Graph{Attempts: 1}
+}
+
+// Add adds a node depending on already added nodes.
+func (g *Graph) Add(name string, fn Func, deps ...string) error {
+	if g.get(name) != nil {
+		return fmt.Errorf("step %q was already added", name)
+	}
+	for _, dep := range deps {
+		if g.get(dep) == nil {
+			return fmt.Errorf("step %q depends on unknown step %q", name, dep)
+		}
+	}
+
+	g.nodes = append(g.nodes, &node{name: name, deps: deps, fn: fn})
+	return nil
+}
+
+// MustAdd is like Add but panics on invalid nodes, which are programming errors.
+func (g *Graph) MustAdd(name string, fn Func, deps ...string) {
+	if err := g.Add(name, fn, deps...); err != nil {
+		panic(err)
+	}
+}
+
+// Run runs all nodes which did not succeed yet and returns the error of the first node which failed for good.
+func (g *Graph) Run(ctx context.Context) error {
+	var firstErr error
+	failed := map[string]bool{}
+
+	for _, n := range g.nodes {
+		if n.done {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if slices.ContainsFunc(n.deps, func(dep string) bool { return failed[dep] }) {
+			failed[n.name] = true
+			continue
+		}
+
+		if err := g.runNode(ctx, n); err != nil {
+			failed[n.name] = true
+			if firstErr == nil {
+				firstErr = &NodeError{Node: n.name, Err: err}
+			}
+			continue
+		}
+		n.done = true
+	}
+
+	return firstErr
+}
+
+func (g *Graph) runNode(ctx context.Context, n *node) error {
+	attempts := max(g.Attempts, 1)
+	for attempt := 1; ; attempt++ {
+		err := n.fn(ctx)
+		if err == nil || attempt >= attempts || ctx.Err() != nil {
+			return err
+		}
+
+		if g.OnRetry != nil {
+			g.OnRetry(n.name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(g.Backoff):
+		}
+	}
+}
+
+// Pending returns the nodes which did not succeed yet, in the order they were added.
+func (g *Graph) Pending() []string {
+	var pending []string
+	for _, n := range g.nodes {
+		if !n.done {
+			pending = append(pending, n.name)
+		}
+	}
+	return pending
+}
+
+func (g *Graph) get(name string) *node {
+	for _, n := range g.nodes {
+		if n.name == name {
+			return n
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package dag
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Graph", func() {
+	var (
+		runs  []string
+		fails map[string]int
+	)
+
+	step := func(name string) Func {
+		return func(ctx context.Context) error {
+			runs = append(runs, name)
+			if fails[name] > 0 {
+				fails[name]--
+				return errors.New(name + " failed")
+			}
+			return nil
+		}
+	}
+
+	newGraph := func() *Graph {
+		g := New()
+		g.MustAdd("push-amd64", step("push-amd64"))
+		g.MustAdd("push-arm64", step("push-arm64"))
+		g.MustAdd("manifest", step("manifest"), "push-amd64", "push-arm64")
+		g.MustAdd("tag", step("tag"), "manifest")
+		g.MustAdd("kernel-boot", step("kernel-boot"))
+		return g
+	}
+
+	BeforeEach(func() {
+		runs = nil
+		fails = map[string]int{}
+	})
+
+	It("should run all nodes in order", func() {
+		Expect(newGraph().Run(context.Background())).To(Succeed())
+		Expect(runs).To(Equal([]string{"push-amd64", "push-arm64", "manifest", "tag", "kernel-boot"}))
+	})
+
+	It("should reject unknown dependencies and duplicates", func() {
+		g := New()
+		Expect(g.Add("manifest", step("manifest"), "push")).To(MatchError(ContainSubstring("unknown step \"push\"")))
+		Expect(g.Add("push", step("push"))).To(Succeed())
+		Expect(g.Add("push", step("push"))).To(MatchError(ContainSubstring("already added")))
+	})
+
+	It("should skip dependents of failed nodes and resume at the failed node", func() {
+		fails["manifest"] = 1
+		g := newGraph()
+		err := g.Run(context.Background())
+		var nodeErr *NodeError
+		Expect(errors.As(err, &nodeErr)).To(BeTrue())
+		Expect(nodeErr.Node).To(Equal("manifest"))
+		Expect(runs).To(Equal([]string{"push-amd64", "push-arm64", "manifest", "kernel-boot"}))
+		Expect(g.Pending()).To(Equal([]string{"manifest", "tag"}))
+
+		runs = nil
+		Expect(g.Run(context.Background())).To(Succeed())
+		Expect(runs).To(Equal([]string{"manifest", "tag"}))
+		Expect(g.Pending()).To(BeEmpty())
+	})
+
+	It("should retry failed nodes without their dependencies", func() {
+		fails["manifest"] = 2
+		g := newGraph()
+		g.Attempts = 3
+		var retried []string
+		g.OnRetry = func(node string, err error) {
+			retried = append(retried, node)
+		}
+		Expect(g.Run(context.Background())).To(Succeed())
+		Expect(retried).To(Equal([]string{"manifest", "manifest"}))
+		Expect(runs).To(Equal([]string{"push-amd64", "push-arm64", "manifest", "manifest", "manifest", "tag", "kernel-boot"}))
+	})
+
+	It("should stop when the context is canceled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		Expect(newGraph().Run(ctx)).To(MatchError(context.Canceled))
+		Expect(runs).To(BeEmpty())
+	})
+})
+
+func TestDag(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Dag Suite")
+}
@@ -1,6 +1,16 @@
 # {{ .Name | ToTitle }} Containerdisk Images
 
 {{ .Description }}
+{{- if .ReleaseNotes }}
+<br />
+<br />
+See the [release notes]({{ .ReleaseNotes }}) for what changed in this version.
+{{- end }}
+{{- if .Inventory }}
+<br />
+<br />
+The published disk ships {{ .Inventory }}.
+{{- end }}
 
 ## Documentation
 
@@ -20,7 +30,7 @@ For how to get started with `KubeVirt` visit the [user guide](https://kubevirt.i
 You can create a VirtualMachine that will import this containerdisk by running the following command:
 
 ```shell
-virtctl create vm {{ if .Instancetype }}--instancetype={{ .Instancetype }} {{ end }}{{ if .Preference }}--preference={{ .Preference }} {{ end }}--volume-import=type:registry,url:docker://{{ .Image }},size:10Gi | kubectl create -f -
+virtctl create vm {{ if .Instancetype }}--instancetype={{ .Instancetype }} {{ end }}{{ if .Preference }}--preference={{ .Preference }} {{ end }}--volume-import=type:registry,url:docker://{{ .Image }},size:{{ .ImportSize }} | kubectl create -f -
 ```
 
 ### Creating a VirtualMachine without persistence with virtctl
@@ -35,4 +45,101 @@ virtctl create vm {{ if .Instancetype }}--instancetype={{ .Instancetype }} {{ en
 
 ```yaml
 {{ .Example -}}
-```
\ No newline at end of file
+```
+
+### Importing this containerdisk as golden image with a DataImportCron
+
+With [CDI](https://github.com/kubevirt/containerized-data-importer) the following manifests import this containerdisk
+into a PVC, poll it for updates and provide the most recent import as DataSource `{{ .Name }}`:
+
+```yaml
+{{ .GoldenImageManifests -}}
+```
+
+Apply them with `kubectl apply -f` and create VirtualMachines with `virtctl create vm --volume-import=type:ds,src:kubevirt-os-images/{{ .Name }}`.
+{{- if .AccessCredentialsUser }}
+
+### Injecting SSH keys with accessCredentials
+
+This containerdisk runs the qemu-guest-agent, so SSH public keys can be injected from a Secret while the VM is running
+instead of embedding them into the cloud-init user data. Create the Secret and add the following to
+`spec.template.spec` of the VirtualMachine definition:
+
+```shell
+kubectl create secret generic my-ssh-keys --from-file=key=$HOME/.ssh/id_ed25519.pub
+```
+
+```yaml
+accessCredentials:
+- sshPublicKey:
+    source:
+      secret:
+        secretName: my-ssh-keys
+    propagationMethod:
+      qemuGuestAgent:
+        users:
+        - {{ .AccessCredentialsUser }}
+```
+{{- end }}
+{{- if not .Hints.IsZero }}
+
+### Virtual hardware hints
+
+VirtualMachines using this containerdisk should be configured with:
+{{- if .Hints.MachineType }}
+  * Machine type `{{ .Hints.MachineType }}`
+{{- end }}
+{{- if .Hints.RequiresRng }}
+  * A virtio-rng device, which the guest requires
+{{- end }}
+{{- if .Hints.SecureBoot }}
+  * Secure boot, which the guest supports
+{{- end }}
+{{- if .Hints.RequiresTPM }}
+  * A TPM device, which the guest requires
+{{- end }}
+
+The hints are also stored in the `containerdisks.kubevirt.io/` annotations and labels of the image.
+{{- end }}
+{{- if .AdditionalDisks }}
+
+### Attaching the additional disks of this containerdisk
+
+This containerdisk ships additional disks next to the boot disk. Each of them can be attached as separate volume by
+selecting its path inside the containerdisk:
+
+```yaml
+{{- range .AdditionalDisks }}
+- name: {{ .Name }}
+  containerDisk:
+    image: {{ $.Image }}
+    path: {{ .Path }}
+{{- end }}
+```
+{{- end }}
+{{- if .KernelBoot }}
+
+### Booting the kernel of this containerdisk directly
+
+The kernel and initrd of this containerdisk are additionally published as kernel boot container. They can be used to
+boot the VM directly by adding the following to the VirtualMachine definition:
+
+```yaml
+firmware:
+  kernelBoot:
+    kernelArgs: {{ .KernelBoot.KernelArgs }}
+    container:
+      image: {{ .KernelBoot.Image }}
+      kernelPath: {{ .KernelBoot.KernelPath }}
+      initrdPath: {{ .KernelBoot.InitrdPath }}
+```
+{{- end }}
+{{- if .Variants }}
+
+### Variants
+
+{{ if .Variant }}This is the {{ .Variant }} variant of the containerdisk. {{ end }}The same version is also published as:
+{{- range .Variants }}
+  * {{ if .Name }}{{ .Name }}{{ else }}default{{ end }} variant: `{{ .Image }}`
+{{- end }}
+{{- end }}
\ No newline at end of file
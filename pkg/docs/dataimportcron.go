@@ -0,0 +1,99 @@
+package docs
+
+import (
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+
+	"kubevirt.io/containerdisks/pkg/common"
+)
+
+const (
+	// DataImportCronSchedule is the schedule in examples at which CDI polls the containerdisk for updates.
+	DataImportCronSchedule = "0 */12 * * *"
+	// GoldenImageNamespace is the namespace CDI uses by convention for golden images.
+	GoldenImageNamespace = "kubevirt-os-images"
+
+	LabelDefaultInstancetype = common.DefaultInstancetypeLabel
+	LabelDefaultPreference   = common.DefaultPreferenceLabel
+)
+
+// GoldenImageLabels returns the labels which let virtctl and the UI infer the instancetype
+// and preference of VMs created from a golden image.
+func GoldenImageLabels(instancetype, preference string) map[string]string {
+	labels := map[string]string{}
+	if instancetype != "" {
+		labels[LabelDefaultInstancetype] = instancetype
+	}
+	if preference != "" {
+		labels[LabelDefaultPreference] = preference
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	return labels
+}
+
+// NewDataImportCron returns a DataImportCron which keeps a golden image PVC of the containerdisk
+// up to date and points the DataSource with the same name to it.
+func NewDataImportCron(name, namespace, image, importSize string, labels map[string]string) *cdiv1beta1.DataImportCron {
+	return &cdiv1beta1.DataImportCron{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "DataImportCron",
+			APIVersion: cdiv1beta1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: cdiv1beta1.DataImportCronSpec{
+			Template: cdiv1beta1.DataVolume{
+				Spec: cdiv1beta1.DataVolumeSpec{
+					Source: &cdiv1beta1.DataVolumeSource{
+						Registry: &cdiv1beta1.DataVolumeSourceRegistry{
+							URL:        ptr.To("docker://" + image),
+							PullMethod: ptr.To(cdiv1beta1.RegistryPullNode),
+						},
+					},
+					Storage: &cdiv1beta1.StorageSpec{
+						Resources: k8sv1.VolumeResourceRequirements{
+							Requests: k8sv1.ResourceList{
+								k8sv1.ResourceStorage: resource.MustParse(importSize),
+							},
+						},
+					},
+				},
+			},
+			Schedule:          DataImportCronSchedule,
+			GarbageCollect:    ptr.To(cdiv1beta1.DataImportCronGarbageCollectOutdated),
+			ManagedDataSource: name,
+		},
+	}
+}
+
+// NewDataSource returns the DataSource managed by the DataImportCron with the same name.
+// CDI points it to the most recent import, VMs can clone from it with a sourceRef.
+func NewDataSource(name, namespace string, labels map[string]string) *cdiv1beta1.DataSource {
+	return &cdiv1beta1.DataSource{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "DataSource",
+			APIVersion: cdiv1beta1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: cdiv1beta1.DataSourceSpec{
+			Source: cdiv1beta1.DataSourceSource{
+				PVC: &cdiv1beta1.DataVolumeSourcePVC{
+					Name:      name,
+					Namespace: namespace,
+				},
+			},
+		},
+	}
+}
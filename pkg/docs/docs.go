@@ -16,12 +16,117 @@ import (
 )
 
 type TemplateData struct {
-	Name         string
-	Description  string
-	Example      string
-	Image        string
-	Instancetype string
-	Preference   string
+	Name            string
+	Description     string
+	Example         string
+	Image           string
+	Instancetype    string
+	Preference      string
+	AdditionalDisks []AdditionalDisk
+	KernelBoot      *KernelBoot
+	// ImportSize is the size of the PVC to import the containerdisk into.
+	ImportSize string
+	// GoldenImageManifests are the DataImportCron and DataSource manifests of the containerdisk.
+	GoldenImageManifests string
+	// Hints describe the virtual hardware recommended for the containerdisk.
+	Hints Hints
+	// AccessCredentialsUser is the user SSH public keys are injected for with accessCredentials.
+	// It is only set if the guest runs the qemu-guest-agent.
+	AccessCredentialsUser string
+	// ReleaseNotes is the URL of the upstream release notes of the documented version.
+	ReleaseNotes string
+	// Inventory summarizes the content of the published disk, e.g. "kernel 6.9, cloud-init 24.1".
+	Inventory string
+	// Variant of the documented image, empty for the default variant.
+	Variant string
+	// Variants are the other published variants of the documented version.
+	Variants []Variant
+}
+
+// Variant is another flavor of the documented version, e.g. a minimal image.
+type Variant struct {
+	Name  string
+	Image string
+}
+
+type KernelBoot struct {
+	// Image is the kernel boot container image.
+	Image      string
+	KernelPath string
+	InitrdPath string
+	KernelArgs string
+}
+
+type AdditionalDisk struct {
+	// Name of the disk and of the volume in the example.
+	Name string
+	// Path of the disk inside the containerdisk.
+	Path string
+}
+
+const (
+	// AnnotationRequiresRng is the annotation and label set if the guest requires a virtio-rng device.
+	AnnotationRequiresRng = "containerdisks.kubevirt.io/requires-virtio-rng"
+	// AnnotationMachineType is the annotation and label containing the recommended machine type.
+	AnnotationMachineType = "containerdisks.kubevirt.io/machine-type"
+	// AnnotationSecureBoot is the annotation and label set if the guest is able to boot with secure boot.
+	AnnotationSecureBoot = "containerdisks.kubevirt.io/secure-boot"
+	// AnnotationRequiresTPM is the annotation and label set if the guest requires a TPM device.
+	AnnotationRequiresTPM = "containerdisks.kubevirt.io/requires-tpm"
+)
+
+// Hints describe the virtual hardware a guest requires or works best with, so UIs and
+// VM templating can configure it without knowing the guest.
+type Hints struct {
+	// RequiresRng indicates that the guest requires a virtio-rng device, e.g. to gather entropy at boot.
+	RequiresRng bool
+	// MachineType is the recommended machine type, e.g. q35.
+	MachineType string
+	// SecureBoot indicates that the guest is able to boot with secure boot.
+	SecureBoot bool
+	// RequiresTPM indicates that the guest requires a TPM device.
+	RequiresTPM bool
+}
+
+// IsZero returns true if no hint is set.
+func (h Hints) IsZero() bool {
+	return h == Hints{}
+}
+
+// Annotations returns the annotations describing the hints.
+func (h Hints) Annotations() map[string]string {
+	annotations := map[string]string{}
+	if h.RequiresRng {
+		annotations[AnnotationRequiresRng] = "true"
+	}
+	if h.MachineType != "" {
+		annotations[AnnotationMachineType] = h.MachineType
+	}
+	if h.SecureBoot {
+		annotations[AnnotationSecureBoot] = "true"
+	}
+	if h.RequiresTPM {
+		annotations[AnnotationRequiresTPM] = "true"
+	}
+	return annotations
+}
+
+// Options returns the options configuring a VM according to the hints.
+func (h Hints) Options() []Option {
+	var opts []Option
+	if h.RequiresRng {
+		opts = append(opts, WithRng())
+	}
+	if h.MachineType != "" {
+		opts = append(opts, WithMachineType(h.MachineType))
+	}
+	if h.SecureBoot {
+		opts = append(opts, WithSecureBoot())
+	}
+	if h.RequiresTPM {
+		opts = append(opts, WithTPM())
+	}
+	return opts
 }
 
 type UserData struct {
@@ -106,6 +211,77 @@ func WithRng() Option {
 	}
 }
 
+// WithAdditionalContainerDisk attaches a further disk contained in the containerdisk image.
+// The path selects the disk file inside the containerdisk.
+func WithAdditionalContainerDisk(name, image, path string) Option {
+	return func(vm *v1.VirtualMachine) {
+		vm.Spec.Template.Spec.Domain.Devices.Disks = append(
+			vm.Spec.Template.Spec.Domain.Devices.Disks,
+			v1.Disk{
+				Name: name,
+				DiskDevice: v1.DiskDevice{
+					Disk: &v1.DiskTarget{
+						Bus: "virtio",
+					},
+				},
+			},
+		)
+		vm.Spec.Template.Spec.Volumes = append(
+			vm.Spec.Template.Spec.Volumes,
+			v1.Volume{
+				Name: name,
+				VolumeSource: v1.VolumeSource{
+					ContainerDisk: &v1.ContainerDiskSource{
+						Image: image,
+						Path:  path,
+					},
+				},
+			},
+		)
+	}
+}
+
+// WithCDRom attaches the disk of the containerdisk as CD-ROM instead of as disk, for containerdisks of ISO images.
+// SATA is used since firmware and installers of most guests don't support VirtIO CD-ROMs.
+func WithCDRom() Option {
+	return func(vm *v1.VirtualMachine) {
+		vm.Spec.Template.Spec.Domain.Devices.Disks[0].DiskDevice = v1.DiskDevice{
+			CDRom: &v1.CDRomTarget{Bus: v1.DiskBusSATA},
+		}
+	}
+}
+
+// WithEmptyDisk attaches an ephemeral blank disk of the given capacity, e.g. as target of installer ISOs. It is
+// booted first, so the VM boots the installed system once the installation wrote a bootloader to it.
+func WithEmptyDisk(name, capacity string) Option {
+	return func(vm *v1.VirtualMachine) {
+		vm.Spec.Template.Spec.Domain.Devices.Disks[0].BootOrder = ptr.To(uint(2))
+		vm.Spec.Template.Spec.Domain.Devices.Disks = append(
+			vm.Spec.Template.Spec.Domain.Devices.Disks,
+			v1.Disk{
+				Name:      name,
+				BootOrder: ptr.To(uint(1)),
+				DiskDevice: v1.DiskDevice{
+					Disk: &v1.DiskTarget{
+						Bus: "virtio",
+					},
+				},
+			},
+		)
+		vm.Spec.Template.Spec.Volumes = append(
+			vm.Spec.Template.Spec.Volumes,
+			v1.Volume{
+				Name: name,
+				VolumeSource: v1.VolumeSource{
+					EmptyDisk: &v1.EmptyDiskSource{
+						Capacity: resource.MustParse(capacity),
+					},
+				},
+			},
+		)
+	}
+}
+
 func withCloudInit(volumeSource v1.VolumeSource) Option {
 	return func(vm *v1.VirtualMachine) {
 		vm.Spec.Template.Spec.Domain.Devices.Disks = append(
@@ -162,6 +338,79 @@ func WithSecureBoot() Option {
 	}
 }
 
+// WithEFI boots the VM with EFI firmware but without secure boot, for guests which ship unsigned bootloaders.
+func WithEFI() Option {
+	return func(vm *v1.VirtualMachine) {
+		vm.Spec.Template.Spec.Domain.Firmware = &v1.Firmware{
+			Bootloader: &v1.Bootloader{
+				EFI: &v1.EFI{
+					SecureBoot: ptr.To(false),
+				},
+			},
+		}
+	}
+}
+
+// WithMemory requests the given amount of memory, e.g. 8Gi, for guests which need more than the default.
+func WithMemory(memory string) Option {
+	return func(vm *v1.VirtualMachine) {
+		vm.Spec.Template.Spec.Domain.Resources.Requests[k8sv1.ResourceMemory] = resource.MustParse(memory)
+	}
+}
+
+// WithMachineType sets the machine type of the VM, e.g. q35.
+func WithMachineType(machineType string) Option {
+	return func(vm *v1.VirtualMachine) {
+		vm.Spec.Template.Spec.Domain.Machine = &v1.Machine{Type: machineType}
+	}
+}
+
+// WithTPM adds a TPM device to the VM.
+func WithTPM() Option {
+	return func(vm *v1.VirtualMachine) {
+		vm.Spec.Template.Spec.Domain.Devices.TPM = &v1.TPMDevice{}
+	}
+}
+
+// WithKernelBoot boots the VM directly from the kernel and initrd contained in the given image.
+func WithKernelBoot(image, kernelPath, initrdPath, kernelArgs string) Option {
+	return func(vm *v1.VirtualMachine) {
+		if vm.Spec.Template.Spec.Domain.Firmware == nil {
+			vm.Spec.Template.Spec.Domain.Firmware = &v1.Firmware{}
+		}
+		vm.Spec.Template.Spec.Domain.Firmware.KernelBoot = &v1.KernelBoot{
+			KernelArgs: kernelArgs,
+			Container: &v1.KernelBootContainer{
+				Image:      image,
+				KernelPath: kernelPath,
+				InitrdPath: initrdPath,
+			},
+		}
+	}
+}
+
+// DefaultImportSize is used for the PVC size in examples if the disk size is not known.
+const DefaultImportSize = "10Gi"
+
+// ImportSize returns a PVC size in Gi which fits a disk with the given virtual size
+// including some headroom for filesystem overhead.
+func ImportSize(virtualSize int64) string {
+	if virtualSize <= 0 {
+		return DefaultImportSize
+	}
+
+	const (
+		gi               = 1024 * 1024 * 1024
+		overheadPercent  = 10
+		percentBase      = 100
+		minimumImportGis = 1
+	)
+	withOverhead := virtualSize + virtualSize*overheadPercent/percentBase
+	gis := max((withOverhead+gi-1)/gi, minimumImportGis)
+
+	return strconv.FormatInt(gis, 10) + "Gi"
+}
+
 func Template() *template.Template {
 	caser := cases.Title(language.English)
 	funcMap := template.FuncMap{
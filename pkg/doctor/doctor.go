@@ -0,0 +1,128 @@
+// Package doctor checks whether the hosts medius talks to can be reached over IPv4 and IPv6, to diagnose
+// IPv6-only and dual-stack environments.
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	networkIPv4 = "ip4"
+	networkIPv6 = "ip6"
+
+	defaultPort        = "443"
+	defaultDialTimeout = 5 * time.Second
+)
+
+// Family is the result of checking a host over one IP family.
+type Family struct {
+	// Addresses are the addresses the host resolved to, the A or AAAA records.
+	Addresses []string `json:",omitempty"`
+	// Reachable is set if a TCP connection to one of the addresses succeeded.
+	Reachable bool
+	Err       string `json:",omitempty"`
+}
+
+// Result is the result of checking a host.
+type Result struct {
+	Host string
+	IPv4 Family
+	IPv6 Family
+}
+
+// Reachable returns whether the host can be reached over any IP family. Go dials both families in
+// parallel (Happy Eyeballs), so one is enough.
+func (r *Result) Reachable() bool {
+	return r.IPv4.Reachable || r.IPv6.Reachable
+}
+
+// Checker checks hosts, its functions are replaced by tests.
+type Checker struct {
+	LookupIP    func(ctx context.Context, network, host string) ([]net.IP, error)
+	DialContext func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// NewChecker returns a checker using the resolver and network of the system.
+func NewChecker() *Checker {
+	return &Checker{
+		LookupIP:    net.DefaultResolver.LookupIP,
+		DialContext: (&net.Dialer{Timeout: defaultDialTimeout}).DialContext,
+	}
+}
+
+// Check resolves a host, optionally with port, over both IP families and connects to it. The port
+// defaults to 443.
+func (c *Checker) Check(ctx context.Context, host string) Result {
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname, port = strings.Trim(host, "[]"), defaultPort
+	}
+
+	return Result{
+		Host: host,
+		IPv4: c.checkFamily(ctx, networkIPv4, hostname, port),
+		IPv6: c.checkFamily(ctx, networkIPv6, hostname, port),
+	}
+}
+
+func (c *Checker) checkFamily(ctx context.Context, network, hostname, port string) Family {
+	var family Family
+	ips, err := c.LookupIP(ctx, network, hostname)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		// The host has no address of the family, which is no error of the network
+		return family
+	}
+	if err != nil {
+		family.Err = fmt.Sprintf("error resolving: %v", err)
+		return family
+	}
+
+	var dialErr error
+	for _, ip := range ips {
+		family.Addresses = append(family.Addresses, ip.String())
+		if family.Reachable {
+			continue
+		}
+		// tcp4 and tcp6 make sure the connection uses the family under test
+		conn, err := c.DialContext(ctx, "tcp"+strings.TrimPrefix(network, "ip"), net.JoinHostPort(ip.String(), port))
+		if err != nil {
+			dialErr = err
+			continue
+		}
+		conn.Close()
+		family.Reachable = true
+	}
+	if !family.Reachable && dialErr != nil {
+		family.Err = fmt.Sprintf("error connecting: %v", dialErr)
+	}
+	return family
+}
+
+// Markdown renders the results as a table.
+func Markdown(results []Result) string {
+	var sb strings.Builder
+	sb.WriteString("| Host | IPv4 | IPv6 |\n")
+	sb.WriteString("|---|---|---|\n")
+	for i := range results {
+		r := &results[i]
+		fmt.Fprintf(&sb, "| %s | %s | %s |\n", r.Host, r.IPv4.status(), r.IPv6.status())
+	}
+	return sb.String()
+}
+
+func (f *Family) status() string {
+	switch {
+	case f.Reachable:
+		return "reachable"
+	case len(f.Addresses) == 0 && f.Err == "":
+		return "no address"
+	default:
+		return "unreachable: " + f.Err
+	}
+}
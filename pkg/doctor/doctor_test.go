@@ -0,0 +1,79 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Doctor", func() {
+	var (
+		dialed  []string
+		checker *Checker
+	)
+
+	BeforeEach(func() {
+		dialed = nil
+		records := map[string][]net.IP{
+			"ip4/dual.example.com":   {net.ParseIP("192.0.2.1")},
+			"ip6/dual.example.com":   {net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2")},
+			"ip4/legacy.example.com": {net.ParseIP("192.0.2.2")},
+		}
+		checker = &Checker{
+			LookupIP: func(_ context.Context, network, host string) ([]net.IP, error) {
+				if host == "broken.example.com" {
+					return nil, &net.DNSError{Err: "server misbehaving", Name: host}
+				}
+				ips, exists := records[network+"/"+host]
+				if !exists {
+					return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+				}
+				return ips, nil
+			},
+			DialContext: func(_ context.Context, network, address string) (net.Conn, error) {
+				dialed = append(dialed, network+" "+address)
+				if address == "[2001:db8::1]:443" || network == "tcp4" && address == "192.0.2.2:443" {
+					return nil, errors.New("network is unreachable")
+				}
+				client, server := net.Pipe()
+				server.Close()
+				return client, nil
+			},
+		}
+	})
+
+	It("should check both IP families", func() {
+		result := checker.Check(context.Background(), "dual.example.com")
+		Expect(result).To(Equal(Result{
+			Host: "dual.example.com",
+			IPv4: Family{Addresses: []string{"192.0.2.1"}, Reachable: true},
+			IPv6: Family{Addresses: []string{"2001:db8::1", "2001:db8::2"}, Reachable: true},
+		}))
+		Expect(dialed).To(Equal([]string{"tcp4 192.0.2.1:443", "tcp6 [2001:db8::1]:443", "tcp6 [2001:db8::2]:443"}))
+		Expect(result.Reachable()).To(BeTrue())
+	})
+
+	It("should report unreachable hosts and hosts without addresses", func() {
+		results := []Result{
+			checker.Check(context.Background(), "legacy.example.com:8443"),
+			checker.Check(context.Background(), "broken.example.com"),
+		}
+		Expect(results[0].Reachable()).To(BeTrue())
+		Expect(results[1].Reachable()).To(BeFalse())
+		Expect(Markdown(results)).To(Equal(`| Host | IPv4 | IPv6 |
+|---|---|---|
+| legacy.example.com:8443 | reachable | no address |
+| broken.example.com | unreachable: error resolving: lookup broken.example.com: server misbehaving | ` +
+			`unreachable: error resolving: lookup broken.example.com: server misbehaving |
+`))
+	})
+})
+
+func TestDoctor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Doctor Suite")
+}
@@ -0,0 +1,308 @@
+package extract
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"kubevirt.io/containerdisks/pkg/buffers"
+	"kubevirt.io/containerdisks/pkg/build"
+)
+
+// Options configure extracting a disk from a containerdisk.
+type Options struct {
+	// Arch is the OCI architecture of the containerdisk to pull from an image index. Defaults to build.DefaultArch.
+	Arch string
+	// AdditionalDisk is the name of an additional disk to extract instead of the primary disk.
+	AdditionalDisk string
+	// ChecksumHash, if set, is used to verify the disk against the checksum label of the containerdisk.
+	ChecksumHash func() hash.Hash
+	// Remote options passed to crane, e.g. for authentication.
+	Remote []crane.Option
+}
+
+// Disk pulls the containerdisk imgRef and streams the embedded disk out of it.
+func Disk(ctx context.Context, imgRef string, options Options) (io.ReadCloser, error) {
+	arch := options.Arch
+	if arch == "" {
+		arch = build.DefaultArch
+	}
+
+	craneOptions := append([]crane.Option{
+		crane.WithContext(ctx),
+		crane.WithPlatform(&v1.Platform{OS: build.ImageOS, Architecture: arch}),
+	}, options.Remote...)
+	img, err := crane.Pull(imgRef, craneOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("error pulling %s: %v", imgRef, err)
+	}
+
+	return DiskFromImage(img, options)
+}
+
+// DiskFromImage streams the embedded disk out of a containerdisk image. The layer containing
+// the disk is verified against its diff ID. Verification errors are returned by Read once
+// the end of the disk is reached, so the disk must be read completely before it is trusted.
+func DiskFromImage(img v1.Image, options Options) (io.ReadCloser, error) {
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("error getting the image config file: %v", err)
+	}
+
+	diskPath, label := build.DiskDir+build.DiskFileName, build.LabelShaSum
+	if customPath := configFile.Config.Labels[build.LabelDiskPath]; customPath != "" {
+		diskPath = strings.TrimPrefix(customPath, "/")
+	}
+	if options.AdditionalDisk != "" {
+		diskPath = build.DiskDir + build.AdditionalDiskFileName(options.AdditionalDisk)
+		label = build.AdditionalDiskLabel(options.AdditionalDisk)
+	}
+
+	checksum := ""
+	if options.ChecksumHash != nil {
+		if checksum = configFile.Config.Labels[label]; checksum == "" {
+			return nil, fmt.Errorf("containerdisk has no checksum label %q", label)
+		}
+	}
+
+	if options.AdditionalDisk == "" {
+		chunked, err := openChunkedDisk(img)
+		if err != nil {
+			return nil, err
+		}
+		if chunked != nil {
+			if options.ChecksumHash != nil {
+				chunked.checksumHash = options.ChecksumHash()
+				chunked.checksum = checksum
+			}
+			return chunked, nil
+		}
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("error getting the image layers: %v", err)
+	}
+
+	for _, layer := range layers {
+		reader, err := openDisk(layer, diskPath)
+		if err != nil {
+			return nil, err
+		}
+		if reader == nil {
+			continue
+		}
+		if options.ChecksumHash != nil {
+			reader.checksumHash = options.ChecksumHash()
+			reader.checksum = checksum
+		}
+		return reader, nil
+	}
+
+	return nil, fmt.Errorf("containerdisk contains no disk %s", diskPath)
+}
+
+// openDisk returns a reader positioned at the disk inside the layer, or nil if the layer does not contain it.
+func openDisk(layer v1.Layer, name string) (*diskReader, error) {
+	diffID, err := layer.DiffID()
+	if err != nil {
+		return nil, fmt.Errorf("error getting the layer diff ID: %v", err)
+	}
+	uncompressed, err := layer.Uncompressed()
+	if err != nil {
+		return nil, fmt.Errorf("error reading layer %s: %v", diffID, err)
+	}
+
+	r := &diskReader{
+		uncompressed: uncompressed,
+		diffID:       diffID,
+		diffIDHash:   sha256.New(),
+	}
+	r.layer = io.TeeReader(uncompressed, r.diffIDHash)
+	r.tarReader = tar.NewReader(r.layer)
+
+	for {
+		header, err := r.tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			uncompressed.Close()
+			return nil, nil
+		}
+		if err != nil {
+			uncompressed.Close()
+			return nil, fmt.Errorf("error reading layer %s: %v", diffID, err)
+		}
+		if header.Typeflag == tar.TypeReg && header.Name == name {
+			return r, nil
+		}
+	}
+}
+
+type diskReader struct {
+	uncompressed io.ReadCloser
+	layer        io.Reader
+	tarReader    *tar.Reader
+
+	diffID     v1.Hash
+	diffIDHash hash.Hash
+
+	checksum     string
+	checksumHash hash.Hash
+}
+
+func (r *diskReader) Read(p []byte) (int, error) {
+	n, err := r.tarReader.Read(p)
+	if r.checksumHash != nil {
+		r.checksumHash.Write(p[:n])
+	}
+	if errors.Is(err, io.EOF) {
+		if verifyErr := r.verify(); verifyErr != nil {
+			return n, verifyErr
+		}
+	}
+	return n, err
+}
+
+// verify reads the rest of the layer to complete its diff ID and compares the hashes.
+func (r *diskReader) verify() error {
+	if _, err := buffers.Copy(io.Discard, r.layer); err != nil {
+		return fmt.Errorf("error reading layer %s: %v", r.diffID, err)
+	}
+	if actual := hex.EncodeToString(r.diffIDHash.Sum(nil)); actual != r.diffID.Hex {
+		return fmt.Errorf("layer digest mismatch: expected %s, got sha256:%s", r.diffID, actual)
+	}
+	if r.checksumHash != nil {
+		if actual := hex.EncodeToString(r.checksumHash.Sum(nil)); actual != r.checksum {
+			return fmt.Errorf("disk checksum mismatch: expected %s, got %s", r.checksum, actual)
+		}
+	}
+	return nil
+}
+
+func (r *diskReader) Close() error {
+	return r.uncompressed.Close()
+}
+
+// openChunkedDisk returns a reader concatenating the chunks of a chunked containerdisk,
+// or nil if the containerdisk is not chunked.
+func openChunkedDisk(img v1.Image) (*chunkedDiskReader, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("error getting the image manifest: %v", err)
+	}
+
+	r := &chunkedDiskReader{chunkLayers: map[string]v1.Layer{}}
+	var indexLayer v1.Layer
+	for _, desc := range manifest.Layers {
+		chunk, isChunk := desc.Annotations[build.AnnotationChunk]
+		_, isIndex := desc.Annotations[build.AnnotationChunkIndex]
+		if !isChunk && !isIndex {
+			continue
+		}
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("error getting layer %s: %v", desc.Digest, err)
+		}
+		if isIndex {
+			indexLayer = layer
+		} else {
+			r.chunkLayers[chunk] = layer
+		}
+	}
+	if indexLayer == nil {
+		return nil, nil
+	}
+
+	index, err := openDisk(indexLayer, build.DiskDir+build.ChunkIndexFileName)
+	if err != nil {
+		return nil, err
+	}
+	if index == nil {
+		return nil, errors.New("chunked containerdisk contains no chunk index")
+	}
+	defer index.Close()
+	data, err := io.ReadAll(index)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the chunk index: %v", err)
+	}
+	r.chunks = strings.Fields(string(data))
+
+	return r, nil
+}
+
+type chunkedDiskReader struct {
+	chunkLayers map[string]v1.Layer
+	chunks      []string
+	current     *diskReader
+
+	checksum     string
+	checksumHash hash.Hash
+}
+
+func (r *chunkedDiskReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.chunks) == 0 {
+				return 0, r.verify()
+			}
+			if err := r.next(); err != nil {
+				return 0, err
+			}
+		}
+
+		n, err := r.current.Read(p)
+		if r.checksumHash != nil {
+			r.checksumHash.Write(p[:n])
+		}
+		if errors.Is(err, io.EOF) {
+			err = r.current.Close()
+			r.current = nil
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+func (r *chunkedDiskReader) next() error {
+	chunk := r.chunks[0]
+	r.chunks = r.chunks[1:]
+
+	layer, ok := r.chunkLayers[chunk]
+	if !ok {
+		return fmt.Errorf("chunked containerdisk contains no layer of chunk %s", chunk)
+	}
+	reader, err := openDisk(layer, build.ChunkDir+chunk)
+	if err != nil {
+		return err
+	}
+	if reader == nil {
+		return fmt.Errorf("chunk layer contains no chunk %s", chunk)
+	}
+	r.current = reader
+	return nil
+}
+
+func (r *chunkedDiskReader) verify() error {
+	if r.checksumHash != nil {
+		if actual := hex.EncodeToString(r.checksumHash.Sum(nil)); actual != r.checksum {
+			return fmt.Errorf("disk checksum mismatch: expected %s, got %s", r.checksum, actual)
+		}
+	}
+	return io.EOF
+}
+
+func (r *chunkedDiskReader) Close() error {
+	if r.current != nil {
+		return r.current.Close()
+	}
+	return nil
+}
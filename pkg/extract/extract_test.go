@@ -0,0 +1,91 @@
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/build"
+)
+
+var _ = Describe("Extract", func() {
+	newContainerDisk := func(primaryChecksum string) v1.Image {
+		dir := GinkgoT().TempDir()
+		primary := filepath.Join(dir, "primary")
+		Expect(os.WriteFile(primary, []byte("primary"), 0o600)).To(Succeed())
+		data := filepath.Join(dir, "data")
+		Expect(os.WriteFile(data, []byte("data"), 0o600)).To(Succeed())
+
+		config := build.ContainerDiskConfig(primaryChecksum, nil)
+		config.Labels[build.AdditionalDiskLabel("data")] = sha256Hex("data")
+		img, err := build.ContainerDisk(primary, "amd64", config, build.AdditionalDisk{Name: "data", Path: data})
+		Expect(err).ToNot(HaveOccurred())
+		return img
+	}
+
+	readAll := func(img v1.Image, options Options) (string, error) {
+		reader, err := DiskFromImage(img, options)
+		Expect(err).ToNot(HaveOccurred())
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		return string(data), err
+	}
+
+	It("should extract the primary disk and verify its checksum", func() {
+		data, err := readAll(newContainerDisk(sha256Hex("primary")), Options{ChecksumHash: sha256.New})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal("primary"))
+	})
+
+	It("should extract additional disks", func() {
+		data, err := readAll(newContainerDisk(sha256Hex("primary")), Options{AdditionalDisk: "data", ChecksumHash: sha256.New})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal("data"))
+	})
+
+	It("should fail if the disk does not match the checksum label", func() {
+		_, err := readAll(newContainerDisk(sha256Hex("other")), Options{ChecksumHash: sha256.New})
+		Expect(err).To(MatchError(ContainSubstring("disk checksum mismatch")))
+	})
+
+	It("should extract and verify the disk of chunked containerdisks", func() {
+		primary := filepath.Join(GinkgoT().TempDir(), "primary")
+		Expect(os.WriteFile(primary, []byte("primary"), 0o600)).To(Succeed())
+		img, err := build.ChunkedContainerDisk(primary, "amd64", build.ContainerDiskConfig(sha256Hex("primary"), nil))
+		Expect(err).ToNot(HaveOccurred())
+
+		data, err := readAll(img, Options{ChecksumHash: sha256.New})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal("primary"))
+	})
+
+	It("should extract disks stored at a custom path", func() {
+		Expect(build.SetDefaultLayout(build.Layout{DiskPath: "/vm/root.img", UID: 107, GID: 107, FileMode: 0o444})).To(Succeed())
+		DeferCleanup(build.SetDefaultLayout, build.DefaultLayout)
+		data, err := readAll(newContainerDisk(sha256Hex("primary")), Options{ChecksumHash: sha256.New})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal("primary"))
+	})
+
+	It("should fail if the disk does not exist", func() {
+		_, err := DiskFromImage(newContainerDisk(""), Options{AdditionalDisk: "missing"})
+		Expect(err).To(MatchError(ContainSubstring("no disk disk/missing.img")))
+	})
+})
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestExtract(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Extract Suite")
+}
@@ -0,0 +1,109 @@
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+const idPrefix = "urn:kubevirt:containerdisks:"
+
+// Feed is an Atom feed (RFC 4287) of published containerdisks.
+type Feed struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Author  Person   `xml:"author"`
+	Entries []Entry  `xml:"entry"`
+}
+
+type Person struct {
+	Name string `xml:"name"`
+}
+
+// Entry is a version of a containerdisk in a Feed.
+type Entry struct {
+	ID      string `xml:"id"`
+	Title   string `xml:"title"`
+	Updated string `xml:"updated"`
+	Link    *Link  `xml:"link,omitempty"`
+	Summary string `xml:"summary"`
+}
+
+type Link struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+// New creates an empty feed, name identifies the feed among the feeds of a site.
+func New(name, title string) *Feed {
+	return &Feed{
+		ID:     idPrefix + "feed:" + name,
+		Title:  title,
+		Author: Person{Name: "KubeVirt"},
+	}
+}
+
+// EntryID returns the ID of the entry of a version of an artifact.
+func EntryID(artifact, version string) string {
+	return idPrefix + artifact + ":" + version
+}
+
+// Load reads a feed, returning an empty feed if the file doesn't exist yet.
+func Load(fileName, name, title string) (*Feed, error) {
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return New(name, title), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Feed{}
+	if err := xml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("error parsing the feed %s: %v", fileName, err)
+	}
+	return f, nil
+}
+
+// Add prepends the entries which are not in the feed yet and keeps at most maxEntries of the newest entries,
+// unlimited if maxEntries is zero. It returns whether the feed changed.
+func (f *Feed) Add(updated time.Time, maxEntries int, entries ...Entry) bool {
+	known := map[string]bool{}
+	for _, entry := range f.Entries {
+		known[entry.ID] = true
+	}
+
+	var added []Entry
+	for _, entry := range entries {
+		if known[entry.ID] {
+			continue
+		}
+		known[entry.ID] = true
+		entry.Updated = updated.UTC().Format(time.RFC3339)
+		added = append(added, entry)
+	}
+	if len(added) == 0 {
+		return false
+	}
+
+	f.Updated = updated.UTC().Format(time.RFC3339)
+	f.Entries = append(added, f.Entries...)
+	if maxEntries > 0 && len(f.Entries) > maxEntries {
+		f.Entries = f.Entries[:maxEntries]
+	}
+	return true
+}
+
+// Marshal renders the feed as XML document.
+func (f *Feed) Marshal() ([]byte, error) {
+	data, err := xml.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
@@ -0,0 +1,78 @@
+package feed
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Feed", func() {
+	first := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+
+	ginkgo.It("Add should prepend new entries and keep the newest ones", func() {
+		f := New("fedora", "fedora containerdisks")
+		Expect(f.Add(first, 2,
+			Entry{ID: EntryID("fedora:42-x86_64", "42-1.1"), Title: "fedora 42-1.1"},
+			Entry{ID: EntryID("fedora:43-x86_64", "43-1.6"), Title: "fedora 43-1.6"},
+		)).To(BeTrue())
+		Expect(f.Add(second, 2, Entry{ID: EntryID("fedora:43-x86_64", "43-1.6")})).To(BeFalse())
+		Expect(f.Updated).To(Equal("2026-03-01T12:00:00Z"))
+
+		Expect(f.Add(second, 2, Entry{ID: EntryID("fedora:43-x86_64", "43-1.7"), Title: "fedora 43-1.7"})).To(BeTrue())
+		Expect(f.Updated).To(Equal("2026-03-02T12:00:00Z"))
+		Expect(f.Entries).To(Equal([]Entry{
+			{ID: "urn:kubevirt:containerdisks:fedora:43-x86_64:43-1.7", Title: "fedora 43-1.7", Updated: "2026-03-02T12:00:00Z"},
+			{ID: "urn:kubevirt:containerdisks:fedora:42-x86_64:42-1.1", Title: "fedora 42-1.1", Updated: "2026-03-01T12:00:00Z"},
+		}))
+	})
+
+	ginkgo.It("Marshal and Load should round trip", func() {
+		f := New("all", "KubeVirt containerdisks")
+		f.Add(first, 0, Entry{
+			ID:      EntryID("leap:15.6-x86_64", "15.6-1"),
+			Title:   "leap 15.6-1",
+			Link:    &Link{Href: "https://example.com/leap/15.6/", Rel: "related"},
+			Summary: "quay.io/containerdisks/leap:15.6-1",
+		})
+		data, err := f.Marshal()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(`<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <id>urn:kubevirt:containerdisks:feed:all</id>
+  <title>KubeVirt containerdisks</title>
+  <updated>2026-03-01T12:00:00Z</updated>
+  <author>
+    <name>KubeVirt</name>
+  </author>
+  <entry>
+    <id>urn:kubevirt:containerdisks:leap:15.6-x86_64:15.6-1</id>
+    <title>leap 15.6-1</title>
+    <updated>2026-03-01T12:00:00Z</updated>
+    <link href="https://example.com/leap/15.6/" rel="related"></link>
+    <summary>quay.io/containerdisks/leap:15.6-1</summary>
+  </entry>
+</feed>
+`))
+
+		fileName := filepath.Join(ginkgo.GinkgoT().TempDir(), "all.atom")
+		Expect(os.WriteFile(fileName, data, 0o600)).To(Succeed())
+		loaded, err := Load(fileName, "all", "ignored")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.Entries).To(Equal(f.Entries))
+		Expect(loaded.Title).To(Equal("KubeVirt containerdisks"))
+
+		missing, err := Load(filepath.Join(ginkgo.GinkgoT().TempDir(), "missing.atom"), "all", "KubeVirt containerdisks")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(missing.Entries).To(BeEmpty())
+	})
+})
+
+func TestFeed(t *testing.T) {
+	RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "Feed Suite")
+}
@@ -0,0 +1,182 @@
+// Package forensics gathers evidence about downloads whose checksum does not match the checksum file of upstream,
+// to tell corrupted mirrors and tampered downloads apart from checksum files which were parsed wrongly.
+package forensics
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+)
+
+// ChunkSize is the granularity with which differences between two downloads are located.
+const ChunkSize = 1024 * 1024
+
+// Verdict is the most likely cause of a checksum mismatch.
+type Verdict string
+
+const (
+	// VerdictParser means the expected checksum is no valid digest of the checksum hash, so the checksum file
+	// was most likely parsed wrongly.
+	VerdictParser Verdict = "parser"
+	// VerdictChecksumFile means upstream serves the same file twice and it does not match the checksum file. Either
+	// the checksum was taken from the wrong line or the file was replaced without updating the checksum file.
+	VerdictChecksumFile Verdict = "checksum-file"
+	// VerdictTransient means the download matched the checksum the second time, so the first one was corrupted
+	// on the way.
+	VerdictTransient Verdict = "transient-corruption"
+	// VerdictUnstable means both downloads differ from each other and from the checksum file, which hints at
+	// a corrupted mirror or a tampered connection.
+	VerdictUnstable Verdict = "unstable-content"
+	// VerdictUnknown means the file could not be downloaded a second time to compare it.
+	VerdictUnknown Verdict = "unknown"
+)
+
+// reportedHeaders are the response headers which tell which server, mirror or cache delivered a download.
+var reportedHeaders = []string{
+	"Age", "Content-Encoding", "Content-Length", "Content-Type", "Date", "ETag", "Last-Modified",
+	"Server", "Via", "X-Cache", "X-Served-By",
+}
+
+// Range is a byte range [Start, End) of a download.
+type Range struct {
+	Start int64
+	End   int64
+}
+
+// Report is the evidence gathered about a checksum mismatch.
+type Report struct {
+	// URL is the URL the download was requested from.
+	URL string
+	// ServedBy is the URL the download was finally served from, after following redirects to mirrors.
+	ServedBy string `json:",omitempty"`
+	// RemoteAddr is the address of the server which delivered the download.
+	RemoteAddr string `json:",omitempty"`
+	// Headers are the response headers describing the server, mirror or cache which delivered the download.
+	Headers map[string]string `json:",omitempty"`
+	// Expected is the checksum found in the checksum file of upstream.
+	Expected string
+	// Actual is the checksum of the download.
+	Actual string
+	// Size is the size of the download.
+	Size int64
+	// Redownloaded is the checksum of a second download of the same URL.
+	Redownloaded string `json:",omitempty"`
+	// RedownloadedSize is the size of the second download.
+	RedownloadedSize int64 `json:",omitempty"`
+	// RedownloadError is set if the second download failed.
+	RedownloadError string `json:",omitempty"`
+	// DifferingRanges are the byte ranges in which both downloads differ.
+	DifferingRanges []Range `json:",omitempty"`
+	// Verdict is the most likely cause of the mismatch.
+	Verdict Verdict
+}
+
+// SetResponse records which server delivered the download.
+func (r *Report) SetResponse(servedBy, remoteAddr string, header http.Header) {
+	r.ServedBy = servedBy
+	r.RemoteAddr = remoteAddr
+	for _, name := range reportedHeaders {
+		if value := header.Get(name); value != "" {
+			if r.Headers == nil {
+				r.Headers = map[string]string{}
+			}
+			r.Headers[name] = value
+		}
+	}
+}
+
+// Classify compares both downloads and determines the verdict. second is nil if the second download failed.
+func (r *Report) Classify(checksumHash func() hash.Hash, first, second *Chunks) {
+	r.Size = first.Size()
+	if second != nil {
+		r.RedownloadedSize = second.Size()
+		r.DifferingRanges = Diff(first, second)
+	}
+
+	switch {
+	case !validDigest(r.Expected, checksumHash().Size()):
+		r.Verdict = VerdictParser
+	case second == nil:
+		r.Verdict = VerdictUnknown
+	case r.Redownloaded == r.Expected:
+		r.Verdict = VerdictTransient
+	case r.Redownloaded == r.Actual:
+		r.Verdict = VerdictChecksumFile
+	default:
+		r.Verdict = VerdictUnstable
+	}
+}
+
+func validDigest(digest string, size int) bool {
+	decoded, err := hex.DecodeString(digest)
+	return err == nil && len(decoded) == size
+}
+
+// Chunks records the digest of every ChunkSize bytes written to it, so two downloads can be compared without
+// keeping both of them.
+type Chunks struct {
+	digests [][]byte
+	current hash.Hash
+	pending int64
+	size    int64
+}
+
+func NewChunks() *Chunks {
+	return &Chunks{current: sha256.New()}
+}
+
+func (c *Chunks) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		n := min(int64(len(p)), ChunkSize-c.pending)
+		c.current.Write(p[:n])
+		c.pending += n
+		c.size += n
+		p = p[n:]
+		if c.pending == ChunkSize {
+			c.flush()
+		}
+	}
+	return written, nil
+}
+
+func (c *Chunks) flush() {
+	c.digests = append(c.digests, c.current.Sum(nil))
+	c.current.Reset()
+	c.pending = 0
+}
+
+// Size returns the number of bytes written.
+func (c *Chunks) Size() int64 {
+	return c.size
+}
+
+func (c *Chunks) sums() [][]byte {
+	if c.pending > 0 {
+		return append(c.digests[:len(c.digests):len(c.digests)], c.current.Sum(nil))
+	}
+	return c.digests
+}
+
+// Diff returns the merged byte ranges in which a and b differ. Bytes only one of them has differ as well.
+func Diff(a, b *Chunks) []Range {
+	sumsA, sumsB := a.sums(), b.sums()
+	size := max(a.Size(), b.Size())
+
+	var ranges []Range
+	for i := range max(len(sumsA), len(sumsB)) {
+		if i < len(sumsA) && i < len(sumsB) && bytes.Equal(sumsA[i], sumsB[i]) {
+			continue
+		}
+		start := int64(i) * ChunkSize
+		end := min(start+ChunkSize, size)
+		if len(ranges) > 0 && ranges[len(ranges)-1].End == start {
+			ranges[len(ranges)-1].End = end
+		} else {
+			ranges = append(ranges, Range{Start: start, End: end})
+		}
+	}
+	return ranges
+}
@@ -0,0 +1,89 @@
+package forensics
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func chunksOf(data []byte) *Chunks {
+	c := NewChunks()
+	// Write in odd pieces to cross chunk boundaries
+	for len(data) > 0 {
+		n := min(len(data), 333333)
+		_, _ = c.Write(data[:n])
+		data = data[n:]
+	}
+	return c
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+var _ = ginkgo.Describe("Forensics", func() {
+	first := bytes.Repeat([]byte("a"), 3*ChunkSize+10)
+
+	ginkgo.It("should locate the differing chunks of two downloads", func() {
+		second := bytes.Clone(first)
+		second[ChunkSize+1] = 'b'
+		second[2*ChunkSize] = 'b'
+		Expect(Diff(chunksOf(first), chunksOf(second))).To(Equal([]Range{{Start: ChunkSize, End: 3 * ChunkSize}}))
+
+		second = bytes.Clone(first)
+		second[0] = 'b'
+		second[len(second)-1] = 'b'
+		Expect(Diff(chunksOf(first), chunksOf(second))).To(Equal([]Range{
+			{Start: 0, End: ChunkSize},
+			{Start: 3 * ChunkSize, End: 3*ChunkSize + 10},
+		}))
+
+		Expect(Diff(chunksOf(first), chunksOf(first))).To(BeEmpty())
+	})
+
+	ginkgo.It("should report truncated downloads as differing", func() {
+		Expect(Diff(chunksOf(first[:ChunkSize+5]), chunksOf(first))).To(Equal([]Range{{Start: ChunkSize, End: 3*ChunkSize + 10}}))
+	})
+
+	ginkgo.DescribeTable("Classify should determine the verdict",
+		func(expected string, redownloaded []byte, verdict Verdict) {
+			r := Report{Expected: expected, Actual: digest(first)}
+			var second *Chunks
+			if redownloaded != nil {
+				second = chunksOf(redownloaded)
+				r.Redownloaded = digest(redownloaded)
+			}
+			r.Classify(sha256.New, chunksOf(first), second)
+			Expect(r.Verdict).To(Equal(verdict))
+			Expect(r.Size).To(Equal(int64(len(first))))
+		},
+		ginkgo.Entry("with an invalid expected checksum", "SHA256", first, VerdictParser),
+		ginkgo.Entry("with the same file downloaded twice", digest([]byte("other")), first, VerdictChecksumFile),
+		ginkgo.Entry("with a matching second download", digest([]byte("other")), []byte("other"), VerdictTransient),
+		ginkgo.Entry("with changing content", digest([]byte("other")), []byte("third"), VerdictUnstable),
+		ginkgo.Entry("without a second download", digest([]byte("other")), nil, VerdictUnknown),
+	)
+
+	ginkgo.It("should only report headers describing the server", func() {
+		r := Report{}
+		r.SetResponse("https://mirror.example.com/disk.qcow2", "192.0.2.1:443", http.Header{
+			"Server":     {"nginx"},
+			"Via":        {"1.1 cache"},
+			"Set-Cookie": {"session=secret"},
+		})
+		Expect(r.ServedBy).To(Equal("https://mirror.example.com/disk.qcow2"))
+		Expect(r.RemoteAddr).To(Equal("192.0.2.1:443"))
+		Expect(r.Headers).To(Equal(map[string]string{"Server": "nginx", "Via": "1.1 cache"}))
+	})
+})
+
+func TestForensics(t *testing.T) {
+	RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "Forensics Suite")
+}
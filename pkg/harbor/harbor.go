@@ -0,0 +1,292 @@
+package harbor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"kubevirt.io/containerdisks/pkg/secrets"
+)
+
+const (
+	// EnvUsername and EnvPassword hold the credentials of the Harbor API, e.g. of a robot account.
+	EnvUsername = "HARBOR_USERNAME"
+	EnvPassword = "HARBOR_PASSWORD"
+	// EnvPasswordSecret references the password in a secret store instead, e.g. k8s://ci/harbor-robot#secret.
+	EnvPasswordSecret = "HARBOR_PASSWORD_SECRET"
+
+	apiPath = "/api/v2.0"
+
+	// Scopes of labels, project labels take precedence over global ones of the same name.
+	scopeProject = "p"
+	scopeGlobal  = "g"
+)
+
+// Statuses of replication executions
+const (
+	StatusInProgress = "InProgress"
+	StatusSucceed    = "Succeed"
+	StatusFailed     = "Failed"
+	StatusStopped    = "Stopped"
+)
+
+// DefaultPollInterval is the time between two checks of the replication executions.
+const DefaultPollInterval = 10 * time.Second
+
+// Client talks to the API of a Harbor instance, which also serves the registry.
+type Client struct {
+	// BaseURL is the URL of the API, e.g. https://harbor.example.com/api/v2.0.
+	BaseURL  string
+	Username string
+	Password string
+	// PasswordSecret references the password in a secret store, it is resolved on the first request.
+	PasswordSecret string
+	HTTPClient     *http.Client
+	// PollInterval is the time between two checks of the replication executions, DefaultPollInterval if unset.
+	PollInterval time.Duration
+}
+
+// NewClient returns a client of the Harbor instance serving the registry host, authenticated with the
+// credentials of the environment.
+func NewClient(host string) *Client {
+	return &Client{
+		BaseURL:        "https://" + host + apiPath,
+		Username:       os.Getenv(EnvUsername),
+		Password:       os.Getenv(EnvPassword),
+		PasswordSecret: os.Getenv(EnvPasswordSecret),
+		HTTPClient:     http.DefaultClient,
+	}
+}
+
+// Repository is a repository of a Harbor project.
+type Repository struct {
+	// Host is the registry host of the Harbor instance.
+	Host    string
+	Project string
+	// Name is the name of the repository inside the project, it may contain slashes.
+	Name string
+}
+
+// ParseRepository splits a repository like harbor.example.com/containerdisks/fedora into the registry host,
+// the project and the repository inside the project.
+func ParseRepository(repo string) (Repository, error) {
+	parts := strings.SplitN(repo, "/", 3)
+	const expectedParts = 3
+	if len(parts) != expectedParts || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return Repository{}, fmt.Errorf("repository %q is not of the form <host>/<project>/<repository>", repo)
+	}
+	return Repository{Host: parts[0], Project: parts[1], Name: parts[2]}, nil
+}
+
+type label struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name,omitempty"`
+	Scope string `json:"scope,omitempty"`
+}
+
+type project struct {
+	ProjectID int64 `json:"project_id"`
+}
+
+type policy struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type execution struct {
+	ID         int64     `json:"id"`
+	Status     string    `json:"status"`
+	StatusText string    `json:"status_text"`
+	StartTime  time.Time `json:"start_time"`
+}
+
+// AddLabel attaches the label to the artifact reference, a tag or digest, of the repository. Labels are looked up
+// in the project first and among the global labels second, they have to be created by a Harbor administrator.
+func (c *Client) AddLabel(ctx context.Context, repo Repository, reference, name string) error {
+	id, err := c.labelID(ctx, repo.Project, name)
+	if err != nil {
+		return err
+	}
+
+	artifactPath := fmt.Sprintf("/projects/%s/repositories/%s/artifacts/%s/labels",
+		url.PathEscape(repo.Project), escapeRepository(repo.Name), url.PathEscape(reference))
+	err = c.do(ctx, http.MethodPost, artifactPath, label{ID: id}, nil)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusConflict {
+		// The artifact already carries the label
+		return nil
+	}
+	return err
+}
+
+func (c *Client) labelID(ctx context.Context, projectName, name string) (int64, error) {
+	var p project
+	if err := c.do(ctx, http.MethodGet, "/projects/"+url.PathEscape(projectName), nil, &p); err != nil {
+		return 0, fmt.Errorf("error looking up project %q: %v", projectName, err)
+	}
+
+	queries := []url.Values{
+		{"scope": {scopeProject}, "project_id": {fmt.Sprint(p.ProjectID)}, "name": {name}},
+		{"scope": {scopeGlobal}, "name": {name}},
+	}
+	for _, query := range queries {
+		var labels []label
+		if err := c.do(ctx, http.MethodGet, "/labels?"+query.Encode(), nil, &labels); err != nil {
+			return 0, fmt.Errorf("error looking up label %q: %v", name, err)
+		}
+		// The name filter of Harbor matches substrings
+		for _, l := range labels {
+			if l.Name == name {
+				return l.ID, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("label %q does not exist in project %q nor globally", name, projectName)
+}
+
+// WaitForReplication waits until the replication policy ran successfully after since, e.g. triggered by
+// a push. It fails if the execution fails or is stopped, or if ctx is done before.
+func (c *Client) WaitForReplication(ctx context.Context, policyName string, since time.Time) error {
+	id, err := c.policyID(ctx, policyName)
+	if err != nil {
+		return err
+	}
+
+	interval := c.PollInterval
+	if interval == 0 {
+		interval = DefaultPollInterval
+	}
+	query := url.Values{"policy_id": {fmt.Sprint(id)}, "sort": {"-start_time"}, "page_size": {"10"}}
+	for {
+		var executions []execution
+		if err := c.do(ctx, http.MethodGet, "/replication/executions?"+query.Encode(), nil, &executions); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("replication policy %q did not finish: %v", policyName, ctx.Err())
+			}
+			return fmt.Errorf("error listing the executions of replication policy %q: %v", policyName, err)
+		}
+		if done, err := replicated(policyName, executions, since); done {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("replication policy %q did not finish: %v", policyName, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// replicated reports whether the oldest execution started after since finished. Executions started
+// before since may not contain the pushed artifacts yet.
+func replicated(policyName string, executions []execution, since time.Time) (bool, error) {
+	var first *execution
+	for i := range executions {
+		if !executions[i].StartTime.Before(since) && (first == nil || executions[i].StartTime.Before(first.StartTime)) {
+			first = &executions[i]
+		}
+	}
+	if first == nil {
+		return false, nil
+	}
+
+	switch first.Status {
+	case StatusSucceed:
+		return true, nil
+	case StatusFailed, StatusStopped:
+		return true, fmt.Errorf("execution %d of replication policy %q is %s: %s", first.ID, policyName, first.Status, first.StatusText)
+	default:
+		return false, nil
+	}
+}
+
+func (c *Client) policyID(ctx context.Context, name string) (int64, error) {
+	var policies []policy
+	if err := c.do(ctx, http.MethodGet, "/replication/policies?"+url.Values{"name": {name}}.Encode(), nil, &policies); err != nil {
+		return 0, fmt.Errorf("error looking up replication policy %q: %v", name, err)
+	}
+	for _, p := range policies {
+		if p.Name == name {
+			return p.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("replication policy %q does not exist", name)
+}
+
+// StatusError is returned for responses of the API with an unexpected status code.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, e.Body)
+}
+
+// maxErrorBody limits how much of an error response is kept
+const maxErrorBody = 1024
+
+func (c *Client) do(ctx context.Context, method, endpoint string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.PasswordSecret != "" {
+		if c.Password, err = secrets.Resolve(ctx, c.PasswordSecret); err != nil {
+			return err
+		}
+		c.PasswordSecret = ""
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(raw))}
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("error decoding the response of %s: %v", endpoint, err)
+	}
+	return nil
+}
+
+// escapeRepository escapes the name of a repository for the API, which expects slashes of nested
+// repositories to be escaped twice.
+func escapeRepository(name string) string {
+	return url.PathEscape(url.PathEscape(name))
+}
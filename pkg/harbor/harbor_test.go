@@ -0,0 +1,119 @@
+package harbor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Harbor", func() {
+	var (
+		responses map[string]string
+		requests  []string
+		client    *Client
+	)
+
+	BeforeEach(func() {
+		responses = map[string]string{}
+		requests = nil
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+string(body))
+			user, password, _ := r.BasicAuth()
+			if user != "robot" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			response, exists := responses[r.Method+" "+r.URL.RequestURI()]
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(response))
+		}))
+		DeferCleanup(server.Close)
+		client = &Client{BaseURL: server.URL + apiPath, Username: "robot", Password: "secret", PollInterval: time.Millisecond}
+	})
+
+	It("ParseRepository should split nested repositories", func() {
+		repo, err := ParseRepository("harbor.example.com/containerdisks/centos/stream")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(repo).To(Equal(Repository{Host: "harbor.example.com", Project: "containerdisks", Name: "centos/stream"}))
+
+		_, err = ParseRepository("harbor.example.com/fedora")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("AddLabel should prefer project labels and escape nested repositories", func() {
+		responses["GET /api/v2.0/projects/containerdisks"] = `{"project_id": 3}`
+		responses["GET /api/v2.0/labels?name=verified&project_id=3&scope=p"] = `[{"id": 7, "name": "verified-old"},{"id": 8, "name": "verified"}]`
+		responses["POST /api/v2.0/projects/containerdisks/repositories/centos%252Fstream/artifacts/9/labels"] = ""
+
+		repo := Repository{Host: "harbor.example.com", Project: "containerdisks", Name: "centos/stream"}
+		Expect(client.AddLabel(context.Background(), repo, "9", "verified")).To(Succeed())
+		Expect(requests).To(ContainElement(`POST /api/v2.0/projects/containerdisks/repositories/centos%252Fstream/artifacts/9/labels {"id":8}`))
+	})
+
+	It("AddLabel should fall back to global labels", func() {
+		responses["GET /api/v2.0/projects/containerdisks"] = `{"project_id": 3}`
+		responses["GET /api/v2.0/labels?name=verified&project_id=3&scope=p"] = `[]`
+		responses["GET /api/v2.0/labels?name=verified&scope=g"] = `[{"id": 1, "name": "verified"}]`
+		responses["POST /api/v2.0/projects/containerdisks/repositories/fedora/artifacts/40/labels"] = ""
+
+		repo := Repository{Host: "harbor.example.com", Project: "containerdisks", Name: "fedora"}
+		Expect(client.AddLabel(context.Background(), repo, "40", "verified")).To(Succeed())
+
+		_, err := client.labelID(context.Background(), "containerdisks", "missing")
+		Expect(err).To(HaveOccurred())
+	})
+
+	DescribeTable("WaitForReplication should wait for the first execution after the push",
+		func(executions []execution, expectedErr string) {
+			raw, err := json.Marshal(executions)
+			Expect(err).ToNot(HaveOccurred())
+			responses["GET /api/v2.0/replication/policies?name=mirror"] = `[{"id": 2, "name": "mirror"}]`
+			responses["GET /api/v2.0/replication/executions?page_size=10&policy_id=2&sort=-start_time"] = string(raw)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			err = client.WaitForReplication(ctx, "mirror", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+			if expectedErr == "" {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			}
+		},
+		Entry("succeeded", []execution{
+			{ID: 2, Status: StatusSucceed, StartTime: time.Date(2024, 5, 1, 12, 1, 0, 0, time.UTC)},
+			{ID: 1, Status: StatusFailed, StartTime: time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)},
+		}, ""),
+		Entry("failed", []execution{
+			{ID: 3, Status: StatusSucceed, StartTime: time.Date(2024, 5, 1, 12, 2, 0, 0, time.UTC)},
+			{ID: 2, Status: StatusFailed, StatusText: "registry unreachable", StartTime: time.Date(2024, 5, 1, 12, 1, 0, 0, time.UTC)},
+		}, `execution 2 of replication policy "mirror" is Failed: registry unreachable`),
+		Entry("in progress", []execution{
+			{ID: 2, Status: StatusInProgress, StartTime: time.Date(2024, 5, 1, 12, 1, 0, 0, time.UTC)},
+		}, "did not finish"),
+		Entry("not started", []execution{
+			{ID: 1, Status: StatusSucceed, StartTime: time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)},
+		}, "did not finish"),
+	)
+
+	It("should report unexpected status codes", func() {
+		client.Password = "wrong"
+		err := client.WaitForReplication(context.Background(), "mirror", time.Now())
+		Expect(err).To(MatchError(ContainSubstring("unexpected status code 401")))
+	})
+})
+
+func TestHarbor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Harbor Suite")
+}
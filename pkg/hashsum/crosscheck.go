@@ -0,0 +1,90 @@
+package hashsum
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+// Algorithm names the digest function of a checksum.
+type Algorithm string
+
+const (
+	SHA256 Algorithm = "sha256"
+	SHA512 Algorithm = "sha512"
+)
+
+// New returns the digest function of the algorithm.
+func (a Algorithm) New() (func() hash.Hash, error) {
+	switch a {
+	case SHA256:
+		return sha256.New, nil
+	case SHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unknown checksum algorithm %q", a)
+	}
+}
+
+// Checksum is the checksum of an image along with where it was published.
+type Checksum struct {
+	Algorithm Algorithm
+	Value     string
+	// Source is the URL of the file the checksum was read from.
+	Source string
+}
+
+// Source is a checksum file listing images with the checksums of one algorithm.
+type Source struct {
+	Locator
+	Algorithm Algorithm
+}
+
+// CrossCheck looks up the image at imageURL in all sources and fails if any two of the checksums of the same
+// algorithm, including the already known ones, disagree. A tampered checksum file then has to be accompanied by
+// tampered copies on all other sources to go unnoticed.
+//
+// Sources which are unavailable or do not list the image are skipped, since upstream does not publish every
+// checksum file for every release, but at least one checksum has to be found. The result contains one checksum
+// per algorithm, the download has to match all of them.
+func CrossCheck(getter http.Getter, imageURL string, known []Checksum, sources ...Source) ([]Checksum, error) {
+	var all []Checksum
+	for _, checksum := range known {
+		// Upstream does not publish checksums for all releases, e.g. Fedora beta releases
+		if checksum.Value != "" {
+			all = append(all, checksum)
+		}
+	}
+	var errs []error
+	for _, source := range sources {
+		value, err := source.Lookup(getter, imageURL)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		all = append(all, Checksum{Algorithm: source.Algorithm, Value: value, Source: source.fileURL(imageURL)})
+	}
+	if len(all) == 0 {
+		return nil, fmt.Errorf("no checksum of %s found: %v", imageURL, errors.Join(errs...))
+	}
+
+	var result []Checksum
+	byAlgorithm := map[Algorithm]Checksum{}
+	for _, checksum := range all {
+		first, exists := byAlgorithm[checksum.Algorithm]
+		if !exists {
+			byAlgorithm[checksum.Algorithm] = checksum
+			result = append(result, checksum)
+			continue
+		}
+		if first.Value != checksum.Value {
+			return nil, fmt.Errorf("%s checksums of %s disagree: %q from %s, %q from %s",
+				checksum.Algorithm, imageURL, first.Value, first.Source, checksum.Value, checksum.Source)
+		}
+	}
+	return result, nil
+}
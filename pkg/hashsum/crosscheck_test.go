@@ -0,0 +1,62 @@
+package hashsum
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CrossCheck", func() {
+	const (
+		imageURL  = "https://example.com/releases/24.04/disk.img"
+		sha256Sum = "aaaa"
+		sha512Sum = "cccc"
+	)
+
+	sha256Sums := Source{Locator: Locator{Location: LocationDirectory, Name: "SHA256SUMS", Format: ChecksumFormatGNU}, Algorithm: SHA256}
+	sha512Sums := Source{Locator: Locator{Location: LocationDirectory, Name: "SHA512SUMS", Format: ChecksumFormatGNU}, Algorithm: SHA512}
+	perImage := Source{Locator: Locator{Location: LocationImage, Name: ".sha256", Format: ChecksumFormatGNU}, Algorithm: SHA256}
+
+	It("should return one checksum per algorithm", func() {
+		getter := filesGetter{
+			"https://example.com/releases/24.04/SHA256SUMS":      sha256Sum + "  disk.img\n",
+			"https://example.com/releases/24.04/SHA512SUMS":      sha512Sum + "  disk.img\n",
+			"https://example.com/releases/24.04/disk.img.sha256": sha256Sum + "\n",
+		}
+		checksums, err := CrossCheck(getter, imageURL, nil, sha256Sums, sha512Sums, perImage)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checksums).To(Equal([]Checksum{
+			{Algorithm: SHA256, Value: sha256Sum, Source: "https://example.com/releases/24.04/SHA256SUMS"},
+			{Algorithm: SHA512, Value: sha512Sum, Source: "https://example.com/releases/24.04/SHA512SUMS"},
+		}))
+	})
+
+	It("should skip unavailable checksum files", func() {
+		getter := filesGetter{"https://example.com/releases/24.04/SHA256SUMS": sha256Sum + "  disk.img\n"}
+		known := []Checksum{{Algorithm: SHA256, Value: sha256Sum, Source: "https://example.com/releases.json"}}
+		checksums, err := CrossCheck(getter, imageURL, known, sha256Sums, sha512Sums)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checksums).To(Equal(known))
+	})
+
+	It("should fail if checksum files disagree", func() {
+		getter := filesGetter{
+			"https://example.com/releases/24.04/SHA256SUMS":      sha256Sum + "  disk.img\n",
+			"https://example.com/releases/24.04/disk.img.sha256": "bbbb\n",
+		}
+		_, err := CrossCheck(getter, imageURL, nil, sha256Sums, perImage)
+		Expect(err).To(MatchError(`sha256 checksums of ` + imageURL + ` disagree: "aaaa" from https://example.com/releases/24.04/SHA256SUMS, ` +
+			`"bbbb" from https://example.com/releases/24.04/disk.img.sha256`))
+	})
+
+	It("should fail if a known checksum disagrees", func() {
+		getter := filesGetter{"https://example.com/releases/24.04/SHA256SUMS": sha256Sum + "  disk.img\n"}
+		known := []Checksum{{Algorithm: SHA256, Value: "bbbb", Source: "https://example.com/releases.json"}}
+		_, err := CrossCheck(getter, imageURL, known, sha256Sums)
+		Expect(err).To(MatchError(ContainSubstring("disagree")))
+	})
+
+	It("should fail without any checksum", func() {
+		_, err := CrossCheck(filesGetter{}, imageURL, []Checksum{{Algorithm: SHA256}}, sha256Sums)
+		Expect(err).To(MatchError(ContainSubstring("no checksum of " + imageURL + " found")))
+	})
+})
@@ -0,0 +1,47 @@
+package hashsum
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var fuzzChecksumRex = regexp.MustCompile(`^[0-9a-z]+$`)
+
+func fuzzParse(f *testing.F, format ChecksumFormat, seeds ...string) {
+	for _, seed := range seeds {
+		data, err := os.ReadFile(seed)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checksums, err := Parse(bytes.NewReader(data), format)
+		if err != nil {
+			return
+		}
+		for name, checksum := range checksums {
+			if name == "" || strings.ContainsAny(name, "\n\r") {
+				t.Errorf("unexpected file name %q", name)
+			}
+			if !fuzzChecksumRex.MatchString(checksum) {
+				t.Errorf("unexpected checksum %q for %q", checksum, name)
+			}
+			if !bytes.Contains(data, []byte(checksum)) {
+				t.Errorf("checksum %q for %q is not part of the input", checksum, name)
+			}
+		}
+	})
+}
+
+func FuzzParseBSD(f *testing.F) {
+	fuzzParse(f, ChecksumFormatBSD, "testdata/bsd.checksum", "testdata/broken.checksum")
+}
+
+func FuzzParseGNU(f *testing.F) {
+	fuzzParse(f, ChecksumFormatGNU, "testdata/gnu.checksum")
+}
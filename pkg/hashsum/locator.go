@@ -0,0 +1,170 @@
+package hashsum
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+var bareChecksumRex = regexp.MustCompile(`^[0-9a-f]+$`)
+
+// Location describes where upstream publishes the checksum file of an image, relative to the image.
+type Location int
+
+const (
+	// LocationDirectory is one checksum file for all files of the directory of the image, e.g. SHA256SUMS of Ubuntu.
+	LocationDirectory Location = iota
+	// LocationParent is one checksum file in the parent directory, listing files by their path relative to it.
+	LocationParent
+	// LocationGlobal is a single checksum file at a fixed URL for all images.
+	LocationGlobal
+	// LocationImage is one checksum file per image, named like the image plus a suffix, e.g. disk.qcow2.sha256.
+	LocationImage
+)
+
+// Locator finds the checksums of images in the checksum files published by upstream, so providers only declare
+// where the checksum file lives instead of assembling its URL.
+type Locator struct {
+	Location Location
+	// Name is the file name of the checksum file for LocationDirectory and LocationParent, its URL for
+	// LocationGlobal and the suffix appended to the image URL for LocationImage.
+	Name   string
+	Format ChecksumFormat
+	// Signed means upstream clearsigns the checksum file with OpenPGP, its signature is verified if the getter
+	// verifies signatures and a keyring is configured.
+	Signed bool
+}
+
+// URL returns the URL of the checksum file listing the files of dirURL, along with the path of dirURL
+// relative to the directory of the checksum file.
+func (l Locator) URL(dirURL string) (checksumURL, prefix string, err error) {
+	dir, err := url.Parse(dirURL)
+	if err != nil {
+		return "", "", fmt.Errorf("error parsing url %s: %v", dirURL, err)
+	}
+	if !strings.HasSuffix(dir.Path, "/") {
+		dir.Path += "/"
+	}
+
+	switch l.Location {
+	case LocationDirectory:
+		return dir.JoinPath(l.Name).String(), "", nil
+	case LocationParent:
+		parent := *dir
+		parent.Path = path.Dir(strings.TrimSuffix(dir.Path, "/")) + "/"
+		return parent.JoinPath(l.Name).String(), path.Base(dir.Path) + "/", nil
+	case LocationGlobal:
+		checksumURL, err := url.Parse(l.Name)
+		if err != nil {
+			return "", "", fmt.Errorf("error parsing checksum file url %s: %v", l.Name, err)
+		}
+		base := strings.TrimSuffix(path.Dir(checksumURL.Path), "/") + "/"
+		if checksumURL.Host == dir.Host && strings.HasPrefix(dir.Path, base) {
+			prefix = strings.TrimPrefix(dir.Path, base)
+		}
+		return l.Name, prefix, nil
+	case LocationImage:
+		return "", "", errors.New("checksum files per image do not list the files of a directory")
+	default:
+		return "", "", fmt.Errorf("unknown checksum file location %d", l.Location)
+	}
+}
+
+// Checksums returns the checksums of all files of dirURL, by their name relative to dirURL.
+func (l Locator) Checksums(getter http.Getter, dirURL string) (map[string]string, error) {
+	checksumURL, prefix, err := l.URL(dirURL)
+	if err != nil {
+		return nil, err
+	}
+	all, err := l.fetch(getter, checksumURL)
+	if err != nil {
+		return nil, err
+	}
+
+	checksums := map[string]string{}
+	for name, checksum := range all {
+		name = strings.TrimPrefix(name, "./")
+		switch {
+		case prefix != "" && strings.HasPrefix(name, prefix):
+			checksums[strings.TrimPrefix(name, prefix)] = checksum
+		case !strings.Contains(name, "/"):
+			// Files listed by their base name only, entries with the full path take precedence
+			if _, exists := checksums[name]; !exists {
+				checksums[name] = checksum
+			}
+		}
+	}
+	return checksums, nil
+}
+
+// Lookup returns the checksum of the image at imageURL.
+func (l Locator) Lookup(getter http.Getter, imageURL string) (string, error) {
+	dirURL, name := path.Split(imageURL)
+	if l.Location != LocationImage {
+		checksums, err := l.Checksums(getter, dirURL)
+		if err != nil {
+			return "", err
+		}
+		if checksum, exists := checksums[name]; exists {
+			return checksum, nil
+		}
+		checksumURL, _, _ := l.URL(dirURL)
+		return "", fmt.Errorf("file %q does not exist in the checksum file %s", name, checksumURL)
+	}
+
+	checksumURL := imageURL + l.Name
+	checksums, err := l.fetch(getter, checksumURL)
+	if err != nil {
+		return "", err
+	}
+	if checksum, exists := checksums[name]; exists {
+		return checksum, nil
+	}
+	// Checksum files of single images don't necessarily name the image
+	if len(checksums) == 1 {
+		for _, checksum := range checksums {
+			return checksum, nil
+		}
+	}
+	return "", fmt.Errorf("file %q does not exist in the checksum file %s", name, checksumURL)
+}
+
+// fileURL returns the URL of the checksum file listing the image at imageURL.
+func (l Locator) fileURL(imageURL string) string {
+	if l.Location == LocationImage {
+		return imageURL + l.Name
+	}
+	dirURL, _ := path.Split(imageURL)
+	checksumURL, _, _ := l.URL(dirURL)
+	return checksumURL
+}
+
+func (l Locator) get(getter http.Getter, checksumURL string) ([]byte, error) {
+	if verifier, ok := getter.(http.SignatureVerifier); ok && l.Signed {
+		return verifier.GetVerifiedWithContext(context.Background(), checksumURL)
+	}
+	return getter.GetAll(checksumURL)
+}
+
+func (l Locator) fetch(getter http.Getter, checksumURL string) (map[string]string, error) {
+	raw, err := l.get(getter, checksumURL)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading the checksum file %s: %v", checksumURL, err)
+	}
+	checksums, err := Parse(bytes.NewReader(raw), l.Format)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the checksum file %s: %v", checksumURL, err)
+	}
+	if fields := strings.Fields(string(raw)); len(checksums) == 0 && len(fields) == 1 && bareChecksumRex.MatchString(fields[0]) {
+		// Files containing nothing but the checksum
+		checksums[""] = fields[0]
+	}
+	return checksums, nil
+}
@@ -0,0 +1,117 @@
+package hashsum
+
+import (
+	"context"
+	"fmt"
+	"hash"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+// filesGetter serves files by their URL.
+type filesGetter map[string]string
+
+func (f filesGetter) GetAll(fileURL string) ([]byte, error) {
+	data, ok := f[fileURL]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", fileURL)
+	}
+	return []byte(data), nil
+}
+
+func (f filesGetter) GetAllWithContext(_ context.Context, fileURL string) ([]byte, error) {
+	return f.GetAll(fileURL)
+}
+
+func (f filesGetter) GetWithChecksum(string, func() hash.Hash) (http.ReadCloserWithChecksum, error) {
+	panic("not implemented")
+}
+
+func (f filesGetter) GetWithChecksumAndContext(context.Context, string, func() hash.Hash) (http.ReadCloserWithChecksum, error) {
+	panic("not implemented")
+}
+
+// verifyingGetter serves the signed text of files from their URL with the fragment #verified.
+type verifyingGetter struct {
+	filesGetter
+}
+
+func (v verifyingGetter) GetVerifiedWithContext(ctx context.Context, fileURL string) ([]byte, error) {
+	return v.GetAllWithContext(ctx, fileURL+"#verified")
+}
+
+var _ = Describe("Locator", func() {
+	const (
+		checksumA = "aaaa"
+		checksumB = "bbbb"
+	)
+
+	getter := filesGetter{
+		"https://example.com/releases/24.04/SHA256SUMS":            checksumA + " *disk.img\n",
+		"https://example.com/releases/SHA256SUMS":                  checksumA + "  24.04/disk.img\n" + checksumB + "  24.10/disk.img\n",
+		"https://example.com/SHA256SUMS":                           checksumB + "  ./releases/24.10/disk.img\n",
+		"https://example.com/releases/24.04/disk.img.sha256":       checksumA + "  disk.img\n",
+		"https://example.com/releases/24.10/disk.img.sha256":       checksumB + "\n",
+		"https://example.com/releases/24.10/other.img.sha256":      checksumB + "  disk.img\n" + checksumA + "  base.img\n",
+		"https://example.com/releases/24.10/Fedora-Cloud-CHECKSUM": "SHA256 (disk.img) = " + checksumB + "\n",
+	}
+
+	DescribeTable("Lookup should find checksum files relative to the image",
+		func(locator Locator, imageURL, expected string) {
+			checksum, err := locator.Lookup(getter, imageURL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(checksum).To(Equal(expected))
+		},
+		Entry("next to the image", Locator{Location: LocationDirectory, Name: "SHA256SUMS", Format: ChecksumFormatGNU},
+			"https://example.com/releases/24.04/disk.img", checksumA),
+		Entry("in the parent directory", Locator{Location: LocationParent, Name: "SHA256SUMS", Format: ChecksumFormatGNU},
+			"https://example.com/releases/24.10/disk.img", checksumB),
+		Entry("in a global file", Locator{Location: LocationGlobal, Name: "https://example.com/SHA256SUMS", Format: ChecksumFormatGNU},
+			"https://example.com/releases/24.10/disk.img", checksumB),
+		Entry("per image", Locator{Location: LocationImage, Name: ".sha256", Format: ChecksumFormatGNU},
+			"https://example.com/releases/24.04/disk.img", checksumA),
+		Entry("per image without file name", Locator{Location: LocationImage, Name: ".sha256", Format: ChecksumFormatGNU},
+			"https://example.com/releases/24.10/disk.img", checksumB),
+		Entry("in BSD format", Locator{Location: LocationDirectory, Name: "Fedora-Cloud-CHECKSUM", Format: ChecksumFormatBSD},
+			"https://example.com/releases/24.10/disk.img", checksumB),
+	)
+
+	It("Checksums should list the files of a directory relative to it", func() {
+		locator := Locator{Location: LocationParent, Name: "SHA256SUMS", Format: ChecksumFormatGNU}
+		checksums, err := locator.Checksums(getter, "https://example.com/releases/24.04")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checksums).To(Equal(map[string]string{"disk.img": checksumA}))
+	})
+
+	It("should fail for files missing in the checksum file", func() {
+		locator := Locator{Location: LocationDirectory, Name: "SHA256SUMS", Format: ChecksumFormatGNU}
+		_, err := locator.Lookup(getter, "https://example.com/releases/24.04/missing.img")
+		Expect(err).To(MatchError(`file "missing.img" does not exist in the checksum file https://example.com/releases/24.04/SHA256SUMS`))
+
+		locator = Locator{Location: LocationImage, Name: ".sha256", Format: ChecksumFormatGNU}
+		_, err = locator.Lookup(getter, "https://example.com/releases/24.10/other.img")
+		Expect(err).To(MatchError(ContainSubstring("does not exist in the checksum file")))
+		_, err = locator.Checksums(getter, "https://example.com/releases/24.10/")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should fail for missing checksum files", func() {
+		locator := Locator{Location: LocationDirectory, Name: "SHA256SUMS", Format: ChecksumFormatGNU}
+		_, err := locator.Lookup(getter, "https://example.com/releases/23.10/disk.img")
+		Expect(err).To(MatchError(ContainSubstring("error downloading the checksum file https://example.com/releases/23.10/SHA256SUMS")))
+	})
+
+	It("should verify the signature of signed checksum files", func() {
+		signed := verifyingGetter{filesGetter{
+			"https://example.com/releases/24.10/CHECKSUM":          "SHA256 (disk.img) = " + checksumA + "\n",
+			"https://example.com/releases/24.10/CHECKSUM#verified": "SHA256 (disk.img) = " + checksumB + "\n",
+		}}
+		locator := Locator{Location: LocationDirectory, Name: "CHECKSUM", Format: ChecksumFormatBSD, Signed: true}
+		checksum, err := locator.Lookup(signed, "https://example.com/releases/24.10/disk.img")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checksum).To(Equal(checksumB))
+	})
+})
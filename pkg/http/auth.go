@@ -0,0 +1,183 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"kubevirt.io/containerdisks/pkg/secrets"
+)
+
+// CredentialsFileEnv is the environment variable pointing to a file with credentials for upstream hosts.
+const CredentialsFileEnv = "MEDIUS_HTTP_CREDENTIALS"
+
+// Credentials authenticate requests to a single upstream host. Secrets can either be set inline, be read
+// from files or from secret stores, the latter two are preferred to keep them out of the credentials file.
+type Credentials struct {
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	PasswordFile string `json:"passwordFile,omitempty"`
+	Token        string `json:"token,omitempty"`
+	TokenFile    string `json:"tokenFile,omitempty"`
+	// PasswordSecret and TokenSecret reference secrets in a secret store, e.g. vault://secret/ci/rhel#token.
+	PasswordSecret string `json:"passwordSecret,omitempty"`
+	TokenSecret    string `json:"tokenSecret,omitempty"`
+	// CertFile and KeyFile configure a TLS client certificate, e.g. for the RHEL CDN.
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+
+	clientOnce sync.Once
+	client     *http.Client
+	clientErr  error
+}
+
+// CredentialStore maps host names (optionally with port) to their credentials.
+type CredentialStore map[string]*Credentials
+
+var (
+	defaultCredentialsMu sync.RWMutex
+	defaultCredentials   CredentialStore
+)
+
+// SetDefaultCredentials sets the credentials used by all HTTPGetters without explicit credentials.
+func SetDefaultCredentials(store CredentialStore) {
+	defaultCredentialsMu.Lock()
+	defer defaultCredentialsMu.Unlock()
+	defaultCredentials = store
+}
+
+func getDefaultCredentials() CredentialStore {
+	defaultCredentialsMu.RLock()
+	defer defaultCredentialsMu.RUnlock()
+	return defaultCredentials
+}
+
+// LoadCredentials reads a JSON file mapping host names to credentials and resolves all secret files.
+func LoadCredentials(fileName string) (CredentialStore, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading credentials file: %v", err)
+	}
+
+	store := CredentialStore{}
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("error parsing credentials file %s: %v", fileName, err)
+	}
+
+	for host, creds := range store {
+		if err := creds.resolve(); err != nil {
+			return nil, fmt.Errorf("error loading credentials for %s: %v", host, err)
+		}
+	}
+
+	return store, nil
+}
+
+// CredentialsFromEnv loads the credentials file referenced by MEDIUS_HTTP_CREDENTIALS.
+// It returns an empty store if the variable is not set.
+func CredentialsFromEnv() (CredentialStore, error) {
+	fileName := os.Getenv(CredentialsFileEnv)
+	if fileName == "" {
+		return CredentialStore{}, nil
+	}
+	return LoadCredentials(fileName)
+}
+
+// Lookup returns the credentials of a host. Credentials configured for a host with port take precedence.
+func (s CredentialStore) Lookup(host string) *Credentials {
+	if creds, ok := s[host]; ok {
+		return creds
+	}
+	// SplitHostPort handles IPv6 literals like [2001:db8::1]:8443, which contain colons themselves
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		if creds, ok := s[hostname]; ok {
+			return creds
+		}
+		return s["["+hostname+"]"]
+	}
+	return nil
+}
+
+func (c *Credentials) resolve() error {
+	if c.PasswordFile != "" {
+		password, err := readSecret(c.PasswordFile)
+		if err != nil {
+			return err
+		}
+		c.Password = password
+	}
+	if c.TokenFile != "" {
+		token, err := readSecret(c.TokenFile)
+		if err != nil {
+			return err
+		}
+		c.Token = token
+	}
+	if c.PasswordSecret != "" {
+		password, err := secrets.Resolve(context.Background(), c.PasswordSecret)
+		if err != nil {
+			return err
+		}
+		c.Password = password
+	}
+	if c.TokenSecret != "" {
+		token, err := secrets.Resolve(context.Background(), c.TokenSecret)
+		if err != nil {
+			return err
+		}
+		c.Token = token
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("certFile and keyFile have to be set together")
+	}
+
+	return nil
+}
+
+func (c *Credentials) authorize(req *http.Request) {
+	switch {
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	case c.Username != "":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+}
+
+// httpClient returns a client presenting the configured client certificate. The client is created
+// once, so connections are reused between requests.
+func (c *Credentials) httpClient() (*http.Client, error) {
+	if c.CertFile == "" {
+		return http.DefaultClient, nil
+	}
+
+	c.clientOnce.Do(func() {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			c.clientErr = fmt.Errorf("error loading client certificate: %v", err)
+			return
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+		c.client = &http.Client{Transport: transport}
+	})
+
+	return c.client, c.clientErr
+}
+
+func readSecret(fileName string) (string, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return "", fmt.Errorf("error reading secret: %v", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Credentials", func() {
+	var (
+		server        *httptest.Server
+		authorization string
+		host          string
+	)
+
+	BeforeEach(func() {
+		authorization = ""
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			_, _ = w.Write([]byte("ok"))
+		}))
+		DeferCleanup(server.Close)
+
+		u, err := url.Parse(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		host = u.Host
+	})
+
+	It("should send basic auth to the configured host", func() {
+		getter := &HTTPGetter{Credentials: CredentialStore{host: {Username: "user", Password: "secret"}}}
+		_, err := getter.GetAll(server.URL + "/file")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authorization).To(Equal("Basic dXNlcjpzZWNyZXQ="))
+	})
+
+	It("should send bearer tokens to hosts matched without port", func() {
+		hostname, _, err := net.SplitHostPort(host)
+		Expect(err).ToNot(HaveOccurred())
+		getter := &HTTPGetter{Credentials: CredentialStore{hostname: {Token: "token"}}}
+		_, err = getter.GetAll(server.URL + "/file")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authorization).To(Equal("Bearer token"))
+	})
+
+	It("should not send credentials to other hosts", func() {
+		getter := &HTTPGetter{Credentials: CredentialStore{"mirror.example.com": {Token: "token"}}}
+		_, err := getter.GetAll(server.URL + "/file")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authorization).To(BeEmpty())
+	})
+
+	It("should fall back to the default credentials", func() {
+		SetDefaultCredentials(CredentialStore{host: {Token: "default"}})
+		DeferCleanup(SetDefaultCredentials, CredentialStore(nil))
+
+		_, err := (&HTTPGetter{}).GetAll(server.URL + "/file")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authorization).To(Equal("Bearer default"))
+	})
+
+	It("should load credentials and secrets from files", func() {
+		dir := GinkgoT().TempDir()
+		tokenFile := filepath.Join(dir, "token")
+		Expect(os.WriteFile(tokenFile, []byte("from-file\n"), 0o600)).To(Succeed())
+		credentialsFile := filepath.Join(dir, "credentials.json")
+		Expect(os.WriteFile(credentialsFile, []byte(`{"mirror.example.com": {"tokenFile": "`+tokenFile+`"}}`), 0o600)).To(Succeed())
+
+		GinkgoT().Setenv(CredentialsFileEnv, credentialsFile)
+		store, err := CredentialsFromEnv()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.Lookup("mirror.example.com").Token).To(Equal("from-file"))
+		Expect(store.Lookup("mirror.example.com:443").Token).To(Equal("from-file"))
+	})
+
+	It("should load secrets from secret stores", func() {
+		GinkgoT().Setenv("MEDIUS_TEST_PASSWORD", "from-store")
+		credentialsFile := filepath.Join(GinkgoT().TempDir(), "credentials.json")
+		Expect(os.WriteFile(credentialsFile,
+			[]byte(`{"mirror.example.com": {"username": "ci", "passwordSecret": "env:MEDIUS_TEST_PASSWORD"}}`), 0o600)).To(Succeed())
+
+		store, err := LoadCredentials(credentialsFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.Lookup("mirror.example.com").Password).To(Equal("from-store"))
+	})
+
+	It("Lookup should match IPv6 literals with port", func() {
+		store := CredentialStore{"[2001:db8::1]": {Token: "bracketed"}, "2001:db8::2": {Token: "plain"}}
+		Expect(store.Lookup("[2001:db8::1]:8443").Token).To(Equal("bracketed"))
+		Expect(store.Lookup("[2001:db8::2]:8443").Token).To(Equal("plain"))
+		Expect(store.Lookup("[2001:db8::3]:8443")).To(BeNil())
+	})
+
+	It("should reject a client certificate without key", func() {
+		credentialsFile := filepath.Join(GinkgoT().TempDir(), "credentials.json")
+		Expect(os.WriteFile(credentialsFile, []byte(`{"cdn.example.com": {"certFile": "cert.pem"}}`), 0o600)).To(Succeed())
+		_, err := LoadCredentials(credentialsFile)
+		Expect(err).To(MatchError(ContainSubstring("certFile and keyFile")))
+	})
+})
@@ -0,0 +1,204 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheOptions configure the cache of small upstream files like checksum files, which is shared by all getters.
+type CacheOptions struct {
+	// Dir persists cached files, so subsequent invocations, e.g. a canary followed by a publish run,
+	// revalidate them instead of loading them again. Files are only cached in memory if it is empty.
+	Dir string
+	// MaxAge is how long a cached file is used without asking upstream whether it changed, so all
+	// invocations within a pipeline run inspect the same upstream state.
+	MaxAge time.Duration
+}
+
+// SetCacheOptions configures the cache used by all getters.
+func SetCacheOptions(options CacheOptions) error {
+	if options.Dir != "" {
+		const permissionDir = 0o755
+		if err := os.MkdirAll(options.Dir, permissionDir); err != nil {
+			return fmt.Errorf("error creating HTTP cache directory: %v", err)
+		}
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.options = options
+	return nil
+}
+
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Body         []byte    `json:"body"`
+	Validated    time.Time `json:"validated"`
+	// Verification is the outcome of verifying the signature of the body, it is dropped with the body
+	// when upstream changes the file.
+	Verification *verification `json:"verification,omitempty"`
+}
+
+// verification records which keyring verified the signature of a cached file and which of its keys signed it.
+type verification struct {
+	Keyring string `json:"keyring"`
+	Signer  string `json:"signer"`
+}
+
+// conditionalCache remembers small responses with validators, so repeated inspection
+// of the same upstream files only costs a 304 Not Modified.
+type conditionalCache struct {
+	mu      sync.Mutex
+	options CacheOptions
+	entries map[string]*cacheEntry
+}
+
+// fresh returns the cached body of fileURL if it was validated less than MaxAge ago.
+func (c *conditionalCache) fresh(fileURL string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.lookup(fileURL)
+	if entry == nil || c.options.MaxAge <= 0 || time.Since(entry.Validated) >= c.options.MaxAge {
+		return nil, false
+	}
+	return entry.Body, true
+}
+
+func (c *conditionalCache) prepare(req *http.Request) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.lookup(req.URL.String())
+	if entry == nil {
+		return nil, false
+	}
+	if entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.LastModified)
+	}
+	return entry.Body, true
+}
+
+// revalidated records that upstream confirmed the cached file is unchanged.
+func (c *conditionalCache) revalidated(fileURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry := c.lookup(fileURL); entry != nil {
+		entry.Validated = time.Now()
+		c.persist(entry)
+	}
+}
+
+// verified returns whether the cached body of fileURL equals body and its signature was verified with the keyring.
+func (c *conditionalCache) verified(fileURL string, body []byte, keyring string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.lookup(fileURL)
+	return entry != nil && entry.Verification != nil && entry.Verification.Keyring == keyring && bytes.Equal(entry.Body, body)
+}
+
+// recordVerification records that the signature of the cached body of fileURL was verified with the keyring.
+func (c *conditionalCache) recordVerification(fileURL string, body []byte, keyring, signer string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry := c.lookup(fileURL); entry != nil && bytes.Equal(entry.Body, body) {
+		entry.Verification = &verification{Keyring: keyring, Signer: signer}
+		c.persist(entry)
+	}
+}
+
+func (c *conditionalCache) store(fileURL string, resp *http.Response, body []byte) {
+	// Large responses are not worth keeping in memory for the whole run
+	const maxCachedBodySize = 1 << 20
+	if len(body) > maxCachedBodySize {
+		return
+	}
+
+	entry := &cacheEntry{
+		URL:          fileURL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Body:         body,
+		Validated:    time.Now(),
+	}
+	if entry.ETag == "" && entry.LastModified == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[fileURL] = entry
+	c.persist(entry)
+}
+
+// lookup returns the entry of fileURL from memory or from the cache directory. c.mu has to be held.
+func (c *conditionalCache) lookup(fileURL string) *cacheEntry {
+	if entry, ok := c.entries[fileURL]; ok {
+		return entry
+	}
+	if c.options.Dir == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.fileName(fileURL))
+	if err != nil {
+		return nil
+	}
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil || entry.URL != fileURL {
+		return nil
+	}
+	c.entries[fileURL] = entry
+
+	return entry
+}
+
+// persist writes an entry to the cache directory. The cache only saves requests, so failing to
+// write it is not an error. c.mu has to be held.
+func (c *conditionalCache) persist(entry *cacheEntry) {
+	if c.options.Dir == "" {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(c.options.Dir, "entry-*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	// Renaming is atomic, so parallel invocations never read partially written entries
+	if err := os.Rename(tmp.Name(), c.fileName(entry.URL)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+func (c *conditionalCache) fileName(fileURL string) string {
+	sum := sha256.Sum256([]byte(fileURL))
+	return filepath.Join(c.options.Dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		server     *httptest.Server
+		requests   int
+		notChanged int
+		version    string
+	)
+
+	// newInvocation forgets all files cached in memory, like a new medius process
+	newInvocation := func() {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		cache.entries = map[string]*cacheEntry{}
+	}
+
+	BeforeEach(func() {
+		requests, notChanged, version = 0, 0, "v1"
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			etag := `"` + version + `"`
+			if r.Header.Get("If-None-Match") == etag {
+				notChanged++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			_, _ = w.Write([]byte("CHECKSUM " + version))
+		}))
+		DeferCleanup(server.Close)
+		DeferCleanup(func() {
+			Expect(SetCacheOptions(CacheOptions{})).To(Succeed())
+			newInvocation()
+		})
+	})
+
+	It("should revalidate files cached by previous invocations", func() {
+		Expect(SetCacheOptions(CacheOptions{Dir: GinkgoT().TempDir()})).To(Succeed())
+		_, err := (&HTTPGetter{}).GetAll(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+
+		newInvocation()
+		data, err := (&HTTPGetter{}).GetAll(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("CHECKSUM v1"))
+		Expect(notChanged).To(Equal(1))
+
+		version = "v2"
+		newInvocation()
+		data, err = (&HTTPGetter{}).GetAll(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("CHECKSUM v2"))
+	})
+
+	It("should not ask upstream again within the maximum age", func() {
+		Expect(SetCacheOptions(CacheOptions{Dir: GinkgoT().TempDir(), MaxAge: time.Hour})).To(Succeed())
+		_, err := (&HTTPGetter{}).GetAll(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+
+		version = "v2"
+		newInvocation()
+		data, err := (&HTTPGetter{}).GetAll(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("CHECKSUM v1"))
+		Expect(requests).To(Equal(1))
+	})
+
+	It("should revalidate files cached earlier in the same invocation", func() {
+		_, err := (&HTTPGetter{}).GetAll(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+
+		data, err := (&HTTPGetter{}).GetAll(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("CHECKSUM v1"))
+		Expect(notChanged).To(Equal(1))
+	})
+
+	It("should not use corrupt entries or entries of other files", func() {
+		dir := GinkgoT().TempDir()
+		Expect(SetCacheOptions(CacheOptions{Dir: dir, MaxAge: time.Hour})).To(Succeed())
+		fileURL := server.URL + "/CHECKSUM"
+		_, err := (&HTTPGetter{}).GetAll(fileURL)
+		Expect(err).ToNot(HaveOccurred())
+
+		version = "v2"
+		for _, entry := range []string{"{", `{"url":"` + server.URL + `/OTHER","etag":"\"v2\"","body":"Zm9yZ2Vk"}`} {
+			Expect(os.WriteFile(cache.fileName(fileURL), []byte(entry), 0o600)).To(Succeed())
+			newInvocation()
+			data, err := (&HTTPGetter{}).GetAll(fileURL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("CHECKSUM v2"))
+		}
+		Expect(notChanged).To(BeZero())
+	})
+
+	It("should not cache files without validators", func() {
+		plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests++
+			_, _ = w.Write([]byte("CHECKSUM"))
+		}))
+		defer plain.Close()
+		Expect(SetCacheOptions(CacheOptions{Dir: GinkgoT().TempDir(), MaxAge: time.Hour})).To(Succeed())
+
+		for range 2 {
+			_, err := (&HTTPGetter{}).GetAll(plain.URL + "/CHECKSUM")
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(requests).To(Equal(2))
+	})
+
+	It("should only keep files in memory without cache directory", func() {
+		_, err := (&HTTPGetter{}).GetAll(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+
+		newInvocation()
+		_, err = (&HTTPGetter{}).GetAll(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requests).To(Equal(2))
+		Expect(notChanged).To(BeZero())
+	})
+})
@@ -1,12 +1,17 @@
 package http
 
 import (
+	"cmp"
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
+	"net"
 	"net/http"
+
+	"kubevirt.io/containerdisks/pkg/bandwidth"
 )
 
 type Getter interface {
@@ -21,28 +26,69 @@ type ReadCloserWithChecksum interface {
 	Checksum() string
 }
 
-type HTTPGetter struct{}
+type HTTPGetter struct {
+	// Credentials authenticate requests to upstream hosts. If unset, the default credentials are used.
+	Credentials CredentialStore
+	// Politeness controls the User-Agent and request pacing. If unset, the default politeness is used.
+	Politeness *Politeness
+	// Overrides point upstream URLs to mirrors. If unset, the default overrides are used.
+	Overrides Overrides
+	// Retry controls how transiently failed requests are retried. If unset, the default retry is used.
+	Retry *Retry
+	// SizeLimits bound the sizes of responses. If unset, the default size limits are used.
+	SizeLimits *SizeLimits
+}
 
 func (h *HTTPGetter) GetAll(fileURL string) ([]byte, error) {
 	return h.GetAllWithContext(context.Background(), fileURL)
 }
 
 func (h *HTTPGetter) GetAllWithContext(ctx context.Context, fileURL string) ([]byte, error) {
+	if snapshot := getDefaultSnapshot(); snapshot.enabled() {
+		return h.getAllFromSnapshot(ctx, snapshot, fileURL)
+	}
+	if mirrorURL, ok := h.overrides().Rewrite(fileURL); ok {
+		return h.getAllFromMirror(ctx, fileURL, mirrorURL)
+	}
+	return h.getAll(ctx, fileURL)
+}
+
+func (h *HTTPGetter) getAll(ctx context.Context, fileURL string) ([]byte, error) {
+	if body, ok := cache.fresh(fileURL); ok {
+		return body, nil
+	}
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, http.NoBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request to load primary repository file from %s: %v", fileURL, err)
 	}
 
-	resp, err := http.DefaultClient.Do(req) //nolint:gosec // G704: request URL is controlled/trusted (not user input)
+	cached, isCached := cache.prepare(req)
+	resp, err := h.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load primary repository file from %s: %v", fileURL, err)
 	}
 	defer resp.Body.Close()
 
+	if isCached && resp.StatusCode == http.StatusNotModified {
+		cache.revalidated(fileURL)
+		return cached, nil
+	}
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
 		return nil, fmt.Errorf("failed to download %s: %v ", fileURL, fmt.Errorf("status : %v", resp.StatusCode))
 	}
-	return io.ReadAll(resp.Body)
+
+	maxFileSize := h.sizeLimits().MaxFileSize
+	if err := checkContentLength(resp.ContentLength, maxFileSize); err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", fileURL, err)
+	}
+	body, err := readAll(resp.Body, maxFileSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", fileURL, err)
+	}
+	cache.store(fileURL, resp, body)
+
+	return body, nil
 }
 
 func (h *HTTPGetter) GetWithChecksum(fileURL string, checksumHasher func() hash.Hash) (ReadCloserWithChecksum, error) {
@@ -53,21 +99,118 @@ func (h *HTTPGetter) GetWithChecksumAndContext(ctx context.Context, fileURL stri
 	readCloser ReadCloserWithChecksum,
 	err error,
 ) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, http.NoBody)
+	if snapshot := getDefaultSnapshot(); snapshot.enabled() {
+		return h.getWithChecksumFromSnapshot(ctx, snapshot, fileURL, checksumHasher)
+	}
+
+	// Disks downloaded from mirrors are verified against the checksum of the artifact
+	mirrorURL, _ := h.overrides().Rewrite(fileURL)
+	body, info, err := h.getFile(ctx, mirrorURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request to load primary repository file from %s: %v", fileURL, err)
+		return nil, err
+	}
+	withChecksum := newReadCloserWithChecksum(body, checksumHasher)
+	withChecksum.info = info
+	return withChecksum, nil
+}
+
+func (h *HTTPGetter) overrides() Overrides {
+	if h.Overrides != nil {
+		return h.Overrides
+	}
+	return getDefaultOverrides()
+}
+
+func (h *HTTPGetter) retry() Retry {
+	if h.Retry != nil {
+		return *h.Retry
+	}
+	return getDefaultRetry()
+}
+
+// do sends the request politely with the credentials configured for its host.
+func (h *HTTPGetter) do(req *http.Request) (*http.Response, error) {
+	politeness := h.Politeness
+	if politeness == nil {
+		defaultPoliteness := getDefaultPoliteness()
+		politeness = &defaultPoliteness
+	}
+	req.Header.Set("User-Agent", politeness.userAgent())
+	if err := politeness.wait(req); err != nil {
+		return nil, err
+	}
+
+	credentials := h.Credentials
+	if credentials == nil {
+		credentials = getDefaultCredentials()
 	}
 
-	resp, err := http.DefaultClient.Do(req) //nolint:gosec // G704: request URL is controlled/trusted (not user input)
+	client := http.DefaultClient
+	if creds := credentials.Lookup(req.URL.Host); creds != nil {
+		creds.authorize(req)
+
+		var err error
+		if client, err = creds.httpClient(); err != nil {
+			return nil, err
+		}
+	}
+
+	base := cmp.Or(client.Transport, http.DefaultTransport)
+	if len(getDefaultPins()) > 0 {
+		var err error
+		if base, err = newPinnedTransport(base); err != nil {
+			return nil, err
+		}
+	}
+
+	redirectingClient := *client
+	redirectingClient.CheckRedirect = checkRedirect(politeness, credentials)
+	retrying := &retryTransport{
+		base:        base,
+		retry:       h.retry(),
+		beforeRetry: politeness.wait,
+	}
+	fallbackURL, hasFallback := h.overrides().Fallback(req.URL.String())
+	if _, hasMirror := h.overrides().Rewrite(req.URL.String()); hasMirror || hasFallback {
+		// Mirrors are used right away instead of an upstream which can not be reached
+		retrying.permanent = unreachable
+	}
+	redirectingClient.Transport = retrying
+	resp, err := redirectingClient.Do(req) //nolint:gosec // G704: request URL is controlled/trusted (not user input)
+	if err != nil && unreachable(err) && hasFallback {
+		return h.doFallback(req, fallbackURL)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to load primary repository file from %s: %v", fileURL, err)
+		return nil, err
 	}
+	// The limits of the host the redirects ended at apply
+	resp.Body = bandwidth.Reader(req.Context(), resp.Request.URL.Host, resp.Body)
+	return resp, nil
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		resp.Body.Close()
-		return nil, fmt.Errorf("failed to download %s: %v ", fileURL, fmt.Errorf("status : %v", resp.StatusCode))
+// doFallback sends the request to the fallback mirror of its URL. The fallback mirror itself has no fallback.
+func (h *HTTPGetter) doFallback(req *http.Request, fallbackURL string) (*http.Response, error) {
+	fallbackReq, err := http.NewRequestWithContext(req.Context(), req.Method, fallbackURL, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range req.Header {
+		// Credentials are looked up for the host of the mirror
+		if key != "Authorization" {
+			fallbackReq.Header[key] = values
+		}
 	}
-	return newReadCloserWithChecksum(resp.Body, checksumHasher), nil
+	fallback := *h
+	fallback.Overrides = Overrides{}
+	return fallback.do(fallbackReq)
+}
+
+// unreachable returns whether err means that the host could not be connected to at all, e.g. because it has
+// no address of the IP family of the network or no route to it.
+func unreachable(err error) bool {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	return errors.As(err, &dnsErr) || (errors.As(err, &opErr) && opErr.Op == "dial")
 }
 
 func newReadCloserWithChecksum(body io.ReadCloser, checksumHasher func() hash.Hash) *readCloserWithChecksum {
@@ -85,6 +228,7 @@ type readCloserWithChecksum struct {
 	body         io.ReadCloser
 	teeReader    io.Reader
 	checksumHash hash.Hash
+	info         *ResponseInfo
 }
 
 func (r *readCloserWithChecksum) Read(p []byte) (n int, err error) {
@@ -98,3 +242,8 @@ func (r *readCloserWithChecksum) Close() error {
 func (r *readCloserWithChecksum) Checksum() string {
 	return hex.EncodeToString(r.checksumHash.Sum(nil))
 }
+
+// ResponseInfo returns which server delivered the body or nil if it was not downloaded.
+func (r *readCloserWithChecksum) ResponseInfo() *ResponseInfo {
+	return r.info
+}
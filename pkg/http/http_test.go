@@ -0,0 +1,88 @@
+package http
+
+import (
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RecordingGetter", func() {
+	var (
+		server   *httptest.Server
+		requests int
+		dir      string
+	)
+
+	BeforeEach(func() {
+		requests = 0
+		dir = GinkgoT().TempDir()
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			_, _ = w.Write([]byte("content of " + r.URL.Path))
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("should record and replay responses", func() {
+		recorder := NewRecordingGetter(dir, RecordModeRecord, nil)
+		data, err := recorder.GetAll(server.URL + "/releases/SHA256SUMS")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("content of /releases/SHA256SUMS"))
+
+		recordingPath, err := recorder.RecordingPath(server.URL + "/releases/SHA256SUMS")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recordingPath).To(HavePrefix(dir))
+		Expect(recordingPath).To(HaveSuffix(filepath.Join("releases", "SHA256SUMS")))
+		// host:port must not end up in the file name, colons are not allowed on Windows
+		Expect(strings.TrimPrefix(recordingPath, dir)).ToNot(ContainSubstring(":"))
+
+		replayer := NewRecordingGetter(dir, RecordModeReplay, nil)
+		data, err = replayer.GetAll(server.URL + "/releases/SHA256SUMS")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("content of /releases/SHA256SUMS"))
+		Expect(requests).To(Equal(1))
+
+		reader, err := replayer.GetWithChecksum(server.URL+"/releases/SHA256SUMS", sha256.New)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reader.Checksum()).To(HaveLen(64))
+		Expect(requests).To(Equal(1))
+	})
+
+	It("should store directory listings as index.html", func() {
+		recorder := NewRecordingGetter(dir, RecordModeRecord, nil)
+		_, err := recorder.GetAll(server.URL + "/releases/")
+		Expect(err).ToNot(HaveOccurred())
+		recordingPath, err := recorder.RecordingPath(server.URL + "/releases/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recordingPath).To(HaveSuffix(filepath.Join("releases", "index.html")))
+		Expect(recordingPath).To(BeAnExistingFile())
+	})
+
+	It("should fail replaying missing recordings", func() {
+		_, err := NewRecordingGetter(dir, RecordModeReplay, nil).GetAll(server.URL + "/missing")
+		Expect(err).To(MatchError(ContainSubstring(RecordEnv)))
+		Expect(requests).To(BeZero())
+	})
+
+	It("should select the mode from the environment", func() {
+		Expect(os.Unsetenv(RecordEnv)).To(Succeed())
+		Expect(RecordModeFromEnv()).To(Equal(RecordModeReplay))
+		GinkgoT().Setenv(RecordEnv, "true")
+		Expect(RecordModeFromEnv()).To(Equal(RecordModeRecord))
+	})
+})
+
+func TestHTTP(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "HTTP Suite")
+}
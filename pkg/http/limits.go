@@ -0,0 +1,183 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// SizeLimits bound the data read from upstream, so a misbehaving or malicious mirror can not exhaust the memory
+// or the disk of the pipeline. Zero values are unlimited.
+type SizeLimits struct {
+	// MaxFileSize is the maximum size of the files read into memory, e.g. checksum files, release metadata and
+	// directory listings.
+	MaxFileSize int64
+	// MaxDownloadSize is the maximum size of a downloaded image, before it is decompressed.
+	MaxDownloadSize int64
+	// MaxDecompressedSize is the maximum size of a decompressed image.
+	MaxDecompressedSize int64
+	// MaxCompressionRatio is the maximum ratio of the decompressed to the compressed size of an image. gzip can
+	// not exceed a ratio of about 1000, while xz compresses the zeroed blocks of sparse raw disks much better.
+	MaxCompressionRatio int64
+}
+
+// DefaultSizeLimits leave room for the largest images published so far.
+var DefaultSizeLimits = SizeLimits{
+	MaxFileSize:         64 << 20,
+	MaxDownloadSize:     64 << 30,
+	MaxDecompressedSize: 256 << 30,
+	MaxCompressionRatio: 10000,
+}
+
+// minCheckedDecompressedSize is the decompressed size below which the compression ratio is not checked yet, as
+// the first blocks of a stream say little about the ratio of the whole image.
+const minCheckedDecompressedSize = 64 << 20
+
+var (
+	defaultSizeLimitsMu sync.RWMutex
+	defaultSizeLimits   = DefaultSizeLimits
+)
+
+// ParseSizeLimits parses the limits from quantities like 64Mi, empty quantities are unlimited.
+func ParseSizeLimits(maxFileSize, maxDownloadSize, maxDecompressedSize string, maxCompressionRatio int64) (SizeLimits, error) {
+	limits := SizeLimits{MaxCompressionRatio: maxCompressionRatio}
+	for _, limit := range []struct {
+		name  string
+		value string
+		size  *int64
+	}{
+		{"file size", maxFileSize, &limits.MaxFileSize},
+		{"download size", maxDownloadSize, &limits.MaxDownloadSize},
+		{"decompressed size", maxDecompressedSize, &limits.MaxDecompressedSize},
+	} {
+		if limit.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(limit.value)
+		if err != nil {
+			return SizeLimits{}, fmt.Errorf("invalid maximum %s %q: %v", limit.name, limit.value, err)
+		}
+		*limit.size = quantity.Value()
+	}
+	return limits, nil
+}
+
+// SetDefaultSizeLimits sets the size limits of all HTTPGetters without explicit size limits.
+func SetDefaultSizeLimits(limits SizeLimits) {
+	defaultSizeLimitsMu.Lock()
+	defer defaultSizeLimitsMu.Unlock()
+	defaultSizeLimits = limits
+}
+
+func getDefaultSizeLimits() SizeLimits {
+	defaultSizeLimitsMu.RLock()
+	defer defaultSizeLimitsMu.RUnlock()
+	return defaultSizeLimits
+}
+
+func (h *HTTPGetter) sizeLimits() SizeLimits {
+	if h.SizeLimits != nil {
+		return *h.SizeLimits
+	}
+	return getDefaultSizeLimits()
+}
+
+// checkContentLength fails early if the announced size of a response exceeds maxSize.
+func checkContentLength(contentLength, maxSize int64) error {
+	if maxSize > 0 && contentLength > maxSize {
+		return fmt.Errorf("response of %d bytes exceeds the maximum size of %d bytes", contentLength, maxSize)
+	}
+	return nil
+}
+
+// readAll reads the body into memory, unless it exceeds maxSize.
+func readAll(body io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("response exceeds the maximum size of %d bytes", maxSize)
+	}
+	return data, nil
+}
+
+// sizeLimitedReadCloser fails once more than maxSize bytes were read, servers may not announce the size.
+type sizeLimitedReadCloser struct {
+	io.ReadCloser
+	maxSize int64
+	read    int64
+}
+
+func (s *sizeLimitedReadCloser) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	s.read += int64(n)
+	if s.read > s.maxSize {
+		return n, fmt.Errorf("download exceeds the maximum size of %d bytes", s.maxSize)
+	}
+	return n, err
+}
+
+// DecompressionGuard bounds the data decompressed from a download by the default size limits, so a
+// decompression bomb fails instead of filling the disk.
+type DecompressionGuard struct {
+	limits       SizeLimits
+	compressed   int64
+	decompressed int64
+}
+
+func NewDecompressionGuard() *DecompressionGuard {
+	return &DecompressionGuard{limits: getDefaultSizeLimits()}
+}
+
+// Compressed counts the data read from the compressed stream r.
+func (g *DecompressionGuard) Compressed(r io.Reader) io.Reader {
+	return &countingReader{reader: r, count: &g.compressed}
+}
+
+// Decompressed fails reading from the decompressed stream r once it exceeds the limits.
+func (g *DecompressionGuard) Decompressed(r io.Reader) io.Reader {
+	return &decompressedReader{reader: r, guard: g}
+}
+
+func (g *DecompressionGuard) check() error {
+	if g.limits.MaxDecompressedSize > 0 && g.decompressed > g.limits.MaxDecompressedSize {
+		return fmt.Errorf("decompressed image exceeds the maximum size of %d bytes", g.limits.MaxDecompressedSize)
+	}
+	if g.limits.MaxCompressionRatio > 0 && g.decompressed > minCheckedDecompressedSize &&
+		g.decompressed > g.limits.MaxCompressionRatio*max(g.compressed, 1) {
+		return fmt.Errorf("image decompressed from %d to %d bytes, exceeding the maximum compression ratio of %d",
+			g.compressed, g.decompressed, g.limits.MaxCompressionRatio)
+	}
+	return nil
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  *int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	*c.count += int64(n)
+	return n, err
+}
+
+type decompressedReader struct {
+	reader io.Reader
+	guard  *DecompressionGuard
+}
+
+func (d *decompressedReader) Read(p []byte) (int, error) {
+	n, err := d.reader.Read(p)
+	d.guard.decompressed += int64(n)
+	if checkErr := d.guard.check(); checkErr != nil {
+		return n, checkErr
+	}
+	return n, err
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// zeros is a reader of endless zero bytes.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+var _ = Describe("SizeLimits", func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/chunked" {
+				// Flushing before writing the body makes the server omit the Content-Length
+				w.(http.Flusher).Flush()
+			}
+			_, _ = w.Write([]byte(strings.Repeat("a", 1024)))
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	getter := func(limits SizeLimits) *HTTPGetter {
+		return &HTTPGetter{SizeLimits: &limits, Retry: &Retry{}}
+	}
+
+	DescribeTable("should reject files exceeding the maximum file size",
+		func(path string) {
+			_, err := getter(SizeLimits{MaxFileSize: 1023}).GetAll(server.URL + path)
+			Expect(err).To(MatchError(ContainSubstring("exceeds the maximum size of 1023 bytes")))
+
+			data, err := getter(SizeLimits{MaxFileSize: 1024}).GetAll(server.URL + path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(HaveLen(1024))
+		},
+		Entry("with a Content-Length", "/file"),
+		Entry("without a Content-Length", "/chunked"),
+	)
+
+	DescribeTable("should reject downloads exceeding the maximum download size",
+		func(path string) {
+			reader, err := getter(SizeLimits{MaxDownloadSize: 1000}).GetWithChecksum(server.URL+path, sha256.New)
+			if err == nil {
+				defer reader.Close()
+				_, err = io.ReadAll(reader)
+			}
+			Expect(err).To(MatchError(ContainSubstring("exceeds the maximum size of 1000 bytes")))
+		},
+		Entry("with a Content-Length", "/file"),
+		Entry("without a Content-Length", "/chunked"),
+	)
+
+	It("should parse size limits", func() {
+		limits, err := ParseSizeLimits("1Mi", "", "2Gi", 10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(limits).To(Equal(SizeLimits{MaxFileSize: 1 << 20, MaxDecompressedSize: 2 << 30, MaxCompressionRatio: 10}))
+
+		_, err = ParseSizeLimits("many", "", "", 0)
+		Expect(err).To(MatchError(ContainSubstring(`invalid maximum file size "many"`)))
+	})
+})
+
+var _ = Describe("DecompressionGuard", func() {
+	compressedZeros := func(size int64) []byte {
+		buf := &bytes.Buffer{}
+		writer := gzip.NewWriter(buf)
+		_, err := io.CopyN(writer, zeros{}, size)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(writer.Close()).To(Succeed())
+		return buf.Bytes()
+	}
+
+	decompress := func(guard *DecompressionGuard, data []byte) (int64, error) {
+		reader, err := gzip.NewReader(guard.Compressed(bytes.NewReader(data)))
+		Expect(err).ToNot(HaveOccurred())
+		return io.Copy(io.Discard, guard.Decompressed(reader))
+	}
+
+	It("should stop decompressing at the maximum decompressed size", func() {
+		guard := &DecompressionGuard{limits: SizeLimits{MaxDecompressedSize: 1 << 20}}
+		_, err := decompress(guard, compressedZeros(2<<20))
+		Expect(err).To(MatchError("decompressed image exceeds the maximum size of 1048576 bytes"))
+	})
+
+	It("should stop decompressing bombs exceeding the maximum compression ratio", func() {
+		guard := &DecompressionGuard{limits: SizeLimits{MaxCompressionRatio: 100}}
+		_, err := decompress(guard, compressedZeros(2*minCheckedDecompressedSize))
+		Expect(err).To(MatchError(ContainSubstring("exceeding the maximum compression ratio of 100")))
+	})
+
+	It("should decompress images within the limits", func() {
+		guard := &DecompressionGuard{limits: DefaultSizeLimits}
+		n, err := decompress(guard, compressedZeros(2*minCheckedDecompressedSize))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(int64(2 * minCheckedDecompressedSize)))
+	})
+})
@@ -0,0 +1,229 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"kubevirt.io/containerdisks/pkg/version"
+)
+
+// ListingEntry is a file or directory of a directory listing.
+type ListingEntry struct {
+	// Name is the unescaped name of the entry, without the trailing slash of directories.
+	Name string
+	// URL is the absolute URL of the entry.
+	URL string
+	Dir bool
+	// Modified is the modification time shown in the listing or zero if it is unknown.
+	Modified time.Time
+	// Size is the size shown in the listing, which is rounded by most servers, or -1 if it is unknown.
+	Size int64
+}
+
+// listingTimeFormats are the modification time formats of Apache, Nginx and lighttpd autoindex pages.
+var listingTimeFormats = []struct {
+	rex    *regexp.Regexp
+	layout string
+}{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}`), "2006-01-02 15:04:05"},
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2} \d{2}:\d{2}`), "2006-01-02 15:04"},
+	{regexp.MustCompile(`\d{2}-[A-Z][a-z]{2}-\d{4} \d{2}:\d{2}`), "02-Jan-2006 15:04"},
+	{regexp.MustCompile(`\d{4}-[A-Z][a-z]{2}-\d{2} \d{2}:\d{2}:\d{2}`), "2006-Jan-02 15:04:05"},
+}
+
+var listingSizeRex = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([KMGT]?)(?:i?B)?$`)
+
+// listingSizeUnits are the binary units listings abbreviate sizes with.
+var listingSizeUnits = map[string]float64{"": 1, "K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40}
+
+// List loads the directory listing at dirURL and returns its entries in the order of the listing.
+// It understands the autoindex pages of Apache, Nginx and lighttpd, as well as JSON indexes as served by
+// Nginx with autoindex_format json. Links to parent directories, sorting links and other pages are left out.
+func List(ctx context.Context, getter Getter, dirURL string) ([]ListingEntry, error) {
+	if !strings.HasSuffix(dirURL, "/") {
+		dirURL += "/"
+	}
+	base, err := url.Parse(dirURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing url %s: %v", dirURL, err)
+	}
+
+	raw, err := getter.GetAllWithContext(ctx, dirURL)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the directory listing %s: %v", dirURL, err)
+	}
+
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		return parseJSONListing(base, trimmed)
+	}
+	return parseHTMLListing(base, raw)
+}
+
+type jsonListingEntry struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	MTime string `json:"mtime"`
+	Size  *int64 `json:"size"`
+}
+
+func parseJSONListing(base *url.URL, raw []byte) ([]ListingEntry, error) {
+	var index []jsonListingEntry
+	if err := json.Unmarshal(raw, &index); err != nil {
+		return nil, fmt.Errorf("error parsing the directory listing %s: %v", base, err)
+	}
+
+	entries := make([]ListingEntry, 0, len(index))
+	for _, e := range index {
+		dir := e.Type == "directory"
+		href := url.PathEscape(e.Name)
+		if dir {
+			href += "/"
+		}
+		entry, ok := listingEntry(base, href)
+		if !ok {
+			continue
+		}
+		if modified, err := time.Parse(time.RFC1123, e.MTime); err == nil {
+			entry.Modified = modified
+		}
+		if e.Size != nil {
+			entry.Size = *e.Size
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// parseHTMLListing collects the links to entries of the directory. The text following a link, up to the
+// next link or the end of the table row, holds the modification time and the size of the entry.
+func parseHTMLListing(base *url.URL, raw []byte) ([]ListingEntry, error) {
+	var entries []ListingEntry
+	var details strings.Builder
+	current := -1
+	finish := func() {
+		if current >= 0 {
+			parseListingDetails(&entries[current], details.String())
+		}
+		current = -1
+		details.Reset()
+	}
+
+	tokenizer := html.NewTokenizer(bytes.NewReader(raw))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			finish()
+			return entries, nil
+		case html.StartTagToken:
+			token := tokenizer.Token()
+			if token.DataAtom != atom.A {
+				continue
+			}
+			href := attribute(token, "href")
+			entry, ok := listingEntry(base, href)
+			if current >= 0 && ok && entries[current].URL == entry.URL {
+				// Icon and name link of the same entry
+				continue
+			}
+			finish()
+			if ok && !slices.ContainsFunc(entries, func(e ListingEntry) bool { return e.URL == entry.URL }) {
+				entries = append(entries, entry)
+				current = len(entries) - 1
+			}
+		case html.EndTagToken:
+			if tokenizer.Token().DataAtom == atom.Tr {
+				finish()
+			}
+		case html.TextToken:
+			if current >= 0 {
+				details.Write(tokenizer.Text())
+				details.WriteByte(' ')
+			}
+		}
+	}
+}
+
+func attribute(token html.Token, name string) string {
+	for _, attr := range token.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// listingEntry returns the entry a link points to, if it is an entry of the directory.
+func listingEntry(base *url.URL, href string) (ListingEntry, bool) {
+	if href == "" || strings.ContainsAny(href, "?#") {
+		return ListingEntry{}, false
+	}
+	target, err := base.Parse(href)
+	if err != nil || target.Scheme != base.Scheme || target.Host != base.Host {
+		return ListingEntry{}, false
+	}
+
+	rest, ok := strings.CutPrefix(target.Path, base.Path)
+	dir := strings.HasSuffix(rest, "/")
+	name := strings.TrimSuffix(rest, "/")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return ListingEntry{}, false
+	}
+
+	return ListingEntry{Name: name, URL: target.String(), Dir: dir, Size: -1}, true
+}
+
+func parseListingDetails(entry *ListingEntry, details string) {
+	for _, format := range listingTimeFormats {
+		if match := format.rex.FindString(details); match != "" {
+			if modified, err := time.Parse(format.layout, match); err == nil {
+				entry.Modified = modified
+				details = strings.Replace(details, match, " ", 1)
+				break
+			}
+		}
+	}
+
+	fields := strings.Fields(details)
+	if len(fields) == 0 {
+		return
+	}
+	// Sizes may be separated from their unit, e.g. "12.3 MiB"
+	size := fields[len(fields)-1]
+	if len(fields) > 1 && !strings.ContainsAny(size[:1], "0123456789") {
+		size = fields[len(fields)-2] + size
+	}
+	if matches := listingSizeRex.FindStringSubmatch(size); matches != nil {
+		value, err := strconv.ParseFloat(matches[1], 64)
+		if err != nil {
+			return
+		}
+		value *= listingSizeUnits[matches[2]]
+		entry.Size = int64(value)
+	}
+}
+
+// SortListingByVersion sorts entries by the versions in their names, newest first, e.g. 15.10 before 15.9
+// and Snapshot20240629 before Snapshot20240115.
+func SortListingByVersion(entries []ListingEntry) {
+	slices.SortStableFunc(entries, func(a, b ListingEntry) int {
+		return version.Compare(b.Name, a.Name)
+	})
+}
+
+// SortListingByModified sorts entries by their modification time, newest first.
+func SortListingByModified(entries []ListingEntry) {
+	slices.SortStableFunc(entries, func(a, b ListingEntry) int {
+		return b.Modified.Compare(a.Modified)
+	})
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Listing", func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		listings := map[string]string{
+			"/appliances/": "apache.html",
+			"/composes/":   "nginx.html",
+			"/leap/":       "lighttpd.html",
+			"/arch/":       "index.json",
+		}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			listing, ok := listings[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			http.ServeFile(w, r, filepath.Join("testdata", "listings", listing))
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	date := func(layout, value string) time.Time {
+		t, err := time.Parse(layout, value)
+		Expect(err).ToNot(HaveOccurred())
+		return t
+	}
+
+	It("should parse Apache autoindex tables", func() {
+		entries, err := List(context.Background(), &HTTPGetter{}, server.URL+"/appliances")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(Equal([]ListingEntry{
+			{Name: "iso", URL: server.URL + "/appliances/iso/", Dir: true, Modified: date(time.DateTime, "2024-06-29 10:00:00"), Size: -1},
+			{
+				Name: "disk-Snapshot20240629.qcow2", URL: server.URL + "/appliances/disk-Snapshot20240629.qcow2",
+				Modified: date(time.DateTime, "2024-06-29 12:30:00"), Size: 1610612736,
+			},
+			{
+				Name: "disk old.qcow2", URL: server.URL + "/appliances/disk%20old.qcow2",
+				Modified: date(time.DateTime, "2024-01-15 08:00:00"), Size: 524288,
+			},
+		}))
+	})
+
+	It("should parse Nginx autoindex pages", func() {
+		entries, err := List(context.Background(), &HTTPGetter{}, server.URL+"/composes/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(3))
+		Expect(entries[0].Name).To(Equal("CentOS-Stream-9-20240115.0"))
+		Expect(entries[0].Dir).To(BeTrue())
+		Expect(entries[0].Modified).To(Equal(date(time.DateTime, "2024-01-15 08:00:00")))
+		Expect(entries[2].Name).To(Equal("CentOS-Stream-9-20240629.0-with-a-very-long-name.qcow2"))
+		Expect(entries[2].Size).To(Equal(int64(1073741824)))
+
+		SortListingByModified(entries)
+		Expect(entries[0].Name).To(Equal("CentOS-Stream-9-20240629.0-with-a-very-long-name.qcow2"))
+		Expect(entries[2].Name).To(Equal("CentOS-Stream-9-20240115.0"))
+	})
+
+	It("should parse lighttpd listings and sort them by version", func() {
+		entries, err := List(context.Background(), &HTTPGetter{}, server.URL+"/leap/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(4))
+		Expect(entries[3].Size).To(Equal(int64(12595)))
+		Expect(entries[0].Modified).To(Equal(date(time.DateTime, "2024-06-12 10:00:00")))
+
+		SortListingByVersion(entries)
+		names := make([]string, 0, len(entries))
+		for _, e := range entries {
+			names = append(names, e.Name)
+		}
+		Expect(names).To(Equal([]string{"15.10", "15.9", "15.6", "SHA256SUMS"}))
+	})
+
+	It("should parse JSON indexes", func() {
+		entries, err := List(context.Background(), &HTTPGetter{}, server.URL+"/arch/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(Equal([]ListingEntry{
+			{
+				Name: "Arch-Linux-x86_64-cloudimg.qcow2", URL: server.URL + "/arch/Arch-Linux-x86_64-cloudimg.qcow2",
+				Modified: date(time.RFC1123, "Sat, 29 Jun 2024 12:30:00 GMT"), Size: 536870912,
+			},
+			{
+				Name: "20240629.245698", URL: server.URL + "/arch/20240629.245698/", Dir: true,
+				Modified: date(time.RFC1123, "Sat, 29 Jun 2024 12:00:00 GMT"), Size: -1,
+			},
+		}))
+	})
+
+	It("should fail for missing listings", func() {
+		_, err := List(context.Background(), &HTTPGetter{}, server.URL+"/missing/")
+		Expect(err).To(MatchError(ContainSubstring("error loading the directory listing")))
+	})
+})
@@ -0,0 +1,187 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// MetalinkSuffix is appended to a download URL to request its Metalink (RFC 5854).
+	// It is supported by the MirrorBrain and MirrorCache redirectors of e.g. openSUSE.
+	MetalinkSuffix = ".meta4"
+	// DefaultMaxMirrors is the number of mirrors of a Metalink which are tried before giving up.
+	DefaultMaxMirrors = 5
+
+	metalinkHashSHA256 = "sha-256"
+)
+
+// Metalink lists the mirrors and hashes of files.
+type Metalink struct {
+	Files []MetalinkFile `xml:"file"`
+}
+
+type MetalinkFile struct {
+	Name   string         `xml:"name,attr"`
+	Size   int64          `xml:"size"`
+	Hashes []MetalinkHash `xml:"hash"`
+	URLs   []MetalinkURL  `xml:"url"`
+	// MetaURLs point to e.g. torrents of the file. They are not used, as fetching from peers
+	// is neither predictable in time nor possible from most CI environments.
+	MetaURLs []MetalinkURL `xml:"metaurl"`
+}
+
+type MetalinkHash struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type MetalinkURL struct {
+	// Priority of the URL, lower values are preferred.
+	Priority  int    `xml:"priority,attr"`
+	MediaType string `xml:"mediatype,attr"`
+	URL       string `xml:",chardata"`
+}
+
+func ParseMetalink(data []byte) (*Metalink, error) {
+	metalink := &Metalink{}
+	if err := xml.Unmarshal(data, metalink); err != nil {
+		return nil, fmt.Errorf("error parsing metalink: %v", err)
+	}
+	return metalink, nil
+}
+
+// File returns the file with the given name, or the only file of the Metalink.
+func (m *Metalink) File(name string) (*MetalinkFile, error) {
+	for i := range m.Files {
+		if m.Files[i].Name == name {
+			return &m.Files[i], nil
+		}
+	}
+	if len(m.Files) == 1 {
+		return &m.Files[0], nil
+	}
+	return nil, fmt.Errorf("metalink contains no file %s", name)
+}
+
+// Mirrors returns the URLs of the file ordered by priority.
+func (f *MetalinkFile) Mirrors() []string {
+	urls := make([]MetalinkURL, len(f.URLs))
+	copy(urls, f.URLs)
+	sort.SliceStable(urls, func(i, j int) bool {
+		return urls[i].Priority < urls[j].Priority
+	})
+
+	mirrors := make([]string, 0, len(urls))
+	for _, u := range urls {
+		mirrors = append(mirrors, strings.TrimSpace(u.URL))
+	}
+	return mirrors
+}
+
+// Hash returns the hex encoded hash of the given type, e.g. sha-256, or an empty string.
+func (f *MetalinkFile) Hash(hashType string) string {
+	for _, h := range f.Hashes {
+		if strings.EqualFold(h.Type, hashType) {
+			return strings.ToLower(strings.TrimSpace(h.Value))
+		}
+	}
+	return ""
+}
+
+// MetalinkGetter downloads files from the mirrors listed in their Metalink to spread the load
+// of large downloads across mirrors. Files without Metalink are downloaded directly. Downloads
+// from mirrors are verified against the sha-256 hash of the Metalink.
+type MetalinkGetter struct {
+	Getter
+	// MaxMirrors is the number of mirrors tried before falling back to the original URL.
+	MaxMirrors int
+}
+
+func NewMetalinkGetter(upstream Getter) *MetalinkGetter {
+	return &MetalinkGetter{Getter: upstream, MaxMirrors: DefaultMaxMirrors}
+}
+
+func (m *MetalinkGetter) GetWithChecksum(fileURL string, checksumHasher func() hash.Hash) (ReadCloserWithChecksum, error) {
+	return m.GetWithChecksumAndContext(context.Background(), fileURL, checksumHasher)
+}
+
+func (m *MetalinkGetter) GetWithChecksumAndContext(ctx context.Context, fileURL string, checksumHasher func() hash.Hash) (
+	ReadCloserWithChecksum, error,
+) {
+	file, err := m.metalinkFile(ctx, fileURL)
+	if err != nil {
+		return m.Getter.GetWithChecksumAndContext(ctx, fileURL, checksumHasher)
+	}
+
+	var errs []error
+	for i, mirror := range file.Mirrors() {
+		if i >= m.MaxMirrors || errors.Is(ctx.Err(), context.Canceled) {
+			break
+		}
+		reader, err := m.Getter.GetWithChecksumAndContext(ctx, mirror, checksumHasher)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if expected := file.Hash(metalinkHashSHA256); expected != "" {
+			return newVerifyingReader(reader, sha256.New(), expected, mirror), nil
+		}
+		return reader, nil
+	}
+
+	reader, err := m.Getter.GetWithChecksumAndContext(ctx, fileURL, checksumHasher)
+	if err != nil {
+		return nil, errors.Join(append(errs, err)...)
+	}
+	return reader, nil
+}
+
+func (m *MetalinkGetter) metalinkFile(ctx context.Context, fileURL string) (*MetalinkFile, error) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := m.Getter.GetAllWithContext(ctx, fileURL+MetalinkSuffix)
+	if err != nil {
+		return nil, err
+	}
+	metalink, err := ParseMetalink(data)
+	if err != nil {
+		return nil, err
+	}
+	return metalink.File(path.Base(u.Path))
+}
+
+// verifyingReader fails the last read if the content does not match the expected hash.
+type verifyingReader struct {
+	ReadCloserWithChecksum
+	hash     hash.Hash
+	expected string
+	source   string
+}
+
+func newVerifyingReader(reader ReadCloserWithChecksum, h hash.Hash, expected, source string) *verifyingReader {
+	return &verifyingReader{ReadCloserWithChecksum: reader, hash: h, expected: expected, source: source}
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloserWithChecksum.Read(p)
+	r.hash.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		if actual := hex.EncodeToString(r.hash.Sum(nil)); actual != r.expected {
+			return n, fmt.Errorf("content of %s does not match the metalink hash: expected %s, got %s", r.source, r.expected, actual)
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MetalinkGetter", func() {
+	const (
+		content = "disk"
+		// sha256 of "disk"
+		contentHash = "1044dec7206e8d7c9fbb4ae8f766668406d2567fc7fc1a160a9d4700fcf8f8e9"
+	)
+
+	var (
+		server   *httptest.Server
+		metalink string
+		requests []string
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		metalink = ""
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.URL.Path)
+			switch r.URL.Path {
+			case "/images/disk.img.meta4":
+				if metalink == "" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write([]byte(metalink))
+			case "/images/disk.img", "/mirror2/disk.img":
+				_, _ = w.Write([]byte(content))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	newMetalink := func(hash string) string {
+		return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<metalink xmlns="urn:ietf:params:xml:ns:metalink">
+  <file name="disk.img">
+    <size>4</size>
+    <hash type="sha-256">%s</hash>
+    <url priority="2">%s/mirror2/disk.img</url>
+    <url priority="1">%s/mirror1/disk.img</url>
+    <metaurl mediatype="torrent">%s/images/disk.img.torrent</metaurl>
+  </file>
+</metalink>`, hash, server.URL, server.URL, server.URL)
+	}
+
+	read := func(getter *MetalinkGetter) (string, error) {
+		reader, err := getter.GetWithChecksum(server.URL+"/images/disk.img", sha256.New)
+		Expect(err).ToNot(HaveOccurred())
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		return string(data), err
+	}
+
+	It("should download from the mirrors in order of priority", func() {
+		metalink = newMetalink(contentHash)
+		data, err := read(NewMetalinkGetter(&HTTPGetter{}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(content))
+		Expect(requests).To(Equal([]string{"/images/disk.img.meta4", "/mirror1/disk.img", "/mirror2/disk.img"}))
+	})
+
+	It("should fail if a mirror serves content not matching the metalink hash", func() {
+		metalink = newMetalink("0000")
+		_, err := read(NewMetalinkGetter(&HTTPGetter{}))
+		Expect(err).To(MatchError(ContainSubstring("does not match the metalink hash")))
+	})
+
+	It("should download directly without metalink", func() {
+		data, err := read(NewMetalinkGetter(&HTTPGetter{}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(content))
+		Expect(requests).To(Equal([]string{"/images/disk.img.meta4", "/images/disk.img"}))
+	})
+
+	It("should parse the files of a metalink", func() {
+		metalink, err := ParseMetalink([]byte(newMetalink(contentHash)))
+		Expect(err).ToNot(HaveOccurred())
+		file, err := metalink.File("disk.img")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Size).To(Equal(int64(4)))
+		Expect(file.Hash("SHA-256")).To(Equal(contentHash))
+		Expect(file.MetaURLs).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// OverridesFileEnv is the environment variable pointing to a file with URL overrides for upstream hosts.
+const OverridesFileEnv = "MEDIUS_HTTP_OVERRIDES"
+
+// Override points requests below an upstream URL to a mirror, e.g. an internal mirror in a restricted
+// environment. The artifact identity, like download URLs in labels, stays the upstream one.
+type Override struct {
+	// Upstream is the URL prefix to replace, e.g. https://cloud.centos.org/centos/.
+	Upstream string `json:"upstream"`
+	// Mirror is the URL prefix to replace it with, e.g. https://mirror.example.com/centos/.
+	Mirror string `json:"mirror"`
+	// Fallback restricts the override to requests which can't reach upstream, e.g. because upstream has no
+	// IPv6 address while the pipeline runs in an IPv6-only network.
+	Fallback bool `json:"fallback,omitempty"`
+}
+
+// Overrides are applied by the longest matching upstream prefix.
+type Overrides []Override
+
+var (
+	defaultOverridesMu sync.RWMutex
+	defaultOverrides   Overrides
+)
+
+// SetDefaultOverrides sets the overrides used by all HTTPGetters without explicit overrides.
+func SetDefaultOverrides(overrides Overrides) {
+	defaultOverridesMu.Lock()
+	defer defaultOverridesMu.Unlock()
+	defaultOverrides = overrides
+}
+
+func getDefaultOverrides() Overrides {
+	defaultOverridesMu.RLock()
+	defer defaultOverridesMu.RUnlock()
+	return defaultOverrides
+}
+
+// LoadOverrides reads a JSON file with a list of overrides and validates them.
+func LoadOverrides(fileName string) (Overrides, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading overrides file: %v", err)
+	}
+
+	var overrides Overrides
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("error parsing overrides file %s: %v", fileName, err)
+	}
+
+	for _, override := range overrides {
+		for _, prefix := range []string{override.Upstream, override.Mirror} {
+			if !isAbsoluteURL(prefix) {
+				return nil, fmt.Errorf("invalid override %q -> %q: %q is no absolute URL", override.Upstream, override.Mirror, prefix)
+			}
+		}
+	}
+
+	return overrides, nil
+}
+
+func isAbsoluteURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// OverridesFromEnv loads the overrides file referenced by MEDIUS_HTTP_OVERRIDES.
+// It returns no overrides if the variable is not set.
+func OverridesFromEnv() (Overrides, error) {
+	fileName := os.Getenv(OverridesFileEnv)
+	if fileName == "" {
+		return nil, nil
+	}
+	return LoadOverrides(fileName)
+}
+
+// Rewrite returns the mirror URL of fileURL and true if an override matches it.
+func (o Overrides) Rewrite(fileURL string) (string, bool) {
+	return o.rewrite(fileURL, false)
+}
+
+// Fallback returns the mirror URL of fileURL and true if a fallback override matches it.
+func (o Overrides) Fallback(fileURL string) (string, bool) {
+	return o.rewrite(fileURL, true)
+}
+
+func (o Overrides) rewrite(fileURL string, fallback bool) (string, bool) {
+	var match *Override
+	for i := range o {
+		if o[i].Fallback != fallback {
+			continue
+		}
+		if strings.HasPrefix(fileURL, o[i].Upstream) && (match == nil || len(o[i].Upstream) > len(match.Upstream)) {
+			match = &o[i]
+		}
+	}
+	if match == nil {
+		return fileURL, false
+	}
+	return match.Mirror + strings.TrimPrefix(fileURL, match.Upstream), true
+}
+
+var checksumRegexp = regexp.MustCompile(`\b[0-9a-fA-F]{32,128}\b`)
+
+// checksums returns the sorted set of all hex encoded checksums in a file.
+func checksums(data []byte) []string {
+	set := map[string]bool{}
+	for _, checksum := range checksumRegexp.FindAll(data, -1) {
+		set[strings.ToLower(string(checksum))] = true
+	}
+	return slices.Sorted(maps.Keys(set))
+}
+
+// getAllFromMirror loads a file from the mirror of fileURL. If upstream is reachable as well, the checksums
+// in both files have to match, so a stale or tampered mirror can't change what is built. Files are compared
+// by their checksums only, as mirrors may render e.g. directory listings differently.
+func (h *HTTPGetter) getAllFromMirror(ctx context.Context, fileURL, mirrorURL string) ([]byte, error) {
+	body, err := h.getAll(ctx, mirrorURL)
+	if err != nil {
+		return nil, err
+	}
+
+	upstreamBody, err := h.getAll(ctx, fileURL)
+	if err != nil {
+		// Mirrors are typically used because upstream is not reachable
+		return body, nil
+	}
+	if !slices.Equal(checksums(body), checksums(upstreamBody)) {
+		return nil, fmt.Errorf("checksums of mirror %s differ from upstream %s", mirrorURL, fileURL)
+	}
+
+	return body, nil
+}
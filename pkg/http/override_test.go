@@ -0,0 +1,122 @@
+package http
+
+import (
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Overrides", func() {
+	const (
+		checksumA = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+		checksumB = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	)
+
+	var (
+		upstream        *httptest.Server
+		mirror          *httptest.Server
+		upstreamContent string
+		mirrorRequests  int
+	)
+
+	BeforeEach(func() {
+		upstreamContent = "SHA256 (disk.qcow2) = " + checksumA + "\n"
+		mirrorRequests = 0
+		upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(upstreamContent))
+		}))
+		DeferCleanup(upstream.Close)
+		mirror = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mirrorRequests++
+			if r.URL.Path == "/mirror/disk.qcow2" {
+				_, _ = w.Write([]byte("disk"))
+				return
+			}
+			_, _ = w.Write([]byte(checksumA + "  disk.qcow2\n"))
+		}))
+		DeferCleanup(mirror.Close)
+	})
+
+	getter := func() *HTTPGetter {
+		return &HTTPGetter{Overrides: Overrides{{Upstream: upstream.URL + "/", Mirror: mirror.URL + "/mirror/"}}}
+	}
+
+	It("Rewrite should use the longest matching prefix", func() {
+		overrides := Overrides{
+			{Upstream: "https://upstream.example.com/", Mirror: "https://mirror.example.com/all/"},
+			{Upstream: "https://upstream.example.com/fedora/", Mirror: "https://mirror.example.com/fedora/"},
+		}
+		rewritten, ok := overrides.Rewrite("https://upstream.example.com/fedora/43/CHECKSUM")
+		Expect(ok).To(BeTrue())
+		Expect(rewritten).To(Equal("https://mirror.example.com/fedora/43/CHECKSUM"))
+		rewritten, ok = overrides.Rewrite("https://upstream.example.com/centos/CHECKSUM")
+		Expect(ok).To(BeTrue())
+		Expect(rewritten).To(Equal("https://mirror.example.com/all/centos/CHECKSUM"))
+		rewritten, ok = overrides.Rewrite("https://other.example.com/CHECKSUM")
+		Expect(ok).To(BeFalse())
+		Expect(rewritten).To(Equal("https://other.example.com/CHECKSUM"))
+	})
+
+	It("should load files from the mirror if their checksums match upstream", func() {
+		data, err := getter().GetAll(upstream.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(checksumA + "  disk.qcow2\n"))
+		Expect(mirrorRequests).To(Equal(1))
+	})
+
+	It("should reject files whose checksums differ from upstream", func() {
+		upstreamContent = "SHA256 (disk.qcow2) = " + checksumB + "\n"
+		_, err := getter().GetAll(upstream.URL + "/CHECKSUM")
+		Expect(err).To(MatchError(ContainSubstring("differ from upstream")))
+	})
+
+	It("should accept files from the mirror if upstream is not reachable", func() {
+		g := getter()
+		upstream.Close()
+		data, err := g.GetAll(upstream.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring(checksumA))
+	})
+
+	It("should download disks from the mirror", func() {
+		reader, err := getter().GetWithChecksum(upstream.URL+"/disk.qcow2", sha256.New)
+		Expect(err).ToNot(HaveOccurred())
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("disk"))
+	})
+
+	It("should only use fallback mirrors if upstream is not reachable", func() {
+		g := &HTTPGetter{Overrides: Overrides{{Upstream: upstream.URL + "/", Mirror: mirror.URL + "/mirror/", Fallback: true}}}
+		_, ok := g.Overrides.Rewrite(upstream.URL + "/disk.qcow2")
+		Expect(ok).To(BeFalse())
+
+		data, err := g.GetAll(upstream.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(upstreamContent))
+		Expect(mirrorRequests).To(BeZero())
+
+		upstream.Close()
+		reader, err := g.GetWithChecksum(upstream.URL+"/disk.qcow2", sha256.New)
+		Expect(err).ToNot(HaveOccurred())
+		defer reader.Close()
+		data, err = io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("disk"))
+		Expect(mirrorRequests).To(Equal(1))
+	})
+
+	It("LoadOverrides should reject relative URLs", func() {
+		fileName := filepath.Join(GinkgoT().TempDir(), "overrides.json")
+		Expect(os.WriteFile(fileName, []byte(`[{"upstream": "https://upstream.example.com/", "mirror": "/mirror/"}]`), 0o600)).To(Succeed())
+		_, err := LoadOverrides(fileName)
+		Expect(err).To(MatchError(ContainSubstring("no absolute URL")))
+	})
+})
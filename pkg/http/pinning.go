@@ -0,0 +1,181 @@
+package http
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// PinsFileEnv is the environment variable pointing to a file with the certificate pins of upstream hosts.
+const PinsFileEnv = "MEDIUS_HTTP_PINS"
+
+const pinPrefix = "sha256/"
+
+// Pins map hostnames to the pins of the public keys their certificates may use, in addition to the regular
+// verification of the certificates. A pin is "sha256/" followed by the base64 encoded SHA-256 digest of the
+// DER encoded SubjectPublicKeyInfo of the key, as used by HPKP. Pinning the key of an intermediate or root
+// certificate of the chain survives the renewal of the leaf certificate.
+type Pins map[string][]string
+
+var (
+	defaultPinsMu sync.RWMutex
+	defaultPins   Pins
+
+	// pinnedTransports caches a pinning transport per base transport, so connections are reused.
+	pinnedTransports sync.Map
+)
+
+// SetDefaultPins sets the pins checked by all HTTPGetters.
+func SetDefaultPins(pins Pins) {
+	defaultPinsMu.Lock()
+	defer defaultPinsMu.Unlock()
+	defaultPins = pins
+}
+
+func getDefaultPins() Pins {
+	defaultPinsMu.RLock()
+	defer defaultPinsMu.RUnlock()
+	return defaultPins
+}
+
+// LoadPins reads a JSON file mapping hostnames to lists of pins and validates them.
+func LoadPins(fileName string) (Pins, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading pins file: %v", err)
+	}
+
+	var pins Pins
+	if err := json.Unmarshal(data, &pins); err != nil {
+		return nil, fmt.Errorf("error parsing pins file %s: %v", fileName, err)
+	}
+
+	for host, hostPins := range pins {
+		if len(hostPins) == 0 {
+			return nil, fmt.Errorf("no pins for host %s", host)
+		}
+		for _, pin := range hostPins {
+			digest, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, pinPrefix))
+			if !strings.HasPrefix(pin, pinPrefix) || err != nil || len(digest) != sha256.Size {
+				return nil, fmt.Errorf("invalid pin %q of host %s, expected %s<base64 encoded SHA-256 digest>", pin, host, pinPrefix)
+			}
+		}
+	}
+
+	return pins, nil
+}
+
+// PinsFromEnv loads the pins file referenced by MEDIUS_HTTP_PINS.
+// It returns no pins if the variable is not set.
+func PinsFromEnv() (Pins, error) {
+	fileName := os.Getenv(PinsFileEnv)
+	if fileName == "" {
+		return nil, nil
+	}
+	return LoadPins(fileName)
+}
+
+// Pin returns the pin of the public key of a certificate.
+func Pin(cert *x509.Certificate) string {
+	digest := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return pinPrefix + base64.StdEncoding.EncodeToString(digest[:])
+}
+
+// verify checks that the certificates of a connection to a pinned host contain a pinned key. The verified
+// chains are checked, or the certificates presented by the server if their verification is skipped.
+func (p Pins) verify(host string, cs tls.ConnectionState) error {
+	hostPins, ok := p[host]
+	if !ok {
+		return nil
+	}
+
+	chains := cs.VerifiedChains
+	if len(chains) == 0 {
+		chains = [][]*x509.Certificate{cs.PeerCertificates}
+	}
+	var presented []string
+	for _, chain := range chains {
+		for _, cert := range chain {
+			pin := Pin(cert)
+			if slices.Contains(hostPins, pin) {
+				return nil
+			}
+			if !slices.Contains(presented, pin) {
+				presented = append(presented, pin)
+			}
+		}
+	}
+	return &pinMismatchError{host: host, pins: hostPins, presented: presented}
+}
+
+// pinMismatchError lists the presented keys, so the pins can be updated after upstream rotated its keys.
+type pinMismatchError struct {
+	host      string
+	pins      []string
+	presented []string
+}
+
+func (e *pinMismatchError) Error() string {
+	return fmt.Sprintf("certificates of %s match none of its pins %v, the server presented the keys %v", e.host, e.pins, e.presented)
+}
+
+// pinnedTransport sends requests to pinned hosts through transports checking their pins. Connections are
+// pinned per host instead of by the server name of the TLS connection, which is empty for IP addresses.
+type pinnedTransport struct {
+	base  *http.Transport
+	mu    sync.Mutex
+	hosts map[string]*http.Transport
+}
+
+// newPinnedTransport returns the pinning transport of base. It reads the default pins on every request, so it
+// is shared by all getters.
+func newPinnedTransport(base http.RoundTripper) (http.RoundTripper, error) {
+	if transport, ok := pinnedTransports.Load(base); ok {
+		return transport.(http.RoundTripper), nil
+	}
+	baseTransport, ok := base.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("certificate pinning is not supported by the transport %T", base)
+	}
+	transport, _ := pinnedTransports.LoadOrStore(base, &pinnedTransport{base: baseTransport, hosts: map[string]*http.Transport{}})
+	return transport.(http.RoundTripper), nil
+}
+
+func (t *pinnedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	if _, pinned := getDefaultPins()[host]; !pinned {
+		return t.base.RoundTrip(req)
+	}
+	// Redirects go through the transport as well, so they can't downgrade pinned hosts either
+	if req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("refusing to connect to the pinned host %s without TLS", host)
+	}
+	return t.hostTransport(host).RoundTrip(req)
+}
+
+func (t *pinnedTransport) hostTransport(host string) *http.Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if transport, ok := t.hosts[host]; ok {
+		return transport
+	}
+	transport := t.base.Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.MinVersion = max(transport.TLSClientConfig.MinVersion, tls.VersionTLS12)
+	transport.TLSClientConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+		return getDefaultPins().verify(host, cs)
+	}
+	t.hosts[host] = transport
+	return transport
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pins", func() {
+	var (
+		server   *httptest.Server
+		requests int
+	)
+
+	otherPin := "sha256/" + base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	BeforeEach(func() {
+		requests = 0
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			_, _ = w.Write([]byte("content"))
+		}))
+		DeferCleanup(server.Close)
+
+		// Trust the certificate of the test server
+		defaultTransport := http.DefaultTransport
+		http.DefaultTransport = server.Client().Transport
+		DeferCleanup(func() {
+			http.DefaultTransport = defaultTransport
+			SetDefaultPins(nil)
+		})
+	})
+
+	serverHost := func() string {
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		return serverURL.Hostname()
+	}
+
+	getter := func() *HTTPGetter {
+		return &HTTPGetter{Retry: &Retry{Attempts: 3}}
+	}
+
+	It("should connect to hosts presenting a pinned key", func() {
+		SetDefaultPins(Pins{serverHost(): {otherPin, Pin(server.Certificate())}})
+		data, err := getter().GetAll(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("content"))
+	})
+
+	It("should reject hosts presenting other keys without retrying", func() {
+		SetDefaultPins(Pins{serverHost(): {otherPin}})
+		_, err := getter().GetAll(server.URL + "/CHECKSUM")
+		Expect(err).To(MatchError(ContainSubstring("match none of its pins [" + otherPin + "]")))
+		Expect(err).To(MatchError(ContainSubstring("the server presented the keys [" + Pin(server.Certificate()) + "]")))
+		Expect(requests).To(BeZero())
+	})
+
+	It("should not check hosts without pins", func() {
+		SetDefaultPins(Pins{"other.example.com": {otherPin}})
+		_, err := getter().GetAll(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should refuse pinned hosts without TLS", func() {
+		SetDefaultPins(Pins{serverHost(): {Pin(server.Certificate())}})
+		_, err := getter().GetAll(strings.Replace(server.URL, "https://", "http://", 1) + "/CHECKSUM")
+		Expect(err).To(MatchError(ContainSubstring("refusing to connect to the pinned host " + serverHost() + " without TLS")))
+	})
+
+	DescribeTable("LoadPins should validate pins",
+		func(content, expectedErr string) {
+			fileName := filepath.Join(GinkgoT().TempDir(), "pins.json")
+			Expect(os.WriteFile(fileName, []byte(content), 0o600)).To(Succeed())
+			pins, err := LoadPins(fileName)
+			if expectedErr == "" {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pins).To(HaveKeyWithValue("cloud.centos.org", []string{otherPin}))
+				return
+			}
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("valid", `{"cloud.centos.org": ["`+otherPin+`"]}`, ""),
+		Entry("no pins", `{"cloud.centos.org": []}`, "no pins for host cloud.centos.org"),
+		Entry("no prefix", `{"cloud.centos.org": ["`+strings.TrimPrefix(otherPin, "sha256/")+`"]}`, "invalid pin"),
+		Entry("short digest", `{"cloud.centos.org": ["sha256/AAAA"]}`, "invalid pin"),
+		Entry("invalid JSON", `[`, "error parsing pins file"),
+	)
+})
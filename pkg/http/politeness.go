@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultUserAgent identifies medius to upstream mirrors, so their operators know whom to contact.
+const DefaultUserAgent = "medius (+https://github.com/kubevirt/containerdisks)"
+
+// Politeness controls how considerate requests to upstream mirrors are.
+type Politeness struct {
+	// UserAgent is sent with every request, defaults to DefaultUserAgent.
+	UserAgent string
+	// MinInterval is the minimum time between the start of two requests to the same host.
+	MinInterval time.Duration
+	// RequestsPerMinute limits the requests to the same host, unlimited if zero.
+	RequestsPerMinute int
+	// RespectRobots spaces out requests to hosts by at least the Crawl-delay of their robots.txt.
+	RespectRobots bool
+}
+
+var (
+	defaultPolitenessMu sync.RWMutex
+	defaultPoliteness   = Politeness{UserAgent: DefaultUserAgent}
+
+	pacer = &hostPacer{next: map[string]time.Time{}}
+	cache = &conditionalCache{entries: map[string]*cacheEntry{}}
+)
+
+// SetDefaultPoliteness sets the politeness of all HTTPGetters without explicit politeness settings.
+func SetDefaultPoliteness(politeness Politeness) {
+	defaultPolitenessMu.Lock()
+	defer defaultPolitenessMu.Unlock()
+	defaultPoliteness = politeness
+}
+
+func getDefaultPoliteness() Politeness {
+	defaultPolitenessMu.RLock()
+	defer defaultPolitenessMu.RUnlock()
+	return defaultPoliteness
+}
+
+func (p *Politeness) userAgent() string {
+	if p.UserAgent == "" {
+		return DefaultUserAgent
+	}
+	return p.UserAgent
+}
+
+// wait blocks until the request may be sent to its host. The longest of the minimum interval, the interval
+// of the requests per minute and the crawl delay of the host applies.
+func (p *Politeness) wait(req *http.Request) error {
+	interval := p.MinInterval
+	if p.RequestsPerMinute > 0 {
+		interval = max(interval, time.Minute/time.Duration(p.RequestsPerMinute))
+	}
+	if p.RespectRobots {
+		interval = max(interval, robots.crawlDelay(req.Context(), req.URL, p.userAgent()))
+	}
+	return pacer.wait(req.Context(), req.URL.Host, interval)
+}
+
+// hostPacer spaces out requests to the same host. It is shared by all getters,
+// as every provider creates its own getter but many providers share mirrors.
+type hostPacer struct {
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+func (p *hostPacer) wait(ctx context.Context, host string, interval time.Duration) error {
+	if interval <= 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	slot := p.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	p.next[host] = slot.Add(interval)
+	p.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
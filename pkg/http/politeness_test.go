@@ -0,0 +1,93 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Politeness", func() {
+	var (
+		server     *httptest.Server
+		userAgent  string
+		requests   int
+		notChanged int
+
+		robotsRequests int
+	)
+
+	BeforeEach(func() {
+		requests, notChanged, robotsRequests = 0, 0, 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == robotsPath {
+				robotsRequests++
+				_, _ = w.Write([]byte("User-agent: *\nCrawl-delay: 0.05\n"))
+				return
+			}
+			requests++
+			userAgent = r.Header.Get("User-Agent")
+			if r.URL.Path == "/etag" {
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					notChanged++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", `"v1"`)
+			}
+			_, _ = w.Write([]byte("content of " + r.URL.Path))
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	It("should send the default User-Agent", func() {
+		_, err := (&HTTPGetter{}).GetAll(server.URL + "/file")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(userAgent).To(Equal(DefaultUserAgent))
+	})
+
+	It("should send a custom User-Agent", func() {
+		_, err := (&HTTPGetter{Politeness: &Politeness{UserAgent: "custom"}}).GetAll(server.URL + "/file")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(userAgent).To(Equal("custom"))
+	})
+
+	It("should use conditional requests for unchanged files", func() {
+		getter := &HTTPGetter{}
+		for range 3 {
+			data, err := getter.GetAll(server.URL + "/etag")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("content of /etag"))
+		}
+		Expect(requests).To(Equal(3))
+		Expect(notChanged).To(Equal(2))
+	})
+
+	It("should pace requests to the same host", func() {
+		const interval = 50 * time.Millisecond
+		getter := &HTTPGetter{Politeness: &Politeness{MinInterval: interval}}
+		start := time.Now()
+		for range 3 {
+			_, err := getter.GetAll(server.URL + "/file")
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(time.Since(start)).To(BeNumerically(">=", 2*interval))
+	})
+	DescribeTable("should limit the requests to the same host",
+		func(politeness *Politeness, expectedRobotsRequests int) {
+			getter := &HTTPGetter{Politeness: politeness}
+			start := time.Now()
+			for range 3 {
+				_, err := getter.GetAll(server.URL + "/file")
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(time.Since(start)).To(BeNumerically(">=", 100*time.Millisecond))
+			Expect(requests).To(Equal(3))
+			Expect(robotsRequests).To(Equal(expectedRobotsRequests))
+		},
+		Entry("per minute", &Politeness{RequestsPerMinute: 1200}, 0),
+		Entry("by the crawl delay of robots.txt", &Politeness{RespectRobots: true}, 1),
+	)
+})
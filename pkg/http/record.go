@@ -0,0 +1,167 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// RecordEnv is the environment variable which switches recording getters from replay to record mode.
+const RecordEnv = "HTTP_RECORD"
+
+type RecordMode int
+
+const (
+	// RecordModeReplay serves all requests from previously recorded responses.
+	RecordModeReplay RecordMode = iota
+	// RecordModeRecord performs real requests and stores the responses.
+	RecordModeRecord
+)
+
+// RecordModeFromEnv returns RecordModeRecord if HTTP_RECORD is set to a non-empty value.
+func RecordModeFromEnv() RecordMode {
+	if os.Getenv(RecordEnv) != "" {
+		return RecordModeRecord
+	}
+	return RecordModeReplay
+}
+
+// RecordingGetter records upstream responses into a directory and replays them later on.
+// Responses are stored below dir in a path derived from the request URL (host/path).
+type RecordingGetter struct {
+	dir      string
+	mode     RecordMode
+	upstream Getter
+}
+
+func NewRecordingGetter(dir string, mode RecordMode, upstream Getter) *RecordingGetter {
+	if upstream == nil {
+		upstream = &HTTPGetter{}
+	}
+	return &RecordingGetter{dir: dir, mode: mode, upstream: upstream}
+}
+
+// RecordingPath returns the file a response for fileURL is recorded to.
+func (r *RecordingGetter) RecordingPath(fileURL string) (string, error) {
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return "", fmt.Errorf("error parsing url %s: %v", fileURL, err)
+	}
+
+	p := strings.TrimSuffix(u.Path, "/")
+	if p == "" || strings.HasSuffix(u.Path, "/") {
+		// Directory listings are stored next to their entries
+		p += "/index.html"
+	}
+	if u.RawQuery != "" {
+		p += "_" + url.PathEscape(u.RawQuery)
+	}
+
+	// Colons, e.g. of host:port, are not allowed in file names on Windows
+	p = strings.ReplaceAll(path.Join(u.Host, p), ":", "_")
+	cleaned := filepath.Clean(filepath.FromSlash(p))
+	if cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("refusing to record %s outside of %s", fileURL, r.dir)
+	}
+
+	return filepath.Join(r.dir, cleaned), nil
+}
+
+func (r *RecordingGetter) GetAll(fileURL string) ([]byte, error) {
+	return r.GetAllWithContext(context.Background(), fileURL)
+}
+
+func (r *RecordingGetter) GetAllWithContext(ctx context.Context, fileURL string) ([]byte, error) {
+	recordingPath, err := r.RecordingPath(fileURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == RecordModeReplay {
+		data, err := os.ReadFile(recordingPath)
+		if err != nil {
+			return nil, fmt.Errorf("no recording for %s, rerun with %s=true to record it: %v", fileURL, RecordEnv, err)
+		}
+		return data, nil
+	}
+
+	data, err := r.upstream.GetAllWithContext(ctx, fileURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeRecording(recordingPath, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (r *RecordingGetter) GetWithChecksum(fileURL string, checksumHasher func() hash.Hash) (ReadCloserWithChecksum, error) {
+	return r.GetWithChecksumAndContext(context.Background(), fileURL, checksumHasher)
+}
+
+// GetWithChecksumAndContext spools the upstream response in record mode, so large responses
+// are not kept in memory while they are recorded.
+func (r *RecordingGetter) GetWithChecksumAndContext(ctx context.Context, fileURL string, checksumHasher func() hash.Hash) (
+	ReadCloserWithChecksum, error,
+) {
+	recordingPath, err := r.RecordingPath(fileURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == RecordModeRecord {
+		spooled, err := SpoolWithChecksum(ctx, r.upstream, fileURL, checksumHasher)
+		if err != nil {
+			return nil, err
+		}
+		err = writeRecordingFrom(recordingPath, spooled)
+		closeErr := spooled.Close()
+		if err != nil {
+			return nil, err
+		}
+		if closeErr != nil {
+			return nil, closeErr
+		}
+	}
+
+	file, err := os.Open(recordingPath)
+	if err != nil {
+		return nil, fmt.Errorf("no recording for %s, rerun with %s=true to record it: %v", fileURL, RecordEnv, err)
+	}
+
+	return newReadCloserWithChecksum(file, checksumHasher), nil
+}
+
+func writeRecording(recordingPath string, data []byte) error {
+	return writeRecordingFrom(recordingPath, bytes.NewReader(data))
+}
+
+func writeRecordingFrom(recordingPath string, data io.Reader) error {
+	const permissionDir = 0o755
+	if err := os.MkdirAll(filepath.Dir(recordingPath), permissionDir); err != nil {
+		return fmt.Errorf("error creating recording directory: %v", err)
+	}
+
+	const permissionFile = 0o644
+	file, err := os.OpenFile(recordingPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, permissionFile)
+	if err != nil {
+		return fmt.Errorf("error writing recording %s: %v", recordingPath, err)
+	}
+	if _, err := io.Copy(file, data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("error writing recording %s: %v", recordingPath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing recording %s: %v", recordingPath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"kubevirt.io/containerdisks/pkg/progress"
+)
+
+const (
+	// MaxRedirects is the maximum number of HTTP redirects and meta refreshes followed for one download.
+	MaxRedirects = 10
+
+	// sniffLength is the number of bytes used to detect HTML pages, like http.DetectContentType.
+	sniffLength = 512
+	// maxHTMLPageSize limits how much of an HTML page is searched for a meta refresh.
+	maxHTMLPageSize = 64 * 1024
+)
+
+var metaRefreshRegexp = regexp.MustCompile(
+	`(?is)<meta[^>]+http-equiv\s*=\s*["']?refresh["']?[^>]+content\s*=\s*["']\s*\d*\s*;?\s*url\s*=\s*['"]?([^"'>\s]+)`)
+
+// checkRedirect limits redirect chains, paces requests to the hosts redirected to and authorizes
+// them with their own credentials instead of the ones of the original host.
+func checkRedirect(politeness *Politeness, credentials CredentialStore) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= MaxRedirects {
+			return fmt.Errorf("stopped after %d redirects: %s", MaxRedirects, redirectChain(via, req))
+		}
+		if err := politeness.wait(req); err != nil {
+			return err
+		}
+		// net/http keeps the Authorization header on redirects to other ports of the same host
+		if req.URL.Host != via[len(via)-1].URL.Host {
+			req.Header.Del("Authorization")
+			if creds := credentials.Lookup(req.URL.Host); creds != nil {
+				creds.authorize(req)
+			}
+		}
+		return nil
+	}
+}
+
+func redirectChain(via []*http.Request, req *http.Request) string {
+	urls := make([]string, 0, len(via)+1)
+	for _, r := range via {
+		urls = append(urls, r.URL.Redacted())
+	}
+	return strings.Join(append(urls, req.URL.Redacted()), " -> ")
+}
+
+// ResponseInfo describes which server delivered a download, to diagnose corrupted downloads.
+type ResponseInfo struct {
+	// URL is the URL the file was finally served from, after following redirects.
+	URL string
+	// RemoteAddr is the address of the server which served the file.
+	RemoteAddr string
+	Header     http.Header
+}
+
+// ResponseInfoOf returns the response info of a download or nil if the getter does not provide it.
+func ResponseInfoOf(readCloser ReadCloserWithChecksum) *ResponseInfo {
+	if r, ok := readCloser.(interface{ ResponseInfo() *ResponseInfo }); ok {
+		return r.ResponseInfo()
+	}
+	return nil
+}
+
+// getFile requests a file. Mirrors which answer with an HTML page containing a meta refresh are followed.
+// Other HTML pages, e.g. error or landing pages delivered with status 200, are rejected, as are bodies
+// which are shorter or longer than announced. The Content-Type header is not trusted, as some mirrors
+// deliver binary files as text/html.
+func (h *HTTPGetter) getFile(ctx context.Context, fileURL string) (io.ReadCloser, *ResponseInfo, error) {
+	info := &ResponseInfo{}
+	// The address of the last connection is the one of the server the last redirect pointed to
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(conn httptrace.GotConnInfo) {
+			info.RemoteAddr = conn.Conn.RemoteAddr().String()
+		},
+	})
+
+	for redirects := 0; ; redirects++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, http.NoBody)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create request to load primary repository file from %s: %v", fileURL, err)
+		}
+
+		resp, err := h.do(req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load primary repository file from %s: %v", fileURL, err)
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			resp.Body.Close()
+			return nil, nil, fmt.Errorf("failed to download %s: %v ", fileURL, fmt.Errorf("status : %v", resp.StatusCode))
+		}
+
+		maxDownloadSize := h.sizeLimits().MaxDownloadSize
+		if err := checkContentLength(resp.ContentLength, maxDownloadSize); err != nil {
+			resp.Body.Close()
+			return nil, nil, fmt.Errorf("failed to download %s: %v", fileURL, err)
+		}
+		if maxDownloadSize > 0 {
+			resp.Body = &sizeLimitedReadCloser{ReadCloser: resp.Body, maxSize: maxDownloadSize}
+		}
+
+		body, target, err := checkBody(resp)
+		if err != nil {
+			resp.Body.Close()
+			return nil, nil, fmt.Errorf("failed to download %s: %v", fileURL, err)
+		}
+		if target == "" {
+			info.URL = resp.Request.URL.Redacted()
+			info.Header = resp.Header
+			return progress.FromContext(ctx).Download(body, resp.ContentLength), info, nil
+		}
+		resp.Body.Close()
+
+		if redirects >= MaxRedirects {
+			return nil, nil, fmt.Errorf("failed to download %s: stopped after %d meta refreshes", fileURL, MaxRedirects)
+		}
+		fileURL = target
+	}
+}
+
+// checkBody returns the body of the response, or the target of a meta refresh if the body is such an HTML page.
+func checkBody(resp *http.Response) (body io.ReadCloser, target string, err error) {
+	reader := bufio.NewReaderSize(resp.Body, sniffLength)
+	peeked, err := reader.Peek(sniffLength)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, "", err
+	}
+
+	if !strings.HasPrefix(http.DetectContentType(peeked), "text/html") {
+		body = &lengthCheckingReadCloser{
+			Reader:   reader,
+			Closer:   resp.Body,
+			expected: resp.ContentLength,
+		}
+		return body, "", nil
+	}
+
+	page, err := io.ReadAll(io.LimitReader(reader, maxHTMLPageSize))
+	if err != nil {
+		return nil, "", err
+	}
+	match := metaRefreshRegexp.FindSubmatch(page)
+	if match == nil {
+		return nil, "", fmt.Errorf("received an HTML page instead of a file (Content-Type %q)", resp.Header.Get("Content-Type"))
+	}
+	refresh, err := resp.Request.URL.Parse(strings.TrimSpace(string(match[1])))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid meta refresh target %q: %v", match[1], err)
+	}
+	if refresh.Scheme != "http" && refresh.Scheme != "https" {
+		return nil, "", fmt.Errorf("unsupported meta refresh target %q", (&url.URL{Scheme: refresh.Scheme}).String())
+	}
+
+	return nil, refresh.String(), nil
+}
+
+// lengthCheckingReadCloser fails at the end of the body if it does not have the announced length.
+type lengthCheckingReadCloser struct {
+	io.Reader
+	io.Closer
+	expected int64
+	read     int64
+}
+
+func (r *lengthCheckingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += int64(n)
+	if errors.Is(err, io.EOF) && r.expected >= 0 && r.read != r.expected {
+		return n, fmt.Errorf("received %d bytes, but %d bytes were announced: %w", r.read, r.expected, io.ErrUnexpectedEOF)
+	}
+	return n, err
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redirects", func() {
+	var (
+		mirror        *httptest.Server
+		redirector    *httptest.Server
+		authorization string
+	)
+
+	BeforeEach(func() {
+		authorization = ""
+		mirror = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			switch r.URL.Path {
+			case "/disk.img":
+				// Some mirrors deliver binary files as text/html
+				w.Header().Set("Content-Type", "text/html")
+				_, _ = w.Write([]byte{'Q', 'F', 'I', 0xfb, 0, 0, 0, 3})
+			case "/refresh.html":
+				_, _ = w.Write([]byte(`<!DOCTYPE html><html><head>` +
+					`<meta http-equiv="refresh" content="0; url='/disk.img'"></head></html>`))
+			case "/landing.html":
+				_, _ = w.Write([]byte(`<!DOCTYPE html><html><body>Please select a mirror</body></html>`))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		DeferCleanup(mirror.Close)
+
+		redirector = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/loop":
+				http.Redirect(w, r, "/loop", http.StatusFound)
+			default:
+				http.Redirect(w, r, mirror.URL+r.URL.Path, http.StatusFound)
+			}
+		}))
+		DeferCleanup(redirector.Close)
+	})
+
+	download := func(getter *HTTPGetter, fileURL string) ([]byte, error) {
+		reader, err := getter.GetWithChecksum(fileURL, sha256.New)
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	}
+
+	It("should accept binary files delivered as text/html", func() {
+		data, err := download(&HTTPGetter{}, mirror.URL+"/disk.img")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(HavePrefix("QFI"))
+	})
+
+	It("should follow redirects to other hosts with the credentials of that host", func() {
+		u, err := url.Parse(mirror.URL)
+		Expect(err).ToNot(HaveOccurred())
+		getter := &HTTPGetter{Credentials: CredentialStore{u.Host: {Token: "mirror"}}}
+
+		data, err := download(getter, redirector.URL+"/disk.img")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(HavePrefix("QFI"))
+		Expect(authorization).To(Equal("Bearer mirror"))
+	})
+
+	It("should not leak credentials to the host redirected to", func() {
+		u, err := url.Parse(redirector.URL)
+		Expect(err).ToNot(HaveOccurred())
+		getter := &HTTPGetter{Credentials: CredentialStore{u.Host: {Token: "redirector"}}}
+
+		_, err = download(getter, redirector.URL+"/disk.img")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authorization).To(BeEmpty())
+	})
+
+	It("should describe the server the file was finally served from", func() {
+		reader, err := (&HTTPGetter{}).GetWithChecksum(redirector.URL+"/disk.img", sha256.New)
+		Expect(err).ToNot(HaveOccurred())
+		defer reader.Close()
+
+		info := ResponseInfoOf(reader)
+		Expect(info).ToNot(BeNil())
+		Expect(info.URL).To(Equal(mirror.URL + "/disk.img"))
+		Expect(info.RemoteAddr).To(Equal(strings.TrimPrefix(mirror.URL, "http://")))
+		Expect(info.Header.Get("Content-Type")).To(Equal("text/html"))
+	})
+
+	It("should follow meta refreshes", func() {
+		data, err := download(&HTTPGetter{}, redirector.URL+"/refresh.html")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(HavePrefix("QFI"))
+	})
+
+	It("should reject HTML pages without meta refresh", func() {
+		_, err := download(&HTTPGetter{}, mirror.URL+"/landing.html")
+		Expect(err).To(MatchError(ContainSubstring("received an HTML page instead of a file")))
+	})
+
+	It("should stop redirect loops", func() {
+		_, err := download(&HTTPGetter{}, redirector.URL+"/loop")
+		Expect(err).To(MatchError(ContainSubstring("stopped after 10 redirects")))
+	})
+
+	It("should fail if the body is shorter than announced", func() {
+		reader := &lengthCheckingReadCloser{Reader: strings.NewReader("short"), Closer: io.NopCloser(nil), expected: 10}
+		_, err := io.ReadAll(reader)
+		Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+		Expect(err).To(MatchError(ContainSubstring("received 5 bytes, but 10 bytes were announced")))
+	})
+})
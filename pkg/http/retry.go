@@ -0,0 +1,250 @@
+package http
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Retry controls how requests which failed transiently are retried.
+type Retry struct {
+	// Attempts is the maximum number of attempts of a request, including the first one. Less than two
+	// attempts disable retries.
+	Attempts int
+	// Backoff is the time to wait before the first retry. It doubles with every further retry up to MaxBackoff,
+	// and every wait is randomized by up to half of it, so the retries of concurrent workers do not align.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Budget is the ratio of retries to requests allowed per host, on top of a small reserve. It keeps the
+	// workers from multiplying the load on a host which fails all requests. Zero disables the budget.
+	Budget float64
+}
+
+// DefaultRetry is the retry configuration of getters and registry clients, unless it is changed by SetDefaultRetry.
+var DefaultRetry = Retry{Attempts: 3, Backoff: time.Second, MaxBackoff: 30 * time.Second, Budget: 0.2}
+
+// retryReserve is the number of retries per host which do not need to be earned by requests first.
+const retryReserve = 10
+
+// retryStatusCodes are the status codes of responses to requests which may succeed when sent again.
+var retryStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+var (
+	defaultRetryMu sync.RWMutex
+	defaultRetry   = DefaultRetry
+
+	budget = &retryBudget{tokens: map[string]float64{}}
+)
+
+// SetDefaultRetry sets the retry configuration of all HTTPGetters without explicit retry settings and of the
+// registry clients.
+func SetDefaultRetry(retry Retry) {
+	defaultRetryMu.Lock()
+	defer defaultRetryMu.Unlock()
+	defaultRetry = retry
+}
+
+func getDefaultRetry() Retry {
+	defaultRetryMu.RLock()
+	defer defaultRetryMu.RUnlock()
+	return defaultRetry
+}
+
+// DefaultRetryTransport returns a round tripper retrying the requests sent through base with the default
+// retry configuration.
+func DefaultRetryTransport(base http.RoundTripper) http.RoundTripper {
+	return RetryTransport(base, getDefaultRetry())
+}
+
+// RetryTransport returns a round tripper retrying the requests sent through base which failed with network
+// errors or with a status code of a transient failure. Only idempotent requests are retried, i.e. requests
+// with an idempotent method or an Idempotency-Key header, and only if their body can be sent again.
+func RetryTransport(base http.RoundTripper, retry Retry) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{base: base, retry: retry}
+}
+
+type retryTransport struct {
+	base  http.RoundTripper
+	retry Retry
+	// beforeRetry is called before every retry, e.g. to pace the retries like other requests to the host.
+	beforeRetry func(req *http.Request) error
+	// permanent optionally marks further errors which are not retried.
+	permanent func(err error) bool
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	budget.deposit(req.URL.Host, t.retry.Budget)
+	resp, err := t.base.RoundTrip(req)
+
+	retried := false
+	for attempt := 1; attempt < t.retry.Attempts && t.retryable(req, resp, err); attempt++ {
+		retryReq, reqErr := rewind(req)
+		if reqErr != nil {
+			break
+		}
+		wait, ok := t.backoff(attempt, resp)
+		if !ok || !budget.withdraw(req.URL.Host, t.retry.Budget) {
+			break
+		}
+		if resp != nil {
+			drain(resp)
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		if t.beforeRetry != nil {
+			if err = t.beforeRetry(retryReq); err != nil {
+				return nil, err
+			}
+		}
+		resp, err = t.base.RoundTrip(retryReq)
+		retried = true
+	}
+	if err != nil && retried {
+		return nil, &retriedError{err: err}
+	}
+	return resp, err
+}
+
+// retriedError is the error of a request which was already retried, it is returned unchanged otherwise.
+type retriedError struct {
+	err error
+}
+
+func (e *retriedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retriedError) Unwrap() error {
+	return e.err
+}
+
+// Retried reports whether err is the error of a request which the retry transport already retried, so
+// callers retrying whole operations, e.g. the upload of a layer, do not retry the request again.
+func Retried(err error) bool {
+	var retriedErr *retriedError
+	return errors.As(err, &retriedErr)
+}
+
+// backoff returns the time to wait before the attempt. A Retry-After longer than MaxBackoff is not waited for.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) (time.Duration, bool) {
+	wait := min(t.retry.Backoff<<(attempt-1), t.retry.MaxBackoff)
+	if wait > 0 {
+		wait = wait/2 + rand.N(wait/2+1) //nolint:gosec // jitter does not need a secure random number
+	}
+	if resp == nil {
+		return wait, true
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		retryAfter := time.Duration(seconds) * time.Second
+		if retryAfter > t.retry.MaxBackoff {
+			return 0, false
+		}
+		wait = max(wait, retryAfter)
+	}
+	return wait, true
+}
+
+// retryable returns whether the request failed transiently and may be sent again.
+func (t *retryTransport) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil || !idempotent(req) {
+		return false
+	}
+	if err != nil {
+		if t.permanent != nil && t.permanent(err) {
+			return false
+		}
+		// Hosts which do not exist will not exist a moment later either, nor will their certificates change
+		var dnsErr *net.DNSError
+		var certErr *tls.CertificateVerificationError
+		var pinErr *pinMismatchError
+		return !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) && !errors.As(err, &certErr) && !errors.As(err, &pinErr)
+	}
+	return slices.Contains(retryStatusCodes, resp.StatusCode)
+}
+
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// rewind returns a copy of the request with a fresh body, to send it again.
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("the body of the request can not be sent again")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retryReq := req.Clone(req.Context())
+	retryReq.Body = body
+	return retryReq, nil
+}
+
+// drain discards the response of a failed attempt, so its connection can be reused.
+func drain(resp *http.Response) {
+	_, _ = io.CopyN(io.Discard, resp.Body, 4096)
+	resp.Body.Close()
+}
+
+// retryBudget tracks the retries every host may still get. It is shared by all getters and registry clients,
+// as they send requests to the same hosts.
+type retryBudget struct {
+	mu     sync.Mutex
+	tokens map[string]float64
+}
+
+// deposit earns the host a fraction of a retry for every request.
+func (b *retryBudget) deposit(host string, ratio float64) {
+	if ratio <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tokens, ok := b.tokens[host]
+	if !ok {
+		tokens = retryReserve
+	}
+	b.tokens[host] = min(tokens+ratio, retryReserve)
+}
+
+// withdraw returns whether the host has a retry left and takes it.
+func (b *retryBudget) withdraw(host string, ratio float64) bool {
+	if ratio <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens[host] < 1 {
+		return false
+	}
+	b.tokens[host]--
+	return true
+}
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retry", func() {
+	var (
+		server   *httptest.Server
+		requests int
+		failures int
+		status   int
+		bodies   []string
+	)
+
+	retry := Retry{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	BeforeEach(func() {
+		requests, failures, status, bodies = 0, 0, http.StatusServiceUnavailable, nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			if r.URL.Path == "/retry-after" {
+				w.Header().Set("Retry-After", "120")
+			}
+			if requests <= failures {
+				w.WriteHeader(status)
+				return
+			}
+			_, _ = w.Write([]byte("content"))
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	send := func(transport http.RoundTripper, method, path, body string) *http.Response {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		Expect(err).ToNot(HaveOccurred())
+		if body == "" {
+			req.Body, req.GetBody = http.NoBody, nil
+		}
+		resp, err := transport.RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(resp.Body.Close)
+		return resp
+	}
+
+	It("should retry transient failures", func() {
+		failures = 2
+		resp := send(RetryTransport(nil, retry), http.MethodGet, "/file", "")
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(requests).To(Equal(3))
+	})
+
+	It("should give up after all attempts", func() {
+		failures = 5
+		resp := send(RetryTransport(nil, retry), http.MethodGet, "/file", "")
+		Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		Expect(requests).To(Equal(3))
+	})
+
+	It("should not retry permanent failures", func() {
+		failures, status = 5, http.StatusNotFound
+		resp := send(RetryTransport(nil, retry), http.MethodGet, "/file", "")
+		Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		Expect(requests).To(Equal(1))
+	})
+
+	It("should not wait for a Retry-After longer than the maximum backoff", func() {
+		failures = 1
+		resp := send(RetryTransport(nil, retry), http.MethodGet, "/retry-after", "")
+		Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		Expect(requests).To(Equal(1))
+	})
+
+	It("should only retry idempotent requests", func() {
+		failures = 1
+		resp := send(RetryTransport(nil, retry), http.MethodPost, "/upload", "data")
+		Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		Expect(requests).To(Equal(1))
+	})
+
+	It("should send the body again with every retry", func() {
+		failures = 1
+		resp := send(RetryTransport(nil, retry), http.MethodPut, "/upload", "data")
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(bodies).To(Equal([]string{"data", "data"}))
+	})
+
+	It("should retry requests with an Idempotency-Key", func() {
+		failures = 1
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/upload", strings.NewReader("data"))
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("Idempotency-Key", "key")
+		resp, err := RetryTransport(nil, retry).RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(bodies).To(Equal([]string{"data", "data"}))
+	})
+
+	It("should stop retrying once the budget of the host is spent", func() {
+		failures = 100
+		transport := RetryTransport(nil, Retry{Attempts: 2, Backoff: time.Millisecond, MaxBackoff: time.Millisecond, Budget: 0.01})
+		for range 12 {
+			send(transport, http.MethodGet, "/file", "")
+		}
+		// The reserve allows ten retries, the requests have not earned another one yet
+		Expect(requests).To(Equal(12 + retryReserve))
+	})
+
+	It("should mark errors of requests which were retried", func() {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+
+		req, err := http.NewRequest(http.MethodGet, closed.URL+"/file", http.NoBody)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = RetryTransport(nil, retry).RoundTrip(req)
+		Expect(err).To(MatchError(syscall.ECONNREFUSED))
+		Expect(Retried(err)).To(BeTrue())
+
+		req, err = http.NewRequest(http.MethodPost, closed.URL+"/upload", strings.NewReader("data"))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = RetryTransport(nil, retry).RoundTrip(req)
+		Expect(err).To(MatchError(syscall.ECONNREFUSED))
+		Expect(Retried(err)).To(BeFalse())
+	})
+
+	It("should retry requests of getters", func() {
+		failures, status = 1, http.StatusBadGateway
+		data, err := (&HTTPGetter{Retry: &retry}).GetAll(server.URL + "/file")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("content"))
+		Expect(requests).To(Equal(2))
+	})
+
+	DescribeTable("backoff should grow exponentially with jitter",
+		func(attempt int, minWait, maxWait time.Duration) {
+			t := &retryTransport{retry: Retry{Backoff: time.Second, MaxBackoff: 5 * time.Second}}
+			for range 20 {
+				wait, ok := t.backoff(attempt, nil)
+				Expect(ok).To(BeTrue())
+				Expect(wait).To(And(BeNumerically(">=", minWait), BeNumerically("<=", maxWait)))
+			}
+		},
+		Entry("first retry", 1, 500*time.Millisecond, time.Second),
+		Entry("second retry", 2, time.Second, 2*time.Second),
+		Entry("capped", 5, 2500*time.Millisecond, 5*time.Second),
+	)
+})
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	robotsPath = "/robots.txt"
+	// maxRobotsSize limits how much of a robots.txt is read, like crawlers do.
+	maxRobotsSize = 512 * 1024
+	// maxCrawlDelay caps the crawl delay of a robots.txt, so a typo can't stall the pipeline for hours.
+	maxCrawlDelay = time.Minute
+	robotsTimeout = 10 * time.Second
+	wildcardAgent = "*"
+)
+
+// robotsCache remembers the crawl delay of every host, robots.txt is only fetched once per host and run.
+type robotsCache struct {
+	mu     sync.Mutex
+	delays map[string]*robotsEntry
+}
+
+type robotsEntry struct {
+	once  sync.Once
+	delay time.Duration
+}
+
+var robots = &robotsCache{delays: map[string]*robotsEntry{}}
+
+// crawlDelay returns the crawl delay the robots.txt of the host of u asks userAgent for. Hosts without
+// robots.txt or which can't be reached have no crawl delay.
+func (c *robotsCache) crawlDelay(ctx context.Context, u *url.URL, userAgent string) time.Duration {
+	origin := u.Scheme + "://" + u.Host
+	c.mu.Lock()
+	entry, exists := c.delays[origin]
+	if !exists {
+		entry = &robotsEntry{}
+		c.delays[origin] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.delay = fetchCrawlDelay(ctx, origin, userAgent)
+	})
+	return entry.delay
+}
+
+func fetchCrawlDelay(ctx context.Context, origin, userAgent string) time.Duration {
+	// The request must not be canceled with the download it is made for, other downloads rely on the result
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), robotsTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+robotsPath, http.NoBody)
+	if err != nil {
+		return 0
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := http.DefaultClient.Do(req) //nolint:gosec // G704: the host is one of the configured upstreams
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+	if err != nil {
+		return 0
+	}
+	return min(parseCrawlDelay(data, agentToken(userAgent)), maxCrawlDelay)
+}
+
+// agentToken returns the product token of a User-Agent robots.txt groups are matched against,
+// e.g. medius for "medius (+https://github.com/kubevirt/containerdisks)".
+func agentToken(userAgent string) string {
+	token, _, _ := strings.Cut(userAgent, " ")
+	token, _, _ = strings.Cut(token, "/")
+	return strings.ToLower(token)
+}
+
+// parseCrawlDelay returns the Crawl-delay of the group matching agent, or of the wildcard group if no group
+// matches. Rules other than Crawl-delay are ignored, medius only downloads the files providers point it to.
+func parseCrawlDelay(data []byte, agent string) time.Duration {
+	delays := map[string]time.Duration{}
+	var groupAgents []string
+	inRules := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		field, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		field, value = strings.ToLower(strings.TrimSpace(field)), strings.TrimSpace(value)
+
+		switch field {
+		case "user-agent":
+			// A User-agent line after rules starts a new group
+			if inRules {
+				groupAgents, inRules = nil, false
+			}
+			groupAgent := strings.ToLower(value)
+			groupAgents = append(groupAgents, groupAgent)
+			// The group of an agent applies even if it has no Crawl-delay
+			if _, exists := delays[groupAgent]; !exists {
+				delays[groupAgent] = 0
+			}
+		case "crawl-delay":
+			inRules = true
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err != nil || seconds < 0 {
+				continue
+			}
+			for _, groupAgent := range groupAgents {
+				delays[groupAgent] = time.Duration(seconds * float64(time.Second))
+			}
+		default:
+			inRules = true
+		}
+	}
+
+	if delay, exists := delays[agent]; exists {
+		return delay
+	}
+	return delays[wildcardAgent]
+}
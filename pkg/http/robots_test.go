@@ -0,0 +1,40 @@
+package http
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Robots", func() {
+	const robotsTxt = `# Mirror policy
+User-agent: googlebot
+User-agent: bingbot
+Disallow: /private/
+Crawl-delay: 1
+
+User-agent: medius
+Crawl-delay: 2.5 # seconds
+
+User-agent: *
+Crawl-delay: 10
+`
+
+	DescribeTable("parseCrawlDelay should use the group of the agent",
+		func(data, agent string, expected time.Duration) {
+			Expect(parseCrawlDelay([]byte(data), agent)).To(Equal(expected))
+		},
+		Entry("specific group", robotsTxt, "medius", 2500*time.Millisecond),
+		Entry("group of several agents", robotsTxt, "bingbot", time.Second),
+		Entry("wildcard group", robotsTxt, "curl", 10*time.Second),
+		Entry("specific group without delay", "User-agent: medius\nDisallow: /\n\nUser-agent: *\nCrawl-delay: 5\n", "medius", time.Duration(0)),
+		Entry("invalid delay", "User-agent: *\nCrawl-delay: soon\n", "medius", time.Duration(0)),
+		Entry("no robots.txt", "", "medius", time.Duration(0)),
+	)
+
+	It("agentToken should return the product token", func() {
+		Expect(agentToken(DefaultUserAgent)).To(Equal("medius"))
+		Expect(agentToken("Medius/1.2 (+https://example.com)")).To(Equal("medius"))
+	})
+})
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/openpgp"           //nolint:staticcheck // upstream signs its checksum files with OpenPGP
+	"golang.org/x/crypto/openpgp/clearsign" //nolint:staticcheck // upstream signs its checksum files with OpenPGP
+)
+
+// SignatureVerifier is implemented by getters which verify the OpenPGP signatures of clearsigned files.
+type SignatureVerifier interface {
+	GetVerifiedWithContext(ctx context.Context, fileURL string) ([]byte, error)
+}
+
+// Keyring holds the OpenPGP keys upstream signs its checksum files with.
+type Keyring struct {
+	entities openpgp.EntityList
+	// id identifies the keys of the keyring, so verification results of other keyrings are not reused.
+	id string
+}
+
+var (
+	defaultKeyringMu sync.RWMutex
+	defaultKeyring   *Keyring
+)
+
+// SetDefaultKeyring sets the keyring verifying signed files of all HTTPGetters. Signatures are not
+// verified if it is nil.
+func SetDefaultKeyring(keyring *Keyring) {
+	defaultKeyringMu.Lock()
+	defer defaultKeyringMu.Unlock()
+	defaultKeyring = keyring
+}
+
+func getDefaultKeyring() *Keyring {
+	defaultKeyringMu.RLock()
+	defer defaultKeyringMu.RUnlock()
+	return defaultKeyring
+}
+
+// LoadKeyring reads a file of ASCII armored OpenPGP public keys, e.g. the keys of https://fedoraproject.org/fedora.gpg.
+func LoadKeyring(fileName string) (*Keyring, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading keyring: %v", err)
+	}
+	return ParseKeyring(data)
+}
+
+// ParseKeyring parses ASCII armored OpenPGP public keys.
+func ParseKeyring(armored []byte) (*Keyring, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(armored))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing keyring: %v", err)
+	}
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("keyring contains no keys")
+	}
+
+	fingerprints := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		fingerprints = append(fingerprints, hex.EncodeToString(entity.PrimaryKey.Fingerprint[:]))
+	}
+	slices.Sort(fingerprints)
+	sum := sha256.Sum256([]byte(strings.Join(fingerprints, ",")))
+
+	return &Keyring{entities: entities, id: hex.EncodeToString(sum[:])}, nil
+}
+
+// verify checks the signature of a clearsigned file and returns the signed text along with the key ID of the signer.
+func (k *Keyring) verify(fileURL string, signed []byte) (text []byte, signer string, err error) {
+	block, _ := clearsign.Decode(signed)
+	if block == nil {
+		return nil, "", fmt.Errorf("%s is not clearsigned", fileURL)
+	}
+	entity, err := openpgp.CheckDetachedSignature(k.entities, bytes.NewReader(block.Bytes), block.ArmoredSignature.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("error verifying the signature of %s: %v", fileURL, err)
+	}
+	return block.Plaintext, entity.PrimaryKey.KeyIdString(), nil
+}
+
+// GetVerified returns the signed text of the clearsigned file at fileURL, after verifying its signature with
+// the default keyring. Files are returned unverified if no keyring is set.
+func (h *HTTPGetter) GetVerified(fileURL string) ([]byte, error) {
+	return h.GetVerifiedWithContext(context.Background(), fileURL)
+}
+
+func (h *HTTPGetter) GetVerifiedWithContext(ctx context.Context, fileURL string) ([]byte, error) {
+	signed, err := h.GetAllWithContext(ctx, fileURL)
+	if err != nil {
+		return nil, err
+	}
+	keyring := getDefaultKeyring()
+	if keyring == nil {
+		return signed, nil
+	}
+
+	// Files which upstream confirmed to be unchanged have been verified by a previous invocation already
+	if cache.verified(fileURL, signed, keyring.id) {
+		if block, _ := clearsign.Decode(signed); block != nil {
+			return block.Plaintext, nil
+		}
+	}
+	text, signer, err := keyring.verify(fileURL, signed)
+	if err != nil {
+		return nil, err
+	}
+	cache.recordVerification(fileURL, signed, keyring.id, signer)
+
+	return text, nil
+}
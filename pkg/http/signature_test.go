@@ -0,0 +1,138 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+
+	"golang.org/x/crypto/openpgp"           //nolint:staticcheck // upstream signs its checksum files with OpenPGP
+	"golang.org/x/crypto/openpgp/armor"     //nolint:staticcheck // upstream signs its checksum files with OpenPGP
+	"golang.org/x/crypto/openpgp/clearsign" //nolint:staticcheck // upstream signs its checksum files with OpenPGP
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Signature", func() {
+	var (
+		server  *httptest.Server
+		signer  *openpgp.Entity
+		keyring *Keyring
+		version string
+	)
+
+	clearsigned := func(entity *openpgp.Entity, text string) []byte {
+		signed := &bytes.Buffer{}
+		w, err := clearsign.Encode(signed, entity.PrivateKey, nil)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = w.Write([]byte(text))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(w.Close()).To(Succeed())
+		return signed.Bytes()
+	}
+
+	armored := func(entity *openpgp.Entity) []byte {
+		keys := &bytes.Buffer{}
+		w, err := armor.Encode(keys, openpgp.PublicKeyType, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entity.Serialize(w)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+		return keys.Bytes()
+	}
+
+	newInvocation := func() {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		cache.entries = map[string]*cacheEntry{}
+	}
+
+	BeforeEach(func() {
+		var err error
+		signer, err = openpgp.NewEntity("Upstream", "", "upstream@example.com", nil)
+		Expect(err).ToNot(HaveOccurred())
+		keyring, err = ParseKeyring(armored(signer))
+		Expect(err).ToNot(HaveOccurred())
+
+		version = "v1"
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			etag := `"` + version + `"`
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			_, _ = w.Write(clearsigned(signer, "SHA256 (disk.qcow2) = "+version+"\n"))
+		}))
+		DeferCleanup(server.Close)
+		DeferCleanup(func() {
+			SetDefaultKeyring(nil)
+			Expect(SetCacheOptions(CacheOptions{})).To(Succeed())
+			newInvocation()
+		})
+		Expect(SetCacheOptions(CacheOptions{Dir: GinkgoT().TempDir()})).To(Succeed())
+	})
+
+	It("should return the signed text of files signed by a key of the keyring", func() {
+		SetDefaultKeyring(keyring)
+		text, err := (&HTTPGetter{}).GetVerified(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(text)).To(Equal("SHA256 (disk.qcow2) = v1\n"))
+	})
+
+	It("should fail on files signed by other keys", func() {
+		other, err := openpgp.NewEntity("Other", "", "other@example.com", nil)
+		Expect(err).ToNot(HaveOccurred())
+		otherKeyring, err := ParseKeyring(armored(other))
+		Expect(err).ToNot(HaveOccurred())
+
+		SetDefaultKeyring(otherKeyring)
+		_, err = (&HTTPGetter{}).GetVerified(server.URL + "/CHECKSUM")
+		Expect(err).To(MatchError(ContainSubstring("error verifying the signature")))
+	})
+
+	It("should fail on files which are not clearsigned", func() {
+		unsigned := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("SHA256 (disk.qcow2) = v1\n"))
+		}))
+		DeferCleanup(unsigned.Close)
+
+		SetDefaultKeyring(keyring)
+		_, err := (&HTTPGetter{}).GetVerified(unsigned.URL + "/CHECKSUM")
+		Expect(err).To(MatchError(ContainSubstring("is not clearsigned")))
+	})
+
+	It("should return files unverified without keyring", func() {
+		data, err := (&HTTPGetter{}).GetVerified(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(HavePrefix("-----BEGIN PGP SIGNED MESSAGE-----"))
+	})
+
+	It("should reuse the verification of unchanged files until they change", func() {
+		SetDefaultKeyring(keyring)
+		_, err := (&HTTPGetter{}).GetVerified(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+
+		// The same keyring without keys fails every verification, so only the cached outcome can succeed
+		SetDefaultKeyring(&Keyring{id: keyring.id})
+		newInvocation()
+		text, err := (&HTTPGetter{}).GetVerified(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(text)).To(Equal("SHA256 (disk.qcow2) = v1\n"))
+
+		version = "v2"
+		newInvocation()
+		_, err = (&HTTPGetter{}).GetVerified(server.URL + "/CHECKSUM")
+		Expect(err).To(MatchError(ContainSubstring("error verifying the signature")))
+	})
+
+	It("should not reuse the verification of other keyrings", func() {
+		SetDefaultKeyring(keyring)
+		_, err := (&HTTPGetter{}).GetVerified(server.URL + "/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+
+		SetDefaultKeyring(&Keyring{id: "other"})
+		newInvocation()
+		_, err = (&HTTPGetter{}).GetVerified(server.URL + "/CHECKSUM")
+		Expect(err).To(MatchError(ContainSubstring("error verifying the signature")))
+	})
+})
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"regexp"
+	"sync"
+)
+
+// WaybackArchive is the URL prefix of the Wayback Machine, under which archived copies of upstream files are served.
+const WaybackArchive = "https://web.archive.org/web/"
+
+var timestampRegexp = regexp.MustCompile(`^[0-9]{4,14}$`)
+
+// Snapshot selects an archived state of upstream instead of the live one, to find out when and why
+// an upstream layout change broke inspecting an artifact.
+type Snapshot struct {
+	// Timestamp loads files as archived by the Wayback Machine closest to it, e.g. 20240115 or 20240115093000.
+	Timestamp string
+	// Dir replays files previously recorded by a RecordingGetter, e.g. the test fixtures of a provider.
+	Dir string
+	// Archive is the URL prefix of the archive, defaults to WaybackArchive.
+	Archive string
+}
+
+var (
+	defaultSnapshotMu sync.RWMutex
+	defaultSnapshot   Snapshot
+)
+
+// SetDefaultSnapshot makes all HTTPGetters load files from the snapshot instead of upstream.
+// Overrides are not applied to snapshots.
+func SetDefaultSnapshot(snapshot Snapshot) error {
+	if snapshot.Timestamp != "" && snapshot.Dir != "" {
+		return errors.New("a snapshot can either be loaded from an archive or from a directory")
+	}
+	if snapshot.Timestamp != "" && !timestampRegexp.MatchString(snapshot.Timestamp) {
+		return fmt.Errorf("invalid snapshot timestamp %q, expected YYYY[MMDD[hhmmss]]", snapshot.Timestamp)
+	}
+
+	defaultSnapshotMu.Lock()
+	defer defaultSnapshotMu.Unlock()
+	defaultSnapshot = snapshot
+	return nil
+}
+
+func getDefaultSnapshot() Snapshot {
+	defaultSnapshotMu.RLock()
+	defer defaultSnapshotMu.RUnlock()
+	return defaultSnapshot
+}
+
+func (s Snapshot) enabled() bool {
+	return s.Timestamp != "" || s.Dir != ""
+}
+
+// ArchivedURL returns the URL of the archived copy of fileURL. The id_ suffix makes the Wayback Machine
+// serve the original file, without rewriting links in directory listings.
+func (s Snapshot) ArchivedURL(fileURL string) string {
+	archive := s.Archive
+	if archive == "" {
+		archive = WaybackArchive
+	}
+	return archive + s.Timestamp + "id_/" + fileURL
+}
+
+func (h *HTTPGetter) getAllFromSnapshot(ctx context.Context, snapshot Snapshot, fileURL string) ([]byte, error) {
+	if snapshot.Dir != "" {
+		return NewRecordingGetter(snapshot.Dir, RecordModeReplay, nil).GetAllWithContext(ctx, fileURL)
+	}
+
+	body, err := h.getAll(ctx, snapshot.ArchivedURL(fileURL))
+	if err != nil {
+		return nil, fmt.Errorf("error loading %s as of %s: %v", fileURL, snapshot.Timestamp, err)
+	}
+	return body, nil
+}
+
+func (h *HTTPGetter) getWithChecksumFromSnapshot(ctx context.Context, snapshot Snapshot, fileURL string, checksumHasher func() hash.Hash) (
+	ReadCloserWithChecksum, error,
+) {
+	if snapshot.Dir != "" {
+		return NewRecordingGetter(snapshot.Dir, RecordModeReplay, nil).GetWithChecksumAndContext(ctx, fileURL, checksumHasher)
+	}
+
+	body, _, err := h.getFile(ctx, snapshot.ArchivedURL(fileURL))
+	if err != nil {
+		return nil, fmt.Errorf("error loading %s as of %s: %v", fileURL, snapshot.Timestamp, err)
+	}
+	return newReadCloserWithChecksum(body, checksumHasher), nil
+}
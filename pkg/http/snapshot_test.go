@@ -0,0 +1,66 @@
+package http
+
+import (
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshot", func() {
+	AfterEach(func() {
+		Expect(SetDefaultSnapshot(Snapshot{})).To(Succeed())
+	})
+
+	It("should load files from the archive as of the timestamp", func() {
+		var paths []string
+		archive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			_, _ = w.Write([]byte("archived"))
+		}))
+		DeferCleanup(archive.Close)
+		Expect(SetDefaultSnapshot(Snapshot{Timestamp: "20240115", Archive: archive.URL + "/web/"})).To(Succeed())
+
+		getter := &HTTPGetter{}
+		body, err := getter.GetAll("https://upstream.example.com/fedora/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(Equal("archived"))
+
+		readCloser, err := getter.GetWithChecksum("https://upstream.example.com/fedora/disk.qcow2", sha256.New)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = io.ReadAll(readCloser)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readCloser.Close()).To(Succeed())
+
+		Expect(paths).To(Equal([]string{
+			"/web/20240115id_/https://upstream.example.com/fedora/CHECKSUM",
+			"/web/20240115id_/https://upstream.example.com/fedora/disk.qcow2",
+		}))
+	})
+
+	It("should replay files recorded to a directory", func() {
+		dir := GinkgoT().TempDir()
+		recordingPath, err := NewRecordingGetter(dir, RecordModeReplay, nil).RecordingPath("https://upstream.example.com/fedora/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Dir(recordingPath), 0o755)).To(Succeed())
+		Expect(os.WriteFile(recordingPath, []byte("recorded"), 0o600)).To(Succeed())
+		Expect(SetDefaultSnapshot(Snapshot{Dir: dir})).To(Succeed())
+
+		body, err := (&HTTPGetter{}).GetAll("https://upstream.example.com/fedora/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(Equal("recorded"))
+
+		_, err = (&HTTPGetter{}).GetAll("https://upstream.example.com/fedora/")
+		Expect(err).To(MatchError(ContainSubstring("no recording")))
+	})
+
+	It("should reject invalid snapshots", func() {
+		Expect(SetDefaultSnapshot(Snapshot{Timestamp: "2024-01-15"})).To(MatchError(ContainSubstring("invalid snapshot timestamp")))
+		Expect(SetDefaultSnapshot(Snapshot{Timestamp: "20240115", Dir: "fixtures"})).ToNot(Succeed())
+	})
+})
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+)
+
+// DefaultSpoolThreshold is the size above which spooled responses are moved from memory to disk.
+const DefaultSpoolThreshold = 32 << 20
+
+// SpooledFile holds a response which was read completely, either in memory or, above a size
+// threshold, in a temporary file. Its checksum is calculated incrementally while spooling.
+type SpooledFile struct {
+	io.ReadSeeker
+	file     *os.File
+	size     int64
+	checksum string
+}
+
+// Spool reads r completely and keeps the content in memory as long as it does not exceed threshold bytes,
+// larger content is spooled to a temporary file. If expectedChecksum is not empty, the content has to match it.
+func Spool(r io.Reader, threshold int64, checksumHasher func() hash.Hash, expectedChecksum string) (*SpooledFile, error) {
+	checksum := checksumHasher()
+	tee := io.TeeReader(r, checksum)
+
+	buf := &bytes.Buffer{}
+	n, err := io.CopyN(buf, tee, threshold+1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error spooling response: %v", err)
+	}
+
+	spooled := &SpooledFile{size: n}
+	if n <= threshold {
+		spooled.ReadSeeker = bytes.NewReader(buf.Bytes())
+	} else if err := spooled.spoolToDisk(buf, tee); err != nil {
+		return nil, err
+	}
+
+	spooled.checksum = hex.EncodeToString(checksum.Sum(nil))
+	if expectedChecksum != "" && spooled.checksum != expectedChecksum {
+		_ = spooled.Close()
+		return nil, fmt.Errorf("expected checksum %q but got %q", expectedChecksum, spooled.checksum)
+	}
+
+	return spooled, nil
+}
+
+// SpoolWithChecksum downloads fileURL with the getter and spools it with DefaultSpoolThreshold.
+func SpoolWithChecksum(ctx context.Context, getter Getter, fileURL string, checksumHasher func() hash.Hash) (*SpooledFile, error) {
+	reader, err := getter.GetWithChecksumAndContext(ctx, fileURL, checksumHasher)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	spooled, err := Spool(reader, DefaultSpoolThreshold, checksumHasher, "")
+	if err != nil {
+		return nil, fmt.Errorf("error spooling %s: %v", fileURL, err)
+	}
+
+	// Both checksums are calculated from the same stream, a mismatch means the spool is corrupted
+	if spooled.Checksum() != reader.Checksum() {
+		_ = spooled.Close()
+		return nil, fmt.Errorf("spooled content of %s is corrupted", fileURL)
+	}
+
+	return spooled, nil
+}
+
+func (s *SpooledFile) spoolToDisk(head *bytes.Buffer, rest io.Reader) error {
+	file, err := os.CreateTemp("", "medius-spool")
+	if err != nil {
+		return fmt.Errorf("error creating spool file: %v", err)
+	}
+	s.file = file
+
+	if _, err := head.WriteTo(file); err != nil {
+		_ = s.Close()
+		return fmt.Errorf("error writing spool file: %v", err)
+	}
+	n, err := io.Copy(file, rest)
+	if err != nil {
+		_ = s.Close()
+		return fmt.Errorf("error writing spool file: %v", err)
+	}
+	s.size += n
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		_ = s.Close()
+		return fmt.Errorf("error rewinding spool file: %v", err)
+	}
+	s.ReadSeeker = file
+
+	return nil
+}
+
+// Size returns the number of spooled bytes.
+func (s *SpooledFile) Size() int64 {
+	return s.size
+}
+
+// Checksum returns the hex encoded checksum of the spooled content.
+func (s *SpooledFile) Checksum() string {
+	return s.checksum
+}
+
+// OnDisk reports if the content was spooled to a temporary file.
+func (s *SpooledFile) OnDisk() bool {
+	return s.file != nil
+}
+
+// Close removes the temporary file, if any.
+func (s *SpooledFile) Close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	closeErr := s.file.Close()
+	removeErr := os.Remove(s.file.Name())
+	s.file = nil
+
+	return errors.Join(closeErr, removeErr)
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Spool", func() {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	DescribeTable("should spool content and calculate its checksum",
+		func(threshold int64, onDisk bool) {
+			spooled, err := Spool(bytes.NewReader(content), threshold, sha256.New, checksum)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(spooled.Close)
+
+			Expect(spooled.OnDisk()).To(Equal(onDisk))
+			Expect(spooled.Size()).To(BeEquivalentTo(len(content)))
+			Expect(spooled.Checksum()).To(Equal(checksum))
+			data, err := io.ReadAll(spooled)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal(content))
+		},
+		Entry("in memory", int64(len(content)), false),
+		Entry("on disk", int64(len(content)-1), true),
+	)
+
+	It("should remove the spool file on close", func() {
+		spooled, err := Spool(bytes.NewReader(content), 10, sha256.New, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(spooled.OnDisk()).To(BeTrue())
+		fileName := spooled.file.Name()
+		Expect(spooled.Close()).To(Succeed())
+		Expect(fileName).ToNot(BeAnExistingFile())
+	})
+
+	It("should fail on checksum mismatches", func() {
+		_, err := Spool(bytes.NewReader(content), 10, sha256.New, "abcd")
+		Expect(err).To(MatchError(ContainSubstring("expected checksum")))
+	})
+})
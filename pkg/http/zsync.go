@@ -0,0 +1,197 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"kubevirt.io/containerdisks/pkg/buffers"
+	"kubevirt.io/containerdisks/pkg/progress"
+	"kubevirt.io/containerdisks/pkg/zsync"
+)
+
+// ZsyncSuffix is appended to a download URL to request its zsync control file.
+const ZsyncSuffix = ".zsync"
+
+// RangeGetter fetches parts of files.
+type RangeGetter interface {
+	GetRangeWithContext(ctx context.Context, fileURL string, offset, length int64) ([]byte, error)
+}
+
+// ZsyncGetter downloads files with a zsync control file as delta to the previous download of the same
+// URL, which is kept as seed in SeedDir. Only the changed blocks are fetched. All other files, and files
+// whose delta download fails, are downloaded completely by the wrapped Getter.
+type ZsyncGetter struct {
+	Getter
+	Ranges  RangeGetter
+	SeedDir string
+}
+
+func NewZsyncGetter(upstream Getter, seedDir string) *ZsyncGetter {
+	return &ZsyncGetter{Getter: upstream, Ranges: &HTTPGetter{}, SeedDir: seedDir}
+}
+
+func (z *ZsyncGetter) GetWithChecksum(fileURL string, checksumHasher func() hash.Hash) (ReadCloserWithChecksum, error) {
+	return z.GetWithChecksumAndContext(context.Background(), fileURL, checksumHasher)
+}
+
+func (z *ZsyncGetter) GetWithChecksumAndContext(ctx context.Context, fileURL string, checksumHasher func() hash.Hash) (
+	ReadCloserWithChecksum, error,
+) {
+	control, targetURL, err := z.control(ctx, fileURL)
+	if err != nil {
+		return z.Getter.GetWithChecksumAndContext(ctx, fileURL, checksumHasher)
+	}
+
+	seed := z.seedPath(fileURL)
+	if err := z.update(ctx, control, targetURL, seed); err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil, ctx.Err()
+		}
+		return z.Getter.GetWithChecksumAndContext(ctx, fileURL, checksumHasher)
+	}
+
+	file, err := os.Open(seed)
+	if err != nil {
+		return nil, err
+	}
+	return newReadCloserWithChecksum(file, checksumHasher), nil
+}
+
+func (z *ZsyncGetter) control(ctx context.Context, fileURL string) (*zsync.Control, string, error) {
+	data, err := z.Getter.GetAllWithContext(ctx, fileURL+ZsyncSuffix)
+	if err != nil {
+		return nil, "", err
+	}
+	control, err := zsync.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The URL of the target is relative to the zsync file
+	base, err := url.Parse(fileURL + ZsyncSuffix)
+	if err != nil {
+		return nil, "", err
+	}
+	target, err := base.Parse(control.URL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return control, target.String(), nil
+}
+
+// seedPath returns the seed of a URL. Seeds are stored by URL, as the file names of e.g. snapshots don't change.
+func (z *ZsyncGetter) seedPath(fileURL string) string {
+	sum := sha256.Sum256([]byte(fileURL))
+	const prefixLength = 8
+	return filepath.Join(z.SeedDir, hex.EncodeToString(sum[:prefixLength])+"-"+path.Base(fileURL))
+}
+
+// update replaces the seed with the target file. The existing seed is used as delta base.
+func (z *ZsyncGetter) update(ctx context.Context, control *zsync.Control, targetURL, seedPath string) error {
+	const permissionDir = 0o755
+	if err := os.MkdirAll(z.SeedDir, permissionDir); err != nil {
+		return err
+	}
+	out, err := os.CreateTemp(z.SeedDir, ".zsync-*")
+	if err != nil {
+		return err
+	}
+	complete := false
+	defer func() {
+		out.Close()
+		if !complete {
+			os.Remove(out.Name())
+		}
+	}()
+
+	if err := z.assemble(ctx, control, targetURL, seedPath, out); err != nil {
+		return err
+	}
+
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := control.Verify(progress.FromContext(ctx).Hash(out, control.Length)); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(out.Name(), seedPath); err != nil {
+		return err
+	}
+	complete = true
+
+	return nil
+}
+
+func (z *ZsyncGetter) assemble(ctx context.Context, control *zsync.Control, targetURL, seedPath string, out *os.File) error {
+	seed, err := os.Open(seedPath)
+	if errors.Is(err, os.ErrNotExist) {
+		// Without seed the file is downloaded completely and becomes the seed of the next download
+		return z.download(ctx, targetURL, out)
+	}
+	if err != nil {
+		return err
+	}
+	defer seed.Close()
+
+	offsets, err := control.Match(seed)
+	if err != nil {
+		return err
+	}
+	_, err = control.Assemble(out, seed, offsets, func(offset, length int64) ([]byte, error) {
+		return z.Ranges.GetRangeWithContext(ctx, targetURL, offset, length)
+	})
+	return err
+}
+
+func (z *ZsyncGetter) download(ctx context.Context, targetURL string, out io.Writer) error {
+	reader, err := z.Getter.GetWithChecksumAndContext(ctx, targetURL, sha256.New)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = buffers.Copy(out, reader)
+	return err
+}
+
+func (h *HTTPGetter) GetRangeWithContext(ctx context.Context, fileURL string, offset, length int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to %s: %v", fileURL, err)
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+
+	resp, err := h.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load range of %s: %v", fileURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("failed to load range of %s: status %v", fileURL, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, length+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != length {
+		return nil, fmt.Errorf("failed to load range of %s: got %d bytes, expected %d", fileURL, len(data), length)
+	}
+	return data, nil
+}
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/zsync"
+)
+
+var _ = Describe("ZsyncGetter", func() {
+	const blocksize = 1024
+
+	var (
+		server      *httptest.Server
+		disk        []byte
+		publishSync bool
+		mu          sync.Mutex
+		ranges      []string
+		fullGets    int
+	)
+
+	BeforeEach(func() {
+		disk = make([]byte, 64*blocksize)
+		rand.New(rand.NewSource(1)).Read(disk) //nolint:gosec // deterministic test data
+		publishSync = true
+		ranges = nil
+		fullGets = 0
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/disk.img":
+				mu.Lock()
+				if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+					ranges = append(ranges, rangeHeader)
+				} else {
+					fullGets++
+				}
+				mu.Unlock()
+				http.ServeContent(w, r, "disk.img", time.Time{}, bytes.NewReader(disk))
+			case "/disk.img.zsync":
+				if !publishSync {
+					http.NotFound(w, r)
+					return
+				}
+				control, err := zsync.Make(bytes.NewReader(disk), "disk.img", blocksize)
+				Expect(err).ToNot(HaveOccurred())
+				_, _ = w.Write(control)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	download := func(getter Getter) string {
+		reader, err := getter.GetWithChecksum(server.URL+"/disk.img", sha256.New)
+		Expect(err).ToNot(HaveOccurred())
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(disk))
+		return reader.Checksum()
+	}
+
+	It("should download the file completely without seed and keep it as seed", func() {
+		seedDir := GinkgoT().TempDir()
+		getter := NewZsyncGetter(&HTTPGetter{}, seedDir)
+
+		sum := sha256.Sum256(disk)
+		Expect(download(getter)).To(Equal(hex.EncodeToString(sum[:])))
+		Expect(fullGets).To(Equal(1))
+		Expect(ranges).To(BeEmpty())
+
+		seed, err := os.ReadFile(getter.seedPath(server.URL + "/disk.img"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(seed).To(Equal(disk))
+	})
+
+	It("should only download changed blocks", func() {
+		getter := NewZsyncGetter(&HTTPGetter{}, GinkgoT().TempDir())
+		download(getter)
+
+		disk = bytes.Clone(disk)
+		copy(disk[10*blocksize+100:], []byte("changed"))
+		download(getter)
+
+		Expect(fullGets).To(Equal(1))
+		Expect(ranges).To(ConsistOf("bytes=10240-11263"))
+	})
+
+	It("should fall back to a full download without zsync file", func() {
+		publishSync = false
+		seedDir := GinkgoT().TempDir()
+		download(NewZsyncGetter(&HTTPGetter{}, seedDir))
+		download(NewZsyncGetter(&HTTPGetter{}, seedDir))
+
+		Expect(fullGets).To(Equal(2))
+		entries, err := os.ReadDir(seedDir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("should fall back to a full download if the delta does not match", func() {
+		getter := NewZsyncGetter(&HTTPGetter{}, GinkgoT().TempDir())
+		download(getter)
+
+		getter.Ranges = rangeGetterFunc(func(offset, length int64) []byte {
+			return []byte(strings.Repeat("x", int(length)))
+		})
+		disk = bytes.Clone(disk)
+		copy(disk[blocksize:], []byte("changed"))
+		download(getter)
+
+		Expect(fullGets).To(Equal(2))
+	})
+})
+
+type rangeGetterFunc func(offset, length int64) []byte
+
+func (f rangeGetterFunc) GetRangeWithContext(_ context.Context, _ string, offset, length int64) ([]byte, error) {
+	return f(offset, length), nil
+}
@@ -0,0 +1,79 @@
+package inventory
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// DriftThreshold is the relative difference of the package counts of two architectures of the same version,
+// above which they are considered to have drifted apart.
+const DriftThreshold = 0.2
+
+// archTokens are the architecture names distributions put into kernel releases, e.g. 6.1.0-18-cloud-amd64.
+var archTokens = map[string]bool{
+	"x86_64": true, "amd64": true, "aarch64": true, "arm64": true, "s390x": true, "ppc64le": true, "ppc64el": true,
+	"riscv64": true,
+}
+
+// Drift compares the inventories of the architectures of the same version, by architecture, and describes
+// discrepancies of the kernel and cloud-init versions and of the package counts. They usually indicate a stale
+// upstream build of one architecture.
+func Drift(inventories map[string]*Inventory) []string {
+	if len(inventories) < 2 {
+		return nil
+	}
+	archs := slices.Sorted(maps.Keys(inventories))
+
+	var drift []string
+	kernels, cloudInits := map[string]string{}, map[string]string{}
+	for _, arch := range archs {
+		kernels[arch] = inventories[arch].Kernel
+		cloudInits[arch] = inventories[arch].CloudInit
+	}
+	if versions := describeIfDiffers(archs, kernels, kernelVersion); versions != "" {
+		drift = append(drift, "kernel versions differ between architectures: "+versions)
+	}
+	if versions := describeIfDiffers(archs, cloudInits, func(v string) string { return v }); versions != "" {
+		drift = append(drift, "cloud-init versions differ between architectures: "+versions)
+	}
+
+	minCount, maxCount := len(inventories[archs[0]].Packages), 0
+	counts := make([]string, 0, len(archs))
+	for _, arch := range archs {
+		count := len(inventories[arch].Packages)
+		minCount, maxCount = min(minCount, count), max(maxCount, count)
+		counts = append(counts, fmt.Sprintf("%s %d", arch, count))
+	}
+	if maxCount > 0 && float64(maxCount-minCount)/float64(maxCount) > DriftThreshold {
+		drift = append(drift, fmt.Sprintf("package counts differ by more than %.0f%% between architectures: %s",
+			DriftThreshold*100, strings.Join(counts, ", ")))
+	}
+
+	return drift
+}
+
+// describeIfDiffers lists the values of all architectures, if they are not equal for all of them after
+// normalizing them. Unknown values are ignored, e.g. of disks without cloud-init.
+func describeIfDiffers(archs []string, values map[string]string, normalize func(string) string) string {
+	var described []string
+	distinct := map[string]bool{}
+	for _, arch := range archs {
+		if v := values[arch]; v != "" {
+			distinct[normalize(v)] = true
+			described = append(described, arch+" "+v)
+		}
+	}
+	if len(distinct) < 2 {
+		return ""
+	}
+	return strings.Join(described, ", ")
+}
+
+// kernelVersion removes the architecture from a kernel release, so the releases of different architectures
+// compare equal, e.g. 6.9.4-200.fc40.x86_64 becomes 6.9.4.200.fc40.
+func kernelVersion(release string) string {
+	tokens := strings.FieldsFunc(release, func(r rune) bool { return r == '.' || r == '-' })
+	return strings.Join(slices.DeleteFunc(tokens, func(token string) bool { return archTokens[token] }), ".")
+}
@@ -0,0 +1,33 @@
+package inventory
+
+import (
+	"context"
+	"slices"
+)
+
+const (
+	// GuestAgentPackage is the name of the qemu-guest-agent package in all supported distributions.
+	GuestAgentPackage = "qemu-guest-agent"
+	// AnnotationGuestAgent is the annotation and label telling whether the disk ships the qemu-guest-agent.
+	AnnotationGuestAgent = "containerdisks.kubevirt.io/guest-agent"
+)
+
+// HasPackage returns true if a package with the name is installed.
+func (i *Inventory) HasPackage(name string) bool {
+	return slices.ContainsFunc(i.Packages, func(p Package) bool {
+		return p.Name == name
+	})
+}
+
+// HasGuestAgent returns true if the qemu-guest-agent is installed. Without it KubeVirt can't report
+// guest information, inject SSH keys with accessCredentials or freeze filesystems for snapshots.
+func (i *Inventory) HasGuestAgent() bool {
+	return i.HasPackage(GuestAgentPackage)
+}
+
+// InjectGuestAgent installs the qemu-guest-agent into the disk with virt-customize. The package manager
+// of the guest downloads the package, so network access is required.
+func InjectGuestAgent(ctx context.Context, disk string) error {
+	_, err := run(ctx, "virt-customize", "-a", disk, "--install", GuestAgentPackage)
+	return err
+}
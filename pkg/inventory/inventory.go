@@ -0,0 +1,165 @@
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"kubevirt.io/containerdisks/pkg/ociartifact"
+	"kubevirt.io/containerdisks/pkg/version"
+)
+
+const (
+	// AnnotationSummary is the annotation and label containing the summary of the inventory,
+	// e.g. "kernel 6.9, cloud-init 24.1".
+	AnnotationSummary = "containerdisks.kubevirt.io/inventory"
+	// MediaType is the media type of the inventory when it is attached to a containerdisk as OCI referrer.
+	MediaType types.MediaType = "application/vnd.kubevirt.containerdisks.inventory.v1+json"
+	// ArtifactConfigMediaType is the config media type, and thereby the artifact type, of the inventory referrer.
+	ArtifactConfigMediaType types.MediaType = "application/vnd.kubevirt.containerdisks.inventory.config.v1+json"
+	// FileName is the name of the inventory in its OCI artifact.
+	FileName = "inventory.json"
+)
+
+// modulesDir contains a directory per installed kernel, named by the kernel release.
+const modulesDir = "/lib/modules"
+
+// Inventory describes the content of a disk image.
+type Inventory struct {
+	// Kernel is the release of the newest installed kernel, e.g. 6.9.4-200.fc40.x86_64.
+	Kernel string `json:"kernel,omitempty"`
+	// CloudInit is the version of the installed cloud-init package.
+	CloudInit string    `json:"cloudInit,omitempty"`
+	Packages  []Package `json:"packages"`
+}
+
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Release string `json:"release,omitempty"`
+	Arch    string `json:"arch,omitempty"`
+}
+
+// Runner runs a command and returns its standard output.
+type Runner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// run is replaced in tests, so libguestfs is not needed to test parsing.
+var run Runner = execRunner
+
+func execRunner(ctx context.Context, name string, args ...string) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("error running %s: %v: %s", name, err, bytes.TrimSpace(exitErr.Stderr))
+	} else if err != nil {
+		return nil, fmt.Errorf("error running %s: %v", name, err)
+	}
+	return out, nil
+}
+
+// Collect inspects the disk image read-only with virt-inspector and virt-ls of guestfs-tools.
+func Collect(ctx context.Context, disk string) (*Inventory, error) {
+	inspection, err := run(ctx, "virt-inspector", "--no-icon", "-a", disk)
+	if err != nil {
+		return nil, err
+	}
+	modules, err := run(ctx, "virt-ls", "-a", disk, modulesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(inspection, modules)
+}
+
+type inspectorOutput struct {
+	OperatingSystems []struct {
+		Applications []struct {
+			Name    string `xml:"name"`
+			Version string `xml:"version"`
+			Release string `xml:"release"`
+			Arch    string `xml:"arch"`
+		} `xml:"applications>application"`
+	} `xml:"operatingsystem"`
+}
+
+// Parse creates an inventory from the XML output of virt-inspector and the listing of the kernel modules directory.
+func Parse(inspection, modules []byte) (*Inventory, error) {
+	var output inspectorOutput
+	if err := xml.Unmarshal(inspection, &output); err != nil {
+		return nil, fmt.Errorf("error parsing virt-inspector output: %v", err)
+	}
+	if len(output.OperatingSystems) == 0 {
+		return nil, errors.New("virt-inspector found no operating system")
+	}
+
+	inventory := &Inventory{Packages: []Package{}}
+	for _, app := range output.OperatingSystems[0].Applications {
+		inventory.Packages = append(inventory.Packages, Package{
+			Name:    app.Name,
+			Version: app.Version,
+			Release: app.Release,
+			Arch:    app.Arch,
+		})
+		if app.Name == "cloud-init" {
+			inventory.CloudInit = app.Version
+		}
+	}
+	slices.SortFunc(inventory.Packages, func(a, b Package) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	inventory.Kernel = version.Latest(strings.Fields(string(modules)))
+	return inventory, nil
+}
+
+// Summary describes the kernel and cloud-init versions briefly, e.g. "kernel 6.9, cloud-init 24.1".
+func (i *Inventory) Summary() string {
+	var parts []string
+	if i.Kernel != "" {
+		parts = append(parts, "kernel "+majorMinor(i.Kernel))
+	}
+	if i.CloudInit != "" {
+		parts = append(parts, "cloud-init "+majorMinor(i.CloudInit))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// majorMinor shortens versions like 6.9.4-200.fc40.x86_64 to 6.9.
+func majorMinor(v string) string {
+	v, _, _ = strings.Cut(v, "-")
+	elements := strings.SplitN(v, ".", 3)
+	const elementsMajorMinor = 2
+	if len(elements) < elementsMajorMinor {
+		return v
+	}
+	return elements[0] + "." + elements[1]
+}
+
+// Artifact packages the inventory as OCI artifact referring to the containerdisk described by subject.
+func (i *Inventory) Artifact(subject v1.Descriptor) (v1.Image, error) {
+	data, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling inventory: %v", err)
+	}
+
+	img, err := ociartifact.New(ArtifactConfigMediaType, ociartifact.File{
+		Name:      FileName,
+		MediaType: MediaType,
+		Data:      append(data, '\n'),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mutate.Subject(img, subject).(v1.Image), nil
+}
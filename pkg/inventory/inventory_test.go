@@ -0,0 +1,142 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const modules = "6.8.5-301.fc40.x86_64\n6.9.4-200.fc40.x86_64\n"
+
+var _ = Describe("Inventory", func() {
+	var inspection []byte
+
+	BeforeEach(func() {
+		var err error
+		inspection, err = os.ReadFile("testdata/virt-inspector.xml")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should parse the packages, kernel and cloud-init version", func() {
+		inventory, err := Parse(inspection, []byte(modules))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inventory.Kernel).To(Equal("6.9.4-200.fc40.x86_64"))
+		Expect(inventory.CloudInit).To(Equal("24.1.4"))
+		Expect(inventory.Packages).To(HaveLen(3))
+		Expect(inventory.Packages[0]).To(Equal(Package{Name: "bash", Version: "5.2.26", Release: "3.fc40", Arch: "x86_64"}))
+		Expect(inventory.Summary()).To(Equal("kernel 6.9, cloud-init 24.1"))
+	})
+
+	It("should fail if no operating system was found", func() {
+		_, err := Parse([]byte("<operatingsystems/>"), nil)
+		Expect(err).To(MatchError(ContainSubstring("no operating system")))
+	})
+
+	It("should summarize partial inventories", func() {
+		Expect((&Inventory{CloudInit: "23"}).Summary()).To(Equal("cloud-init 23"))
+		Expect((&Inventory{}).Summary()).To(BeEmpty())
+	})
+
+	It("should collect the inventory with guestfs-tools", func() {
+		DeferCleanup(func(r Runner) { run = r }, run)
+		var commands []string
+		run = func(_ context.Context, name string, args ...string) ([]byte, error) {
+			commands = append(commands, name+" "+strings.Join(args, " "))
+			if name == "virt-inspector" {
+				return inspection, nil
+			}
+			return []byte(modules), nil
+		}
+
+		inventory, err := Collect(context.Background(), "disk.qcow2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inventory.Kernel).To(Equal("6.9.4-200.fc40.x86_64"))
+		Expect(commands).To(Equal([]string{"virt-inspector --no-icon -a disk.qcow2", "virt-ls -a disk.qcow2 /lib/modules"}))
+
+		run = func(context.Context, string, ...string) ([]byte, error) {
+			return nil, errors.New("libguestfs is not installed")
+		}
+		_, err = Collect(context.Background(), "disk.qcow2")
+		Expect(err).To(MatchError("libguestfs is not installed"))
+	})
+
+	It("should detect the qemu-guest-agent", func() {
+		inventory, err := Parse(inspection, []byte(modules))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inventory.HasPackage("bash")).To(BeTrue())
+		Expect(inventory.HasGuestAgent()).To(BeFalse())
+
+		inventory.Packages = append(inventory.Packages, Package{Name: GuestAgentPackage, Version: "8.2.2"})
+		Expect(inventory.HasGuestAgent()).To(BeTrue())
+	})
+
+	It("should inject the qemu-guest-agent with virt-customize", func() {
+		DeferCleanup(func(r Runner) { run = r }, run)
+		var command string
+		run = func(_ context.Context, name string, args ...string) ([]byte, error) {
+			command = name + " " + strings.Join(args, " ")
+			return nil, nil
+		}
+
+		Expect(InjectGuestAgent(context.Background(), "disk.qcow2")).To(Succeed())
+		Expect(command).To(Equal("virt-customize -a disk.qcow2 --install qemu-guest-agent"))
+	})
+
+	It("should package the inventory as referrer of the containerdisk", func() {
+		subject := v1.Descriptor{
+			MediaType: "application/vnd.oci.image.manifest.v1+json",
+			Digest:    v1.Hash{Algorithm: "sha256", Hex: strings.Repeat("a", 64)},
+			Size:      1234,
+		}
+		img, err := (&Inventory{Kernel: "6.9.4"}).Artifact(subject)
+		Expect(err).ToNot(HaveOccurred())
+
+		manifest, err := img.Manifest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Subject).To(Equal(&subject))
+		Expect(manifest.Config.MediaType).To(Equal(ArtifactConfigMediaType))
+		Expect(manifest.Layers).To(HaveLen(1))
+		Expect(manifest.Layers[0].MediaType).To(Equal(MediaType))
+	})
+})
+
+var _ = Describe("Drift", func() {
+	packages := func(count int) []Package {
+		return make([]Package, count)
+	}
+
+	It("should ignore single architectures", func() {
+		Expect(Drift(map[string]*Inventory{"x86_64": {Kernel: "6.9.4-200.fc40.x86_64"}})).To(BeEmpty())
+	})
+
+	It("should ignore the architecture in kernel releases", func() {
+		Expect(Drift(map[string]*Inventory{
+			"x86_64":  {Kernel: "6.1.0-18-cloud-amd64", CloudInit: "22.4.2", Packages: packages(100)},
+			"aarch64": {Kernel: "6.1.0-18-cloud-arm64", CloudInit: "22.4.2", Packages: packages(90)},
+		})).To(BeEmpty())
+	})
+
+	It("should describe differing versions and package counts", func() {
+		Expect(Drift(map[string]*Inventory{
+			"x86_64":  {Kernel: "6.9.4-200.fc40.x86_64", CloudInit: "24.1", Packages: packages(400)},
+			"aarch64": {Kernel: "6.8.5-301.fc40.aarch64", Packages: packages(300)},
+			"s390x":   {Kernel: "6.9.4-200.fc40.s390x", CloudInit: "23.4", Packages: packages(390)},
+		})).To(Equal([]string{
+			"kernel versions differ between architectures: aarch64 6.8.5-301.fc40.aarch64, s390x 6.9.4-200.fc40.s390x, " +
+				"x86_64 6.9.4-200.fc40.x86_64",
+			"cloud-init versions differ between architectures: s390x 23.4, x86_64 24.1",
+			"package counts differ by more than 20% between architectures: aarch64 300, s390x 390, x86_64 400",
+		}))
+	})
+})
+
+func TestInventory(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Inventory Suite")
+}
@@ -0,0 +1,94 @@
+package licenses
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// NoAssertion is reported as license of containerdisks which do not declare one, as defined by SPDX.
+const NoAssertion = "NOASSERTION"
+
+// Report summarizes the licenses of all published containerdisks, e.g. for enterprises redistributing them.
+type Report struct {
+	Generated      time.Time       `json:"generated"`
+	ContainerDisks []ContainerDisk `json:"containerdisks"`
+}
+
+type ContainerDisk struct {
+	// Image is the pullable reference of the containerdisk, e.g. quay.io/containerdisks/fedora:43.
+	Image string `json:"image"`
+	// Digest of the image (index) the reference currently points to.
+	Digest        string   `json:"digest,omitempty"`
+	Architectures []string `json:"architectures"`
+	// License is the SPDX license expression of the OS.
+	License string `json:"license"`
+	// Redistributable is false for containerdisks which may only be published to private registries.
+	Redistributable bool `json:"redistributable"`
+}
+
+func New(generated time.Time) *Report {
+	return &Report{Generated: generated.UTC()}
+}
+
+// Add adds a containerdisk to the report, containerdisks without license are reported with NoAssertion.
+func (r *Report) Add(containerDisk ContainerDisk) {
+	if containerDisk.License == "" {
+		containerDisk.License = NoAssertion
+	}
+	r.ContainerDisks = append(r.ContainerDisks, containerDisk)
+	slices.SortFunc(r.ContainerDisks, func(a, b ContainerDisk) int {
+		return strings.Compare(a.Image, b.Image)
+	})
+}
+
+// Licenses returns the images of the containerdisks by license.
+func (r *Report) Licenses() map[string][]string {
+	licenses := map[string][]string{}
+	for _, containerDisk := range r.ContainerDisks {
+		licenses[containerDisk.License] = append(licenses[containerDisk.License], containerDisk.Image)
+	}
+	return licenses
+}
+
+// Undeclared returns the images of the containerdisks without license.
+func (r *Report) Undeclared() []string {
+	return r.Licenses()[NoAssertion]
+}
+
+func (r *Report) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling license report: %v", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Markdown renders the report as a table of all containerdisks followed by a summary per license.
+func (r *Report) Markdown() []byte {
+	var b strings.Builder
+	b.WriteString("# Containerdisk licenses\n\n")
+	fmt.Fprintf(&b, "Generated at %s.\n\n", r.Generated.Format(time.RFC3339))
+	b.WriteString("| Image | Architectures | License | Redistributable |\n")
+	b.WriteString("|-------|---------------|---------|-----------------|\n")
+	for _, containerDisk := range r.ContainerDisks {
+		redistributable := "yes"
+		if !containerDisk.Redistributable {
+			redistributable = "no"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", containerDisk.Image,
+			strings.Join(containerDisk.Architectures, ", "), containerDisk.License, redistributable)
+	}
+
+	b.WriteString("\n## Summary\n\n")
+	b.WriteString("| License | Containerdisks |\n")
+	b.WriteString("|---------|----------------|\n")
+	licenses := r.Licenses()
+	for _, license := range slices.Sorted(maps.Keys(licenses)) {
+		fmt.Fprintf(&b, "| %s | %d |\n", license, len(licenses[license]))
+	}
+	return []byte(b.String())
+}
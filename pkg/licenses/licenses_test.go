@@ -0,0 +1,72 @@
+package licenses
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Licenses", func() {
+	var r *Report
+
+	ginkgo.BeforeEach(func() {
+		r = New(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC))
+		r.Add(ContainerDisk{
+			Image:           "quay.io/containerdisks/ubuntu:24.04",
+			Architectures:   []string{"x86_64"},
+			License:         "LicenseRef-Ubuntu",
+			Redistributable: true,
+		})
+		r.Add(ContainerDisk{
+			Image:           "quay.io/containerdisks/fedora:43",
+			Architectures:   []string{"x86_64", "aarch64"},
+			License:         "LicenseRef-Fedora",
+			Redistributable: true,
+		})
+		r.Add(ContainerDisk{Image: "registry.example.com/containerdisks/appliance:1", Architectures: []string{"x86_64"}})
+	})
+
+	ginkgo.It("should report containerdisks without license", func() {
+		Expect(r.ContainerDisks[0].Image).To(Equal("quay.io/containerdisks/fedora:43"))
+		Expect(r.Undeclared()).To(ConsistOf("registry.example.com/containerdisks/appliance:1"))
+		Expect(r.Licenses()).To(HaveKeyWithValue("LicenseRef-Fedora", []string{"quay.io/containerdisks/fedora:43"}))
+	})
+
+	ginkgo.It("should marshal the report", func() {
+		data, err := r.Marshal()
+		Expect(err).ToNot(HaveOccurred())
+
+		var parsed Report
+		Expect(json.Unmarshal(data, &parsed)).To(Succeed())
+		Expect(parsed).To(Equal(*r))
+	})
+
+	ginkgo.It("should render the report as Markdown", func() {
+		Expect(string(r.Markdown())).To(Equal(`# Containerdisk licenses
+
+Generated at 2026-10-17T12:00:00Z.
+
+| Image | Architectures | License | Redistributable |
+|-------|---------------|---------|-----------------|
+| quay.io/containerdisks/fedora:43 | x86_64, aarch64 | LicenseRef-Fedora | yes |
+| quay.io/containerdisks/ubuntu:24.04 | x86_64 | LicenseRef-Ubuntu | yes |
+| registry.example.com/containerdisks/appliance:1 | x86_64 | NOASSERTION | no |
+
+## Summary
+
+| License | Containerdisks |
+|---------|----------------|
+| LicenseRef-Fedora | 1 |
+| LicenseRef-Ubuntu | 1 |
+| NOASSERTION | 1 |
+`))
+	})
+})
+
+func TestLicenses(t *testing.T) {
+	RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "Licenses Suite")
+}
@@ -0,0 +1,108 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+// LockedArtifact pins the upstream disk an artifact resolved to at the time the lock file was generated.
+type LockedArtifact struct {
+	Checksum    string `json:"checksum"`
+	DownloadURL string `json:"downloadURL"`
+}
+
+// Lock maps artifact descriptions (name:version) and architectures to the pinned upstream disks.
+type Lock struct {
+	Artifacts map[string]map[string]LockedArtifact `json:"artifacts"`
+}
+
+func New() *Lock {
+	return &Lock{Artifacts: map[string]map[string]LockedArtifact{}}
+}
+
+// Add inspects the artifact and pins its current upstream disk.
+func (l *Lock) Add(artifact api.Artifact) error {
+	metadata := artifact.Metadata()
+	details, err := artifact.Inspect()
+	if err != nil {
+		return fmt.Errorf("error introspecting artifact %q: %v", metadata.Describe(), err)
+	}
+
+	if l.Artifacts[metadata.Describe()] == nil {
+		l.Artifacts[metadata.Describe()] = map[string]LockedArtifact{}
+	}
+	l.Artifacts[metadata.Describe()][metadata.Arch] = LockedArtifact{
+		Checksum:    details.Checksum,
+		DownloadURL: details.DownloadURL,
+	}
+
+	return nil
+}
+
+// Get returns the pinned upstream disk of an artifact.
+func (l *Lock) Get(artifact api.Artifact) (LockedArtifact, bool) {
+	metadata := artifact.Metadata()
+	locked, ok := l.Artifacts[metadata.Describe()][metadata.Arch]
+	return locked, ok
+}
+
+// Locked wraps the artifact, so that Inspect fails if upstream does not serve the pinned disk anymore.
+func (l *Lock) Locked(artifact api.Artifact) api.Artifact {
+	return &lockedArtifact{Artifact: artifact, lock: l}
+}
+
+func Read(fileName string) (*Lock, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading lock file: %v", err)
+	}
+
+	lock := New()
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("error parsing lock file %s: %v", fileName, err)
+	}
+
+	return lock, nil
+}
+
+func (l *Lock) Write(fileName string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	const permissionFile = 0o644
+	if err := os.WriteFile(fileName, append(data, '\n'), permissionFile); err != nil {
+		return fmt.Errorf("error writing lock file %s: %v", fileName, err)
+	}
+
+	return nil
+}
+
+type lockedArtifact struct {
+	api.Artifact
+	lock *Lock
+}
+
+func (a *lockedArtifact) Inspect() (*api.ArtifactDetails, error) {
+	description := a.Metadata().Describe()
+	locked, ok := a.lock.Get(a.Artifact)
+	if !ok {
+		return nil, fmt.Errorf("artifact %q (%s) is not in the lock file", description, a.Metadata().Arch)
+	}
+
+	details, err := a.Artifact.Inspect()
+	if err != nil {
+		return nil, err
+	}
+
+	if details.Checksum != locked.Checksum || details.DownloadURL != locked.DownloadURL {
+		return nil, fmt.Errorf("upstream of artifact %q (%s) changed since it was locked: got %s (%s), locked %s (%s)",
+			description, a.Metadata().Arch, details.DownloadURL, details.Checksum, locked.DownloadURL, locked.Checksum)
+	}
+
+	return details, nil
+}
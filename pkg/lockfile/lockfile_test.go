@@ -0,0 +1,64 @@
+package lockfile
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+var _ = Describe("Lockfile", func() {
+	newArtifact := func(arch, checksum string) api.Artifact {
+		return generic.New(
+			&api.ArtifactDetails{Checksum: checksum, DownloadURL: "https://example.com/" + arch + ".qcow2"},
+			&api.Metadata{Name: "distro", Version: "1", Arch: arch},
+		)
+	}
+
+	It("should round-trip through a file", func() {
+		lock := New()
+		Expect(lock.Add(newArtifact("x86_64", "aaaa"))).To(Succeed())
+		Expect(lock.Add(newArtifact("aarch64", "bbbb"))).To(Succeed())
+
+		fileName := filepath.Join(GinkgoT().TempDir(), "medius.lock")
+		Expect(lock.Write(fileName)).To(Succeed())
+		read, err := Read(fileName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(read).To(Equal(lock))
+		Expect(read.Artifacts["distro:1"]).To(HaveKeyWithValue("aarch64", LockedArtifact{
+			Checksum:    "bbbb",
+			DownloadURL: "https://example.com/aarch64.qcow2",
+		}))
+	})
+
+	It("should inspect locked artifacts which did not change upstream", func() {
+		lock := New()
+		Expect(lock.Add(newArtifact("x86_64", "aaaa"))).To(Succeed())
+
+		details, err := lock.Locked(newArtifact("x86_64", "aaaa")).Inspect()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(details.Checksum).To(Equal("aaaa"))
+	})
+
+	It("should fail to inspect locked artifacts which changed upstream", func() {
+		lock := New()
+		Expect(lock.Add(newArtifact("x86_64", "aaaa"))).To(Succeed())
+
+		_, err := lock.Locked(newArtifact("x86_64", "cccc")).Inspect()
+		Expect(err).To(MatchError(ContainSubstring("changed since it was locked")))
+	})
+
+	It("should fail to inspect artifacts which are not locked", func() {
+		_, err := New().Locked(newArtifact("x86_64", "aaaa")).Inspect()
+		Expect(err).To(MatchError(ContainSubstring("not in the lock file")))
+	})
+})
+
+func TestLockfile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Lockfile Suite")
+}
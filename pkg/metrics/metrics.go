@@ -0,0 +1,227 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Type is the Prometheus type of a metric.
+type Type string
+
+const (
+	Counter Type = "counter"
+	Gauge   Type = "gauge"
+)
+
+// Definition describes a metric emitted by medius. Alert rules and the dashboard are generated from
+// the definitions, so they can't refer to metrics which don't exist.
+type Definition struct {
+	Name   string
+	Help   string
+	Type   Type
+	Labels []string
+}
+
+// Label names
+const (
+	LabelStage     = "stage"
+	LabelArtifact  = "artifact"
+	LabelOperation = "operation"
+)
+
+var (
+	// StageLastRun is the time a stage of the pipeline finished the last time.
+	StageLastRun = Definition{
+		Name:   "medius_stage_last_run_timestamp_seconds",
+		Help:   "Time the stage finished the last time.",
+		Type:   Gauge,
+		Labels: []string{LabelStage},
+	}
+	// StageLastSuccess is the time a stage of the pipeline finished without any failed containerdisk.
+	StageLastSuccess = Definition{
+		Name:   "medius_stage_last_success_timestamp_seconds",
+		Help:   "Time the stage finished without any failed containerdisk the last time.",
+		Type:   Gauge,
+		Labels: []string{LabelStage},
+	}
+	// VerificationFailures counts the runs in a row a containerdisk failed verification.
+	VerificationFailures = Definition{
+		Name:   "medius_verification_failures_in_a_row",
+		Help:   "Number of runs in a row the containerdisk failed verification.",
+		Type:   Gauge,
+		Labels: []string{LabelArtifact},
+	}
+	// VerificationBootTime is the time the last verification of a containerdisk took from creating the VM
+	// until its tests passed.
+	VerificationBootTime = Definition{
+		Name:   "medius_verification_boot_seconds",
+		Help:   "Time from creating the VM until the guest passed its tests in the last verification.",
+		Type:   Gauge,
+		Labels: []string{LabelArtifact},
+	}
+	// BootTimeRegression is 1 if the last verified build of a containerdisk boots noticeably slower than
+	// older builds.
+	BootTimeRegression = Definition{
+		Name:   "medius_boot_time_regression",
+		Help:   "Whether the last verified build of the containerdisk boots slower than older builds.",
+		Type:   Gauge,
+		Labels: []string{LabelArtifact},
+	}
+	// RegistryRequests counts the requests to registries by operation, e.g. push or copy.
+	RegistryRequests = Definition{
+		Name:   "medius_registry_requests_total",
+		Help:   "Number of registry operations.",
+		Type:   Counter,
+		Labels: []string{LabelOperation},
+	}
+	// RegistryErrors counts the failed requests to registries by operation.
+	RegistryErrors = Definition{
+		Name:   "medius_registry_errors_total",
+		Help:   "Number of failed registry operations.",
+		Type:   Counter,
+		Labels: []string{LabelOperation},
+	}
+)
+
+// Definitions returns all metrics emitted by medius.
+func Definitions() []Definition {
+	return []Definition{
+		StageLastRun, StageLastSuccess, VerificationFailures, VerificationBootTime, BootTimeRegression,
+		RegistryRequests, RegistryErrors,
+	}
+}
+
+// Set holds the samples of a metrics file in the text format of Prometheus. Every stage updates the
+// samples of a file written by the previous runs, so counters and streaks continue across runs and the
+// file can be exported with the textfile collector of the node exporter.
+type Set struct {
+	mu      sync.Mutex
+	samples map[string]float64
+}
+
+func New() *Set {
+	return &Set{samples: map[string]float64{}}
+}
+
+// Load reads the samples of a metrics file. A missing file results in an empty set.
+func Load(fileName string) (*Set, error) {
+	s := New()
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading metrics file: %v", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid sample %q in metrics file %s", line, fileName)
+		}
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sample %q in metrics file %s: %v", line, fileName, err)
+		}
+		s.samples[line[:i]] = value
+	}
+
+	return s, scanner.Err()
+}
+
+// Set sets the sample of a metric with the given label values.
+func (s *Set) Set(d Definition, value float64, labelValues ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[d.series(labelValues)] = value
+}
+
+// Add adds delta to the sample of a metric with the given label values.
+func (s *Set) Add(d Definition, delta float64, labelValues ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[d.series(labelValues)] += delta
+}
+
+// Get returns the sample of a metric with the given label values.
+func (s *Set) Get(d Definition, labelValues ...string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.samples[d.series(labelValues)]
+}
+
+// Write writes all samples in the text format of Prometheus. The file is replaced atomically,
+// so the textfile collector never reads partially written files.
+func (s *Set) Write(fileName string) error {
+	s.mu.Lock()
+	var buf bytes.Buffer
+	series := slices.Sorted(maps.Keys(s.samples))
+	for _, d := range Definitions() {
+		var lines []string
+		for _, name := range series {
+			if name == d.Name || strings.HasPrefix(name, d.Name+"{") {
+				lines = append(lines, name+" "+strconv.FormatFloat(s.samples[name], 'f', -1, 64))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", d.Name, d.Help, d.Name, d.Type)
+		for _, line := range lines {
+			buf.WriteString(line + "\n")
+		}
+	}
+	s.mu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error writing metrics file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(buf.Bytes())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing metrics file: %v", err)
+	}
+	const permissionFile = 0o644
+	if err := os.Chmod(tmp.Name(), permissionFile); err != nil {
+		return fmt.Errorf("error writing metrics file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), fileName); err != nil {
+		return fmt.Errorf("error writing metrics file %s: %v", fileName, err)
+	}
+
+	return nil
+}
+
+func (d Definition) series(labelValues []string) string {
+	if len(d.Labels) == 0 {
+		return d.Name
+	}
+
+	labels := make([]string, 0, len(d.Labels))
+	for i, label := range d.Labels {
+		value := ""
+		if i < len(labelValues) {
+			value = labelValues[i]
+		}
+		labels = append(labels, label+"="+strconv.Quote(value))
+	}
+	return d.Name + "{" + strings.Join(labels, ",") + "}"
+}
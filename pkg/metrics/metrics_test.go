@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/testutil"
+)
+
+var _ = Describe("Metrics", func() {
+	It("should continue samples of previous runs", func() {
+		fileName := filepath.Join(GinkgoT().TempDir(), "medius.prom")
+		s, err := Load(fileName)
+		Expect(err).ToNot(HaveOccurred())
+		s.Add(RegistryRequests, 2, "push")
+		s.Add(VerificationFailures, 1, "fedora:43")
+		s.Set(StageLastSuccess, 1719664200, "promote")
+		Expect(s.Write(fileName)).To(Succeed())
+
+		data, err := os.ReadFile(fileName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(`# HELP medius_stage_last_success_timestamp_seconds Time the stage finished without any failed containerdisk the last time.
+# TYPE medius_stage_last_success_timestamp_seconds gauge
+medius_stage_last_success_timestamp_seconds{stage="promote"} 1719664200
+# HELP medius_verification_failures_in_a_row Number of runs in a row the containerdisk failed verification.
+# TYPE medius_verification_failures_in_a_row gauge
+medius_verification_failures_in_a_row{artifact="fedora:43"} 1
+# HELP medius_registry_requests_total Number of registry operations.
+# TYPE medius_registry_requests_total counter
+medius_registry_requests_total{operation="push"} 2
+`))
+
+		s, err = Load(fileName)
+		Expect(err).ToNot(HaveOccurred())
+		s.Add(RegistryRequests, 1, "push")
+		s.Add(VerificationFailures, 1, "fedora:43")
+		Expect(s.Get(RegistryRequests, "push")).To(Equal(3.0))
+		Expect(s.Get(VerificationFailures, "fedora:43")).To(Equal(2.0))
+		Expect(s.Get(StageLastSuccess, "promote")).To(Equal(1719664200.0))
+	})
+
+	It("should reject invalid metrics files", func() {
+		fileName := filepath.Join(GinkgoT().TempDir(), "medius.prom")
+		Expect(os.WriteFile(fileName, []byte("medius_registry_requests_total{operation=\"push\"} many\n"), 0o600)).To(Succeed())
+		_, err := Load(fileName)
+		Expect(err).To(MatchError(ContainSubstring("invalid sample")))
+	})
+
+	It("should keep the generated alert rules and dashboard in sync", func() {
+		alerts, err := Alerts()
+		Expect(err).ToNot(HaveOccurred())
+		testutil.ExpectGolden("../../deploy/monitoring/alerts.yaml", alerts)
+
+		dashboard, err := Dashboard()
+		Expect(err).ToNot(HaveOccurred())
+		testutil.ExpectGolden("../../deploy/monitoring/dashboard.json", append(dashboard, '\n'))
+	})
+
+	It("should only refer to emitted metrics in alert rules and the dashboard", func() {
+		known := map[string]bool{}
+		for _, d := range Definitions() {
+			known[d.Name] = true
+		}
+
+		alerts, err := Alerts()
+		Expect(err).ToNot(HaveOccurred())
+		dashboard, err := Dashboard()
+		Expect(err).ToNot(HaveOccurred())
+		for _, name := range regexp.MustCompile(`medius_[a-z_]+`).FindAllString(string(alerts)+string(dashboard), -1) {
+			Expect(known).To(HaveKey(name))
+		}
+	})
+})
+
+func TestMetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Metrics Suite")
+}
@@ -0,0 +1,207 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Thresholds of the recommended alert rules
+const (
+	// NoPublishHours is the time without a successful promotion after which MediusNoSuccessfulPublish fires.
+	NoPublishHours = 48
+	// FailureStreak is the number of failed verifications in a row after which MediusVerificationFailing fires.
+	FailureStreak = 3
+	// RegistryErrorRatio is the ratio of failed registry operations above which MediusRegistryErrors fires.
+	RegistryErrorRatio = 0.1
+)
+
+const stagePromote = "promote"
+
+type ruleGroups struct {
+	Groups []ruleGroup `json:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string `json:"name"`
+	Rules []rule `json:"rules"`
+}
+
+type rule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// Alerts renders the recommended Prometheus alert rules.
+func Alerts() ([]byte, error) {
+	warning := map[string]string{"severity": "warning"}
+	groups := ruleGroups{Groups: []ruleGroup{{
+		Name: "medius",
+		Rules: []rule{
+			{
+				Alert: "MediusNoSuccessfulPublish",
+				Expr: fmt.Sprintf(`time() - max(%s{%s=%q}) > %d * 3600`,
+					StageLastSuccess.Name, LabelStage, stagePromote, NoPublishHours),
+				For:    "1h",
+				Labels: warning,
+				Annotations: map[string]string{
+					"summary":     fmt.Sprintf("No containerdisks were promoted successfully in %d hours", NoPublishHours),
+					"description": "The promote stage of the medius pipeline did not finish without failures recently.",
+				},
+			},
+			{
+				Alert:  "MediusVerificationFailing",
+				Expr:   fmt.Sprintf(`max by (%s) (%s) >= %d`, LabelArtifact, VerificationFailures.Name, FailureStreak),
+				For:    "0m",
+				Labels: warning,
+				Annotations: map[string]string{
+					"summary":     fmt.Sprintf("{{ $labels.%s }} failed verification {{ $value }} runs in a row", LabelArtifact),
+					"description": "The containerdisk is not updated until it passes verification again.",
+				},
+			},
+			{
+				Alert:  "MediusBootTimeRegression",
+				Expr:   fmt.Sprintf(`max by (%s) (%s) > 0`, LabelArtifact, BootTimeRegression.Name),
+				For:    "0m",
+				Labels: warning,
+				Annotations: map[string]string{
+					"summary":     fmt.Sprintf("{{ $labels.%s }} boots slower than its older builds", LabelArtifact),
+					"description": "The last verified build took noticeably longer until the guest passed its tests.",
+				},
+			},
+			{
+				Alert: "MediusRegistryErrors",
+				Expr: fmt.Sprintf(`sum(rate(%s[1h])) / sum(rate(%s[1h])) > %g`,
+					RegistryErrors.Name, RegistryRequests.Name, RegistryErrorRatio),
+				For:    "30m",
+				Labels: warning,
+				Annotations: map[string]string{
+					"summary":     fmt.Sprintf("More than %g%% of the registry operations of medius fail", RegistryErrorRatio*100),
+					"description": "Pushing or copying containerdisks fails, check the credentials and the state of the registries.",
+				},
+			},
+		},
+	}}}
+
+	return yaml.Marshal(groups)
+}
+
+type dashboard struct {
+	Title         string  `json:"title"`
+	UID           string  `json:"uid"`
+	SchemaVersion int     `json:"schemaVersion"`
+	Refresh       string  `json:"refresh"`
+	Time          timeRef `json:"time"`
+	Panels        []panel `json:"panels"`
+}
+
+type timeRef struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type panel struct {
+	ID          int         `json:"id"`
+	Title       string      `json:"title"`
+	Type        string      `json:"type"`
+	GridPos     gridPos     `json:"gridPos"`
+	Targets     []target    `json:"targets"`
+	FieldConfig fieldConfig `json:"fieldConfig"`
+}
+
+type gridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type target struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+	RefID        string `json:"refId"`
+}
+
+type fieldConfig struct {
+	Defaults fieldDefaults `json:"defaults"`
+}
+
+type fieldDefaults struct {
+	Unit string `json:"unit,omitempty"`
+}
+
+// Dashboard renders a Grafana dashboard of the pipeline.
+func Dashboard() ([]byte, error) {
+	const (
+		width  = 12
+		height = 8
+	)
+	panels := []panel{
+		{
+			Title: "Time since last success",
+			Type:  "stat",
+			Targets: []target{{
+				Expr:         fmt.Sprintf("time() - %s", StageLastSuccess.Name),
+				LegendFormat: "{{" + LabelStage + "}}",
+			}},
+			FieldConfig: fieldConfig{Defaults: fieldDefaults{Unit: "s"}},
+		},
+		{
+			Title: "Time since last run",
+			Type:  "stat",
+			Targets: []target{{
+				Expr:         fmt.Sprintf("time() - %s", StageLastRun.Name),
+				LegendFormat: "{{" + LabelStage + "}}",
+			}},
+			FieldConfig: fieldConfig{Defaults: fieldDefaults{Unit: "s"}},
+		},
+		{
+			Title: "Verification failures in a row",
+			Type:  "bargauge",
+			Targets: []target{{
+				Expr:         fmt.Sprintf("%s > 0", VerificationFailures.Name),
+				LegendFormat: "{{" + LabelArtifact + "}}",
+			}},
+		},
+		{
+			Title: "Boot time",
+			Type:  "timeseries",
+			Targets: []target{{
+				Expr:         VerificationBootTime.Name,
+				LegendFormat: "{{" + LabelArtifact + "}}",
+			}},
+			FieldConfig: fieldConfig{Defaults: fieldDefaults{Unit: "s"}},
+		},
+		{
+			Title: "Registry error ratio",
+			Type:  "timeseries",
+			Targets: []target{{
+				Expr: fmt.Sprintf("sum by (%s) (rate(%s[1h])) / sum by (%s) (rate(%s[1h]))",
+					LabelOperation, RegistryErrors.Name, LabelOperation, RegistryRequests.Name),
+				LegendFormat: "{{" + LabelOperation + "}}",
+			}},
+			FieldConfig: fieldConfig{Defaults: fieldDefaults{Unit: "percentunit"}},
+		},
+	}
+	for i := range panels {
+		panels[i].ID = i + 1
+		panels[i].GridPos = gridPos{H: height, W: width, X: (i % 2) * width, Y: (i / 2) * height}
+		for j := range panels[i].Targets {
+			panels[i].Targets[j].RefID = string(rune('A' + j))
+		}
+	}
+
+	const schemaVersion = 39
+	return json.MarshalIndent(dashboard{
+		Title:         "medius",
+		UID:           "medius",
+		SchemaVersion: schemaVersion,
+		Refresh:       "5m",
+		Time:          timeRef{From: "now-7d", To: "now"},
+		Panels:        panels,
+	}, "", "  ")
+}
@@ -0,0 +1,86 @@
+package ociartifact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// AnnotationTitle is the annotation tools like oras use as file name of a layer.
+const AnnotationTitle = "org.opencontainers.image.title"
+
+// File is stored as a single layer of an OCI artifact.
+type File struct {
+	Name      string
+	MediaType types.MediaType
+	Data      []byte
+}
+
+// New packages the files as OCI artifact with one uncompressed layer per file.
+func New(configMediaType types.MediaType, files ...File) (v1.Image, error) {
+	img := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, configMediaType)
+
+	addenda := make([]mutate.Addendum, 0, len(files))
+	for _, file := range files {
+		addenda = append(addenda, mutate.Addendum{
+			Layer: newStaticLayer(file.Data, file.MediaType),
+			Annotations: map[string]string{
+				AnnotationTitle: file.Name,
+			},
+		})
+	}
+
+	img, err := mutate.Append(img, addenda...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating OCI artifact: %v", err)
+	}
+
+	return img, nil
+}
+
+// staticLayer is an uncompressed in-memory layer.
+type staticLayer struct {
+	data      []byte
+	digest    v1.Hash
+	mediaType types.MediaType
+}
+
+func newStaticLayer(data []byte, mediaType types.MediaType) *staticLayer {
+	sum := sha256.Sum256(data)
+	return &staticLayer{
+		data:      data,
+		digest:    v1.Hash{Algorithm: "sha256", Hex: fmt.Sprintf("%x", sum)},
+		mediaType: mediaType,
+	}
+}
+
+func (l *staticLayer) Digest() (v1.Hash, error) {
+	return l.digest, nil
+}
+
+func (l *staticLayer) DiffID() (v1.Hash, error) {
+	return l.digest, nil
+}
+
+func (l *staticLayer) Compressed() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(l.data)), nil
+}
+
+func (l *staticLayer) Uncompressed() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(l.data)), nil
+}
+
+func (l *staticLayer) Size() (int64, error) {
+	return int64(len(l.data)), nil
+}
+
+func (l *staticLayer) MediaType() (types.MediaType, error) {
+	return l.mediaType, nil
+}
@@ -0,0 +1,41 @@
+package ociartifact
+
+import (
+	"io"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OCI artifact", func() {
+	It("should store every file as separate layer", func() {
+		img, err := New("application/vnd.example.config.v1+json",
+			File{Name: "a.yaml", MediaType: "application/vnd.example.v1+yaml", Data: []byte("a: 1\n")},
+			File{Name: "b.yaml", MediaType: "application/vnd.example.v1+yaml", Data: []byte("b: 2\n")},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		manifest, err := img.Manifest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.MediaType).To(Equal(types.OCIManifestSchema1))
+		Expect(manifest.Config.MediaType).To(Equal(types.MediaType("application/vnd.example.config.v1+json")))
+		Expect(manifest.Layers).To(HaveLen(2))
+		Expect(manifest.Layers[1].MediaType).To(Equal(types.MediaType("application/vnd.example.v1+yaml")))
+		Expect(manifest.Layers[1].Annotations).To(HaveKeyWithValue(AnnotationTitle, "b.yaml"))
+
+		layers, err := img.Layers()
+		Expect(err).ToNot(HaveOccurred())
+		reader, err := layers[1].Uncompressed()
+		Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("b: 2\n"))
+	})
+})
+
+func TestOCIArtifact(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "OCI Artifact Suite")
+}
@@ -0,0 +1,64 @@
+// Package osinfo maps containerdisks to the short IDs of libosinfo, e.g. fedora43, which KubeVirt UIs and the VM
+// wizard use to pick the OS profile of a VM.
+package osinfo
+
+import (
+	"strings"
+
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+const (
+	// LabelID is the label and annotation containing the libosinfo short ID of the OS of the containerdisk.
+	LabelID = "containerdisks.kubevirt.io/osinfo"
+	// labelTemplatePrefix is the prefix of the labels common-templates use to declare the OS they support,
+	// e.g. os.template.kubevirt.io/fedora43: "true".
+	labelTemplatePrefix = "os.template.kubevirt.io/"
+)
+
+// prefixes are the libosinfo short IDs of the OSes of the providers without version, the version of the
+// containerdisk is appended.
+var prefixes = map[string]string{
+	"fedora":        "fedora",
+	"centos-stream": "centos-stream",
+	"debian":        "debian",
+	"ubuntu":        "ubuntu",
+	"opensuse-leap": "opensuse",
+}
+
+// unversioned are the libosinfo short IDs of rolling distributions, which have no versions.
+var unversioned = map[string]string{
+	"opensuse-tumbleweed": "opensusetumbleweed",
+}
+
+// ID returns the libosinfo short ID of the OS of a containerdisk. It is the OSInfo of the metadata if set,
+// otherwise it is derived from the name and version. Unknown OSes have no ID.
+func ID(metadata *api.Metadata) string {
+	if metadata.OSInfo != "" {
+		return metadata.OSInfo
+	}
+	if id, exists := unversioned[metadata.Name]; exists {
+		return id
+	}
+	prefix, exists := prefixes[metadata.Name]
+	if !exists || metadata.Version == "" {
+		return ""
+	}
+	// Pre-releases like 43-beta share the ID of the release
+	version, _, _ := strings.Cut(metadata.Version, "-")
+	if version == "rawhide" {
+		return prefix + "-rawhide"
+	}
+	return prefix + version
+}
+
+// Labels returns the labels identifying the OS of a containerdisk, nothing if the ID is unknown.
+func Labels(id string) map[string]string {
+	if id == "" {
+		return map[string]string{}
+	}
+	return map[string]string{
+		LabelID:                  id,
+		labelTemplatePrefix + id: "true",
+	}
+}
@@ -0,0 +1,39 @@
+package osinfo
+
+import (
+	"testing"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+var _ = ginkgo.Describe("OSInfo", func() {
+	ginkgo.DescribeTable("ID should derive the libosinfo short ID", func(metadata api.Metadata, expected string) {
+		Expect(ID(&metadata)).To(Equal(expected))
+	},
+		ginkgo.Entry("fedora", api.Metadata{Name: "fedora", Version: "43"}, "fedora43"),
+		ginkgo.Entry("fedora pre-release", api.Metadata{Name: "fedora", Version: "44-beta"}, "fedora44"),
+		ginkgo.Entry("fedora rawhide", api.Metadata{Name: "fedora", Version: "rawhide"}, "fedora-rawhide"),
+		ginkgo.Entry("centos-stream", api.Metadata{Name: "centos-stream", Version: "10"}, "centos-stream10"),
+		ginkgo.Entry("ubuntu", api.Metadata{Name: "ubuntu", Version: "24.04"}, "ubuntu24.04"),
+		ginkgo.Entry("opensuse-leap", api.Metadata{Name: "opensuse-leap", Version: "15.6"}, "opensuse15.6"),
+		ginkgo.Entry("opensuse-tumbleweed", api.Metadata{Name: "opensuse-tumbleweed", Version: "1.0.0"}, "opensusetumbleweed"),
+		ginkgo.Entry("explicit", api.Metadata{Name: "appliance", Version: "1", OSInfo: "rhel9.4"}, "rhel9.4"),
+		ginkgo.Entry("unknown", api.Metadata{Name: "cirros", Version: "6.1"}, ""),
+	)
+
+	ginkgo.It("Labels should identify the OS for UIs and common-templates", func() {
+		Expect(Labels("fedora43")).To(Equal(map[string]string{
+			LabelID:                            "fedora43",
+			"os.template.kubevirt.io/fedora43": "true",
+		}))
+		Expect(Labels("")).To(BeEmpty())
+	})
+})
+
+func TestOSInfo(t *testing.T) {
+	RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "OSInfo Suite")
+}
@@ -0,0 +1,316 @@
+package progress
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxLogLines is the number of recent log lines shown below the progress bars.
+const maxLogLines = 5
+
+// Counter tracks the transferred bytes of a download, the hashing of a file or an upload.
+type Counter struct {
+	done  atomic.Int64
+	total atomic.Int64
+	// started is the time the transfer started in nanoseconds since the epoch, zero if it did not start yet
+	started atomic.Int64
+}
+
+// Transfer is a snapshot of a Counter.
+type Transfer struct {
+	Done int64
+	// Total is zero or less if the size of the transfer is unknown.
+	Total int64
+	// Rate is the average number of bytes per second since the transfer started.
+	Rate float64
+	// ETA is the estimated remaining time of the transfer, zero if it is unknown or the transfer finished.
+	ETA time.Duration
+}
+
+// Active returns whether the transfer started and did not finish yet.
+func (t *Transfer) Active() bool {
+	return t.Rate > 0 && (t.Total <= 0 || t.Done < t.Total)
+}
+
+func (c *Counter) start(total int64, now time.Time) {
+	c.done.Store(0)
+	c.total.Store(total)
+	c.started.Store(now.UnixNano())
+}
+
+func (c *Counter) snapshot(now time.Time) Transfer {
+	t := Transfer{Done: c.done.Load(), Total: c.total.Load()}
+	started := c.started.Load()
+	if started == 0 {
+		return t
+	}
+	elapsed := now.Sub(time.Unix(0, started)).Seconds()
+	if elapsed <= 0 || t.Done == 0 {
+		return t
+	}
+	t.Rate = float64(t.Done) / elapsed
+	if t.Total > t.Done {
+		t.ETA = time.Duration(float64(t.Total-t.Done) / t.Rate * float64(time.Second)).Round(time.Second)
+	}
+	return t
+}
+
+func (c *Counter) percent() (int, bool) {
+	total := c.total.Load()
+	if total <= 0 {
+		return 0, false
+	}
+	const hundred = 100
+	return int(min(c.done.Load()*hundred/total, hundred)), true
+}
+
+type artifact struct {
+	name     string
+	download Counter
+	hash     Counter
+	upload   Counter
+	state    atomic.Value
+}
+
+// Status is a snapshot of the progress of an artifact.
+type Status struct {
+	Name     string
+	State    string
+	Download Transfer
+	Hash     Transfer
+	Upload   Transfer
+}
+
+// Tracker collects the progress of all artifacts processed by a stage, for humans running long local publishes.
+type Tracker struct {
+	mu        sync.Mutex
+	artifacts []*artifact
+	logs      []string
+	partial   []byte
+	lines     int
+	// now returns the current time, it is replaced by tests.
+	now func() time.Time
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{now: time.Now}
+}
+
+// Start adds an artifact to the tracker and returns its reporter.
+func (t *Tracker) Start(name string) *Reporter {
+	a := &artifact{name: name}
+	a.state.Store("started")
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.artifacts = append(t.artifacts, a)
+	return &Reporter{tracker: t, artifact: a}
+}
+
+// Snapshot returns the progress of all artifacts in the order they started.
+func (t *Tracker) Snapshot() []Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	statuses := make([]Status, 0, len(t.artifacts))
+	for _, a := range t.artifacts {
+		statuses = append(statuses, Status{
+			Name:     a.name,
+			State:    a.state.Load().(string),
+			Download: a.download.snapshot(now),
+			Hash:     a.hash.snapshot(now),
+			Upload:   a.upload.snapshot(now),
+		})
+	}
+	return statuses
+}
+
+// Write collects log lines, so logs can be shown below the progress bars instead of tearing them apart.
+func (t *Tracker) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.partial = append(t.partial, p...)
+	for {
+		i := bytes.IndexByte(t.partial, '\n')
+		if i < 0 {
+			break
+		}
+		t.logs = append(t.logs, string(t.partial[:i]))
+		t.partial = t.partial[i+1:]
+	}
+	if len(t.logs) > maxLogLines {
+		t.logs = t.logs[len(t.logs)-maxLogLines:]
+	}
+
+	return len(p), nil
+}
+
+// Render writes one line with the download, hashing, upload and state of every artifact, followed by recent logs.
+func (t *Tracker) Render(w io.Writer) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := io.WriteString(w, t.render())
+	return err
+}
+
+func (t *Tracker) render() string {
+	nameWidth := 0
+	for _, a := range t.artifacts {
+		nameWidth = max(nameWidth, len(a.name))
+	}
+
+	now := t.now()
+	var b strings.Builder
+	for _, a := range t.artifacts {
+		fmt.Fprintf(&b, "%-*s  download %s  hash %s  upload %s  %s\n",
+			nameWidth, a.name, bar(&a.download, now), bar(&a.hash, now), bar(&a.upload, now), a.state.Load())
+	}
+	for _, line := range t.logs {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// Run redraws the progress on a terminal until ctx is done, then draws it a last time.
+func (t *Tracker) Run(ctx context.Context, out io.Writer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		t.redraw(out)
+		select {
+		case <-ctx.Done():
+			t.redraw(out)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *Tracker) redraw(out io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	frame := t.render()
+	var b strings.Builder
+	if t.lines > 0 {
+		// Move the cursor back to the start of the previous frame and clear it
+		fmt.Fprintf(&b, "\x1b[%dA\x1b[J", t.lines)
+	}
+	b.WriteString(frame)
+	_, _ = io.WriteString(out, b.String())
+	t.lines = strings.Count(frame, "\n")
+}
+
+func bar(c *Counter, now time.Time) string {
+	const width = 10
+	transfer := c.snapshot(now)
+	rate := ""
+	if transfer.Active() {
+		rate = FormatRate(transfer.Rate)
+		if transfer.ETA > 0 {
+			rate += " " + transfer.ETA.String()
+		}
+	}
+
+	percent, ok := c.percent()
+	if !ok {
+		return fmt.Sprintf("[%s]   - %-18s", strings.Repeat(" ", width), rate)
+	}
+	const hundred = 100
+	filled := percent * width / hundred
+	return fmt.Sprintf("[%s%s] %3d%% %-18s", strings.Repeat("#", filled), strings.Repeat(" ", width-filled), percent, rate)
+}
+
+// FormatRate formats bytes per second with a binary unit, e.g. 12.5 MiB/s.
+func FormatRate(bytesPerSecond float64) string {
+	const unit = 1024
+	units := []string{"B/s", "KiB/s", "MiB/s", "GiB/s"}
+	i := 0
+	for bytesPerSecond >= unit && i < len(units)-1 {
+		bytesPerSecond /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", bytesPerSecond, units[i])
+}
+
+// Reporter reports the progress of a single artifact. All methods of a nil reporter do nothing,
+// so code can report progress without checking whether a tracker is running.
+type Reporter struct {
+	tracker  *Tracker
+	artifact *artifact
+}
+
+type contextKey struct{}
+
+// WithReporter returns a context carrying the reporter of an artifact.
+func WithReporter(ctx context.Context, r *Reporter) context.Context {
+	return context.WithValue(ctx, contextKey{}, r)
+}
+
+// FromContext returns the reporter of the artifact processed with ctx or nil.
+func FromContext(ctx context.Context) *Reporter {
+	r, _ := ctx.Value(contextKey{}).(*Reporter)
+	return r
+}
+
+// SetState describes what is currently done with the artifact, e.g. "booting VM".
+func (r *Reporter) SetState(state string) {
+	if r == nil {
+		return
+	}
+	r.artifact.state.Store(state)
+}
+
+// Download counts the bytes read from body towards the download of the artifact.
+// A total of zero or less means the size of the download is unknown.
+func (r *Reporter) Download(body io.ReadCloser, total int64) io.ReadCloser {
+	if r == nil {
+		return body
+	}
+	r.artifact.download.start(total, r.tracker.now())
+	return &countingReader{ReadCloser: body, counter: &r.artifact.download}
+}
+
+// Hash counts the bytes read from body towards hashing a local file of the artifact, e.g. verifying an
+// assembled delta download. A total of zero or less means the size of the file is unknown.
+func (r *Reporter) Hash(body io.ReadCloser, total int64) io.ReadCloser {
+	if r == nil {
+		return body
+	}
+	r.artifact.hash.start(total, r.tracker.now())
+	return &countingReader{ReadCloser: body, counter: &r.artifact.hash}
+}
+
+// Upload sets the uploaded bytes of the artifact.
+func (r *Reporter) Upload(done, total int64) {
+	if r == nil {
+		return
+	}
+	// Uploads report their progress repeatedly, they start with the first report or a report of nothing done
+	if done == 0 {
+		r.artifact.upload.started.Store(r.tracker.now().UnixNano())
+	} else {
+		r.artifact.upload.started.CompareAndSwap(0, r.tracker.now().UnixNano())
+	}
+	r.artifact.upload.total.Store(total)
+	r.artifact.upload.done.Store(done)
+}
+
+type countingReader struct {
+	io.ReadCloser
+	counter *Counter
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.counter.done.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,106 @@
+package progress
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Progress", func() {
+	ginkgo.It("should render downloads, hashing, uploads and states", func() {
+		t := NewTracker()
+		now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		t.now = func() time.Time { return now }
+		fedora := t.Start("fedora:43")
+		t.Start("ubuntu:24.04").SetState("nothing to do")
+
+		body := fedora.Download(io.NopCloser(strings.NewReader(strings.Repeat("x", 3*1024*1024))), 4*1024*1024)
+		_, err := io.ReadAll(body)
+		Expect(err).ToNot(HaveOccurred())
+		fedora.Upload(0, 50)
+		fedora.Upload(50, 50)
+		fedora.SetState("pushing")
+		now = now.Add(2 * time.Second)
+
+		var out bytes.Buffer
+		Expect(t.Render(&out)).To(Succeed())
+		Expect(out.String()).To(Equal(
+			"fedora:43     download [#######   ]  75% 1.5 MiB/s 1s        hash [          ]   -                     " +
+				"upload [##########] 100%                     pushing\n" +
+				"ubuntu:24.04  download [          ]   -                     hash [          ]   -                     " +
+				"upload [          ]   -                     nothing to do\n"))
+	})
+
+	ginkgo.It("should snapshot the rate and ETA of transfers", func() {
+		t := NewTracker()
+		now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		t.now = func() time.Time { return now }
+		fedora := t.Start("fedora:43")
+		body := fedora.Hash(io.NopCloser(strings.NewReader(strings.Repeat("x", 100))), 400)
+		_, err := io.ReadAll(body)
+		Expect(err).ToNot(HaveOccurred())
+		now = now.Add(10 * time.Second)
+
+		statuses := t.Snapshot()
+		Expect(statuses).To(HaveLen(1))
+		Expect(statuses[0].State).To(Equal("started"))
+		Expect(statuses[0].Hash).To(Equal(Transfer{Done: 100, Total: 400, Rate: 10, ETA: 30 * time.Second}))
+		Expect(statuses[0].Hash.Active()).To(BeTrue())
+		Expect(statuses[0].Download.Active()).To(BeFalse())
+		Expect(FormatRate(3.5 * 1024 * 1024 * 1024)).To(Equal("3.5 GiB/s"))
+	})
+
+	ginkgo.It("should show recent log lines below the progress", func() {
+		t := NewTracker()
+		t.Start("fedora:43")
+		for i := range maxLogLines + 2 {
+			_, err := t.Write([]byte("line " + strings.Repeat("x", i) + "\n"))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		_, err := t.Write([]byte("partial"))
+		Expect(err).ToNot(HaveOccurred())
+
+		var out bytes.Buffer
+		Expect(t.Render(&out)).To(Succeed())
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		Expect(lines).To(HaveLen(1 + maxLogLines))
+		Expect(lines[1]).To(Equal("line xx"))
+		Expect(out.String()).ToNot(ContainSubstring("partial"))
+	})
+
+	ginkgo.It("should redraw over the previous frame", func() {
+		t := NewTracker()
+		t.Start("fedora:43")
+		var out bytes.Buffer
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		t.Run(ctx, &out, time.Hour)
+		Expect(out.String()).To(ContainSubstring("\x1b[1A\x1b[J"))
+	})
+
+	ginkgo.It("should ignore reports without a tracker", func() {
+		reporter := FromContext(context.Background())
+		Expect(reporter).To(BeNil())
+		reporter.SetState("building")
+		reporter.Upload(1, 2)
+		body := io.NopCloser(strings.NewReader("disk"))
+		Expect(reporter.Download(body, 4)).To(BeIdenticalTo(body))
+		Expect(reporter.Hash(body, 4)).To(BeIdenticalTo(body))
+	})
+
+	ginkgo.It("should carry reporters in contexts", func() {
+		reporter := NewTracker().Start("fedora:43")
+		Expect(FromContext(WithReporter(context.Background(), reporter))).To(BeIdenticalTo(reporter))
+	})
+})
+
+func TestProgress(t *testing.T) {
+	RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "Progress Suite")
+}
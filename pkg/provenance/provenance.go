@@ -0,0 +1,81 @@
+// Package provenance describes containerdisks with annotations derived from their metadata, and back-fills them
+// as OCI referrers on containerdisks which were published before the annotations existed.
+package provenance
+
+import (
+	"maps"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/build"
+	"kubevirt.io/containerdisks/pkg/ociartifact"
+	"kubevirt.io/containerdisks/pkg/osinfo"
+)
+
+const (
+	// AnnotationEOL is the annotation and label containing the end of life date (YYYY-MM-DD) of the version.
+	AnnotationEOL = "containerdisks.kubevirt.io/eol"
+	// ArtifactType is the config media type, and thereby the artifact type, of back-filled annotations.
+	ArtifactType types.MediaType = "application/vnd.kubevirt.containerdisks.annotations.v1+json"
+)
+
+// Annotations returns the annotations of a containerdisk which are derived from its metadata. They are stored
+// as annotations and labels of the image.
+func Annotations(metadata *api.Metadata) map[string]string {
+	annotations := metadata.Hints.Annotations()
+	if metadata.ReleaseNotes != "" {
+		annotations[build.AnnotationReleaseNotes] = metadata.ReleaseNotes
+	}
+	if metadata.License != "" {
+		annotations[build.AnnotationLicenses] = metadata.License
+	}
+	if metadata.Variant != api.VariantDefault {
+		annotations[build.AnnotationVariant] = string(metadata.Variant)
+	}
+	if metadata.EOL != "" {
+		annotations[AnnotationEOL] = metadata.EOL
+	}
+	maps.Copy(annotations, osinfo.Labels(osinfo.ID(metadata)))
+	return annotations
+}
+
+// Missing returns the annotations which are not set to the same value in labels, the labels of a published image.
+func Missing(annotations, labels map[string]string) map[string]string {
+	missing := map[string]string{}
+	for key, value := range annotations {
+		if labels[key] != value {
+			missing[key] = value
+		}
+	}
+	return missing
+}
+
+// VersionTags returns the tags of a repository which were published for the version and variant of metadata,
+// i.e. its moving version tag and the tags of its builds, e.g. 43 and 43-1.1-2407011200 for version 43.
+func VersionTags(tags []string, metadata *api.Metadata) []string {
+	var versionTags []string
+	for _, tag := range tags {
+		if api.VariantOf(tag) != metadata.Variant {
+			continue
+		}
+		if tag == metadata.Tag() || strings.HasPrefix(tag, metadata.Version+"-") {
+			versionTags = append(versionTags, tag)
+		}
+	}
+	return versionTags
+}
+
+// Artifact returns an OCI artifact carrying the annotations in its manifest, which refers to the containerdisk
+// described by subject. Attaching the same annotations again results in the same artifact.
+func Artifact(subject v1.Descriptor, annotations map[string]string) (v1.Image, error) {
+	img, err := ociartifact.New(ArtifactType)
+	if err != nil {
+		return nil, err
+	}
+	img = mutate.Annotations(img, annotations).(v1.Image)
+	return mutate.Subject(img, subject).(v1.Image), nil
+}
@@ -0,0 +1,75 @@
+package provenance
+
+import (
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/build"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/osinfo"
+)
+
+var _ = Describe("Provenance", func() {
+	metadata := &api.Metadata{
+		Name:         "fedora",
+		Version:      "43",
+		EOL:          "2026-12-01",
+		ReleaseNotes: "https://docs.example.com/fedora/43/release-notes",
+		License:      "LicenseRef-Fedora",
+		Hints:        docs.Hints{SecureBoot: true},
+	}
+
+	It("Annotations should describe the metadata", func() {
+		Expect(Annotations(metadata)).To(Equal(map[string]string{
+			docs.AnnotationSecureBoot:          "true",
+			build.AnnotationReleaseNotes:       "https://docs.example.com/fedora/43/release-notes",
+			build.AnnotationLicenses:           "LicenseRef-Fedora",
+			AnnotationEOL:                      "2026-12-01",
+			osinfo.LabelID:                     "fedora43",
+			"os.template.kubevirt.io/fedora43": "true",
+		}))
+	})
+
+	It("Missing should only return annotations the labels lack or contradict", func() {
+		annotations := map[string]string{AnnotationEOL: "2026-12-01", build.AnnotationLicenses: "MIT", osinfo.LabelID: "fedora43"}
+		labels := map[string]string{build.AnnotationLicenses: "MIT", osinfo.LabelID: "fedora42", build.LabelShaSum: "abc"}
+		Expect(Missing(annotations, labels)).To(Equal(map[string]string{AnnotationEOL: "2026-12-01", osinfo.LabelID: "fedora43"}))
+	})
+
+	It("VersionTags should select the builds of the version and variant", func() {
+		tags := []string{"43", "43-1.1", "43-2407011200", "43-minimal", "43-1.1-minimal", "42", "42-1.1", "430", "latest"}
+		Expect(VersionTags(tags, metadata)).To(Equal([]string{"43", "43-1.1", "43-2407011200"}))
+		minimal := &api.Metadata{Name: "fedora", Version: "43", Variant: api.VariantMinimal}
+		Expect(VersionTags(tags, minimal)).To(Equal([]string{"43-minimal", "43-1.1-minimal"}))
+	})
+
+	It("Artifact should refer to the containerdisk", func() {
+		subject, err := partial.Descriptor(empty.Image)
+		Expect(err).ToNot(HaveOccurred())
+
+		artifact, err := Artifact(*subject, map[string]string{AnnotationEOL: "2026-12-01"})
+		Expect(err).ToNot(HaveOccurred())
+		manifest, err := artifact.Manifest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Config.MediaType).To(Equal(ArtifactType))
+		Expect(manifest.Annotations).To(HaveKeyWithValue(AnnotationEOL, "2026-12-01"))
+		Expect(manifest.Subject).To(Equal(&v1.Descriptor{MediaType: subject.MediaType, Size: subject.Size, Digest: subject.Digest}))
+
+		digest, err := artifact.Digest()
+		Expect(err).ToNot(HaveOccurred())
+		again, err := Artifact(*subject, map[string]string{AnnotationEOL: "2026-12-01"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(again.Digest()).To(Equal(digest))
+	})
+})
+
+func TestProvenance(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Provenance Suite")
+}
@@ -0,0 +1,125 @@
+package quarantine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultMaxFailures is the number of runs in a row an artifact may fail before it is quarantined.
+const DefaultMaxFailures = 3
+
+// Record tracks the failures in a row of a single artifact.
+type Record struct {
+	Failures  int       `json:"failures"`
+	LastError string    `json:"lastError"`
+	Since     time.Time `json:"since"`
+}
+
+// Quarantine tracks failing artifacts across runs. Artifacts which failed MaxFailures times in a row
+// are quarantined: their failures are still reported, but don't fail the run anymore, so a single
+// broken upstream can't block all other containerdisks. A success lifts the quarantine.
+type Quarantine struct {
+	MaxFailures int `json:"-"`
+	// Artifacts maps artifact descriptions (name:version) to their failures in a row.
+	Artifacts map[string]*Record `json:"artifacts"`
+
+	mu sync.Mutex
+}
+
+func New(maxFailures int) *Quarantine {
+	if maxFailures <= 0 {
+		maxFailures = DefaultMaxFailures
+	}
+	return &Quarantine{MaxFailures: maxFailures, Artifacts: map[string]*Record{}}
+}
+
+// Read loads the quarantine of previous runs. A missing file results in an empty quarantine.
+func Read(fileName string, maxFailures int) (*Quarantine, error) {
+	q := New(maxFailures)
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return q, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading quarantine file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, q); err != nil {
+		return nil, fmt.Errorf("error parsing quarantine file %s: %v", fileName, err)
+	}
+	if q.Artifacts == nil {
+		q.Artifacts = map[string]*Record{}
+	}
+
+	return q, nil
+}
+
+func (q *Quarantine) Write(fileName string) error {
+	q.mu.Lock()
+	data, err := json.MarshalIndent(q, "", "  ")
+	q.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	const permissionFile = 0o644
+	if err := os.WriteFile(fileName, append(data, '\n'), permissionFile); err != nil {
+		return fmt.Errorf("error writing quarantine file %s: %v", fileName, err)
+	}
+
+	return nil
+}
+
+// RecordFailure counts a failure of the artifact and returns true if it is quarantined now.
+func (q *Quarantine) RecordFailure(name string, failure error, now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	record, ok := q.Artifacts[name]
+	if !ok {
+		record = &Record{Since: now}
+		q.Artifacts[name] = record
+	}
+	record.Failures++
+	record.LastError = failure.Error()
+
+	return record.Failures >= q.MaxFailures
+}
+
+// RecordSuccess resets the failures of the artifact and lifts its quarantine.
+func (q *Quarantine) RecordSuccess(name string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.Artifacts, name)
+}
+
+// Quarantined returns the sorted descriptions of all quarantined artifacts.
+func (q *Quarantine) Quarantined() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var quarantined []string
+	for _, name := range slices.Sorted(maps.Keys(q.Artifacts)) {
+		if q.Artifacts[name].Failures >= q.MaxFailures {
+			quarantined = append(quarantined, name)
+		}
+	}
+	return quarantined
+}
+
+// Get returns the failure record of an artifact.
+func (q *Quarantine) Get(name string) (Record, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	record, ok := q.Artifacts[name]
+	if !ok {
+		return Record{}, false
+	}
+	return *record, true
+}
@@ -0,0 +1,56 @@
+package quarantine
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Quarantine", func() {
+	now := time.Date(2024, 6, 29, 12, 30, 0, 0, time.UTC)
+
+	It("should quarantine artifacts failing too many times in a row", func() {
+		q := New(2)
+		Expect(q.RecordFailure("fedora:43", errors.New("boot failed"), now)).To(BeFalse())
+		Expect(q.Quarantined()).To(BeEmpty())
+		Expect(q.RecordFailure("fedora:43", errors.New("ssh failed"), now.Add(time.Hour))).To(BeTrue())
+		Expect(q.Quarantined()).To(Equal([]string{"fedora:43"}))
+
+		record, ok := q.Get("fedora:43")
+		Expect(ok).To(BeTrue())
+		Expect(record).To(Equal(Record{Failures: 2, LastError: "ssh failed", Since: now}))
+	})
+
+	It("should lift the quarantine on success", func() {
+		q := New(1)
+		Expect(q.RecordFailure("fedora:43", errors.New("boot failed"), now)).To(BeTrue())
+		q.RecordSuccess("fedora:43")
+		Expect(q.Quarantined()).To(BeEmpty())
+		Expect(q.RecordFailure("fedora:43", errors.New("boot failed"), now)).To(BeTrue())
+	})
+
+	It("should persist failures across runs", func() {
+		fileName := filepath.Join(GinkgoT().TempDir(), "quarantine.json")
+		q, err := Read(fileName, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(q.RecordFailure("fedora:43", errors.New("boot failed"), now)).To(BeFalse())
+		Expect(q.Write(fileName)).To(Succeed())
+
+		q, err = Read(fileName, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(q.RecordFailure("fedora:43", errors.New("boot failed"), now)).To(BeTrue())
+	})
+
+	It("should default the maximum failures", func() {
+		Expect(New(0).MaxFailures).To(Equal(DefaultMaxFailures))
+	})
+})
+
+func TestQuarantine(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Quarantine Suite")
+}
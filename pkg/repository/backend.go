@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	crname "github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Backend names an implementation of Repository. Registries differ in the manifests and media types they accept
+// and serve, so a registry which misbehaves with one client library may work with another.
+type Backend string
+
+const (
+	// BackendContainersImageInspect inspects images with containers/image, honoring registries.conf and the auth
+	// files of the container tools. containers/image is only used for reading, the backend pushes, tags and copies
+	// images with go-containerregistry like BackendGoContainerRegistry.
+	BackendContainersImageInspect Backend = "containers-image-inspect"
+	// BackendGoContainerRegistry runs all operations with go-containerregistry, which e.g. works with registries
+	// rejecting the manifest media types containers/image asks for.
+	BackendGoContainerRegistry Backend = "go-containerregistry"
+	// BackendOras pushes, tags and copies images with oras-go, e.g. for registries failing chunked blob uploads.
+	// Images are read with go-containerregistry.
+	BackendOras Backend = "oras-go"
+)
+
+// backendRepositories returns the implementation of each backend, it is replaced by tests.
+var backendRepositories = map[Backend]func() Repository{
+	BackendContainersImageInspect: func() Repository { return RepositoryImpl{} },
+	BackendGoContainerRegistry:    func() Repository { return GoContainerRegistry{} },
+	BackendOras:                   func() Repository { return Oras{} },
+}
+
+// NewRepository returns the implementation of the backend.
+func (b Backend) NewRepository() (Repository, error) {
+	newRepository, exists := backendRepositories[b]
+	if !exists {
+		return nil, fmt.Errorf("unknown registry backend %q, supported are %s, %s and %s",
+			b, BackendContainersImageInspect, BackendGoContainerRegistry, BackendOras)
+	}
+	return newRepository(), nil
+}
+
+// Backends selects the backend of each registry by its host. The entry with the empty host is used for all
+// other registries.
+type Backends map[string]Backend
+
+// ParseBackends parses backend selections like "ghcr.io=go-containerregistry". A selection without host
+// replaces the default backend.
+func ParseBackends(specs []string) (Backends, error) {
+	backends := Backends{"": BackendContainersImageInspect}
+	for _, spec := range specs {
+		host, backend := "", spec
+		if i := strings.LastIndex(spec, "="); i >= 0 {
+			host, backend = spec[:i], spec[i+1:]
+			if host == "" {
+				return nil, fmt.Errorf("registry backend %q lacks the registry host", spec)
+			}
+		}
+		if _, err := Backend(backend).NewRepository(); err != nil {
+			return nil, err
+		}
+		backends[host] = Backend(backend)
+	}
+	return backends, nil
+}
+
+// For returns the backend of the registry of an image or repository reference.
+func (b Backends) For(ref string) Backend {
+	if backend, exists := b[registryOf(ref)]; exists {
+		return backend
+	}
+	if backend, exists := b[""]; exists {
+		return backend
+	}
+	return BackendContainersImageInspect
+}
+
+func registryOf(ref string) string {
+	if parsed, err := crname.ParseReference(ref); err == nil {
+		return parsed.Context().RegistryStr()
+	}
+	if parsed, err := crname.NewRepository(ref); err == nil {
+		return parsed.RegistryStr()
+	}
+	return ""
+}
+
+var (
+	defaultBackends      = Backends{"": BackendContainersImageInspect}
+	defaultBackendsMutex sync.Mutex
+)
+
+// SetDefaultBackends sets the backends used by New.
+func SetDefaultBackends(b Backends) {
+	defaultBackendsMutex.Lock()
+	defer defaultBackendsMutex.Unlock()
+	defaultBackends = b
+}
+
+func getDefaultBackends() Backends {
+	defaultBackendsMutex.Lock()
+	defer defaultBackendsMutex.Unlock()
+	return defaultBackends
+}
+
+// New returns a Repository running every operation with the default backend of the registry it talks to.
+func New() Repository {
+	return &router{backends: getDefaultBackends()}
+}
+
+// router dispatches the operations of Repository to the backend of their registry. Copies are run by the
+// backend of the destination registry.
+type router struct {
+	backends Backends
+}
+
+func (r *router) backend(ref string) (Repository, error) {
+	return r.backends.For(ref).NewRepository()
+}
+
+func (r *router) ImageMetadata(imgRef, arch string, insecure bool) (*ImageInfo, error) {
+	repo, err := r.backend(imgRef)
+	if err != nil {
+		return nil, err
+	}
+	return repo.ImageMetadata(imgRef, arch, insecure)
+}
+
+func (r *router) PushImage(ctx context.Context, img v1.Image, imgRef string) error {
+	repo, err := r.backend(imgRef)
+	if err != nil {
+		return err
+	}
+	return repo.PushImage(ctx, img, imgRef)
+}
+
+func (r *router) PushImageIndex(ctx context.Context, img v1.ImageIndex, imgRef string) error {
+	repo, err := r.backend(imgRef)
+	if err != nil {
+		return err
+	}
+	return repo.PushImageIndex(ctx, img, imgRef)
+}
+
+func (r *router) TagManifest(ctx context.Context, manifest remote.Taggable, imgRef string) error {
+	repo, err := r.backend(imgRef)
+	if err != nil {
+		return err
+	}
+	return repo.TagManifest(ctx, manifest, imgRef)
+}
+
+func (r *router) CopyImage(ctx context.Context, srcRef, dstRef string, insecure bool) error {
+	repo, err := r.backend(dstRef)
+	if err != nil {
+		return err
+	}
+	return repo.CopyImage(ctx, srcRef, dstRef, insecure)
+}
+
+func (r *router) PinnedReference(ctx context.Context, imgRef string, insecure bool) (string, error) {
+	repo, err := r.backend(imgRef)
+	if err != nil {
+		return "", err
+	}
+	return repo.PinnedReference(ctx, imgRef, insecure)
+}
+
+func (r *router) Descriptor(ctx context.Context, imgRef string, insecure bool) (*v1.Descriptor, error) {
+	repo, err := r.backend(imgRef)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Descriptor(ctx, imgRef, insecure)
+}
+
+func (r *router) ListTags(ctx context.Context, repo string, insecure bool) ([]string, error) {
+	backend, err := r.backend(repo)
+	if err != nil {
+		return nil, err
+	}
+	return backend.ListTags(ctx, repo, insecure)
+}
+
+// GoContainerRegistry is the implementation of BackendGoContainerRegistry.
+type GoContainerRegistry struct {
+	RepositoryImpl
+}
+
+// ImageMetadata reads the config of imgRef, or of its arch image if imgRef is an image index.
+func (GoContainerRegistry) ImageMetadata(imgRef, arch string, insecure bool) (*ImageInfo, error) {
+	options := []crane.Option{withTransport(insecure)}
+	if arch != "" {
+		options = append(options, crane.WithPlatform(&v1.Platform{OS: "linux", Architecture: arch}))
+	}
+	if insecure {
+		options = append(options, crane.Insecure)
+	}
+	craneOptions := crane.GetOptions(options...)
+
+	ref, err := crname.ParseReference(imgRef, craneOptions.Name...)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing image: %w", err)
+	}
+	desc, err := remote.Get(ref, craneOptions.Remote...)
+	if err != nil {
+		return nil, err
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing manifest for image: %w", err)
+	}
+	config, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("error reading the config of image: %w", err)
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("error reading the layers of image: %w", err)
+	}
+
+	imageInfo := &ImageInfo{
+		Created:       &config.Created.Time,
+		DockerVersion: config.DockerVersion,
+		Labels:        config.Config.Labels,
+		Architecture:  config.Architecture,
+		Os:            config.OS,
+		Env:           config.Config.Env,
+	}
+	if tag, ok := ref.(crname.Tag); ok {
+		imageInfo.Tag = tag.TagStr()
+	}
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			return nil, fmt.Errorf("error reading the digest of a layer: %w", err)
+		}
+		imageInfo.Layers = append(imageInfo.Layers, digest.String())
+	}
+	return imageInfo, nil
+}
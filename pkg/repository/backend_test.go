@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	pkghttp "kubevirt.io/containerdisks/pkg/http"
+)
+
+var _ = Describe("Backends", func() {
+	It("ParseBackends should select backends per registry host", func() {
+		backends, err := ParseBackends([]string{"ghcr.io=go-containerregistry", "localhost:5000=containers-image-inspect"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backends.For("ghcr.io/containerdisks/fedora:40")).To(Equal(BackendGoContainerRegistry))
+		Expect(backends.For("ghcr.io/containerdisks/fedora")).To(Equal(BackendGoContainerRegistry))
+		Expect(backends.For("localhost:5000/fedora@sha256:" + strings.Repeat("0", 64))).To(Equal(BackendContainersImageInspect))
+		Expect(backends.For("quay.io/containerdisks/fedora:40")).To(Equal(BackendContainersImageInspect))
+	})
+
+	It("ParseBackends should replace the default backend", func() {
+		backends, err := ParseBackends([]string{"go-containerregistry", "quay.io=containers-image-inspect"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backends.For("ghcr.io/containerdisks/fedora:40")).To(Equal(BackendGoContainerRegistry))
+		Expect(backends.For("quay.io/containerdisks/fedora:40")).To(Equal(BackendContainersImageInspect))
+	})
+
+	DescribeTable("ParseBackends should reject invalid selections",
+		func(spec, expectedErr string) {
+			_, err := ParseBackends([]string{spec})
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("unknown backend", "ghcr.io=skopeo", `unknown registry backend "skopeo"`),
+		Entry("missing host", "=go-containerregistry", "lacks the registry host"),
+	)
+
+	It("New should route operations to the backend of the registry", func() {
+		backends, err := ParseBackends([]string{"ghcr.io=go-containerregistry", "harbor.example.com=oras-go"})
+		Expect(err).ToNot(HaveOccurred())
+		SetDefaultBackends(backends)
+		DeferCleanup(SetDefaultBackends, Backends{"": BackendContainersImageInspect})
+
+		repo, ok := New().(*router)
+		Expect(ok).To(BeTrue())
+		Expect(repo.backend("ghcr.io/containerdisks/fedora:40")).To(Equal(GoContainerRegistry{}))
+		Expect(repo.backend("harbor.example.com/containerdisks/fedora:40")).To(Equal(Oras{}))
+		Expect(repo.backend("quay.io/containerdisks/fedora:40")).To(Equal(RepositoryImpl{}))
+	})
+
+	It("should fail operations on registries with an unknown backend", func() {
+		repo := &router{backends: Backends{"": BackendContainersImageInspect, "ghcr.io": "skopeo"}}
+		err := repo.PushImage(context.Background(), nil, "ghcr.io/containerdisks/fedora:43")
+		Expect(err).To(MatchError(ContainSubstring(`unknown registry backend "skopeo"`)))
+		_, err = repo.ListTags(context.Background(), "ghcr.io/containerdisks/fedora", false)
+		Expect(err).To(MatchError(ContainSubstring(`unknown registry backend "skopeo"`)))
+	})
+
+	It("should push with the backend of the registry", func() {
+		var pushed []string
+		for backend := range backendRepositories {
+			recording := &recordingRepository{backend: backend, pushed: &pushed}
+			DeferCleanup(func(newRepository func() Repository) { backendRepositories[backend] = newRepository },
+				backendRepositories[backend])
+			backendRepositories[backend] = func() Repository { return recording }
+		}
+
+		repo := &router{backends: Backends{"": BackendContainersImageInspect, "ghcr.io": BackendGoContainerRegistry}}
+		ctx := context.Background()
+		Expect(repo.PushImage(ctx, nil, "quay.io/containerdisks/fedora:43")).To(Succeed())
+		Expect(repo.PushImageIndex(ctx, nil, "ghcr.io/containerdisks/fedora:43")).To(Succeed())
+		Expect(repo.TagManifest(ctx, nil, "ghcr.io/containerdisks/fedora:latest")).To(Succeed())
+		Expect(repo.CopyImage(ctx, "ghcr.io/containerdisks/fedora:43", "quay.io/containerdisks/fedora:latest", false)).To(Succeed())
+		Expect(pushed).To(Equal([]string{
+			"containers-image-inspect quay.io/containerdisks/fedora:43",
+			"go-containerregistry ghcr.io/containerdisks/fedora:43",
+			"go-containerregistry ghcr.io/containerdisks/fedora:latest",
+			"containers-image-inspect quay.io/containerdisks/fedora:latest",
+		}))
+	})
+
+	DescribeTable("the registry client should retry operations unless the transport retried them",
+		func(err error, expected bool) {
+			Expect(retryPredicate(err)).To(Equal(expected))
+		},
+		Entry("interrupted upload", fmt.Errorf("error uploading layer: %w", io.ErrUnexpectedEOF), true),
+		Entry("reset connection", &url.Error{Op: "Patch", URL: "https://quay.io", Err: syscall.ECONNRESET}, true),
+		Entry("temporary status", &transport.Error{StatusCode: http.StatusServiceUnavailable}, true),
+		Entry("permanent status", &transport.Error{StatusCode: http.StatusForbidden}, false),
+		Entry("retried request", retriedError(), false),
+		Entry("no error", nil, false),
+	)
+
+	It("should recognize errors of go-containerregistry", func() {
+		manifestUnknown := fmt.Errorf("error introspecting image: %w", &transport.Error{
+			Errors: []transport.Diagnostic{{Code: transport.ManifestUnknownErrorCode}},
+		})
+		Expect(IsManifestUnknownError(manifestUnknown)).To(BeTrue())
+		Expect(IsRepositoryUnknownError(manifestUnknown)).To(BeFalse())
+
+		nameUnknown := &transport.Error{Errors: []transport.Diagnostic{{Code: transport.NameUnknownErrorCode}}}
+		Expect(IsRepositoryUnknownError(nameUnknown)).To(BeTrue())
+		Expect(IsManifestUnknownError(nameUnknown)).To(BeFalse())
+
+		Expect(IsArchUnknownError(errors.New("no child with platform linux/s390x in index quay.io/containerdisks/fedora:40"))).To(BeTrue())
+	})
+})
+
+// recordingRepository records the references pushed with its backend.
+type recordingRepository struct {
+	Repository
+	backend Backend
+	pushed  *[]string
+}
+
+func (r *recordingRepository) record(imgRef string) error {
+	*r.pushed = append(*r.pushed, string(r.backend)+" "+imgRef)
+	return nil
+}
+
+func (r *recordingRepository) PushImage(_ context.Context, _ v1.Image, imgRef string) error {
+	return r.record(imgRef)
+}
+
+func (r *recordingRepository) PushImageIndex(_ context.Context, _ v1.ImageIndex, imgRef string) error {
+	return r.record(imgRef)
+}
+
+func (r *recordingRepository) TagManifest(_ context.Context, _ remote.Taggable, imgRef string) error {
+	return r.record(imgRef)
+}
+
+func (r *recordingRepository) CopyImage(_ context.Context, _, dstRef string, _ bool) error {
+	return r.record(dstRef)
+}
+
+// retriedError returns the error of a GET request the retry transport gave up on, after the registry closed the
+// connection of every attempt.
+func retriedError() error {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		Expect(err).ToNot(HaveOccurred())
+		conn.Close()
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: pkghttp.RetryTransport(nil, pkghttp.Retry{Attempts: 2, Backoff: time.Millisecond})}
+	_, err := client.Get(server.URL)
+	Expect(err).To(MatchError(io.EOF))
+	return err
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	crname "github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	orasremote "oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+
+	"kubevirt.io/containerdisks/pkg/progress"
+)
+
+// Oras is the implementation of BackendOras. It pushes, tags and copies images with oras-go, which uploads every
+// blob with a single PUT request instead of chunks and copies the whole graph of an image including indexes of
+// Docker media types. Images are read with go-containerregistry like BackendGoContainerRegistry.
+type Oras struct {
+	GoContainerRegistry
+}
+
+func (Oras) PushImage(ctx context.Context, img v1.Image, imgRef string) error {
+	graph := &orasGraph{seen: map[v1.Hash]bool{}}
+	if err := graph.addImage(img); err != nil {
+		return err
+	}
+	return graph.push(ctx, imgRef)
+}
+
+func (Oras) PushImageIndex(ctx context.Context, imageIndex v1.ImageIndex, imgRef string) error {
+	graph := &orasGraph{seen: map[v1.Hash]bool{}}
+	if err := graph.addIndex(imageIndex); err != nil {
+		return err
+	}
+	return graph.push(ctx, imgRef)
+}
+
+// TagManifest puts the manifest of an image or index, which was already pushed to the repository of imgRef,
+// under the tag of imgRef.
+func (Oras) TagManifest(ctx context.Context, manifest remote.Taggable, imgRef string) error {
+	raw, err := manifest.RawManifest()
+	if err != nil {
+		return fmt.Errorf("error reading manifest: %v", err)
+	}
+	var mediaType struct {
+		MediaType string `json:"mediaType"`
+	}
+	if err := json.Unmarshal(raw, &mediaType); err != nil {
+		return fmt.Errorf("error parsing manifest: %v", err)
+	}
+	if mediaType.MediaType == "" {
+		return errors.New("manifest lacks its media type")
+	}
+
+	repo, reference, err := orasRepository(imgRef, false)
+	if err != nil {
+		return err
+	}
+	desc := ocispec.Descriptor{MediaType: mediaType.MediaType, Digest: digest.FromBytes(raw), Size: int64(len(raw))}
+	return repo.PushReference(ctx, desc, bytes.NewReader(raw), reference)
+}
+
+func (Oras) CopyImage(ctx context.Context, srcRef, dstRef string, insecure bool) error {
+	src, srcReference, err := orasRepository(srcRef, insecure)
+	if err != nil {
+		return err
+	}
+	dst, dstReference, err := orasRepository(dstRef, insecure)
+	if err != nil {
+		return err
+	}
+	_, err = oras.Copy(ctx, src, srcReference, dst, dstReference, oras.DefaultCopyOptions)
+	return err
+}
+
+// orasRepository returns the repository of imgRef along with the tag or digest it references. Requests use the
+// credentials and the transport of go-containerregistry.
+func orasRepository(imgRef string, insecure bool) (repo *orasremote.Repository, reference string, err error) {
+	var options []crname.Option
+	if insecure {
+		options = append(options, crname.Insecure)
+	}
+	ref, err := crname.ParseReference(imgRef, options...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	repo, err = orasremote.NewRepository(ref.Context().Name())
+	if err != nil {
+		return nil, "", err
+	}
+	repo.PlainHTTP = ref.Context().Scheme() == "http"
+	repo.Client = &auth.Client{
+		Client:     &http.Client{Transport: registryTransport(insecure)},
+		Cache:      auth.NewCache(),
+		Credential: orasCredential,
+	}
+	return repo, ref.Identifier(), nil
+}
+
+// orasCredential looks up the credentials of a registry in the keychain of go-containerregistry, so both backends
+// authenticate with the same auth files.
+func orasCredential(_ context.Context, hostport string) (auth.Credential, error) {
+	registry, err := crname.NewRegistry(hostport)
+	if err != nil {
+		return auth.EmptyCredential, err
+	}
+	authenticator, err := authn.DefaultKeychain.Resolve(registry)
+	if err != nil {
+		return auth.EmptyCredential, err
+	}
+	config, err := authenticator.Authorization()
+	if err != nil {
+		return auth.EmptyCredential, err
+	}
+	return auth.Credential{
+		Username:     config.Username,
+		Password:     config.Password,
+		RefreshToken: config.IdentityToken,
+		AccessToken:  config.RegistryToken,
+	}, nil
+}
+
+// orasGraph is the content of an image or index in the order it is pushed: the blobs first, then the manifests of
+// the children and the manifest of the image or index itself last.
+type orasGraph struct {
+	blobs     []orasBlob
+	manifests []orasManifest
+	seen      map[v1.Hash]bool
+}
+
+type orasBlob struct {
+	desc ocispec.Descriptor
+	open func() (io.ReadCloser, error)
+}
+
+type orasManifest struct {
+	desc ocispec.Descriptor
+	raw  []byte
+}
+
+func (g *orasGraph) addImage(img v1.Image) error {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return fmt.Errorf("error reading manifest: %v", err)
+	}
+	if !g.seen[manifest.Config.Digest] {
+		g.seen[manifest.Config.Digest] = true
+		g.blobs = append(g.blobs, orasBlob{desc: ociDescriptor(manifest.Config), open: func() (io.ReadCloser, error) {
+			config, err := img.RawConfigFile()
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(bytes.NewReader(config)), nil
+		}})
+	}
+	for _, layerDesc := range manifest.Layers {
+		if g.seen[layerDesc.Digest] {
+			continue
+		}
+		g.seen[layerDesc.Digest] = true
+		layer, err := img.LayerByDigest(layerDesc.Digest)
+		if err != nil {
+			return fmt.Errorf("error reading layer %s: %v", layerDesc.Digest, err)
+		}
+		g.blobs = append(g.blobs, orasBlob{desc: ociDescriptor(layerDesc), open: layer.Compressed})
+	}
+
+	return g.addManifest(img)
+}
+
+func (g *orasGraph) addIndex(imageIndex v1.ImageIndex) error {
+	indexManifest, err := imageIndex.IndexManifest()
+	if err != nil {
+		return fmt.Errorf("error reading index manifest: %v", err)
+	}
+	for _, child := range indexManifest.Manifests {
+		switch {
+		case child.MediaType.IsIndex():
+			childIndex, err := imageIndex.ImageIndex(child.Digest)
+			if err != nil {
+				return fmt.Errorf("error reading index %s: %v", child.Digest, err)
+			}
+			if err := g.addIndex(childIndex); err != nil {
+				return err
+			}
+		case child.MediaType.IsImage():
+			img, err := imageIndex.Image(child.Digest)
+			if err != nil {
+				return fmt.Errorf("error reading image %s: %v", child.Digest, err)
+			}
+			if err := g.addImage(img); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported media type %q of %s", child.MediaType, child.Digest)
+		}
+	}
+
+	return g.addManifest(imageIndex)
+}
+
+func (g *orasGraph) addManifest(manifest interface {
+	RawManifest() ([]byte, error)
+	MediaType() (types.MediaType, error)
+}) error {
+	raw, err := manifest.RawManifest()
+	if err != nil {
+		return fmt.Errorf("error reading manifest: %v", err)
+	}
+	mediaType, err := manifest.MediaType()
+	if err != nil {
+		return fmt.Errorf("error reading the media type of manifest: %v", err)
+	}
+	g.manifests = append(g.manifests, orasManifest{
+		desc: ocispec.Descriptor{MediaType: string(mediaType), Digest: digest.FromBytes(raw), Size: int64(len(raw))},
+		raw:  raw,
+	})
+	return nil
+}
+
+// push uploads the blobs which are missing in the repository of imgRef, the manifests of the children by their
+// digest and the last manifest under the reference of imgRef.
+func (g *orasGraph) push(ctx context.Context, imgRef string) error {
+	repo, reference, err := orasRepository(imgRef, false)
+	if err != nil {
+		return err
+	}
+
+	reporter := progress.FromContext(ctx)
+	var complete, total int64
+	for _, blob := range g.blobs {
+		total += blob.desc.Size
+	}
+	reporter.Upload(complete, total)
+	for _, blob := range g.blobs {
+		if err := pushBlob(ctx, repo, blob); err != nil {
+			return err
+		}
+		complete += blob.desc.Size
+		reporter.Upload(complete, total)
+	}
+
+	root := g.manifests[len(g.manifests)-1]
+	for _, child := range g.manifests[:len(g.manifests)-1] {
+		if err := repo.Push(ctx, child.desc, bytes.NewReader(child.raw)); err != nil {
+			return fmt.Errorf("error pushing manifest %s: %v", child.desc.Digest, err)
+		}
+	}
+	if err := repo.PushReference(ctx, root.desc, bytes.NewReader(root.raw), reference); err != nil {
+		return fmt.Errorf("error pushing manifest %s: %v", root.desc.Digest, err)
+	}
+	return nil
+}
+
+func pushBlob(ctx context.Context, repo *orasremote.Repository, blob orasBlob) error {
+	exists, err := repo.Exists(ctx, blob.desc)
+	if err != nil {
+		return fmt.Errorf("error checking blob %s: %v", blob.desc.Digest, err)
+	}
+	if exists {
+		return nil
+	}
+
+	content, err := blob.open()
+	if err != nil {
+		return fmt.Errorf("error reading blob %s: %v", blob.desc.Digest, err)
+	}
+	defer content.Close()
+	if err := repo.Push(ctx, blob.desc, content); err != nil {
+		return fmt.Errorf("error pushing blob %s: %v", blob.desc.Digest, err)
+	}
+	return nil
+}
+
+func ociDescriptor(desc v1.Descriptor) ocispec.Descriptor {
+	return ocispec.Descriptor{MediaType: string(desc.MediaType), Digest: digest.Digest(desc.Digest.String()), Size: desc.Size}
+}
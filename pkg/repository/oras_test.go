@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	crname "github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Oras", func() {
+	var host string
+
+	digestOf := func(imgRef string) string {
+		digest, err := crane.Digest(imgRef)
+		Expect(err).ToNot(HaveOccurred())
+		return digest
+	}
+
+	BeforeEach(func() {
+		server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+		DeferCleanup(server.Close)
+		host = strings.TrimPrefix(server.URL, "http://")
+	})
+
+	It("should push images", func() {
+		img, err := random.Image(1024, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(Oras{}.PushImage(context.Background(), img, host+"/containerdisks/fedora:43")).To(Succeed())
+
+		expected, err := img.Digest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(digestOf(host + "/containerdisks/fedora:43")).To(Equal(expected.String()))
+		pulled, err := crane.Pull(host + "/containerdisks/fedora:43")
+		Expect(err).ToNot(HaveOccurred())
+		layers, err := pulled.Layers()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(layers).To(HaveLen(2))
+	})
+
+	It("should push image indexes along with their images", func() {
+		index, err := random.Index(1024, 1, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(Oras{}.PushImageIndex(context.Background(), index, host+"/containerdisks/fedora:43")).To(Succeed())
+
+		expected, err := index.Digest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(digestOf(host + "/containerdisks/fedora:43")).To(Equal(expected.String()))
+		manifest, err := index.IndexManifest()
+		Expect(err).ToNot(HaveOccurred())
+		for _, child := range manifest.Manifests {
+			Expect(digestOf(host + "/containerdisks/fedora@" + child.Digest.String())).To(Equal(child.Digest.String()))
+		}
+	})
+
+	It("should tag pushed manifests", func() {
+		img, err := random.Image(1024, 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(Oras{}.PushImage(context.Background(), img, host+"/containerdisks/fedora:43")).To(Succeed())
+
+		Expect(Oras{}.TagManifest(context.Background(), img, host+"/containerdisks/fedora:latest")).To(Succeed())
+		Expect(digestOf(host + "/containerdisks/fedora:latest")).To(Equal(digestOf(host + "/containerdisks/fedora:43")))
+	})
+
+	It("should copy image indexes between repositories", func() {
+		index, err := random.Index(1024, 1, 2)
+		Expect(err).ToNot(HaveOccurred())
+		staging, err := crname.ParseReference(host + "/staging/fedora:43")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(remote.WriteIndex(staging, index)).To(Succeed())
+
+		Expect(Oras{}.CopyImage(context.Background(), host+"/staging/fedora:43", host+"/containerdisks/fedora:43", false)).To(Succeed())
+		Expect(digestOf(host + "/containerdisks/fedora:43")).To(Equal(digestOf(host + "/staging/fedora:43")))
+		manifest, err := index.IndexManifest()
+		Expect(err).ToNot(HaveOccurred())
+		for _, child := range manifest.Manifests {
+			Expect(digestOf(host + "/containerdisks/fedora@" + child.Digest.String())).To(Equal(child.Digest.String()))
+		}
+	})
+})
@@ -2,8 +2,13 @@ package repository
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/docker/distribution/registry/api/errcode"
@@ -12,10 +17,15 @@ import (
 	crname "github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/pkg/errors"
 	"go.podman.io/image/v5/image"
 	"go.podman.io/image/v5/transports/alltransports"
 	"go.podman.io/image/v5/types"
+
+	"kubevirt.io/containerdisks/pkg/bandwidth"
+	pkghttp "kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/progress"
 )
 
 type ImageInfo struct {
@@ -33,7 +43,11 @@ type Repository interface {
 	ImageMetadata(imgRef, arch string, insecure bool) (*ImageInfo, error)
 	PushImage(ctx context.Context, img v1.Image, imgRef string) error
 	PushImageIndex(ctx context.Context, img v1.ImageIndex, imgRef string) error
+	TagManifest(ctx context.Context, manifest remote.Taggable, imgRef string) error
 	CopyImage(ctx context.Context, srcRef, dstRef string, insecure bool) error
+	PinnedReference(ctx context.Context, imgRef string, insecure bool) (string, error)
+	Descriptor(ctx context.Context, imgRef string, insecure bool) (*v1.Descriptor, error)
+	ListTags(ctx context.Context, repo string, insecure bool) ([]string, error)
 }
 
 type RepositoryImpl struct{}
@@ -83,7 +97,9 @@ func (r RepositoryImpl) ImageMetadata(imgRef, arch string, insecure bool) (image
 }
 
 func (r RepositoryImpl) PushImage(ctx context.Context, img v1.Image, imgRef string) error {
-	return crane.Push(img, imgRef, crane.WithContext(ctx))
+	progressOption, done := withUploadProgress(ctx)
+	defer done()
+	return crane.Push(img, imgRef, crane.WithContext(ctx), progressOption, withTransport(false))
 }
 
 func (r RepositoryImpl) PushImageIndex(ctx context.Context, imageIndex v1.ImageIndex, imageRef string) error {
@@ -92,12 +108,91 @@ func (r RepositoryImpl) PushImageIndex(ctx context.Context, imageIndex v1.ImageI
 		return err
 	}
 
-	return remote.WriteIndex(ref, imageIndex, crane.GetOptions(crane.WithContext(ctx)).Remote...)
+	progressOption, done := withUploadProgress(ctx)
+	defer done()
+	options := crane.GetOptions(crane.WithContext(ctx), progressOption, withTransport(false))
+	return remote.WriteIndex(ref, imageIndex, options.Remote...)
+}
+
+// TagManifest puts the manifest of an image or index, which was already pushed to the repository of imgRef,
+// under the tag of imgRef. Only the manifest is uploaded, its layers and child manifests are referenced by digest.
+func (r RepositoryImpl) TagManifest(ctx context.Context, manifest remote.Taggable, imgRef string) error {
+	ref, err := crname.NewTag(imgRef)
+	if err != nil {
+		return err
+	}
+
+	options := crane.GetOptions(crane.WithContext(ctx), withTransport(false))
+	return remote.Tag(ref, manifest, options.Remote...)
+}
+
+// withUploadProgress reports the progress of an upload to the progress reporter of ctx, if there is one.
+// done has to be called once the upload finished.
+func withUploadProgress(ctx context.Context) (option crane.Option, done func()) {
+	reporter := progress.FromContext(ctx)
+	if reporter == nil {
+		return func(*crane.Options) {}, func() {}
+	}
+
+	const bufferedUpdates = 16
+	updates := make(chan v1.Update, bufferedUpdates)
+	finished := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+				reporter.Upload(update.Complete, update.Total)
+			case <-finished:
+				return
+			}
+		}
+	}()
+
+	return func(o *crane.Options) {
+		o.Remote = append(o.Remote, remote.WithProgress(updates))
+	}, func() { close(finished) }
+}
+
+// withTransport sends the requests to registries through the retry transport of the upstream getters, limiting
+// their bandwidth if a limit is set. The transport replaces the one crane creates for insecure registries, so it
+// skips TLS verification itself. The registry client keeps retrying whole operations, e.g. the upload of a layer
+// whose body can not be sent again, but it does not retry status codes or requests the transport already retried.
+func withTransport(insecure bool) crane.Option {
+	return func(o *crane.Options) {
+		crane.WithTransport(registryTransport(insecure))(o)
+		o.Remote = append(o.Remote, remote.WithRetryStatusCodes(), remote.WithRetryPredicate(retryPredicate))
+	}
+}
+
+// registryTransport returns the transport of requests to registries, which retries them and limits their bandwidth.
+func registryTransport(insecure bool) http.RoundTripper {
+	transport := remote.DefaultTransport.(*http.Transport).Clone()
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // the registry is insecure on purpose
+	}
+	return pkghttp.DefaultRetryTransport(bandwidth.Transport(transport))
+}
+
+// retryPredicate matches the errors the registry client retries by default, except for errors of requests
+// which were already retried by the retry transport.
+func retryPredicate(err error) bool {
+	if err == nil || pkghttp.Retried(err) {
+		return false
+	}
+	if temporary, ok := err.(interface{ Temporary() bool }); ok && temporary.Temporary() {
+		return !errors.Is(err, context.DeadlineExceeded)
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, net.ErrClosed)
 }
 
 func (r RepositoryImpl) CopyImage(ctx context.Context, srcRef, dstRef string, insecure bool) error {
 	options := []crane.Option{
 		crane.WithContext(ctx),
+		withTransport(insecure),
 	}
 
 	if insecure {
@@ -107,6 +202,80 @@ func (r RepositoryImpl) CopyImage(ctx context.Context, srcRef, dstRef string, in
 	return crane.Copy(srcRef, dstRef, options...)
 }
 
+// PinnedReference resolves the current digest of imgRef and returns a reference pinned to it,
+// so that subsequent copies are not affected by concurrent retagging of imgRef.
+func (r RepositoryImpl) PinnedReference(ctx context.Context, imgRef string, insecure bool) (string, error) {
+	options := []crane.Option{
+		crane.WithContext(ctx),
+		withTransport(insecure),
+	}
+
+	if insecure {
+		options = append(options, crane.Insecure)
+	}
+
+	ref, err := crname.ParseReference(imgRef, crane.GetOptions(options...).Name...)
+	if err != nil {
+		return "", err
+	}
+
+	digest, err := crane.Digest(imgRef, options...)
+	if err != nil {
+		return "", err
+	}
+
+	return ref.Context().Digest(digest).String(), nil
+}
+
+// Descriptor returns the descriptor of the manifest imgRef points to, e.g. to attach referrers to it. A reference
+// which does not exist has no descriptor.
+func (r RepositoryImpl) Descriptor(ctx context.Context, imgRef string, insecure bool) (*v1.Descriptor, error) {
+	options := []crane.Option{
+		crane.WithContext(ctx),
+		withTransport(insecure),
+	}
+
+	if insecure {
+		options = append(options, crane.Insecure)
+	}
+
+	craneOptions := crane.GetOptions(options...)
+	ref, err := crname.ParseReference(imgRef, craneOptions.Name...)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptor, err := remote.Head(ref, craneOptions.Remote...)
+	// Responses to HEAD requests have no body with an error code
+	var transportErr *transport.Error
+	if errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	return descriptor, err
+}
+
+// ListTags returns all tags of a repository. A repository which does not exist yet has no tags.
+func (r RepositoryImpl) ListTags(ctx context.Context, repo string, insecure bool) ([]string, error) {
+	options := []crane.Option{
+		crane.WithContext(ctx),
+		withTransport(insecure),
+	}
+
+	if insecure {
+		options = append(options, crane.Insecure)
+	}
+
+	tags, err := crane.ListTags(repo, options...)
+	if err != nil {
+		if hasTransportErrorCode(err, transport.NameUnknownErrorCode) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return tags, nil
+}
+
 func parseImageSource(ctx context.Context, sys *types.SystemContext, name string) (types.ImageSource, error) {
 	ref, err := alltransports.ParseImageName(name)
 	if err != nil {
@@ -117,6 +286,9 @@ func parseImageSource(ctx context.Context, sys *types.SystemContext, name string
 }
 
 func IsManifestUnknownError(err error) bool {
+	if hasTransportErrorCode(err, transport.ManifestUnknownErrorCode) {
+		return true
+	}
 	ec := getErrorCode(err)
 	if ec == nil {
 		return false
@@ -131,6 +303,9 @@ func IsManifestUnknownError(err error) bool {
 }
 
 func IsRepositoryUnknownError(err error) bool {
+	if hasTransportErrorCode(err, transport.NameUnknownErrorCode) {
+		return true
+	}
 	ec := getErrorCode(err)
 	if ec == nil {
 		return false
@@ -160,7 +335,22 @@ func IsTagUnknownError(err error) bool {
 }
 
 func IsArchUnknownError(err error) bool {
-	return strings.Contains(err.Error(), "no image found in manifest list for architecture")
+	return strings.Contains(err.Error(), "no image found in manifest list for architecture") ||
+		strings.Contains(err.Error(), "no child with platform")
+}
+
+// hasTransportErrorCode reports whether err is an error of go-containerregistry with the code.
+func hasTransportErrorCode(err error, code transport.ErrorCode) bool {
+	var transportErr *transport.Error
+	if !errors.As(err, &transportErr) {
+		return false
+	}
+	for _, diagnostic := range transportErr.Errors {
+		if diagnostic.Code == code {
+			return true
+		}
+	}
+	return false
 }
 
 func getErrorCode(err error) errcode.ErrorCoder {
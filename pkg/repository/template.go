@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// PlaceholderRegistry is replaced with the registry the containerdisk is pushed to or read from.
+	PlaceholderRegistry = "{{registry}}"
+	// PlaceholderOrg is replaced with the configured organization or namespace.
+	PlaceholderOrg = "{{org}}"
+	// PlaceholderName is replaced with the name of the containerdisk, e.g. fedora.
+	PlaceholderName = "{{name}}"
+	// PlaceholderArch is replaced with the architecture of the containerdisk, e.g. x86_64.
+	// Multi-architecture containerdisks use the architecture of their first artifact.
+	PlaceholderArch = "{{arch}}"
+
+	// DefaultTemplate places containerdisks directly below the registry.
+	DefaultTemplate = PlaceholderRegistry + "/" + PlaceholderName
+)
+
+var placeholderRegexp = regexp.MustCompile(`{{[^}]*}}`)
+
+// Template names the repositories of containerdisks, so forks and downstream distributions
+// can publish them under their own namespaces.
+type Template struct {
+	template string
+	org      string
+}
+
+// ParseTemplate validates a repository template like "{{registry}}/{{org}}/{{name}}-{{arch}}".
+// The template has to start with the registry, so repositories stay relative to the registry
+// and containerdisks can be promoted from one registry to another.
+func ParseTemplate(template, org string) (*Template, error) {
+	if !strings.HasPrefix(template, PlaceholderRegistry+"/") {
+		return nil, fmt.Errorf("repository template %q has to start with %s/", template, PlaceholderRegistry)
+	}
+	if !strings.Contains(template, PlaceholderName) {
+		return nil, fmt.Errorf("repository template %q has to contain %s", template, PlaceholderName)
+	}
+	for _, placeholder := range placeholderRegexp.FindAllString(template, -1) {
+		switch placeholder {
+		case PlaceholderRegistry, PlaceholderName, PlaceholderArch:
+		case PlaceholderOrg:
+			if org == "" {
+				return nil, fmt.Errorf("repository template %q uses %s but no organization is set", template, PlaceholderOrg)
+			}
+		default:
+			return nil, fmt.Errorf("repository template %q contains unknown placeholder %s", template, placeholder)
+		}
+	}
+	if strings.Contains(strings.TrimPrefix(template, PlaceholderRegistry), PlaceholderRegistry) {
+		return nil, fmt.Errorf("repository template %q may contain %s only once", template, PlaceholderRegistry)
+	}
+
+	return &Template{template: template, org: org}, nil
+}
+
+// Name returns the repository of a containerdisk in registry. An empty registry returns the
+// repository relative to the registry, as it is stored in the results of a run.
+func (t *Template) Name(registry, name, arch string) string {
+	replacer := strings.NewReplacer(
+		PlaceholderOrg, t.org,
+		PlaceholderName, name,
+		PlaceholderArch, arch,
+	)
+	repository := replacer.Replace(strings.TrimPrefix(t.template, PlaceholderRegistry+"/"))
+	if registry == "" {
+		return repository
+	}
+	return strings.TrimSuffix(registry, "/") + "/" + repository
+}
+
+var (
+	defaultTemplate      = &Template{template: DefaultTemplate}
+	defaultTemplateMutex sync.Mutex
+)
+
+// SetDefaultTemplate sets the template used by Name.
+func SetDefaultTemplate(t *Template) {
+	defaultTemplateMutex.Lock()
+	defer defaultTemplateMutex.Unlock()
+	defaultTemplate = t
+}
+
+func getDefaultTemplate() *Template {
+	defaultTemplateMutex.Lock()
+	defer defaultTemplateMutex.Unlock()
+	return defaultTemplate
+}
+
+// Name returns the repository of a containerdisk in registry according to the default template.
+func Name(registry, name, arch string) string {
+	return getDefaultTemplate().Name(registry, name, arch)
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Template", func() {
+	DescribeTable("Name should render repositories",
+		func(template, org, registry, expected string) {
+			t, err := ParseTemplate(template, org)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t.Name(registry, "fedora", "x86_64")).To(Equal(expected))
+		},
+		Entry("default", DefaultTemplate, "", "quay.io/containerdisks", "quay.io/containerdisks/fedora"),
+		Entry("default without registry", DefaultTemplate, "", "", "fedora"),
+		Entry("registry with trailing slash", DefaultTemplate, "", "registry.example.com/", "registry.example.com/fedora"),
+		Entry("org and arch", "{{registry}}/{{org}}/{{name}}-{{arch}}", "downstream", "registry.example.com",
+			"registry.example.com/downstream/fedora-x86_64"),
+		Entry("org and arch without registry", "{{registry}}/{{org}}/{{name}}-{{arch}}", "downstream", "",
+			"downstream/fedora-x86_64"),
+	)
+
+	DescribeTable("ParseTemplate should reject invalid templates",
+		func(template, org, expectedErr string) {
+			_, err := ParseTemplate(template, org)
+			Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+		},
+		Entry("without registry", "{{org}}/{{name}}", "downstream", "has to start with {{registry}}/"),
+		Entry("registry not first", "{{org}}/{{registry}}/{{name}}", "downstream", "has to start with {{registry}}/"),
+		Entry("registry twice", "{{registry}}/{{registry}}/{{name}}", "", "only once"),
+		Entry("without name", "{{registry}}/{{org}}", "downstream", "has to contain {{name}}"),
+		Entry("unknown placeholder", "{{registry}}/{{name}}-{{version}}", "", "unknown placeholder {{version}}"),
+		Entry("org without organization", "{{registry}}/{{org}}/{{name}}", "", "no organization is set"),
+	)
+
+	It("Name should use the default template", func() {
+		t, err := ParseTemplate("{{registry}}/{{org}}/{{name}}", "downstream")
+		Expect(err).ToNot(HaveOccurred())
+		SetDefaultTemplate(t)
+		DeferCleanup(SetDefaultTemplate, &Template{template: DefaultTemplate})
+
+		Expect(Name("registry.example.com", "fedora", "x86_64")).To(Equal("registry.example.com/downstream/fedora"))
+	})
+})
+
+func TestRepository(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Repository Suite")
+}
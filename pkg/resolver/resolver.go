@@ -0,0 +1,175 @@
+// Package resolver replaces the system DNS resolver of all outbound connections, for build environments
+// whose DNS can't resolve the upstream mirrors.
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	dnsPort        = "53"
+	dnsMessageType = "application/dns-message"
+	// maxDNSMessageSize is the maximum size of a DNS message over TCP, which DoH messages share.
+	maxDNSMessageSize = 65535
+	dialTimeout       = 5 * time.Second
+)
+
+// New returns a resolver for spec, which is either the URL of a DNS-over-HTTPS endpoint (RFC 8484), e.g.
+// https://dns.example.com/dns-query, or a comma separated list of nameservers with optional port, e.g.
+// 192.0.2.53,[2001:db8::53]:5353.
+func New(spec string) (*net.Resolver, error) {
+	if strings.HasPrefix(spec, "https://") {
+		endpoint, err := url.Parse(spec)
+		if err != nil || endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid DNS-over-HTTPS endpoint %q", spec)
+		}
+		return newDoHResolver(endpoint.String()), nil
+	}
+
+	var nameservers []string
+	for _, nameserver := range strings.Split(spec, ",") {
+		nameserver = strings.TrimSpace(nameserver)
+		if _, _, err := net.SplitHostPort(nameserver); err != nil {
+			nameserver = net.JoinHostPort(strings.Trim(nameserver, "[]"), dnsPort)
+		}
+		host, _, _ := net.SplitHostPort(nameserver)
+		if net.ParseIP(host) == nil {
+			return nil, fmt.Errorf("nameserver %q is no IP address", host)
+		}
+		nameservers = append(nameservers, nameserver)
+	}
+	return newNameserverResolver(nameservers), nil
+}
+
+// Install makes the resolver the one of all connections which don't set their own, which includes the
+// HTTP getters and both registry clients.
+func Install(r *net.Resolver) {
+	net.DefaultResolver = r
+}
+
+func newNameserverResolver(nameservers []string) *net.Resolver {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	var next atomic.Uint32
+	return &net.Resolver{
+		PreferGo: true,
+		// Nameservers are used in turn, so the retries of the resolver reach the other nameservers
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			nameserver := nameservers[int(next.Add(1)-1)%len(nameservers)]
+			return dialer.DialContext(ctx, network, nameserver)
+		},
+	}
+}
+
+func newDoHResolver(endpoint string) *net.Resolver {
+	// The endpoint itself is resolved by the system resolver, not by the resolver being created
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: dialTimeout, Resolver: &net.Resolver{}}).DialContext
+	client := &http.Client{Transport: transport}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return &dohConn{ctx: ctx, client: client, endpoint: endpoint}, nil
+		},
+	}
+}
+
+// dohConn is a connection to a DNS server over TCP, whose length prefixed queries are sent to a
+// DNS-over-HTTPS endpoint instead.
+type dohConn struct {
+	ctx      context.Context
+	client   *http.Client
+	endpoint string
+	deadline time.Time
+
+	queries bytes.Buffer
+	answers bytes.Buffer
+}
+
+func (c *dohConn) Write(p []byte) (int, error) {
+	c.queries.Write(p)
+	for c.queries.Len() >= 2 {
+		length := int(binary.BigEndian.Uint16(c.queries.Bytes()))
+		if c.queries.Len() < 2+length {
+			break
+		}
+		query := c.queries.Next(2 + length)[2:]
+
+		answer, err := c.exchange(query)
+		if err != nil {
+			return 0, err
+		}
+		// Answers are limited to maxDNSMessageSize, their length fits
+		c.answers.Write(binary.BigEndian.AppendUint16(nil, uint16(len(answer)))) //nolint:gosec
+		c.answers.Write(answer)
+	}
+	return len(p), nil
+}
+
+func (c *dohConn) exchange(query []byte) ([]byte, error) {
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", dnsMessageType)
+	req.Header.Set("Accept", dnsMessageType)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from DNS-over-HTTPS endpoint %s", resp.StatusCode, c.endpoint)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxDNSMessageSize))
+}
+
+func (c *dohConn) Read(p []byte) (int, error) {
+	if c.answers.Len() == 0 {
+		return 0, io.EOF
+	}
+	return c.answers.Read(p)
+}
+
+func (c *dohConn) Close() error {
+	return nil
+}
+
+func (c *dohConn) LocalAddr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+func (c *dohConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+func (c *dohConn) SetDeadline(t time.Time) error {
+	c.deadline = t
+	return nil
+}
+
+func (c *dohConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (c *dohConn) SetWriteDeadline(t time.Time) error {
+	c.deadline = t
+	return nil
+}
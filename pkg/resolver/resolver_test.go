@@ -0,0 +1,106 @@
+package resolver
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	typeA         = 1
+	headerSize    = 12
+	flagsResponse = 0x8180
+)
+
+// answer answers A queries for mirror.example.com with 192.0.2.1 and every other query without records.
+func answer(query []byte) []byte {
+	// The question starts after the header and ends after the type and class following the name
+	end := headerSize
+	for query[end] != 0 {
+		end += int(query[end]) + 1
+	}
+	name := query[headerSize : end+1]
+	qtype := binary.BigEndian.Uint16(query[end+1:])
+	end += 5
+
+	response := append([]byte{}, query[:2]...)
+	response = binary.BigEndian.AppendUint16(response, flagsResponse)
+	found := qtype == typeA && string(name) == "\x06mirror\x07example\x03com\x00"
+	answers := uint16(0)
+	if found {
+		answers = 1
+	}
+	response = binary.BigEndian.AppendUint16(response, 1)
+	response = binary.BigEndian.AppendUint16(response, answers)
+	response = append(response, 0, 0, 0, 0)
+	response = append(response, query[headerSize:end]...)
+	if found {
+		// Name pointer to the question, type A, class IN, TTL 60, 4 bytes of address
+		response = append(response, 0xc0, headerSize, 0, typeA, 0, 1, 0, 0, 0, 60, 0, 4, 192, 0, 2, 1)
+	}
+	return response
+}
+
+var _ = Describe("Resolver", func() {
+	It("should resolve with DNS-over-HTTPS", func() {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.Header.Get("Content-Type") != dnsMessageType {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			query, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", dnsMessageType)
+			_, _ = w.Write(answer(query))
+		}))
+		DeferCleanup(server.Close)
+
+		r, err := New(server.URL + "/dns-query")
+		Expect(err).ToNot(HaveOccurred())
+		// The test server uses a self-signed certificate
+		r.Dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return &dohConn{ctx: ctx, client: server.Client(), endpoint: server.URL + "/dns-query"}, nil
+		}
+		Expect(r.LookupHost(context.Background(), "mirror.example.com")).To(ConsistOf("192.0.2.1"))
+		_, err = r.LookupHost(context.Background(), "other.example.com")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should resolve with custom nameservers", func() {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(conn.Close)
+		go func() {
+			buf := make([]byte, maxDNSMessageSize)
+			for {
+				n, addr, err := conn.ReadFrom(buf)
+				if err != nil {
+					return
+				}
+				_, _ = conn.WriteTo(answer(buf[:n]), addr)
+			}
+		}()
+
+		r, err := New(conn.LocalAddr().String())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(r.LookupHost(context.Background(), "mirror.example.com")).To(ConsistOf("192.0.2.1"))
+	})
+
+	It("should reject invalid specs", func() {
+		_, err := New("dns.example.com")
+		Expect(err).To(MatchError(`nameserver "dns.example.com" is no IP address`))
+		_, err = New("https:///dns-query")
+		Expect(err).To(MatchError(ContainSubstring("invalid DNS-over-HTTPS endpoint")))
+	})
+})
+
+func TestResolver(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Resolver Suite")
+}
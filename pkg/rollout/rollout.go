@@ -0,0 +1,144 @@
+package rollout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+)
+
+// DefaultRequiredSuccesses moves floating tags as soon as a containerdisk passed verification once.
+const DefaultRequiredSuccesses = 1
+
+// Candidate is a promoted containerdisk whose floating tags were not moved yet.
+type Candidate struct {
+	// Tags contains the version-pinned tags the containerdisk was promoted with, the unique one first.
+	Tags []string `json:"tags"`
+	// FloatingTags contains the tags to move once the containerdisk passed enough verifications.
+	FloatingTags []string `json:"floatingTags"`
+	// KernelBoot is set if kernel boot containers were published along with the containerdisk.
+	KernelBoot bool `json:"kernelBoot,omitempty"`
+	// Successes counts the verifications in a row the containerdisk passed.
+	Successes int `json:"successes"`
+}
+
+// Rollout tracks containerdisks across runs until their floating tags like "41" or "latest" may be moved.
+// Version-pinned tags are published right away, floating tags only after RequiredSuccesses verifications
+// in a row, so transient upstream regressions don't reach most users.
+type Rollout struct {
+	RequiredSuccesses int `json:"-"`
+	// Candidates maps artifact descriptions (name:version) to containerdisks waiting for their floating tags.
+	Candidates map[string]*Candidate `json:"candidates"`
+
+	mu sync.Mutex
+}
+
+func New(requiredSuccesses int) *Rollout {
+	if requiredSuccesses <= 0 {
+		requiredSuccesses = DefaultRequiredSuccesses
+	}
+	return &Rollout{RequiredSuccesses: requiredSuccesses, Candidates: map[string]*Candidate{}}
+}
+
+// Read loads the rollout of previous runs. A missing file results in an empty rollout.
+func Read(fileName string, requiredSuccesses int) (*Rollout, error) {
+	r := New(requiredSuccesses)
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading rollout file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("error parsing rollout file %s: %v", fileName, err)
+	}
+	if r.Candidates == nil {
+		r.Candidates = map[string]*Candidate{}
+	}
+
+	return r, nil
+}
+
+func (r *Rollout) Write(fileName string) error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	const permissionFile = 0o644
+	if err := os.WriteFile(fileName, append(data, '\n'), permissionFile); err != nil {
+		return fmt.Errorf("error writing rollout file %s: %v", fileName, err)
+	}
+
+	return nil
+}
+
+// Propose records a freshly verified containerdisk, replacing any older candidate of the artifact.
+// It returns true if the floating tags may be moved right away, in which case nothing is recorded.
+func (r *Rollout) Propose(name string, candidate Candidate) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	candidate.Successes = 1
+	if candidate.Successes >= r.RequiredSuccesses || len(candidate.FloatingTags) == 0 {
+		delete(r.Candidates, name)
+		return true
+	}
+	r.Candidates[name] = &candidate
+
+	return false
+}
+
+// RecordSuccess counts a passed verification of a candidate. It returns the candidate and true if its
+// floating tags may be moved now, in which case the candidate is removed.
+func (r *Rollout) RecordSuccess(name string) (Candidate, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	candidate, ok := r.Candidates[name]
+	if !ok {
+		return Candidate{}, false
+	}
+	candidate.Successes++
+	if candidate.Successes < r.RequiredSuccesses {
+		return *candidate, false
+	}
+	delete(r.Candidates, name)
+
+	return *candidate, true
+}
+
+// RecordFailure resets the passed verifications of a candidate.
+func (r *Rollout) RecordFailure(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if candidate, ok := r.Candidates[name]; ok {
+		candidate.Successes = 0
+	}
+}
+
+// Pending returns the sorted descriptions of all candidates.
+func (r *Rollout) Pending() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Sorted(maps.Keys(r.Candidates))
+}
+
+// Get returns the candidate of an artifact.
+func (r *Rollout) Get(name string) (Candidate, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	candidate, ok := r.Candidates[name]
+	if !ok {
+		return Candidate{}, false
+	}
+	return *candidate, true
+}
@@ -0,0 +1,86 @@
+package rollout
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rollout", func() {
+	candidate := Candidate{
+		Tags:         []string{"fedora:43-2406291230"},
+		FloatingTags: []string{"fedora:43", "fedora:latest"},
+	}
+
+	It("should move floating tags right away by default", func() {
+		r := New(0)
+		Expect(r.Propose("fedora:43", candidate)).To(BeTrue())
+		Expect(r.Pending()).To(BeEmpty())
+	})
+
+	It("should move floating tags right away if there are none", func() {
+		r := New(3)
+		Expect(r.Propose("fedora:43", Candidate{Tags: candidate.Tags})).To(BeTrue())
+		Expect(r.Pending()).To(BeEmpty())
+	})
+
+	It("should move floating tags after enough successes in a row", func() {
+		r := New(3)
+		Expect(r.Propose("fedora:43", candidate)).To(BeFalse())
+		Expect(r.Pending()).To(Equal([]string{"fedora:43"}))
+
+		c, ready := r.RecordSuccess("fedora:43")
+		Expect(ready).To(BeFalse())
+		Expect(c.Successes).To(Equal(2))
+
+		c, ready = r.RecordSuccess("fedora:43")
+		Expect(ready).To(BeTrue())
+		Expect(c.FloatingTags).To(Equal(candidate.FloatingTags))
+		Expect(r.Pending()).To(BeEmpty())
+	})
+
+	It("should reset successes on failure", func() {
+		r := New(2)
+		Expect(r.Propose("fedora:43", candidate)).To(BeFalse())
+		r.RecordFailure("fedora:43")
+		_, ready := r.RecordSuccess("fedora:43")
+		Expect(ready).To(BeFalse())
+		_, ready = r.RecordSuccess("fedora:43")
+		Expect(ready).To(BeTrue())
+	})
+
+	It("should replace older candidates", func() {
+		r := New(2)
+		Expect(r.Propose("fedora:43", candidate)).To(BeFalse())
+		_, ready := r.RecordSuccess("fedora:43")
+		Expect(ready).To(BeTrue())
+
+		Expect(r.Propose("fedora:43", candidate)).To(BeFalse())
+		newer := Candidate{Tags: []string{"fedora:43-2406301230"}, FloatingTags: candidate.FloatingTags}
+		Expect(r.Propose("fedora:43", newer)).To(BeFalse())
+		c, ok := r.Get("fedora:43")
+		Expect(ok).To(BeTrue())
+		Expect(c.Tags).To(Equal(newer.Tags))
+		Expect(c.Successes).To(Equal(1))
+	})
+
+	It("should persist candidates across runs", func() {
+		fileName := filepath.Join(GinkgoT().TempDir(), "rollout.json")
+		r, err := Read(fileName, 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(r.Propose("fedora:43", candidate)).To(BeFalse())
+		Expect(r.Write(fileName)).To(Succeed())
+
+		r, err = Read(fileName, 2)
+		Expect(err).ToNot(HaveOccurred())
+		_, ready := r.RecordSuccess("fedora:43")
+		Expect(ready).To(BeTrue())
+	})
+})
+
+func TestRollout(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rollout Suite")
+}
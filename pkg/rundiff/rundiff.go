@@ -0,0 +1,175 @@
+package rundiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+// Kinds of changes of an artifact between two runs
+const (
+	KindAdded   = "added"
+	KindRemoved = "removed"
+	KindChanged = "changed"
+)
+
+// Fields of State which are compared
+const (
+	FieldVersion = "version"
+	FieldDigest  = "digest"
+	FieldStatus  = "status"
+)
+
+const (
+	statusFailed      = " failed"
+	statusQuarantined = "quarantined"
+)
+
+// State is what a run reports about an artifact.
+type State struct {
+	// Version is the most specific tag pinned to the version, e.g. 40-1.14, or the tag of the build.
+	Version string `json:",omitempty"`
+	Digest  string `json:",omitempty"`
+	// Status is the last stage the artifact went through, suffixed with " failed" if it failed.
+	Status string `json:",omitempty"`
+}
+
+// Change describes how an artifact differs between two runs.
+type Change struct {
+	Artifact string
+	Kind     string
+	// Fields lists the fields which differ between Before and After of changed artifacts.
+	Fields []string `json:",omitempty"`
+	Before *State   `json:",omitempty"`
+	After  *State   `json:",omitempty"`
+}
+
+// Load reads the results file of a run.
+func Load(fileName string) (map[string]api.ArtifactResult, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	results := map[string]api.ArtifactResult{}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("error parsing the results file %s: %v", fileName, err)
+	}
+	return results, nil
+}
+
+// StateOf returns the state of an artifact in the results of a run.
+func StateOf(result *api.ArtifactResult) State {
+	state := State{Digest: result.Digest, Status: result.Stage}
+	switch {
+	case result.Quarantined:
+		state.Status = statusQuarantined
+	case result.Err != "":
+		state.Status += statusFailed
+	}
+
+	for _, tag := range result.Tags {
+		if !slices.Contains(result.FloatingTags, tag) {
+			state.Version = tag[strings.LastIndex(tag, ":")+1:]
+		}
+	}
+	return state
+}
+
+// Diff returns the changes of the artifacts from the results of one run to the results of another, sorted
+// by artifact. Unchanged artifacts are omitted.
+func Diff(before, after map[string]api.ArtifactResult) []Change {
+	var changes []Change
+	for artifact, result := range before {
+		beforeState := StateOf(&result)
+		afterResult, exists := after[artifact]
+		if !exists {
+			changes = append(changes, Change{Artifact: artifact, Kind: KindRemoved, Before: &beforeState})
+			continue
+		}
+
+		afterState := StateOf(&afterResult)
+		if fields := changedFields(&beforeState, &afterState); len(fields) > 0 {
+			changes = append(changes, Change{
+				Artifact: artifact, Kind: KindChanged, Fields: fields, Before: &beforeState, After: &afterState,
+			})
+		}
+	}
+	for artifact, result := range after {
+		if _, exists := before[artifact]; !exists {
+			afterState := StateOf(&result)
+			changes = append(changes, Change{Artifact: artifact, Kind: KindAdded, After: &afterState})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Artifact < changes[j].Artifact
+	})
+	return changes
+}
+
+func changedFields(before, after *State) []string {
+	var fields []string
+	if before.Version != after.Version {
+		fields = append(fields, FieldVersion)
+	}
+	// Runs which did not push anything, e.g. because nothing changed upstream, don't know the digest
+	if before.Digest != after.Digest && before.Digest != "" && after.Digest != "" {
+		fields = append(fields, FieldDigest)
+	}
+	if before.Status != after.Status {
+		fields = append(fields, FieldStatus)
+	}
+	return fields
+}
+
+// Markdown renders the changes as a table for release announcements.
+func Markdown(changes []Change) string {
+	if len(changes) == 0 {
+		return "No changes.\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("| Artifact | Change | Version | Digest | Status |\n")
+	sb.WriteString("|---|---|---|---|---|\n")
+	for i := range changes {
+		c := &changes[i]
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n", c.Artifact, c.Kind,
+			c.column(func(s *State) string { return s.Version }),
+			c.column(func(s *State) string { return shortDigest(s.Digest) }),
+			c.column(func(s *State) string { return s.Status }))
+	}
+	return sb.String()
+}
+
+// column renders a field of the change, changed fields as transition from the old to the new value.
+func (c *Change) column(field func(*State) string) string {
+	switch {
+	case c.Before == nil:
+		return field(c.After)
+	case c.After == nil:
+		return field(c.Before)
+	}
+
+	before, after := field(c.Before), field(c.After)
+	if before == after || before == "" {
+		return after
+	}
+	if after == "" {
+		return before
+	}
+	return before + " → " + after
+}
+
+func shortDigest(digest string) string {
+	const shortLength = len("sha256:") + 12
+	if len(digest) > shortLength {
+		return digest[:shortLength]
+	}
+	return digest
+}
@@ -0,0 +1,97 @@
+package rundiff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+var _ = Describe("Rundiff", func() {
+	const (
+		digestA = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+		digestB = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	)
+
+	ubuntu := api.ArtifactResult{
+		Tags:         []string{"ubuntu:24.04-2407011200", "ubuntu:24.04"},
+		FloatingTags: []string{"ubuntu:24.04"},
+		Stage:        "promote",
+	}
+	before := map[string]api.ArtifactResult{
+		"fedora:40-x86_64": {
+			Tags:         []string{"fedora:40-2407011200", "fedora:40-1.14", "fedora:40", "fedora:latest"},
+			FloatingTags: []string{"fedora:40", "fedora:latest"},
+			Digest:       digestA,
+			Stage:        "verify",
+		},
+		"ubuntu:24.04-x86_64": ubuntu,
+		"debian:11-x86_64":    {Tags: []string{"debian:11-2407011200"}, Stage: "promote"},
+	}
+	after := map[string]api.ArtifactResult{
+		"fedora:40-x86_64": {
+			Tags:         []string{"fedora:40-2407021200", "fedora:40-1.15", "fedora:40", "fedora:latest"},
+			FloatingTags: []string{"fedora:40", "fedora:latest"},
+			Digest:       digestB,
+			Stage:        "verify",
+			Err:          "timed out",
+		},
+		"ubuntu:24.04-x86_64": ubuntu,
+		"debian:13-x86_64":    {Tags: []string{"debian:13-2407021200"}, Stage: "push", Quarantined: true},
+	}
+
+	It("StateOf should use the most specific pinned tag as version", func() {
+		result := before["fedora:40-x86_64"]
+		Expect(StateOf(&result)).To(Equal(State{Version: "40-1.14", Digest: digestA, Status: "verify"}))
+	})
+
+	It("Diff should report added, removed and changed artifacts", func() {
+		changes := Diff(before, after)
+		Expect(changes).To(Equal([]Change{
+			{Artifact: "debian:11-x86_64", Kind: KindRemoved, Before: &State{Version: "11-2407011200", Status: "promote"}},
+			{Artifact: "debian:13-x86_64", Kind: KindAdded, After: &State{Version: "13-2407021200", Status: "quarantined"}},
+			{
+				Artifact: "fedora:40-x86_64",
+				Kind:     KindChanged,
+				Fields:   []string{FieldVersion, FieldDigest, FieldStatus},
+				Before:   &State{Version: "40-1.14", Digest: digestA, Status: "verify"},
+				After:    &State{Version: "40-1.15", Digest: digestB, Status: "verify failed"},
+			},
+		}))
+
+		Expect(Markdown(changes)).To(Equal(`| Artifact | Change | Version | Digest | Status |
+|---|---|---|---|---|
+| debian:11-x86_64 | removed | 11-2407011200 |  | promote |
+| debian:13-x86_64 | added | 13-2407021200 |  | quarantined |
+| fedora:40-x86_64 | changed | 40-1.14 → 40-1.15 | sha256:aaaaaaaaaaaa → sha256:bbbbbbbbbbbb | verify → verify failed |
+`))
+	})
+
+	It("Diff should ignore unknown digests", func() {
+		unpushed := before["fedora:40-x86_64"]
+		unpushed.Digest = ""
+		Expect(Diff(before, map[string]api.ArtifactResult{
+			"fedora:40-x86_64":    unpushed,
+			"ubuntu:24.04-x86_64": before["ubuntu:24.04-x86_64"],
+			"debian:11-x86_64":    before["debian:11-x86_64"],
+		})).To(BeEmpty())
+		Expect(Markdown(nil)).To(Equal("No changes.\n"))
+	})
+
+	It("Load should read results files", func() {
+		fileName := filepath.Join(GinkgoT().TempDir(), "results.json")
+		Expect(os.WriteFile(fileName, []byte(`{"fedora:40-x86_64": {"Tags": ["fedora:40-1.14"], "Stage": "push"}}`), 0o600)).To(Succeed())
+		results, err := Load(fileName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(HaveKeyWithValue("fedora:40-x86_64", api.ArtifactResult{Tags: []string{"fedora:40-1.14"}, Stage: "push"}))
+	})
+})
+
+func TestRundiff(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rundiff Suite")
+}
@@ -0,0 +1,311 @@
+// Package runlock prevents concurrent publish runs from racing on the same repositories. A run holds a lease
+// per repository in a directory shared by all runs, e.g. a volume mounted into every pipeline job. Leases expire
+// unless they are renewed, so a crashed run only blocks the repositories until its leases run out.
+package runlock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is the time a lease is valid without being renewed.
+const DefaultTTL = 10 * time.Minute
+
+// Lease is the content of the lock file of a repository.
+type Lease struct {
+	Repository string    `json:"repository"`
+	Holder     string    `json:"holder"`
+	Acquired   time.Time `json:"acquired"`
+	Expires    time.Time `json:"expires"`
+}
+
+func (l *Lease) expired(now time.Time) bool {
+	return !now.Before(l.Expires)
+}
+
+// LockedError is returned if a repository is locked by another run.
+type LockedError struct {
+	Lease Lease
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("repository %s is locked by %s since %s until %s", e.Lease.Repository, e.Lease.Holder,
+		e.Lease.Acquired.Format(time.RFC3339), e.Lease.Expires.Format(time.RFC3339))
+}
+
+// Locks are the leases of a run on its repositories.
+type Locks struct {
+	dir          string
+	holder       string
+	ttl          time.Duration
+	repositories []string
+
+	mu       sync.Mutex
+	acquired map[string]time.Time
+}
+
+// Holder identifies the current process and is unique per run, e.g. runner-1/4711/0123abcd.
+func Holder() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	id := make([]byte, 4)
+	_, _ = rand.Read(id)
+	return fmt.Sprintf("%s/%d/%s", hostname, os.Getpid(), hex.EncodeToString(id))
+}
+
+// New returns the locks of holder on the repositories in dir. Nothing is locked before Acquire is called.
+func New(dir, holder string, repositories []string, ttl time.Duration) *Locks {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	repositories = slices.Clone(repositories)
+	slices.Sort(repositories)
+	return &Locks{
+		dir:          dir,
+		holder:       holder,
+		ttl:          ttl,
+		repositories: slices.Compact(repositories),
+		acquired:     map[string]time.Time{},
+	}
+}
+
+// Acquire locks all repositories or none of them. Expired leases of other runs are taken over, leases which
+// are still valid fail with a LockedError.
+func (l *Locks) Acquire(now time.Time) error {
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return fmt.Errorf("error creating run lock directory: %v", err)
+	}
+
+	for _, repository := range l.repositories {
+		if err := l.acquire(repository, now); err != nil {
+			return errors.Join(err, l.Release())
+		}
+	}
+	return nil
+}
+
+func (l *Locks) acquire(repository string, now time.Time) error {
+	lease := Lease{Repository: repository, Holder: l.holder, Acquired: now, Expires: now.Add(l.ttl)}
+	fileName := l.fileName(repository)
+	for range 2 {
+		err := create(fileName, &lease)
+		if err == nil {
+			l.mu.Lock()
+			l.acquired[repository] = now
+			l.mu.Unlock()
+			return nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+
+		current, err := read(fileName)
+		if err != nil {
+			return err
+		}
+		if !current.expired(now) {
+			return &LockedError{Lease: *current}
+		}
+		if err = l.takeOver(fileName, current, now); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("repository %s was locked by another run while taking over its expired lease", repository)
+}
+
+// create writes the lease to a temporary file and links it to fileName, which fails if fileName exists. Unlike
+// opening fileName exclusively, other runs never read a partially written lease.
+func create(fileName string, lease *Lease) error {
+	tmpFile, err := writeTemp(filepath.Dir(fileName), lease)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile)
+
+	if err := os.Link(tmpFile, fileName); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		return fmt.Errorf("error creating lock file %s: %v", fileName, err)
+	}
+	return nil
+}
+
+// takeOver removes the expired lease in fileName. The lease is first moved aside, so of several runs taking
+// it over at the same time only one removes it. A lease renewed in the meantime is put back.
+func (l *Locks) takeOver(fileName string, expired *Lease, now time.Time) error {
+	asideFile := fileName + "." + escape(l.holder) + ".expired"
+	if err := os.Rename(fileName, asideFile); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error taking over expired lock file %s: %v", fileName, err)
+	}
+	defer os.Remove(asideFile)
+
+	moved, err := read(asideFile)
+	if err != nil {
+		return err
+	}
+	if moved.Holder != expired.Holder || !moved.expired(now) {
+		if err = os.Link(asideFile, fileName); err != nil && !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("error restoring lock file %s: %v", fileName, err)
+		}
+		return &LockedError{Lease: *moved}
+	}
+	return nil
+}
+
+// Renew extends the leases of all acquired repositories. It fails if a lease was taken over or removed with
+// ForceUnlock, in which case the run has to stop publishing.
+func (l *Locks) Renew(now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for repository, acquired := range l.acquired {
+		fileName := l.fileName(repository)
+		current, err := read(fileName)
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("lease of repository %s was removed", repository)
+		} else if err != nil {
+			return err
+		}
+		if current.Holder != l.holder {
+			return fmt.Errorf("lease of repository %s was taken over by %s", repository, current.Holder)
+		}
+
+		lease := Lease{Repository: repository, Holder: l.holder, Acquired: acquired, Expires: now.Add(l.ttl)}
+		tmpFile, err := writeTemp(l.dir, &lease)
+		if err != nil {
+			return err
+		}
+		if err = os.Rename(tmpFile, fileName); err != nil {
+			os.Remove(tmpFile)
+			return fmt.Errorf("error renewing lock file %s: %v", fileName, err)
+		}
+	}
+	return nil
+}
+
+// Keep renews the leases every third of their TTL until stop is called. onLost is called once if the leases
+// can't be renewed.
+func (l *Locks) Keep(ctx context.Context, onLost func(error)) (stop func()) {
+	keepCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-keepCtx.Done():
+				return
+			case <-ticker.C:
+				if err := l.Renew(time.Now()); err != nil {
+					onLost(err)
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Release removes the leases which are still held by the run.
+func (l *Locks) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var errs []error
+	for repository := range l.acquired {
+		fileName := l.fileName(repository)
+		if current, err := read(fileName); err == nil && current.Holder == l.holder {
+			if err = os.Remove(fileName); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, fmt.Errorf("error releasing lock file %s: %v", fileName, err))
+			}
+		}
+		delete(l.acquired, repository)
+	}
+	return errors.Join(errs...)
+}
+
+// ForceUnlock removes the leases on the repositories regardless of their holder and returns them, for runs
+// which were killed without releasing their leases.
+func (l *Locks) ForceUnlock() ([]Lease, error) {
+	var removed []Lease
+	for _, repository := range l.repositories {
+		fileName := l.fileName(repository)
+		current, err := read(fileName)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err = os.Remove(fileName); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, fmt.Errorf("error removing lock file %s: %v", fileName, err)
+		}
+		// Unreadable leases are removed as well, they can't be released by their holder anymore
+		if current != nil {
+			removed = append(removed, *current)
+		}
+	}
+	return removed, nil
+}
+
+// fileName escapes the repository, e.g. quay.io/containerdisks/fedora is locked by quay.io_containerdisks_fedora.lock.
+func (l *Locks) fileName(repository string) string {
+	return filepath.Join(l.dir, escape(repository)+".lock")
+}
+
+func escape(name string) string {
+	return strings.NewReplacer("/", "_", ":", "_", "\\", "_").Replace(name)
+}
+
+func writeTemp(dir string, lease *Lease) (string, error) {
+	data, err := json.Marshal(lease)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.CreateTemp(dir, ".lease-*")
+	if err != nil {
+		return "", fmt.Errorf("error writing lease: %v", err)
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("error writing lease: %v", err)
+	}
+	return file.Name(), nil
+}
+
+func read(fileName string) (*Lease, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error reading lock file: %v", err)
+	}
+	lease := &Lease{}
+	if err := json.Unmarshal(data, lease); err != nil {
+		return nil, fmt.Errorf("error parsing lock file %s: %v", fileName, err)
+	}
+	return lease, nil
+}
@@ -0,0 +1,104 @@
+package runlock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Runlock", func() {
+	const ttl = 10 * time.Minute
+
+	var dir string
+	now := time.Date(2024, 6, 29, 12, 30, 0, 0, time.UTC)
+	repositories := []string{"quay.io/containerdisks/fedora", "quay.io/containerdisks/centos-stream"}
+
+	BeforeEach(func() {
+		dir = filepath.Join(GinkgoT().TempDir(), "locks")
+	})
+
+	It("should lock the repositories of a single run", func() {
+		first := New(dir, "first", repositories, ttl)
+		Expect(first.Acquire(now)).To(Succeed())
+		Expect(filepath.Join(dir, "quay.io_containerdisks_fedora.lock")).To(BeAnExistingFile())
+
+		second := New(dir, "second", repositories[:1], ttl)
+		var locked *LockedError
+		Expect(errors.As(second.Acquire(now.Add(time.Minute)), &locked)).To(BeTrue())
+		Expect(locked.Lease).To(Equal(Lease{
+			Repository: "quay.io/containerdisks/fedora",
+			Holder:     "first",
+			Acquired:   now,
+			Expires:    now.Add(ttl),
+		}))
+
+		Expect(first.Release()).To(Succeed())
+		Expect(second.Acquire(now.Add(time.Minute))).To(Succeed())
+	})
+
+	It("should not race on other repositories", func() {
+		Expect(New(dir, "first", repositories[:1], ttl).Acquire(now)).To(Succeed())
+		Expect(New(dir, "second", repositories[1:], ttl).Acquire(now)).To(Succeed())
+	})
+
+	It("should lock all repositories or none", func() {
+		Expect(New(dir, "first", repositories[:1], ttl).Acquire(now)).To(Succeed())
+		second := New(dir, "second", repositories, ttl)
+		Expect(second.Acquire(now)).To(MatchError(ContainSubstring("repository quay.io/containerdisks/fedora is locked by first")))
+		Expect(filepath.Join(dir, "quay.io_containerdisks_centos-stream.lock")).ToNot(BeAnExistingFile())
+	})
+
+	It("should take over expired leases", func() {
+		Expect(New(dir, "crashed", repositories, ttl).Acquire(now)).To(Succeed())
+		Expect(New(dir, "second", repositories, ttl).Acquire(now.Add(ttl))).To(Succeed())
+		entries, err := os.ReadDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+	})
+
+	It("should keep renewed leases", func() {
+		first := New(dir, "first", repositories, ttl)
+		Expect(first.Acquire(now)).To(Succeed())
+		Expect(first.Renew(now.Add(ttl - time.Minute))).To(Succeed())
+		Expect(New(dir, "second", repositories, ttl).Acquire(now.Add(ttl))).To(MatchError(ContainSubstring("locked by first")))
+	})
+
+	It("should fail renewing leases which were forcibly unlocked", func() {
+		first := New(dir, "first", repositories, ttl)
+		Expect(first.Acquire(now)).To(Succeed())
+
+		second := New(dir, "second", repositories[:1], ttl)
+		removed, err := second.ForceUnlock()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(ConsistOf(HaveField("Holder", "first")))
+		Expect(second.Acquire(now)).To(Succeed())
+
+		Expect(first.Renew(now.Add(time.Minute))).To(MatchError(ContainSubstring("was taken over by second")))
+		// Releasing does not remove the leases of other runs
+		Expect(first.Release()).To(Succeed())
+		Expect(filepath.Join(dir, "quay.io_containerdisks_fedora.lock")).To(BeAnExistingFile())
+	})
+
+	It("should remove unreadable leases with ForceUnlock", func() {
+		Expect(os.MkdirAll(dir, 0o755)).To(Succeed())
+		fileName := filepath.Join(dir, "quay.io_containerdisks_fedora.lock")
+		Expect(os.WriteFile(fileName, []byte("{"), 0o600)).To(Succeed())
+
+		locks := New(dir, "first", repositories[:1], ttl)
+		Expect(locks.Acquire(now)).To(MatchError(ContainSubstring("error parsing lock file")))
+		removed, err := locks.ForceUnlock()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(BeEmpty())
+		Expect(locks.Acquire(now)).To(Succeed())
+	})
+})
+
+func TestRunlock(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Runlock Suite")
+}
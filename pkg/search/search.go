@@ -0,0 +1,122 @@
+// Package search finds containerdisks in the catalog by their name, version, architectures and metadata.
+package search
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/osinfo"
+)
+
+// Fields terms can be restricted to with <field>:<value>
+const (
+	FieldName    = "name"
+	FieldVersion = "version"
+	FieldArch    = "arch"
+	FieldVariant = "variant"
+	FieldLicense = "license"
+	FieldOS      = "os"
+)
+
+var fields = []string{FieldArch, FieldLicense, FieldName, FieldOS, FieldVariant, FieldVersion}
+
+// Item is a containerdisk of the catalog.
+type Item struct {
+	// Image is the reference of the moving version tag of the containerdisk.
+	Image         string
+	Name          string
+	Version       string
+	Variant       string   `json:",omitempty"`
+	Architectures []string `json:",omitempty"`
+	License       string   `json:",omitempty"`
+	OSInfo        string   `json:",omitempty"`
+	EOL           string   `json:",omitempty"`
+	description   string
+}
+
+// NewItem returns the item of the containerdisk published as image, the metadata of the artifacts of all
+// architectures is merged.
+func NewItem(image string, metadata []*api.Metadata) Item {
+	first := metadata[0]
+	item := Item{
+		Image:       image,
+		Name:        first.Name,
+		Version:     first.Version,
+		Variant:     string(first.Variant),
+		License:     first.License,
+		OSInfo:      osinfo.ID(first),
+		EOL:         first.EOL,
+		description: first.Description,
+	}
+	for _, m := range metadata {
+		if m.Arch != "" && !slices.Contains(item.Architectures, m.Arch) {
+			item.Architectures = append(item.Architectures, m.Arch)
+		}
+	}
+	return item
+}
+
+// term is a word of a query, optionally restricted to a field.
+type term struct {
+	field string
+	value string
+}
+
+// Query is a parsed search query. An item matches if it matches all terms of the query.
+type Query []term
+
+// Parse parses a query of space separated terms. A term matches an item if it is contained in its name,
+// version, architectures, variant, license, OS identifier or description, ignoring case. A term like arch:aarch64
+// only matches the field, which has to be equal to the value.
+func Parse(query string) (Query, error) {
+	var q Query
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		field, value, found := strings.Cut(word, ":")
+		if !found || !slices.Contains(fields, field) {
+			q = append(q, term{value: word})
+			continue
+		}
+		if value == "" {
+			return nil, fmt.Errorf("term %q has no value", word)
+		}
+		q = append(q, term{field: field, value: value})
+	}
+	return q, nil
+}
+
+// Match returns whether the item matches all terms.
+func (q Query) Match(item *Item) bool {
+	for _, t := range q {
+		if !t.match(item) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t term) match(item *Item) bool {
+	values := map[string][]string{
+		FieldName:    {item.Name},
+		FieldVersion: {item.Version},
+		FieldArch:    item.Architectures,
+		FieldVariant: {item.Variant},
+		FieldLicense: {item.License},
+		FieldOS:      {item.OSInfo},
+	}
+	if t.field != "" {
+		return slices.ContainsFunc(values[t.field], func(value string) bool {
+			return strings.EqualFold(value, t.value)
+		})
+	}
+
+	for _, field := range fields {
+		for _, value := range values[field] {
+			if strings.Contains(strings.ToLower(value), t.value) {
+				return true
+			}
+		}
+	}
+	return strings.Contains(strings.ToLower(item.description), t.value)
+}
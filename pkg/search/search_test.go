@@ -0,0 +1,66 @@
+package search
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+var _ = Describe("Search", func() {
+	fedora := NewItem("quay.io/containerdisks/fedora:43", []*api.Metadata{
+		{Name: "fedora", Version: "43", Arch: "x86_64", License: "LicenseRef-Fedora", Description: "Fedora Cloud Base"},
+		{Name: "fedora", Version: "43", Arch: "aarch64", License: "LicenseRef-Fedora", Description: "Fedora Cloud Base"},
+	})
+	debian := NewItem("quay.io/containerdisks/debian:13-minimal", []*api.Metadata{
+		{Name: "debian", Version: "13", Arch: "x86_64", Variant: api.VariantMinimal, Description: "Debian trixie"},
+	})
+
+	match := func(query string, items ...*Item) []string {
+		q, err := Parse(query)
+		Expect(err).ToNot(HaveOccurred())
+		var images []string
+		for _, item := range items {
+			if q.Match(item) {
+				images = append(images, item.Image)
+			}
+		}
+		return images
+	}
+
+	It("NewItem should merge the architectures and derive the OS identifier", func() {
+		Expect(fedora.Architectures).To(Equal([]string{"x86_64", "aarch64"}))
+		Expect(fedora.OSInfo).To(Equal("fedora43"))
+		Expect(debian.Variant).To(Equal("minimal"))
+	})
+
+	It("should match free terms against all fields and the description", func() {
+		Expect(match("FED", &fedora, &debian)).To(Equal([]string{fedora.Image}))
+		Expect(match("trixie", &fedora, &debian)).To(Equal([]string{debian.Image}))
+		Expect(match("x86", &fedora, &debian)).To(Equal([]string{fedora.Image, debian.Image}))
+		Expect(match("x86 minimal", &fedora, &debian)).To(Equal([]string{debian.Image}))
+	})
+
+	It("should match field terms exactly", func() {
+		Expect(match("arch:aarch64", &fedora, &debian)).To(Equal([]string{fedora.Image}))
+		Expect(match("arch:aarch", &fedora, &debian)).To(BeEmpty())
+		Expect(match("version:13 os:debian13", &fedora, &debian)).To(Equal([]string{debian.Image}))
+	})
+
+	It("should treat unknown fields as free terms", func() {
+		Expect(match("cloud:base", &fedora)).To(BeEmpty())
+		Expect(match("https://", &fedora)).To(BeEmpty())
+	})
+
+	It("should reject field terms without value", func() {
+		_, err := Parse("arch:")
+		Expect(err).To(MatchError(`term "arch:" has no value`))
+	})
+})
+
+func TestSearch(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Search Suite")
+}
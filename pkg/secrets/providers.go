@@ -0,0 +1,164 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Environment variables configuring the Vault provider
+const (
+	EnvVaultAddr      = "VAULT_ADDR"
+	EnvVaultToken     = "VAULT_TOKEN"
+	EnvVaultNamespace = "VAULT_NAMESPACE"
+)
+
+// Vault reads secrets from the KV version 2 engine of HashiCorp Vault. The reference vault://secret/ci/quay#token
+// reads the key token of the secret ci/quay in the engine mounted at secret.
+type Vault struct {
+	// Addr is the address of the Vault server, VAULT_ADDR if unset.
+	Addr string
+	// Token authenticates the requests, VAULT_TOKEN if unset.
+	Token string
+	// Namespace is the Vault Enterprise namespace, VAULT_NAMESPACE if unset.
+	Namespace  string
+	HTTPClient *http.Client
+}
+
+func (v *Vault) Resolve(ctx context.Context, ref *url.URL) (string, error) {
+	secretKey, err := key(ref)
+	if err != nil {
+		return "", err
+	}
+	addr := strings.TrimSuffix(valueOrEnv(v.Addr, EnvVaultAddr), "/")
+	if addr == "" {
+		return "", fmt.Errorf("%s is not set", EnvVaultAddr)
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s/data/%s", addr, ref.Host, strings.TrimPrefix(ref.Path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", valueOrEnv(v.Token, EnvVaultToken))
+	if namespace := valueOrEnv(v.Namespace, EnvVaultNamespace); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	httpClient := v.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d from Vault", resp.StatusCode)
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("error parsing Vault response: %v", err)
+	}
+	value, exists := secret.Data.Data[secretKey]
+	if !exists {
+		return "", fmt.Errorf("secret has no key %q", secretKey)
+	}
+	return fmt.Sprint(value), nil
+}
+
+// SOPS decrypts files encrypted with SOPS by calling the sops binary, which picks up the keys (age, PGP or a
+// cloud KMS) from its usual configuration. The reference sops:///ci/secrets.yaml#quay.token extracts the nested
+// key token of quay, keys are separated by dots.
+type SOPS struct {
+	// Binary is the path of the sops binary, looked up in PATH if unset.
+	Binary string
+}
+
+func (s *SOPS) Resolve(ctx context.Context, ref *url.URL) (string, error) {
+	secretKey, err := key(ref)
+	if err != nil {
+		return "", err
+	}
+
+	binary := s.Binary
+	if binary == "" {
+		binary = "sops"
+	}
+	var extract strings.Builder
+	for _, part := range strings.Split(secretKey, ".") {
+		fmt.Fprintf(&extract, "[%q]", part)
+	}
+
+	//nolint:gosec // the binary and the file are configured by the operator
+	cmd := exec.CommandContext(ctx, binary, "--decrypt", "--extract", extract.String(), ref.Path)
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error decrypting %s: %v", ref.Path, err)
+	}
+	return string(out), nil
+}
+
+// Kubernetes reads Secrets from the cluster medius runs in, authenticated with its service account. The
+// reference k8s://ci/quay-credentials#token reads the key token of the Secret quay-credentials in the
+// namespace ci.
+type Kubernetes struct {
+	// Config is the configuration of the client, the in-cluster configuration if unset.
+	Config *rest.Config
+}
+
+func (k *Kubernetes) Resolve(ctx context.Context, ref *url.URL) (string, error) {
+	secretKey, err := key(ref)
+	if err != nil {
+		return "", err
+	}
+	namespace, name := ref.Host, strings.TrimPrefix(ref.Path, "/")
+	if namespace == "" || name == "" || strings.Contains(name, "/") {
+		return "", fmt.Errorf("reference %s is not of the form k8s://<namespace>/<name>#<key>", redact(ref))
+	}
+
+	config := k.Config
+	if config == nil {
+		if config, err = rest.InClusterConfig(); err != nil {
+			return "", fmt.Errorf("error loading the in-cluster configuration: %v", err)
+		}
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	value, exists := secret.Data[secretKey]
+	if !exists {
+		return "", fmt.Errorf("secret has no key %q", secretKey)
+	}
+	return string(value), nil
+}
+
+func valueOrEnv(value, env string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(env)
+}
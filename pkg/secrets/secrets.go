@@ -0,0 +1,121 @@
+// Package secrets resolves references to secrets kept in external secret stores, so long-lived tokens don't
+// have to be passed as plain environment variables. References are URLs whose scheme selects the provider:
+//
+//	env:NAME                      the environment variable NAME
+//	file:///path/to/secret        the content of a file
+//	vault://mount/path#key        the key of a Vault KV version 2 secret, see Vault
+//	sops:///path/to/file.yaml#key the key of a SOPS encrypted file, see SOPS
+//	k8s://namespace/name#key      the key of a Kubernetes Secret, when running in-cluster, see Kubernetes
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Provider resolves references of the scheme it is registered for to the value of the secret.
+type Provider interface {
+	Resolve(ctx context.Context, ref *url.URL) (string, error)
+}
+
+// ProviderFunc adapts a function to a Provider.
+type ProviderFunc func(ctx context.Context, ref *url.URL) (string, error)
+
+func (f ProviderFunc) Resolve(ctx context.Context, ref *url.URL) (string, error) {
+	return f(ctx, ref)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{
+		"env":   ProviderFunc(resolveEnv),
+		"file":  ProviderFunc(resolveFile),
+		"vault": &Vault{},
+		"sops":  &SOPS{},
+		"k8s":   &Kubernetes{},
+	}
+)
+
+// Register sets the provider of a scheme, replacing the provider registered before.
+func Register(scheme string, provider Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[scheme] = provider
+}
+
+func getProvider(scheme string) Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	return providers[scheme]
+}
+
+// Resolve returns the value of the secret a reference points to, with surrounding whitespace removed.
+func Resolve(ctx context.Context, ref string) (string, error) {
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("error parsing secret reference: %v", err)
+	}
+
+	provider := getProvider(parsed.Scheme)
+	if provider == nil {
+		return "", fmt.Errorf("unknown secret provider %q, expected one of %s", parsed.Scheme, strings.Join(schemes(), ", "))
+	}
+
+	value, err := provider.Resolve(ctx, parsed)
+	if err != nil {
+		// The reference is logged instead of the value, it contains no secret
+		return "", fmt.Errorf("error resolving secret %s: %v", redact(parsed), err)
+	}
+	return strings.TrimSpace(value), nil
+}
+
+func schemes() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for scheme := range providers {
+		names = append(names, scheme)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// redact drops user info from the reference, in case somebody put a password there.
+func redact(ref *url.URL) string {
+	redacted := *ref
+	redacted.User = nil
+	return redacted.String()
+}
+
+// key returns the key inside the secret, the fragment of the reference.
+func key(ref *url.URL) (string, error) {
+	if ref.Fragment == "" {
+		return "", fmt.Errorf("reference %s is missing the #key of the secret", redact(ref))
+	}
+	return ref.Fragment, nil
+}
+
+func resolveEnv(_ context.Context, ref *url.URL) (string, error) {
+	name := ref.Opaque
+	if name == "" {
+		name = ref.Host
+	}
+	value, exists := os.LookupEnv(name)
+	if !exists {
+		return "", fmt.Errorf("environment variable %q is not set", name)
+	}
+	return value, nil
+}
+
+func resolveFile(_ context.Context, ref *url.URL) (string, error) {
+	data, err := os.ReadFile(ref.Path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,96 @@
+package streams
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"kubevirt.io/containerdisks/pkg/ociartifact"
+)
+
+const (
+	// MediaType is the media type of the streams document when it is pushed as OCI artifact.
+	MediaType types.MediaType = "application/vnd.kubevirt.containerdisks.streams.v1+json"
+	// ArtifactConfigMediaType is the config media type of the streams OCI artifact.
+	ArtifactConfigMediaType types.MediaType = "application/vnd.kubevirt.containerdisks.streams.config.v1+json"
+	// FileName is the name of the streams document when it is published as a static file.
+	FileName = "streams.json"
+)
+
+// Streams describes all published containerdisks, similar to the CoreOS stream metadata.
+type Streams struct {
+	Metadata       Metadata                 `json:"metadata"`
+	ContainerDisks map[string]ContainerDisk `json:"containerdisks"`
+}
+
+type Metadata struct {
+	LastModified time.Time `json:"last-modified"`
+}
+
+// ContainerDisk holds all published streams (versions) of a containerdisk.
+type ContainerDisk struct {
+	Streams map[string]Stream `json:"streams"`
+}
+
+type Stream struct {
+	// Image is the pullable reference of the stream, e.g. quay.io/containerdisks/fedora:40.
+	Image string `json:"image"`
+	// Digest of the image (index) the stream currently points to.
+	Digest string `json:"digest"`
+	// Latest indicates that the "latest" tag points to this stream.
+	Latest bool `json:"latest,omitempty"`
+	// EOL is the end of life date of the upstream version, if known.
+	EOL string `json:"eol,omitempty"`
+	// ReleaseNotes is the URL of the upstream release notes of the stream, if known.
+	ReleaseNotes string `json:"release-notes,omitempty"`
+	// Variant of the stream, e.g. minimal. Streams of variants are keyed by their suffixed version, e.g. 43-minimal.
+	Variant       string                  `json:"variant,omitempty"`
+	Architectures map[string]Architecture `json:"architectures"`
+}
+
+type Architecture struct {
+	// Labels of the image, including the checksum and size annotations.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func New(lastModified time.Time) *Streams {
+	return &Streams{
+		Metadata:       Metadata{LastModified: lastModified.UTC()},
+		ContainerDisks: map[string]ContainerDisk{},
+	}
+}
+
+// Add adds or replaces a stream of a containerdisk.
+func (s *Streams) Add(name, version string, stream Stream) {
+	containerDisk, ok := s.ContainerDisks[name]
+	if !ok {
+		containerDisk = ContainerDisk{Streams: map[string]Stream{}}
+		s.ContainerDisks[name] = containerDisk
+	}
+	containerDisk.Streams[version] = stream
+}
+
+func (s *Streams) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling streams: %v", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Artifact packages the streams document as OCI artifact with a single layer.
+func (s *Streams) Artifact() (v1.Image, error) {
+	data, err := s.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return ociartifact.New(ArtifactConfigMediaType, ociartifact.File{
+		Name:      FileName,
+		MediaType: MediaType,
+		Data:      data,
+	})
+}
@@ -0,0 +1,66 @@
+package streams
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Streams", func() {
+	var s *Streams
+
+	BeforeEach(func() {
+		s = New(time.Date(2024, 6, 29, 12, 30, 0, 0, time.UTC))
+		s.Add("fedora", "40", Stream{
+			Image:  "quay.io/containerdisks/fedora:40",
+			Digest: "sha256:abcd",
+			Latest: true,
+			Architectures: map[string]Architecture{
+				"x86_64": {Labels: map[string]string{"shasum": "1234"}},
+			},
+		})
+		s.Add("fedora", "39", Stream{Image: "quay.io/containerdisks/fedora:39", EOL: "2024-11-26"})
+	})
+
+	It("should marshal the streams document", func() {
+		data, err := s.Marshal()
+		Expect(err).ToNot(HaveOccurred())
+
+		var parsed Streams
+		Expect(json.Unmarshal(data, &parsed)).To(Succeed())
+		Expect(parsed).To(Equal(*s))
+		Expect(string(data)).To(ContainSubstring(`"last-modified": "2024-06-29T12:30:00Z"`))
+		Expect(parsed.ContainerDisks["fedora"].Streams).To(HaveLen(2))
+	})
+
+	It("should package the streams document as OCI artifact", func() {
+		img, err := s.Artifact()
+		Expect(err).ToNot(HaveOccurred())
+
+		manifest, err := img.Manifest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Config.MediaType).To(Equal(ArtifactConfigMediaType))
+		Expect(manifest.Layers).To(HaveLen(1))
+		Expect(manifest.Layers[0].MediaType).To(Equal(MediaType))
+		Expect(manifest.Layers[0].Annotations).To(HaveKeyWithValue("org.opencontainers.image.title", FileName))
+
+		layers, err := img.Layers()
+		Expect(err).ToNot(HaveOccurred())
+		reader, err := layers[0].Uncompressed()
+		Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		expected, err := s.Marshal()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(expected))
+	})
+})
+
+func TestStreams(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Streams Suite")
+}
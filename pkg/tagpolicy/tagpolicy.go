@@ -0,0 +1,79 @@
+package tagpolicy
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Scheme describes how the versions of a provider are structured.
+type Scheme string
+
+const (
+	// SchemeExact uses versions as they are, e.g. for date based snapshots like "20240629".
+	SchemeExact Scheme = ""
+	// SchemeSemver expands "1.2.3" to "1.2.3", "1.2" and "1".
+	SchemeSemver Scheme = "semver"
+	// SchemePointRelease expands point releases like "24.04.1" to "24.04.1" and "24.04",
+	// but never to the bare major version.
+	SchemePointRelease Scheme = "point-release"
+)
+
+// LatestTag is the floating tag pointing to the latest stable release.
+const LatestTag = "latest"
+
+var dottedVersionRex = regexp.MustCompile(`^v?(\d+)(\.\d+)*$`)
+
+// Tags computes the tags to apply to a containerdisk, ordered from the most to the least specific tag.
+// The unique tags and the moving version tag are expanded according to the scheme and deduplicated.
+// Floating aliases follow the version tags and if latest is true, the "latest" tag is appended last.
+func Tags(scheme Scheme, version string, uniqueTags, aliases []string, latest bool) []string {
+	var tags []string
+	seen := map[string]bool{}
+	add := func(candidates ...string) {
+		for _, tag := range candidates {
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	for _, tag := range uniqueTags {
+		add(Expand(scheme, tag)...)
+	}
+	// The moving version tag is less specific than the unique tags
+	add(Expand(scheme, version)...)
+	add(aliases...)
+
+	if latest {
+		add(LatestTag)
+	}
+
+	return tags
+}
+
+// Expand returns all tags a version expands to with the given scheme, the most specific tag first.
+func Expand(scheme Scheme, version string) []string {
+	if version == "" {
+		return nil
+	}
+
+	if scheme == SchemeExact || !dottedVersionRex.MatchString(version) {
+		return []string{version}
+	}
+
+	components := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	minComponents := 1
+	if scheme == SchemePointRelease {
+		const majorMinor = 2
+		minComponents = min(majorMinor, len(components))
+	}
+
+	tags := []string{version}
+	for i := len(components) - 1; i >= minComponents; i-- {
+		tags = append(tags, strings.Join(components[:i], "."))
+	}
+
+	return tags
+}
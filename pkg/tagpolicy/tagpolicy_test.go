@@ -0,0 +1,45 @@
+package tagpolicy
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tagpolicy", func() {
+	DescribeTable("Expand should expand versions according to the scheme",
+		func(scheme Scheme, version string, expected []string) {
+			Expect(Expand(scheme, version)).To(Equal(expected))
+		},
+		Entry("exact", SchemeExact, "1.2.3", []string{"1.2.3"}),
+		Entry("exact snapshot", SchemeExact, "20240629", []string{"20240629"}),
+		Entry("semver", SchemeSemver, "1.2.3", []string{"1.2.3", "1.2", "1"}),
+		Entry("semver with prefix", SchemeSemver, "v1.2", []string{"v1.2", "1"}),
+		Entry("semver major only", SchemeSemver, "40", []string{"40"}),
+		Entry("semver prerelease", SchemeSemver, "1.2.3-rc1", []string{"1.2.3-rc1"}),
+		Entry("point release", SchemePointRelease, "24.04.1", []string{"24.04.1", "24.04"}),
+		Entry("point release major.minor", SchemePointRelease, "15.6", []string{"15.6"}),
+		Entry("point release major only", SchemePointRelease, "13", []string{"13"}),
+		Entry("empty", SchemeSemver, "", []string(nil)),
+	)
+
+	DescribeTable("Tags should compute an ordered set of tags",
+		func(scheme Scheme, version string, uniqueTags, aliases []string, latest bool, expected []string) {
+			Expect(Tags(scheme, version, uniqueTags, aliases, latest)).To(Equal(expected))
+		},
+		Entry("exact with unique tags", SchemeExact, "40", []string{"40-1.14", ""}, nil, true,
+			[]string{"40-1.14", "40", LatestTag}),
+		Entry("semver deduplicates", SchemeSemver, "1.2", []string{"1.2.3"}, nil, false,
+			[]string{"1.2.3", "1.2", "1"}),
+		Entry("point release", SchemePointRelease, "24.04", []string{"24.04.1"}, nil, true,
+			[]string{"24.04.1", "24.04", LatestTag}),
+		Entry("aliases", SchemeExact, "13", []string{"13-20240629"}, []string{"trixie", "13"}, true,
+			[]string{"13-20240629", "13", "trixie", LatestTag}),
+	)
+})
+
+func TestTagpolicy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tagpolicy Suite")
+}
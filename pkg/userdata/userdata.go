@@ -0,0 +1,92 @@
+// Package userdata generates the user data of containerdisks, which configures the login user and its SSH keys,
+// as plain cloud-init or Ignition configs and as Secrets VMs can reference in their cloud-init volume.
+package userdata
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/docs"
+)
+
+// Format is the format of generated user data.
+type Format string
+
+const (
+	// FormatDefault is the format the containerdisk uses in its examples and verification.
+	FormatDefault   Format = ""
+	FormatCloudInit Format = "cloud-init"
+	FormatIgnition  Format = "ignition"
+)
+
+// SecretKey is the key of Secrets KubeVirt reads the user data from.
+const SecretKey = "userdata"
+
+const cloudConfigHeader = "#cloud-config"
+
+// UserData is the generated user data of a containerdisk.
+type UserData struct {
+	// Username is the login user the SSH keys are authorized for.
+	Username string
+	Format   Format
+	// Data is the rendered cloud-init or Ignition config. Without authorized keys it contains a placeholder,
+	// which has to be replaced with a public key before use.
+	Data string
+}
+
+// Generate returns the user data of the artifact authorizing the keys, in the format of the artifact unless
+// another format is requested. Artifacts without example user data, e.g. generic images, have none.
+func Generate(artifact api.Artifact, format Format, authorizedKeys []string) (*UserData, error) {
+	metadata := artifact.Metadata()
+	data := &docs.UserData{
+		Username: metadata.ExampleUserData.Username,
+		// The Ignition template quotes the keys in place
+		AuthorizedKeys: slices.Clone(authorizedKeys),
+	}
+	if data.Username == "" {
+		return nil, fmt.Errorf("containerdisk %s has no user data", metadata.Describe())
+	}
+
+	userData := &UserData{Username: data.Username, Format: format}
+	switch format {
+	case FormatDefault:
+		userData.Data = artifact.UserData(data)
+		if userData.Data == "" {
+			return nil, fmt.Errorf("containerdisk %s has no user data", metadata.Describe())
+		}
+		userData.Format = FormatIgnition
+		if strings.HasPrefix(userData.Data, cloudConfigHeader) {
+			userData.Format = FormatCloudInit
+		}
+	case FormatCloudInit:
+		userData.Data = docs.CloudInit(data)
+	case FormatIgnition:
+		userData.Data = docs.Ignition(data)
+	default:
+		return nil, fmt.Errorf("unknown user data format %q, expected %s or %s", format, FormatCloudInit, FormatIgnition)
+	}
+
+	return userData, nil
+}
+
+// Secret returns a Secret holding the user data, for the userDataSecretRef of a cloudInitNoCloud or
+// cloudInitConfigDrive volume. Unlike inline user data, Secrets keep the keys out of the VM manifest.
+func (u *UserData) Secret(name, namespace string) *k8sv1.Secret {
+	return &k8sv1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: k8sv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Type:       k8sv1.SecretTypeOpaque,
+		StringData: map[string]string{SecretKey: u.Data},
+	}
+}
@@ -0,0 +1,53 @@
+package userdata
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/artifacts/debian"
+	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/docs"
+)
+
+var _ = Describe("UserData", func() {
+	artifact := debian.New("13", "trixie", "x86_64", &docs.UserData{Username: "debian"}, nil)
+
+	It("should generate the user data in the format of the containerdisk", func() {
+		userData, err := Generate(artifact, FormatDefault, []string{"ssh-ed25519 AAAA key@example.com"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(userData.Username).To(Equal("debian"))
+		Expect(userData.Format).To(Equal(FormatCloudInit))
+		Expect(userData.Data).To(ContainSubstring("- ssh-ed25519 AAAA key@example.com"))
+	})
+
+	It("should generate placeholders and other formats", func() {
+		userData, err := Generate(artifact, FormatIgnition, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(userData.Format).To(Equal(FormatIgnition))
+		Expect(userData.Data).To(ContainSubstring(`"name": "debian"`))
+		Expect(userData.Data).To(ContainSubstring(`"ssh-rsa AAAA..."`))
+
+		_, err = Generate(artifact, "butane", nil)
+		Expect(err).To(MatchError(`unknown user data format "butane", expected cloud-init or ignition`))
+	})
+
+	It("should reject containerdisks without user data", func() {
+		_, err := Generate(generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: "cirros", Version: "6.1"}), FormatDefault, nil)
+		Expect(err).To(MatchError("containerdisk cirros:6.1 has no user data"))
+	})
+
+	It("should wrap the user data into a Secret", func() {
+		secret := (&UserData{Data: "#cloud-config\n"}).Secret("debian-userdata", "vms")
+		Expect(secret.Name).To(Equal("debian-userdata"))
+		Expect(secret.Namespace).To(Equal("vms"))
+		Expect(secret.StringData).To(Equal(map[string]string{SecretKey: "#cloud-config\n"}))
+	})
+})
+
+func TestUserData(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "UserData Suite")
+}
@@ -0,0 +1,138 @@
+package utilitydisk
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// swapPageSize is the page size of x86_64 and the default one of aarch64. The swap header is written in
+	// little endian, so swap disks are only usable on little endian architectures.
+	swapPageSize    = 4096
+	swapHeaderStart = 1024
+	swapMinPages    = 10
+	swapSignature   = "SWAPSPACE2"
+	swapLabel       = "swap"
+
+	sectorSize        = 512
+	fatReservedSecs   = 32
+	fatCount          = 2
+	fatMinClusters    = 65525
+	fatRootCluster    = 2
+	fatBackupBootSec  = 6
+	fatFSInfoSec      = 1
+	fatMediaFixedDisk = 0xf8
+	fatLabel          = "SCRATCH"
+	fatMaxSectors     = 1<<32 - 1
+)
+
+// volumeID derives the UUID and volume ID of a filesystem from its type and size, so generating the same disk
+// again results in the same content.
+func volumeID(filesystem Filesystem, size int64) [16]byte {
+	id := sha256.Sum256(fmt.Appendf(nil, "containerdisks/%s/%d", filesystem, size))
+	var uuid [16]byte
+	copy(uuid[:], id[:])
+	// Mark it as name based UUID of RFC 4122
+	uuid[6] = uuid[6]&0x0f | 0x50
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return uuid
+}
+
+// swapExtents returns the header of a Linux swap area (version 1) spanning the disk.
+func swapExtents(size int64) ([]extent, error) {
+	pages := size / swapPageSize
+	if pages < swapMinPages {
+		return nil, fmt.Errorf("swap disk must be at least %d bytes", swapMinPages*swapPageSize)
+	}
+
+	header := make([]byte, swapPageSize)
+	binary.LittleEndian.PutUint32(header[swapHeaderStart:], 1)
+	binary.LittleEndian.PutUint32(header[swapHeaderStart+4:], uint32(pages-1))
+	uuid := volumeID(FilesystemSwap, size)
+	copy(header[swapHeaderStart+12:], uuid[:])
+	copy(header[swapHeaderStart+28:], swapLabel)
+	copy(header[swapPageSize-len(swapSignature):], swapSignature)
+	return []extent{{offset: 0, data: header}}, nil
+}
+
+// fatSectorsPerCluster follows the recommendations of the FAT specification for the size of the volume.
+func fatSectorsPerCluster(size int64) int64 {
+	const gi = 1 << 30
+	switch {
+	case size <= 260<<20:
+		return 1
+	case size <= 8*gi:
+		return 8
+	case size <= 16*gi:
+		return 16
+	case size <= 32*gi:
+		return 32
+	default:
+		return 64
+	}
+}
+
+// vfatExtents returns the boot sectors and file allocation tables of an empty FAT32 filesystem spanning the disk.
+func vfatExtents(size int64) ([]extent, error) {
+	sectors := size / sectorSize
+	if sectors > fatMaxSectors {
+		return nil, fmt.Errorf("vfat disk must not be larger than %d bytes", int64(fatMaxSectors)*sectorSize)
+	}
+	sectorsPerCluster := fatSectorsPerCluster(size)
+	// The size of a FAT as calculated by the FAT specification, it may be slightly larger than required
+	divisor := (256*sectorsPerCluster + fatCount) / 2
+	fatSectors := (sectors - fatReservedSecs + divisor - 1) / divisor
+	clusters := (sectors - fatReservedSecs - fatCount*fatSectors) / sectorsPerCluster
+	if clusters < fatMinClusters {
+		return nil, fmt.Errorf("vfat disk of %d bytes is too small for FAT32", size)
+	}
+
+	boot := make([]byte, sectorSize)
+	copy(boot, []byte{0xeb, 0x58, 0x90})
+	copy(boot[3:], "MEDIUS  ")
+	binary.LittleEndian.PutUint16(boot[11:], sectorSize)
+	boot[13] = byte(sectorsPerCluster)
+	binary.LittleEndian.PutUint16(boot[14:], fatReservedSecs)
+	boot[16] = fatCount
+	boot[21] = fatMediaFixedDisk
+	binary.LittleEndian.PutUint16(boot[24:], 63)
+	binary.LittleEndian.PutUint16(boot[26:], 255)
+	binary.LittleEndian.PutUint32(boot[32:], uint32(sectors))
+	binary.LittleEndian.PutUint32(boot[36:], uint32(fatSectors))
+	binary.LittleEndian.PutUint32(boot[44:], fatRootCluster)
+	binary.LittleEndian.PutUint16(boot[48:], fatFSInfoSec)
+	binary.LittleEndian.PutUint16(boot[50:], fatBackupBootSec)
+	boot[64] = 0x80
+	boot[66] = 0x29
+	uuid := volumeID(FilesystemVFAT, size)
+	copy(boot[67:71], uuid[:])
+	copy(boot[71:82], fmt.Sprintf("%-11s", fatLabel))
+	copy(boot[82:90], "FAT32   ")
+	boot[510], boot[511] = 0x55, 0xaa
+
+	fsInfo := make([]byte, sectorSize)
+	binary.LittleEndian.PutUint32(fsInfo, 0x41615252)
+	binary.LittleEndian.PutUint32(fsInfo[484:], 0x61417272)
+	// The root directory occupies the first cluster
+	binary.LittleEndian.PutUint32(fsInfo[488:], uint32(clusters-1))
+	binary.LittleEndian.PutUint32(fsInfo[492:], fatRootCluster+1)
+	binary.LittleEndian.PutUint32(fsInfo[508:], 0xaa550000)
+
+	// The first two entries are reserved, the third one marks the root directory as end of its cluster chain
+	fat := make([]byte, 12)
+	binary.LittleEndian.PutUint32(fat, 0x0ffffff0|fatMediaFixedDisk)
+	binary.LittleEndian.PutUint32(fat[4:], 0x0fffffff)
+	binary.LittleEndian.PutUint32(fat[8:], 0x0fffffff)
+
+	extents := []extent{
+		{offset: 0, data: boot},
+		{offset: fatFSInfoSec * sectorSize, data: fsInfo},
+		{offset: fatBackupBootSec * sectorSize, data: boot},
+		{offset: (fatBackupBootSec + fatFSInfoSec) * sectorSize, data: fsInfo},
+	}
+	for i := range int64(fatCount) {
+		extents = append(extents, extent{offset: (fatReservedSecs + i*fatSectors) * sectorSize, data: fat})
+	}
+	return extents, nil
+}
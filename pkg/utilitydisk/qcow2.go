@@ -0,0 +1,110 @@
+package utilitydisk
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+const (
+	clusterBits = 16
+	clusterSize = 1 << clusterBits
+	// l2Entries is the number of 8 byte entries of an L2 table filling a cluster.
+	l2Entries = clusterSize / 8
+	// refcountEntries is the number of 16 bit refcounts of a refcount block filling a cluster.
+	refcountEntries = clusterSize / 2
+	// copiedFlag marks L1 and L2 entries of clusters which are referenced once.
+	copiedFlag = uint64(1) << 63
+	// qcow2Version 2 has the simplest header, which all versions of qemu-img read.
+	qcow2Version = 2
+)
+
+var qcow2Magic = []byte{'Q', 'F', 'I', 0xfb}
+
+// extent is data at an offset of the disk. All other data of the disk reads as zeros.
+type extent struct {
+	offset int64
+	data   []byte
+}
+
+// writeQcow2 writes a qcow2 image with a virtual size of size bytes containing the extents. Only the clusters
+// of the extents are allocated, so the image stays small regardless of its virtual size. The image is written
+// from scratch, without timestamps or random data, so the same extents always result in the same image.
+func writeQcow2(w io.Writer, size int64, extents []extent) error {
+	clusters := map[int64][]byte{}
+	for _, e := range extents {
+		for written := 0; written < len(e.data); {
+			offset := e.offset + int64(written)
+			cluster, ok := clusters[offset/clusterSize]
+			if !ok {
+				cluster = make([]byte, clusterSize)
+				clusters[offset/clusterSize] = cluster
+			}
+			written += copy(cluster[offset%clusterSize:], e.data[written:])
+		}
+	}
+	dataClusters := slices.Sorted(maps.Keys(clusters))
+
+	var l2Tables []int64
+	for _, index := range dataClusters {
+		if table := index / l2Entries; !slices.Contains(l2Tables, table) {
+			l2Tables = append(l2Tables, table)
+		}
+	}
+
+	// The image consists of the header, the refcount table, a single refcount block, the L1 table,
+	// the L2 tables and the data clusters, in this order.
+	l1Size := divideRoundUp(divideRoundUp(size, clusterSize), l2Entries)
+	l1Offset := int64(3 * clusterSize)
+	l2Offset := l1Offset + divideRoundUp(l1Size*8, clusterSize)*clusterSize
+	dataOffset := l2Offset + int64(len(l2Tables))*clusterSize
+	imageClusters := dataOffset/clusterSize + int64(len(dataClusters))
+	if imageClusters > refcountEntries {
+		return fmt.Errorf("disk of %d bytes needs more than %d clusters", size, refcountEntries)
+	}
+
+	header := make([]byte, clusterSize)
+	copy(header, qcow2Magic)
+	binary.BigEndian.PutUint32(header[4:], qcow2Version)
+	binary.BigEndian.PutUint32(header[20:], clusterBits)
+	binary.BigEndian.PutUint64(header[24:], uint64(size))
+	binary.BigEndian.PutUint32(header[36:], uint32(l1Size))
+	binary.BigEndian.PutUint64(header[40:], uint64(l1Offset))
+	binary.BigEndian.PutUint64(header[48:], clusterSize)
+	binary.BigEndian.PutUint32(header[56:], 1)
+
+	refcountTable := make([]byte, clusterSize)
+	binary.BigEndian.PutUint64(refcountTable, 2*clusterSize)
+	refcountBlock := make([]byte, clusterSize)
+	for i := range imageClusters {
+		binary.BigEndian.PutUint16(refcountBlock[i*2:], 1)
+	}
+
+	l1 := make([]byte, l2Offset-l1Offset)
+	for i, table := range l2Tables {
+		binary.BigEndian.PutUint64(l1[table*8:], uint64(l2Offset+int64(i)*clusterSize)|copiedFlag)
+	}
+	l2 := make([]byte, dataOffset-l2Offset)
+	for i, index := range dataClusters {
+		entry := int64(slices.Index(l2Tables, index/l2Entries))*clusterSize + index%l2Entries*8
+		binary.BigEndian.PutUint64(l2[entry:], uint64(dataOffset+int64(i)*clusterSize)|copiedFlag)
+	}
+
+	for _, data := range [][]byte{header, refcountTable, refcountBlock, l1, l2} {
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("error writing qcow2 image: %v", err)
+		}
+	}
+	for _, index := range dataClusters {
+		if _, err := w.Write(clusters[index]); err != nil {
+			return fmt.Errorf("error writing qcow2 image: %v", err)
+		}
+	}
+	return nil
+}
+
+func divideRoundUp(n, d int64) int64 {
+	return (n + d - 1) / d
+}
@@ -0,0 +1,53 @@
+// Package utilitydisk generates blank disks, optionally formatted with a filesystem or as swap area, which are
+// published as containerdisks for scratch volumes. The disks are generated reproducibly as sparse qcow2 images,
+// without external tools.
+package utilitydisk
+
+import (
+	"fmt"
+	"io"
+)
+
+// Filesystem is the format of a generated disk.
+type Filesystem string
+
+const (
+	// FilesystemNone leaves the disk unformatted.
+	FilesystemNone Filesystem = ""
+	// FilesystemSwap formats the disk as Linux swap area labeled "swap".
+	FilesystemSwap Filesystem = "swap"
+	// FilesystemVFAT formats the disk with an empty FAT32 filesystem labeled "SCRATCH", which Linux and
+	// Windows guests can mount without further tools.
+	FilesystemVFAT Filesystem = "vfat"
+)
+
+// Spec describes a generated disk.
+type Spec struct {
+	// Size is the virtual size of the disk in bytes, a multiple of 512.
+	Size       int64
+	Filesystem Filesystem
+}
+
+// Generate writes the qcow2 image of the disk described by spec to w.
+func Generate(w io.Writer, spec Spec) error {
+	if spec.Size <= 0 || spec.Size%sectorSize != 0 {
+		return fmt.Errorf("disk size %d must be a positive multiple of %d", spec.Size, sectorSize)
+	}
+
+	var extents []extent
+	var err error
+	switch spec.Filesystem {
+	case FilesystemNone:
+	case FilesystemSwap:
+		extents, err = swapExtents(spec.Size)
+	case FilesystemVFAT:
+		extents, err = vfatExtents(spec.Size)
+	default:
+		return fmt.Errorf("unsupported filesystem %q, use %s or %s", spec.Filesystem, FilesystemSwap, FilesystemVFAT)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeQcow2(w, spec.Size, extents)
+}
@@ -0,0 +1,83 @@
+package utilitydisk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Utility disks", func() {
+	generate := func(spec Spec) []byte {
+		var buf bytes.Buffer
+		Expect(Generate(&buf, spec)).To(Succeed())
+		return buf.Bytes()
+	}
+
+	// readAt resolves offset of the disk through the L1 and L2 tables of the image.
+	readAt := func(image []byte, offset int64, length int) []byte {
+		l1Offset := int64(binary.BigEndian.Uint64(image[40:]))
+		cluster := offset / clusterSize
+		l2Offset := int64(binary.BigEndian.Uint64(image[l1Offset+cluster/l2Entries*8:]) &^ copiedFlag)
+		if l2Offset == 0 {
+			return make([]byte, length)
+		}
+		dataOffset := int64(binary.BigEndian.Uint64(image[l2Offset+cluster%l2Entries*8:]) &^ copiedFlag)
+		if dataOffset == 0 {
+			return make([]byte, length)
+		}
+		start := dataOffset + offset%clusterSize
+		return image[start : start+int64(length)]
+	}
+
+	It("should generate sparse qcow2 images of the virtual size", func() {
+		image := generate(Spec{Size: 10 << 30})
+		Expect(image[:4]).To(Equal(qcow2Magic))
+		Expect(binary.BigEndian.Uint64(image[24:])).To(BeEquivalentTo(10 << 30))
+		Expect(image).To(HaveLen(4 * clusterSize))
+		Expect(readAt(image, 5<<30, 16)).To(Equal(make([]byte, 16)))
+	})
+
+	It("should generate swap areas", func() {
+		image := generate(Spec{Size: 1 << 30, Filesystem: FilesystemSwap})
+		header := readAt(image, 0, swapPageSize)
+		Expect(string(header[swapPageSize-10:])).To(Equal("SWAPSPACE2"))
+		Expect(binary.LittleEndian.Uint32(header[1028:])).To(BeEquivalentTo(1<<30/swapPageSize - 1))
+		Expect(string(header[1052:1056])).To(Equal("swap"))
+	})
+
+	It("should generate FAT32 filesystems with their backup boot sector and both FATs", func() {
+		image := generate(Spec{Size: 10 << 30, Filesystem: FilesystemVFAT})
+		boot := readAt(image, 0, sectorSize)
+		Expect(boot[510:]).To(Equal([]byte{0x55, 0xaa}))
+		Expect(string(boot[82:90])).To(Equal("FAT32   "))
+		Expect(readAt(image, fatBackupBootSec*sectorSize, sectorSize)).To(Equal(boot))
+
+		fatSectors := int64(binary.LittleEndian.Uint32(boot[36:]))
+		for i := range int64(fatCount) {
+			fat := readAt(image, (fatReservedSecs+i*fatSectors)*sectorSize, 12)
+			Expect(binary.LittleEndian.Uint32(fat[8:])).To(BeEquivalentTo(0x0fffffff))
+		}
+	})
+
+	It("should generate the same image again", func() {
+		spec := Spec{Size: 1 << 30, Filesystem: FilesystemVFAT}
+		Expect(generate(spec)).To(Equal(generate(spec)))
+	})
+
+	DescribeTable("should reject disks it can't generate", func(spec Spec, message string) {
+		Expect(Generate(&bytes.Buffer{}, spec)).To(MatchError(ContainSubstring(message)))
+	},
+		Entry("unaligned size", Spec{Size: 1000}, "multiple of 512"),
+		Entry("unknown filesystem", Spec{Size: 1 << 30, Filesystem: "ext4"}, "unsupported filesystem"),
+		Entry("small swap", Spec{Size: 4096, Filesystem: FilesystemSwap}, "at least"),
+		Entry("small vfat", Spec{Size: 16 << 20, Filesystem: FilesystemVFAT}, "too small"),
+	)
+})
+
+func TestUtilityDisk(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Utility Disk Suite")
+}
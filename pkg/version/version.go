@@ -0,0 +1,193 @@
+// Package version compares the version schemes of upstream distributions, so picking the latest release
+// works the same for all providers.
+package version
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// latestAlias names files which upstream always points to the newest release, e.g. CentOS-Stream-9-latest.
+const latestAlias = "latest"
+
+// prereleaseRanks orders the markers which make a version a prerelease of the version before them.
+var prereleaseRanks = map[string]int{
+	"dev":     0,
+	"alpha":   1,
+	"beta":    2,
+	"pre":     3,
+	"preview": 3,
+	"rc":      4,
+}
+
+// Version is a parsed version. Versions are split into runs of digits and runs of letters, so
+// all of the following schemes compare as expected:
+//
+//   - semantic versions: 1.9.0 < 1.10.0-rc.1 < 1.10.0 (build metadata after + is ignored)
+//   - date snapshots: 20240115 < 20240629, Snapshot20240115 < Snapshot20240629
+//   - EL point releases and composes: 9.4 < 9.10, 9-20240115.0 < 9-20240115.10
+//   - Ubuntu releases: 23.10 < 24.04 < 24.04.1
+//   - prereleases: 44 Beta < 44, 2.0~rc1 < 2.0
+//   - aliases of the newest release: 9-20240422.0 < 9-latest
+type Version struct {
+	raw        string
+	release    []token
+	prerelease []token
+}
+
+type token struct {
+	number string
+	text   string
+}
+
+func (t token) numeric() bool {
+	return t.text == ""
+}
+
+func compareTokens(a, b token) int {
+	switch {
+	case a.text == latestAlias || b.text == latestAlias:
+		return cmp.Compare(boolRank(a.text == latestAlias), boolRank(b.text == latestAlias))
+	case a.numeric() && b.numeric():
+		if c := cmp.Compare(len(a.number), len(b.number)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.number, b.number)
+	case a.numeric():
+		// Like rpm and dpkg, numbers sort after letters
+		return 1
+	case b.numeric():
+		return -1
+	default:
+		return strings.Compare(a.text, b.text)
+	}
+}
+
+// Parse splits a version into its tokens. Every string is a valid version.
+func Parse(raw string) Version {
+	v := Version{raw: raw}
+	s := strings.ToLower(strings.TrimSpace(raw))
+	if len(s) > 1 && s[0] == 'v' && isDigit(s[1]) {
+		s = s[1:]
+	}
+	s, _, _ = strings.Cut(s, "+")
+
+	// Debian marks prereleases with a tilde
+	s, pre, tilde := strings.Cut(s, "~")
+	tokens := tokenize(s)
+	for i, t := range tokens {
+		if _, ok := prereleaseRanks[t.text]; ok && i > 0 {
+			v.release, v.prerelease = tokens[:i], tokens[i:]
+			break
+		}
+	}
+	if v.prerelease == nil {
+		v.release = tokens
+	}
+	if tilde {
+		v.prerelease = append(v.prerelease, tokenize(pre)...)
+		if len(v.prerelease) == 0 {
+			v.prerelease = []token{{text: "pre"}}
+		}
+	}
+	return v
+}
+
+func tokenize(s string) []token {
+	var tokens []token
+	for s != "" {
+		i := 0
+		switch {
+		case isDigit(s[0]):
+			for i < len(s) && isDigit(s[i]) {
+				i++
+			}
+			tokens = append(tokens, token{number: strings.TrimLeft(s[:i], "0")})
+		case isLetter(s[0]):
+			for i < len(s) && isLetter(s[i]) {
+				i++
+			}
+			tokens = append(tokens, token{text: s[:i]})
+		default:
+			// Separators like ".", "-", "_" and spaces
+			i = 1
+		}
+		s = s[i:]
+	}
+	return tokens
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+// String returns the version as it was parsed.
+func (v Version) String() string {
+	return v.raw
+}
+
+// Prerelease reports whether the version is a prerelease, like 1.0.0-rc.1 or 44 Beta.
+func (v Version) Prerelease() bool {
+	return len(v.prerelease) > 0
+}
+
+// Compare returns -1 if v is older than other, 1 if it is newer and 0 if both are the same version.
+func (v Version) Compare(other Version) int {
+	if c := compareSequence(v.release, other.release); c != 0 {
+		return c
+	}
+	switch {
+	case !v.Prerelease() && !other.Prerelease():
+		return 0
+	case !v.Prerelease():
+		return 1
+	case !other.Prerelease():
+		return -1
+	}
+	if c := cmp.Compare(prereleaseRanks[v.prerelease[0].text], prereleaseRanks[other.prerelease[0].text]); c != 0 {
+		return c
+	}
+	return compareSequence(v.prerelease, other.prerelease)
+}
+
+func compareSequence(a, b []token) int {
+	for i := range min(len(a), len(b)) {
+		if c := compareTokens(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	// Additional components make a version newer, e.g. 24.04.1 > 24.04
+	return cmp.Compare(len(a), len(b))
+}
+
+// Compare parses and compares two versions, see Version.Compare.
+func Compare(a, b string) int {
+	return Parse(a).Compare(Parse(b))
+}
+
+// SortDescending sorts versions newest first.
+func SortDescending(versions []string) {
+	slices.SortStableFunc(versions, func(a, b string) int {
+		return Compare(b, a)
+	})
+}
+
+// Latest returns the newest of the versions or an empty string if there are none.
+func Latest(versions []string) string {
+	if len(versions) == 0 {
+		return ""
+	}
+	return slices.MaxFunc(versions, Compare)
+}
@@ -0,0 +1,62 @@
+package version
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version", func() {
+	DescribeTable("Compare should order versions of all schemes",
+		func(older, newer string) {
+			Expect(Compare(older, newer)).To(Equal(-1))
+			Expect(Compare(newer, older)).To(Equal(1))
+			Expect(Compare(newer, newer)).To(Equal(0))
+		},
+		Entry("semver", "1.9.0", "1.10.0"),
+		Entry("semver with v prefix", "v1.2.3", "1.2.4"),
+		Entry("semver prerelease", "1.10.0-rc.1", "1.10.0"),
+		Entry("semver prerelease order", "1.10.0-alpha.2", "1.10.0-beta.1"),
+		Entry("semver release candidates", "1.10.0-rc.2", "1.10.0-rc.10"),
+		Entry("date snapshots", "20240115", "20240629"),
+		Entry("named date snapshots", "Snapshot20240115", "Snapshot20240629"),
+		Entry("EL point releases", "9.4", "9.10"),
+		Entry("EL composes", "9-20240115.0", "9-20240115.10"),
+		Entry("Ubuntu releases", "23.10", "24.04"),
+		Entry("Ubuntu point releases", "24.04", "24.04.1"),
+		Entry("Fedora prereleases", "44 Beta", "44"),
+		Entry("Fedora releases after prereleases", "43", "44 Beta"),
+		Entry("Fedora releases", "99", "100"),
+		Entry("Debian prereleases", "2.0~rc1", "2.0"),
+		Entry("numbers after letters", "1.0a", "1.0.1"),
+		Entry("latest alias", "9-20240422.0", "9-latest"),
+	)
+
+	It("should ignore build metadata and leading zeros", func() {
+		Expect(Compare("1.0.0+build.1", "1.0.0+build.2")).To(Equal(0))
+		Expect(Compare("24.04", "24.4")).To(Equal(0))
+	})
+
+	It("should detect prereleases", func() {
+		Expect(Parse("44 Beta").Prerelease()).To(BeTrue())
+		Expect(Parse("1.0.0-rc.1").Prerelease()).To(BeTrue())
+		Expect(Parse("2.0~1").Prerelease()).To(BeTrue())
+		Expect(Parse("Snapshot20240629").Prerelease()).To(BeFalse())
+		Expect(Parse("9-stream").Prerelease()).To(BeFalse())
+		Expect(Parse("44 Beta").String()).To(Equal("44 Beta"))
+	})
+
+	It("should sort and pick the latest version", func() {
+		versions := []string{"15.9", "44 Beta", "15.10", "9", "100"}
+		Expect(Latest(versions)).To(Equal("100"))
+		SortDescending(versions)
+		Expect(versions).To(Equal([]string{"100", "44 Beta", "15.10", "15.9", "9"}))
+		Expect(Latest(nil)).To(BeEmpty())
+	})
+})
+
+func TestVersion(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Version Suite")
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package workspace
+
+import (
+	"errors"
+	"syscall"
+)
+
+// tempDirEnv are the environment variables os.TempDir reads the temp directory from.
+var tempDirEnv = []string{"TMPDIR"}
+
+func processAlive(pid int) bool {
+	// Signal 0 only checks if the process exists
+	err := syscall.Kill(pid, syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package workspace
+
+import (
+	"errors"
+	"os"
+)
+
+// tempDirEnv are the environment variables os.TempDir reads the temp directory from.
+var tempDirEnv = []string{"TMP", "TEMP"}
+
+func processAlive(pid int) bool {
+	// On Windows FindProcess opens a handle to the process and fails if it does not exist
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return errors.Is(err, os.ErrPermission)
+	}
+	_ = process.Release()
+	return true
+}
@@ -0,0 +1,263 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// DirPrefix is the prefix of all workspace directories below the scratch root.
+	DirPrefix = "medius-run-"
+	// ownerFile stores the host and the PID of the process owning a workspace, e.g. runner-1/4711.
+	ownerFile = ".owner"
+)
+
+// Workspace is the scratch directory of a single medius run. All temporary files of a run are created
+// below it, so that they can be removed at once when the run ends or is aborted.
+type Workspace struct {
+	dir string
+
+	mu    sync.Mutex
+	quota int64
+	usage int64
+}
+
+// ErrQuotaExceeded is returned when writing to an allocation would exceed the quota of the workspace.
+var ErrQuotaExceeded = errors.New("workspace quota exceeded")
+
+// New creates a workspace below root, which defaults to the system temp directory.
+func New(root string) (*Workspace, error) {
+	if root == "" {
+		root = os.TempDir()
+	}
+
+	dir, err := os.MkdirTemp(root, DirPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("error creating workspace: %v", err)
+	}
+
+	const permissionFile = 0o644
+	if err := os.WriteFile(filepath.Join(dir, ownerFile), []byte(owner(os.Getpid())), permissionFile); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("error writing workspace owner: %v", err)
+	}
+
+	return &Workspace{dir: dir}, nil
+}
+
+// Dir returns the directory of the workspace.
+func (w *Workspace) Dir() string {
+	return w.dir
+}
+
+// UseAsTempDir points the temp directory of the process to the workspace, so that all
+// temporary files, including the ones of libraries, are created inside of it.
+func (w *Workspace) UseAsTempDir() error {
+	for _, env := range tempDirEnv {
+		if err := os.Setenv(env, w.dir); err != nil {
+			return fmt.Errorf("error setting %s: %v", env, err)
+		}
+	}
+	return nil
+}
+
+// SetQuota limits the number of bytes which can be written to all allocations of the workspace
+// at the same time. A quota of zero or less disables the limit.
+func (w *Workspace) SetQuota(quota int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.quota = quota
+}
+
+// Usage returns the number of bytes currently written to all allocations of the workspace.
+func (w *Workspace) Usage() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.usage
+}
+
+// Allocate creates a directory for the temporary files of a single artifact. The allocation
+// should be released as soon as the artifact is done, so its space is available to others.
+func (w *Workspace) Allocate(name string) (*Allocation, error) {
+	dir, err := os.MkdirTemp(w.dir, sanitize(name)+"-")
+	if err != nil {
+		return nil, fmt.Errorf("error allocating scratch space for %s: %v", name, err)
+	}
+	return &Allocation{ws: w, dir: dir}, nil
+}
+
+func (w *Workspace) reserve(n int64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.quota > 0 && w.usage+n > w.quota {
+		return fmt.Errorf("%w: %d of %d bytes in use", ErrQuotaExceeded, w.usage, w.quota)
+	}
+	w.usage += n
+	return nil
+}
+
+func (w *Workspace) free(n int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.usage -= n
+}
+
+// CreateTemp creates a temporary file in the workspace.
+func (w *Workspace) CreateTemp(pattern string) (*os.File, error) {
+	return os.CreateTemp(w.dir, pattern)
+}
+
+// Close removes the workspace with all its content.
+func (w *Workspace) Close() error {
+	if err := os.RemoveAll(w.dir); err != nil {
+		return fmt.Errorf("error removing workspace %s: %v", w.dir, err)
+	}
+	return nil
+}
+
+// Allocation is the scratch space of a single artifact inside a workspace.
+type Allocation struct {
+	ws   *Workspace
+	dir  string
+	mu   sync.Mutex
+	used int64
+}
+
+// Dir returns the directory of the allocation.
+func (a *Allocation) Dir() string {
+	return a.dir
+}
+
+// CreateTemp creates a temporary file in the allocation. Writes to it should go through Writer
+// to be accounted for in the quota.
+func (a *Allocation) CreateTemp(pattern string) (*os.File, error) {
+	return os.CreateTemp(a.dir, pattern)
+}
+
+// Writer returns a writer which accounts all bytes written to w against the workspace quota
+// and fails with ErrQuotaExceeded instead of writing beyond it.
+func (a *Allocation) Writer(w io.Writer) io.Writer {
+	return &quotaWriter{allocation: a, w: w}
+}
+
+// Release removes the allocation with all its files and returns its space to the workspace.
+func (a *Allocation) Release() error {
+	a.mu.Lock()
+	used := a.used
+	a.used = 0
+	a.mu.Unlock()
+
+	a.ws.free(used)
+	if err := os.RemoveAll(a.dir); err != nil {
+		return fmt.Errorf("error releasing scratch space %s: %v", a.dir, err)
+	}
+	return nil
+}
+
+func (a *Allocation) reserve(n int64) error {
+	if err := a.ws.reserve(n); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.used += n
+	return nil
+}
+
+type quotaWriter struct {
+	allocation *Allocation
+	w          io.Writer
+}
+
+func (q *quotaWriter) Write(p []byte) (int, error) {
+	if err := q.allocation.reserve(int64(len(p))); err != nil {
+		return 0, err
+	}
+	return q.w.Write(p)
+}
+
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == ':' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// CleanupStale removes workspaces below root which were left behind by runs that were killed
+// without getting a chance to clean up. Workspaces of processes which are still alive are kept, as are
+// workspaces of other hosts, whose processes can't be checked if root is shared, e.g. by several runners.
+func CleanupStale(root string) (removed []string, err error) {
+	if root == "" {
+		root = os.TempDir()
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("error reading scratch root %s: %v", root, err)
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), DirPrefix) {
+			continue
+		}
+
+		dir := filepath.Join(root, entry.Name())
+		if ownerAlive(dir) {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed = append(removed, dir)
+	}
+
+	return removed, errors.Join(errs...)
+}
+
+// owner identifies the process pid of this host. PIDs are only unique per host, or PID namespace of a
+// container, which has a hostname of its own.
+func owner(pid int) string {
+	return hostname() + "/" + strconv.Itoa(pid)
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return name
+}
+
+func ownerAlive(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, ownerFile))
+	if err != nil {
+		// The owner is written right after creating the workspace, keep it to not race with its creation
+		return true
+	}
+
+	// Owners without host were written by older versions, which may have run on any host
+	host, rawPID, found := strings.Cut(strings.TrimSpace(string(data)), "/")
+	if !found || host != hostname() {
+		return true
+	}
+	pid, err := strconv.Atoi(rawPID)
+	if err != nil || pid <= 0 {
+		return false
+	}
+	if pid == os.Getpid() {
+		return true
+	}
+
+	return processAlive(pid)
+}
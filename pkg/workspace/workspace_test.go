@@ -0,0 +1,128 @@
+package workspace
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Workspace", func() {
+	var root string
+
+	BeforeEach(func() {
+		root = GinkgoT().TempDir()
+	})
+
+	It("should create temporary files below its directory and remove them on close", func() {
+		ws, err := New(root)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ws.Dir()).To(HavePrefix(filepath.Join(root, DirPrefix)))
+
+		file, err := ws.CreateTemp("disk")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+		Expect(filepath.Dir(file.Name())).To(Equal(ws.Dir()))
+
+		Expect(ws.Close()).To(Succeed())
+		Expect(ws.Dir()).ToNot(BeADirectory())
+	})
+
+	It("should be usable as temp directory of the process", func() {
+		for _, env := range tempDirEnv {
+			GinkgoT().Setenv(env, os.Getenv(env))
+		}
+
+		ws, err := New(root)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(ws.Close)
+
+		Expect(ws.UseAsTempDir()).To(Succeed())
+		Expect(os.TempDir()).To(Equal(ws.Dir()))
+	})
+
+	It("should track the usage of allocations", func() {
+		ws, err := New(root)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(ws.Close)
+
+		allocation, err := ws.Allocate("fedora:40")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filepath.Dir(allocation.Dir())).To(Equal(ws.Dir()))
+		Expect(filepath.Base(allocation.Dir())).To(HavePrefix("fedora_40-"))
+
+		file, err := allocation.CreateTemp("disk")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = allocation.Writer(file).Write(make([]byte, 100))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+		Expect(ws.Usage()).To(BeEquivalentTo(100))
+
+		Expect(allocation.Release()).To(Succeed())
+		Expect(ws.Usage()).To(BeZero())
+		Expect(file.Name()).ToNot(BeAnExistingFile())
+	})
+
+	It("should enforce the quota across allocations", func() {
+		ws, err := New(root)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(ws.Close)
+		ws.SetQuota(150)
+
+		first, err := ws.Allocate("first")
+		Expect(err).ToNot(HaveOccurred())
+		second, err := ws.Allocate("second")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = first.Writer(io.Discard).Write(make([]byte, 100))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = second.Writer(io.Discard).Write(make([]byte, 100))
+		Expect(err).To(MatchError(ErrQuotaExceeded))
+
+		Expect(first.Release()).To(Succeed())
+		_, err = second.Writer(io.Discard).Write(make([]byte, 100))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should clean up stale workspaces only", func() {
+		ws, err := New(root)
+		Expect(err).ToNot(HaveOccurred())
+
+		stale := filepath.Join(root, DirPrefix+"stale")
+		Expect(os.Mkdir(stale, 0o755)).To(Succeed())
+		// PIDs are limited to 2^22 on Linux, so this process can't exist
+		Expect(os.WriteFile(filepath.Join(stale, ownerFile), []byte(owner(99999999)), 0o644)).To(Succeed())
+
+		unrelated := filepath.Join(root, "unrelated")
+		Expect(os.Mkdir(unrelated, 0o755)).To(Succeed())
+
+		removed, err := CleanupStale(root)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(ConsistOf(stale))
+		Expect(ws.Dir()).To(BeADirectory())
+		Expect(unrelated).To(BeADirectory())
+	})
+
+	DescribeTable("should keep workspaces whose owner can't be checked on this host",
+		func(owner string) {
+			foreign := filepath.Join(root, DirPrefix+"foreign")
+			Expect(os.Mkdir(foreign, 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(foreign, ownerFile), []byte(owner), 0o644)).To(Succeed())
+
+			removed, err := CleanupStale(root)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(removed).To(BeEmpty())
+			Expect(foreign).To(BeADirectory())
+		},
+		Entry("owned by another host sharing the scratch directory", "other-runner.example.com/99999999"),
+		Entry("written by an older version without host", "99999999"),
+	)
+})
+
+func TestWorkspace(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Workspace Suite")
+}
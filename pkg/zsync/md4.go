@@ -0,0 +1,18 @@
+package zsync
+
+import (
+	"golang.org/x/crypto/md4" //nolint:staticcheck // zsync files use MD4 as strong block checksum
+)
+
+// md4Size is the size of an MD4 checksum, which zsync uses as strong block checksum.
+const md4Size = md4.Size
+
+// md4Sum returns the MD4 checksum (RFC 1320) of data. MD4 is broken and only used to
+// find matching blocks, the assembled file is verified with the SHA-1 of the zsync file.
+func md4Sum(data []byte) [md4Size]byte {
+	h := md4.New()
+	h.Write(data)
+	var sum [md4Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
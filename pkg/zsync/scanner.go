@@ -0,0 +1,100 @@
+package zsync
+
+import (
+	"errors"
+	"io"
+)
+
+// scanner slides a window of blocksize over a reader and keeps the rolling checksum of
+// the window up to date, as described in the rsync algorithm.
+type scanner struct {
+	reader    io.Reader
+	blocksize int
+	eof       bool
+
+	buf  []byte
+	pos  int
+	base int64
+
+	a, b uint16
+}
+
+func newScanner(reader io.Reader, blocksize int) *scanner {
+	const minChunks = 4
+	return &scanner{
+		reader:    reader,
+		blocksize: blocksize,
+		buf:       make([]byte, 0, max(minChunks*blocksize, 1024*1024)),
+	}
+}
+
+// start positions the window at the current offset. It returns false if less than a block is left.
+func (s *scanner) start() (bool, error) {
+	if ok, err := s.ensure(s.blocksize); !ok || err != nil {
+		return false, err
+	}
+	s.a, s.b = rsumBlock(s.window())
+	return true, nil
+}
+
+// roll moves the window by one byte.
+func (s *scanner) roll() (bool, error) {
+	if ok, err := s.ensure(s.blocksize + 1); !ok || err != nil {
+		return false, err
+	}
+	oldc, newc := uint16(s.buf[s.pos]), uint16(s.buf[s.pos+s.blocksize])
+	s.a += newc - oldc
+	s.b += s.a - oldc*uint16(s.blocksize) //nolint:gosec // the checksum is defined with 16 bit overflows
+	s.pos++
+	return true, nil
+}
+
+// skipWindow moves the window behind the current one.
+func (s *scanner) skipWindow() (bool, error) {
+	s.pos += s.blocksize
+	return s.start()
+}
+
+func (s *scanner) window() []byte {
+	return s.buf[s.pos : s.pos+s.blocksize]
+}
+
+func (s *scanner) offset() int64 {
+	return s.base + int64(s.pos)
+}
+
+func (s *scanner) rsum() uint32 {
+	return uint32(s.a)<<16 | uint32(s.b)
+}
+
+// ensure makes sure n bytes are buffered from the current position.
+func (s *scanner) ensure(n int) (bool, error) {
+	for len(s.buf)-s.pos < n {
+		if s.eof {
+			return false, nil
+		}
+		if s.pos > 0 {
+			s.buf = s.buf[:copy(s.buf, s.buf[s.pos:])]
+			s.base += int64(s.pos)
+			s.pos = 0
+		}
+		read, err := s.reader.Read(s.buf[len(s.buf):cap(s.buf)])
+		s.buf = s.buf[:len(s.buf)+read]
+		if errors.Is(err, io.EOF) {
+			s.eof = true
+		} else if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// rsumBlock calculates the weak checksum of a block like zsync.
+func rsumBlock(data []byte) (a, b uint16) {
+	length := len(data)
+	for i, c := range data {
+		a += uint16(c)
+		b += uint16(length-i) * uint16(c) //nolint:gosec // the checksum is defined with 16 bit overflows
+	}
+	return a, b
+}
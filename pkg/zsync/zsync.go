@@ -0,0 +1,291 @@
+package zsync
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1" //nolint:gosec // zsync files only provide SHA-1 checksums
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	rsumSize = 4
+	// maxBlocksize protects against zsync files making us allocate huge buffers.
+	maxBlocksize = 16 * 1024 * 1024
+)
+
+// Control is a parsed zsync control file, describing the blocks of a target file.
+type Control struct {
+	Filename  string
+	Blocksize int
+	Length    int64
+	// URL of the target file, it may be relative to the zsync file.
+	URL string
+	// SHA1 is the hex encoded SHA-1 checksum of the target file.
+	SHA1 string
+
+	seqMatches    int
+	rsumBytes     int
+	checksumBytes int
+	rsums         []uint32
+	checksums     [][]byte
+}
+
+// Parse reads a zsync control file as created by zsyncmake.
+func Parse(r io.Reader) (*Control, error) {
+	reader := bufio.NewReader(r)
+	c := &Control{}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("error reading zsync header: %v", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, fmt.Errorf("invalid zsync header line %q", line)
+		}
+		if err := c.setHeader(key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	blocks := c.Blocks()
+	c.rsums = make([]uint32, blocks)
+	c.checksums = make([][]byte, blocks)
+	buf := make([]byte, c.rsumBytes+c.checksumBytes)
+	for i := range blocks {
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, fmt.Errorf("error reading checksums of block %d: %v", i, err)
+		}
+		var rsum [rsumSize]byte
+		copy(rsum[rsumSize-c.rsumBytes:], buf[:c.rsumBytes])
+		c.rsums[i] = binary.BigEndian.Uint32(rsum[:])
+		c.checksums[i] = bytes.Clone(buf[c.rsumBytes:])
+	}
+
+	return c, nil
+}
+
+func (c *Control) setHeader(key, value string) error {
+	var err error
+	switch key {
+	case "Filename":
+		c.Filename = value
+	case "Blocksize":
+		c.Blocksize, err = strconv.Atoi(value)
+	case "Length":
+		c.Length, err = strconv.ParseInt(value, 10, 64)
+	case "URL":
+		// Only the first URL is used
+		if c.URL == "" {
+			c.URL = value
+		}
+	case "SHA-1":
+		c.SHA1 = strings.ToLower(value)
+	case "Hash-Lengths":
+		lengths := strings.Split(value, ",")
+		const hashLengths = 3
+		if len(lengths) != hashLengths {
+			return fmt.Errorf("invalid zsync Hash-Lengths %q", value)
+		}
+		if c.seqMatches, err = strconv.Atoi(lengths[0]); err == nil {
+			if c.rsumBytes, err = strconv.Atoi(lengths[1]); err == nil {
+				c.checksumBytes, err = strconv.Atoi(lengths[2])
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid zsync header %s: %v", key, err)
+	}
+	return nil
+}
+
+func (c *Control) validate() error {
+	const minChecksumBytes = 3
+	switch {
+	case c.URL == "":
+		return errors.New("zsync file has no URL, compressed targets are not supported")
+	case c.SHA1 == "":
+		return errors.New("zsync file has no SHA-1")
+	case c.Blocksize <= 0 || c.Blocksize > maxBlocksize:
+		return fmt.Errorf("invalid zsync blocksize %d", c.Blocksize)
+	case c.Length < 0:
+		return fmt.Errorf("invalid zsync length %d", c.Length)
+	case c.rsumBytes < 1 || c.rsumBytes > rsumSize || c.checksumBytes < minChecksumBytes || c.checksumBytes > md4Size:
+		return fmt.Errorf("invalid zsync hash lengths %d,%d,%d", c.seqMatches, c.rsumBytes, c.checksumBytes)
+	}
+	return nil
+}
+
+// Blocks returns the number of blocks of the target file.
+func (c *Control) Blocks() int {
+	return int((c.Length + int64(c.Blocksize) - 1) / int64(c.Blocksize))
+}
+
+// Match scans seed for blocks of the target file and returns the offset in seed of every
+// block, or -1 if the block was not found.
+func (c *Control) Match(seed io.Reader) ([]int64, error) {
+	offsets := make([]int64, c.Blocks())
+	for i := range offsets {
+		offsets[i] = -1
+	}
+
+	// The last block is zero padded and hardly ever found in a seed, it is always fetched
+	index := map[uint32][]int{}
+	for i := range c.Blocks() {
+		if int64(i+1)*int64(c.Blocksize) > c.Length {
+			break
+		}
+		index[c.rsums[i]] = append(index[c.rsums[i]], i)
+	}
+	if len(index) == 0 {
+		return offsets, nil
+	}
+
+	s := newScanner(seed, c.Blocksize)
+	mask := uint32(1<<(8*c.rsumBytes) - 1)
+	if c.rsumBytes == rsumSize {
+		mask = ^uint32(0)
+	}
+
+	ok, err := s.start()
+	for ok && err == nil {
+		advanced := false
+		if candidates, found := index[s.rsum()&mask]; found {
+			sum := md4Sum(s.window())
+			matched := false
+			for _, block := range candidates {
+				if offsets[block] == -1 && bytes.Equal(sum[:c.checksumBytes], c.checksums[block]) {
+					offsets[block] = s.offset()
+					matched = true
+				}
+			}
+			if matched {
+				ok, err = s.skipWindow()
+				advanced = true
+			}
+		}
+		if !advanced {
+			ok, err = s.roll()
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading seed: %v", err)
+	}
+
+	return offsets, nil
+}
+
+// Fetcher fetches length bytes of the target file starting at offset.
+type Fetcher func(offset, length int64) ([]byte, error)
+
+// Assemble writes the target file to out. Matched blocks are copied from seed, all others are
+// fetched. It returns the number of fetched bytes. The result must be checked with Verify.
+func (c *Control) Assemble(out io.WriterAt, seed io.ReaderAt, offsets []int64, fetch Fetcher) (int64, error) {
+	var fetched int64
+	blocksize := int64(c.Blocksize)
+	buf := make([]byte, c.Blocksize)
+
+	for block := 0; block < len(offsets); {
+		start := int64(block) * blocksize
+		if offsets[block] >= 0 {
+			if _, err := seed.ReadAt(buf, offsets[block]); err != nil {
+				return fetched, fmt.Errorf("error reading block %d from seed: %v", block, err)
+			}
+			if _, err := out.WriteAt(buf[:min(blocksize, c.Length-start)], start); err != nil {
+				return fetched, err
+			}
+			block++
+			continue
+		}
+
+		// Fetch consecutive missing blocks with a single request
+		end := block + 1
+		for end < len(offsets) && offsets[end] < 0 {
+			end++
+		}
+		length := min(int64(end)*blocksize, c.Length) - start
+		data, err := fetch(start, length)
+		if err != nil {
+			return fetched, err
+		}
+		if int64(len(data)) != length {
+			return fetched, fmt.Errorf("fetched %d bytes at offset %d, expected %d", len(data), start, length)
+		}
+		if _, err := out.WriteAt(data, start); err != nil {
+			return fetched, err
+		}
+		fetched += length
+		block = end
+	}
+
+	return fetched, nil
+}
+
+// Verify checks r against the length and SHA-1 of the target file.
+func (c *Control) Verify(r io.Reader) error {
+	h := sha1.New() //nolint:gosec // zsync files only provide SHA-1 checksums
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return err
+	}
+	if n != c.Length {
+		return fmt.Errorf("assembled %d bytes, expected %d", n, c.Length)
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != c.SHA1 {
+		return fmt.Errorf("SHA-1 mismatch: expected %s, got %s", c.SHA1, actual)
+	}
+	return nil
+}
+
+// Make creates a zsync control file for data read from r with the most conservative hash lengths.
+// It is meant for tests and tools, as upstreams publish their own zsync files.
+func Make(r io.Reader, fileName string, blocksize int) ([]byte, error) {
+	var (
+		sums   bytes.Buffer
+		length int64
+	)
+	h := sha1.New() //nolint:gosec // zsync files only provide SHA-1 checksums
+	block := make([]byte, blocksize)
+	for {
+		n, err := io.ReadFull(r, block)
+		if n > 0 {
+			h.Write(block[:n])
+			length += int64(n)
+			clear(block[n:])
+
+			a, b := rsumBlock(block)
+			sums.Write(binary.BigEndian.AppendUint32(nil, uint32(a)<<16|uint32(b)))
+			sum := md4Sum(block)
+			sums.Write(sum[:])
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "zsync: 0.6.2\nFilename: %s\nBlocksize: %d\nLength: %d\nHash-Lengths: 1,%d,%d\nURL: %s\nSHA-1: %s\n\n",
+		fileName, blocksize, length, rsumSize, md4Size, fileName, hex.EncodeToString(h.Sum(nil)))
+	buf.Write(sums.Bytes())
+
+	return buf.Bytes(), nil
+}
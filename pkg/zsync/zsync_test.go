@@ -0,0 +1,93 @@
+package zsync
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("zsync", func() {
+	const blocksize = 1024
+
+	var oldFile, newFile []byte
+
+	BeforeEach(func() {
+		random := rand.New(rand.NewSource(42)) //nolint:gosec // deterministic test data
+		oldFile = make([]byte, 64*blocksize+100)
+		random.Read(oldFile)
+
+		// Change one block and insert some bytes, which shifts all following blocks
+		newFile = bytes.Clone(oldFile[:10*blocksize])
+		newFile = append(newFile, bytes.Repeat([]byte{1}, blocksize)...)
+		newFile = append(newFile, []byte("inserted")...)
+		newFile = append(newFile, oldFile[11*blocksize:]...)
+	})
+
+	DescribeTable("md4Sum should match the RFC 1320 test suite",
+		func(input, expected string) {
+			sum := md4Sum([]byte(input))
+			Expect(hex.EncodeToString(sum[:])).To(Equal(expected))
+		},
+		Entry("empty", "", "31d6cfe0d16ae931b73c59d7e0c089c0"),
+		Entry("abc", "abc", "a448017aaf21d8525fc10ae87aa6729d"),
+		Entry("message digest", "message digest", "d9130a8164549fe818874806e1c7014b"),
+		Entry("multiple blocks", "12345678901234567890123456789012345678901234567890123456789012345678901234567890",
+			"e33b4ddc9c38f2199c3e7b164fcc0536"),
+	)
+
+	It("should assemble the new file from the seed and fetch only changed blocks", func() {
+		control, err := Parse(bytes.NewReader(makeZsync(newFile, blocksize)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(control.Blocks()).To(Equal(65))
+		Expect(control.URL).To(Equal("disk.img"))
+
+		offsets, err := control.Match(bytes.NewReader(oldFile))
+		Expect(err).ToNot(HaveOccurred())
+
+		out, err := os.Create(filepath.Join(GinkgoT().TempDir(), "out"))
+		Expect(err).ToNot(HaveOccurred())
+		defer out.Close()
+
+		var requests int
+		fetched, err := control.Assemble(out, bytes.NewReader(oldFile), offsets, func(offset, length int64) ([]byte, error) {
+			requests++
+			return newFile[offset : offset+length], nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetched).To(BeNumerically("<", 4*blocksize))
+		Expect(requests).To(BeNumerically("<=", 3))
+
+		_, err = out.Seek(0, 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(control.Verify(out)).To(Succeed())
+	})
+
+	It("should detect corrupted results", func() {
+		control, err := Parse(bytes.NewReader(makeZsync(newFile, blocksize)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(control.Verify(bytes.NewReader(oldFile))).ToNot(Succeed())
+	})
+
+	It("should reject zsync files of compressed targets", func() {
+		data := []byte("zsync: 0.6.2\nBlocksize: 1024\nLength: 0\nHash-Lengths: 1,4,16\nZ-URL: disk.img.gz\nSHA-1: 00\n\n")
+		_, err := Parse(bytes.NewReader(data))
+		Expect(err).To(MatchError(ContainSubstring("compressed targets are not supported")))
+	})
+})
+
+func makeZsync(data []byte, blocksize int) []byte {
+	zsyncFile, err := Make(bytes.NewReader(data), "disk.img", blocksize)
+	Expect(err).ToNot(HaveOccurred())
+	return zsyncFile
+}
+
+func TestZsync(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Zsync Suite")
+}
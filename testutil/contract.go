@@ -0,0 +1,86 @@
+package testutil
+
+import (
+	"encoding/hex"
+	"net/url"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/architecture"
+	"kubevirt.io/containerdisks/pkg/docs"
+)
+
+// DescribeArtifactContract registers a set of specs every api.Artifact implementation has to satisfy.
+// newArtifact must return a fresh artifact which is wired up with mocks, so that Inspect
+// does not reach out to the network.
+func DescribeArtifactContract(text string, newArtifact func() api.Artifact) bool {
+	return Describe("Artifact contract: "+text, func() {
+		var artifact api.Artifact
+
+		BeforeEach(func() {
+			artifact = newArtifact()
+		})
+
+		It("should return stable metadata", func() {
+			metadata := artifact.Metadata()
+			Expect(metadata).ToNot(BeNil())
+			Expect(metadata.Name).ToNot(BeEmpty())
+			Expect(metadata.Version).ToNot(BeEmpty())
+			Expect(metadata.Description).ToNot(BeEmpty())
+			Expect(metadata.Arch).ToNot(BeEmpty())
+			Expect(artifact.Metadata()).To(Equal(metadata))
+			Expect(newArtifact().Metadata()).To(Equal(metadata))
+		})
+
+		It("should inspect deterministically", func() {
+			details, err := artifact.Inspect()
+			Expect(err).ToNot(HaveOccurred())
+			again, err := artifact.Inspect()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(comparableDetails(again)).To(Equal(comparableDetails(details)))
+		})
+
+		It("should map the architecture", func() {
+			details, err := artifact.Inspect()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(details.ImageArchitecture).To(Equal(architecture.GetImageArchitecture(artifact.Metadata().Arch)))
+		})
+
+		It("should provide a well-formed checksum", func() {
+			details, err := artifact.Inspect()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(details.ChecksumHash).ToNot(BeNil())
+			Expect(details.Checksum).To(HaveLen(hex.EncodedLen(details.ChecksumHash().Size())))
+			_, err = hex.DecodeString(details.Checksum)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should provide a well-formed download URL", func() {
+			details, err := artifact.Inspect()
+			Expect(err).ToNot(HaveOccurred())
+			downloadURL, err := url.Parse(details.DownloadURL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(downloadURL.Scheme).To(Equal("https"))
+			Expect(downloadURL.Host).ToNot(BeEmpty())
+			Expect(path.Base(downloadURL.Path)).ToNot(BeElementOf("", "/", "."))
+		})
+
+		It("should create an example VM with the containerdisk", func() {
+			metadata := artifact.Metadata()
+			vm := artifact.VM(metadata.Name, "registry/image", artifact.UserData(&docs.UserData{Username: "user"}))
+			Expect(vm).ToNot(BeNil())
+			Expect(vm.Spec.Template).ToNot(BeNil())
+			Expect(vm.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("VolumeSource.ContainerDisk.Image", "registry/image")))
+		})
+	})
+}
+
+// comparableDetails strips the non-comparable hash function from the artifact details.
+func comparableDetails(details *api.ArtifactDetails) api.ArtifactDetails {
+	comparable := *details
+	comparable.ChecksumHash = nil
+	return comparable
+}
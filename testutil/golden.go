@@ -0,0 +1,30 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/gomega"
+)
+
+// UpdateGoldenEnv is the environment variable which, when set to a non-empty value,
+// makes ExpectGolden rewrite the golden files instead of comparing against them.
+const UpdateGoldenEnv = "UPDATE_GOLDEN"
+
+// ExpectGolden compares actual with the content of the golden file at goldenPath.
+// Run the tests with UPDATE_GOLDEN=true to regenerate the golden files after an intended change.
+func ExpectGolden(goldenPath string, actual []byte) {
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		const permissionDir = 0o755
+		ExpectWithOffset(1, os.MkdirAll(filepath.Dir(goldenPath), permissionDir)).To(Succeed())
+		const permissionFile = 0o644
+		ExpectWithOffset(1, os.WriteFile(goldenPath, actual, permissionFile)).To(Succeed())
+		return
+	}
+
+	expected, err := os.ReadFile(goldenPath)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred(),
+		"golden file %s is missing, run the tests with %s=true to create it", goldenPath, UpdateGoldenEnv)
+	ExpectWithOffset(1, string(actual)).To(Equal(string(expected)),
+		"output differs from golden file %s, run the tests with %s=true to update it", goldenPath, UpdateGoldenEnv)
+}
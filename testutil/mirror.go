@@ -0,0 +1,143 @@
+package testutil
+
+import (
+	"context"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	pkghttp "kubevirt.io/containerdisks/pkg/http"
+)
+
+// MirrorServer is a fake upstream mirror which serves a fixture directory over HTTP.
+// Directories are served as HTML directory listings, like most distribution mirrors do.
+// Additionally redirects, arbitrary status codes and slow responses can be configured per path.
+type MirrorServer struct {
+	*httptest.Server
+
+	mu        sync.RWMutex
+	redirects map[string]string
+	statuses  map[string]int
+	delays    map[string]time.Duration
+}
+
+type MirrorOption func(m *MirrorServer)
+
+// WithRedirect answers requests for path with a redirect to target.
+func WithRedirect(path, target string) MirrorOption {
+	return func(m *MirrorServer) {
+		m.redirects[path] = target
+	}
+}
+
+// WithStatus answers requests for path with the given status code and an empty body.
+func WithStatus(path string, statusCode int) MirrorOption {
+	return func(m *MirrorServer) {
+		m.statuses[path] = statusCode
+	}
+}
+
+// WithNotFound answers requests for path with 404 even if the fixture exists.
+func WithNotFound(path string) MirrorOption {
+	return WithStatus(path, http.StatusNotFound)
+}
+
+// WithDelay delays the response for path by the given duration.
+func WithDelay(path string, delay time.Duration) MirrorOption {
+	return func(m *MirrorServer) {
+		m.delays[path] = delay
+	}
+}
+
+// NewMirrorServer starts a fake mirror serving the files below fixtureDir.
+// The caller has to Close the server when done.
+func NewMirrorServer(fixtureDir string, opts ...MirrorOption) *MirrorServer {
+	m := &MirrorServer{
+		redirects: map[string]string{},
+		statuses:  map[string]int{},
+		delays:    map[string]time.Duration{},
+	}
+	m.Apply(opts...)
+
+	fileServer := http.FileServer(http.Dir(fixtureDir))
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.mu.RLock()
+		delay, hasDelay := m.delays[r.URL.Path]
+		target, hasRedirect := m.redirects[r.URL.Path]
+		status, hasStatus := m.statuses[r.URL.Path]
+		m.mu.RUnlock()
+
+		if hasDelay {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		switch {
+		case hasRedirect:
+			http.Redirect(w, r, target, http.StatusFound)
+		case hasStatus:
+			w.WriteHeader(status)
+		default:
+			fileServer.ServeHTTP(w, r)
+		}
+	}))
+
+	return m
+}
+
+// Apply configures additional options on a running server.
+func (m *MirrorServer) Apply(opts ...MirrorOption) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, opt := range opts {
+		opt(m)
+	}
+}
+
+// Getter returns a getter which rewrites all URLs starting with upstreamPrefix to the mirror server.
+// This allows to test providers with hardcoded upstream URLs against the fixtures.
+func (m *MirrorServer) Getter(upstreamPrefix string) pkghttp.Getter {
+	return &rewritingGetter{
+		from:   upstreamPrefix,
+		to:     m.URL + "/",
+		getter: &pkghttp.HTTPGetter{},
+	}
+}
+
+type rewritingGetter struct {
+	from   string
+	to     string
+	getter pkghttp.Getter
+}
+
+func (r *rewritingGetter) rewrite(fileURL string) string {
+	if strings.HasPrefix(fileURL, r.from) {
+		return r.to + strings.TrimPrefix(strings.TrimPrefix(fileURL, r.from), "/")
+	}
+	return fileURL
+}
+
+func (r *rewritingGetter) GetAll(fileURL string) ([]byte, error) {
+	return r.getter.GetAll(r.rewrite(fileURL))
+}
+
+func (r *rewritingGetter) GetAllWithContext(ctx context.Context, fileURL string) ([]byte, error) {
+	return r.getter.GetAllWithContext(ctx, r.rewrite(fileURL))
+}
+
+func (r *rewritingGetter) GetWithChecksum(fileURL string, checksumHasher func() hash.Hash) (pkghttp.ReadCloserWithChecksum, error) {
+	return r.getter.GetWithChecksum(r.rewrite(fileURL), checksumHasher)
+}
+
+func (r *rewritingGetter) GetWithChecksumAndContext(ctx context.Context, fileURL string, checksumHasher func() hash.Hash) (
+	pkghttp.ReadCloserWithChecksum, error,
+) {
+	return r.getter.GetWithChecksumAndContext(ctx, r.rewrite(fileURL), checksumHasher)
+}
@@ -0,0 +1,67 @@
+package testutil
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MirrorServer", func() {
+	var mirror *MirrorServer
+
+	BeforeEach(func() {
+		mirror = NewMirrorServer("testdata/mirror")
+		DeferCleanup(mirror.Close)
+	})
+
+	It("should serve directory listings", func() {
+		raw, err := mirror.Getter("https://example.org/").GetAll("https://example.org/images/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(raw)).To(ContainSubstring(`<a href="CHECKSUM">CHECKSUM</a>`))
+		Expect(string(raw)).To(ContainSubstring(`<a href="disk.qcow2">disk.qcow2</a>`))
+	})
+
+	It("should serve files with checksums", func() {
+		reader, err := mirror.Getter("https://example.org").GetWithChecksum("https://example.org/images/disk.qcow2", sha256.New)
+		Expect(err).ToNot(HaveOccurred())
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("hello"))
+		Expect(reader.Checksum()).To(Equal("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"))
+	})
+
+	It("should follow redirects", func() {
+		mirror.Apply(WithRedirect("/latest/CHECKSUM", "/images/CHECKSUM"))
+		raw, err := mirror.Getter("https://example.org/").GetAll("https://example.org/latest/CHECKSUM")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(raw)).To(ContainSubstring("disk.qcow2"))
+	})
+
+	It("should fail on configured status codes", func() {
+		mirror.Apply(WithNotFound("/images/CHECKSUM"), WithStatus("/images/disk.qcow2", http.StatusServiceUnavailable))
+		_, err := mirror.Getter("https://example.org/").GetAll("https://example.org/images/CHECKSUM")
+		Expect(err).To(MatchError(ContainSubstring("404")))
+		_, err = mirror.Getter("https://example.org/").GetAll("https://example.org/images/disk.qcow2")
+		Expect(err).To(MatchError(ContainSubstring("503")))
+	})
+
+	It("should delay slow responses", func() {
+		mirror.Apply(WithDelay("/images/CHECKSUM", time.Second))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := mirror.Getter("https://example.org/").GetAllWithContext(ctx, "https://example.org/images/CHECKSUM")
+		Expect(err).To(MatchError(ContainSubstring("context deadline exceeded")))
+	})
+})
+
+func TestTestutil(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Testutil Suite")
+}
@@ -10,14 +10,18 @@ import (
 
 type mockGetter struct {
 	mockFile string
+	mockData []byte
 }
 
 func (m *mockGetter) GetAll(_ string) ([]byte, error) {
+	if m.mockData != nil {
+		return m.mockData, nil
+	}
 	return os.ReadFile(m.mockFile)
 }
 
-func (m *mockGetter) GetAllWithContext(_ context.Context, _ string) ([]byte, error) {
-	return os.ReadFile(m.mockFile)
+func (m *mockGetter) GetAllWithContext(_ context.Context, fileURL string) ([]byte, error) {
+	return m.GetAll(fileURL)
 }
 
 func (m *mockGetter) GetWithChecksum(_ string, _ func() hash.Hash) (http.ReadCloserWithChecksum, error) {
@@ -31,3 +35,11 @@ func (m *mockGetter) GetWithChecksumAndContext(_ context.Context, _ string, _ fu
 func NewMockGetter(mockFile string) *mockGetter {
 	return &mockGetter{mockFile: mockFile}
 }
+
+// NewMockGetterFromBytes returns a getter which serves data for every request.
+func NewMockGetterFromBytes(data []byte) *mockGetter {
+	if data == nil {
+		data = []byte{}
+	}
+	return &mockGetter{mockData: data}
+}